@@ -0,0 +1,88 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// Backend 配置来源，提供原始 YAML 内容并在内容变化时通知
+type Backend interface {
+	// Name 返回配置来源名称，用于版本记录
+	Name() string
+	// Load 读取当前配置内容
+	Load(ctx context.Context) ([]byte, error)
+	// Watch 监视配置变化，内容变化时调用 onChange，ctx 结束时停止
+	Watch(ctx context.Context, onChange func(data []byte)) error
+}
+
+// FileBackend 本地文件配置来源
+type FileBackend struct {
+	path string
+}
+
+// NewFileBackend 创建本地文件配置来源
+func NewFileBackend(path string) *FileBackend {
+	return &FileBackend{path: path}
+}
+
+// Name 返回配置来源名称
+func (b *FileBackend) Name() string {
+	return "file"
+}
+
+// Load 读取配置文件
+func (b *FileBackend) Load(ctx context.Context) ([]byte, error) {
+	data, err := os.ReadFile(b.path)
+	if err != nil {
+		return nil, fmt.Errorf("读取配置文件失败: %w", err)
+	}
+	return data, nil
+}
+
+// Watch 监视配置文件所在目录，兼容编辑器先删除再创建的保存方式
+func (b *FileBackend) Watch(ctx context.Context, onChange func(data []byte)) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("创建文件监视器失败: %w", err)
+	}
+
+	if err := watcher.Add(filepath.Dir(b.path)); err != nil {
+		watcher.Close()
+		return fmt.Errorf("监视配置目录失败: %w", err)
+	}
+
+	target := filepath.Clean(b.path)
+	go func() {
+		defer watcher.Close()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if filepath.Clean(event.Name) != target || event.Op&(fsnotify.Write|fsnotify.Create) == 0 {
+					continue
+				}
+				data, err := b.Load(ctx)
+				if err != nil {
+					fmt.Printf("读取新配置失败: %v\n", err)
+					continue
+				}
+				onChange(data)
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				fmt.Printf("配置文件监视错误: %v\n", err)
+			}
+		}
+	}()
+
+	return nil
+}
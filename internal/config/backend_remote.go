@@ -0,0 +1,237 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RemoteBackendConfig 远程配置来源配置
+type RemoteBackendConfig struct {
+	// 存储类型：etcd, consul
+	Provider string `yaml:"provider" mapstructure:"provider"`
+	// 服务地址，如 http://127.0.0.1:2379、http://127.0.0.1:8500
+	Endpoint string `yaml:"endpoint" mapstructure:"endpoint"`
+	// 存放 YAML 配置的键
+	Key string `yaml:"key" mapstructure:"key"`
+	// 访问令牌（Consul ACL Token 或 etcd Bearer Token）
+	Token string `yaml:"token" mapstructure:"token"`
+	// 轮询间隔（etcd）或阻塞查询最长等待时间（Consul），默认 30s
+	PollInterval time.Duration `yaml:"poll_interval" mapstructure:"poll_interval"`
+}
+
+// NewRemoteBackend 根据配置创建远程配置来源
+func NewRemoteBackend(cfg RemoteBackendConfig) (Backend, error) {
+	if cfg.Endpoint == "" {
+		return nil, fmt.Errorf("远程配置地址不能为空")
+	}
+	if cfg.Key == "" {
+		return nil, fmt.Errorf("远程配置键不能为空")
+	}
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = 30 * time.Second
+	}
+	cfg.Endpoint = strings.TrimRight(cfg.Endpoint, "/")
+
+	switch cfg.Provider {
+	case "etcd":
+		return &etcdBackend{cfg: cfg, client: &http.Client{Timeout: 10 * time.Second}}, nil
+	case "consul":
+		// 阻塞查询需要比等待时间更长的超时
+		return &consulBackend{cfg: cfg, client: &http.Client{Timeout: cfg.PollInterval + 10*time.Second}}, nil
+	default:
+		return nil, fmt.Errorf("不支持的远程配置类型: %s", cfg.Provider)
+	}
+}
+
+// etcdBackend 基于 etcd v3 HTTP 网关的配置来源，按修订号轮询变化
+type etcdBackend struct {
+	cfg    RemoteBackendConfig
+	client *http.Client
+}
+
+// Name 返回配置来源名称
+func (b *etcdBackend) Name() string {
+	return "etcd"
+}
+
+// Load 读取配置内容
+func (b *etcdBackend) Load(ctx context.Context) ([]byte, error) {
+	data, _, err := b.get(ctx)
+	return data, err
+}
+
+// Watch 轮询键的修订号，变化时通知
+func (b *etcdBackend) Watch(ctx context.Context, onChange func(data []byte)) error {
+	_, revision, err := b.get(ctx)
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		ticker := time.NewTicker(b.cfg.PollInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				data, rev, err := b.get(ctx)
+				if err != nil {
+					fmt.Printf("读取etcd配置失败: %v\n", err)
+					continue
+				}
+				if rev != revision {
+					revision = rev
+					onChange(data)
+				}
+			}
+		}
+	}()
+
+	return nil
+}
+
+// get 读取键值及其修订号
+func (b *etcdBackend) get(ctx context.Context) ([]byte, int64, error) {
+	body, _ := json.Marshal(map[string]string{
+		"key": base64.StdEncoding.EncodeToString([]byte(b.cfg.Key)),
+	})
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, b.cfg.Endpoint+"/v3/kv/range", bytes.NewReader(body))
+	if err != nil {
+		return nil, 0, fmt.Errorf("创建etcd请求失败: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if b.cfg.Token != "" {
+		req.Header.Set("Authorization", b.cfg.Token)
+	}
+
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return nil, 0, fmt.Errorf("请求etcd失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, 0, fmt.Errorf("etcd返回错误状态 %d: %s", resp.StatusCode, msg)
+	}
+
+	var result struct {
+		Kvs []struct {
+			Value       string `json:"value"`
+			ModRevision string `json:"mod_revision"`
+		} `json:"kvs"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, 0, fmt.Errorf("解析etcd响应失败: %w", err)
+	}
+	if len(result.Kvs) == 0 {
+		return nil, 0, fmt.Errorf("etcd中不存在配置键: %s", b.cfg.Key)
+	}
+
+	data, err := base64.StdEncoding.DecodeString(result.Kvs[0].Value)
+	if err != nil {
+		return nil, 0, fmt.Errorf("解码etcd配置失败: %w", err)
+	}
+	revision, _ := strconv.ParseInt(result.Kvs[0].ModRevision, 10, 64)
+	return data, revision, nil
+}
+
+// consulBackend 基于 Consul KV 的配置来源，使用阻塞查询监视变化
+type consulBackend struct {
+	cfg    RemoteBackendConfig
+	client *http.Client
+}
+
+// Name 返回配置来源名称
+func (b *consulBackend) Name() string {
+	return "consul"
+}
+
+// Load 读取配置内容
+func (b *consulBackend) Load(ctx context.Context) ([]byte, error) {
+	data, _, err := b.get(ctx, 0)
+	return data, err
+}
+
+// Watch 通过阻塞查询等待 ModifyIndex 变化
+func (b *consulBackend) Watch(ctx context.Context, onChange func(data []byte)) error {
+	_, index, err := b.get(ctx, 0)
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		for {
+			data, newIndex, err := b.get(ctx, index)
+			if ctx.Err() != nil {
+				return
+			}
+			if err != nil {
+				fmt.Printf("读取Consul配置失败: %v\n", err)
+				// 出错时退避，避免空转
+				select {
+				case <-ctx.Done():
+					return
+				case <-time.After(5 * time.Second):
+				}
+				continue
+			}
+			// 索引回退时重置（Consul 快照恢复等场景）
+			if newIndex < index {
+				index = 0
+				continue
+			}
+			if newIndex != index {
+				index = newIndex
+				onChange(data)
+			}
+		}
+	}()
+
+	return nil
+}
+
+// get 读取键值，index 大于0时进行阻塞查询
+func (b *consulBackend) get(ctx context.Context, index uint64) ([]byte, uint64, error) {
+	url := fmt.Sprintf("%s/v1/kv/%s?raw=true", b.cfg.Endpoint, strings.TrimLeft(b.cfg.Key, "/"))
+	if index > 0 {
+		url += fmt.Sprintf("&index=%d&wait=%s", index, b.cfg.PollInterval)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, 0, fmt.Errorf("创建Consul请求失败: %w", err)
+	}
+	if b.cfg.Token != "" {
+		req.Header.Set("X-Consul-Token", b.cfg.Token)
+	}
+
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return nil, 0, fmt.Errorf("请求Consul失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, 0, fmt.Errorf("Consul中不存在配置键: %s", b.cfg.Key)
+	}
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, 0, fmt.Errorf("Consul返回错误状态 %d: %s", resp.StatusCode, msg)
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, 0, fmt.Errorf("读取Consul响应失败: %w", err)
+	}
+	newIndex, _ := strconv.ParseUint(resp.Header.Get("X-Consul-Index"), 10, 64)
+	return data, newIndex, nil
+}
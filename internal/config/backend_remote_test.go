@@ -0,0 +1,305 @@
+package config
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeKV 模拟 etcd/Consul 中保存配置的单个键，每次写入递增修订号
+type fakeKV struct {
+	mu       sync.Mutex
+	value    string
+	revision uint64
+	changed  chan struct{}
+	token    string
+}
+
+func newFakeKV(value string) *fakeKV {
+	return &fakeKV{value: value, revision: 1, changed: make(chan struct{})}
+}
+
+// set 写入新值并唤醒等待中的阻塞查询
+func (kv *fakeKV) set(value string) {
+	kv.mu.Lock()
+	defer kv.mu.Unlock()
+	kv.value = value
+	kv.revision++
+	close(kv.changed)
+	kv.changed = make(chan struct{})
+}
+
+func (kv *fakeKV) get() (string, uint64, chan struct{}) {
+	kv.mu.Lock()
+	defer kv.mu.Unlock()
+	return kv.value, kv.revision, kv.changed
+}
+
+// etcdServer 模拟 etcd v3 HTTP 网关的 /v3/kv/range 接口
+func etcdServer(t *testing.T, key string, kv *fakeKV) string {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/v3/kv/range" {
+			http.NotFound(w, r)
+			return
+		}
+		if r.Header.Get("Authorization") != kv.token {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var req struct {
+			Key string `json:"key"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		if req.Key != base64.StdEncoding.EncodeToString([]byte(key)) {
+			json.NewEncoder(w).Encode(map[string]interface{}{})
+			return
+		}
+		value, revision, _ := kv.get()
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"kvs": []map[string]string{{
+				"value":        base64.StdEncoding.EncodeToString([]byte(value)),
+				"mod_revision": strconv.FormatUint(revision, 10),
+			}},
+		})
+	}))
+	t.Cleanup(srv.Close)
+	return srv.URL
+}
+
+// consulServer 模拟 Consul KV 接口，index 与当前修订号相同时阻塞到值变化或 wait 超时
+func consulServer(t *testing.T, key string, kv *fakeKV) string {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/kv/"+key || r.URL.Query().Get("raw") != "true" {
+			http.NotFound(w, r)
+			return
+		}
+		if r.Header.Get("X-Consul-Token") != kv.token {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		value, revision, changed := kv.get()
+		if index := r.URL.Query().Get("index"); index == strconv.FormatUint(revision, 10) {
+			wait, _ := time.ParseDuration(r.URL.Query().Get("wait"))
+			select {
+			case <-changed:
+			case <-time.After(wait):
+			case <-r.Context().Done():
+				return
+			}
+			value, revision, _ = kv.get()
+		}
+		w.Header().Set("X-Consul-Index", strconv.FormatUint(revision, 10))
+		fmt.Fprint(w, value)
+	}))
+	t.Cleanup(srv.Close)
+	return srv.URL
+}
+
+// remoteServers 按类型启动模拟服务
+var remoteServers = map[string]func(t *testing.T, key string, kv *fakeKV) string{
+	"etcd":   etcdServer,
+	"consul": consulServer,
+}
+
+// remoteYAML 返回监听指定端口的完整配置
+func remoteYAML(port int) string {
+	return strings.Replace(minimalYAML, "port: 8080", fmt.Sprintf("port: %d", port), 1) + defaultRouteYAML
+}
+
+func TestRemoteBackendLoad(t *testing.T) {
+	for provider, start := range remoteServers {
+		t.Run(provider, func(t *testing.T) {
+			kv := newFakeKV("server:\n  port: 8080\n")
+			kv.token = "secret"
+			endpoint := start(t, "gateway/config.yaml", kv)
+
+			backend, err := NewRemoteBackend(RemoteBackendConfig{
+				Provider: provider, Endpoint: endpoint + "/", Key: "gateway/config.yaml", Token: "secret",
+			})
+			if err != nil {
+				t.Fatalf("创建远程配置来源失败: %v", err)
+			}
+			if backend.Name() != provider {
+				t.Fatalf("Name() = %q，期望 %q", backend.Name(), provider)
+			}
+			data, err := backend.Load(context.Background())
+			if err != nil {
+				t.Fatalf("读取远程配置失败: %v", err)
+			}
+			if string(data) != "server:\n  port: 8080\n" {
+				t.Fatalf("读取的配置 = %q", data)
+			}
+
+			// 键不存在或令牌错误时返回错误
+			missing, _ := NewRemoteBackend(RemoteBackendConfig{Provider: provider, Endpoint: endpoint, Key: "other", Token: "secret"})
+			if _, err := missing.Load(context.Background()); err == nil {
+				t.Fatal("读取不存在的键应失败")
+			}
+			denied, _ := NewRemoteBackend(RemoteBackendConfig{Provider: provider, Endpoint: endpoint, Key: "gateway/config.yaml"})
+			if _, err := denied.Load(context.Background()); err == nil {
+				t.Fatal("未携带令牌时读取应失败")
+			}
+		})
+	}
+}
+
+func TestRemoteBackendWatch(t *testing.T) {
+	for provider, start := range remoteServers {
+		t.Run(provider, func(t *testing.T) {
+			kv := newFakeKV("v1")
+			endpoint := start(t, "gateway/config.yaml", kv)
+			backend, err := NewRemoteBackend(RemoteBackendConfig{
+				Provider: provider, Endpoint: endpoint, Key: "gateway/config.yaml", PollInterval: 20 * time.Millisecond,
+			})
+			if err != nil {
+				t.Fatalf("创建远程配置来源失败: %v", err)
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			changes := make(chan string, 10)
+			if err := backend.Watch(ctx, func(data []byte) { changes <- string(data) }); err != nil {
+				t.Fatalf("监视远程配置失败: %v", err)
+			}
+
+			// 值未变化时不通知
+			select {
+			case data := <-changes:
+				t.Fatalf("配置未变化时收到通知: %q", data)
+			case <-time.After(100 * time.Millisecond):
+			}
+
+			kv.set("v2")
+			select {
+			case data := <-changes:
+				if data != "v2" {
+					t.Fatalf("通知的配置 = %q，期望 v2", data)
+				}
+			case <-time.After(2 * time.Second):
+				t.Fatal("远程配置变化后未收到通知")
+			}
+		})
+	}
+}
+
+func TestNewRemoteBackendRejectsInvalidConfig(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  RemoteBackendConfig
+		want string
+	}{
+		{"缺少地址", RemoteBackendConfig{Provider: "etcd", Key: "k"}, "远程配置地址不能为空"},
+		{"缺少键", RemoteBackendConfig{Provider: "consul", Endpoint: "http://127.0.0.1:8500"}, "远程配置键不能为空"},
+		{"未知类型", RemoteBackendConfig{Provider: "zookeeper", Endpoint: "http://127.0.0.1:2181", Key: "k"}, "不支持的远程配置类型"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewRemoteBackend(tt.cfg)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("err = %v，期望包含 %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfigCenterRollbackRemoteVersion(t *testing.T) {
+	kv := newFakeKV(remoteYAML(8080))
+	endpoint := consulServer(t, "gateway/config.yaml", kv)
+	backend, err := NewRemoteBackend(RemoteBackendConfig{
+		Provider: "consul", Endpoint: endpoint, Key: "gateway/config.yaml", PollInterval: time.Second,
+	})
+	if err != nil {
+		t.Fatalf("创建远程配置来源失败: %v", err)
+	}
+
+	cc := NewConfigCenter(10)
+	cc.SetBackend(backend)
+	if err := cc.Init(""); err != nil {
+		t.Fatalf("初始化配置中心失败: %v", err)
+	}
+	if err := cc.WatchConfig(nil); err != nil {
+		t.Fatalf("监视远程配置失败: %v", err)
+	}
+	defer cc.StopWatch()
+	initial := cc.LatestVersion()
+
+	// 远程推送的新版本
+	kv.set(remoteYAML(9090))
+	select {
+	case version := <-cc.Subscribe():
+		if version.Config.Server.Port != 9090 {
+			t.Fatalf("推送版本的端口 = %d，期望 9090", version.Config.Server.Port)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("远程配置变化后未记录新版本")
+	}
+	if got := cc.GetCurrentConfig().Server.Port; got != 9090 {
+		t.Fatalf("当前端口 = %d，期望 9090", got)
+	}
+
+	// 回滚到远程读取的初始版本
+	if _, err := cc.RollbackConfig(initial); err != nil {
+		t.Fatalf("回滚失败: %v", err)
+	}
+	if got := cc.GetCurrentConfig().Server.Port; got != 8080 {
+		t.Fatalf("回滚后端口 = %d，期望 8080", got)
+	}
+	versions := cc.GetVersions()
+	if len(versions) != 3 {
+		t.Fatalf("版本数 = %d，期望初始、远程推送和回滚共 3 个", len(versions))
+	}
+}
+
+func TestConfigCenterWatchAppliesBeforeRecording(t *testing.T) {
+	kv := newFakeKV(remoteYAML(8080))
+	endpoint := etcdServer(t, "gateway/config.yaml", kv)
+	backend, _ := NewRemoteBackend(RemoteBackendConfig{
+		Provider: "etcd", Endpoint: endpoint, Key: "gateway/config.yaml", PollInterval: 20 * time.Millisecond,
+	})
+
+	cc := NewConfigCenter(10)
+	cc.SetBackend(backend)
+	if err := cc.Init(""); err != nil {
+		t.Fatalf("初始化配置中心失败: %v", err)
+	}
+	applied := make(chan int, 10)
+	apply := func(cfg *Config) error {
+		applied <- cfg.Server.Port
+		if cfg.Server.Port == 9090 {
+			return fmt.Errorf("重载失败")
+		}
+		return nil
+	}
+	if err := cc.WatchConfig(apply); err != nil {
+		t.Fatalf("监视远程配置失败: %v", err)
+	}
+	defer cc.StopWatch()
+
+	// 应用失败的变更不记录版本
+	kv.set(remoteYAML(9090))
+	if port := <-applied; port != 9090 {
+		t.Fatalf("应用的端口 = %d，期望 9090", port)
+	}
+	kv.set(remoteYAML(9091))
+	select {
+	case version := <-cc.Subscribe():
+		if version.Config.Server.Port != 9091 {
+			t.Fatalf("记录的版本端口 = %d，期望 9091", version.Config.Server.Port)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("应用成功的变更未记录版本")
+	}
+	if n := len(cc.GetVersions()); n != 2 {
+		t.Fatalf("版本数 = %d，期望初始版本和应用成功的版本共 2 个", n)
+	}
+}
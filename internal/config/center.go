@@ -1,12 +1,13 @@
 package config
 
 import (
+	"bytes"
+	"context"
 	"fmt"
 	"reflect"
 	"sync"
 	"time"
 
-	"github.com/fsnotify/fsnotify"
 	"github.com/mitchellh/mapstructure"
 	"github.com/spf13/viper"
 )
@@ -27,6 +28,8 @@ type ConfigCenter struct {
 	mu            sync.RWMutex
 	viper         *viper.Viper
 	notifyChan    chan ConfigVersion
+	backend       Backend
+	cancelWatch   context.CancelFunc
 }
 
 // NewConfigCenter 创建配置中心管理器
@@ -38,21 +41,36 @@ func NewConfigCenter(maxVersions int) *ConfigCenter {
 	}
 }
 
+// SetBackend 设置配置来源，需在 Init 之前调用，未设置时使用本地文件
+func (cc *ConfigCenter) SetBackend(backend Backend) {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+	cc.backend = backend
+}
+
 // Init 初始化配置中心
+// 未通过 SetBackend 指定配置来源时从 configPath 读取本地文件
 func (cc *ConfigCenter) Init(configPath string) error {
 	cc.mu.Lock()
 	defer cc.mu.Unlock()
 
-	// 设置配置文件
-	cc.viper.SetConfigFile(configPath)
+	if cc.backend == nil {
+		cc.backend = NewFileBackend(configPath)
+	}
+
+	// 设置配置格式
 	cc.viper.SetConfigType("yaml")
 
 	// 环境变量支持
 	cc.viper.SetEnvPrefix("GATEWAY")
 	cc.viper.AutomaticEnv()
 
-	// 读取配置文件
-	if err := cc.viper.ReadInConfig(); err != nil {
+	// 读取配置
+	data, err := cc.backend.Load(context.Background())
+	if err != nil {
+		return fmt.Errorf("读取配置失败: %w", err)
+	}
+	if err := cc.viper.ReadConfig(bytes.NewReader(data)); err != nil {
 		return fmt.Errorf("读取配置文件失败: %w", err)
 	}
 
@@ -61,91 +79,109 @@ func (cc *ConfigCenter) Init(configPath string) error {
 	// 调试输出，查看read_timeout类型和值
 	fmt.Printf("[DEBUG] read_timeout type: %T, value: %#v\n", cc.viper.Get("server.read_timeout"), cc.viper.Get("server.read_timeout"))
 
-	// 解析配置，增强DecodeHook支持数字转time.Duration
-	var config Config
-	decodeHook := mapstructure.ComposeDecodeHookFunc(
-		// 支持字符串和数字转time.Duration
-		func(f reflect.Type, t reflect.Type, data interface{}) (interface{}, error) {
-			if t == reflect.TypeOf(time.Duration(0)) {
-				switch v := data.(type) {
-				case string:
-					d, err := time.ParseDuration(v)
-					if err != nil {
-						fmt.Printf("[DEBUG] time.ParseDuration(%q) err: %v\n", v, err)
-					}
-					return d, err
-				case int, int64, float64:
-					sec := reflect.ValueOf(v).Convert(reflect.TypeOf(int64(0))).Int()
-					return time.Duration(sec) * time.Second, nil
-				}
-			}
-			return data, nil
-		},
-		mapstructure.StringToTimeDurationHookFunc(),
-		mapstructure.StringToSliceHookFunc(","),
-	)
-	decoderConfig := &mapstructure.DecoderConfig{
-		DecodeHook: decodeHook,
-		Result:     &config,
-	}
-	decoder, err := mapstructure.NewDecoder(decoderConfig)
+	// 解析配置
+	config, err := decodeCenterConfig(cc.viper.AllSettings())
 	if err != nil {
-		return fmt.Errorf("创建解码器失败: %w", err)
-	}
-	if err := decoder.Decode(cc.viper.AllSettings()); err != nil {
-		return fmt.Errorf("解析配置文件失败: %w", err)
+		return err
 	}
 
 	// 解析后调试输出
 	fmt.Printf("[DEBUG] config.Server.ReadTimeout type: %T, value: %#v\n", config.Server.ReadTimeout, config.Server.ReadTimeout)
 
 	// 验证配置
-	if err := ValidateConfig(&config); err != nil {
+	if err := ValidateConfig(config); err != nil {
 		return fmt.Errorf("配置验证失败: %w", err)
 	}
 
 	// 保存当前配置
-	cc.currentConfig = &config
+	cc.currentConfig = config
 
 	// 添加初始版本
-	cc.addVersion("initial", "初始配置")
+	cc.addVersion("initial", fmt.Sprintf("初始配置（%s）", cc.backend.Name()))
 
 	return nil
 }
 
-// WatchConfig 监视配置变化
-func (cc *ConfigCenter) WatchConfig() {
-	cc.viper.WatchConfig()
-	cc.viper.OnConfigChange(func(e fsnotify.Event) {
-		cc.mu.Lock()
-		defer cc.mu.Unlock()
-
-		// 读取新配置
-		var newConfig Config
-		if err := cc.viper.Unmarshal(&newConfig); err != nil {
-			fmt.Printf("解析新配置失败: %v\n", err)
-			return
-		}
+// WatchConfig 监视配置来源变化，变更经验证后记录版本并发送通知
+// apply 不为空时先以 apply 应用新配置，应用失败的变更不记录版本
+func (cc *ConfigCenter) WatchConfig(apply func(*Config) error) error {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+
+	if cc.backend == nil {
+		return fmt.Errorf("配置中心未初始化")
+	}
+	if cc.cancelWatch != nil {
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	onChange := func(data []byte) {
+		cc.applyChange(data, apply)
+	}
+	if err := cc.backend.Watch(ctx, onChange); err != nil {
+		cancel()
+		return fmt.Errorf("监视配置失败: %w", err)
+	}
+	cc.cancelWatch = cancel
+	return nil
+}
+
+// StopWatch 停止监视配置来源
+func (cc *ConfigCenter) StopWatch() {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+
+	if cc.cancelWatch != nil {
+		cc.cancelWatch()
+		cc.cancelWatch = nil
+	}
+}
+
+// applyChange 应用配置来源推送的新内容
+func (cc *ConfigCenter) applyChange(data []byte, apply func(*Config) error) {
+	// 读取新配置
+	v := viper.New()
+	v.SetConfigType("yaml")
+	if err := v.ReadConfig(bytes.NewReader(data)); err != nil {
+		fmt.Printf("读取新配置失败: %v\n", err)
+		return
+	}
+	newConfig, err := decodeCenterConfig(v.AllSettings())
+	if err != nil {
+		fmt.Printf("解析新配置失败: %v\n", err)
+		return
+	}
+
+	// 验证新配置
+	if err := ValidateConfig(newConfig); err != nil {
+		fmt.Printf("新配置验证失败: %v\n", err)
+		return
+	}
 
-		// 验证新配置
-		if err := ValidateConfig(&newConfig); err != nil {
-			fmt.Printf("新配置验证失败: %v\n", err)
+	if apply != nil {
+		if err := apply(newConfig); err != nil {
+			fmt.Printf("应用新配置失败: %v\n", err)
 			return
 		}
+	}
+
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
 
-		// 更新配置
-		cc.currentConfig = &newConfig
+	// apply 的重载钩子已通过 RecordConfig 记录该版本
+	if cc.currentConfig == newConfig {
+		return
+	}
 
-		// 添加新版本
-		version := cc.addVersion("auto", "自动更新")
+	// 更新配置
+	cc.currentConfig = newConfig
 
-		// 发送通知
-		select {
-		case cc.notifyChan <- version:
-		default:
-			// 通道已满，丢弃通知
-		}
-	})
+	// 添加新版本
+	version := cc.addVersion(cc.backend.Name(), "自动更新")
+
+	// 发送通知
+	cc.notify(version)
 }
 
 // GetCurrentConfig 获取当前配置
@@ -155,15 +191,41 @@ func (cc *ConfigCenter) GetCurrentConfig() *Config {
 	return cc.currentConfig
 }
 
-// UpdateConfig 更新配置（支持部分更新）
-func (cc *ConfigCenter) UpdateConfig(newConfig *Config, comment string) error {
+// InitFromConfig 以已加载的配置初始化配置中心（配置由 ConfigManager 管理时使用）
+func (cc *ConfigCenter) InitFromConfig(config *Config, source string) error {
+	if err := ValidateConfig(config); err != nil {
+		return fmt.Errorf("配置验证失败: %w", err)
+	}
+
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+
+	cc.currentConfig = config
+	cc.addVersion("initial", fmt.Sprintf("初始配置（%s）", source))
+	return nil
+}
+
+// RecordConfig 记录外部加载的新配置（如文件重载），返回新版本号，配置与当前配置相同时忽略并返回空字符串
+func (cc *ConfigCenter) RecordConfig(config *Config, source, comment string) string {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+
+	if config == cc.currentConfig {
+		return ""
+	}
+	cc.currentConfig = config
+	return cc.addVersion(source, comment).Version
+}
+
+// UpdateConfig 更新配置（支持部分更新），返回新版本号
+func (cc *ConfigCenter) UpdateConfig(newConfig *Config, comment string) (string, error) {
 	cc.mu.Lock()
 	defer cc.mu.Unlock()
 
 	// 获取当前配置
 	currentConfig := cc.currentConfig
 	if currentConfig == nil {
-		return fmt.Errorf("当前配置未初始化")
+		return "", fmt.Errorf("当前配置未初始化")
 	}
 
 	// 创建合并后的配置
@@ -188,11 +250,20 @@ func (cc *ConfigCenter) UpdateConfig(newConfig *Config, comment string) error {
 	if newConfig.Log.MaxBackups > 0 {
 		mergedConfig.Log.MaxBackups = newConfig.Log.MaxBackups
 	}
+	if newConfig.Log.Access != nil {
+		mergedConfig.Log.Access = newConfig.Log.Access
+	}
+	if newConfig.Log.Sampling != nil {
+		mergedConfig.Log.Sampling = newConfig.Log.Sampling
+	}
 
 	// 合并插件配置
 	if len(newConfig.Plugins.Available) > 0 {
 		mergedConfig.Plugins.Available = newConfig.Plugins.Available
 	}
+	if len(newConfig.Plugins.External) > 0 {
+		mergedConfig.Plugins.External = newConfig.Plugins.External
+	}
 
 	// 合并路由配置 - 只更新非空值
 	if len(newConfig.Routes) > 0 {
@@ -215,7 +286,7 @@ func (cc *ConfigCenter) UpdateConfig(newConfig *Config, comment string) error {
 
 	// 验证合并后的配置
 	if err := ValidateConfig(&mergedConfig); err != nil {
-		return fmt.Errorf("配置验证失败: %w", err)
+		return "", fmt.Errorf("配置验证失败: %w", err)
 	}
 
 	// 更新配置
@@ -225,40 +296,40 @@ func (cc *ConfigCenter) UpdateConfig(newConfig *Config, comment string) error {
 	version := cc.addVersion("manual", comment)
 
 	// 发送通知
-	select {
-	case cc.notifyChan <- version:
-	default:
-		// 通道已满，丢弃通知
-	}
+	cc.notify(version)
 
-	return nil
+	return version.Version, nil
 }
 
-// RollbackConfig 回滚配置
-func (cc *ConfigCenter) RollbackConfig(version string) error {
+// RollbackConfig 回滚配置，返回回滚后的新版本号
+func (cc *ConfigCenter) RollbackConfig(version string) (string, error) {
 	cc.mu.Lock()
 	defer cc.mu.Unlock()
 
-	// 查找指定版本
+	// 查找指定版本（包括远程来源推送的版本）
 	var targetVersion *ConfigVersion
-	for _, v := range cc.versions {
-		if v.Version == version {
-			targetVersion = &v
+	for i := range cc.versions {
+		if cc.versions[i].Version == version {
+			targetVersion = &cc.versions[i]
 			break
 		}
 	}
 
 	if targetVersion == nil {
-		return fmt.Errorf("未找到指定版本: %s", version)
+		return "", fmt.Errorf("未找到指定版本: %s", version)
 	}
 
-	// 更新配置
-	cc.currentConfig = &targetVersion.Config
+	// 更新配置，复制一份避免版本历史被后续修改影响
+	rollbackConfig := targetVersion.Config
+	cc.currentConfig = &rollbackConfig
 
 	// 添加回滚版本
-	cc.addVersion("rollback", fmt.Sprintf("回滚到版本 %s", version))
+	rollbackVersion := cc.addVersion("rollback", fmt.Sprintf("回滚到版本 %s", version))
 
-	return nil
+	// 发送通知
+	cc.notify(rollbackVersion)
+
+	return rollbackVersion.Version, nil
 }
 
 // GetVersions 获取配置版本历史
@@ -271,6 +342,17 @@ func (cc *ConfigCenter) GetVersions() []ConfigVersion {
 	return versions
 }
 
+// LatestVersion 返回最新的配置版本号，没有版本时返回空字符串
+func (cc *ConfigCenter) LatestVersion() string {
+	cc.mu.RLock()
+	defer cc.mu.RUnlock()
+
+	if len(cc.versions) == 0 {
+		return ""
+	}
+	return cc.versions[len(cc.versions)-1].Version
+}
+
 // GetVersion 获取指定版本的配置
 func (cc *ConfigCenter) GetVersion(version string) (*ConfigVersion, error) {
 	cc.mu.RLock()
@@ -290,6 +372,15 @@ func (cc *ConfigCenter) Subscribe() <-chan ConfigVersion {
 	return cc.notifyChan
 }
 
+// notify 发送配置变更通知，通道已满时丢弃
+func (cc *ConfigCenter) notify(version ConfigVersion) {
+	select {
+	case cc.notifyChan <- version:
+	default:
+		// 通道已满，丢弃通知
+	}
+}
+
 // addVersion 添加配置版本
 func (cc *ConfigCenter) addVersion(source, comment string) ConfigVersion {
 	version := ConfigVersion{
@@ -309,3 +400,50 @@ func (cc *ConfigCenter) addVersion(source, comment string) ConfigVersion {
 
 	return version
 }
+
+// decodeCenterConfig 解析配置，增强DecodeHook支持数字转time.Duration
+func decodeCenterConfig(settings map[string]interface{}) (*Config, error) {
+	var config Config
+	if err := decodeSettings(settings, &config); err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+// decodeSettings 将通用结构解码到 result
+func decodeSettings(settings interface{}, result interface{}) error {
+	decodeHook := mapstructure.ComposeDecodeHookFunc(
+		// 支持字符串和数字转time.Duration
+		func(f reflect.Type, t reflect.Type, data interface{}) (interface{}, error) {
+			if t == reflect.TypeOf(time.Duration(0)) {
+				switch v := data.(type) {
+				case string:
+					d, err := time.ParseDuration(v)
+					if err != nil {
+						fmt.Printf("[DEBUG] time.ParseDuration(%q) err: %v\n", v, err)
+					}
+					return d, err
+				case int, int64, float64:
+					sec := reflect.ValueOf(v).Convert(reflect.TypeOf(int64(0))).Int()
+					return time.Duration(sec) * time.Second, nil
+				}
+			}
+			return data, nil
+		},
+		mapstructure.StringToTimeDurationHookFunc(),
+		mapstructure.StringToSliceHookFunc(","),
+	)
+	decoderConfig := &mapstructure.DecoderConfig{
+		DecodeHook: decodeHook,
+		Result:     result,
+	}
+	decoder, err := mapstructure.NewDecoder(decoderConfig)
+	if err != nil {
+		return fmt.Errorf("创建解码器失败: %w", err)
+	}
+	if err := decoder.Decode(settings); err != nil {
+		return fmt.Errorf("解析配置文件失败: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,49 @@
+package config
+
+import (
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DecodeConfigMap 将 JSON/YAML 解析得到的通用结构解码为配置，键名与配置文件一致
+func DecodeConfigMap(settings map[string]interface{}) (*Config, error) {
+	return decodeCenterConfig(settings)
+}
+
+// DecodeRouteMap 将通用结构解码为单个路由配置
+func DecodeRouteMap(settings map[string]interface{}) (*RouteConfig, error) {
+	var route RouteConfig
+	if err := decodeSettings(settings, &route); err != nil {
+		return nil, err
+	}
+	return &route, nil
+}
+
+// EncodeConfigMap 将配置转换为与配置文件键名一致的通用结构，便于以 JSON 输出
+func EncodeConfigMap(config *Config) (map[string]interface{}, error) {
+	data, err := yaml.Marshal(config)
+	if err != nil {
+		return nil, fmt.Errorf("序列化配置失败: %w", err)
+	}
+
+	var settings map[string]interface{}
+	if err := yaml.Unmarshal(data, &settings); err != nil {
+		return nil, fmt.Errorf("转换配置失败: %w", err)
+	}
+	return settings, nil
+}
+
+// EncodeRouteMap 将路由配置转换为与配置文件键名一致的通用结构
+func EncodeRouteMap(route *RouteConfig) (map[string]interface{}, error) {
+	data, err := yaml.Marshal(route)
+	if err != nil {
+		return nil, fmt.Errorf("序列化路由失败: %w", err)
+	}
+
+	var settings map[string]interface{}
+	if err := yaml.Unmarshal(data, &settings); err != nil {
+		return nil, fmt.Errorf("转换路由失败: %w", err)
+	}
+	return settings, nil
+}
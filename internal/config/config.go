@@ -1,6 +1,9 @@
 package config
 
 import (
+	"fmt"
+	"net"
+	"regexp"
 	"strings"
 	"time"
 
@@ -24,6 +27,152 @@ type ServerConfig struct {
 	WriteTimeout            time.Duration `yaml:"write_timeout" mapstructure:"write_timeout"`
 	MaxHeaderBytes          int           `yaml:"max_header_bytes" mapstructure:"max_header_bytes"`
 	GracefulShutdownTimeout time.Duration `yaml:"graceful_shutdown_timeout" mapstructure:"graceful_shutdown_timeout"`
+	// 读取请求头的超时时间，为0时使用 read_timeout
+	ReadHeaderTimeout time.Duration `yaml:"read_header_timeout" mapstructure:"read_header_timeout"`
+	// keep-alive 连接等待下一个请求的超时时间，为0时使用 read_timeout
+	IdleTimeout time.Duration `yaml:"idle_timeout" mapstructure:"idle_timeout"`
+	TLS         *TLSConfig    `yaml:"tls" mapstructure:"tls"`
+	// 上游连接池配置
+	Transport TransportConfig `yaml:"transport" mapstructure:"transport"`
+	// 管理API配置
+	Admin AdminConfig `yaml:"admin" mapstructure:"admin"`
+	// 死信日志配置
+	DeadLetter DeadLetterConfig `yaml:"dead_letter" mapstructure:"dead_letter"`
+	// 网关自身产生的错误响应附加的诊断头名称，为空时不添加
+	ErrorSourceHeader string `yaml:"error_source_header" mapstructure:"error_source_header"`
+	// 错误消息目录，目录下的 <语言>.json 为该语言的错误消息，按 Accept-Language 本地化错误响应；为空时使用默认消息
+	ErrorMessagesDir string `yaml:"error_messages_dir" mapstructure:"error_messages_dir"`
+	// 转发失败时按错误类别（dns/connect/timeout/reset/other）返回的响应，未配置的类别超时返回 504，其他返回 502
+	UpstreamErrors map[string]UpstreamErrorConfig `yaml:"upstream_errors" mapstructure:"upstream_errors"`
+	// 请求捕获配置
+	Capture CaptureConfig `yaml:"capture" mapstructure:"capture"`
+	// 可信代理IP或网段，只采信来自这些地址的 X-Forwarded-For/X-Real-IP；未配置时采信所有来源
+	TrustedProxies []string `yaml:"trusted_proxies" mapstructure:"trusted_proxies"`
+	// 并发限制配置，超出限制时优先拒绝低优先级请求
+	Concurrency ConcurrencyConfig `yaml:"concurrency" mapstructure:"concurrency"`
+	// 远程配置来源，配置后启动时从 etcd 或 Consul 读取完整配置并监视变化，本地文件只作为引导配置
+	ConfigSource *RemoteBackendConfig `yaml:"config_source" mapstructure:"config_source"`
+}
+
+// ConcurrencyConfig 并发限制配置，按优先级分类为每类请求划分可占用的并发上限
+type ConcurrencyConfig struct {
+	// 最大并发请求数，0 表示不限制
+	MaxRequests int `yaml:"max_requests" mapstructure:"max_requests"`
+	// 消费者标识请求头，默认 X-API-Key
+	ConsumerHeader string `yaml:"consumer_header" mapstructure:"consumer_header"`
+	// 未匹配任何分类的请求可占用的并发比例（0-1]，默认 0.8
+	DefaultMaxRatio float64 `yaml:"default_max_ratio" mapstructure:"default_max_ratio"`
+	// 优先级分类，按顺序匹配第一个满足条件的分类，只对来自 trusted_proxies 的请求生效
+	Classes []QoSClassConfig `yaml:"classes" mapstructure:"classes"`
+}
+
+// QoSClassConfig 请求优先级分类，配置的条件需全部满足
+type QoSClassConfig struct {
+	// 分类名称，用于日志和监控指标
+	Name string `yaml:"name" mapstructure:"name"`
+	// 匹配的消费者标识（consumer_header 的值）
+	Consumers []string `yaml:"consumers" mapstructure:"consumers"`
+	// 匹配的请求头，值为空时只要求请求头存在
+	Headers map[string]string `yaml:"headers" mapstructure:"headers"`
+	// 该分类可占用的并发比例（0-1]，默认 1 即可使用全部并发
+	MaxRatio float64 `yaml:"max_ratio" mapstructure:"max_ratio"`
+}
+
+// TransportConfig 上游连接池配置，零值表示使用 Go 默认值
+type TransportConfig struct {
+	// 所有上游的最大空闲连接数
+	MaxIdleConns int `yaml:"max_idle_conns" mapstructure:"max_idle_conns"`
+	// 每个上游主机的最大空闲连接数
+	MaxIdleConnsPerHost int `yaml:"max_idle_conns_per_host" mapstructure:"max_idle_conns_per_host"`
+	// 每个上游主机的最大连接数，0 表示不限制
+	MaxConnsPerHost int `yaml:"max_conns_per_host" mapstructure:"max_conns_per_host"`
+	// 空闲连接超时时间
+	IdleConnTimeout time.Duration `yaml:"idle_conn_timeout" mapstructure:"idle_conn_timeout"`
+	// 建立连接超时时间
+	DialTimeout time.Duration `yaml:"dial_timeout" mapstructure:"dial_timeout"`
+	// TCP keep-alive 探测间隔
+	KeepAlive time.Duration `yaml:"keep_alive" mapstructure:"keep_alive"`
+	// 禁用 HTTP keep-alive，每个请求使用新连接
+	DisableKeepAlives bool `yaml:"disable_keep_alives" mapstructure:"disable_keep_alives"`
+}
+
+// TLSConfig HTTPS监听配置
+type TLSConfig struct {
+	// 是否启用HTTPS
+	Enabled bool `yaml:"enabled" mapstructure:"enabled"`
+	// HTTPS监听端口，为0或与server.port相同时只监听HTTPS
+	Port int `yaml:"port" mapstructure:"port"`
+	// 证书文件路径
+	CertFile string `yaml:"cert_file" mapstructure:"cert_file"`
+	// 私钥文件路径
+	KeyFile string `yaml:"key_file" mapstructure:"key_file"`
+	// 最低TLS版本：1.0, 1.1, 1.2, 1.3
+	MinVersion string `yaml:"min_version" mapstructure:"min_version"`
+	// 客户端CA证书路径，配置后启用双向认证（mTLS）
+	ClientCAFile string `yaml:"client_ca_file" mapstructure:"client_ca_file"`
+	// 通过 ALPN 协商启用 HTTP/2，未启用时只支持 HTTP/1.1
+	HTTP2 bool `yaml:"http2" mapstructure:"http2"`
+	// HTTP/3（QUIC）监听配置，未配置时不监听
+	HTTP3 *HTTP3Config `yaml:"http3" mapstructure:"http3"`
+}
+
+// HTTP3Config HTTP/3 监听配置，与 HTTPS 使用相同的证书和处理器
+type HTTP3Config struct {
+	// 是否启用HTTP/3
+	Enabled bool `yaml:"enabled" mapstructure:"enabled"`
+	// UDP监听端口，为0时与HTTPS端口相同
+	Port int `yaml:"port" mapstructure:"port"`
+}
+
+// AdminConfig 管理API配置
+type AdminConfig struct {
+	// 访问令牌，请求需携带 Authorization: Bearer <token>，为空时不启用管理API
+	Token string `yaml:"token" mapstructure:"token"`
+	// 保留的配置版本数量，默认 10
+	MaxVersions int `yaml:"max_versions" mapstructure:"max_versions"`
+	// 审计日志输出：文件路径，为空或 log 时写入网关日志
+	AuditLog string `yaml:"audit_log" mapstructure:"audit_log"`
+}
+
+// CaptureConfig 请求捕获配置，在内存中保留最近的请求和响应摘要，通过管理API查看
+type CaptureConfig struct {
+	// 是否启用
+	Enabled bool `yaml:"enabled" mapstructure:"enabled"`
+	// 保留的记录数，默认 100
+	Capacity int `yaml:"capacity" mapstructure:"capacity"`
+	// 采样率（0-1），默认 1 即捕获全部请求
+	SampleRate float64 `yaml:"sample_rate" mapstructure:"sample_rate"`
+	// 记录的请求体和响应体最大字节数，默认 1024
+	MaxBodySize int `yaml:"max_body_size" mapstructure:"max_body_size"`
+	// 额外隐藏的请求头和响应头，Authorization、Cookie 等默认隐藏
+	RedactHeaders []string `yaml:"redact_headers" mapstructure:"redact_headers"`
+	// 隐藏的 JSON 字段名（不区分大小写），如 password
+	RedactFields []string `yaml:"redact_fields" mapstructure:"redact_fields"`
+}
+
+// DeadLetterConfig 死信日志配置，记录重试耗尽后仍转发失败的请求
+type DeadLetterConfig struct {
+	// 是否启用
+	Enabled bool `yaml:"enabled" mapstructure:"enabled"`
+	// 输出位置：log（写入网关日志，默认）或文件路径（每行一条 JSON 记录）
+	Output string `yaml:"output" mapstructure:"output"`
+	// 记录的请求体最大字节数，默认 65536
+	MaxBodySize int `yaml:"max_body_size" mapstructure:"max_body_size"`
+}
+
+// UpstreamErrorCategories 可配置响应的转发失败类别
+var UpstreamErrorCategories = []string{"dns", "connect", "timeout", "reset", "other"}
+
+// UpstreamErrorConfig 转发失败时返回的响应
+type UpstreamErrorConfig struct {
+	// 响应状态码，为 0 时使用默认状态码
+	Status int `yaml:"status" mapstructure:"status"`
+	// 响应体，为空时返回网关统一格式的错误响应
+	Body string `yaml:"body" mapstructure:"body"`
+	// 响应体类型，默认 application/json
+	ContentType string `yaml:"content_type" mapstructure:"content_type"`
+	// Retry-After 响应头（秒），为 0 时不设置
+	RetryAfter int `yaml:"retry_after" mapstructure:"retry_after"`
 }
 
 // LogConfig 日志配置
@@ -35,12 +184,38 @@ type LogConfig struct {
 	MaxAge     int    `yaml:"max_age" mapstructure:"max_age"`
 	MaxBackups int    `yaml:"max_backups" mapstructure:"max_backups"`
 	Compress   bool   `yaml:"compress" mapstructure:"compress"`
+	// 独立的访问日志输出，未配置时访问日志在 info 级别下写入主日志
+	Access *AccessLogConfig `yaml:"access" mapstructure:"access"`
+	// 访问日志采样配置，未配置时记录所有请求
+	Sampling *LogSamplingConfig `yaml:"sampling" mapstructure:"sampling"`
+	// 慢请求阈值，耗时不低于该值的请求记录警告日志，0 表示不记录
+	SlowRequestThreshold time.Duration `yaml:"slow_request_threshold" mapstructure:"slow_request_threshold"`
+}
+
+// LogSamplingConfig 访问日志采样配置，错误响应不受采样率影响
+type LogSamplingConfig struct {
+	// 其他请求的采样率 [0, 1]，0 表示只记录错误响应
+	Rate float64 `yaml:"rate" mapstructure:"rate"`
+	// 状态码不低于该值的请求始终记录，默认 500
+	AlwaysStatus int `yaml:"always_status" mapstructure:"always_status"`
+}
+
+// AccessLogConfig 访问日志配置，轮转参数为 0 时使用主日志的配置
+type AccessLogConfig struct {
+	// 输出位置：stdout 或文件路径
+	Output     string `yaml:"output" mapstructure:"output"`
+	MaxSize    int    `yaml:"max_size" mapstructure:"max_size"`
+	MaxAge     int    `yaml:"max_age" mapstructure:"max_age"`
+	MaxBackups int    `yaml:"max_backups" mapstructure:"max_backups"`
+	Compress   *bool  `yaml:"compress" mapstructure:"compress"`
 }
 
 // PluginsConfig 插件配置
 type PluginsConfig struct {
 	Available []PluginConfig            `yaml:"available" mapstructure:"available"`
 	Routes    map[string][]PluginConfig `yaml:"routes" mapstructure:"routes"`
+	// 外部插件（Go 插件 .so 文件）路径，启动时加载并注册，之后可在 available 中按名称配置
+	External []string `yaml:"external" mapstructure:"external"`
 }
 
 // PluginConfig 插件配置
@@ -49,6 +224,12 @@ type PluginConfig struct {
 	Enabled bool                   `yaml:"enabled" mapstructure:"enabled"`
 	Order   int                    `yaml:"order" mapstructure:"order"`
 	Config  map[string]interface{} `yaml:"config" mapstructure:"config"`
+	// 插件结果缓存时间，未配置时使用默认值 10s，0 表示不缓存
+	CacheTTL *time.Duration `yaml:"cache_ttl" mapstructure:"cache_ttl"`
+	// 跳过该插件的请求路径，支持 * 通配符
+	SkipPaths []string `yaml:"skip_paths" mapstructure:"skip_paths"`
+	// 跳过该插件的请求方法
+	SkipMethods []string `yaml:"skip_methods" mapstructure:"skip_methods"`
 }
 
 // RouteMatch 路由匹配规则
@@ -60,15 +241,170 @@ type RouteMatch struct {
 	Method      string            `yaml:"method" mapstructure:"method"`
 	Headers     map[string]string `yaml:"headers" mapstructure:"headers"`
 	QueryParams map[string]string `yaml:"query_params" mapstructure:"query_params"`
+	// 允许的客户端IP或网段（CIDR），为空时不限制
+	SourceCIDR []string `yaml:"source_cidr" mapstructure:"source_cidr"`
+
+	// regex 和 wildcard 类型预编译的路径正则，由 Compile 生成
+	pattern *regexp.Regexp
+}
+
+// pathPattern 返回 regex 和 wildcard 类型路径对应的正则表达式，其他类型返回 false
+func (m RouteMatch) pathPattern() (string, bool) {
+	switch m.Type {
+	case "regex":
+		return m.Path, true
+	case "wildcard":
+		// * 匹配任意字符，其余字符按字面匹配
+		return "^" + strings.ReplaceAll(regexp.QuoteMeta(m.Path), `\*`, ".*") + "$", true
+	default:
+		return "", false
+	}
+}
+
+// Compile 预编译 regex 和 wildcard 类型的路径匹配规则，匹配请求时不再编译
+func (m *RouteMatch) Compile() error {
+	expr, ok := m.pathPattern()
+	if !ok {
+		m.pattern = nil
+		return nil
+	}
+	pattern, err := regexp.Compile(expr)
+	if err != nil {
+		return err
+	}
+	m.pattern = pattern
+	return nil
+}
+
+// MatchPath 检查请求路径是否匹配，未指定匹配类型时按 exact 处理
+// regex 和 wildcard 类型需先调用 Compile，未编译时不匹配任何路径
+func (m RouteMatch) MatchPath(path string) bool {
+	switch m.Type {
+	case "prefix":
+		return strings.HasPrefix(path, m.Path)
+	case "regex", "wildcard":
+		return m.pattern != nil && m.pattern.MatchString(path)
+	default:
+		return path == m.Path
+	}
+}
+
+// Equivalent 检查两条匹配规则是否无法区分：匹配类型、路径、优先级及其他条件完全相同
+// 未指定匹配类型时按 exact 处理
+func (m RouteMatch) Equivalent(other RouteMatch) bool {
+	matchType := func(t string) string {
+		if t == "" {
+			return "exact"
+		}
+		return t
+	}
+	if matchType(m.Type) != matchType(other.Type) || m.Path != other.Path || m.Priority != other.Priority ||
+		m.Host != other.Host || m.Method != other.Method {
+		return false
+	}
+	if !sameStringMap(m.Headers, other.Headers) || !sameStringMap(m.QueryParams, other.QueryParams) {
+		return false
+	}
+	return sameStringSet(m.SourceCIDR, other.SourceCIDR)
+}
+
+// sameStringMap 比较两个字符串映射是否相同，nil 与空映射视为相同
+func sameStringMap(a, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for key, value := range a {
+		if other, exists := b[key]; !exists || other != value {
+			return false
+		}
+	}
+	return true
+}
+
+// sameStringSet 比较两个字符串列表包含的元素是否相同，忽略顺序
+func sameStringSet(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	counts := make(map[string]int, len(a))
+	for _, item := range a {
+		counts[item]++
+	}
+	for _, item := range b {
+		if counts[item] == 0 {
+			return false
+		}
+		counts[item]--
+	}
+	return true
+}
+
+// MatchSourceIP 检查客户端IP是否属于 source_cidr，未配置 source_cidr 时返回 true
+func (m RouteMatch) MatchSourceIP(clientIP string) bool {
+	if len(m.SourceCIDR) == 0 {
+		return true
+	}
+	ip := net.ParseIP(clientIP)
+	if ip == nil {
+		return false
+	}
+	for _, cidr := range m.SourceCIDR {
+		if ipNet, err := ParseCIDR(cidr); err == nil && ipNet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseCIDR 解析网段，单个IP视为只包含该IP的网段
+func ParseCIDR(cidr string) (*net.IPNet, error) {
+	if !strings.Contains(cidr, "/") {
+		ip := net.ParseIP(cidr)
+		if ip == nil {
+			return nil, fmt.Errorf("无效的IP地址: %s", cidr)
+		}
+		bits := net.IPv6len * 8
+		if ip4 := ip.To4(); ip4 != nil {
+			ip, bits = ip4, net.IPv4len*8
+		}
+		return &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}, nil
+	}
+	_, ipNet, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return nil, fmt.Errorf("无效的网段: %s", cidr)
+	}
+	return ipNet, nil
 }
 
 // RouteConfig 路由配置
 type RouteConfig struct {
-	Name     string          `yaml:"name" mapstructure:"name"`
-	Match    RouteMatch      `yaml:"match" mapstructure:"match"`
-	Target   TargetConfig    `yaml:"target" mapstructure:"target"`
-	Plugins  []string        `yaml:"plugins" mapstructure:"plugins"`
-	Response *ResponseConfig `yaml:"response" mapstructure:"response"`
+	Name    string       `yaml:"name" mapstructure:"name"`
+	Match   RouteMatch   `yaml:"match" mapstructure:"match"`
+	Target  TargetConfig `yaml:"target" mapstructure:"target"`
+	Plugins []string     `yaml:"plugins" mapstructure:"plugins"`
+	// 插件执行顺序：order（默认）按插件的 order 排序，list 按 plugins 列表中的顺序执行
+	PluginOrder string `yaml:"plugin_order" mapstructure:"plugin_order"`
+	// 路由级插件配置，按插件名覆盖 plugins.available 中的同名配置项
+	PluginConfig map[string]map[string]interface{} `yaml:"plugin_config" mapstructure:"plugin_config"`
+	Response     *ResponseConfig                   `yaml:"response" mapstructure:"response"`
+	// 仅在响应为 4xx/5xx 时记录完整请求和响应详情
+	LogOnError bool `yaml:"log_on_error" mapstructure:"log_on_error"`
+	// 错误预算，上游错误率超出预算时主动拒绝部分请求
+	ErrorBudget *ErrorBudgetConfig `yaml:"error_budget" mapstructure:"error_budget"`
+}
+
+// ErrorBudgetConfig 错误预算配置
+type ErrorBudgetConfig struct {
+	// 允许的上游错误率（0-1），如 0.05 表示 5%
+	Budget float64 `yaml:"budget" mapstructure:"budget"`
+	// 统计窗口，默认 1m
+	Window time.Duration `yaml:"window" mapstructure:"window"`
+	// 窗口内请求数达到该值才计算错误率，默认 20
+	MinRequests int `yaml:"min_requests" mapstructure:"min_requests"`
+	// 最大拒绝比例（0-1），保留部分请求探测上游是否恢复，默认 0.9
+	MaxShedRatio float64 `yaml:"max_shed_ratio" mapstructure:"max_shed_ratio"`
+	// 拒绝请求的状态码，429 或 503，默认 503
+	Status int `yaml:"status" mapstructure:"status"`
 }
 
 // ResponseConfig 响应配置
@@ -80,12 +416,157 @@ type ResponseConfig struct {
 
 // TargetConfig 目标服务配置
 type TargetConfig struct {
-	// 服务地址
+	// 服务地址，多个后端用逗号分隔
 	URL string `yaml:"url" mapstructure:"url"`
-	// 超时时间（毫秒）
+	// 负载均衡配置（多个后端时生效）
+	LoadBalancer *LoadBalancerConfig `yaml:"load_balancer" mapstructure:"load_balancer"`
+	// 超时时间（毫秒，含重试），为 0 时不限制
 	Timeout int `yaml:"timeout" mapstructure:"timeout"`
-	// 重试次数
+	// 上游连接失败时的重试次数（多个后端时切换后端）
 	Retries int `yaml:"retries" mapstructure:"retries"`
+	// 按请求方法覆盖超时和重试次数，按顺序匹配第一个包含请求方法的配置
+	MethodPolicies []MethodPolicyConfig `yaml:"method_policies" mapstructure:"method_policies"`
+	// 路径编码处理：raw（默认，保留客户端原始编码，如 %2F）、decoded（按解码后的路径重新编码）
+	PathEncoding string `yaml:"path_encoding" mapstructure:"path_encoding"`
+	// 上游TLS配置（https目标）
+	TLS *UpstreamTLSConfig `yaml:"tls" mapstructure:"tls"`
+	// 转发请求头的大小写配置
+	HeaderCase *HeaderCaseConfig `yaml:"header_case" mapstructure:"header_case"`
+	// 上游响应头过滤配置
+	ResponseHeaders *ResponseHeaderFilterConfig `yaml:"response_headers" mapstructure:"response_headers"`
+	// 会话保持配置，将同一客户端固定到同一后端
+	Sticky *StickyConfig `yaml:"sticky" mapstructure:"sticky"`
+	// 按权重在多个目标间分配流量（灰度发布），配置后不再使用 url 选择后端
+	TrafficSplit []TrafficSplitConfig `yaml:"traffic_split" mapstructure:"traffic_split"`
+	// 流量镜像配置，按比例将请求复制到镜像服务，镜像响应被丢弃
+	Mirror *MirrorConfig `yaml:"mirror" mapstructure:"mirror"`
+	// 转发到上游时附加的固定请求头，覆盖客户端同名请求头
+	Headers map[string]string `yaml:"headers" mapstructure:"headers"`
+	// 流式响应配置，text/event-stream 响应始终逐块转发
+	Streaming *StreamingConfig `yaml:"streaming" mapstructure:"streaming"`
+}
+
+// StreamingConfig 流式响应配置
+type StreamingConfig struct {
+	// 额外按流式转发的响应类型（如 application/x-ndjson），text/event-stream 无需配置
+	ContentTypes []string `yaml:"content_types" mapstructure:"content_types"`
+}
+
+// MirrorConfig 流量镜像配置
+type MirrorConfig struct {
+	// 镜像服务地址
+	URL string `yaml:"url" mapstructure:"url"`
+	// 镜像的请求百分比（0-100）
+	Percentage float64 `yaml:"percentage" mapstructure:"percentage"`
+	// 镜像请求超时时间（毫秒），默认 5000
+	Timeout int `yaml:"timeout" mapstructure:"timeout"`
+}
+
+// StickyConfig 会话保持配置
+type StickyConfig struct {
+	// 会话保持 cookie 名称，默认 gateway_sticky
+	Cookie string `yaml:"cookie" mapstructure:"cookie"`
+	// 按请求头的值固定后端（如 X-Session-ID），配置后不使用 cookie
+	Header string `yaml:"header" mapstructure:"header"`
+	// cookie 有效期，为 0 时为会话 cookie
+	TTL time.Duration `yaml:"ttl" mapstructure:"ttl"`
+}
+
+// TrafficSplitConfig 流量分配目标
+type TrafficSplitConfig struct {
+	// 目标服务地址，多个后端用逗号分隔
+	Target string `yaml:"target" mapstructure:"target"`
+	// 权重（百分比），所有目标的权重之和必须为 100
+	Weight int `yaml:"weight" mapstructure:"weight"`
+}
+
+// Backends 返回目标的后端地址列表
+func (t TargetConfig) Backends() []string {
+	var backends []string
+	for _, backend := range strings.Split(t.URL, ",") {
+		if backend = strings.TrimSpace(backend); backend != "" {
+			backends = append(backends, backend)
+		}
+	}
+	return backends
+}
+
+// Policy 返回请求方法对应的超时时间和重试次数，未匹配 method_policies 时使用 target 配置
+func (t TargetConfig) Policy(method string) (time.Duration, int) {
+	timeout, retries := t.Timeout, t.Retries
+	for _, policy := range t.MethodPolicies {
+		if !policy.matches(method) {
+			continue
+		}
+		if policy.Timeout > 0 {
+			timeout = policy.Timeout
+		}
+		if policy.Retries != nil {
+			retries = *policy.Retries
+		}
+		break
+	}
+	return time.Duration(timeout) * time.Millisecond, retries
+}
+
+// MethodPolicyConfig 按请求方法覆盖的超时和重试配置
+type MethodPolicyConfig struct {
+	// 适用的请求方法，不区分大小写
+	Methods []string `yaml:"methods" mapstructure:"methods"`
+	// 超时时间（毫秒），为 0 时使用 target.timeout
+	Timeout int `yaml:"timeout" mapstructure:"timeout"`
+	// 重试次数，未配置时使用 target.retries
+	Retries *int `yaml:"retries" mapstructure:"retries"`
+}
+
+// matches 判断配置是否适用于请求方法
+func (p MethodPolicyConfig) matches(method string) bool {
+	for _, m := range p.Methods {
+		if strings.EqualFold(m, method) {
+			return true
+		}
+	}
+	return false
+}
+
+// LoadBalancerConfig 负载均衡配置
+type LoadBalancerConfig struct {
+	// 负载均衡策略：round_robin（默认）、least_latency、consistent_hash
+	Strategy string `yaml:"strategy" mapstructure:"strategy"`
+	// 一致性哈希的键来源：client_ip（默认）、path、header:<名称>
+	HashKey string `yaml:"hash_key" mapstructure:"hash_key"`
+	// 一致性哈希环上每个后端的虚拟节点数，默认 160
+	VirtualNodes int `yaml:"virtual_nodes" mapstructure:"virtual_nodes"`
+}
+
+// HeaderCaseConfig 转发到上游的请求头名称大小写配置
+type HeaderCaseConfig struct {
+	// 大小写模式：canonical（默认，Go 规范形式如 X-Api-Key）、lower（全部小写）
+	Mode string `yaml:"mode" mapstructure:"mode"`
+	// 指定请求头的精确写法，键不区分大小写，优先于 mode
+	Mappings map[string]string `yaml:"mappings" mapstructure:"mappings"`
+}
+
+// ResponseHeaderFilterConfig 上游响应头过滤配置，名称不区分大小写
+type ResponseHeaderFilterConfig struct {
+	// 只转发这些响应头，为空时转发全部；Content-Type、Content-Length、Content-Encoding 始终转发
+	Allow []string `yaml:"allow" mapstructure:"allow"`
+	// 不转发这些响应头，优先于 allow
+	Deny []string `yaml:"deny" mapstructure:"deny"`
+}
+
+// UpstreamTLSConfig 上游TLS配置
+type UpstreamTLSConfig struct {
+	// CA证书路径，用于校验上游证书（内部CA）
+	CAFile string `yaml:"ca_file" mapstructure:"ca_file"`
+	// 客户端证书路径（mTLS）
+	CertFile string `yaml:"cert_file" mapstructure:"cert_file"`
+	// 客户端私钥路径（mTLS）
+	KeyFile string `yaml:"key_file" mapstructure:"key_file"`
+	// 跳过证书校验（仅限测试环境）
+	InsecureSkipVerify bool `yaml:"insecure_skip_verify" mapstructure:"insecure_skip_verify"`
+	// SNI 覆盖，为空时使用目标主机名
+	ServerName string `yaml:"server_name" mapstructure:"server_name"`
 }
 
 var GlobalConfig Config
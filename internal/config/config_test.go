@@ -0,0 +1,76 @@
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTargetPolicy(t *testing.T) {
+	retries := 3
+	target := TargetConfig{
+		Timeout: 1000,
+		Retries: 1,
+		MethodPolicies: []MethodPolicyConfig{
+			{Methods: []string{"get", "HEAD"}, Timeout: 5000, Retries: &retries},
+			{Methods: []string{"PUT"}, Timeout: 2000},
+		},
+	}
+	tests := []struct {
+		method      string
+		wantTimeout time.Duration
+		wantRetries int
+	}{
+		{"GET", 5 * time.Second, 3},
+		{"HEAD", 5 * time.Second, 3},
+		// 只覆盖超时，重试次数沿用 target 配置
+		{"PUT", 2 * time.Second, 1},
+		{"POST", time.Second, 1},
+	}
+	for _, tt := range tests {
+		timeout, retries := target.Policy(tt.method)
+		if timeout != tt.wantTimeout || retries != tt.wantRetries {
+			t.Fatalf("%s 策略 = (%v, %d)，期望 (%v, %d)", tt.method, timeout, retries, tt.wantTimeout, tt.wantRetries)
+		}
+	}
+}
+
+func TestRouteMatchPath(t *testing.T) {
+	tests := []struct {
+		match RouteMatch
+		path  string
+		want  bool
+	}{
+		{RouteMatch{Type: "exact", Path: "/orders"}, "/orders", true},
+		{RouteMatch{Type: "exact", Path: "/orders"}, "/orders/1", false},
+		// 未指定类型时按 exact 处理
+		{RouteMatch{Path: "/orders"}, "/orders/1", false},
+		{RouteMatch{Type: "prefix", Path: "/orders"}, "/orders/1", true},
+		{RouteMatch{Type: "prefix", Path: "/orders"}, "/users", false},
+		{RouteMatch{Type: "regex", Path: `^/users/\d+$`}, "/users/42", true},
+		{RouteMatch{Type: "regex", Path: `^/users/\d+$`}, "/users/abc", false},
+		{RouteMatch{Type: "regex", Path: `/v\d/`}, "/api/v2/users", true},
+		{RouteMatch{Type: "wildcard", Path: "/static/*"}, "/static/css/site.css", true},
+		{RouteMatch{Type: "wildcard", Path: "/static/*"}, "/api/static/site.css", false},
+		{RouteMatch{Type: "wildcard", Path: "/files/*.json"}, "/files/a/b.json", true},
+		// 除 * 外的字符按字面匹配
+		{RouteMatch{Type: "wildcard", Path: "/files/*.json"}, "/files/a/bxjson", false},
+		{RouteMatch{Type: "wildcard", Path: "/api/*/items"}, "/api/v1/items/1", false},
+	}
+	for _, tt := range tests {
+		match := tt.match
+		if err := match.Compile(); err != nil {
+			t.Fatalf("编译 %s %s 失败: %v", match.Type, match.Path, err)
+		}
+		if got := match.MatchPath(tt.path); got != tt.want {
+			t.Fatalf("%s %s 匹配 %s = %v，期望 %v", match.Type, match.Path, tt.path, got, tt.want)
+		}
+	}
+
+	// 未编译的 regex 规则不匹配任何路径
+	if (RouteMatch{Type: "regex", Path: ".*"}).MatchPath("/") {
+		t.Fatal("未编译的正则规则不应匹配")
+	}
+	if err := (&RouteMatch{Type: "regex", Path: "^/users/(\\d+$"}).Compile(); err == nil {
+		t.Fatal("无效的正则表达式应编译失败")
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strings"
 
 	"github.com/mitchellh/mapstructure"
@@ -51,7 +52,12 @@ func LoadConfig(configPath string) (*Config, error) {
 		return nil, fmt.Errorf("创建解码器失败: %w", err)
 	}
 
-	if err := decoder.Decode(v.AllSettings()); err != nil {
+	settings := v.AllSettings()
+	if _, err := mergeIncludes(v.ConfigFileUsed(), settings); err != nil {
+		return nil, err
+	}
+
+	if err := decoder.Decode(expandEnvVars(settings)); err != nil {
 		return nil, fmt.Errorf("解析配置文件失败: %w", err)
 	}
 
@@ -63,6 +69,132 @@ func LoadConfig(configPath string) (*Config, error) {
 	return &config, nil
 }
 
+// mergeIncludes 读取 include 指定的文件并将其中的路由合并到主配置，返回解析后的 include 路径
+// include 路径相对于主配置文件所在目录，支持通配符；被包含的文件只能定义 routes，路由名称在所有文件中不能重复
+func mergeIncludes(configPath string, settings map[string]interface{}) ([]string, error) {
+	patterns, err := includePatterns(configPath, settings["include"])
+	if err != nil {
+		return nil, err
+	}
+	delete(settings, "include")
+	if len(patterns) == 0 {
+		return nil, nil
+	}
+
+	routes, _ := settings["routes"].([]interface{})
+	sources := make(map[string]string)
+	for _, route := range routes {
+		if name := routeName(route); name != "" {
+			sources[name] = configPath
+		}
+	}
+
+	for _, pattern := range patterns {
+		files, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("无效的 include 路径 %s: %w", pattern, err)
+		}
+		for _, file := range files {
+			iv := viper.New()
+			iv.SetConfigFile(file)
+			iv.SetConfigType("yaml")
+			if err := iv.ReadInConfig(); err != nil {
+				return nil, fmt.Errorf("读取包含的配置文件 %s 失败: %w", file, err)
+			}
+			for key := range iv.AllSettings() {
+				if key != "routes" {
+					return nil, fmt.Errorf("包含的配置文件 %s 只能定义 routes，不支持 %s", file, key)
+				}
+			}
+
+			included, ok := iv.Get("routes").([]interface{})
+			if !ok && iv.Get("routes") != nil {
+				return nil, fmt.Errorf("包含的配置文件 %s 的 routes 必须是列表", file)
+			}
+			for _, route := range included {
+				name := routeName(route)
+				if source, exists := sources[name]; exists && name != "" {
+					return nil, fmt.Errorf("路由 %s 在 %s 和 %s 中重复定义", name, source, file)
+				}
+				sources[name] = file
+				routes = append(routes, route)
+			}
+		}
+	}
+
+	settings["routes"] = routes
+	return patterns, nil
+}
+
+// includePatterns 解析 include 配置，相对路径基于主配置文件所在目录
+func includePatterns(configPath string, value interface{}) ([]string, error) {
+	var raw []string
+	switch v := value.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		raw = []string{v}
+	case []interface{}:
+		for _, item := range v {
+			pattern, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("include 必须是路径列表")
+			}
+			raw = append(raw, pattern)
+		}
+	default:
+		return nil, fmt.Errorf("include 必须是路径列表")
+	}
+
+	patterns := make([]string, 0, len(raw))
+	for _, pattern := range raw {
+		if !filepath.IsAbs(pattern) {
+			pattern = filepath.Join(filepath.Dir(configPath), pattern)
+		}
+		patterns = append(patterns, pattern)
+	}
+	return patterns, nil
+}
+
+// routeName 返回路由配置中的名称
+func routeName(route interface{}) string {
+	if m, ok := route.(map[string]interface{}); ok {
+		name, _ := m["name"].(string)
+		return name
+	}
+	return ""
+}
+
+// envVarPattern 匹配配置值中的 ${VAR} 和 ${VAR:-default}
+var envVarPattern = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)(:-([^}]*))?\}`)
+
+// expandEnvVars 递归替换配置中字符串值里的环境变量引用
+// 变量未设置或为空时使用默认值，没有默认值时替换为空字符串
+func expandEnvVars(value interface{}) interface{} {
+	switch v := value.(type) {
+	case string:
+		return envVarPattern.ReplaceAllStringFunc(v, func(ref string) string {
+			match := envVarPattern.FindStringSubmatch(ref)
+			if env := os.Getenv(match[1]); env != "" {
+				return env
+			}
+			return match[3]
+		})
+	case map[string]interface{}:
+		for key, item := range v {
+			v[key] = expandEnvVars(item)
+		}
+		return v
+	case []interface{}:
+		for i, item := range v {
+			v[i] = expandEnvVars(item)
+		}
+		return v
+	default:
+		return value
+	}
+}
+
 // validateConfig 验证配置
 func validateConfig(config *Config) error {
 	// 验证服务器配置
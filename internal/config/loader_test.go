@@ -0,0 +1,197 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+func TestExpandEnvVars(t *testing.T) {
+	t.Setenv("GW_TEST_HOST", "10.0.0.1")
+	t.Setenv("GW_TEST_EMPTY", "")
+
+	tests := []struct {
+		name  string
+		value string
+		want  string
+	}{
+		{"已设置", "http://${GW_TEST_HOST}:8081", "http://10.0.0.1:8081"},
+		{"已设置时忽略默认值", "${GW_TEST_HOST:-127.0.0.1}", "10.0.0.1"},
+		{"未设置", "secret-${GW_TEST_MISSING}", "secret-"},
+		{"未设置时使用默认值", "${GW_TEST_MISSING:-127.0.0.1}", "127.0.0.1"},
+		{"为空时使用默认值", "${GW_TEST_EMPTY:-fallback}", "fallback"},
+		{"默认值为空", "${GW_TEST_MISSING:-}", ""},
+		{"多个引用", "${GW_TEST_HOST}/${GW_TEST_MISSING:-v1}", "10.0.0.1/v1"},
+		{"不是引用", "$GW_TEST_HOST and ${1INVALID}", "$GW_TEST_HOST and ${1INVALID}"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := expandEnvVars(tt.value); got != tt.want {
+				t.Fatalf("expandEnvVars(%q) = %q，期望 %q", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadConfigExpandsEnvVars(t *testing.T) {
+	t.Setenv("GW_TEST_UPSTREAM", "http://10.0.0.1:8081")
+	t.Setenv("GW_TEST_BACKEND_TOKEN", "s3cret")
+	path := writeConfigFile(t, minimalYAML+`
+routes:
+  - name: api
+    match:
+      type: prefix
+      path: /api
+    target:
+      url: ${GW_TEST_UPSTREAM}
+      headers:
+        X-Backend-Token: ${GW_TEST_BACKEND_TOKEN}
+plugins:
+  available:
+    - name: interface_auth
+      enabled: true
+      config:
+        secret: ${GW_TEST_SECRET:-dev-secret}
+        scopes: [read, "${GW_TEST_SCOPE:-write}"]
+`)
+	cm := NewConfigManager(path)
+	if err := cm.LoadConfig(""); err != nil {
+		t.Fatalf("加载配置失败: %v", err)
+	}
+
+	cfg := cm.GetConfig()
+	if route := cfg.Routes[0]; route.Target.URL != "http://10.0.0.1:8081" {
+		t.Fatalf("target.url = %q，期望使用环境变量的值", route.Target.URL)
+	}
+	// viper 将键名转为小写，转发时按规范形式设置请求头
+	if token := cfg.Routes[0].Target.Headers["x-backend-token"]; token != "s3cret" {
+		t.Fatalf("target.headers 的值 = %q，期望使用环境变量的值", token)
+	}
+	settings := cfg.Plugins.Available[0].Config
+	scopes, _ := settings["scopes"].([]interface{})
+	if settings["secret"] != "dev-secret" || len(scopes) != 2 || scopes[1] != "write" {
+		t.Fatalf("插件配置 = %v，期望嵌套的字符串值使用默认值", settings)
+	}
+
+	// 展开后的值同样经过验证，缺少必填的 target.url 时拒绝加载
+	path = writeConfigFile(t, minimalYAML+`
+routes:
+  - name: api
+    match:
+      type: prefix
+      path: /api
+    target:
+      url: ${GW_TEST_MISSING}
+`)
+	if err := NewConfigManager(path).TestConfig(""); err == nil {
+		t.Fatal("环境变量未设置导致 target.url 为空时期望验证失败")
+	}
+}
+
+// writeFiles 在 dir 下按相对路径写入文件，自动创建所在目录
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for file, content := range files {
+		path := filepath.Join(dir, file)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestLoadConfigGlobIncludes(t *testing.T) {
+	path := writeConfigFile(t, minimalYAML+`
+include: [routes/*.yaml, extra/orders.yaml]
+routes:
+  - name: main
+    match:
+      type: prefix
+      path: /
+    target:
+      url: http://127.0.0.1:8081
+`)
+	dir := filepath.Dir(path)
+	writeFiles(t, dir, map[string]string{
+		"routes/b.yaml":     routeFileYAML("b", "/b"),
+		"routes/a.yaml":     routeFileYAML("a", "/a"),
+		"routes/notes.txt":  "不匹配通配符的文件不加载",
+		"extra/orders.yaml": routeFileYAML("orders", "/orders"),
+	})
+
+	cm := NewConfigManager(path)
+	if err := cm.LoadConfig(""); err != nil {
+		t.Fatalf("加载配置失败: %v", err)
+	}
+	var names []string
+	for _, route := range cm.GetConfig().Routes {
+		names = append(names, route.Name)
+	}
+	if got := strings.Join(names, ","); got != "main,a,b,orders" {
+		t.Fatalf("合并后的路由 = %s，期望 main,a,b,orders", got)
+	}
+
+	// 匹配 include 的文件新建或修改时触发重载
+	for _, tt := range []struct {
+		file string
+		op   fsnotify.Op
+		want bool
+	}{
+		{"routes/c.yaml", fsnotify.Create, true},
+		{"routes/a.yaml", fsnotify.Write, true},
+		{"routes/a.yaml", fsnotify.Remove, false},
+		{"routes/notes.txt", fsnotify.Write, false},
+		{"extra/orders.yaml", fsnotify.Write, true},
+		{"extra/users.yaml", fsnotify.Create, false},
+	} {
+		if got := cm.isConfigFile(filepath.Join(dir, tt.file), tt.op); got != tt.want {
+			t.Fatalf("%s %v 触发重载 = %v，期望 %v", tt.file, tt.op, got, tt.want)
+		}
+	}
+}
+
+func TestIncludeRejectsInvalidFiles(t *testing.T) {
+	tests := []struct {
+		name  string
+		main  string
+		files map[string]string
+		want  string
+	}{
+		{
+			name:  "与主配置重名",
+			main:  defaultRouteYAML,
+			files: map[string]string{"routes/a.yaml": routeFileYAML("default", "/a")},
+			want:  "路由 default 在 ",
+		},
+		{
+			name: "不同文件之间重名",
+			files: map[string]string{
+				"routes/a.yaml": routeFileYAML("orders", "/a"),
+				"routes/b.yaml": routeFileYAML("orders", "/b"),
+			},
+			want: "routes/a.yaml 和 ",
+		},
+		{
+			name:  "定义路由以外的配置",
+			files: map[string]string{"routes/a.yaml": "server:\n  port: 9090\n"},
+			want:  "只能定义 routes，不支持 server",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeConfigFile(t, minimalYAML+"include: [routes/*.yaml]\n"+tt.main)
+			writeFiles(t, filepath.Dir(path), tt.files)
+			err := NewConfigManager(path).TestConfig("")
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("TestConfig 返回 %v，期望包含 %q", err, tt.want)
+			}
+		})
+	}
+}
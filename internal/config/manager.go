@@ -5,6 +5,7 @@ import (
 	"os"
 	"os/signal"
 	"path/filepath"
+	"strings"
 	"sync"
 	"syscall"
 	"time"
@@ -21,9 +22,13 @@ type ConfigManager struct {
 	viper         *viper.Viper
 	watcher       *fsnotify.Watcher
 	mu            sync.RWMutex
-	reloadChan    chan struct{}
-	stopChan      chan struct{}
-	reloadHooks   []func(*Config) error
+	// 串行化配置重载，同一时间只执行一次重载
+	reloadMu    sync.Mutex
+	reloadChan  chan struct{}
+	stopChan    chan struct{}
+	reloadHooks []func(*Config) error
+	// 主配置文件 include 的文件路径，可包含通配符
+	includes []string
 }
 
 // NewConfigManager 创建配置管理器
@@ -72,7 +77,12 @@ func (cm *ConfigManager) TestConfig(configPath string) error {
 		return fmt.Errorf("创建解码器失败: %w", err)
 	}
 
-	if err := decoder.Decode(testViper.AllSettings()); err != nil {
+	settings := testViper.AllSettings()
+	if _, err := mergeIncludes(configPath, settings); err != nil {
+		return err
+	}
+
+	if err := decoder.Decode(expandEnvVars(settings)); err != nil {
 		return fmt.Errorf("解析配置文件失败: %w", err)
 	}
 
@@ -94,16 +104,17 @@ func (cm *ConfigManager) LoadConfig(configPath string) error {
 	cm.mu.Lock()
 	defer cm.mu.Unlock()
 
-	// 设置配置文件
-	cm.viper.SetConfigFile(configPath)
-	cm.viper.SetConfigType("yaml")
+	// 读入新的viper实例，加载失败或回滚时保留原有的配置内容
+	v := viper.New()
+	v.SetConfigFile(configPath)
+	v.SetConfigType("yaml")
 
 	// 环境变量支持
-	cm.viper.SetEnvPrefix("GATEWAY")
-	cm.viper.AutomaticEnv()
+	v.SetEnvPrefix("GATEWAY")
+	v.AutomaticEnv()
 
 	// 读取配置文件
-	if err := cm.viper.ReadInConfig(); err != nil {
+	if err := v.ReadInConfig(); err != nil {
 		return fmt.Errorf("读取配置文件失败: %w", err)
 	}
 
@@ -122,7 +133,13 @@ func (cm *ConfigManager) LoadConfig(configPath string) error {
 		return fmt.Errorf("创建解码器失败: %w", err)
 	}
 
-	if err := decoder.Decode(cm.viper.AllSettings()); err != nil {
+	settings := v.AllSettings()
+	includes, err := mergeIncludes(configPath, settings)
+	if err != nil {
+		return err
+	}
+
+	if err := decoder.Decode(expandEnvVars(settings)); err != nil {
 		return fmt.Errorf("解析配置文件失败: %w", err)
 	}
 
@@ -133,13 +150,20 @@ func (cm *ConfigManager) LoadConfig(configPath string) error {
 
 	// 更新当前配置
 	cm.currentConfig = &config
+	cm.viper = v
+	cm.includes = includes
+	cm.watchIncludes()
 
 	fmt.Printf("✓ 配置文件 %s 加载成功\n", configPath)
 	return nil
 }
 
 // ReloadConfig 热重载配置 - 类似 nginx -s reload
+// 与其他重载和 ApplyConfig 串行执行
 func (cm *ConfigManager) ReloadConfig() error {
+	cm.reloadMu.Lock()
+	defer cm.reloadMu.Unlock()
+
 	fmt.Println("正在重新加载配置...")
 
 	// 测试新配置
@@ -147,19 +171,105 @@ func (cm *ConfigManager) ReloadConfig() error {
 		return fmt.Errorf("配置测试失败: %w", err)
 	}
 
+	// 保存当前配置，钩子执行失败时回滚
+	previous := cm.snapshot()
+
 	// 加载新配置
 	if err := cm.LoadConfig(cm.configPath); err != nil {
 		return fmt.Errorf("配置加载失败: %w", err)
 	}
 
 	// 执行重载钩子
-	for _, hook := range cm.reloadHooks {
-		if err := hook(cm.currentConfig); err != nil {
+	if err := cm.runReloadHooks(cm.GetConfig()); err != nil {
+		return cm.rollback(previous, err)
+	}
+
+	fmt.Println("✓ 配置重载成功")
+	return nil
+}
+
+// ApplyConfig 应用内存中的新配置并执行重载钩子（与文件重载相同的流程）
+// 与文件重载串行执行
+func (cm *ConfigManager) ApplyConfig(config *Config) error {
+	cm.reloadMu.Lock()
+	defer cm.reloadMu.Unlock()
+
+	previous := cm.snapshot()
+	if err := cm.SetConfig(config); err != nil {
+		return err
+	}
+
+	if err := cm.runReloadHooks(config); err != nil {
+		return cm.rollback(previous, err)
+	}
+
+	fmt.Println("✓ 配置已应用")
+	return nil
+}
+
+// runReloadHooks 依次执行重载钩子
+func (cm *ConfigManager) runReloadHooks(config *Config) error {
+	cm.mu.RLock()
+	hooks := make([]func(*Config) error, len(cm.reloadHooks))
+	copy(hooks, cm.reloadHooks)
+	cm.mu.RUnlock()
+
+	for _, hook := range hooks {
+		if err := hook(config); err != nil {
 			return fmt.Errorf("重载钩子执行失败: %w", err)
 		}
 	}
+	return nil
+}
 
-	fmt.Println("✓ 配置重载成功")
+// configSnapshot 重载前的配置状态，重载钩子执行失败时整体恢复
+type configSnapshot struct {
+	config   *Config
+	viper    *viper.Viper
+	includes []string
+}
+
+// snapshot 保存当前配置、配置文件内容和 include 的文件路径
+func (cm *ConfigManager) snapshot() configSnapshot {
+	cm.mu.RLock()
+	defer cm.mu.RUnlock()
+	return configSnapshot{
+		config:   cm.currentConfig,
+		viper:    cm.viper,
+		includes: cm.includes,
+	}
+}
+
+// rollback 重载钩子执行失败后恢复之前的配置，并重新执行钩子撤销已生效的部分变更
+func (cm *ConfigManager) rollback(previous configSnapshot, cause error) error {
+	if previous.config == nil {
+		return cause
+	}
+
+	cm.mu.Lock()
+	cm.currentConfig = previous.config
+	cm.viper = previous.viper
+	cm.includes = previous.includes
+	cm.mu.Unlock()
+
+	if err := cm.runReloadHooks(previous.config); err != nil {
+		fmt.Printf("✗ 配置回滚失败: %v\n", err)
+		return fmt.Errorf("%w; 回滚到之前的配置失败: %v", cause, err)
+	}
+
+	fmt.Printf("✗ %v，已回滚到之前的配置（%d 条路由，%d 个插件）\n", cause, len(previous.config.Routes), len(previous.config.Plugins.Available))
+	return fmt.Errorf("%w，已回滚到之前的配置", cause)
+}
+
+// SetConfig 直接设置内存中的配置（用于嵌入或测试场景，无需配置文件）
+func (cm *ConfigManager) SetConfig(config *Config) error {
+	if err := ValidateConfig(config); err != nil {
+		return fmt.Errorf("配置验证失败: %w", err)
+	}
+
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+	cm.currentConfig = config
 	return nil
 }
 
@@ -184,30 +294,29 @@ func (cm *ConfigManager) WatchConfig() error {
 		return fmt.Errorf("创建文件监视器失败: %w", err)
 	}
 
-	cm.watcher = watcher
-
 	// 监视配置文件所在目录
 	configDir := filepath.Dir(cm.configPath)
 	if err := watcher.Add(configDir); err != nil {
+		watcher.Close()
 		return fmt.Errorf("添加监视目录失败: %w", err)
 	}
 
+	cm.mu.Lock()
+	cm.watcher = watcher
+	cm.watchIncludes()
+	cm.mu.Unlock()
+
 	go func() {
 		for {
 			select {
 			case event := <-watcher.Events:
-				if event.Name == cm.configPath && (event.Op&fsnotify.Write == fsnotify.Write) {
+				if cm.isConfigFile(event.Name, event.Op) {
 					fmt.Printf("检测到配置文件变化: %s\n", event.Name)
 
 					// 延迟重载，避免文件写入未完成
 					time.Sleep(100 * time.Millisecond)
 
-					// 发送重载信号
-					select {
-					case cm.reloadChan <- struct{}{}:
-					default:
-						// 通道已满，忽略
-					}
+					cm.TriggerReload()
 				}
 			case err := <-watcher.Errors:
 				fmt.Printf("文件监视错误: %v\n", err)
@@ -220,6 +329,59 @@ func (cm *ConfigManager) WatchConfig() error {
 	return nil
 }
 
+// watchIncludes 监视 include 文件所在目录，调用方需持有锁
+func (cm *ConfigManager) watchIncludes() {
+	if cm.watcher == nil {
+		return
+	}
+	for _, pattern := range cm.includes {
+		dirs := make(map[string]bool)
+		if !strings.ContainsAny(filepath.Dir(pattern), "*?[") {
+			dirs[filepath.Dir(pattern)] = true
+		}
+		files, _ := filepath.Glob(pattern)
+		for _, file := range files {
+			dirs[filepath.Dir(file)] = true
+		}
+		for dir := range dirs {
+			if err := cm.watcher.Add(dir); err != nil {
+				fmt.Printf("添加监视目录 %s 失败: %v\n", dir, err)
+			}
+		}
+	}
+}
+
+// isConfigFile 检查文件事件是否涉及主配置文件或 include 的文件
+// include 的文件在新建时也触发重载，便于按目录添加路由文件
+func (cm *ConfigManager) isConfigFile(name string, op fsnotify.Op) bool {
+	if name == cm.configPath {
+		return op&fsnotify.Write == fsnotify.Write
+	}
+	if op&(fsnotify.Write|fsnotify.Create) == 0 {
+		return false
+	}
+
+	cm.mu.RLock()
+	defer cm.mu.RUnlock()
+	for _, pattern := range cm.includes {
+		if matched, _ := filepath.Match(pattern, name); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// TriggerReload 请求异步重载配置，由重载工作协程执行
+// 已有待执行的重载时合并为一次，返回 false 表示本次请求被合并
+func (cm *ConfigManager) TriggerReload() bool {
+	select {
+	case cm.reloadChan <- struct{}{}:
+		return true
+	default:
+		return false
+	}
+}
+
 // StartReloadWorker 启动重载工作协程
 func (cm *ConfigManager) StartReloadWorker() {
 	go func() {
@@ -254,8 +416,8 @@ func (cm *ConfigManager) HandleSignals() {
 			switch sig {
 			case syscall.SIGHUP, syscall.SIGUSR1:
 				fmt.Printf("收到信号 %v，重新加载配置\n", sig)
-				if err := cm.ReloadConfig(); err != nil {
-					fmt.Printf("配置重载失败: %v\n", err)
+				if !cm.TriggerReload() {
+					fmt.Println("已有待执行的配置重载，本次请求已合并")
 				}
 			}
 		}
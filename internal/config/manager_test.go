@@ -0,0 +1,209 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// minimalYAML 可通过验证的最小配置文件内容，routes 部分由调用方追加
+const minimalYAML = `
+server:
+  port: 8080
+  read_timeout: 1s
+  write_timeout: 1s
+  max_header_bytes: 1048576
+  graceful_shutdown_timeout: 1s
+log:
+  level: info
+  format: json
+  output: stdout
+  max_size: 1
+  max_age: 1
+  max_backups: 1
+`
+
+// defaultRouteYAML 单条默认路由
+const defaultRouteYAML = `
+routes:
+  - name: default
+    match:
+      type: prefix
+      path: /
+    target:
+      url: http://127.0.0.1:8081
+`
+
+// writeConfigFile 将配置内容写入临时目录下的 config.yaml 并返回路径
+func writeConfigFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestTestConfigRejectsUndefinedPlugin(t *testing.T) {
+	path := writeConfigFile(t, minimalYAML+`
+routes:
+  - name: api
+    match:
+      type: prefix
+      path: /api
+    target:
+      url: http://127.0.0.1:8081
+    plugins: [missing]
+`)
+	err := NewConfigManager(path).TestConfig("")
+	if err == nil || !strings.Contains(err.Error(), "路由 api 引用的插件 missing 未在 plugins.available 中定义") {
+		t.Fatalf("TestConfig 应拒绝引用未定义插件的路由，实际: %v", err)
+	}
+}
+
+func TestTriggerReloadCoalesces(t *testing.T) {
+	cm := NewConfigManager("")
+	if !cm.TriggerReload() {
+		t.Fatal("第一次重载请求应被接受")
+	}
+	for i := 0; i < 10; i++ {
+		if cm.TriggerReload() {
+			t.Fatal("已有待执行的重载时后续请求应被合并")
+		}
+	}
+}
+
+func TestConcurrentReloadsAreSerialized(t *testing.T) {
+	path := writeConfigFile(t, minimalYAML+defaultRouteYAML)
+	cm := NewConfigManager(path)
+	if err := cm.LoadConfig(""); err != nil {
+		t.Fatal(err)
+	}
+
+	var active, maxActive, runs atomic.Int32
+	cm.AddReloadHook(func(cfg *Config) error {
+		n := active.Add(1)
+		defer active.Add(-1)
+		for {
+			max := maxActive.Load()
+			if n <= max || maxActive.CompareAndSwap(max, n) {
+				break
+			}
+		}
+		runs.Add(1)
+		if len(cfg.Routes) != 1 || cfg.Routes[0].Name != "default" {
+			t.Errorf("重载钩子收到不完整的配置: %+v", cfg.Routes)
+		}
+		time.Sleep(time.Millisecond)
+		return nil
+	})
+	cm.StartReloadWorker()
+	defer cm.Stop()
+
+	// 同时从文件重载、管理API和信号/文件监视触发重载
+	applied := cm.GetConfig()
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(3)
+		go func() {
+			defer wg.Done()
+			if err := cm.ReloadConfig(); err != nil {
+				t.Errorf("重载失败: %v", err)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			if err := cm.ApplyConfig(applied); err != nil {
+				t.Errorf("应用配置失败: %v", err)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			cm.TriggerReload()
+		}()
+	}
+	wg.Wait()
+
+	// 等待工作协程处理完合并后的重载请求
+	deadline := time.Now().Add(time.Second)
+	for len(cm.reloadChan) > 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	// 等待正在执行的重载结束
+	cm.reloadMu.Lock()
+	cm.reloadMu.Unlock()
+
+	if got := maxActive.Load(); got != 1 {
+		t.Fatalf("最多有 %d 个重载同时执行，期望串行", got)
+	}
+	if runs.Load() < 10 {
+		t.Fatalf("重载钩子执行 %d 次，期望至少 10 次", runs.Load())
+	}
+	if cfg := cm.GetConfig(); cfg == nil || len(cfg.Routes) != 1 {
+		t.Fatalf("重载后的配置不一致: %+v", cfg)
+	}
+}
+
+// routeFileYAML 只包含一条路由的 include 文件内容
+func routeFileYAML(name, path string) string {
+	return `
+routes:
+  - name: ` + name + `
+    match:
+      type: prefix
+      path: ` + path + `
+    target:
+      url: http://127.0.0.1:8081
+`
+}
+
+func TestReloadHookFailureRestoresConfig(t *testing.T) {
+	path := writeConfigFile(t, minimalYAML+"include: [routes/*.yaml]\n")
+	dir := filepath.Dir(path)
+	writeFiles(t, dir, map[string]string{
+		"routes/a.yaml": routeFileYAML("a", "/a"),
+		"extra/b.yaml":  routeFileYAML("b", "/b"),
+	})
+
+	cm := NewConfigManager(path)
+	if err := cm.LoadConfig(""); err != nil {
+		t.Fatal(err)
+	}
+	previous := cm.GetConfig()
+	cm.AddReloadHook(func(cfg *Config) error {
+		if cfg.Server.Port != 8080 {
+			return fmt.Errorf("端口 %d 不可用", cfg.Server.Port)
+		}
+		return nil
+	})
+
+	// 新配置修改端口并 include 其他目录，钩子执行失败
+	updated := strings.Replace(minimalYAML, "port: 8080", "port: 9090", 1) + "include: [extra/*.yaml]\n"
+	if err := os.WriteFile(path, []byte(updated), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := cm.ReloadConfig(); err == nil || !strings.Contains(err.Error(), "已回滚到之前的配置") {
+		t.Fatalf("重载钩子失败时返回 %v，期望回滚", err)
+	}
+
+	// 配置、配置文件内容和 include 路径都恢复为重载前的状态
+	if cm.GetConfig() != previous {
+		t.Fatalf("回滚后配置 = %+v，期望恢复为重载前的配置", cm.GetConfig())
+	}
+	if port := cm.viper.GetInt("server.port"); port != 8080 {
+		t.Fatalf("回滚后配置文件内容中的端口 = %d，期望 8080", port)
+	}
+	if len(cm.includes) != 1 || cm.includes[0] != filepath.Join(dir, "routes/*.yaml") {
+		t.Fatalf("回滚后 include 路径 = %v，期望 routes/*.yaml", cm.includes)
+	}
+	if !cm.isConfigFile(filepath.Join(dir, "routes/c.yaml"), fsnotify.Create) || cm.isConfigFile(filepath.Join(dir, "extra/c.yaml"), fsnotify.Create) {
+		t.Fatal("回滚后应只监视重载前 include 的文件")
+	}
+}
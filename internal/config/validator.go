@@ -2,7 +2,12 @@ package config
 
 import (
 	"fmt"
+	"mime"
+	"net/http"
 	"net/url"
+	"path/filepath"
+	"regexp"
+	"slices"
 	"strings"
 )
 
@@ -24,6 +29,32 @@ func ValidateConfig(config *Config) error {
 		return fmt.Errorf("路由配置验证失败: %w", err)
 	}
 
+	if err := validateRoutePluginRefs(config); err != nil {
+		return fmt.Errorf("路由插件验证失败: %w", err)
+	}
+
+	return nil
+}
+
+// validateRoutePluginRefs 验证路由引用的插件均已在 plugins.available 中定义并启用
+func validateRoutePluginRefs(config *Config) error {
+	enabled := make(map[string]bool, len(config.Plugins.Available))
+	for _, plugin := range config.Plugins.Available {
+		enabled[plugin.Name] = enabled[plugin.Name] || plugin.Enabled
+	}
+
+	for _, route := range config.Routes {
+		for _, name := range route.Plugins {
+			isEnabled, defined := enabled[name]
+			if !defined {
+				return fmt.Errorf("路由 %s 引用的插件 %s 未在 plugins.available 中定义", route.Name, name)
+			}
+			if !isEnabled {
+				return fmt.Errorf("路由 %s 引用的插件 %s 未启用", route.Name, name)
+			}
+		}
+	}
+
 	return nil
 }
 
@@ -49,6 +80,136 @@ func validateServerConfig(config *ServerConfig) error {
 		return fmt.Errorf("无效的优雅关闭超时时间: %v", config.GracefulShutdownTimeout)
 	}
 
+	if config.ReadHeaderTimeout < 0 {
+		return fmt.Errorf("无效的请求头读取超时时间: %v", config.ReadHeaderTimeout)
+	}
+
+	if config.IdleTimeout < 0 {
+		return fmt.Errorf("无效的空闲连接超时时间: %v", config.IdleTimeout)
+	}
+
+	if err := validateTransportConfig(&config.Transport); err != nil {
+		return fmt.Errorf("连接池配置验证失败: %w", err)
+	}
+
+	if strings.ContainsAny(config.ErrorSourceHeader, " \t\r\n:") {
+		return fmt.Errorf("无效的诊断头名称: %q", config.ErrorSourceHeader)
+	}
+
+	for category, response := range config.UpstreamErrors {
+		if !slices.Contains(UpstreamErrorCategories, category) {
+			return fmt.Errorf("upstream_errors 中未知的错误类别: %s（可选 %s）", category, strings.Join(UpstreamErrorCategories, "/"))
+		}
+		if response.Status != 0 && (response.Status < 400 || response.Status > 599) {
+			return fmt.Errorf("upstream_errors.%s 的状态码必须在 400-599 之间: %d", category, response.Status)
+		}
+		if response.RetryAfter < 0 {
+			return fmt.Errorf("upstream_errors.%s 的 retry_after 不能为负数: %d", category, response.RetryAfter)
+		}
+	}
+
+	if capture := config.Capture; capture.Enabled {
+		if capture.Capacity < 0 {
+			return fmt.Errorf("无效的请求捕获容量: %d", capture.Capacity)
+		}
+		if capture.SampleRate < 0 || capture.SampleRate > 1 {
+			return fmt.Errorf("无效的请求捕获采样率: %v", capture.SampleRate)
+		}
+		if capture.MaxBodySize < 0 {
+			return fmt.Errorf("无效的请求捕获消息体大小: %d", capture.MaxBodySize)
+		}
+	}
+
+	for _, proxy := range config.TrustedProxies {
+		if _, err := ParseCIDR(proxy); err != nil {
+			return fmt.Errorf("trusted_proxies 配置错误: %w", err)
+		}
+	}
+
+	if err := validateConcurrencyConfig(&config.Concurrency); err != nil {
+		return fmt.Errorf("并发限制配置验证失败: %w", err)
+	}
+	// 分类依据的请求头只采信可信代理发送的值
+	if len(config.Concurrency.Classes) > 0 && len(config.TrustedProxies) == 0 {
+		return fmt.Errorf("并发限制配置验证失败: 配置优先级分类时需要配置 trusted_proxies")
+	}
+
+	if source := config.ConfigSource; source != nil {
+		if source.Provider != "etcd" && source.Provider != "consul" {
+			return fmt.Errorf("不支持的远程配置类型: %q（可选 etcd/consul）", source.Provider)
+		}
+		if source.Endpoint == "" || source.Key == "" {
+			return fmt.Errorf("远程配置来源需要配置 endpoint 和 key")
+		}
+		if source.PollInterval < 0 {
+			return fmt.Errorf("无效的远程配置轮询间隔: %v", source.PollInterval)
+		}
+	}
+
+	if config.DeadLetter.MaxBodySize < 0 {
+		return fmt.Errorf("无效的死信请求体大小: %d", config.DeadLetter.MaxBodySize)
+	}
+
+	if config.TLS != nil && config.TLS.Enabled {
+		if err := validateTLSConfig(config.TLS); err != nil {
+			return fmt.Errorf("TLS配置验证失败: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// validateTLSConfig 验证TLS配置
+func validateTLSConfig(config *TLSConfig) error {
+	if config.Port < 0 || config.Port > 65535 {
+		return fmt.Errorf("无效的HTTPS端口号: %d", config.Port)
+	}
+
+	if config.CertFile == "" {
+		return fmt.Errorf("证书文件路径不能为空")
+	}
+
+	if config.KeyFile == "" {
+		return fmt.Errorf("私钥文件路径不能为空")
+	}
+
+	validVersions := map[string]bool{
+		"":    true,
+		"1.0": true,
+		"1.1": true,
+		"1.2": true,
+		"1.3": true,
+	}
+
+	if !validVersions[config.MinVersion] {
+		return fmt.Errorf("无效的最低TLS版本: %s", config.MinVersion)
+	}
+
+	if h3 := config.HTTP3; h3 != nil && (h3.Port < 0 || h3.Port > 65535) {
+		return fmt.Errorf("无效的HTTP/3端口号: %d", h3.Port)
+	}
+
+	return nil
+}
+
+// validateTransportConfig 验证上游连接池配置
+func validateTransportConfig(config *TransportConfig) error {
+	if config.MaxIdleConns < 0 {
+		return fmt.Errorf("无效的最大空闲连接数: %d", config.MaxIdleConns)
+	}
+
+	if config.MaxIdleConnsPerHost < 0 {
+		return fmt.Errorf("无效的单主机最大空闲连接数: %d", config.MaxIdleConnsPerHost)
+	}
+
+	if config.MaxConnsPerHost < 0 {
+		return fmt.Errorf("无效的单主机最大连接数: %d", config.MaxConnsPerHost)
+	}
+
+	if config.IdleConnTimeout < 0 || config.DialTimeout < 0 || config.KeepAlive < 0 {
+		return fmt.Errorf("连接池超时时间不能为负数")
+	}
+
 	return nil
 }
 
@@ -98,6 +259,59 @@ func validateLogConfig(config *LogConfig) error {
 		return fmt.Errorf("无效的备份文件数量: %d", config.MaxBackups)
 	}
 
+	if access := config.Access; access != nil {
+		if access.Output == "" {
+			return fmt.Errorf("访问日志输出不能为空")
+		}
+		// 两个轮转写入器不能共用同一个文件
+		if access.Output != "stdout" && filepath.Clean(access.Output) == filepath.Clean(config.Output) {
+			return fmt.Errorf("访问日志不能与主日志使用同一个文件: %s", access.Output)
+		}
+		if access.MaxSize < 0 || access.MaxAge < 0 || access.MaxBackups < 0 {
+			return fmt.Errorf("访问日志的轮转参数不能为负数")
+		}
+	}
+
+	if sampling := config.Sampling; sampling != nil {
+		if sampling.Rate < 0 || sampling.Rate > 1 {
+			return fmt.Errorf("sampling.rate 必须在 [0, 1] 范围内: %v", sampling.Rate)
+		}
+		if sampling.AlwaysStatus != 0 && (sampling.AlwaysStatus < 100 || sampling.AlwaysStatus > 599) {
+			return fmt.Errorf("无效的 sampling.always_status: %d", sampling.AlwaysStatus)
+		}
+	}
+
+	if config.SlowRequestThreshold < 0 {
+		return fmt.Errorf("slow_request_threshold 不能为负数: %v", config.SlowRequestThreshold)
+	}
+
+	return nil
+}
+
+// validateConcurrencyConfig 验证并发限制配置
+func validateConcurrencyConfig(config *ConcurrencyConfig) error {
+	if config.MaxRequests < 0 {
+		return fmt.Errorf("无效的最大并发请求数: %d", config.MaxRequests)
+	}
+	if config.DefaultMaxRatio < 0 || config.DefaultMaxRatio > 1 {
+		return fmt.Errorf("default_max_ratio 必须在 (0, 1] 范围内: %v", config.DefaultMaxRatio)
+	}
+	names := make(map[string]bool, len(config.Classes))
+	for i, class := range config.Classes {
+		if class.Name == "" {
+			return fmt.Errorf("classes[%d] 名称不能为空", i)
+		}
+		if names[class.Name] {
+			return fmt.Errorf("优先级分类 %s 重复", class.Name)
+		}
+		names[class.Name] = true
+		if len(class.Consumers) == 0 && len(class.Headers) == 0 {
+			return fmt.Errorf("优先级分类 %s 未配置匹配条件", class.Name)
+		}
+		if class.MaxRatio < 0 || class.MaxRatio > 1 {
+			return fmt.Errorf("优先级分类 %s 的 max_ratio 必须在 (0, 1] 范围内: %v", class.Name, class.MaxRatio)
+		}
+	}
 	return nil
 }
 
@@ -110,6 +324,18 @@ func validatePluginsConfig(config *PluginsConfig) error {
 		}
 	}
 
+	// 验证外部插件路径
+	external := make(map[string]bool, len(config.External))
+	for i, path := range config.External {
+		if path == "" {
+			return fmt.Errorf("外部插件[%d]路径不能为空", i)
+		}
+		if external[path] {
+			return fmt.Errorf("外部插件路径重复: %s", path)
+		}
+		external[path] = true
+	}
+
 	// 验证路由插件
 	for routeName, plugins := range config.Routes {
 		for i, plugin := range plugins {
@@ -132,6 +358,21 @@ func validatePluginConfig(config *PluginConfig) error {
 		return fmt.Errorf("无效的插件执行顺序: %d", config.Order)
 	}
 
+	if config.CacheTTL != nil && *config.CacheTTL < 0 {
+		return fmt.Errorf("无效的插件结果缓存时间: %v", *config.CacheTTL)
+	}
+
+	for _, path := range config.SkipPaths {
+		if !strings.HasPrefix(path, "/") {
+			return fmt.Errorf("无效的跳过路径: %s", path)
+		}
+	}
+	for _, method := range config.SkipMethods {
+		if method == "" || strings.ContainsAny(method, " \t\r\n") {
+			return fmt.Errorf("无效的跳过方法: %q", method)
+		}
+	}
+
 	return nil
 }
 
@@ -141,15 +382,58 @@ func validateRoutesConfig(routes []RouteConfig) error {
 		return fmt.Errorf("至少需要配置一个路由")
 	}
 
+	// 路由名称用作插件链和路由表的键，必须唯一
+	names := make(map[string]int, len(routes))
 	for i, route := range routes {
 		if err := validateRouteConfig(&route); err != nil {
 			return fmt.Errorf("路由[%d]配置验证失败: %w", i, err)
 		}
+		if j, exists := names[route.Name]; exists {
+			return fmt.Errorf("路由[%d]名称 %s 与路由[%d]重复", i, route.Name, j)
+		}
+		names[route.Name] = i
+	}
+
+	// 匹配条件和优先级完全相同的路由无法区分，实际生效的路由取决于配置顺序
+	for i := range routes {
+		for j := 0; j < i; j++ {
+			if routes[i].Match.Equivalent(routes[j].Match) {
+				return fmt.Errorf("路由[%d] %s 与路由[%d] %s 的匹配条件和优先级相同，无法区分", i, routes[i].Name, j, routes[j].Name)
+			}
+		}
 	}
 
 	return nil
 }
 
+// internalTargetPattern 内部处理目标的格式，如 internal://default
+var internalTargetPattern = regexp.MustCompile(`^internal://[A-Za-z0-9_.-]+$`)
+
+// validateInternalTarget 验证 internal:// 目标及其响应配置
+// 未配置 response 时返回 200 和默认内容，未配置 status 时使用 200
+func validateInternalTarget(config *RouteConfig) error {
+	if !internalTargetPattern.MatchString(config.Target.URL) {
+		return fmt.Errorf("无效的内部目标: %s，格式应为 internal://<名称>", config.Target.URL)
+	}
+
+	response := config.Response
+	if response == nil {
+		return nil
+	}
+	if response.Status != 0 && (response.Status < 100 || response.Status > 599) {
+		return fmt.Errorf("无效的内部响应状态码: %d", response.Status)
+	}
+	if strings.ContainsAny(response.ContentType, "\r\n") {
+		return fmt.Errorf("无效的内部响应内容类型: %q", response.ContentType)
+	}
+	return nil
+}
+
+// ValidateRouteConfig 验证单个路由配置（动态增改路由时使用）
+func ValidateRouteConfig(config *RouteConfig) error {
+	return validateRouteConfig(config)
+}
+
 // validateRouteConfig 验证单个路由配置
 func validateRouteConfig(config *RouteConfig) error {
 	if config.Name == "" {
@@ -164,17 +448,229 @@ func validateRouteConfig(config *RouteConfig) error {
 		return fmt.Errorf("路由路径不能为空")
 	}
 
+	// regex 类型的 path 为正则表达式，加载时编译，避免无效的正则表达式使路由永远无法匹配
+	if err := config.Match.Compile(); err != nil {
+		return fmt.Errorf("路由 %s 的正则表达式无效: %v", config.Name, err)
+	}
+
+	for _, cidr := range config.Match.SourceCIDR {
+		if _, err := ParseCIDR(cidr); err != nil {
+			return fmt.Errorf("source_cidr 配置错误: %w", err)
+		}
+	}
+
+	switch config.PluginOrder {
+	case "", "order", "list":
+	default:
+		return fmt.Errorf("路由 %s 的 plugin_order 无效: %s（可选值: order, list）", config.Name, config.PluginOrder)
+	}
+
+	for name := range config.PluginConfig {
+		used := false
+		for _, pluginName := range config.Plugins {
+			used = used || pluginName == name
+		}
+		if !used {
+			return fmt.Errorf("plugin_config 中的插件 %s 未在 plugins 中启用", name)
+		}
+	}
+
 	if config.Target.URL == "" {
 		return fmt.Errorf("路由目标URL不能为空")
 	}
 
 	// 检查是否为内部URL
-	if !strings.HasPrefix(config.Target.URL, "internal://") {
-		// 对于非内部URL，验证URL格式
-		if _, err := url.Parse(config.Target.URL); err != nil {
-			return fmt.Errorf("无效的目标URL: %s", config.Target.URL)
+	if strings.HasPrefix(config.Target.URL, "internal://") {
+		if err := validateInternalTarget(config); err != nil {
+			return err
+		}
+	} else {
+		// 对于非内部URL，验证每个后端的URL格式
+		for _, backend := range config.Target.Backends() {
+			if _, err := url.Parse(backend); err != nil {
+				return fmt.Errorf("无效的目标URL: %s", backend)
+			}
+		}
+	}
+
+	if config.Target.Timeout < 0 {
+		return fmt.Errorf("无效的超时时间: %d", config.Target.Timeout)
+	}
+
+	if config.Target.Retries < 0 {
+		return fmt.Errorf("无效的重试次数: %d", config.Target.Retries)
+	}
+
+	for i, policy := range config.Target.MethodPolicies {
+		if len(policy.Methods) == 0 {
+			return fmt.Errorf("method_policies[%d] 未配置请求方法", i)
+		}
+		if policy.Timeout < 0 {
+			return fmt.Errorf("method_policies[%d] 无效的超时时间: %d", i, policy.Timeout)
+		}
+		if policy.Retries != nil && *policy.Retries < 0 {
+			return fmt.Errorf("method_policies[%d] 无效的重试次数: %d", i, *policy.Retries)
+		}
+	}
+
+	if lb := config.Target.LoadBalancer; lb != nil {
+		switch lb.Strategy {
+		case "", "round_robin", "least_latency", "consistent_hash":
+		default:
+			return fmt.Errorf("无效的负载均衡策略: %s", lb.Strategy)
+		}
+		switch {
+		case lb.HashKey == "", lb.HashKey == "client_ip", lb.HashKey == "path":
+		case strings.HasPrefix(lb.HashKey, "header:") && strings.TrimPrefix(lb.HashKey, "header:") != "":
+		default:
+			return fmt.Errorf("无效的一致性哈希键: %s", lb.HashKey)
+		}
+		if lb.VirtualNodes < 0 {
+			return fmt.Errorf("无效的虚拟节点数: %d", lb.VirtualNodes)
+		}
+	}
+
+	if tls := config.Target.TLS; tls != nil && (tls.CertFile == "") != (tls.KeyFile == "") {
+		return fmt.Errorf("上游TLS客户端证书和私钥必须同时配置")
+	}
+
+	if hc := config.Target.HeaderCase; hc != nil {
+		switch hc.Mode {
+		case "", "canonical", "lower":
+		default:
+			return fmt.Errorf("无效的请求头大小写模式: %s", hc.Mode)
+		}
+		for name, exact := range hc.Mappings {
+			if !strings.EqualFold(name, exact) {
+				return fmt.Errorf("请求头大小写映射 %s: %s 只能改变大小写", name, exact)
+			}
+		}
+	}
+
+	for name, value := range config.Target.Headers {
+		if name == "" || strings.ContainsAny(name, " \t\r\n:") {
+			return fmt.Errorf("无效的请求头名称: %q", name)
+		}
+		if reservedHeader(name) {
+			return fmt.Errorf("target.headers 不能设置请求头 %s", http.CanonicalHeaderKey(name))
+		}
+		if strings.ContainsAny(value, "\r\n\x00") {
+			return fmt.Errorf("请求头 %s 的值包含非法字符", http.CanonicalHeaderKey(name))
+		}
+	}
+
+	if filter := config.Target.ResponseHeaders; filter != nil {
+		for _, name := range append(append([]string(nil), filter.Allow...), filter.Deny...) {
+			if name == "" || strings.ContainsAny(name, " \t\r\n:") {
+				return fmt.Errorf("无效的响应头名称: %q", name)
+			}
+		}
+	}
+
+	if budget := config.ErrorBudget; budget != nil {
+		if budget.Budget <= 0 || budget.Budget >= 1 {
+			return fmt.Errorf("error_budget.budget 必须在 (0, 1) 范围内: %v", budget.Budget)
+		}
+		if budget.Window < 0 {
+			return fmt.Errorf("无效的 error_budget.window: %v", budget.Window)
+		}
+		if budget.MinRequests < 0 {
+			return fmt.Errorf("无效的 error_budget.min_requests: %d", budget.MinRequests)
+		}
+		if budget.MaxShedRatio < 0 || budget.MaxShedRatio > 1 {
+			return fmt.Errorf("error_budget.max_shed_ratio 必须在 [0, 1] 范围内: %v", budget.MaxShedRatio)
+		}
+		switch budget.Status {
+		case 0, http.StatusTooManyRequests, http.StatusServiceUnavailable:
+		default:
+			return fmt.Errorf("error_budget.status 只能为 429 或 503: %d", budget.Status)
+		}
+	}
+
+	if sticky := config.Target.Sticky; sticky != nil {
+		if sticky.TTL < 0 {
+			return fmt.Errorf("无效的会话保持有效期: %v", sticky.TTL)
+		}
+		if sticky.Cookie != "" && strings.ContainsAny(sticky.Cookie, " \t\r\n;,=") {
+			return fmt.Errorf("无效的会话保持 cookie 名称: %q", sticky.Cookie)
+		}
+	}
+
+	if len(config.Target.TrafficSplit) > 0 {
+		if strings.HasPrefix(config.Target.URL, "internal://") {
+			return fmt.Errorf("内部目标不支持 traffic_split")
+		}
+		total := 0
+		for i, split := range config.Target.TrafficSplit {
+			if split.Target == "" || strings.HasPrefix(split.Target, "internal://") {
+				return fmt.Errorf("traffic_split[%d] 无效的目标: %q", i, split.Target)
+			}
+			for _, backend := range (TargetConfig{URL: split.Target}).Backends() {
+				if _, err := url.Parse(backend); err != nil {
+					return fmt.Errorf("traffic_split[%d] 无效的目标URL: %s", i, backend)
+				}
+			}
+			if split.Weight < 0 {
+				return fmt.Errorf("traffic_split[%d] 无效的权重: %d", i, split.Weight)
+			}
+			total += split.Weight
+		}
+		if total != 100 {
+			return fmt.Errorf("traffic_split 的权重之和必须为 100: %d", total)
+		}
+	}
+
+	if mirror := config.Target.Mirror; mirror != nil {
+		if strings.HasPrefix(config.Target.URL, "internal://") {
+			return fmt.Errorf("内部目标不支持 mirror")
+		}
+		if u, err := url.Parse(mirror.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("无效的镜像服务地址: %q", mirror.URL)
+		}
+		if mirror.Percentage < 0 || mirror.Percentage > 100 {
+			return fmt.Errorf("mirror.percentage 必须在 [0, 100] 范围内: %v", mirror.Percentage)
+		}
+		if mirror.Timeout < 0 {
+			return fmt.Errorf("无效的 mirror.timeout: %d", mirror.Timeout)
 		}
 	}
 
+	if streaming := config.Target.Streaming; streaming != nil {
+		for i, contentType := range streaming.ContentTypes {
+			if _, _, err := mime.ParseMediaType(contentType); err != nil {
+				return fmt.Errorf("streaming.content_types[%d] 无效的响应类型: %q", i, contentType)
+			}
+		}
+	}
+
+	switch config.Target.PathEncoding {
+	case "", "raw", "decoded":
+	default:
+		return fmt.Errorf("无效的路径编码方式: %s", config.Target.PathEncoding)
+	}
+
 	return nil
 }
+
+// reservedHeaders 逐跳请求头和由 HTTP 客户端写出的请求头，不能通过 target.headers 设置
+var reservedHeaders = []string{
+	"Connection",
+	"Keep-Alive",
+	"Proxy-Connection",
+	"Te",
+	"Trailer",
+	"Transfer-Encoding",
+	"Upgrade",
+	"Host",
+	"Content-Length",
+}
+
+// reservedHeader 判断请求头是否不能通过 target.headers 设置
+func reservedHeader(name string) bool {
+	for _, reserved := range reservedHeaders {
+		if strings.EqualFold(name, reserved) {
+			return true
+		}
+	}
+	return false
+}
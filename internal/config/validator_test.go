@@ -0,0 +1,345 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+// validConfig 返回可通过验证的最小配置
+func validConfig() *Config {
+	return &Config{
+		Server: ServerConfig{
+			Port:                    8080,
+			ReadTimeout:             time.Second,
+			WriteTimeout:            time.Second,
+			MaxHeaderBytes:          1 << 20,
+			GracefulShutdownTimeout: time.Second,
+		},
+		Log: LogConfig{
+			Level:      "info",
+			Format:     "json",
+			Output:     "stdout",
+			MaxSize:    1,
+			MaxAge:     1,
+			MaxBackups: 1,
+		},
+		Routes: []RouteConfig{
+			{
+				Name:   "default",
+				Match:  RouteMatch{Type: "prefix", Path: "/"},
+				Target: TargetConfig{URL: "http://127.0.0.1:8081"},
+			},
+		},
+	}
+}
+
+// expectInvalid 断言配置验证失败，且错误信息包含 want
+func expectInvalid(t *testing.T, cfg *Config, want string) {
+	t.Helper()
+	err := ValidateConfig(cfg)
+	if err == nil {
+		t.Fatalf("配置验证应失败（期望错误包含 %q）", want)
+	}
+	if !strings.Contains(err.Error(), want) {
+		t.Fatalf("错误信息 %q 不包含 %q", err.Error(), want)
+	}
+}
+
+func TestValidateConfigAcceptsMinimalConfig(t *testing.T) {
+	if err := ValidateConfig(validConfig()); err != nil {
+		t.Fatalf("最小配置验证失败: %v", err)
+	}
+}
+
+func TestValidateRoutesRejectsDuplicateNames(t *testing.T) {
+	cfg := validConfig()
+	cfg.Routes = append(cfg.Routes, RouteConfig{
+		Name:   "default",
+		Match:  RouteMatch{Type: "prefix", Path: "/api"},
+		Target: TargetConfig{URL: "http://127.0.0.1:8082"},
+	})
+	expectInvalid(t, cfg, "名称 default 与路由[0]重复")
+}
+
+func TestValidateRoutesAcceptsDistinctNames(t *testing.T) {
+	cfg := validConfig()
+	cfg.Routes = append(cfg.Routes, RouteConfig{
+		Name:   "api",
+		Match:  RouteMatch{Type: "prefix", Path: "/api"},
+		Target: TargetConfig{URL: "http://127.0.0.1:8082"},
+	})
+	if err := ValidateConfig(cfg); err != nil {
+		t.Fatalf("名称不同的路由验证失败: %v", err)
+	}
+}
+
+func TestValidateRoutePluginRefs(t *testing.T) {
+	tests := []struct {
+		name      string
+		available []PluginConfig
+		want      string
+	}{
+		{"未定义的插件", nil, "路由 default 引用的插件 auth 未在 plugins.available 中定义"},
+		{"未启用的插件", []PluginConfig{{Name: "auth", Enabled: false}}, "路由 default 引用的插件 auth 未启用"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := validConfig()
+			cfg.Plugins.Available = tt.available
+			cfg.Routes[0].Plugins = []string{"auth"}
+			expectInvalid(t, cfg, tt.want)
+		})
+	}
+
+	cfg := validConfig()
+	cfg.Plugins.Available = []PluginConfig{{Name: "auth", Enabled: true}}
+	cfg.Routes[0].Plugins = []string{"auth"}
+	if err := ValidateConfig(cfg); err != nil {
+		t.Fatalf("引用已启用插件的配置验证失败: %v", err)
+	}
+}
+
+func TestValidateRoutesRejectsIndistinguishableMatches(t *testing.T) {
+	orders := RouteMatch{Type: "exact", Path: "/orders", Headers: map[string]string{"X-Version": "2"}, SourceCIDR: []string{"10.0.0.0/8", "192.168.0.0/16"}}
+	tests := []struct {
+		name      string
+		other     RouteMatch
+		ambiguous bool
+	}{
+		{"相同的精确匹配", orders, true},
+		{"未指定类型按精确匹配", RouteMatch{Path: "/orders", Headers: map[string]string{"X-Version": "2"}, SourceCIDR: []string{"192.168.0.0/16", "10.0.0.0/8"}}, true},
+		{"优先级不同", RouteMatch{Type: "exact", Path: "/orders", Priority: 1, Headers: map[string]string{"X-Version": "2"}, SourceCIDR: orders.SourceCIDR}, false},
+		{"匹配类型不同", RouteMatch{Type: "prefix", Path: "/orders", Headers: map[string]string{"X-Version": "2"}, SourceCIDR: orders.SourceCIDR}, false},
+		{"请求方法不同", RouteMatch{Type: "exact", Path: "/orders", Method: "POST", Headers: map[string]string{"X-Version": "2"}, SourceCIDR: orders.SourceCIDR}, false},
+		{"请求头不同", RouteMatch{Type: "exact", Path: "/orders", Headers: map[string]string{"X-Version": "3"}, SourceCIDR: orders.SourceCIDR}, false},
+		{"来源网段不同", RouteMatch{Type: "exact", Path: "/orders", Headers: map[string]string{"X-Version": "2"}}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := validConfig()
+			cfg.Routes = append(cfg.Routes,
+				RouteConfig{Name: "orders-v1", Match: orders, Target: TargetConfig{URL: "http://127.0.0.1:8082"}},
+				RouteConfig{Name: "orders-v2", Match: tt.other, Target: TargetConfig{URL: "http://127.0.0.1:8083"}},
+			)
+			if !tt.ambiguous {
+				if err := ValidateConfig(cfg); err != nil {
+					t.Fatalf("可区分的路由验证失败: %v", err)
+				}
+				return
+			}
+			expectInvalid(t, cfg, "路由[2] orders-v2 与路由[1] orders-v1 的匹配条件和优先级相同，无法区分")
+		})
+	}
+}
+
+func TestTestConfigRejectsDuplicateExactRoutes(t *testing.T) {
+	path := writeConfigFile(t, minimalYAML+`
+routes:
+  - name: health
+    match:
+      type: exact
+      path: /health
+    target:
+      url: http://127.0.0.1:8081
+  - name: health-v2
+    match:
+      type: exact
+      path: /health
+    target:
+      url: http://127.0.0.1:8082
+`)
+	err := NewConfigManager(path).TestConfig("")
+	if err == nil || !strings.Contains(err.Error(), "路由[1] health-v2 与路由[0] health 的匹配条件和优先级相同") {
+		t.Fatalf("TestConfig 应拒绝路径相同的两条精确匹配路由，实际: %v", err)
+	}
+}
+
+func TestTestConfigRejectsInvalidRegex(t *testing.T) {
+	routes := `
+routes:
+  - name: users
+    match:
+      type: regex
+      path: %s
+    target:
+      url: http://127.0.0.1:8081
+`
+	path := writeConfigFile(t, minimalYAML+fmt.Sprintf(routes, `'^/users/(\d+$'`))
+	err := NewConfigManager(path).TestConfig("")
+	if err == nil || !strings.Contains(err.Error(), "路由 users 的正则表达式无效") {
+		t.Fatalf("TestConfig 应拒绝正则表达式无效的路由，实际: %v", err)
+	}
+
+	path = writeConfigFile(t, minimalYAML+fmt.Sprintf(routes, `'^/users/(\d+)$'`))
+	if err := NewConfigManager(path).TestConfig(""); err != nil {
+		t.Fatalf("TestConfig 应接受有效的正则表达式，实际: %v", err)
+	}
+}
+
+func TestValidateInternalTarget(t *testing.T) {
+	tests := []struct {
+		name     string
+		url      string
+		response *ResponseConfig
+		want     string
+	}{
+		{name: "未配置响应", url: "internal://health"},
+		{name: "配置响应", url: "internal://maintenance", response: &ResponseConfig{Status: 503, Content: "维护中", ContentType: "text/plain; charset=utf-8"}},
+		{name: "未配置状态码", url: "internal://default", response: &ResponseConfig{Content: "ok"}},
+		{name: "缺少名称", url: "internal://", want: "无效的内部目标: internal://"},
+		{name: "包含路径", url: "internal://health/check", want: "格式应为 internal://<名称>"},
+		{name: "状态码无效", url: "internal://health", response: &ResponseConfig{Status: 999}, want: "无效的内部响应状态码: 999"},
+		{name: "内容类型包含换行", url: "internal://health", response: &ResponseConfig{ContentType: "text/plain\r\nX-Injected: 1"}, want: "无效的内部响应内容类型"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := validConfig()
+			cfg.Routes[0].Target.URL = tt.url
+			cfg.Routes[0].Response = tt.response
+			if tt.want == "" {
+				if err := ValidateConfig(cfg); err != nil {
+					t.Fatalf("内部路由验证失败: %v", err)
+				}
+				return
+			}
+			expectInvalid(t, cfg, tt.want)
+		})
+	}
+}
+
+func TestValidateTrafficSplit(t *testing.T) {
+	tests := []struct {
+		name   string
+		splits []TrafficSplitConfig
+		want   string
+	}{
+		{"权重之和为 100", []TrafficSplitConfig{{Target: "http://v1:8080", Weight: 90}, {Target: "http://v2:8080", Weight: 8}, {Target: "http://v3:8080", Weight: 2}}, ""},
+		{"权重之和不足 100", []TrafficSplitConfig{{Target: "http://v1:8080", Weight: 90}, {Target: "http://v2:8080", Weight: 5}}, "traffic_split 的权重之和必须为 100: 95"},
+		{"权重之和超过 100", []TrafficSplitConfig{{Target: "http://v1:8080", Weight: 90}, {Target: "http://v2:8080", Weight: 20}}, "traffic_split 的权重之和必须为 100: 110"},
+		{"负权重", []TrafficSplitConfig{{Target: "http://v1:8080", Weight: 110}, {Target: "http://v2:8080", Weight: -10}}, "traffic_split[1] 无效的权重: -10"},
+		{"缺少目标", []TrafficSplitConfig{{Weight: 100}}, `traffic_split[0] 无效的目标: ""`},
+		{"内部目标", []TrafficSplitConfig{{Target: "internal://health", Weight: 100}}, "traffic_split[0] 无效的目标"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := validConfig()
+			cfg.Routes[0].Target.TrafficSplit = tt.splits
+			if tt.want == "" {
+				if err := ValidateConfig(cfg); err != nil {
+					t.Fatalf("流量分配验证失败: %v", err)
+				}
+				return
+			}
+			expectInvalid(t, cfg, tt.want)
+		})
+	}
+}
+
+func TestValidateTargetHeaders(t *testing.T) {
+	tests := []struct {
+		name    string
+		headers map[string]string
+		want    string
+	}{
+		{"普通请求头", map[string]string{"X-Internal": "true", "Authorization": "Bearer static"}, ""},
+		{"空值", map[string]string{"X-Empty": ""}, ""},
+		{"逐跳请求头", map[string]string{"connection": "close"}, "target.headers 不能设置请求头 Connection"},
+		{"传输编码", map[string]string{"Transfer-Encoding": "chunked"}, "target.headers 不能设置请求头 Transfer-Encoding"},
+		{"Host", map[string]string{"host": "example.com"}, "target.headers 不能设置请求头 Host"},
+		{"名称包含冒号", map[string]string{"X-A:B": "1"}, `无效的请求头名称: "X-A:B"`},
+		{"名称为空", map[string]string{"": "1"}, `无效的请求头名称: ""`},
+		{"值包含换行", map[string]string{"X-Token": "a\r\nX-Injected: 1"}, "请求头 X-Token 的值包含非法字符"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := validConfig()
+			cfg.Routes[0].Target.Headers = tt.headers
+			if tt.want == "" {
+				if err := ValidateConfig(cfg); err != nil {
+					t.Fatalf("请求头注入验证失败: %v", err)
+				}
+				return
+			}
+			expectInvalid(t, cfg, tt.want)
+		})
+	}
+}
+
+func TestValidateUpstreamErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		errors map[string]UpstreamErrorConfig
+		want   string
+	}{
+		{"所有类别", map[string]UpstreamErrorConfig{
+			"dns": {Status: 503}, "connect": {Status: 503, RetryAfter: 30}, "timeout": {Body: `{"retry":true}`},
+			"reset": {Status: 502, Body: "reset", ContentType: "text/plain"}, "other": {},
+		}, ""},
+		{"未知类别", map[string]UpstreamErrorConfig{"tls": {Status: 503}}, "upstream_errors 中未知的错误类别: tls"},
+		{"状态码不是错误", map[string]UpstreamErrorConfig{"connect": {Status: 200}}, "upstream_errors.connect 的状态码必须在 400-599 之间"},
+		{"状态码超出范围", map[string]UpstreamErrorConfig{"timeout": {Status: 600}}, "upstream_errors.timeout 的状态码必须在 400-599 之间"},
+		{"retry_after 为负数", map[string]UpstreamErrorConfig{"reset": {RetryAfter: -1}}, "upstream_errors.reset 的 retry_after 不能为负数"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := validConfig()
+			cfg.Server.UpstreamErrors = tt.errors
+			if tt.want == "" {
+				if err := ValidateConfig(cfg); err != nil {
+					t.Fatalf("转发失败响应验证失败: %v", err)
+				}
+				return
+			}
+			expectInvalid(t, cfg, tt.want)
+		})
+	}
+}
+
+func TestValidateConfigSource(t *testing.T) {
+	tests := []struct {
+		name   string
+		source *RemoteBackendConfig
+		want   string
+	}{
+		{"etcd", &RemoteBackendConfig{Provider: "etcd", Endpoint: "http://127.0.0.1:2379", Key: "gateway/config.yaml"}, ""},
+		{"consul", &RemoteBackendConfig{Provider: "consul", Endpoint: "http://127.0.0.1:8500", Key: "gateway/config.yaml", PollInterval: time.Minute}, ""},
+		{"未知类型", &RemoteBackendConfig{Provider: "zookeeper", Endpoint: "http://127.0.0.1:2181", Key: "k"}, "不支持的远程配置类型"},
+		{"缺少键", &RemoteBackendConfig{Provider: "etcd", Endpoint: "http://127.0.0.1:2379"}, "远程配置来源需要配置 endpoint 和 key"},
+		{"轮询间隔为负数", &RemoteBackendConfig{Provider: "etcd", Endpoint: "http://127.0.0.1:2379", Key: "k", PollInterval: -time.Second}, "无效的远程配置轮询间隔"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := validConfig()
+			cfg.Server.ConfigSource = tt.source
+			if tt.want == "" {
+				if err := ValidateConfig(cfg); err != nil {
+					t.Fatalf("远程配置来源验证失败: %v", err)
+				}
+				return
+			}
+			expectInvalid(t, cfg, tt.want)
+		})
+	}
+}
+
+func TestValidateConcurrencyClassesRequireTrustedProxies(t *testing.T) {
+	cfg := validConfig()
+	cfg.Server.Concurrency = ConcurrencyConfig{
+		MaxRequests: 100,
+		Classes:     []QoSClassConfig{{Name: "paid", Consumers: []string{"key-a"}}},
+	}
+	expectInvalid(t, cfg, "配置优先级分类时需要配置 trusted_proxies")
+
+	cfg.Server.TrustedProxies = []string{"10.0.0.0/8"}
+	if err := ValidateConfig(cfg); err != nil {
+		t.Fatalf("配置可信代理后验证失败: %v", err)
+	}
+}
@@ -0,0 +1,208 @@
+package errors
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+// defaultChannelTimeout 通知渠道默认超时时间
+const defaultChannelTimeout = 5 * time.Second
+
+// WebhookChannel Webhook 通知渠道，以 JSON 格式 POST 到指定地址
+type WebhookChannel struct {
+	url     string
+	headers map[string]string
+	client  *http.Client
+}
+
+// webhookPayload Webhook 请求体
+type webhookPayload struct {
+	Level   string      `json:"level"`
+	Message string      `json:"message"`
+	Details interface{} `json:"details,omitempty"`
+	Time    time.Time   `json:"time"`
+}
+
+// NewWebhookChannel 创建 Webhook 通知渠道，timeout 为 0 时使用默认超时
+func NewWebhookChannel(url string, headers map[string]string, timeout time.Duration) *WebhookChannel {
+	if timeout <= 0 {
+		timeout = defaultChannelTimeout
+	}
+	return &WebhookChannel{
+		url:     url,
+		headers: headers,
+		client:  &http.Client{Timeout: timeout},
+	}
+}
+
+// Send 发送通知
+func (c *WebhookChannel) Send(ctx context.Context, level NotificationLevel, message string, details interface{}) error {
+	return postJSON(ctx, c.client, c.url, c.headers, webhookPayload{
+		Level:   level.String(),
+		Message: message,
+		Details: details,
+		Time:    time.Now(),
+	})
+}
+
+// postJSON 以 JSON 格式 POST 请求，非 2xx 响应视为失败
+func postJSON(ctx context.Context, client *http.Client, url string, headers map[string]string, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("序列化通知内容失败: %v", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("创建通知请求失败: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("发送通知失败: %v", err)
+	}
+	defer resp.Body.Close()
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("发送通知失败: %s %s", resp.Status, strings.TrimSpace(string(respBody)))
+	}
+	return nil
+}
+
+// EmailConfig 邮件通知配置
+type EmailConfig struct {
+	// SMTP 服务器地址，如 smtp.example.com:587
+	Addr string
+	// 认证用户名，为空时不认证
+	Username string
+	// 认证密码
+	Password string
+	// 发件人
+	From string
+	// 收件人
+	To []string
+	// 邮件主题前缀
+	SubjectPrefix string
+	// 连接和发送超时时间
+	Timeout time.Duration
+}
+
+// EmailChannel SMTP 邮件通知渠道，服务器支持时使用 STARTTLS
+type EmailChannel struct {
+	config EmailConfig
+}
+
+// NewEmailChannel 创建邮件通知渠道
+func NewEmailChannel(config EmailConfig) *EmailChannel {
+	if config.Timeout <= 0 {
+		config.Timeout = defaultChannelTimeout
+	}
+	if config.SubjectPrefix == "" {
+		config.SubjectPrefix = "[gateway-go]"
+	}
+	return &EmailChannel{config: config}
+}
+
+// Send 发送通知
+func (c *EmailChannel) Send(ctx context.Context, level NotificationLevel, message string, details interface{}) error {
+	deadline := time.Now().Add(c.config.Timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+
+	dialer := &net.Dialer{Deadline: deadline}
+	conn, err := dialer.DialContext(ctx, "tcp", c.config.Addr)
+	if err != nil {
+		return fmt.Errorf("连接SMTP服务器失败: %v", err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(deadline)
+
+	host, _, err := net.SplitHostPort(c.config.Addr)
+	if err != nil {
+		return fmt.Errorf("无效的SMTP服务器地址: %s", c.config.Addr)
+	}
+	client, err := smtp.NewClient(conn, host)
+	if err != nil {
+		return fmt.Errorf("创建SMTP会话失败: %v", err)
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			return fmt.Errorf("SMTP STARTTLS 失败: %v", err)
+		}
+	}
+	if c.config.Username != "" {
+		// PlainAuth 只允许在 TLS 连接或本机服务器上发送密码
+		if err := client.Auth(smtp.PlainAuth("", c.config.Username, c.config.Password, host)); err != nil {
+			return fmt.Errorf("SMTP 认证失败: %v", err)
+		}
+	}
+
+	if err := client.Mail(c.config.From); err != nil {
+		return fmt.Errorf("设置发件人失败: %v", err)
+	}
+	for _, to := range c.config.To {
+		if err := client.Rcpt(to); err != nil {
+			return fmt.Errorf("设置收件人 %s 失败: %v", to, err)
+		}
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("发送邮件失败: %v", err)
+	}
+	if _, err := w.Write(c.buildMessage(level, message, details)); err != nil {
+		return fmt.Errorf("发送邮件失败: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("发送邮件失败: %v", err)
+	}
+	return client.Quit()
+}
+
+// buildMessage 构建邮件内容
+func (c *EmailChannel) buildMessage(level NotificationLevel, message string, details interface{}) []byte {
+	subject := fmt.Sprintf("%s [%s] %s", c.config.SubjectPrefix, level, message)
+
+	var body bytes.Buffer
+	fmt.Fprintf(&body, "级别: %s\r\n时间: %s\r\n消息: %s\r\n", level, time.Now().Format(time.RFC3339), message)
+	if details != nil {
+		if data, err := json.MarshalIndent(details, "", "  "); err == nil {
+			fmt.Fprintf(&body, "详情:\r\n%s\r\n", strings.ReplaceAll(string(data), "\n", "\r\n"))
+		}
+	}
+
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", c.config.From)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(c.config.To, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mimeEncodeHeader(subject))
+	fmt.Fprintf(&msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	msg.WriteString("\r\n")
+	msg.Write(body.Bytes())
+	return msg.Bytes()
+}
+
+// mimeEncodeHeader 对包含非 ASCII 字符的邮件头编码，并去掉换行防止头注入
+func mimeEncodeHeader(value string) string {
+	value = strings.NewReplacer("\r", " ", "\n", " ").Replace(value)
+	return mime.BEncoding.Encode("UTF-8", value)
+}
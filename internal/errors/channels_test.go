@@ -0,0 +1,138 @@
+package errors
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// receivedRequest 通知服务端收到的请求
+type receivedRequest struct {
+	path   string
+	query  string
+	header http.Header
+	body   []byte
+}
+
+// notificationServer 启动记录请求的通知服务端，响应 status 和 reply
+func notificationServer(t *testing.T, status int, reply string) (*httptest.Server, <-chan receivedRequest) {
+	t.Helper()
+	requests := make(chan receivedRequest, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests <- receivedRequest{path: r.URL.Path, query: r.URL.RawQuery, header: r.Header, body: body}
+		w.WriteHeader(status)
+		io.WriteString(w, reply)
+	}))
+	t.Cleanup(server.Close)
+	return server, requests
+}
+
+// receive 读取服务端收到的请求，没有请求时返回 false
+func receive(requests <-chan receivedRequest) (receivedRequest, bool) {
+	select {
+	case r := <-requests:
+		return r, true
+	default:
+		return receivedRequest{}, false
+	}
+}
+
+func TestWebhookChannelPayload(t *testing.T) {
+	server, requests := notificationServer(t, http.StatusOK, "")
+	channel := NewWebhookChannel(server.URL+"/hook", map[string]string{"X-Token": "secret"}, time.Second)
+
+	err := channel.Send(context.Background(), LevelCritical, "[500] 上游故障", map[string]interface{}{"route": "orders"})
+	if err != nil {
+		t.Fatalf("发送通知失败: %v", err)
+	}
+
+	r, ok := receive(requests)
+	if !ok {
+		t.Fatal("Webhook 未收到请求")
+	}
+	if r.path != "/hook" || r.header.Get("Content-Type") != "application/json" || r.header.Get("X-Token") != "secret" {
+		t.Fatalf("Webhook 请求 = %s %v", r.path, r.header)
+	}
+	var payload struct {
+		Level   string                 `json:"level"`
+		Message string                 `json:"message"`
+		Details map[string]interface{} `json:"details"`
+		Time    time.Time              `json:"time"`
+	}
+	if err := json.Unmarshal(r.body, &payload); err != nil {
+		t.Fatalf("解析请求体 %s 失败: %v", r.body, err)
+	}
+	if payload.Level != "critical" || payload.Message != "[500] 上游故障" || payload.Details["route"] != "orders" || payload.Time.IsZero() {
+		t.Fatalf("Webhook 请求体 = %s", r.body)
+	}
+}
+
+func TestWebhookChannelRejectsErrorStatus(t *testing.T) {
+	server, _ := notificationServer(t, http.StatusBadGateway, "down")
+	err := NewWebhookChannel(server.URL, nil, time.Second).Send(context.Background(), LevelError, "msg", nil)
+	if err == nil || !strings.Contains(err.Error(), "down") {
+		t.Fatalf("非 2xx 响应时返回 %v，期望包含响应内容的错误", err)
+	}
+}
+
+func TestNotifierLevelThresholdAndInterval(t *testing.T) {
+	server, requests := notificationServer(t, http.StatusOK, "")
+	notifier := NewErrorNotifier(NotificationConfig{
+		Enabled:        true,
+		LevelThreshold: LevelError,
+		Interval:       100 * time.Millisecond,
+		Channels:       []NotificationChannel{NewWebhookChannel(server.URL, nil, time.Second)},
+	})
+	ctx := context.Background()
+
+	// 404 为 warning 级别，低于阈值不通知
+	notifier.Notify(ctx, New(ErrNotFound, "not found"))
+	if _, ok := receive(requests); ok {
+		t.Fatal("低于级别阈值的错误不应通知")
+	}
+
+	if err := notifier.Notify(ctx, New(ErrInternalServerError, "boom")); err != nil {
+		t.Fatalf("发送通知失败: %v", err)
+	}
+	r, ok := receive(requests)
+	if !ok || !strings.Contains(string(r.body), `"level":"critical"`) {
+		t.Fatalf("500 错误通知 = %s，期望 critical 级别", r.body)
+	}
+
+	// 通知间隔内的错误被合并
+	notifier.Notify(ctx, New(ErrInternalServerError, "boom"))
+	if _, ok := receive(requests); ok {
+		t.Fatal("通知间隔内不应重复通知")
+	}
+	time.Sleep(120 * time.Millisecond)
+	notifier.Notify(ctx, New(ErrInternalServerError, "boom"))
+	if _, ok := receive(requests); !ok {
+		t.Fatal("通知间隔过后应再次通知")
+	}
+}
+
+func TestEmailMessage(t *testing.T) {
+	channel := NewEmailChannel(EmailConfig{From: "gateway@example.com", To: []string{"ops@example.com", "dev@example.com"}})
+	msg := string(channel.buildMessage(LevelError, "上游故障\r\nBcc: evil@example.com", map[string]interface{}{"route": "orders"}))
+
+	header, body, _ := strings.Cut(msg, "\r\n\r\n")
+	if !strings.Contains(header, "From: gateway@example.com\r\n") || !strings.Contains(header, "To: ops@example.com, dev@example.com\r\n") {
+		t.Fatalf("邮件头 = %q", header)
+	}
+	// 主题中的换行被去除，不能注入邮件头
+	if strings.Contains(header, "\r\nBcc:") {
+		t.Fatalf("邮件头被注入: %q", header)
+	}
+	if !strings.Contains(header, "Subject: =?UTF-8?b?") {
+		t.Fatalf("包含中文的主题未编码: %q", header)
+	}
+	if !strings.Contains(body, "级别: error") || !strings.Contains(body, `"route": "orders"`) {
+		t.Fatalf("邮件正文 = %q", body)
+	}
+}
@@ -0,0 +1,169 @@
+package errors
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// levelEmoji 通知级别对应的标识
+var levelEmoji = map[NotificationLevel]string{
+	LevelInfo:     "ℹ️",
+	LevelWarning:  "⚠️",
+	LevelError:    "❌",
+	LevelCritical: "🚨",
+}
+
+// formatDetails 将通知详情格式化为缩进的 JSON，详情为空时返回空字符串
+func formatDetails(details interface{}) string {
+	if details == nil {
+		return ""
+	}
+	data, err := json.MarshalIndent(details, "", "  ")
+	if err != nil {
+		return fmt.Sprintf("%v", details)
+	}
+	return string(data)
+}
+
+// SlackChannel Slack 通知渠道，通过 Incoming Webhook 发送
+type SlackChannel struct {
+	url    string
+	client *http.Client
+}
+
+// slackPayload Slack 消息
+type slackPayload struct {
+	Text string `json:"text"`
+}
+
+// NewSlackChannel 创建 Slack 通知渠道，timeout 为 0 时使用默认超时
+func NewSlackChannel(webhookURL string, timeout time.Duration) *SlackChannel {
+	if timeout <= 0 {
+		timeout = defaultChannelTimeout
+	}
+	return &SlackChannel{
+		url:    webhookURL,
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+// Send 发送通知
+func (c *SlackChannel) Send(ctx context.Context, level NotificationLevel, message string, details interface{}) error {
+	var text strings.Builder
+	fmt.Fprintf(&text, "%s *[%s]* %s", levelEmoji[level], strings.ToUpper(level.String()), message)
+	if d := formatDetails(details); d != "" {
+		fmt.Fprintf(&text, "\n```%s```", d)
+	}
+	return postJSON(ctx, c.client, c.url, nil, slackPayload{Text: text.String()})
+}
+
+// DingTalkChannel 钉钉通知渠道，通过自定义机器人 Webhook 发送，支持加签
+type DingTalkChannel struct {
+	url    string
+	secret string
+	client *http.Client
+}
+
+// dingTalkPayload 钉钉 markdown 消息
+type dingTalkPayload struct {
+	MsgType  string `json:"msgtype"`
+	Markdown struct {
+		Title string `json:"title"`
+		Text  string `json:"text"`
+	} `json:"markdown"`
+}
+
+// dingTalkResponse 钉钉接口响应，errcode 非 0 表示发送失败
+type dingTalkResponse struct {
+	ErrCode int    `json:"errcode"`
+	ErrMsg  string `json:"errmsg"`
+}
+
+// NewDingTalkChannel 创建钉钉通知渠道，secret 为空时不加签，timeout 为 0 时使用默认超时
+func NewDingTalkChannel(webhookURL, secret string, timeout time.Duration) *DingTalkChannel {
+	if timeout <= 0 {
+		timeout = defaultChannelTimeout
+	}
+	return &DingTalkChannel{
+		url:    webhookURL,
+		secret: secret,
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+// Send 发送通知
+func (c *DingTalkChannel) Send(ctx context.Context, level NotificationLevel, message string, details interface{}) error {
+	payload := dingTalkPayload{MsgType: "markdown"}
+	payload.Markdown.Title = fmt.Sprintf("[%s] %s", strings.ToUpper(level.String()), message)
+
+	var text strings.Builder
+	fmt.Fprintf(&text, "### %s %s\n\n", levelEmoji[level], payload.Markdown.Title)
+	fmt.Fprintf(&text, "- 级别: %s\n- 时间: %s\n", level, time.Now().Format(time.RFC3339))
+	if d := formatDetails(details); d != "" {
+		fmt.Fprintf(&text, "\n```\n%s\n```\n", d)
+	}
+	payload.Markdown.Text = text.String()
+
+	requestURL, err := c.signedURL(time.Now())
+	if err != nil {
+		return err
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("序列化通知内容失败: %v", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, requestURL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("创建通知请求失败: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("发送通知失败: %v", err)
+	}
+	defer resp.Body.Close()
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("发送通知失败: %s %s", resp.Status, strings.TrimSpace(string(respBody)))
+	}
+
+	// 钉钉在请求失败（如签名错误、被限流）时仍返回 200
+	var result dingTalkResponse
+	if err := json.Unmarshal(respBody, &result); err == nil && result.ErrCode != 0 {
+		return fmt.Errorf("发送通知失败: 钉钉返回 %d %s", result.ErrCode, result.ErrMsg)
+	}
+	return nil
+}
+
+// signedURL 返回附加签名参数的地址：sign = Base64(HmacSHA256(timestamp + "\n" + secret))
+func (c *DingTalkChannel) signedURL(now time.Time) (string, error) {
+	if c.secret == "" {
+		return c.url, nil
+	}
+	u, err := url.Parse(c.url)
+	if err != nil {
+		return "", fmt.Errorf("无效的钉钉 Webhook 地址: %v", err)
+	}
+
+	timestamp := strconv.FormatInt(now.UnixMilli(), 10)
+	mac := hmac.New(sha256.New, []byte(c.secret))
+	mac.Write([]byte(timestamp + "\n" + c.secret))
+
+	query := u.Query()
+	query.Set("timestamp", timestamp)
+	query.Set("sign", base64.StdEncoding.EncodeToString(mac.Sum(nil)))
+	u.RawQuery = query.Encode()
+	return u.String(), nil
+}
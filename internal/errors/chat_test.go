@@ -0,0 +1,91 @@
+package errors
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSlackChannelPayload(t *testing.T) {
+	server, requests := notificationServer(t, http.StatusOK, "ok")
+	channel := NewSlackChannel(server.URL+"/services/T000", time.Second)
+
+	if err := channel.Send(context.Background(), LevelCritical, "[500] 上游故障", map[string]interface{}{"route": "orders"}); err != nil {
+		t.Fatalf("发送通知失败: %v", err)
+	}
+	r, ok := receive(requests)
+	if !ok {
+		t.Fatal("Slack 未收到请求")
+	}
+
+	var payload map[string]interface{}
+	if err := json.Unmarshal(r.body, &payload); err != nil {
+		t.Fatalf("解析请求体 %s 失败: %v", r.body, err)
+	}
+	text, _ := payload["text"].(string)
+	if len(payload) != 1 || !strings.HasPrefix(text, "🚨 *[CRITICAL]* [500] 上游故障\n```") || !strings.Contains(text, `"route": "orders"`) {
+		t.Fatalf("Slack 消息 = %s", r.body)
+	}
+}
+
+func TestDingTalkChannelPayload(t *testing.T) {
+	server, requests := notificationServer(t, http.StatusOK, `{"errcode":0,"errmsg":"ok"}`)
+	channel := NewDingTalkChannel(server.URL+"/robot/send?access_token=abc", "SEC123", time.Second)
+
+	if err := channel.Send(context.Background(), LevelError, "[503] 熔断", nil); err != nil {
+		t.Fatalf("发送通知失败: %v", err)
+	}
+	r, ok := receive(requests)
+	if !ok {
+		t.Fatal("钉钉未收到请求")
+	}
+
+	var payload struct {
+		MsgType  string `json:"msgtype"`
+		Markdown struct {
+			Title string `json:"title"`
+			Text  string `json:"text"`
+		} `json:"markdown"`
+	}
+	if err := json.Unmarshal(r.body, &payload); err != nil {
+		t.Fatalf("解析请求体 %s 失败: %v", r.body, err)
+	}
+	if payload.MsgType != "markdown" || payload.Markdown.Title != "[ERROR] [503] 熔断" ||
+		!strings.HasPrefix(payload.Markdown.Text, "### ❌ [ERROR] [503] 熔断\n\n- 级别: error\n") {
+		t.Fatalf("钉钉消息 = %s", r.body)
+	}
+
+	// 签名 = Base64(HmacSHA256(timestamp + "\n" + secret))，保留原有查询参数
+	query, _ := url.ParseQuery(r.query)
+	mac := hmac.New(sha256.New, []byte("SEC123"))
+	mac.Write([]byte(query.Get("timestamp") + "\n" + "SEC123"))
+	if query.Get("access_token") != "abc" || query.Get("sign") != base64.StdEncoding.EncodeToString(mac.Sum(nil)) {
+		t.Fatalf("钉钉请求参数 = %s", r.query)
+	}
+}
+
+func TestDingTalkChannelWithoutSecret(t *testing.T) {
+	server, requests := notificationServer(t, http.StatusOK, `{"errcode":0}`)
+	if err := NewDingTalkChannel(server.URL+"/send?access_token=abc", "", time.Second).Send(context.Background(), LevelError, "msg", nil); err != nil {
+		t.Fatalf("发送通知失败: %v", err)
+	}
+	if r, _ := receive(requests); r.query != "access_token=abc" {
+		t.Fatalf("未配置密钥时请求参数 = %s，期望不加签", r.query)
+	}
+}
+
+func TestDingTalkChannelReportsErrCode(t *testing.T) {
+	// 钉钉在签名错误时仍返回 200
+	server, _ := notificationServer(t, http.StatusOK, `{"errcode":310000,"errmsg":"sign not match"}`)
+	err := NewDingTalkChannel(server.URL, "wrong", time.Second).Send(context.Background(), LevelError, "msg", nil)
+	if err == nil || !strings.Contains(err.Error(), "sign not match") {
+		t.Fatalf("errcode 非 0 时返回 %v，期望发送失败", err)
+	}
+}
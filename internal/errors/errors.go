@@ -220,6 +220,9 @@ const (
 	ErrTokenInvalid       ErrorCode = "TOKEN_INVALID"
 	ErrTooManyRequests    ErrorCode = "TOO_MANY_REQUESTS"
 	ErrCircuitBreakerOpen ErrorCode = "CIRCUIT_BREAKER_OPEN"
+	ErrProxyFailed        ErrorCode = "PROXY_FAILED"
+	ErrDeadlineExceeded   ErrorCode = "DEADLINE_EXCEEDED"
+	ErrBodyRead           ErrorCode = "BODY_READ_ERROR"
 )
 
 // 业务级错误
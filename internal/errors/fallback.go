@@ -0,0 +1,175 @@
+package errors
+
+import (
+	"sync"
+	"time"
+)
+
+// FallbackConfig 降级配置
+type FallbackConfig struct {
+	// 统计窗口，按窗口的 1/10 粒度滑动
+	WindowSize time.Duration
+	// 触发降级的错误率（窗口内错误数 / 请求总数，0-1）
+	ErrorThreshold float64
+	// 恢复的错误率，降级后错误率低于该值时恢复（0-1，不大于 ErrorThreshold）
+	RecoveryThreshold float64
+	// 计算错误率所需的最少请求数，窗口内请求不足时不降级
+	MinRequests int
+}
+
+// DefaultFallbackConfig 默认降级配置
+var DefaultFallbackConfig = FallbackConfig{
+	WindowSize:        time.Minute,
+	ErrorThreshold:    0.5,
+	RecoveryThreshold: 0.2,
+	MinRequests:       10,
+}
+
+// fallbackBuckets 统计窗口划分的时间桶数量，窗口按桶的粒度滑动
+const fallbackBuckets = 10
+
+// fallbackBucket 时间桶，记录一个时间片内的请求结果
+type fallbackBucket struct {
+	// 时间片序号（时间 / 桶宽度），用于判断桶是否已过期
+	slot      int64
+	successes int
+	failures  int
+}
+
+// FallbackHandler 降级处理器，窗口内错误率超过阈值时改为执行降级逻辑
+// 请求结果按时间桶计数，内存占用和每次统计的开销与请求量无关
+type FallbackHandler struct {
+	config FallbackConfig
+	// 桶宽度（纳秒）
+	width    int64
+	buckets  [fallbackBuckets]fallbackBucket
+	degraded bool
+	mu       sync.Mutex
+}
+
+// NewFallbackHandler 创建降级处理器
+func NewFallbackHandler(config FallbackConfig) *FallbackHandler {
+	if config.RecoveryThreshold > config.ErrorThreshold {
+		config.RecoveryThreshold = config.ErrorThreshold
+	}
+	width := int64(config.WindowSize) / fallbackBuckets
+	if width <= 0 {
+		width = 1
+	}
+	return &FallbackHandler{
+		config: config,
+		width:  width,
+	}
+}
+
+// Execute 执行操作，已降级时直接执行降级逻辑，操作失败时记录错误并执行降级逻辑
+func (h *FallbackHandler) Execute(fn func() error, fallback func(error) error) error {
+	if h.IsDegraded() {
+		return fallback(nil)
+	}
+
+	if err := fn(); err != nil {
+		h.RecordError(err)
+		return fallback(err)
+	}
+
+	h.RecordSuccess()
+	return nil
+}
+
+// RecordSuccess 记录成功的请求
+func (h *FallbackHandler) RecordSuccess() {
+	h.record(false)
+}
+
+// RecordError 记录失败的请求
+func (h *FallbackHandler) RecordError(err error) {
+	h.record(true)
+}
+
+// record 记录请求结果并更新降级状态
+func (h *FallbackHandler) record(failed bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	now := time.Now()
+	slot := now.UnixNano() / h.width
+	bucket := &h.buckets[slot%fallbackBuckets]
+	if bucket.slot != slot {
+		// 桶中是上一轮窗口的旧数据
+		*bucket = fallbackBucket{slot: slot}
+	}
+	if failed {
+		bucket.failures++
+	} else {
+		bucket.successes++
+	}
+	h.update(now)
+}
+
+// GetErrorRate 获取窗口内的错误率（错误数 / 请求总数）
+func (h *FallbackHandler) GetErrorRate() float64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	failures, total := h.stats(time.Now())
+	return errorRate(failures, total)
+}
+
+// IsDegraded 是否处于降级状态
+func (h *FallbackHandler) IsDegraded() bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.update(time.Now())
+	return h.degraded
+}
+
+// update 按窗口内的错误率切换降级状态，调用方需持有锁
+func (h *FallbackHandler) update(now time.Time) {
+	failures, total := h.stats(now)
+
+	// 请求不足时数据不具代表性，不降级；已降级时视为故障已过去
+	if total < h.config.MinRequests {
+		h.degraded = false
+		return
+	}
+
+	rate := errorRate(failures, total)
+	if h.degraded {
+		h.degraded = rate >= h.config.RecoveryThreshold
+	} else {
+		h.degraded = rate >= h.config.ErrorThreshold
+	}
+}
+
+// stats 汇总窗口内各时间桶的错误数和请求总数，调用方需持有锁
+func (h *FallbackHandler) stats(now time.Time) (int, int) {
+	current := now.UnixNano() / h.width
+	failures, total := 0, 0
+	for _, bucket := range h.buckets {
+		if current-bucket.slot >= fallbackBuckets {
+			continue
+		}
+		failures += bucket.failures
+		total += bucket.failures + bucket.successes
+	}
+	return failures, total
+}
+
+// errorRate 计算错误率
+func errorRate(failures, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(failures) / float64(total)
+}
+
+// Reset 清空统计并退出降级状态
+func (h *FallbackHandler) Reset() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.buckets = [fallbackBuckets]fallbackBucket{}
+	h.degraded = false
+}
@@ -0,0 +1,118 @@
+package errors
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestFallbackDegradesAndRecovers(t *testing.T) {
+	h := NewFallbackHandler(FallbackConfig{
+		WindowSize:        time.Minute,
+		ErrorThreshold:    0.5,
+		RecoveryThreshold: 0.2,
+		MinRequests:       4,
+	})
+	failure := fmt.Errorf("upstream error")
+
+	// 请求数不足 MinRequests 时不降级
+	for i := 0; i < 3; i++ {
+		h.RecordError(failure)
+	}
+	if h.IsDegraded() {
+		t.Fatal("请求数不足时不应降级")
+	}
+
+	// 错误率 4/5 超过阈值后降级
+	h.RecordSuccess()
+	h.RecordError(failure)
+	if !h.IsDegraded() {
+		t.Fatalf("错误率 %.2f 超过阈值后应降级", h.GetErrorRate())
+	}
+
+	// 错误率降到恢复阈值以下前保持降级（4/10）
+	for i := 0; i < 5; i++ {
+		h.RecordSuccess()
+	}
+	if got := h.GetErrorRate(); got != 0.4 || !h.IsDegraded() {
+		t.Fatalf("错误率 = %.2f，降级 = %v，期望 0.4 且保持降级", got, h.IsDegraded())
+	}
+
+	// 错误率 4/20 仍不低于恢复阈值，4/21 低于阈值后恢复
+	for i := 0; i < 10; i++ {
+		h.RecordSuccess()
+	}
+	if !h.IsDegraded() {
+		t.Fatal("错误率等于恢复阈值时应保持降级")
+	}
+	h.RecordSuccess()
+	if h.IsDegraded() {
+		t.Fatalf("错误率 %.2f 低于恢复阈值后应恢复", h.GetErrorRate())
+	}
+}
+
+func TestFallbackExecute(t *testing.T) {
+	h := NewFallbackHandler(FallbackConfig{WindowSize: time.Minute, ErrorThreshold: 0.5, MinRequests: 1})
+
+	calls := 0
+	fallbackErr := fmt.Errorf("fallback")
+	run := func(fail bool) (error, error) {
+		var cause error
+		err := h.Execute(func() error {
+			calls++
+			if fail {
+				return fmt.Errorf("failed")
+			}
+			return nil
+		}, func(err error) error {
+			cause = err
+			return fallbackErr
+		})
+		return err, cause
+	}
+
+	if err, _ := run(false); err != nil {
+		t.Fatalf("成功时返回 %v", err)
+	}
+	if err, cause := run(true); err != fallbackErr || cause == nil {
+		t.Fatalf("失败时返回 %v（原因 %v），期望执行降级逻辑并传入错误", err, cause)
+	}
+	// 错误率 1/2 达到阈值，降级后不再执行操作
+	if err, cause := run(false); err != fallbackErr || cause != nil || calls != 2 {
+		t.Fatalf("降级后返回 %v（原因 %v），操作执行 %d 次，期望直接降级", err, cause, calls)
+	}
+
+	h.Reset()
+	if h.IsDegraded() || h.GetErrorRate() != 0 {
+		t.Fatal("重置后应清空统计并退出降级")
+	}
+}
+
+func TestFallbackWindowExpires(t *testing.T) {
+	h := NewFallbackHandler(FallbackConfig{WindowSize: 100 * time.Millisecond, ErrorThreshold: 0.5, MinRequests: 2})
+	h.RecordError(nil)
+	h.RecordError(nil)
+	if !h.IsDegraded() {
+		t.Fatal("错误率 100% 时应降级")
+	}
+
+	// 窗口过后旧结果不再计入，请求不足时退出降级
+	time.Sleep(120 * time.Millisecond)
+	if h.IsDegraded() || h.GetErrorRate() != 0 {
+		t.Fatalf("窗口过后错误率 = %.2f，期望旧结果过期", h.GetErrorRate())
+	}
+	h.RecordSuccess()
+	h.RecordSuccess()
+	if h.IsDegraded() {
+		t.Fatal("窗口内只有成功请求时不应降级")
+	}
+}
+
+func BenchmarkFallbackRecord(b *testing.B) {
+	h := NewFallbackHandler(DefaultFallbackConfig)
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			h.RecordSuccess()
+		}
+	})
+}
@@ -3,8 +3,12 @@ package errors
 import (
 	"encoding/json"
 	"fmt"
+	"net/http"
 	"os"
 	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
 	"sync"
 )
 
@@ -36,6 +40,9 @@ var (
 		ErrResourceNotFound:   "资源不存在",
 		ErrResourceExists:     "资源已存在",
 		ErrOperationFailed:    "操作失败",
+		ErrProxyFailed:        "代理请求失败",
+		ErrDeadlineExceeded:   "请求超过截止时间",
+		ErrBodyRead:           "读取请求体失败",
 	}
 
 	// 全局错误消息实例
@@ -46,16 +53,9 @@ var (
 
 // LoadErrorMessages 加载错误消息
 func LoadErrorMessages(lang string, filePath string) error {
-	// 读取错误消息文件
-	data, err := os.ReadFile(filePath)
+	messages, err := readErrorMessages(filePath)
 	if err != nil {
-		return fmt.Errorf("读取错误消息文件失败: %v", err)
-	}
-
-	// 解析错误消息
-	var messages map[ErrorCode]string
-	if err := json.Unmarshal(data, &messages); err != nil {
-		return fmt.Errorf("解析错误消息失败: %v", err)
+		return err
 	}
 
 	// 更新错误消息
@@ -66,6 +66,22 @@ func LoadErrorMessages(lang string, filePath string) error {
 	return nil
 }
 
+// readErrorMessages 读取并解析错误消息文件
+func readErrorMessages(filePath string) (map[ErrorCode]string, error) {
+	// 读取错误消息文件
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("读取错误消息文件失败: %v", err)
+	}
+
+	// 解析错误消息
+	var messages map[ErrorCode]string
+	if err := json.Unmarshal(data, &messages); err != nil {
+		return nil, fmt.Errorf("解析错误消息文件 %s 失败: %v", filePath, err)
+	}
+	return messages, nil
+}
+
 // LoadAllErrorMessages 加载所有语言的错误消息
 func LoadAllErrorMessages(dir string) error {
 	// 遍历目录下的所有错误消息文件
@@ -85,16 +101,50 @@ func LoadAllErrorMessages(dir string) error {
 	})
 }
 
-// GetErrorMessage 获取错误消息
-func GetErrorMessage(code ErrorCode, lang string) string {
+// ReloadErrorMessages 重新加载目录下所有语言的错误消息，整体替换已加载的消息；dir 为空时清空
+// 任一文件加载失败时保留原有消息
+func ReloadErrorMessages(dir string) error {
+	all := make(map[string]map[ErrorCode]string)
+	if dir != "" {
+		err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if info.IsDir() || filepath.Ext(path) != ".json" {
+				return nil
+			}
+			messages, err := readErrorMessages(path)
+			if err != nil {
+				return err
+			}
+			all[filepath.Base(path[:len(path)-5])] = messages
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	globalMessages.mu.Lock()
+	globalMessages.Messages = all
+	globalMessages.mu.Unlock()
+	return nil
+}
+
+// LookupErrorMessage 获取指定语言已加载的错误消息，不回退到默认消息
+func LookupErrorMessage(code ErrorCode, lang string) (string, bool) {
 	globalMessages.mu.RLock()
 	defer globalMessages.mu.RUnlock()
 
+	msg, ok := globalMessages.Messages[lang][code]
+	return msg, ok
+}
+
+// GetErrorMessage 获取错误消息
+func GetErrorMessage(code ErrorCode, lang string) string {
 	// 尝试获取指定语言的错误消息
-	if messages, ok := globalMessages.Messages[lang]; ok {
-		if msg, ok := messages[code]; ok {
-			return msg
-		}
+	if msg, ok := LookupErrorMessage(code, lang); ok {
+		return msg
 	}
 
 	// 返回默认错误消息
@@ -105,3 +155,116 @@ func GetErrorMessage(code ErrorCode, lang string) string {
 	// 返回通用错误消息
 	return "未知错误"
 }
+
+// NegotiateLanguage 按 Accept-Language 选择已加载的语言，未匹配时返回空字符串（使用默认消息）
+//
+// 按权重 q 从高到低依次匹配，语言标签不区分大小写；没有完全匹配的语言时按主语言匹配（如 en-US 匹配 en）
+func NegotiateLanguage(acceptLanguage string) string {
+	if acceptLanguage == "" {
+		return ""
+	}
+
+	globalMessages.mu.RLock()
+	defer globalMessages.mu.RUnlock()
+	if len(globalMessages.Messages) == 0 {
+		return ""
+	}
+
+	for _, tag := range parseAcceptLanguage(acceptLanguage) {
+		if lang, ok := globalMessages.matchLanguage(tag); ok {
+			return lang
+		}
+	}
+	return ""
+}
+
+// RequestLanguage 按请求的 Accept-Language 选择已加载的语言
+func RequestLanguage(req *http.Request) string {
+	return NegotiateLanguage(req.Header.Get("Accept-Language"))
+}
+
+// matchLanguage 查找与语言标签匹配的已加载语言，调用方需持有读锁
+// 依次尝试完全匹配、请求的主语言（en-US 匹配 en）、主语言相同的其他地区（en 匹配 en-GB）
+func (m *ErrorMessages) matchLanguage(tag string) (string, bool) {
+	primary, _, _ := strings.Cut(tag, "-")
+	var regional []string
+	for lang := range m.Messages {
+		if strings.EqualFold(lang, tag) {
+			return lang, true
+		}
+		if langPrimary, _, _ := strings.Cut(lang, "-"); strings.EqualFold(langPrimary, primary) {
+			regional = append(regional, lang)
+		}
+	}
+	for _, lang := range regional {
+		if strings.EqualFold(lang, primary) {
+			return lang, true
+		}
+	}
+	if len(regional) > 0 {
+		// 有多个地区时按名称选择，保证结果稳定
+		sort.Strings(regional)
+		return regional[0], true
+	}
+	return "", false
+}
+
+// parseAcceptLanguage 解析 Accept-Language，按权重从高到低返回语言标签，忽略 q=0 和通配符 *
+func parseAcceptLanguage(header string) []string {
+	type weighted struct {
+		tag string
+		q   float64
+	}
+	var tags []weighted
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		tag = strings.TrimSpace(tag)
+		if tag == "" || tag == "*" {
+			continue
+		}
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		if q <= 0 {
+			continue
+		}
+		tags = append(tags, weighted{tag: tag, q: q})
+	}
+	// 权重相同时保持原有顺序
+	sort.SliceStable(tags, func(i, j int) bool { return tags[i].q > tags[j].q })
+
+	result := make([]string, len(tags))
+	for i, t := range tags {
+		result[i] = t.tag
+	}
+	return result
+}
+
+// StatusErrorCode 获取 HTTP 状态码对应的错误代码，用于本地化按状态码返回的错误
+func StatusErrorCode(status int) ErrorCode {
+	switch status {
+	case http.StatusBadRequest:
+		return "BAD_REQUEST"
+	case http.StatusUnauthorized:
+		return "UNAUTHORIZED"
+	case http.StatusForbidden:
+		return "FORBIDDEN"
+	case http.StatusNotFound:
+		return ErrResourceNotFound
+	case http.StatusRequestTimeout, http.StatusGatewayTimeout:
+		return ErrTimeout
+	case http.StatusTooManyRequests:
+		return ErrTooManyRequests
+	case http.StatusBadGateway:
+		return ErrProxyFailed
+	case http.StatusServiceUnavailable:
+		return "SERVICE_UNAVAILABLE"
+	default:
+		return ErrInternalServer
+	}
+}
@@ -0,0 +1,133 @@
+package errors
+
+import (
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeMessages 在 dir 下写入各语言的错误消息文件
+func writeMessages(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// loadTestMessages 加载测试用的 en、en-GB、ja 错误消息，测试结束时清空
+func loadTestMessages(t *testing.T) {
+	t.Helper()
+	dir := t.TempDir()
+	writeMessages(t, dir, map[string]string{
+		"en.json":    `{"PROXY_FAILED": "Proxy request failed", "TIMEOUT": "Request timeout"}`,
+		"en-GB.json": `{"PROXY_FAILED": "Proxy request failed (GB)"}`,
+		"ja.json":    `{"PROXY_FAILED": "プロキシ要求に失敗しました"}`,
+		"notes.txt":  "not a language file",
+	})
+	if err := LoadAllErrorMessages(dir); err != nil {
+		t.Fatalf("加载错误消息失败: %v", err)
+	}
+	t.Cleanup(func() { ReloadErrorMessages("") })
+}
+
+func TestNegotiateLanguage(t *testing.T) {
+	loadTestMessages(t)
+
+	tests := []struct {
+		accept string
+		lang   string
+	}{
+		{"en", "en"},
+		{"EN-gb", "en-GB"},
+		{"en-US", "en"},
+		{"ja-JP,en;q=0.8", "ja"},
+		{"fr;q=0.9, en;q=0.5, ja;q=0.7", "ja"},
+		{"ja;q=0, en-GB", "en-GB"},
+		{"*, ja;q=0.1", "ja"},
+		{"fr, de;q=0.8", ""},
+		{"en;q=abc", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := NegotiateLanguage(tt.accept); got != tt.lang {
+			t.Fatalf("Accept-Language %q 协商的语言 = %q，期望 %q", tt.accept, got, tt.lang)
+		}
+	}
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Accept-Language", "en-AU")
+	if got := RequestLanguage(req); got != "en" {
+		t.Fatalf("请求协商的语言 = %q，期望 en", got)
+	}
+}
+
+func TestNegotiateLanguageRegionalFallback(t *testing.T) {
+	dir := t.TempDir()
+	writeMessages(t, dir, map[string]string{
+		"pt-PT.json": `{}`,
+		"pt-BR.json": `{}`,
+	})
+	if err := ReloadErrorMessages(dir); err != nil {
+		t.Fatalf("加载错误消息失败: %v", err)
+	}
+	t.Cleanup(func() { ReloadErrorMessages("") })
+
+	// 只有地区语言时主语言按名称选择，结果稳定
+	for i := 0; i < 10; i++ {
+		if got := NegotiateLanguage("pt"); got != "pt-BR" {
+			t.Fatalf("pt 协商的语言 = %q，期望 pt-BR", got)
+		}
+	}
+}
+
+func TestGetErrorMessageFallback(t *testing.T) {
+	loadTestMessages(t)
+
+	tests := []struct {
+		code    ErrorCode
+		lang    string
+		message string
+	}{
+		{ErrProxyFailed, "en", "Proxy request failed"},
+		{ErrProxyFailed, "ja", "プロキシ要求に失敗しました"},
+		// 语言文件中没有的错误代码使用默认消息
+		{ErrTooManyRequests, "en", "请求过于频繁"},
+		// 未加载的语言和未协商到语言时使用默认消息
+		{ErrProxyFailed, "fr", "代理请求失败"},
+		{ErrTimeout, "", "请求超时"},
+		{"NO_SUCH_CODE", "en", "未知错误"},
+	}
+	for _, tt := range tests {
+		if got := GetErrorMessage(tt.code, tt.lang); got != tt.message {
+			t.Fatalf("GetErrorMessage(%s, %q) = %q，期望 %q", tt.code, tt.lang, got, tt.message)
+		}
+	}
+	if _, ok := LookupErrorMessage(ErrTooManyRequests, "en"); ok {
+		t.Fatal("LookupErrorMessage 不应回退到默认消息")
+	}
+}
+
+func TestReloadErrorMessagesKeepsOnFailure(t *testing.T) {
+	loadTestMessages(t)
+
+	dir := t.TempDir()
+	writeMessages(t, dir, map[string]string{"de.json": `{"PROXY_FAILED": `})
+	if err := ReloadErrorMessages(dir); err == nil {
+		t.Fatal("文件格式错误时期望重新加载失败")
+	}
+	if got := NegotiateLanguage("en"); got != "en" {
+		t.Fatalf("重新加载失败后协商的语言 = %q，期望保留原有的 en", got)
+	}
+
+	// 重新加载整体替换已加载的语言
+	writeMessages(t, dir, map[string]string{"de.json": `{"PROXY_FAILED": "Proxy-Anfrage fehlgeschlagen"}`})
+	if err := ReloadErrorMessages(dir); err != nil {
+		t.Fatalf("重新加载错误消息失败: %v", err)
+	}
+	if NegotiateLanguage("en") != "" || NegotiateLanguage("de-AT") != "de" {
+		t.Fatal("重新加载后应只保留新目录中的语言")
+	}
+}
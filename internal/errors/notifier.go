@@ -17,6 +17,32 @@ const (
 	LevelCritical
 )
 
+// String 返回通知级别名称
+func (l NotificationLevel) String() string {
+	switch l {
+	case LevelInfo:
+		return "info"
+	case LevelWarning:
+		return "warning"
+	case LevelError:
+		return "error"
+	case LevelCritical:
+		return "critical"
+	default:
+		return fmt.Sprintf("level(%d)", int(l))
+	}
+}
+
+// ParseNotificationLevel 解析通知级别名称：info/warning/error/critical
+func ParseNotificationLevel(name string) (NotificationLevel, error) {
+	for level := LevelInfo; level <= LevelCritical; level++ {
+		if level.String() == name {
+			return level, nil
+		}
+	}
+	return LevelInfo, fmt.Errorf("无效的通知级别: %s，可选 info/warning/error/critical", name)
+}
+
 // NotificationChannel 通知渠道
 type NotificationChannel interface {
 	// Send 发送通知
@@ -60,19 +86,16 @@ func NewErrorNotifier(config NotificationConfig) *ErrorNotifier {
 
 // Notify 发送通知
 func (n *ErrorNotifier) Notify(ctx context.Context, err error) error {
-	if !n.config.Enabled {
-		return nil
-	}
-
-	// 检查通知级别
 	level := n.getErrorLevel(err)
-	if level < n.config.LevelThreshold {
-		return nil
-	}
 
-	// 检查通知间隔
+	// 检查通知开关、级别和间隔
 	n.mu.Lock()
-	if time.Since(n.lastNotify) < n.config.Interval {
+	config := n.config
+	if !config.Enabled || len(config.Channels) == 0 || level < config.LevelThreshold {
+		n.mu.Unlock()
+		return nil
+	}
+	if time.Since(n.lastNotify) < config.Interval {
 		n.mu.Unlock()
 		return nil
 	}
@@ -85,7 +108,7 @@ func (n *ErrorNotifier) Notify(ctx context.Context, err error) error {
 
 	// 发送通知
 	var lastErr error
-	for _, channel := range n.config.Channels {
+	for _, channel := range config.Channels {
 		if err := channel.Send(ctx, level, message, details); err != nil {
 			lastErr = err
 		}
@@ -124,12 +147,16 @@ func (n *ErrorNotifier) buildMessage(err error) string {
 // buildDetails 构建通知详情
 func (n *ErrorNotifier) buildDetails(err error) interface{} {
 	if e, ok := err.(*Error); ok {
-		return map[string]interface{}{
+		details := map[string]interface{}{
 			"code":    e.Code,
 			"message": e.Message,
 			"details": e.Details,
-			"error":   e.Err,
 		}
+		// error 接口序列化为 JSON 时会丢失内容，转为字符串
+		if e.Err != nil {
+			details["error"] = e.Err.Error()
+		}
+		return details
 	}
 	return nil
 }
@@ -0,0 +1,51 @@
+package errors
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+// RequestIDHeader 请求 ID 请求头
+const RequestIDHeader = "X-Request-ID"
+
+// RequestIDKey gin 上下文中请求 ID 的键
+const RequestIDKey = "_request_id"
+
+// Response 网关错误响应体，网关自身产生的错误响应统一使用该格式
+type Response struct {
+	// HTTP 状态码
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	// 请求 ID，与响应头 X-Request-ID 相同
+	RequestID string `json:"request_id"`
+	// 错误详情，如校验失败的字段，没有时为 null
+	Details any `json:"details"`
+}
+
+// NewResponse 创建错误响应体
+func NewResponse(c *gin.Context, code int, message string, details any) *Response {
+	return &Response{
+		Code:      code,
+		Message:   message,
+		RequestID: RequestID(c),
+		Details:   details,
+	}
+}
+
+// RequestID 获取请求 ID
+func RequestID(c *gin.Context) string {
+	return c.GetString(RequestIDKey)
+}
+
+// Abort 写出错误响应并中止请求
+func Abort(c *gin.Context, status int, message string) {
+	AbortWithDetails(c, status, message, nil)
+}
+
+// AbortWithDetails 写出带错误详情的错误响应并中止请求，响应已写出时只中止请求
+func AbortWithDetails(c *gin.Context, status int, message string, details any) {
+	if c.Writer.Written() {
+		c.Abort()
+		return
+	}
+	c.AbortWithStatusJSON(status, NewResponse(c, status, message, details))
+}
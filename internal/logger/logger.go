@@ -14,24 +14,17 @@ import (
 var (
 	// Log 全局日志实例
 	Log *zap.Logger
+	// Access 独立的访问日志实例，未配置 log.access 时为 nil
+	Access *zap.Logger
 )
 
 // Init 初始化日志
 func Init(config *config.LogConfig) error {
-	// 创建日志目录
-	if err := os.MkdirAll(filepath.Dir(config.Output), 0755); err != nil {
+	writeSyncer, err := newWriteSyncer(config.Output, config.MaxSize, config.MaxBackups, config.MaxAge, config.Compress)
+	if err != nil {
 		return err
 	}
 
-	// 配置日志轮转
-	writer := &lumberjack.Logger{
-		Filename:   config.Output,
-		MaxSize:    config.MaxSize,
-		MaxBackups: config.MaxBackups,
-		MaxAge:     config.MaxAge,
-		Compress:   config.Compress,
-	}
-
 	// 配置编码器
 	encoderConfig := zapcore.EncoderConfig{
 		TimeKey:        "time",
@@ -61,14 +54,6 @@ func Init(config *config.LogConfig) error {
 		encoder = zapcore.NewJSONEncoder(encoderConfig)
 	}
 
-	// 配置输出
-	var writeSyncer zapcore.WriteSyncer
-	if config.Output == "stdout" {
-		writeSyncer = zapcore.AddSync(os.Stdout)
-	} else {
-		writeSyncer = zapcore.AddSync(writer)
-	}
-
 	// 配置日志级别
 	level := zap.InfoLevel
 	switch config.Level {
@@ -92,9 +77,70 @@ func Init(config *config.LogConfig) error {
 	// 创建日志实例
 	Log = zap.New(core, zap.AddCaller(), zap.AddStacktrace(zap.ErrorLevel))
 
+	// 创建访问日志实例
+	Access = nil
+	if config.Access != nil {
+		access, err := newAccessLogger(config, encoderConfig)
+		if err != nil {
+			return err
+		}
+		Access = access
+	}
+
 	return nil
 }
 
+// newWriteSyncer 创建日志输出，文件输出按大小轮转
+func newWriteSyncer(output string, maxSize, maxBackups, maxAge int, compress bool) (zapcore.WriteSyncer, error) {
+	if output == "stdout" {
+		return zapcore.AddSync(os.Stdout), nil
+	}
+
+	// 创建日志目录
+	if err := os.MkdirAll(filepath.Dir(output), 0755); err != nil {
+		return nil, err
+	}
+
+	// 配置日志轮转
+	return zapcore.AddSync(&lumberjack.Logger{
+		Filename:   output,
+		MaxSize:    maxSize,
+		MaxBackups: maxBackups,
+		MaxAge:     maxAge,
+		Compress:   compress,
+	}), nil
+}
+
+// newAccessLogger 创建访问日志实例，固定为 JSON 格式、info 级别，未配置的轮转参数使用主日志的配置
+func newAccessLogger(config *config.LogConfig, encoderConfig zapcore.EncoderConfig) (*zap.Logger, error) {
+	access := config.Access
+	maxSize, maxBackups, maxAge, compress := config.MaxSize, config.MaxBackups, config.MaxAge, config.Compress
+	if access.MaxSize > 0 {
+		maxSize = access.MaxSize
+	}
+	if access.MaxBackups > 0 {
+		maxBackups = access.MaxBackups
+	}
+	if access.MaxAge > 0 {
+		maxAge = access.MaxAge
+	}
+	if access.Compress != nil {
+		compress = *access.Compress
+	}
+
+	writeSyncer, err := newWriteSyncer(access.Output, maxSize, maxBackups, maxAge, compress)
+	if err != nil {
+		return nil, err
+	}
+
+	// 每条访问日志只包含时间和请求字段
+	encoderConfig.LevelKey = ""
+	encoderConfig.CallerKey = ""
+	encoderConfig.MessageKey = ""
+	encoderConfig.StacktraceKey = ""
+	return zap.New(zapcore.NewCore(zapcore.NewJSONEncoder(encoderConfig), writeSyncer, zap.InfoLevel)), nil
+}
+
 // Debug 记录调试日志
 func Debug(msg string, fields ...zap.Field) {
 	Log.Debug(msg, fields...)
@@ -127,6 +173,9 @@ func With(fields ...zap.Field) *zap.Logger {
 
 // Sync 同步日志
 func Sync() error {
+	if Access != nil {
+		_ = Access.Sync()
+	}
 	return Log.Sync()
 }
 
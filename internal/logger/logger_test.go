@@ -0,0 +1,51 @@
+package logger
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gateway-go/internal/config"
+)
+
+func TestInitAccessLogger(t *testing.T) {
+	dir := t.TempDir()
+	compress := false
+	cfg := &config.LogConfig{
+		Level:      "info",
+		Format:     "json",
+		Output:     filepath.Join(dir, "gateway.log"),
+		MaxSize:    10,
+		MaxAge:     7,
+		MaxBackups: 3,
+		Compress:   true,
+		Access:     &config.AccessLogConfig{Output: filepath.Join(dir, "access", "access.log"), MaxBackups: 30, Compress: &compress},
+	}
+	previous, previousAccess := Log, Access
+	t.Cleanup(func() { Log, Access = previous, previousAccess })
+	if err := Init(cfg); err != nil {
+		t.Fatalf("初始化日志失败: %v", err)
+	}
+
+	Access.Info("access", Field("path", "/orders"))
+	// info 级别的 JSON 日志不输出 msg 字段
+	Log.Warn("主日志", Field("source", "main"))
+	Sync()
+
+	// 访问日志和主日志分别写入各自的文件
+	access, err := os.ReadFile(cfg.Access.Output)
+	if err != nil || !strings.Contains(string(access), `"path":"/orders"`) || strings.Contains(string(access), `"source"`) {
+		t.Fatalf("访问日志文件内容 = %q, %v", access, err)
+	}
+	main, err := os.ReadFile(cfg.Output)
+	if err != nil || !strings.Contains(string(main), `"source":"main"`) || strings.Contains(string(main), "/orders") {
+		t.Fatalf("主日志文件内容 = %q, %v", main, err)
+	}
+
+	// 未配置 log.access 时不创建独立的访问日志
+	cfg.Access = nil
+	if err := Init(cfg); err != nil || Access != nil {
+		t.Fatalf("未配置 log.access 时 Access = %v, %v", Access, err)
+	}
+}
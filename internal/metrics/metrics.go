@@ -0,0 +1,251 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// defaultRegistry 默认指标注册表
+var defaultRegistry = &Registry{}
+
+// Registry 指标注册表
+type Registry struct {
+	collectors []collector
+	mu         sync.RWMutex
+}
+
+// collector 可输出为 Prometheus 文本格式的指标
+type collector interface {
+	writeTo(w io.Writer)
+}
+
+// register 注册指标
+func (r *Registry) register(c collector) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.collectors = append(r.collectors, c)
+}
+
+// WritePrometheus 以 Prometheus 文本格式输出所有指标
+func (r *Registry) WritePrometheus(w io.Writer) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, c := range r.collectors {
+		c.writeTo(w)
+	}
+}
+
+// WritePrometheus 以 Prometheus 文本格式输出默认注册表中的指标
+func WritePrometheus(w io.Writer) {
+	defaultRegistry.WritePrometheus(w)
+}
+
+// CounterVec 带标签的计数器
+type CounterVec struct {
+	name   string
+	help   string
+	labels []string
+	values map[string]*labeledValue
+	mu     sync.RWMutex
+}
+
+// labeledValue 某组标签值对应的计数
+type labeledValue struct {
+	labelValues []string
+	value       float64
+}
+
+// NewCounterVec 创建计数器并注册到默认注册表
+func NewCounterVec(name, help string, labels ...string) *CounterVec {
+	c := &CounterVec{
+		name:   name,
+		help:   help,
+		labels: labels,
+		values: make(map[string]*labeledValue),
+	}
+	defaultRegistry.register(c)
+	return c
+}
+
+// Inc 计数加一，labelValues 与创建时的标签一一对应
+func (c *CounterVec) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Add 计数增加 v
+func (c *CounterVec) Add(v float64, labelValues ...string) {
+	if len(labelValues) != len(c.labels) {
+		return
+	}
+	key := strings.Join(labelValues, "\xff")
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	lv, exists := c.values[key]
+	if !exists {
+		lv = &labeledValue{labelValues: append([]string(nil), labelValues...)}
+		c.values[key] = lv
+	}
+	lv.value += v
+}
+
+// Value 获取指定标签的当前计数
+func (c *CounterVec) Value(labelValues ...string) float64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if lv, exists := c.values[strings.Join(labelValues, "\xff")]; exists {
+		return lv.value
+	}
+	return 0
+}
+
+// writeTo 输出 Prometheus 文本格式
+func (c *CounterVec) writeTo(w io.Writer) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	fmt.Fprintf(w, "# HELP %s %s\n", c.name, c.help)
+	fmt.Fprintf(w, "# TYPE %s counter\n", c.name)
+
+	// 无标签计数器未计数时也输出 0
+	if len(c.labels) == 0 && len(c.values) == 0 {
+		fmt.Fprintf(w, "%s 0\n", c.name)
+		return
+	}
+
+	keys := make([]string, 0, len(c.values))
+	for key := range c.values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		lv := c.values[key]
+		fmt.Fprintf(w, "%s%s %s\n", c.name, formatLabels(c.labels, lv.labelValues), formatValue(lv.value))
+	}
+}
+
+// formatLabels 格式化标签，如 {route="api",status="500"}
+func formatLabels(names, values []string) string {
+	if len(names) == 0 {
+		return ""
+	}
+	pairs := make([]string, len(names))
+	for i, name := range names {
+		pairs[i] = name + "=" + strconv.Quote(values[i])
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+// formatValue 格式化数值，整数不带小数部分
+func formatValue(v float64) string {
+	if v == math.Trunc(v) && math.Abs(v) < 1e15 {
+		return strconv.FormatInt(int64(v), 10)
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// SizeBuckets 消息体大小直方图的默认分桶（字节），从 256B 到 16MB
+var SizeBuckets = []float64{256, 1 << 10, 4 << 10, 16 << 10, 64 << 10, 256 << 10, 1 << 20, 4 << 20, 16 << 20}
+
+// HistogramVec 带标签的直方图
+type HistogramVec struct {
+	name    string
+	help    string
+	labels  []string
+	buckets []float64
+	values  map[string]*labeledHistogram
+	mu      sync.RWMutex
+}
+
+// labeledHistogram 某组标签值对应的分桶计数
+type labeledHistogram struct {
+	labelValues []string
+	// 落入各分桶（不大于上界）的观测次数，不累计
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+// NewHistogramVec 创建直方图并注册到默认注册表，buckets 为升序的分桶上界
+func NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	h := &HistogramVec{
+		name:    name,
+		help:    help,
+		labels:  labels,
+		buckets: buckets,
+		values:  make(map[string]*labeledHistogram),
+	}
+	defaultRegistry.register(h)
+	return h
+}
+
+// Observe 记录一次观测值，labelValues 与创建时的标签一一对应
+func (h *HistogramVec) Observe(v float64, labelValues ...string) {
+	if len(labelValues) != len(h.labels) {
+		return
+	}
+	key := strings.Join(labelValues, "\xff")
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	lh, exists := h.values[key]
+	if !exists {
+		lh = &labeledHistogram{
+			labelValues: append([]string(nil), labelValues...),
+			counts:      make([]uint64, len(h.buckets)),
+		}
+		h.values[key] = lh
+	}
+	if i := sort.SearchFloat64s(h.buckets, v); i < len(h.buckets) {
+		lh.counts[i]++
+	}
+	lh.count++
+	lh.sum += v
+}
+
+// Count 获取指定标签的观测次数
+func (h *HistogramVec) Count(labelValues ...string) uint64 {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	if lh, exists := h.values[strings.Join(labelValues, "\xff")]; exists {
+		return lh.count
+	}
+	return 0
+}
+
+// writeTo 输出 Prometheus 文本格式
+func (h *HistogramVec) writeTo(w io.Writer) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	fmt.Fprintf(w, "# HELP %s %s\n", h.name, h.help)
+	fmt.Fprintf(w, "# TYPE %s histogram\n", h.name)
+
+	keys := make([]string, 0, len(h.values))
+	for key := range h.values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	names := append(append([]string(nil), h.labels...), "le")
+	for _, key := range keys {
+		lh := h.values[key]
+		values := append(append([]string(nil), lh.labelValues...), "")
+		var cumulative uint64
+		for i, bound := range h.buckets {
+			cumulative += lh.counts[i]
+			values[len(values)-1] = formatValue(bound)
+			fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, formatLabels(names, values), cumulative)
+		}
+		values[len(values)-1] = "+Inf"
+		fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, formatLabels(names, values), lh.count)
+		fmt.Fprintf(w, "%s_sum%s %s\n", h.name, formatLabels(h.labels, lh.labelValues), formatValue(lh.sum))
+		fmt.Fprintf(w, "%s_count%s %d\n", h.name, formatLabels(h.labels, lh.labelValues), lh.count)
+	}
+}
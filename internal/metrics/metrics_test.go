@@ -0,0 +1,47 @@
+package metrics
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestHistogramVecWritePrometheus(t *testing.T) {
+	h := &HistogramVec{
+		name:    "test_size_bytes",
+		help:    "测试大小",
+		labels:  []string{"route"},
+		buckets: []float64{100, 1000},
+		values:  make(map[string]*labeledHistogram),
+	}
+	for _, v := range []float64{50, 100, 500, 5000} {
+		h.Observe(v, "orders")
+	}
+	h.Observe(10, "users")
+	// 标签数量不匹配时忽略
+	h.Observe(10)
+
+	if h.Count("orders") != 4 || h.Count("users") != 1 || h.Count("missing") != 0 {
+		t.Fatalf("观测次数 = %d/%d/%d，期望 4/1/0", h.Count("orders"), h.Count("users"), h.Count("missing"))
+	}
+
+	var buf bytes.Buffer
+	h.writeTo(&buf)
+	want := strings.Join([]string{
+		"# HELP test_size_bytes 测试大小",
+		"# TYPE test_size_bytes histogram",
+		`test_size_bytes_bucket{route="orders",le="100"} 2`,
+		`test_size_bytes_bucket{route="orders",le="1000"} 3`,
+		`test_size_bytes_bucket{route="orders",le="+Inf"} 4`,
+		`test_size_bytes_sum{route="orders"} 5650`,
+		`test_size_bytes_count{route="orders"} 4`,
+		`test_size_bytes_bucket{route="users",le="100"} 1`,
+		`test_size_bytes_bucket{route="users",le="1000"} 1`,
+		`test_size_bytes_bucket{route="users",le="+Inf"} 1`,
+		`test_size_bytes_sum{route="users"} 10`,
+		`test_size_bytes_count{route="users"} 1`,
+	}, "\n") + "\n"
+	if buf.String() != want {
+		t.Fatalf("输出 =\n%s\n期望 =\n%s", buf.String(), want)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"crypto/md5"
 	"encoding/hex"
 	"encoding/json"
+	"hash/maphash"
 	"sync"
 	"time"
 
@@ -18,29 +19,60 @@ type PluginResult struct {
 	Expire  time.Time
 }
 
+// cacheCleanupInterval 过期缓存的清理间隔，与各插件的缓存时间无关
+const cacheCleanupInterval = 10 * time.Second
+
+// pluginCacheShards 插件缓存分片数，缓存键按哈希分散到各分片，减少高并发下的锁竞争
+const pluginCacheShards = 16
+
 // PluginCache 插件结果缓存
 type PluginCache struct {
+	shards [pluginCacheShards]pluginCacheShard
+	seed   maphash.Seed
+	// 保护缓存时间配置，与缓存分片的锁相互独立
+	mu sync.RWMutex
+	// 默认缓存时间
+	ttl time.Duration
+	// 按插件名配置的缓存时间，覆盖默认值
+	pluginTTLs map[string]time.Duration
+	// 关闭后停止清理过期缓存
+	stopChan  chan struct{}
+	closeOnce sync.Once
+}
+
+// pluginCacheShard 插件缓存分片，每个分片使用独立的锁
+type pluginCacheShard struct {
 	cache map[string]*PluginResult
 	mu    sync.RWMutex
-	ttl   time.Duration
 }
 
-// NewPluginCache 创建插件缓存
+// NewPluginCache 创建插件缓存，ttl 为默认缓存时间
 func NewPluginCache(ttl time.Duration) *PluginCache {
 	pc := &PluginCache{
-		cache: make(map[string]*PluginResult),
-		ttl:   ttl,
+		seed:       maphash.MakeSeed(),
+		ttl:        ttl,
+		pluginTTLs: make(map[string]time.Duration),
+		stopChan:   make(chan struct{}),
+	}
+	for i := range pc.shards {
+		pc.shards[i].cache = make(map[string]*PluginResult)
 	}
 	// 启动清理过期缓存的goroutine
 	go pc.cleanup()
 	return pc
 }
 
+// shard 返回缓存键所在的分片
+func (pc *PluginCache) shard(key string) *pluginCacheShard {
+	return &pc.shards[maphash.String(pc.seed, key)%pluginCacheShards]
+}
+
 // Get 获取缓存结果（兼容 chain.PluginCacheIface）
 func (pc *PluginCache) Get(key string) (interface{}, bool) {
-	pc.mu.RLock()
-	defer pc.mu.RUnlock()
-	result, exists := pc.cache[key]
+	shard := pc.shard(key)
+	shard.mu.RLock()
+	defer shard.mu.RUnlock()
+	result, exists := shard.cache[key]
 	if !exists {
 		return nil, false
 	}
@@ -51,30 +83,64 @@ func (pc *PluginCache) Get(key string) (interface{}, bool) {
 }
 
 // Set 设置缓存结果（兼容 chain.PluginCacheIface）
-func (pc *PluginCache) Set(key string, data interface{}) {
-	pc.mu.Lock()
-	defer pc.mu.Unlock()
-	pc.cache[key] = &PluginResult{
+func (pc *PluginCache) Set(key string, data interface{}, ttl time.Duration) {
+	shard := pc.shard(key)
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+	shard.cache[key] = &PluginResult{
 		Success: true,
 		Error:   nil,
 		Data:    data.(map[string]interface{}),
-		Expire:  time.Now().Add(pc.ttl),
+		Expire:  time.Now().Add(ttl),
+	}
+}
+
+// TTL 返回插件结果的缓存时间（兼容 chain.PluginCacheIface），0 表示不缓存
+func (pc *PluginCache) TTL(pluginName string) time.Duration {
+	pc.mu.RLock()
+	defer pc.mu.RUnlock()
+	if ttl, exists := pc.pluginTTLs[pluginName]; exists {
+		return ttl
 	}
+	return pc.ttl
+}
+
+// SetPluginTTLs 替换按插件名配置的缓存时间，未包含的插件使用默认缓存时间
+func (pc *PluginCache) SetPluginTTLs(ttls map[string]time.Duration) {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+	pc.pluginTTLs = ttls
 }
 
-// cleanup 清理过期缓存
+// cleanup 清理过期缓存，每条缓存按各自的过期时间判断，逐个分片加锁，不阻塞其他分片的读写
 func (pc *PluginCache) cleanup() {
-	ticker := time.NewTicker(pc.ttl)
+	ticker := time.NewTicker(cacheCleanupInterval)
 	defer ticker.Stop()
-	for range ticker.C {
-		pc.mu.Lock()
-		now := time.Now()
-		for key, result := range pc.cache {
-			if now.After(result.Expire) {
-				delete(pc.cache, key)
+	for {
+		select {
+		case <-ticker.C:
+			for i := range pc.shards {
+				pc.shards[i].removeExpired(time.Now())
 			}
+		case <-pc.stopChan:
+			return
+		}
+	}
+}
+
+// Close 停止清理过期缓存的goroutine
+func (pc *PluginCache) Close() {
+	pc.closeOnce.Do(func() { close(pc.stopChan) })
+}
+
+// removeExpired 删除分片中已过期的缓存
+func (s *pluginCacheShard) removeExpired(now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for key, result := range s.cache {
+		if now.After(result.Expire) {
+			delete(s.cache, key)
 		}
-		pc.mu.Unlock()
 	}
 }
 
@@ -0,0 +1,136 @@
+package plugin
+
+import (
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// cachedResults 返回缓存中的结果总数（包括已过期未清理的）
+func cachedResults(pc *PluginCache) int {
+	total := 0
+	for i := range pc.shards {
+		pc.shards[i].mu.RLock()
+		total += len(pc.shards[i].cache)
+		pc.shards[i].mu.RUnlock()
+	}
+	return total
+}
+
+func TestPluginCacheExpire(t *testing.T) {
+	pc := NewPluginCache(time.Minute)
+	defer pc.Close()
+
+	pc.Set("active", map[string]interface{}{"active": true}, time.Minute)
+	pc.Set("expired", map[string]interface{}{"active": true}, -time.Second)
+	if data, ok := pc.Get("active"); !ok || data.(map[string]interface{})["active"] != true {
+		t.Fatalf("未过期的缓存 = %v %v", data, ok)
+	}
+	if _, ok := pc.Get("expired"); ok {
+		t.Fatal("已过期的缓存仍可读取")
+	}
+	if _, ok := pc.Get("missing"); ok {
+		t.Fatal("不存在的缓存可读取")
+	}
+}
+
+func TestPluginCacheCleanupBoundsMemory(t *testing.T) {
+	pc := NewPluginCache(time.Minute)
+	defer pc.Close()
+
+	// 大量不同的键在过期后被清理，缓存大小只取决于未过期的键
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				pc.Set("token-"+strconv.Itoa(g)+"-"+strconv.Itoa(i), map[string]interface{}{}, time.Millisecond)
+			}
+		}(g)
+	}
+	wg.Wait()
+	pc.Set("long", map[string]interface{}{}, time.Minute)
+
+	now := time.Now().Add(time.Second)
+	for i := range pc.shards {
+		pc.shards[i].removeExpired(now)
+	}
+	if n := cachedResults(pc); n != 1 {
+		t.Fatalf("清理后缓存 %d 条，期望只剩未过期的 1 条", n)
+	}
+	if _, ok := pc.Get("long"); !ok {
+		t.Fatal("未过期的缓存被清理")
+	}
+}
+
+func TestPluginCacheClose(t *testing.T) {
+	pc := NewPluginCache(time.Minute)
+	pc.Close()
+	// 重复关闭不 panic
+	pc.Close()
+}
+
+// singleLockPluginCache 使用单个锁保护全部结果，作为分片前实现的基准
+type singleLockPluginCache struct {
+	cache map[string]*PluginResult
+	mu    sync.RWMutex
+}
+
+func (pc *singleLockPluginCache) Get(key string) (interface{}, bool) {
+	pc.mu.RLock()
+	defer pc.mu.RUnlock()
+	result, exists := pc.cache[key]
+	if !exists || time.Now().After(result.Expire) {
+		return nil, false
+	}
+	return result.Data, true
+}
+
+func (pc *singleLockPluginCache) Set(key string, data interface{}, ttl time.Duration) {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+	pc.cache[key] = &PluginResult{Success: true, Data: data.(map[string]interface{}), Expire: time.Now().Add(ttl)}
+}
+
+// benchmarkPluginCache 并发读取缓存，每 100 次读取中有 1 次写入，模拟令牌内省结果的缓存命中
+func benchmarkPluginCache(b *testing.B, get func(string) (interface{}, bool), set func(string, interface{}, time.Duration)) {
+	keys := make([]string, 1024)
+	data := map[string]interface{}{"active": true}
+	for i := range keys {
+		keys[i] = "token-" + strconv.Itoa(i)
+		set(keys[i], data, time.Minute)
+	}
+	var seq atomic.Int64
+
+	b.ReportAllocs()
+	b.SetParallelism(8)
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		i := int(seq.Add(1)) * 7919
+		for pb.Next() {
+			i++
+			key := keys[i%len(keys)]
+			if i%100 == 0 {
+				set(key, data, time.Minute)
+				continue
+			}
+			get(key)
+		}
+	})
+}
+
+// BenchmarkPluginCacheParallel 对比高并发下分片与单锁插件缓存的吞吐，需在多核下运行（如 -cpu 8,16）才能体现锁竞争
+func BenchmarkPluginCacheParallel(b *testing.B) {
+	b.Run("single", func(b *testing.B) {
+		pc := &singleLockPluginCache{cache: make(map[string]*PluginResult)}
+		benchmarkPluginCache(b, pc.Get, pc.Set)
+	})
+	b.Run("sharded", func(b *testing.B) {
+		pc := NewPluginCache(time.Minute)
+		defer pc.Close()
+		benchmarkPluginCache(b, pc.Get, pc.Set)
+	})
+}
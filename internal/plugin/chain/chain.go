@@ -1,7 +1,11 @@
 package chain
 
 import (
+	"reflect"
 	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"gateway-go/internal/plugin/core"
 
@@ -11,7 +15,9 @@ import (
 // PluginCacheIface 插件缓存接口，避免循环依赖
 type PluginCacheIface interface {
 	Get(key string) (result interface{}, ok bool)
-	Set(key string, result interface{})
+	Set(key string, result interface{}, ttl time.Duration)
+	// TTL 返回插件结果的缓存时间，0 表示不缓存
+	TTL(pluginName string) time.Duration
 }
 
 // GenerateCacheKeyFunc 生成缓存 key 的函数类型
@@ -22,6 +28,17 @@ type Chain struct {
 	plugins []core.Plugin
 	cache   PluginCacheIface // 插件结果缓存接口
 	genKey  GenerateCacheKeyFunc
+	skips   map[string]*SkipRule // 按插件名配置的跳过规则
+	// 通过管理API临时禁用的插件集合，只读，修改时整体替换，重新加载插件链后恢复
+	disabled atomic.Pointer[map[string]bool]
+	// 串行化对 disabled 的修改，执行插件链时不获取
+	disabledMu sync.Mutex
+}
+
+// PluginState 插件链中插件的启用状态
+type PluginState struct {
+	Name    string `json:"name"`
+	Enabled bool   `json:"enabled"`
 }
 
 // NewChain 创建插件链
@@ -51,11 +68,84 @@ func (c *Chain) AddPlugin(p core.Plugin) {
 	})
 }
 
+// AppendPlugin 按添加顺序追加插件，不按执行顺序排序，用于路由自定义插件顺序
+func (c *Chain) AppendPlugin(p core.Plugin) {
+	c.plugins = append(c.plugins, p)
+}
+
+// SetSkipRule 设置插件的跳过规则，rule 为 nil 时清除
+func (c *Chain) SetSkipRule(pluginName string, rule *SkipRule) {
+	if rule == nil {
+		delete(c.skips, pluginName)
+		return
+	}
+	if c.skips == nil {
+		c.skips = make(map[string]*SkipRule)
+	}
+	c.skips[pluginName] = rule
+}
+
+// SetEnabled 启用或禁用插件链中的插件，插件不在链中时返回 false
+func (c *Chain) SetEnabled(pluginName string, enabled bool) bool {
+	found := false
+	for _, p := range c.plugins {
+		found = found || p.Name() == pluginName
+	}
+	if !found {
+		return false
+	}
+
+	c.disabledMu.Lock()
+	defer c.disabledMu.Unlock()
+	current := c.disabledSet()
+	disabled := make(map[string]bool, len(current)+1)
+	for name := range current {
+		disabled[name] = true
+	}
+	if enabled {
+		delete(disabled, pluginName)
+	} else {
+		disabled[pluginName] = true
+	}
+	c.disabled.Store(&disabled)
+	return true
+}
+
+// disabledSet 返回当前禁用的插件集合，调用方不得修改
+func (c *Chain) disabledSet() map[string]bool {
+	if disabled := c.disabled.Load(); disabled != nil {
+		return *disabled
+	}
+	return nil
+}
+
+// States 按执行顺序返回插件链中插件的启用状态
+func (c *Chain) States() []PluginState {
+	states := make([]PluginState, 0, len(c.plugins))
+	disabled := c.disabledSet()
+	for _, p := range c.plugins {
+		states = append(states, PluginState{Name: p.Name(), Enabled: !disabled[p.Name()]})
+	}
+	return states
+}
+
 // Execute 执行插件链
 func (c *Chain) Execute(ctx *gin.Context) error {
+	disabled := c.disabledSet()
 	for _, p := range c.plugins {
+		if disabled[p.Name()] {
+			continue
+		}
+		if rule, exists := c.skips[p.Name()]; exists && rule.Matches(ctx) {
+			continue
+		}
+		var ttl time.Duration
+		if c.cache != nil && c.genKey != nil && cacheable(p) {
+			ttl = c.cache.TTL(p.Name())
+		}
+		useCache := ttl > 0
 		var cacheKey string
-		if c.cache != nil && c.genKey != nil {
+		if useCache {
 			cacheKey = c.genKey(ctx, p.Name())
 			if result, ok := c.cache.Get(cacheKey); ok {
 				// 命中缓存，回填 ctx
@@ -67,20 +157,55 @@ func (c *Chain) Execute(ctx *gin.Context) error {
 				continue
 			}
 		}
+		var before map[string]interface{}
+		if useCache {
+			before = snapshotKeys(ctx)
+		}
 		if err := p.Execute(ctx); err != nil {
 			return err
 		}
-		// 执行后写入缓存
-		if c.cache != nil && c.genKey != nil {
-			resultKey := "plugin_result_" + p.Name()
-			if val, exists := ctx.Get(resultKey); exists {
-				c.cache.Set(cacheKey, map[string]interface{}{resultKey: val})
+		// 插件已中止请求（如已写出拒绝响应），不再执行后续插件
+		if ctx.IsAborted() {
+			return nil
+		}
+		// 执行后写入缓存：插件写出 plugin_result_<插件名> 时，缓存插件写入的全部上下文键，命中时一并回填
+		if useCache {
+			if _, exists := ctx.Get("plugin_result_" + p.Name()); exists {
+				c.cache.Set(cacheKey, changedKeys(ctx, before), ttl)
 			}
 		}
 	}
 	return nil
 }
 
+// snapshotKeys 复制插件执行前的上下文键值
+func snapshotKeys(ctx *gin.Context) map[string]interface{} {
+	snapshot := make(map[string]interface{}, len(ctx.Keys))
+	for key, value := range ctx.Keys {
+		snapshot[key] = value
+	}
+	return snapshot
+}
+
+// changedKeys 返回插件执行后新增或修改的上下文键值
+func changedKeys(ctx *gin.Context, before map[string]interface{}) map[string]interface{} {
+	changed := make(map[string]interface{})
+	for key, value := range ctx.Keys {
+		if old, exists := before[key]; !exists || !reflect.DeepEqual(old, value) {
+			changed[key] = value
+		}
+	}
+	return changed
+}
+
+// cacheable 判断插件执行结果是否允许缓存
+func cacheable(p core.Plugin) bool {
+	if cp, ok := p.(core.CacheablePlugin); ok {
+		return cp.Cacheable()
+	}
+	return true
+}
+
 // Clear 清空插件链
 func (c *Chain) Clear() {
 	c.plugins = make([]core.Plugin, 0)
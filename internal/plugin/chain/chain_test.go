@@ -0,0 +1,166 @@
+package chain
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"gateway-go/internal/plugin/core"
+
+	"github.com/gin-gonic/gin"
+)
+
+// mapCache 不过期的测试缓存，按插件名返回缓存时间
+type mapCache struct {
+	data map[string]interface{}
+	ttls map[string]time.Duration
+}
+
+func newMapCache() *mapCache {
+	return &mapCache{data: make(map[string]interface{}), ttls: make(map[string]time.Duration)}
+}
+
+func (c *mapCache) Get(key string) (interface{}, bool) {
+	result, ok := c.data[key]
+	return result, ok
+}
+
+func (c *mapCache) Set(key string, result interface{}, ttl time.Duration) {
+	c.data[key] = result
+}
+
+func (c *mapCache) TTL(pluginName string) time.Duration {
+	if ttl, ok := c.ttls[pluginName]; ok {
+		return ttl
+	}
+	return time.Minute
+}
+
+// cacheKey 按插件名和 Authorization 请求头生成缓存键
+func cacheKey(ctx *gin.Context, pluginName string) string {
+	return pluginName + "|" + ctx.GetHeader("Authorization")
+}
+
+// newContext 创建携带 Authorization 请求头的测试上下文
+func newContext(token string) *gin.Context {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodGet, "/orders", nil)
+	c.Request.Header.Set("Authorization", token)
+	return c
+}
+
+// claimsPlugin 写出多个上下文键的测试插件
+type claimsPlugin struct {
+	*core.BasePlugin
+	calls int
+}
+
+func (p *claimsPlugin) Init(config interface{}) error { return nil }
+
+func (p *claimsPlugin) Execute(ctx *gin.Context) error {
+	p.calls++
+	ctx.Set("jwt_claims", map[string]interface{}{"sub": "alice"})
+	ctx.Set("consumer", "alice")
+	ctx.Set("trace", ctx.GetString("trace")+"+claims")
+	ctx.Set("plugin_result_"+p.Name(), true)
+	return nil
+}
+
+func TestChainCacheRestoresAllChangedKeys(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cache := newMapCache()
+	p := &claimsPlugin{BasePlugin: core.NewBasePlugin("claims", 1, nil)}
+	ch := NewChainWithCache(cache, cacheKey)
+	ch.AddPlugin(p)
+
+	run := func() *gin.Context {
+		c := newContext("Bearer t1")
+		c.Set("request_id", "r1")
+		c.Set("trace", "in")
+		if err := ch.Execute(c); err != nil {
+			t.Fatalf("执行插件链失败: %v", err)
+		}
+		return c
+	}
+	run()
+
+	// 缓存插件新增和修改的键，不缓存插件执行前已有且未修改的键
+	entry, _ := cache.Get(cacheKey(newContext("Bearer t1"), "claims"))
+	cached, _ := entry.(map[string]interface{})
+	for _, key := range []string{"jwt_claims", "consumer", "trace", "plugin_result_claims"} {
+		if _, ok := cached[key]; !ok {
+			t.Fatalf("缓存 = %v，缺少插件写入的键 %s", cached, key)
+		}
+	}
+	if _, ok := cached["request_id"]; ok {
+		t.Fatalf("缓存 = %v，不应包含插件未修改的键", cached)
+	}
+
+	// 命中缓存时跳过插件并回填全部键
+	c := run()
+	if p.calls != 1 {
+		t.Fatalf("插件执行 %d 次，期望第二次请求命中缓存", p.calls)
+	}
+	claims, _ := c.Get("jwt_claims")
+	if claims.(map[string]interface{})["sub"] != "alice" || c.GetString("consumer") != "alice" ||
+		c.GetString("trace") != "in+claims" || c.GetString("request_id") != "r1" {
+		t.Fatalf("命中缓存后的上下文 = %v", c.Keys)
+	}
+}
+
+// authPlugin 校验令牌是否被吊销的测试插件，cacheable 控制是否参与结果缓存
+type authPlugin struct {
+	*core.BasePlugin
+	revoked   map[string]bool
+	cacheable bool
+	calls     int
+}
+
+func (p *authPlugin) Init(config interface{}) error { return nil }
+
+func (p *authPlugin) Cacheable() bool { return p.cacheable }
+
+func (p *authPlugin) Execute(ctx *gin.Context) error {
+	p.calls++
+	if p.revoked[ctx.GetHeader("Authorization")] {
+		ctx.AbortWithStatus(http.StatusUnauthorized)
+		return nil
+	}
+	ctx.Set("plugin_result_"+p.Name(), "ok")
+	return nil
+}
+
+func TestChainSkipsCacheForOptedOutPlugin(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tests := []struct {
+		name      string
+		cacheable bool
+		wantCalls int
+		wantAbort bool
+	}{
+		// 未声明不缓存时，吊销后的令牌仍命中缓存的认证结果
+		{"允许缓存", true, 1, false},
+		{"声明不缓存", false, 2, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &authPlugin{BasePlugin: core.NewBasePlugin("auth", 1, nil), revoked: map[string]bool{}, cacheable: tt.cacheable}
+			ch := NewChainWithCache(newMapCache(), cacheKey)
+			ch.AddPlugin(p)
+
+			if err := ch.Execute(newContext("Bearer t1")); err != nil {
+				t.Fatalf("执行插件链失败: %v", err)
+			}
+			// 令牌失效后再次请求
+			p.revoked["Bearer t1"] = true
+			c := newContext("Bearer t1")
+			if err := ch.Execute(c); err != nil {
+				t.Fatalf("执行插件链失败: %v", err)
+			}
+			if p.calls != tt.wantCalls || c.IsAborted() != tt.wantAbort {
+				t.Fatalf("插件执行 %d 次，请求中止 = %v，期望 %d 次、%v", p.calls, c.IsAborted(), tt.wantCalls, tt.wantAbort)
+			}
+		})
+	}
+}
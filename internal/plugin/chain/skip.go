@@ -0,0 +1,63 @@
+package chain
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SkipRule 插件跳过规则，请求路径或方法匹配时插件链不执行该插件
+type SkipRule struct {
+	exactPaths   map[string]bool
+	pathPatterns []*regexp.Regexp
+	methods      map[string]bool
+}
+
+// NewSkipRule 创建跳过规则，路径支持 * 通配符（如 /api/health*），方法不区分大小写
+// 未配置任何路径和方法时返回 nil
+func NewSkipRule(paths, methods []string) (*SkipRule, error) {
+	if len(paths) == 0 && len(methods) == 0 {
+		return nil, nil
+	}
+
+	rule := &SkipRule{
+		exactPaths: make(map[string]bool),
+		methods:    make(map[string]bool),
+	}
+	for _, path := range paths {
+		if !strings.Contains(path, "*") {
+			rule.exactPaths[path] = true
+			continue
+		}
+		regexPattern := "^" + strings.ReplaceAll(regexp.QuoteMeta(path), `\*`, ".*") + "$"
+		regex, err := regexp.Compile(regexPattern)
+		if err != nil {
+			return nil, fmt.Errorf("无效的跳过路径: %s, %v", path, err)
+		}
+		rule.pathPatterns = append(rule.pathPatterns, regex)
+	}
+	for _, method := range methods {
+		rule.methods[strings.ToUpper(method)] = true
+	}
+	return rule, nil
+}
+
+// Matches 检查请求是否匹配跳过规则，路径或方法任一匹配即跳过
+func (r *SkipRule) Matches(ctx *gin.Context) bool {
+	if r.methods[ctx.Request.Method] {
+		return true
+	}
+
+	path := ctx.Request.URL.Path
+	if r.exactPaths[path] {
+		return true
+	}
+	for _, regex := range r.pathPatterns {
+		if regex.MatchString(path) {
+			return true
+		}
+	}
+	return false
+}
@@ -1,5 +1,7 @@
 package plugin
 
+import "time"
+
 // PluginConfig 插件配置
 type PluginConfig struct {
 	// 插件名称
@@ -10,4 +12,10 @@ type PluginConfig struct {
 	Order int `json:"order"`
 	// 插件配置
 	Config interface{} `json:"config"`
+	// 插件结果缓存时间，为 nil 时使用默认值，0 表示不缓存
+	CacheTTL *time.Duration `json:"cache_ttl"`
+	// 跳过该插件的请求路径，支持 * 通配符
+	SkipPaths []string `json:"skip_paths"`
+	// 跳过该插件的请求方法
+	SkipMethods []string `json:"skip_methods"`
 }
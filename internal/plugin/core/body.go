@@ -0,0 +1,37 @@
+package core
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+
+	"gateway-go/internal/pool"
+)
+
+// bodyBuffers 读取请求体使用的缓冲区池
+var bodyBuffers = pool.NewBufferPool()
+
+// ErrBodyTooLarge 请求体超过读取上限
+var ErrBodyTooLarge = fmt.Errorf("请求体过大")
+
+// ReadBody 读取完整请求体并还原，使代理仍能转发（重试时通过 GetBody 重放）
+// 请求体超过 limit 字节时返回 ErrBodyTooLarge
+func ReadBody(req *http.Request, limit int64) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+	body, err := bodyBuffers.ReadAll(io.LimitReader(req.Body, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(body)) > limit {
+		return nil, ErrBodyTooLarge
+	}
+
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(body)), nil
+	}
+	return body, nil
+}
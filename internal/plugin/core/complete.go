@@ -0,0 +1,31 @@
+package core
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+// completionKey 请求完成回调在上下文中的键
+const completionKey = "_plugin_completions"
+
+// OnComplete 注册请求处理结束后执行的回调，用于释放插件在 Execute 中占用的资源
+// 无论请求被转发、被后续插件中止还是转发失败，回调都会执行，按注册的相反顺序执行
+func OnComplete(ctx *gin.Context, fn func()) {
+	var completions []func()
+	if value, exists := ctx.Get(completionKey); exists {
+		completions, _ = value.([]func())
+	}
+	ctx.Set(completionKey, append(completions, fn))
+}
+
+// RunCompletions 执行已注册的请求完成回调，由网关在请求处理结束时调用
+func RunCompletions(ctx *gin.Context) {
+	value, exists := ctx.Get(completionKey)
+	if !exists {
+		return
+	}
+	completions, _ := value.([]func())
+	delete(ctx.Keys, completionKey)
+	for i := len(completions) - 1; i >= 0; i-- {
+		completions[i]()
+	}
+}
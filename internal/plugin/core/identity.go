@@ -0,0 +1,21 @@
+package core
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+// identityKey 认证插件校验通过的身份信息在上下文中的键
+const identityKey = "_verified_identity"
+
+// SetIdentity 记录认证插件校验通过的身份信息（如消费者名称、令牌声明）
+// 只能由完成凭证校验的认证插件调用，后续插件可信任其中的字段
+func SetIdentity(ctx *gin.Context, identity map[string]interface{}) {
+	ctx.Set(identityKey, identity)
+}
+
+// Identity 返回认证插件校验通过的身份信息，未经认证时返回 nil
+func Identity(ctx *gin.Context) map[string]interface{} {
+	identity, _ := ctx.Get(identityKey)
+	result, _ := identity.(map[string]interface{})
+	return result
+}
@@ -8,6 +8,8 @@ import (
 type Plugin interface {
 	// Name 返回插件名称
 	Name() string
+	// ValidateConfig 校验插件配置，在 Init 之前调用
+	ValidateConfig(config map[string]interface{}) error
 	// Init 初始化插件
 	Init(config interface{}) error
 	// Execute 执行插件
@@ -20,6 +22,16 @@ type Plugin interface {
 	GetDependencies() []string
 }
 
+// CacheablePlugin 可选接口，插件通过该接口声明执行结果是否允许缓存
+// 未实现该接口的插件默认允许缓存；认证类插件的结果依赖凭证的实时状态，应返回 false
+type CacheablePlugin interface {
+	Cacheable() bool
+}
+
+// Factory 插件工厂，每次调用返回一个未初始化的新插件实例
+// 通过工厂注册的插件支持按路由使用独立配置
+type Factory func() Plugin
+
 // BasePlugin 基础插件实现
 type BasePlugin struct {
 	name         string
@@ -46,6 +58,11 @@ func (p *BasePlugin) Order() int {
 	return p.order
 }
 
+// ValidateConfig 校验插件配置，默认不校验
+func (p *BasePlugin) ValidateConfig(config map[string]interface{}) error {
+	return nil
+}
+
 // Stop 停止插件
 func (p *BasePlugin) Stop() error {
 	return nil
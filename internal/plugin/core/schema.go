@@ -0,0 +1,167 @@
+package core
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FieldType 插件配置字段类型
+type FieldType string
+
+const (
+	// FieldString 字符串
+	FieldString FieldType = "string"
+	// FieldBool 布尔值
+	FieldBool FieldType = "bool"
+	// FieldInt 整数（YAML 解析为 int，JSON 解析为整数值的 float64）
+	FieldInt FieldType = "int"
+	// FieldNumber 数字，整数或小数
+	FieldNumber FieldType = "number"
+	// FieldDuration 时长字符串（如 "5s"）或整数
+	FieldDuration FieldType = "duration"
+	// FieldStringList 字符串数组
+	FieldStringList FieldType = "string_list"
+	// FieldList 任意数组
+	FieldList FieldType = "list"
+	// FieldObject 对象
+	FieldObject FieldType = "object"
+)
+
+// ConfigSchema 插件配置结构声明，键为字段名
+type ConfigSchema map[string]FieldType
+
+// Validate 校验配置，拒绝未声明的字段和类型不匹配的值
+func (s ConfigSchema) Validate(config map[string]interface{}) error {
+	// 按字段名排序，保证错误信息稳定
+	keys := make([]string, 0, len(config))
+	for key := range config {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		fieldType, declared := s[key]
+		if !declared {
+			return fmt.Errorf("未知的配置项: %s", key)
+		}
+		if value := config[key]; value != nil && !matchFieldType(fieldType, value) {
+			return fmt.Errorf("配置项 %s 类型错误，期望 %s，实际为 %T", key, fieldType, value)
+		}
+	}
+	return nil
+}
+
+// matchFieldType 检查值是否符合字段类型
+func matchFieldType(fieldType FieldType, value interface{}) bool {
+	switch fieldType {
+	case FieldString:
+		_, ok := value.(string)
+		return ok
+	case FieldBool:
+		_, ok := value.(bool)
+		return ok
+	case FieldInt:
+		_, ok := ToInt(value)
+		return ok
+	case FieldNumber:
+		_, ok := ToFloat64(value)
+		return ok
+	case FieldDuration:
+		if s, ok := value.(string); ok {
+			if _, err := time.ParseDuration(s); err == nil {
+				return true
+			}
+			_, err := strconv.Atoi(s)
+			return err == nil
+		}
+		_, ok := ToInt(value)
+		return ok
+	case FieldStringList:
+		switch list := value.(type) {
+		case []string:
+			return true
+		case []interface{}:
+			for _, item := range list {
+				if _, ok := item.(string); !ok {
+					return false
+				}
+			}
+			return true
+		}
+		return false
+	case FieldList:
+		switch value.(type) {
+		case []interface{}, []string:
+			return true
+		}
+		return false
+	case FieldObject:
+		_, ok := value.(map[string]interface{})
+		return ok
+	}
+	return false
+}
+
+// ToInt 将配置值转换为整数，兼容 YAML/JSON 解析出的各种数值类型和数字字符串，小数返回 false
+func ToInt(value interface{}) (int, bool) {
+	switch v := value.(type) {
+	case int:
+		return v, true
+	case int8:
+		return int(v), true
+	case int16:
+		return int(v), true
+	case int32:
+		return int(v), true
+	case int64:
+		return int(v), true
+	case uint:
+		return int(v), true
+	case uint8:
+		return int(v), true
+	case uint16:
+		return int(v), true
+	case uint32:
+		return int(v), true
+	case uint64:
+		return int(v), true
+	case float32:
+		return ToInt(float64(v))
+	case float64:
+		if v != math.Trunc(v) {
+			return 0, false
+		}
+		return int(v), true
+	case string:
+		n, err := strconv.Atoi(strings.TrimSpace(v))
+		if err != nil {
+			return 0, false
+		}
+		return n, true
+	}
+	return 0, false
+}
+
+// ToFloat64 将配置值转换为浮点数，兼容各种数值类型和数字字符串
+func ToFloat64(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		if err != nil {
+			return 0, false
+		}
+		return f, true
+	}
+	if n, ok := ToInt(value); ok {
+		return float64(n), true
+	}
+	return 0, false
+}
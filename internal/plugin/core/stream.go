@@ -0,0 +1,19 @@
+package core
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+// streamingKey 流式响应标记在上下文中的键
+const streamingKey = "_streaming_response"
+
+// MarkStreaming 标记当前响应为流式响应（如 SSE），由网关在收到上游响应头时调用
+func MarkStreaming(ctx *gin.Context) {
+	ctx.Set(streamingKey, true)
+}
+
+// IsStreaming 返回当前响应是否为流式响应
+// 替换 ctx.Writer 的插件应据此跳过响应体缓存，流式响应的每次写入都会立即发送给客户端
+func IsStreaming(ctx *gin.Context) bool {
+	return ctx.GetBool(streamingKey)
+}
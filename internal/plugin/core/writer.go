@@ -0,0 +1,54 @@
+package core
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// StatusWriter 只捕获响应状态码的写入器，不缓存响应体
+// 响应体直接写入被包装的写入器，大响应和流式响应不会占用额外内存，Flush 等方法由被包装的写入器提供
+type StatusWriter struct {
+	gin.ResponseWriter
+	// onStatus 首次写入状态码时调用，返回实际写出的状态码
+	onStatus      func(code int) int
+	statusWritten bool
+}
+
+// WrapWriter 用 StatusWriter 替换 ctx.Writer
+// onStatus 在首次写入状态码时调用一次（未显式写入状态码时为 200），可返回其他状态码替换上游的状态码
+func WrapWriter(ctx *gin.Context, onStatus func(code int) int) *StatusWriter {
+	w := &StatusWriter{ResponseWriter: ctx.Writer, onStatus: onStatus}
+	ctx.Writer = w
+	return w
+}
+
+// WriteHeader 写入状态码
+func (w *StatusWriter) WriteHeader(code int) {
+	if !w.statusWritten {
+		w.statusWritten = true
+		code = w.onStatus(code)
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+// Write 写入响应体
+func (w *StatusWriter) Write(data []byte) (int, error) {
+	if !w.statusWritten {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+// WriteString 写入字符串响应体
+func (w *StatusWriter) WriteString(s string) (int, error) {
+	if !w.statusWritten {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.ResponseWriter.WriteString(s)
+}
+
+// Unwrap 返回被包装的写入器，供 http.ResponseController 访问底层连接
+func (w *StatusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,93 @@
+package core
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// discardWriter 丢弃响应体的 http.ResponseWriter，只统计写入的字节数
+type discardWriter struct {
+	header  http.Header
+	status  int
+	written int64
+	flushes int
+}
+
+func (w *discardWriter) Header() http.Header         { return w.header }
+func (w *discardWriter) WriteHeader(code int)        { w.status = code }
+func (w *discardWriter) Write(p []byte) (int, error) { w.written += int64(len(p)); return len(p), nil }
+func (w *discardWriter) Flush()                      { w.flushes++ }
+
+func TestStatusWriterReplacesStatusOnce(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	rec := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(rec)
+
+	var seen []int
+	WrapWriter(ctx, func(code int) int {
+		seen = append(seen, code)
+		return http.StatusServiceUnavailable
+	})
+	ctx.Writer.WriteHeader(http.StatusOK)
+	ctx.Writer.WriteString("body")
+	ctx.Writer.WriteString("")
+
+	if len(seen) != 1 || seen[0] != http.StatusOK {
+		t.Fatalf("onStatus 调用 = %v，期望只以 200 调用一次", seen)
+	}
+	if rec.Code != http.StatusServiceUnavailable || rec.Body.String() != "body" {
+		t.Fatalf("响应 = %d %q，期望 503 body", rec.Code, rec.Body.String())
+	}
+
+	// 未显式写入状态码时按 200 调用
+	ctx, _ = gin.CreateTestContext(httptest.NewRecorder())
+	seen = nil
+	WrapWriter(ctx, func(code int) int {
+		seen = append(seen, code)
+		return code
+	})
+	ctx.Writer.Write([]byte("a"))
+	ctx.Writer.Write([]byte("b"))
+	if len(seen) != 1 || seen[0] != http.StatusOK {
+		t.Fatalf("隐式写入状态码时 onStatus 调用 = %v，期望 [200]", seen)
+	}
+}
+
+func TestStatusWriterDoesNotBufferLargeResponses(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	sink := &discardWriter{header: http.Header{}}
+	ctx, _ := gin.CreateTestContext(sink)
+	// 两层包装，模拟多个插件同时替换写入器
+	WrapWriter(ctx, func(code int) int { return code })
+	WrapWriter(ctx, func(code int) int { return code })
+
+	const total = 64 << 20
+	chunk := bytes.Repeat([]byte("x"), 32<<10)
+
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	for written := 0; written < total; written += len(chunk) {
+		if _, err := ctx.Writer.Write(chunk); err != nil {
+			t.Fatalf("写入响应体失败: %v", err)
+		}
+		ctx.Writer.Flush()
+	}
+	runtime.ReadMemStats(&after)
+
+	if sink.written != total || sink.status != http.StatusOK {
+		t.Fatalf("底层写入器收到 %d 字节，状态码 %d", sink.written, sink.status)
+	}
+	if sink.flushes != total/len(chunk) {
+		t.Fatalf("Flush 调用 %d 次，期望每块 1 次", sink.flushes)
+	}
+	// 写入 64MB 响应体期间的内存分配应与响应大小无关
+	if allocated := after.TotalAlloc - before.TotalAlloc; allocated > 1<<20 {
+		t.Fatalf("写入 %d 字节响应时分配了 %d 字节内存，响应体被缓冲", total, allocated)
+	}
+}
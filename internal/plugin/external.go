@@ -0,0 +1,52 @@
+package plugin
+
+import (
+	"fmt"
+	goplugin "plugin"
+
+	"gateway-go/internal/plugin/core"
+)
+
+// ExternalSymbol 外部插件导出的工厂函数名
+const ExternalSymbol = "New"
+
+// LoadExternal 打开 Go 插件（.so）并返回其导出的插件工厂
+// 外部插件需导出 func New() core.Plugin，且与网关使用相同的 Go 版本和依赖版本编译
+func LoadExternal(path string) (core.Factory, error) {
+	p, err := goplugin.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("打开外部插件失败: %w", err)
+	}
+	symbol, err := p.Lookup(ExternalSymbol)
+	if err != nil {
+		return nil, fmt.Errorf("外部插件未导出 %s: %w", ExternalSymbol, err)
+	}
+
+	// 导出函数时为函数值，导出变量时为变量指针
+	switch factory := symbol.(type) {
+	case func() core.Plugin:
+		return factory, nil
+	case *func() core.Plugin:
+		return *factory, nil
+	case *core.Factory:
+		return *factory, nil
+	default:
+		return nil, fmt.Errorf("外部插件的 %s 类型为 %T，期望 func() core.Plugin", ExternalSymbol, symbol)
+	}
+}
+
+// RegisterExternal 加载外部插件并通过工厂注册，返回插件名称
+// 外部插件与内置插件一样支持路由级配置
+func (m *Manager) RegisterExternal(path string) (string, error) {
+	factory, err := LoadExternal(path)
+	if err != nil {
+		return "", err
+	}
+	if p := factory(); p == nil {
+		return "", fmt.Errorf("外部插件的 %s 返回了空插件", ExternalSymbol)
+	}
+	if err := m.RegisterFactory(factory); err != nil {
+		return "", err
+	}
+	return factory().Name(), nil
+}
@@ -0,0 +1,96 @@
+package plugin
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// buildExternalPlugin 将 testdata 下的示例插件编译为 Go 插件，返回 .so 文件路径
+func buildExternalPlugin(t *testing.T, name string) string {
+	t.Helper()
+	if testing.Short() {
+		t.Skip("short 模式下跳过编译外部插件")
+	}
+	switch runtime.GOOS {
+	case "linux", "darwin", "freebsd":
+	default:
+		t.Skipf("%s 不支持 Go 插件", runtime.GOOS)
+	}
+	if out, err := exec.Command("go", "env", "CGO_ENABLED").Output(); err != nil || strings.TrimSpace(string(out)) != "1" {
+		t.Skip("Go 插件需要启用 cgo")
+	}
+
+	output := filepath.Join(t.TempDir(), name+".so")
+	args := []string{"build", "-buildmode=plugin", "-o", output}
+	if raceEnabled {
+		args = append(args, "-race")
+	}
+	cmd := exec.Command("go", append(args, "./testdata/"+name)...)
+	cmd.Env = os.Environ()
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("编译外部插件失败: %v\n%s", err, out)
+	}
+	return output
+}
+
+func TestRegisterExternalPlugin(t *testing.T) {
+	path := buildExternalPlugin(t, "hello")
+
+	m := NewManager()
+	name, err := m.RegisterExternal(path)
+	if err != nil {
+		t.Fatalf("加载外部插件失败: %v", err)
+	}
+	if name != "hello" {
+		t.Fatalf("外部插件名称 = %q，期望 hello", name)
+	}
+
+	// 外部插件与内置插件一样按配置初始化，并支持路由级配置覆盖
+	if err := m.LoadAvailablePlugins([]PluginConfig{{Name: "hello", Enabled: true, Config: map[string]interface{}{"value": "gateway"}}}); err != nil {
+		t.Fatalf("初始化外部插件失败: %v", err)
+	}
+	if err := m.LoadRoutePlugins("api", []string{"hello"}, map[string]map[string]interface{}{"hello": {"value": "route"}}, false); err != nil {
+		t.Fatalf("加载路由插件失败: %v", err)
+	}
+	if err := m.LoadRoutePlugins("web", []string{"hello"}, nil, false); err != nil {
+		t.Fatalf("加载路由插件失败: %v", err)
+	}
+	defer m.Stop()
+
+	gin.SetMode(gin.TestMode)
+	for route, want := range map[string]string{"api": "route", "web": "gateway"} {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+		if err := m.Execute(c, route); err != nil {
+			t.Fatalf("执行路由 %s 的插件失败: %v", route, err)
+		}
+		if got := c.Request.Header.Get("X-Hello"); got != want {
+			t.Fatalf("路由 %s 的 X-Hello = %q，期望 %q", route, got, want)
+		}
+	}
+
+	// 同名插件不能重复注册
+	if _, err := m.RegisterExternal(path); err == nil {
+		t.Fatal("重复注册外部插件应失败")
+	}
+}
+
+func TestLoadExternalErrors(t *testing.T) {
+	if _, err := LoadExternal(filepath.Join(t.TempDir(), "missing.so")); err == nil || !strings.Contains(err.Error(), "打开外部插件失败") {
+		t.Fatalf("加载不存在的外部插件返回 %v", err)
+	}
+
+	invalid := filepath.Join(t.TempDir(), "invalid.so")
+	os.WriteFile(invalid, []byte("not a plugin"), 0o600)
+	if _, err := LoadExternal(invalid); err == nil {
+		t.Fatal("加载格式错误的外部插件应失败")
+	}
+}
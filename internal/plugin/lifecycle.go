@@ -2,6 +2,7 @@ package plugin
 
 import (
 	"fmt"
+	"strings"
 	"sync"
 	"time"
 
@@ -19,6 +20,24 @@ const (
 	StateFailed
 )
 
+// String 返回状态名称
+func (s PluginState) String() string {
+	switch s {
+	case StateStopped:
+		return "stopped"
+	case StateStarting:
+		return "starting"
+	case StateRunning:
+		return "running"
+	case StateStopping:
+		return "stopping"
+	case StateFailed:
+		return "failed"
+	default:
+		return "unknown"
+	}
+}
+
 // PluginInfo 插件信息
 type PluginInfo struct {
 	Plugin       core.Plugin
@@ -35,6 +54,8 @@ type LifecycleManager struct {
 	mu        sync.RWMutex
 	stateChan chan *PluginStateChange
 	stopChan  chan struct{}
+	// 通过 Subscribe 订阅状态变更的通道
+	subscribers map[chan *PluginStateChange]struct{}
 }
 
 // PluginStateChange 插件状态变更
@@ -49,9 +70,10 @@ type PluginStateChange struct {
 // NewLifecycleManager 创建生命周期管理器
 func NewLifecycleManager() *LifecycleManager {
 	return &LifecycleManager{
-		plugins:   make(map[string]*PluginInfo),
-		stateChan: make(chan *PluginStateChange, 100),
-		stopChan:  make(chan struct{}),
+		plugins:     make(map[string]*PluginInfo),
+		stateChan:   make(chan *PluginStateChange, 100),
+		stopChan:    make(chan struct{}),
+		subscribers: make(map[chan *PluginStateChange]struct{}),
 	}
 }
 
@@ -72,6 +94,12 @@ func (m *LifecycleManager) Register(p core.Plugin, config interface{}, dependenc
 		Config:       config,
 	}
 
+	// 新插件与已注册插件构成循环依赖时拒绝注册
+	if err := m.detectCycle(name); err != nil {
+		delete(m.plugins, name)
+		return err
+	}
+
 	return nil
 }
 
@@ -89,6 +117,11 @@ func (m *LifecycleManager) Start(name string) error {
 		return fmt.Errorf("插件 %s 状态错误: %v", name, info.State)
 	}
 
+	if err := m.detectCycle(name); err != nil {
+		m.mu.Unlock()
+		return err
+	}
+
 	// 检查依赖
 	for _, dep := range info.Dependencies {
 		depInfo, exists := m.plugins[dep]
@@ -205,19 +238,146 @@ func (m *LifecycleManager) GetPlugin(name string) (core.Plugin, error) {
 	return info.Plugin, nil
 }
 
-// ListPlugins 列出所有插件
+// ListPlugins 列出所有插件，返回的插件信息为副本
 func (m *LifecycleManager) ListPlugins() map[string]*PluginInfo {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
 	plugins := make(map[string]*PluginInfo)
 	for name, info := range m.plugins {
-		plugins[name] = info
+		snapshot := *info
+		plugins[name] = &snapshot
 	}
 
 	return plugins
 }
 
+// StartOrder 按依赖关系对插件排序，被依赖的插件排在前面，其余保持传入顺序
+// 依赖的插件不在 names 中时返回错误，存在循环依赖时返回包含循环路径的错误
+func (m *LifecycleManager) StartOrder(names []string) ([]string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	included := make(map[string]bool, len(names))
+	for _, name := range names {
+		included[name] = true
+	}
+
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	marks := make(map[string]int, len(names))
+	order := make([]string, 0, len(names))
+	var path []string
+
+	var visit func(name string) error
+	visit = func(name string) error {
+		switch marks[name] {
+		case visited:
+			return nil
+		case visiting:
+			return dependencyCycleError(path, name)
+		}
+
+		marks[name] = visiting
+		path = append(path, name)
+		if info, exists := m.plugins[name]; exists {
+			for _, dep := range info.Dependencies {
+				if !included[dep] {
+					return fmt.Errorf("插件 %s 依赖的插件 %s 未启用", name, dep)
+				}
+				if err := visit(dep); err != nil {
+					return err
+				}
+			}
+		}
+		path = path[:len(path)-1]
+		marks[name] = visited
+		order = append(order, name)
+		return nil
+	}
+
+	for _, name := range names {
+		if err := visit(name); err != nil {
+			return nil, err
+		}
+	}
+	return order, nil
+}
+
+// detectCycle 从指定插件出发沿依赖关系查找循环依赖，未注册的依赖忽略，调用方需持有锁
+func (m *LifecycleManager) detectCycle(name string) error {
+	visited := make(map[string]bool)
+	onPath := make(map[string]bool)
+	var path []string
+
+	var visit func(name string) error
+	visit = func(name string) error {
+		if onPath[name] {
+			return dependencyCycleError(path, name)
+		}
+		if visited[name] {
+			return nil
+		}
+		info, exists := m.plugins[name]
+		if !exists {
+			return nil
+		}
+
+		onPath[name] = true
+		path = append(path, name)
+		for _, dep := range info.Dependencies {
+			if err := visit(dep); err != nil {
+				return err
+			}
+		}
+		path = path[:len(path)-1]
+		onPath[name] = false
+		visited[name] = true
+		return nil
+	}
+
+	return visit(name)
+}
+
+// dependencyCycleError 根据当前依赖路径生成循环依赖错误，如 a -> b -> a
+func dependencyCycleError(path []string, name string) error {
+	start := 0
+	for i, n := range path {
+		if n == name {
+			start = i
+			break
+		}
+	}
+	cycle := append(append([]string(nil), path[start:]...), name)
+	return fmt.Errorf("插件存在循环依赖: %s", strings.Join(cycle, " -> "))
+}
+
+// failDependents 将 remaining 中直接或间接依赖 failed 的插件标记为失败
+// remaining 需按 StartOrder 的顺序排列
+func (m *LifecycleManager) failDependents(failed string, remaining []string) {
+	failedSet := map[string]bool{failed: true}
+	for _, name := range remaining {
+		m.mu.RLock()
+		info, exists := m.plugins[name]
+		var deps []string
+		if exists {
+			deps = info.Dependencies
+		}
+		m.mu.RUnlock()
+
+		for _, dep := range deps {
+			if failedSet[dep] {
+				failedSet[name] = true
+				m.updateState(name, StateFailed, fmt.Errorf("依赖的插件 %s 启动失败", dep))
+				break
+			}
+		}
+	}
+}
+
 // updateState 更新插件状态
 func (m *LifecycleManager) updateState(name string, state PluginState, err error) {
 	m.mu.Lock()
@@ -236,17 +396,45 @@ func (m *LifecycleManager) updateState(name string, state PluginState, err error
 	m.mu.Unlock()
 
 	// 发送状态变更通知
-	select {
-	case m.stateChan <- &PluginStateChange{
+	change := &PluginStateChange{
 		Name:      name,
 		OldState:  oldState,
 		NewState:  state,
 		Error:     err,
 		Timestamp: time.Now(),
-	}:
+	}
+	select {
+	case m.stateChan <- change:
 	default:
 		// 通道已满，丢弃通知
 	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for ch := range m.subscribers {
+		select {
+		case ch <- change:
+		default:
+			// 订阅者处理过慢，丢弃通知
+		}
+	}
+}
+
+// Subscribe 订阅插件状态变更，多个订阅者互不影响；返回的函数用于取消订阅并关闭通道
+func (m *LifecycleManager) Subscribe() (<-chan *PluginStateChange, func()) {
+	ch := make(chan *PluginStateChange, 16)
+	m.mu.Lock()
+	m.subscribers[ch] = struct{}{}
+	m.mu.Unlock()
+
+	return ch, func() {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		if _, exists := m.subscribers[ch]; exists {
+			delete(m.subscribers, ch)
+			close(ch)
+		}
+	}
 }
 
 // WatchState 监听插件状态变更
@@ -258,4 +446,11 @@ func (m *LifecycleManager) WatchState() <-chan *PluginStateChange {
 func (m *LifecycleManager) Close() {
 	close(m.stopChan)
 	close(m.stateChan)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for ch := range m.subscribers {
+		delete(m.subscribers, ch)
+		close(ch)
+	}
 }
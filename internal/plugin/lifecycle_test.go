@@ -0,0 +1,103 @@
+package plugin
+
+import (
+	"strings"
+	"testing"
+)
+
+// registerAll 依次注册插件，deps 为插件名到依赖的映射，返回第一个注册失败的错误
+func registerAll(m *LifecycleManager, names []string, deps map[string][]string) error {
+	var inits []string
+	for i, name := range names {
+		if err := m.Register(newTestPlugin(name, i+1, &inits), nil, deps[name]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestLifecycleRegisterDetectsCycle(t *testing.T) {
+	tests := []struct {
+		name  string
+		names []string
+		deps  map[string][]string
+		cycle string
+	}{
+		{
+			name:  "自身依赖",
+			names: []string{"a"},
+			deps:  map[string][]string{"a": {"a"}},
+			cycle: "a -> a",
+		},
+		{
+			name:  "两个插件",
+			names: []string{"a", "b"},
+			deps:  map[string][]string{"a": {"b"}, "b": {"a"}},
+			cycle: "b -> a -> b",
+		},
+		{
+			name:  "三个插件",
+			names: []string{"a", "b", "c"},
+			deps:  map[string][]string{"a": {"b"}, "b": {"c"}, "c": {"a"}},
+			cycle: "c -> a -> b -> c",
+		},
+		{
+			name:  "循环不包含起点",
+			names: []string{"x", "a", "b"},
+			deps:  map[string][]string{"x": {"a"}, "a": {"b"}, "b": {"a"}},
+			cycle: "b -> a -> b",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewLifecycleManager()
+			err := registerAll(m, tt.names, tt.deps)
+			if err == nil || err.Error() != "插件存在循环依赖: "+tt.cycle {
+				t.Fatalf("注册返回 %v，期望循环依赖 %s", err, tt.cycle)
+			}
+			// 构成循环的插件不注册，其余插件不受影响
+			last := tt.names[len(tt.names)-1]
+			if _, err := m.GetState(last); err == nil {
+				t.Fatalf("构成循环依赖的插件 %s 不应注册", last)
+			}
+			if got := len(m.ListPlugins()); got != len(tt.names)-1 {
+				t.Fatalf("已注册 %d 个插件，期望 %d", got, len(tt.names)-1)
+			}
+		})
+	}
+}
+
+func TestLifecycleStartOrderDAG(t *testing.T) {
+	// api 依赖 auth 和 cache，auth 和 cache 都依赖 store
+	m := NewLifecycleManager()
+	deps := map[string][]string{
+		"api":   {"auth", "cache"},
+		"auth":  {"store"},
+		"cache": {"store"},
+	}
+	if err := registerAll(m, []string{"store", "cache", "auth", "api", "log"}, deps); err != nil {
+		t.Fatalf("注册无循环的依赖关系失败: %v", err)
+	}
+
+	order, err := m.StartOrder([]string{"log", "api", "auth", "cache", "store"})
+	if err != nil {
+		t.Fatalf("计算启动顺序失败: %v", err)
+	}
+	if got := strings.Join(order, ","); got != "log,store,auth,cache,api" {
+		t.Fatalf("启动顺序 = %s，期望 log,store,auth,cache,api", got)
+	}
+
+	// 每个插件都排在它依赖的插件之后
+	position := make(map[string]int, len(order))
+	for i, name := range order {
+		position[name] = i
+	}
+	for name, list := range deps {
+		for _, dep := range list {
+			if position[dep] > position[name] {
+				t.Fatalf("插件 %s 排在依赖的 %s 之前: %v", name, dep, order)
+			}
+		}
+	}
+}
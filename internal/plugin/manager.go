@@ -20,9 +20,19 @@ type Manager struct {
 	routeChains map[string]*chain.Chain
 	// 插件注册表（保持向后兼容）
 	registry map[string]core.Plugin
-	mu       sync.RWMutex
+	// 插件工厂，用于创建路由级配置的独立插件实例
+	factories map[string]core.Factory
+	// 可用插件的全局配置，路由级配置在此基础上覆盖
+	availableConfigs map[string]map[string]interface{}
+	// 按路由创建的独立插件实例，路由插件链替换或删除时停止
+	routeInstances map[string][]core.Plugin
+	// 按插件名配置的跳过规则，加载路由插件链时应用
+	skipRules map[string]*chain.SkipRule
+	mu        sync.RWMutex
 
 	pluginCache *PluginCache // 插件结果缓存
+	// 插件生命周期状态
+	lifecycle *LifecycleManager
 }
 
 // NewManager 创建插件管理器
@@ -31,7 +41,12 @@ func NewManager() *Manager {
 		availablePlugins: make(map[string]core.Plugin),
 		routeChains:      make(map[string]*chain.Chain),
 		registry:         make(map[string]core.Plugin),
+		factories:        make(map[string]core.Factory),
+		availableConfigs: make(map[string]map[string]interface{}),
+		routeInstances:   make(map[string][]core.Plugin),
+		skipRules:        make(map[string]*chain.SkipRule),
 		pluginCache:      NewPluginCache(10 * time.Second), // 默认10秒，可调整
+		lifecycle:        NewLifecycleManager(),
 	}
 }
 
@@ -45,10 +60,31 @@ func (m *Manager) Register(p core.Plugin) error {
 		return fmt.Errorf("插件 %s 已注册", name)
 	}
 
+	if err := m.lifecycle.Register(p, nil, p.GetDependencies()); err != nil {
+		return err
+	}
 	m.registry[name] = p
 	return nil
 }
 
+// Lifecycle 返回插件生命周期管理器，记录各插件的加载状态和最近一次错误
+func (m *Manager) Lifecycle() *LifecycleManager {
+	return m.lifecycle
+}
+
+// RegisterFactory 通过工厂注册插件，注册后的插件支持路由级配置
+func (m *Manager) RegisterFactory(factory core.Factory) error {
+	p := factory()
+	if err := m.Register(p); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.factories[p.Name()] = factory
+	return nil
+}
+
 // RegisterAvailablePlugin 注册可用插件
 func (m *Manager) RegisterAvailablePlugin(name string, p core.Plugin) error {
 	m.mu.Lock()
@@ -72,49 +108,212 @@ func (m *Manager) LoadAvailablePlugins(configs []PluginConfig) error {
 		return configs[i].Order < configs[j].Order
 	})
 
-	// 加载可用插件
+	// 收集启用的插件，未启用的插件记为停止状态
+	enabled := make(map[string]PluginConfig)
+	var names []string
 	for _, cfg := range configs {
 		if !cfg.Enabled {
+			if _, exists := m.registry[cfg.Name]; exists {
+				m.lifecycle.updateState(cfg.Name, StateStopped, nil)
+			}
 			continue
 		}
-
-		p, exists := m.registry[cfg.Name]
-		if !exists {
+		if _, exists := m.registry[cfg.Name]; !exists {
 			return fmt.Errorf("插件 %s 未注册", cfg.Name)
 		}
+		if _, exists := enabled[cfg.Name]; !exists {
+			names = append(names, cfg.Name)
+		}
+		enabled[cfg.Name] = cfg
+	}
+
+	// 按依赖关系确定加载顺序，被依赖的插件先加载
+	order, err := m.lifecycle.StartOrder(names)
+	if err != nil {
+		return err
+	}
 
-		if err := p.Init(cfg.Config); err != nil {
-			return fmt.Errorf("初始化插件 %s 失败: %v", cfg.Name, err)
+	// 加载可用插件
+	pluginTTLs := make(map[string]time.Duration)
+	skipRules := make(map[string]*chain.SkipRule)
+	for i, name := range order {
+		cfg := enabled[name]
+		p := m.registry[name]
+		m.lifecycle.updateState(name, StateStarting, nil)
+
+		skipRule, err := initPlugin(p, cfg)
+		if err != nil {
+			m.lifecycle.updateState(name, StateFailed, err)
+			// 依赖该插件的插件不再启动
+			m.lifecycle.failDependents(name, order[i+1:])
+			return err
 		}
+		m.lifecycle.updateState(name, StateRunning, nil)
 
 		// 注册为可用插件
-		m.availablePlugins[cfg.Name] = p
+		configMap, _ := cfg.Config.(map[string]interface{})
+		m.availablePlugins[name] = p
+		m.availableConfigs[name] = configMap
+		if cfg.CacheTTL != nil {
+			pluginTTLs[name] = *cfg.CacheTTL
+		}
+		if skipRule != nil {
+			skipRules[name] = skipRule
+		}
 	}
 
+	m.pluginCache.SetPluginTTLs(pluginTTLs)
+	m.skipRules = skipRules
 	return nil
 }
 
+// initPlugin 校验插件配置并初始化插件，返回插件的跳过规则
+func initPlugin(p core.Plugin, cfg PluginConfig) (*chain.SkipRule, error) {
+	configMap, _ := cfg.Config.(map[string]interface{})
+	if err := p.ValidateConfig(configMap); err != nil {
+		return nil, fmt.Errorf("插件 %s 配置无效: %v", cfg.Name, err)
+	}
+
+	skipRule, err := chain.NewSkipRule(cfg.SkipPaths, cfg.SkipMethods)
+	if err != nil {
+		return nil, fmt.Errorf("插件 %s 配置无效: %v", cfg.Name, err)
+	}
+
+	if err := p.Init(cfg.Config); err != nil {
+		return nil, fmt.Errorf("初始化插件 %s 失败: %v", cfg.Name, err)
+	}
+	return skipRule, nil
+}
+
 // LoadRoutePlugins 加载路由插件
-func (m *Manager) LoadRoutePlugins(routeName string, pluginNames []string) error {
+// overrides 为路由级插件配置，配置了的插件会创建该路由独享的实例，未配置的插件共享全局实例
+// keepOrder 为 true 时按 pluginNames 的顺序执行插件，否则按插件的 Order() 排序
+func (m *Manager) LoadRoutePlugins(routeName string, pluginNames []string, overrides map[string]map[string]interface{}, keepOrder bool) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
 	ch := chain.NewChainWithCache(m.pluginCache, GenerateCacheKey)
+	var instances []core.Plugin
 
 	// 根据插件名称加载路由插件
 	for _, pluginName := range pluginNames {
 		p, exists := m.availablePlugins[pluginName]
 		if !exists {
+			stopPlugins(instances)
 			return fmt.Errorf("路由 %s 使用的插件 %s 不可用", routeName, pluginName)
 		}
 
-		ch.AddPlugin(p)
+		if override, ok := overrides[pluginName]; ok {
+			instance, err := m.newRouteInstance(pluginName, override)
+			if err != nil {
+				stopPlugins(instances)
+				return fmt.Errorf("路由 %s 的插件 %s: %v", routeName, pluginName, err)
+			}
+			instances = append(instances, instance)
+			p = instance
+		}
+
+		if keepOrder {
+			ch.AppendPlugin(p)
+		} else {
+			ch.AddPlugin(p)
+		}
+		ch.SetSkipRule(pluginName, m.skipRules[pluginName])
 	}
 
 	m.routeChains[routeName] = ch
+	stopPlugins(m.routeInstances[routeName])
+	m.routeInstances[routeName] = instances
 	return nil
 }
 
+// newRouteInstance 使用全局配置合并路由级配置创建独立插件实例
+func (m *Manager) newRouteInstance(pluginName string, override map[string]interface{}) (core.Plugin, error) {
+	factory, exists := m.factories[pluginName]
+	if !exists {
+		return nil, fmt.Errorf("插件不支持路由级配置")
+	}
+
+	merged := make(map[string]interface{}, len(m.availableConfigs[pluginName])+len(override))
+	for key, value := range m.availableConfigs[pluginName] {
+		merged[key] = value
+	}
+	for key, value := range override {
+		merged[key] = value
+	}
+
+	p := factory()
+	if err := p.ValidateConfig(merged); err != nil {
+		return nil, fmt.Errorf("配置无效: %v", err)
+	}
+	if err := p.Init(merged); err != nil {
+		return nil, fmt.Errorf("初始化失败: %v", err)
+	}
+	return p, nil
+}
+
+// RemoveRoutePlugins 删除路由插件链
+func (m *Manager) RemoveRoutePlugins(routeName string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.routeChains, routeName)
+	stopPlugins(m.routeInstances[routeName])
+	delete(m.routeInstances, routeName)
+}
+
+// SetRoutePluginEnabled 启用或禁用路由插件链中的插件，禁用的插件在该路由的请求中跳过
+// 状态只保存在当前插件链中，重新加载路由插件（配置重载、更新路由）后恢复为配置的状态
+// 插件链原子替换禁用集合，只需读锁，不等待进行中的请求执行完插件链
+func (m *Manager) SetRoutePluginEnabled(routeName, pluginName string, enabled bool) error {
+	m.mu.RLock()
+	ch, exists := m.routeChains[routeName]
+	m.mu.RUnlock()
+	if !exists {
+		return fmt.Errorf("路由 %s 未加载插件", routeName)
+	}
+	if !ch.SetEnabled(pluginName, enabled) {
+		return fmt.Errorf("路由 %s 未使用插件 %s", routeName, pluginName)
+	}
+	return nil
+}
+
+// RoutePluginStates 返回各路由插件链中插件的启用状态
+func (m *Manager) RoutePluginStates() map[string][]chain.PluginState {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	states := make(map[string][]chain.PluginState, len(m.routeChains))
+	for routeName, ch := range m.routeChains {
+		states[routeName] = ch.States()
+	}
+	return states
+}
+
+// Stop 停止所有路由独享的插件实例和已加载的可用插件，并停止插件结果缓存的清理
+func (m *Manager) Stop() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.pluginCache.Close()
+
+	for routeName, instances := range m.routeInstances {
+		stopPlugins(instances)
+		delete(m.routeInstances, routeName)
+	}
+	for name, p := range m.availablePlugins {
+		p.Stop()
+		m.lifecycle.updateState(name, StateStopped, nil)
+	}
+}
+
+// stopPlugins 停止路由独享的插件实例
+func stopPlugins(plugins []core.Plugin) {
+	for _, p := range plugins {
+		p.Stop()
+	}
+}
+
 // Execute 执行插件链
 func (m *Manager) Execute(ctx *gin.Context, routeName string) error {
 	m.mu.RLock()
@@ -0,0 +1,386 @@
+package plugin
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"gateway-go/internal/plugin/core"
+
+	"github.com/gin-gonic/gin"
+)
+
+// testPlugin 记录初始化顺序的测试插件
+type testPlugin struct {
+	*core.BasePlugin
+	inits   *[]string
+	initErr error
+}
+
+// newTestPlugin 创建测试插件，初始化时将插件名追加到 inits
+func newTestPlugin(name string, order int, inits *[]string, dependencies ...string) *testPlugin {
+	return &testPlugin{BasePlugin: core.NewBasePlugin(name, order, dependencies), inits: inits}
+}
+
+func (p *testPlugin) Init(config interface{}) error {
+	if p.initErr != nil {
+		return p.initErr
+	}
+	*p.inits = append(*p.inits, p.Name())
+	return nil
+}
+
+// Execute 将插件名追加到上下文的 executed 中，记录执行顺序
+func (p *testPlugin) Execute(ctx *gin.Context) error {
+	ctx.Set("executed", append(ctx.GetStringSlice("executed"), p.Name()))
+	return nil
+}
+
+// newTestContext 创建 GET / 请求的测试上下文
+func newTestContext() *gin.Context {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+	return c
+}
+
+// enabledConfigs 按插件名生成启用的插件配置，order 依次递增
+func enabledConfigs(names ...string) []PluginConfig {
+	configs := make([]PluginConfig, 0, len(names))
+	for i, name := range names {
+		configs = append(configs, PluginConfig{Name: name, Enabled: true, Order: i + 1})
+	}
+	return configs
+}
+
+func TestLoadAvailablePluginsDependencyOrder(t *testing.T) {
+	var inits []string
+	m := NewManager()
+	for _, p := range []core.Plugin{
+		newTestPlugin("auth", 1, &inits, "store"),
+		newTestPlugin("log", 2, &inits),
+		newTestPlugin("store", 3, &inits),
+	} {
+		if err := m.Register(p); err != nil {
+			t.Fatalf("注册插件 %s 失败: %v", p.Name(), err)
+		}
+	}
+
+	// 被依赖的 store 先于 auth 初始化，其余插件保持 order 顺序
+	if err := m.LoadAvailablePlugins(enabledConfigs("auth", "log", "store")); err != nil {
+		t.Fatalf("加载插件失败: %v", err)
+	}
+	if got := strings.Join(inits, ","); got != "store,auth,log" {
+		t.Fatalf("初始化顺序 = %s，期望 store,auth,log", got)
+	}
+	for name, info := range m.Lifecycle().ListPlugins() {
+		if info.State != StateRunning {
+			t.Fatalf("插件 %s 状态 = %s，期望 running", name, info.State)
+		}
+	}
+
+	// 依赖的插件未启用时拒绝加载
+	err := m.LoadAvailablePlugins(enabledConfigs("auth", "log"))
+	if err == nil || !strings.Contains(err.Error(), "插件 auth 依赖的插件 store 未启用") {
+		t.Fatalf("依赖未启用时返回 %v，期望加载失败", err)
+	}
+}
+
+func TestLoadAvailablePluginsFailedDependency(t *testing.T) {
+	var inits []string
+	store := newTestPlugin("store", 1, &inits)
+	store.initErr = fmt.Errorf("连接失败")
+	m := NewManager()
+	for _, p := range []core.Plugin{
+		store,
+		newTestPlugin("auth", 2, &inits, "store"),
+		newTestPlugin("audit", 3, &inits, "auth"),
+	} {
+		if err := m.Register(p); err != nil {
+			t.Fatalf("注册插件 %s 失败: %v", p.Name(), err)
+		}
+	}
+
+	if err := m.LoadAvailablePlugins(enabledConfigs("store", "auth", "audit")); err == nil {
+		t.Fatal("依赖的插件初始化失败时期望加载失败")
+	}
+	if len(inits) != 0 {
+		t.Fatalf("已初始化的插件 = %v，期望依赖失败的插件都不初始化", inits)
+	}
+
+	// 直接和间接依赖失败插件的插件都标记为失败
+	infos := m.Lifecycle().ListPlugins()
+	for name, reason := range map[string]string{
+		"store": "连接失败",
+		"auth":  "依赖的插件 store 启动失败",
+		"audit": "依赖的插件 auth 启动失败",
+	} {
+		info := infos[name]
+		if info.State != StateFailed || info.LastError == nil || !strings.Contains(info.LastError.Error(), reason) {
+			t.Fatalf("插件 %s 状态 = %s，错误 = %v，期望 failed 且包含 %q", name, info.State, info.LastError, reason)
+		}
+	}
+}
+
+// schemaPlugin 按 ConfigSchema 校验配置的测试插件，secret 为必填项
+type schemaPlugin struct {
+	*testPlugin
+}
+
+var testSchema = core.ConfigSchema{
+	"secret":  core.FieldString,
+	"limit":   core.FieldInt,
+	"ratio":   core.FieldNumber,
+	"strict":  core.FieldBool,
+	"timeout": core.FieldDuration,
+	"paths":   core.FieldStringList,
+	"options": core.FieldObject,
+}
+
+func (p *schemaPlugin) ValidateConfig(config map[string]interface{}) error {
+	if err := testSchema.Validate(config); err != nil {
+		return err
+	}
+	if secret, _ := config["secret"].(string); secret == "" {
+		return fmt.Errorf("缺少必填配置项: secret")
+	}
+	return nil
+}
+
+func TestLoadAvailablePluginsValidatesConfigSchema(t *testing.T) {
+	tests := []struct {
+		name   string
+		config map[string]interface{}
+		want   string
+	}{
+		// YAML 解析出 int，JSON 解析出整数值的 float64，时长可为字符串或整数
+		{"有效配置", map[string]interface{}{
+			"secret": "s", "limit": 10, "ratio": 0.5, "strict": true, "timeout": "5s",
+			"paths": []interface{}{"/a", "/b"}, "options": map[string]interface{}{"k": "v"},
+		}, ""},
+		{"JSON 数值", map[string]interface{}{"secret": "s", "limit": float64(10), "ratio": float64(1), "timeout": float64(30)}, ""},
+		{"未知配置项", map[string]interface{}{"secret": "s", "dimension": "ip"}, "未知的配置项: dimension"},
+		{"整数为小数", map[string]interface{}{"secret": "s", "limit": 1.5}, "配置项 limit 类型错误，期望 int"},
+		{"整数为非数字字符串", map[string]interface{}{"secret": "s", "limit": "ten"}, "配置项 limit 类型错误，期望 int"},
+		{"布尔为字符串", map[string]interface{}{"secret": "s", "strict": "true"}, "配置项 strict 类型错误，期望 bool"},
+		{"无效时长", map[string]interface{}{"secret": "s", "timeout": "5 minutes"}, "配置项 timeout 类型错误，期望 duration"},
+		{"字符串数组含数字", map[string]interface{}{"secret": "s", "paths": []interface{}{"/a", 1}}, "配置项 paths 类型错误，期望 string_list"},
+		{"对象为字符串", map[string]interface{}{"secret": "s", "options": "k=v"}, "配置项 options 类型错误，期望 object"},
+		{"缺少必填项", map[string]interface{}{"limit": 10}, "缺少必填配置项: secret"},
+		{"未配置", nil, "缺少必填配置项: secret"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var inits []string
+			m := NewManager()
+			if err := m.Register(&schemaPlugin{newTestPlugin("signer", 1, &inits)}); err != nil {
+				t.Fatalf("注册插件失败: %v", err)
+			}
+			configs := enabledConfigs("signer")
+			configs[0].Config = tt.config
+
+			err := m.LoadAvailablePlugins(configs)
+			if tt.want == "" {
+				if err != nil || len(inits) != 1 {
+					t.Fatalf("加载插件 err = %v，已初始化 %v，期望校验通过并初始化", err, inits)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), "插件 signer 配置无效") || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("加载插件 err = %v，期望包含 %q", err, tt.want)
+			}
+			// 配置无效时不初始化插件
+			if len(inits) != 0 {
+				t.Fatalf("配置无效时插件被初始化: %v", inits)
+			}
+		})
+	}
+}
+
+func TestRegisterRejectsDependencyCycle(t *testing.T) {
+	var inits []string
+	m := NewManager()
+	if err := m.Register(newTestPlugin("a", 1, &inits, "b")); err != nil {
+		t.Fatalf("注册插件 a 失败: %v", err)
+	}
+
+	err := m.Register(newTestPlugin("b", 2, &inits, "a"))
+	if err == nil || !strings.Contains(err.Error(), "插件存在循环依赖: b -> a -> b") {
+		t.Fatalf("注册构成循环依赖的插件返回 %v，期望列出循环路径", err)
+	}
+	// 注册失败的插件不保留，修正依赖后可以重新注册
+	if err := m.Register(newTestPlugin("b", 2, &inits)); err != nil {
+		t.Fatalf("重新注册插件 b 失败: %v", err)
+	}
+}
+
+func TestLoadRoutePluginsOrder(t *testing.T) {
+	var inits []string
+	m := NewManager()
+	for i, name := range []string{"auth", "rate_limit", "cors"} {
+		if err := m.RegisterAvailablePlugin(name, newTestPlugin(name, i+1, &inits)); err != nil {
+			t.Fatalf("注册插件 %s 失败: %v", name, err)
+		}
+	}
+
+	// 两条路由使用相同的插件，按列表顺序执行时顺序不同
+	routes := []struct {
+		name      string
+		plugins   []string
+		keepOrder bool
+		want      string
+	}{
+		{"auth-first", []string{"auth", "rate_limit", "cors"}, true, "auth,rate_limit,cors"},
+		{"limit-first", []string{"rate_limit", "cors", "auth"}, true, "rate_limit,cors,auth"},
+		{"by-order", []string{"rate_limit", "cors", "auth"}, false, "auth,rate_limit,cors"},
+	}
+	for _, route := range routes {
+		if err := m.LoadRoutePlugins(route.name, route.plugins, nil, route.keepOrder); err != nil {
+			t.Fatalf("加载路由 %s 的插件失败: %v", route.name, err)
+		}
+	}
+
+	gin.SetMode(gin.TestMode)
+	for _, route := range routes {
+		c := newTestContext()
+		if err := m.Execute(c, route.name); err != nil {
+			t.Fatalf("执行路由 %s 的插件失败: %v", route.name, err)
+		}
+		if got := strings.Join(c.GetStringSlice("executed"), ","); got != route.want {
+			t.Fatalf("路由 %s 的插件执行顺序 = %s，期望 %s", route.name, got, route.want)
+		}
+	}
+}
+
+// blockingPlugin 执行时通知 entered 并阻塞直到 release 关闭的测试插件
+type blockingPlugin struct {
+	*testPlugin
+	entered chan struct{}
+	release chan struct{}
+}
+
+func (p *blockingPlugin) Execute(ctx *gin.Context) error {
+	select {
+	case p.entered <- struct{}{}:
+	default:
+	}
+	<-p.release
+	return nil
+}
+
+func TestSetRoutePluginEnabledDuringExecute(t *testing.T) {
+	var inits []string
+	m := NewManager()
+	blocking := &blockingPlugin{testPlugin: newTestPlugin("slow", 1, &inits), entered: make(chan struct{}, 1), release: make(chan struct{})}
+	for name, p := range map[string]core.Plugin{"slow": blocking, "auth": newTestPlugin("auth", 2, &inits)} {
+		if err := m.RegisterAvailablePlugin(name, p); err != nil {
+			t.Fatalf("注册插件 %s 失败: %v", name, err)
+		}
+	}
+	if err := m.LoadRoutePlugins("orders", []string{"slow", "auth"}, nil, false); err != nil {
+		t.Fatalf("加载路由插件失败: %v", err)
+	}
+
+	// 请求执行插件链期间切换插件状态，不等待请求完成
+	gin.SetMode(gin.TestMode)
+	inflight := newTestContext()
+	done := make(chan error, 1)
+	go func() { done <- m.Execute(inflight, "orders") }()
+	<-blocking.entered
+
+	toggled := make(chan error, 1)
+	go func() { toggled <- m.SetRoutePluginEnabled("orders", "auth", false) }()
+	select {
+	case err := <-toggled:
+		if err != nil {
+			t.Fatalf("禁用插件失败: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("禁用插件被进行中的请求阻塞")
+	}
+	close(blocking.release)
+	if err := <-done; err != nil {
+		t.Fatalf("执行插件链失败: %v", err)
+	}
+
+	// 后续请求跳过禁用的插件，重新启用后恢复执行
+	for _, tt := range []struct {
+		enabled bool
+		want    string
+	}{
+		{false, ""},
+		{true, "auth"},
+	} {
+		if err := m.SetRoutePluginEnabled("orders", "auth", tt.enabled); err != nil {
+			t.Fatalf("设置插件状态失败: %v", err)
+		}
+		c := newTestContext()
+		if err := m.Execute(c, "orders"); err != nil {
+			t.Fatalf("执行插件链失败: %v", err)
+		}
+		if got := strings.Join(c.GetStringSlice("executed"), ","); got != tt.want {
+			t.Fatalf("enabled=%v 时执行的插件 = %q，期望 %q", tt.enabled, got, tt.want)
+		}
+	}
+
+	if err := m.SetRoutePluginEnabled("orders", "missing", false); err == nil {
+		t.Fatal("禁用路由未使用的插件应返回错误")
+	}
+}
+
+// resultPlugin 写出执行结果的测试插件，结果可被插件结果缓存
+type resultPlugin struct {
+	*testPlugin
+	calls int
+}
+
+func (p *resultPlugin) Execute(ctx *gin.Context) error {
+	p.calls++
+	ctx.Set("plugin_result_"+p.Name(), p.calls)
+	return nil
+}
+
+func TestPluginCacheTTLPerPlugin(t *testing.T) {
+	var inits []string
+	m := NewManager()
+	defer m.Stop()
+
+	short, long, never := 50*time.Millisecond, time.Hour, time.Duration(0)
+	plugins := map[string]*resultPlugin{}
+	configs := enabledConfigs("geo", "profile", "quota", "default")
+	for i, ttl := range []*time.Duration{&short, &long, &never, nil} {
+		name := configs[i].Name
+		plugins[name] = &resultPlugin{testPlugin: newTestPlugin(name, i+1, &inits)}
+		if err := m.Register(plugins[name]); err != nil {
+			t.Fatalf("注册插件 %s 失败: %v", name, err)
+		}
+		configs[i].CacheTTL = ttl
+	}
+	if err := m.LoadAvailablePlugins(configs); err != nil {
+		t.Fatalf("加载插件失败: %v", err)
+	}
+	if err := m.LoadRoutePlugins("orders", []string{"geo", "profile", "quota", "default"}, nil, false); err != nil {
+		t.Fatalf("加载路由插件失败: %v", err)
+	}
+
+	gin.SetMode(gin.TestMode)
+	execute := func() {
+		if err := m.Execute(newTestContext(), "orders"); err != nil {
+			t.Fatalf("执行插件链失败: %v", err)
+		}
+	}
+	execute()
+	execute()
+	time.Sleep(2 * short)
+	execute()
+
+	// geo 的缓存在 50ms 后过期，profile 和未配置的插件仍命中缓存，cache_ttl 为 0 的插件不缓存
+	for name, want := range map[string]int{"geo": 2, "profile": 1, "quota": 3, "default": 1} {
+		if got := plugins[name].calls; got != want {
+			t.Fatalf("插件 %s 执行 %d 次，期望 %d 次", name, got, want)
+		}
+	}
+}
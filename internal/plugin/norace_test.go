@@ -0,0 +1,6 @@
+//go:build !race
+
+package plugin
+
+// raceEnabled 测试是否启用了竞态检测，外部插件需使用相同的参数编译
+const raceEnabled = false
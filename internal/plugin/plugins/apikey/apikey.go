@@ -0,0 +1,292 @@
+package apikey
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"gateway-go/internal/errors"
+	"gateway-go/internal/plugin/core"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 默认配置
+const (
+	defaultKeyLocation    = "header:X-Api-Key"
+	defaultConsumerHeader = "X-Consumer-ID"
+	// 哈希密钥的前缀，值为密钥的 SHA-256 十六进制编码
+	hashPrefix = "sha256:"
+)
+
+// 上下文键
+const (
+	// ConsumerKey 识别出的消费者名称
+	ConsumerKey = "api_key_consumer"
+	// MetadataKey 消费者配置的元数据
+	MetadataKey = "api_key_metadata"
+)
+
+// APIKeyPlugin API Key 认证插件，按配置的密钥识别消费者
+type APIKeyPlugin struct {
+	*core.BasePlugin
+	settings *settings
+	mu       sync.RWMutex
+}
+
+// settings 解析后的插件配置
+type settings struct {
+	// 密钥来源：header、query 或 authorization
+	source string
+	// 请求头或查询参数名
+	name            string
+	consumerHeader  string
+	hideCredentials bool
+	consumers       []consumer
+}
+
+// consumer 单个密钥对应的消费者
+type consumer struct {
+	name string
+	// 密钥的 SHA-256 摘要，明文密钥在加载时计算
+	digest   []byte
+	metadata map[string]interface{}
+	// 允许访问的路由，为空时不限制
+	allowedRoutes map[string]bool
+}
+
+// New 创建 API Key 认证插件
+func New() *APIKeyPlugin {
+	return &APIKeyPlugin{
+		BasePlugin: core.NewBasePlugin("api_key", 8, nil),
+	}
+}
+
+// configSchema 插件配置结构
+var configSchema = core.ConfigSchema{
+	"key_location":     core.FieldString,
+	"consumer_header":  core.FieldString,
+	"hide_credentials": core.FieldBool,
+	"keys":             core.FieldList,
+}
+
+// keySchema 密钥配置结构
+var keySchema = core.ConfigSchema{
+	"key":            core.FieldString,
+	"consumer":       core.FieldString,
+	"metadata":       core.FieldObject,
+	"allowed_routes": core.FieldStringList,
+}
+
+// ValidateConfig 校验插件配置
+func (p *APIKeyPlugin) ValidateConfig(config map[string]interface{}) error {
+	if err := configSchema.Validate(config); err != nil {
+		return err
+	}
+	_, err := parseSettings(config)
+	return err
+}
+
+// Init 初始化插件
+func (p *APIKeyPlugin) Init(config interface{}) error {
+	// 类型断言
+	configMap, ok := config.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("配置类型错误，期望 map[string]interface{}")
+	}
+
+	s, err := parseSettings(configMap)
+	if err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	p.settings = s
+	p.mu.Unlock()
+	return nil
+}
+
+// parseSettings 解析插件配置
+func parseSettings(config map[string]interface{}) (*settings, error) {
+	s := &settings{
+		consumerHeader: defaultConsumerHeader,
+	}
+
+	location := defaultKeyLocation
+	if value, ok := config["key_location"].(string); ok && value != "" {
+		location = value
+	}
+	source, name, err := parseKeyLocation(location)
+	if err != nil {
+		return nil, err
+	}
+	s.source, s.name = source, name
+
+	if header, ok := config["consumer_header"].(string); ok && header != "" {
+		s.consumerHeader = header
+	}
+	s.hideCredentials, _ = config["hide_credentials"].(bool)
+
+	var items []interface{}
+	switch list := config["keys"].(type) {
+	case []interface{}:
+		items = list
+	case nil:
+	default:
+		return nil, fmt.Errorf("keys 必须为数组")
+	}
+	if len(items) == 0 {
+		return nil, fmt.Errorf("至少需要配置一个 API Key")
+	}
+
+	digests := make(map[string]bool, len(items))
+	for i, item := range items {
+		raw, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("keys[%d] 必须为对象", i)
+		}
+		if err := keySchema.Validate(raw); err != nil {
+			return nil, fmt.Errorf("keys[%d]: %w", i, err)
+		}
+
+		c := consumer{}
+		c.name, _ = raw["consumer"].(string)
+		if c.name == "" {
+			return nil, fmt.Errorf("keys[%d] 未配置 consumer", i)
+		}
+		key, _ := raw["key"].(string)
+		if c.digest, err = keyDigest(key); err != nil {
+			return nil, fmt.Errorf("keys[%d]: %v", i, err)
+		}
+		if digests[string(c.digest)] {
+			return nil, fmt.Errorf("keys[%d] 的密钥与其他消费者重复", i)
+		}
+		digests[string(c.digest)] = true
+
+		c.metadata, _ = raw["metadata"].(map[string]interface{})
+		if routes, ok := raw["allowed_routes"].([]interface{}); ok && len(routes) > 0 {
+			c.allowedRoutes = make(map[string]bool, len(routes))
+			for _, route := range routes {
+				if name, ok := route.(string); ok {
+					c.allowedRoutes[name] = true
+				}
+			}
+		}
+		s.consumers = append(s.consumers, c)
+	}
+	return s, nil
+}
+
+// parseKeyLocation 解析密钥来源：header:<请求头>、query:<参数名> 或 Authorization
+func parseKeyLocation(location string) (string, string, error) {
+	if strings.EqualFold(location, "authorization") {
+		return "authorization", "Authorization", nil
+	}
+	source, name, ok := strings.Cut(location, ":")
+	if !ok || name == "" || (source != "header" && source != "query") {
+		return "", "", fmt.Errorf("key_location 格式错误，支持 header:<请求头>、query:<参数名> 或 Authorization: %s", location)
+	}
+	return source, name, nil
+}
+
+// keyDigest 计算密钥的 SHA-256 摘要，sha256: 前缀的值视为已哈希的密钥
+func keyDigest(key string) ([]byte, error) {
+	if key == "" {
+		return nil, fmt.Errorf("未配置 key")
+	}
+	if !strings.HasPrefix(key, hashPrefix) {
+		sum := sha256.Sum256([]byte(key))
+		return sum[:], nil
+	}
+	digest, err := hex.DecodeString(strings.TrimPrefix(key, hashPrefix))
+	if err != nil || len(digest) != sha256.Size {
+		return nil, fmt.Errorf("哈希密钥必须为 sha256: 加 64 位十六进制摘要")
+	}
+	return digest, nil
+}
+
+// Execute 执行插件
+func (p *APIKeyPlugin) Execute(ctx *gin.Context) error {
+	p.mu.RLock()
+	s := p.settings
+	p.mu.RUnlock()
+	if s == nil {
+		return nil
+	}
+
+	// 消费者请求头只能由插件写入
+	ctx.Request.Header.Del(s.consumerHeader)
+
+	key := s.extractKey(ctx.Request)
+	if key == "" {
+		errors.Abort(ctx, http.StatusUnauthorized, "缺少 API Key")
+		return fmt.Errorf("请求未携带 API Key")
+	}
+
+	c := s.lookup(key)
+	if c == nil {
+		errors.Abort(ctx, http.StatusUnauthorized, "无效的 API Key")
+		return fmt.Errorf("无效的 API Key")
+	}
+
+	if route := ctx.GetString("route"); c.allowedRoutes != nil && !c.allowedRoutes[route] {
+		errors.Abort(ctx, http.StatusForbidden, "API Key 无权访问该路由")
+		return fmt.Errorf("消费者 %s 无权访问路由 %s", c.name, route)
+	}
+
+	if s.hideCredentials {
+		s.removeKey(ctx.Request)
+	}
+	ctx.Set(ConsumerKey, c.name)
+	if c.metadata != nil {
+		ctx.Set(MetadataKey, c.metadata)
+	}
+	core.SetIdentity(ctx, map[string]interface{}{"consumer": c.name})
+	ctx.Request.Header.Set(s.consumerHeader, c.name)
+	return nil
+}
+
+// extractKey 按配置的来源读取请求中的密钥
+func (s *settings) extractKey(req *http.Request) string {
+	switch s.source {
+	case "query":
+		return req.URL.Query().Get(s.name)
+	case "authorization":
+		value := req.Header.Get("Authorization")
+		if scheme, token, ok := strings.Cut(value, " "); ok && strings.EqualFold(scheme, "Bearer") {
+			return strings.TrimSpace(token)
+		}
+		return strings.TrimSpace(value)
+	default:
+		return req.Header.Get(s.name)
+	}
+}
+
+// removeKey 转发前删除请求中的密钥
+func (s *settings) removeKey(req *http.Request) {
+	switch s.source {
+	case "query":
+		query := req.URL.Query()
+		query.Del(s.name)
+		req.URL.RawQuery = query.Encode()
+	default:
+		req.Header.Del(s.name)
+	}
+}
+
+// lookup 按摘要查找密钥对应的消费者
+// 与所有密钥逐一进行常量时间比较，耗时与匹配位置无关
+func (s *settings) lookup(key string) *consumer {
+	sum := sha256.Sum256([]byte(key))
+	var matched *consumer
+	for i := range s.consumers {
+		if subtle.ConstantTimeCompare(sum[:], s.consumers[i].digest) == 1 {
+			matched = &s.consumers[i]
+		}
+	}
+	return matched
+}
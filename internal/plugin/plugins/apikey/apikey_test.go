@@ -0,0 +1,147 @@
+package apikey
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"gateway-go/internal/plugin/plugintest"
+
+	"github.com/gin-gonic/gin"
+)
+
+// execute 执行插件，prepare 用于设置请求，返回响应记录和请求上下文
+func execute(p *APIKeyPlugin, target string, prepare func(req *http.Request)) (*httptest.ResponseRecorder, *gin.Context) {
+	gin.SetMode(gin.TestMode)
+	rec := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(rec)
+	c.Request = httptest.NewRequest(http.MethodGet, target, nil)
+	c.Set("route", "orders")
+	if prepare != nil {
+		prepare(c.Request)
+	}
+	p.Execute(c)
+	if !c.IsAborted() {
+		c.Status(http.StatusOK)
+	}
+	return rec, c
+}
+
+// keysConfig 返回 alice 使用明文密钥、bob 使用哈希密钥的配置
+func keysConfig(location string) map[string]interface{} {
+	sum := sha256.Sum256([]byte("bob-key"))
+	return map[string]interface{}{
+		"key_location": location,
+		"keys": []interface{}{
+			map[string]interface{}{"key": "alice-key", "consumer": "alice", "metadata": map[string]interface{}{"tier": "gold"}},
+			map[string]interface{}{"key": "sha256:" + hex.EncodeToString(sum[:]), "consumer": "bob"},
+		},
+	}
+}
+
+func TestAPIKeyLocations(t *testing.T) {
+	locations := []struct {
+		location string
+		path     string
+		// withKey 按密钥来源在请求中携带密钥
+		withKey func(req *http.Request, key string)
+	}{
+		{"header:X-Api-Key", "/api/orders", func(req *http.Request, key string) { req.Header.Set("X-Api-Key", key) }},
+		{"query:api_key", "/api/orders?page=2", func(req *http.Request, key string) {
+			query := req.URL.Query()
+			query.Set("api_key", key)
+			req.URL.RawQuery = query.Encode()
+		}},
+		{"Authorization", "/api/orders", func(req *http.Request, key string) { req.Header.Set("Authorization", "Bearer "+key) }},
+	}
+
+	for _, loc := range locations {
+		t.Run(loc.location, func(t *testing.T) {
+			p := plugintest.Init(t, New(), keysConfig(loc.location))
+
+			for key, consumer := range map[string]string{"alice-key": "alice", "bob-key": "bob"} {
+				rec, c := execute(p, loc.path, func(req *http.Request) { loc.withKey(req, key) })
+				if rec.Code != http.StatusOK || c.GetString(ConsumerKey) != consumer || c.Request.Header.Get("X-Consumer-ID") != consumer {
+					t.Fatalf("密钥 %s 的状态码 = %d，消费者 = %q，期望 200 和 %s", key, rec.Code, c.GetString(ConsumerKey), consumer)
+				}
+			}
+
+			if rec, _ := execute(p, loc.path, nil); rec.Code != http.StatusUnauthorized || !strings.Contains(rec.Body.String(), "缺少 API Key") {
+				t.Fatalf("缺少密钥时响应 = %d %s，期望 401", rec.Code, rec.Body.String())
+			}
+			rec, _ := execute(p, loc.path, func(req *http.Request) { loc.withKey(req, "unknown-key") })
+			if rec.Code != http.StatusUnauthorized || !strings.Contains(rec.Body.String(), "无效的 API Key") {
+				t.Fatalf("未知密钥时响应 = %d %s，期望 401", rec.Code, rec.Body.String())
+			}
+			// 哈希配置的密钥不能用摘要本身通过认证
+			sum := sha256.Sum256([]byte("bob-key"))
+			if rec, _ := execute(p, loc.path, func(req *http.Request) { loc.withKey(req, "sha256:"+hex.EncodeToString(sum[:])) }); rec.Code != http.StatusUnauthorized {
+				t.Fatalf("使用摘要作为密钥时状态码 = %d，期望 401", rec.Code)
+			}
+		})
+	}
+}
+
+func TestAPIKeyMetadataAndForgedConsumer(t *testing.T) {
+	p := plugintest.Init(t, New(), keysConfig("header:X-Api-Key"))
+
+	_, c := execute(p, "/api/orders", func(req *http.Request) { req.Header.Set("X-Api-Key", "alice-key") })
+	metadata, _ := c.Get(MetadataKey)
+	if m, _ := metadata.(map[string]interface{}); m["tier"] != "gold" {
+		t.Fatalf("消费者元数据 = %v，期望 tier=gold", metadata)
+	}
+
+	// 未认证的请求不能伪造消费者请求头
+	_, c = execute(p, "/api/orders", func(req *http.Request) { req.Header.Set("X-Consumer-ID", "alice") })
+	if got := c.Request.Header.Get("X-Consumer-ID"); got != "" {
+		t.Fatalf("未认证请求的 X-Consumer-ID = %q，期望删除", got)
+	}
+}
+
+func TestAPIKeyAllowedRoutes(t *testing.T) {
+	p := plugintest.Init(t, New(), map[string]interface{}{
+		"keys": []interface{}{
+			map[string]interface{}{"key": "k1", "consumer": "reports", "allowed_routes": []interface{}{"reports"}},
+		},
+	})
+	// execute 将请求的路由设为 orders
+	if rec, _ := execute(p, "/api/orders", func(req *http.Request) { req.Header.Set("X-Api-Key", "k1") }); rec.Code != http.StatusForbidden {
+		t.Fatalf("访问未授权路由时状态码 = %d，期望 403", rec.Code)
+	}
+}
+
+func TestAPIKeyHideCredentials(t *testing.T) {
+	for location, check := range map[string]func(req *http.Request) bool{
+		"header:X-Api-Key": func(req *http.Request) bool { return req.Header.Get("X-Api-Key") == "" },
+		"query:api_key":    func(req *http.Request) bool { return req.URL.RawQuery == "page=2" },
+	} {
+		config := keysConfig(location)
+		config["hide_credentials"] = true
+		p := plugintest.Init(t, New(), config)
+		_, c := execute(p, "/api/orders?page=2&api_key=alice-key", func(req *http.Request) { req.Header.Set("X-Api-Key", "alice-key") })
+		if c.IsAborted() || !check(c.Request) {
+			t.Fatalf("%s: 转发的请求 %s %v 仍包含密钥", location, c.Request.URL, c.Request.Header)
+		}
+	}
+}
+
+func TestAPIKeyConfigErrors(t *testing.T) {
+	for want, config := range map[string]map[string]interface{}{
+		"至少需要配置一个 API Key":  {},
+		"key_location 格式错误": {"key_location": "cookie:sid", "keys": []interface{}{map[string]interface{}{"key": "k", "consumer": "c"}}},
+		"未配置 consumer":      {"keys": []interface{}{map[string]interface{}{"key": "k"}}},
+		"未配置 key":           {"keys": []interface{}{map[string]interface{}{"consumer": "c"}}},
+		"哈希密钥必须为 sha256":    {"keys": []interface{}{map[string]interface{}{"key": "sha256:abc", "consumer": "c"}}},
+		"密钥与其他消费者重复": {"keys": []interface{}{
+			map[string]interface{}{"key": "k", "consumer": "a"},
+			map[string]interface{}{"key": "k", "consumer": "b"},
+		}},
+	} {
+		if err := New().ValidateConfig(config); err == nil || !strings.Contains(err.Error(), want) {
+			t.Fatalf("校验配置返回 %v，期望包含 %q", err, want)
+		}
+	}
+}
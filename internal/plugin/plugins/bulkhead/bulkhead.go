@@ -0,0 +1,207 @@
+package bulkhead
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"gateway-go/internal/errors"
+	"gateway-go/internal/plugin/core"
+
+	"github.com/gin-gonic/gin"
+)
+
+// defaultQueueTimeout 排队请求的默认等待时间
+const defaultQueueTimeout = time.Second
+
+// BulkheadPlugin 并发隔离插件，按路由限制同时处理的请求数，保护处理较慢的上游
+type BulkheadPlugin struct {
+	*core.BasePlugin
+	settings *settings
+	// 按路由创建的隔离舱，配置变更时整体替换
+	compartments map[string]*compartment
+	mu           sync.RWMutex
+}
+
+// settings 解析后的插件配置
+type settings struct {
+	maxConcurrent int
+	maxQueue      int
+	queueTimeout  time.Duration
+}
+
+// compartment 单个路由的隔离舱
+type compartment struct {
+	// 缓冲信号量，容量为最大并发数
+	slots chan struct{}
+	// 正在排队的请求数
+	waiting  int64
+	maxQueue int64
+	timeout  time.Duration
+}
+
+// New 创建并发隔离插件
+func New() *BulkheadPlugin {
+	return &BulkheadPlugin{
+		BasePlugin:   core.NewBasePlugin("bulkhead", 7, nil),
+		compartments: make(map[string]*compartment),
+	}
+}
+
+// configSchema 插件配置结构
+var configSchema = core.ConfigSchema{
+	"max_concurrent": core.FieldInt,
+	"max_queue":      core.FieldInt,
+	"queue_timeout":  core.FieldDuration,
+}
+
+// ValidateConfig 校验插件配置
+func (p *BulkheadPlugin) ValidateConfig(config map[string]interface{}) error {
+	if err := configSchema.Validate(config); err != nil {
+		return err
+	}
+	_, err := parseSettings(config)
+	return err
+}
+
+// Init 初始化插件
+func (p *BulkheadPlugin) Init(config interface{}) error {
+	// 类型断言
+	configMap, ok := config.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("配置类型错误，期望 map[string]interface{}")
+	}
+
+	s, err := parseSettings(configMap)
+	if err != nil {
+		return err
+	}
+
+	// 进行中的请求在原隔离舱中释放，不影响新配置的计数
+	p.mu.Lock()
+	p.settings = s
+	p.compartments = make(map[string]*compartment)
+	p.mu.Unlock()
+	return nil
+}
+
+// parseSettings 解析插件配置
+func parseSettings(config map[string]interface{}) (*settings, error) {
+	s := &settings{
+		queueTimeout: defaultQueueTimeout,
+	}
+
+	maxConcurrent, ok := core.ToInt(config["max_concurrent"])
+	if !ok || maxConcurrent <= 0 {
+		return nil, fmt.Errorf("max_concurrent 必须大于 0")
+	}
+	s.maxConcurrent = maxConcurrent
+
+	if value, exists := config["max_queue"]; exists {
+		maxQueue, ok := core.ToInt(value)
+		if !ok || maxQueue < 0 {
+			return nil, fmt.Errorf("max_queue 不能小于 0")
+		}
+		s.maxQueue = maxQueue
+	}
+
+	switch value := config["queue_timeout"].(type) {
+	case nil:
+	case string:
+		timeout, err := time.ParseDuration(value)
+		if err != nil {
+			millis, convErr := strconv.Atoi(value)
+			if convErr != nil {
+				return nil, fmt.Errorf("queue_timeout 格式错误: %w", err)
+			}
+			timeout = time.Duration(millis) * time.Millisecond
+		}
+		s.queueTimeout = timeout
+	default:
+		millis, _ := core.ToInt(value)
+		s.queueTimeout = time.Duration(millis) * time.Millisecond
+	}
+	if s.queueTimeout <= 0 {
+		return nil, fmt.Errorf("queue_timeout 必须大于 0")
+	}
+	return s, nil
+}
+
+// Execute 执行插件
+func (p *BulkheadPlugin) Execute(ctx *gin.Context) error {
+	c := p.compartment(ctx.GetString("route"))
+	if c == nil {
+		return nil
+	}
+
+	if !c.acquire(ctx) {
+		// 客户端已断开时无需写响应
+		if ctx.Request.Context().Err() != nil {
+			ctx.Abort()
+			return nil
+		}
+		errors.Abort(ctx, http.StatusServiceUnavailable, "并发请求过多，请稍后重试")
+		return nil
+	}
+
+	// 请求处理结束（包括响应写出）后释放并发槽位
+	core.OnComplete(ctx, c.release)
+	return nil
+}
+
+// compartment 获取路由对应的隔离舱
+func (p *BulkheadPlugin) compartment(route string) *compartment {
+	p.mu.RLock()
+	s := p.settings
+	c := p.compartments[route]
+	p.mu.RUnlock()
+	if s == nil || c != nil {
+		return c
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if c = p.compartments[route]; c == nil {
+		c = &compartment{
+			slots:    make(chan struct{}, s.maxConcurrent),
+			maxQueue: int64(s.maxQueue),
+			timeout:  s.queueTimeout,
+		}
+		p.compartments[route] = c
+	}
+	return c
+}
+
+// acquire 获取并发槽位，已满时在排队名额内等待，超时、客户端断开或排队已满时返回 false
+func (c *compartment) acquire(ctx *gin.Context) bool {
+	select {
+	case c.slots <- struct{}{}:
+		return true
+	default:
+	}
+
+	if atomic.AddInt64(&c.waiting, 1) > c.maxQueue {
+		atomic.AddInt64(&c.waiting, -1)
+		return false
+	}
+	defer atomic.AddInt64(&c.waiting, -1)
+
+	timer := time.NewTimer(c.timeout)
+	defer timer.Stop()
+	select {
+	case c.slots <- struct{}{}:
+		return true
+	case <-timer.C:
+		return false
+	case <-ctx.Request.Context().Done():
+		return false
+	}
+}
+
+// release 释放并发槽位
+func (c *compartment) release() {
+	<-c.slots
+}
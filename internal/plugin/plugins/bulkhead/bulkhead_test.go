@@ -0,0 +1,36 @@
+package bulkhead
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseSettings(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  map[string]interface{}
+		want    settings
+		wantErr bool
+	}{
+		{name: "默认排队配置", config: map[string]interface{}{"max_concurrent": 4}, want: settings{maxConcurrent: 4, queueTimeout: time.Second}},
+		{name: "时长字符串", config: map[string]interface{}{"max_concurrent": 4, "max_queue": 8, "queue_timeout": "250ms"}, want: settings{maxConcurrent: 4, maxQueue: 8, queueTimeout: 250 * time.Millisecond}},
+		{name: "毫秒数", config: map[string]interface{}{"max_concurrent": 1, "queue_timeout": 1500}, want: settings{maxConcurrent: 1, queueTimeout: 1500 * time.Millisecond}},
+		{name: "毫秒数字符串", config: map[string]interface{}{"max_concurrent": 1, "queue_timeout": "20"}, want: settings{maxConcurrent: 1, queueTimeout: 20 * time.Millisecond}},
+		{name: "缺少并发数", config: map[string]interface{}{}, wantErr: true},
+		{name: "并发数为 0", config: map[string]interface{}{"max_concurrent": 0}, wantErr: true},
+		{name: "排队数为负数", config: map[string]interface{}{"max_concurrent": 1, "max_queue": -1}, wantErr: true},
+		{name: "超时格式错误", config: map[string]interface{}{"max_concurrent": 1, "queue_timeout": "soon"}, wantErr: true},
+		{name: "超时为 0", config: map[string]interface{}{"max_concurrent": 1, "queue_timeout": 0}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := parseSettings(tt.config)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseSettings() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && *s != tt.want {
+				t.Fatalf("parseSettings() = %+v，期望 %+v", *s, tt.want)
+			}
+		})
+	}
+}
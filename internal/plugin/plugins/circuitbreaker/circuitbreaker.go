@@ -2,8 +2,11 @@ package circuitbreaker
 
 import (
 	"fmt"
+	"gateway-go/internal/errors"
 	"gateway-go/internal/plugin/core"
 	"net/http"
+	"sort"
+	"strconv"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -20,13 +23,97 @@ const (
 	StateHalfOpen
 )
 
+// String 返回状态名称
+func (s CircuitBreakerState) String() string {
+	switch s {
+	case StateOpen:
+		return "open"
+	case StateHalfOpen:
+		return "half_open"
+	default:
+		return "closed"
+	}
+}
+
+// 手动干预状态，由管理API设置，优先于自动状态切换
+const (
+	overrideNone int32 = iota
+	overrideOpen
+	overrideClosed
+)
+
+// 管理API支持的操作
+const (
+	ActionOpen   = "open"   // 强制熔断，直到执行 reset
+	ActionClosed = "closed" // 强制放行，失败率达到阈值也不熔断，直到执行 reset
+	ActionReset  = "reset"  // 取消手动干预，恢复为关闭状态并清空统计窗口
+)
+
+// Status 熔断器状态快照
+type Status struct {
+	Target      string    `json:"target"`
+	State       string    `json:"state"`
+	Forced      bool      `json:"forced"`
+	FailureRate float64   `json:"failure_rate"`
+	Failures    int32     `json:"failures"`
+	Requests    int32     `json:"requests"`
+	LastUsed    time.Time `json:"last_used"`
+}
+
 // CircuitBreaker 熔断器
+// 关闭状态下窗口内请求数达到 min_requests 且失败率达到 failure_threshold（百分比）时熔断；
+// 熔断 recovery_timeout 秒后进入半开状态，放行 half_open_quota 个探测请求，
+// 连续 success_threshold 个探测成功后恢复，任一探测失败则重新熔断
 type CircuitBreaker struct {
-	state         int32 // CircuitBreakerState
-	halfOpenQuota int32
-	window        *Window
-	config        map[string]interface{}
-	lastUsed      time.Time // 最后使用时间
+	state             int32 // CircuitBreakerState
+	halfOpenQuota     int32
+	halfOpenSuccesses int32
+	openedAt          int64 // 熔断时间（UnixNano），恢复超时从该时间开始计算
+	override          int32 // 手动干预状态
+	window            *Window
+	settings          settings
+	lastUsed          time.Time // 最后使用时间
+}
+
+// settings 熔断器参数，创建熔断器时从插件配置解析
+type settings struct {
+	failureThreshold int // 失败率阈值（百分比）
+	minRequests      int // 计算失败率所需的最少请求数
+	recoveryTimeout  int // 恢复超时（秒）
+	halfOpenQuota    int // 半开状态最大探测请求数
+	successThreshold int // 半开状态恢复所需的连续成功数
+	windowSize       int // 统计窗口大小（秒）
+}
+
+// parseSettings 从插件配置解析熔断器参数，未配置或类型无法转换时使用默认值
+func parseSettings(config map[string]interface{}) settings {
+	s := settings{
+		failureThreshold: 50,
+		minRequests:      10,
+		recoveryTimeout:  30,
+		halfOpenQuota:    2,
+		successThreshold: 2,
+		windowSize:       10,
+	}
+	if ft, ok := core.ToInt(config["failure_threshold"]); ok {
+		s.failureThreshold = ft
+	}
+	if mr, ok := core.ToInt(config["min_requests"]); ok {
+		s.minRequests = mr
+	}
+	if rt, ok := core.ToInt(config["recovery_timeout"]); ok {
+		s.recoveryTimeout = rt
+	}
+	if hoq, ok := core.ToInt(config["half_open_quota"]); ok {
+		s.halfOpenQuota = hoq
+	}
+	if st, ok := core.ToInt(config["success_threshold"]); ok {
+		s.successThreshold = st
+	}
+	if ws, ok := core.ToInt(config["window_size"]); ok {
+		s.windowSize = ws
+	}
+	return s
 }
 
 // CircuitBreakerPlugin 熔断器插件
@@ -47,6 +134,44 @@ func New() *CircuitBreakerPlugin {
 	}
 }
 
+// configSchema 插件配置结构
+var configSchema = core.ConfigSchema{
+	"failure_threshold": core.FieldInt,
+	"min_requests":      core.FieldInt,
+	"recovery_timeout":  core.FieldInt,
+	"half_open_quota":   core.FieldInt,
+	"success_threshold": core.FieldInt,
+	"window_size":       core.FieldInt,
+}
+
+// ValidateConfig 校验插件配置
+func (p *CircuitBreakerPlugin) ValidateConfig(config map[string]interface{}) error {
+	if err := configSchema.Validate(config); err != nil {
+		return err
+	}
+
+	s := parseSettings(config)
+	if s.failureThreshold < 1 || s.failureThreshold > 100 {
+		return fmt.Errorf("failure_threshold 必须在 1-100 之间（失败率百分比）: %d", s.failureThreshold)
+	}
+	if s.minRequests < 1 {
+		return fmt.Errorf("min_requests 必须大于 0: %d", s.minRequests)
+	}
+	if s.recoveryTimeout < 1 {
+		return fmt.Errorf("recovery_timeout 必须大于 0: %d", s.recoveryTimeout)
+	}
+	if s.halfOpenQuota < 1 {
+		return fmt.Errorf("half_open_quota 必须大于 0: %d", s.halfOpenQuota)
+	}
+	if s.successThreshold < 1 || s.successThreshold > s.halfOpenQuota {
+		return fmt.Errorf("success_threshold 必须在 1 到 half_open_quota（%d）之间: %d", s.halfOpenQuota, s.successThreshold)
+	}
+	if s.windowSize < 1 {
+		return fmt.Errorf("window_size 必须大于 0: %d", s.windowSize)
+	}
+	return nil
+}
+
 // Init 初始化插件
 func (p *CircuitBreakerPlugin) Init(config interface{}) error {
 	// 类型断言
@@ -75,21 +200,22 @@ func (p *CircuitBreakerPlugin) Execute(ctx *gin.Context) error {
 	// 获取或创建熔断器
 	cb := p.getCircuitBreaker(target)
 
-	// 检查熔断器状态
+	// 检查熔断器状态，拒绝时中止请求，不再转发到上游
 	if !cb.allowRequest() {
-		ctx.JSON(http.StatusServiceUnavailable, gin.H{
-			"error": "服务暂时不可用",
-		})
-		ctx.Abort()
+		ctx.Header("Retry-After", strconv.Itoa(cb.retryAfter()))
+		errors.Abort(ctx, http.StatusServiceUnavailable, "服务暂时不可用")
 		return nil
 	}
 
-	// 设置响应写入器以捕获状态码
-	writer := &responseWriter{
-		ResponseWriter: ctx.Writer,
-		circuitBreaker: cb,
-	}
-	ctx.Writer = writer
+	// 设置响应写入器以捕获状态码，根据状态码更新熔断器
+	core.WrapWriter(ctx, func(code int) int {
+		if code >= 500 {
+			cb.recordFailure()
+		} else {
+			cb.recordSuccess()
+		}
+		return code
+	})
 
 	return nil
 }
@@ -116,8 +242,8 @@ func (p *CircuitBreakerPlugin) cleanupInactiveCircuitBreakers() {
 
 	now := time.Now()
 	for target, cb := range p.circuitBreakers {
-		// 如果熔断器超过30分钟未使用，则清理
-		if now.Sub(cb.lastUsed) > 30*time.Minute {
+		// 如果熔断器超过30分钟未使用且未被手动干预，则清理
+		if now.Sub(cb.lastUsed) > 30*time.Minute && atomic.LoadInt32(&cb.override) == overrideNone {
 			delete(p.circuitBreakers, target)
 		}
 	}
@@ -145,42 +271,14 @@ func (p *CircuitBreakerPlugin) getCircuitBreaker(target string) *CircuitBreaker
 		return cb
 	}
 
-	// 从配置中获取参数
-	failureThreshold := 5
-	recoveryTimeout := 30
-	halfOpenQuota := 2
-	successThreshold := 3
-	windowSize := 10
-
-	if ft, ok := p.config["failure_threshold"].(int); ok {
-		failureThreshold = ft
-	}
-	if rt, ok := p.config["recovery_timeout"].(int); ok {
-		recoveryTimeout = rt
-	}
-	if hoq, ok := p.config["half_open_quota"].(int); ok {
-		halfOpenQuota = hoq
-	}
-	if st, ok := p.config["success_threshold"].(int); ok {
-		successThreshold = st
-	}
-	if ws, ok := p.config["window_size"].(int); ok {
-		windowSize = ws
-	}
-
 	// 创建新的熔断器
+	settings := parseSettings(p.config)
 	cb = &CircuitBreaker{
 		state:         int32(StateClosed),
-		halfOpenQuota: int32(halfOpenQuota),
-		config: map[string]interface{}{
-			"failure_threshold": failureThreshold,
-			"recovery_timeout":  recoveryTimeout,
-			"half_open_quota":   halfOpenQuota,
-			"success_threshold": successThreshold,
-			"window_size":       windowSize,
-		},
-		window:   NewWindow(10, time.Duration(windowSize)*time.Second),
-		lastUsed: time.Now(),
+		halfOpenQuota: int32(settings.halfOpenQuota),
+		settings:      settings,
+		window:        NewWindow(10, time.Duration(settings.windowSize)*time.Second),
+		lastUsed:      time.Now(),
 	}
 	p.circuitBreakers[target] = cb
 
@@ -189,29 +287,31 @@ func (p *CircuitBreakerPlugin) getCircuitBreaker(target string) *CircuitBreaker
 
 // allowRequest 检查是否允许请求
 func (cb *CircuitBreaker) allowRequest() bool {
+	switch atomic.LoadInt32(&cb.override) {
+	case overrideOpen:
+		return false
+	case overrideClosed:
+		return true
+	}
+
 	state := atomic.LoadInt32(&cb.state)
 
 	switch CircuitBreakerState(state) {
 	case StateClosed:
-		// 检查失败率是否超过阈值
-		failureThreshold := cb.config["failure_threshold"].(int)
-		if cb.window.GetFailureRate() >= float64(failureThreshold)/100.0 {
-			atomic.StoreInt32(&cb.state, int32(StateOpen))
-			return false
-		}
 		return true
 	case StateOpen:
 		// 检查是否达到恢复时间
-		recoveryTimeout := cb.config["recovery_timeout"].(int)
-		if time.Since(time.Unix(0, cb.window.getCurrentBucket().timestamp)) >= time.Duration(recoveryTimeout)*time.Second {
-			// 尝试转换为半开状态
-			if atomic.CompareAndSwapInt32(&cb.state, int32(StateOpen), int32(StateHalfOpen)) {
-				halfOpenQuota := cb.config["half_open_quota"].(int)
-				atomic.StoreInt32(&cb.halfOpenQuota, int32(halfOpenQuota))
-				return true
-			}
+		openedAt := atomic.LoadInt64(&cb.openedAt)
+		if time.Since(time.Unix(0, openedAt)) < time.Duration(cb.settings.recoveryTimeout)*time.Second {
+			return false
 		}
-		return false
+		// 尝试转换为半开状态，由转换成功的请求重置探测配额
+		if atomic.CompareAndSwapInt32(&cb.state, int32(StateOpen), int32(StateHalfOpen)) {
+			atomic.StoreInt32(&cb.halfOpenSuccesses, 0)
+			atomic.StoreInt32(&cb.halfOpenQuota, int32(cb.settings.halfOpenQuota)-1)
+			return true
+		}
+		return cb.allowRequest()
 	case StateHalfOpen:
 		// 检查半开配额
 		quota := atomic.AddInt32(&cb.halfOpenQuota, -1)
@@ -224,53 +324,122 @@ func (cb *CircuitBreaker) allowRequest() bool {
 // recordFailure 记录失败
 func (cb *CircuitBreaker) recordFailure() {
 	cb.window.RecordFailure()
+	if atomic.LoadInt32(&cb.override) != overrideNone {
+		return
+	}
+	switch CircuitBreakerState(atomic.LoadInt32(&cb.state)) {
+	case StateHalfOpen:
+		// 探测失败，重新熔断
+		cb.trip(StateHalfOpen)
+	case StateClosed:
+		// 检查失败率是否超过阈值
+		failures, total := cb.window.GetStats()
+		if int(total) >= cb.settings.minRequests &&
+			float64(failures)*100 >= float64(cb.settings.failureThreshold)*float64(total) {
+			cb.trip(StateClosed)
+		}
+	}
 }
 
 // recordSuccess 记录成功
 func (cb *CircuitBreaker) recordSuccess() {
 	cb.window.RecordSuccess()
-	state := atomic.LoadInt32(&cb.state)
-	if CircuitBreakerState(state) == StateHalfOpen {
-		// 检查成功率是否达到阈值
-		successThreshold := cb.config["success_threshold"].(int)
-		if cb.window.GetFailureRate() < float64(successThreshold)/100.0 {
-			// 重置熔断器状态
-			atomic.StoreInt32(&cb.state, int32(StateClosed))
+	if CircuitBreakerState(atomic.LoadInt32(&cb.state)) != StateHalfOpen {
+		return
+	}
+	// 连续探测成功数达到阈值时恢复
+	if atomic.AddInt32(&cb.halfOpenSuccesses, 1) >= int32(cb.settings.successThreshold) {
+		if atomic.CompareAndSwapInt32(&cb.state, int32(StateHalfOpen), int32(StateClosed)) {
+			cb.window.Reset()
 		}
 	}
 }
 
-// Stop 停止插件
-func (p *CircuitBreakerPlugin) Stop() error {
-	close(p.stopCh)
-	return nil
+// retryAfter 返回距离进入半开状态的秒数，至少为 1
+func (cb *CircuitBreaker) retryAfter() int {
+	openedAt := time.Unix(0, atomic.LoadInt64(&cb.openedAt))
+	remaining := time.Until(openedAt.Add(time.Duration(cb.settings.recoveryTimeout) * time.Second))
+	if seconds := int((remaining + time.Second - 1) / time.Second); seconds > 1 {
+		return seconds
+	}
+	return 1
 }
 
-// responseWriter 响应写入器，用于捕获状态码
-type responseWriter struct {
-	gin.ResponseWriter
-	circuitBreaker *CircuitBreaker
-	statusWritten  bool
+// trip 从 from 状态切换为熔断状态
+// 先记录熔断时间再切换状态，避免并发请求读到开启状态时仍使用上一次的熔断时间；
+// openedAt 只在开启状态下使用，切换失败时覆盖也不影响其他状态
+func (cb *CircuitBreaker) trip(from CircuitBreakerState) {
+	atomic.StoreInt64(&cb.openedAt, time.Now().UnixNano())
+	atomic.CompareAndSwapInt32(&cb.state, int32(from), int32(StateOpen))
 }
 
-// WriteHeader 写入状态码
-func (w *responseWriter) WriteHeader(code int) {
-	if !w.statusWritten {
-		w.statusWritten = true
-		// 根据状态码更新熔断器
-		if code >= 500 {
-			w.circuitBreaker.recordFailure()
-		} else {
-			w.circuitBreaker.recordSuccess()
-		}
+// status 返回熔断器状态快照
+func (cb *CircuitBreaker) status(target string) Status {
+	failures, total := cb.window.GetStats()
+	return Status{
+		Target:      target,
+		State:       CircuitBreakerState(atomic.LoadInt32(&cb.state)).String(),
+		Forced:      atomic.LoadInt32(&cb.override) != overrideNone,
+		FailureRate: cb.window.GetFailureRate(),
+		Failures:    failures,
+		Requests:    total,
+		LastUsed:    cb.lastUsed,
+	}
+}
+
+// Statuses 返回所有熔断器的状态快照
+func (p *CircuitBreakerPlugin) Statuses() []Status {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	result := make([]Status, 0, len(p.circuitBreakers))
+	for target, cb := range p.circuitBreakers {
+		result = append(result, cb.status(target))
 	}
-	w.ResponseWriter.WriteHeader(code)
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Target < result[j].Target
+	})
+	return result
 }
 
-// Write 写入响应体
-func (w *responseWriter) Write(data []byte) (int, error) {
-	if !w.statusWritten {
-		w.WriteHeader(http.StatusOK)
+// SetState 手动设置目标服务的熔断器状态
+// open、closed 在目标服务尚无熔断器时创建，reset 只作用于已有的熔断器
+func (p *CircuitBreakerPlugin) SetState(target, action string) (Status, error) {
+	var cb *CircuitBreaker
+	switch action {
+	case ActionOpen, ActionClosed:
+		cb = p.getCircuitBreaker(target)
+	case ActionReset:
+		p.mu.RLock()
+		cb = p.circuitBreakers[target]
+		p.mu.RUnlock()
+		if cb == nil {
+			return Status{}, fmt.Errorf("目标服务 %s 没有熔断器", target)
+		}
+	default:
+		return Status{}, fmt.Errorf("无效的熔断器操作: %s，可选 open、closed、reset", action)
+	}
+
+	switch action {
+	case ActionOpen:
+		atomic.StoreInt32(&cb.override, overrideOpen)
+		atomic.StoreInt64(&cb.openedAt, time.Now().UnixNano())
+		atomic.StoreInt32(&cb.state, int32(StateOpen))
+	case ActionClosed:
+		atomic.StoreInt32(&cb.override, overrideClosed)
+		atomic.StoreInt32(&cb.state, int32(StateClosed))
+		cb.window.Reset()
+	case ActionReset:
+		atomic.StoreInt32(&cb.state, int32(StateClosed))
+		atomic.StoreInt32(&cb.halfOpenSuccesses, 0)
+		cb.window.Reset()
+		atomic.StoreInt32(&cb.override, overrideNone)
 	}
-	return w.ResponseWriter.Write(data)
+	return cb.status(target), nil
+}
+
+// Stop 停止插件
+func (p *CircuitBreakerPlugin) Stop() error {
+	close(p.stopCh)
+	return nil
 }
@@ -0,0 +1,241 @@
+package circuitbreaker
+
+import (
+	"bytes"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+func TestParseSettingsNumericTypes(t *testing.T) {
+	want := settings{
+		failureThreshold: 40,
+		minRequests:      5,
+		recoveryTimeout:  7,
+		halfOpenQuota:    3,
+		successThreshold: 2,
+		windowSize:       20,
+	}
+	tests := []struct {
+		name   string
+		config map[string]interface{}
+	}{
+		{"int", map[string]interface{}{
+			"failure_threshold": 40, "min_requests": 5, "recovery_timeout": 7,
+			"half_open_quota": 3, "success_threshold": 2, "window_size": 20,
+		}},
+		{"int64", map[string]interface{}{
+			"failure_threshold": int64(40), "min_requests": int64(5), "recovery_timeout": int64(7),
+			"half_open_quota": int64(3), "success_threshold": int64(2), "window_size": int64(20),
+		}},
+		// JSON 解码（管理API）得到 float64
+		{"float64", map[string]interface{}{
+			"failure_threshold": 40.0, "min_requests": 5.0, "recovery_timeout": 7.0,
+			"half_open_quota": 3.0, "success_threshold": 2.0, "window_size": 20.0,
+		}},
+		// 环境变量覆盖得到字符串
+		{"string", map[string]interface{}{
+			"failure_threshold": "40", "min_requests": "5", "recovery_timeout": "7",
+			"half_open_quota": "3", "success_threshold": "2", "window_size": "20",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := New().ValidateConfig(tt.config); err != nil {
+				t.Fatalf("校验配置失败: %v", err)
+			}
+			if got := parseSettings(tt.config); got != want {
+				t.Fatalf("parseSettings = %+v，期望 %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestParseSettingsFromViper(t *testing.T) {
+	v := viper.New()
+	v.SetConfigType("yaml")
+	yaml := []byte(`
+config:
+  failure_threshold: 40
+  min_requests: 5
+  recovery_timeout: 7
+`)
+	if err := v.ReadConfig(bytes.NewReader(yaml)); err != nil {
+		t.Fatal(err)
+	}
+	config := v.GetStringMap("config")
+
+	got := parseSettings(config)
+	if got.failureThreshold != 40 || got.minRequests != 5 || got.recoveryTimeout != 7 {
+		t.Fatalf("viper 解析的配置未生效: %+v", got)
+	}
+	// 未配置的参数使用默认值
+	if got.halfOpenQuota != 2 || got.successThreshold != 2 || got.windowSize != 10 {
+		t.Fatalf("未配置的参数未使用默认值: %+v", got)
+	}
+}
+
+func TestValidateConfigRejectsInvalidSettings(t *testing.T) {
+	for name, config := range map[string]map[string]interface{}{
+		"失败率超过100":   {"failure_threshold": 150},
+		"非整数":        {"min_requests": 1.5},
+		"成功阈值超过探测配额": {"half_open_quota": 1, "success_threshold": 2},
+		"非数字字符串":     {"window_size": "ten"},
+	} {
+		if err := New().ValidateConfig(config); err == nil {
+			t.Fatalf("%s: 期望校验失败", name)
+		}
+	}
+}
+
+// newBreaker 按配置创建单个熔断器（不启动插件的清理协程）
+func newBreaker(config map[string]interface{}) *CircuitBreaker {
+	p := New()
+	p.config = config
+	return p.getCircuitBreaker("http://upstream")
+}
+
+// currentState 返回熔断器当前状态
+func (cb *CircuitBreaker) currentState() CircuitBreakerState {
+	return CircuitBreakerState(atomic.LoadInt32(&cb.state))
+}
+
+// expireOpen 将熔断时间提前，使恢复超时立即到期
+func (cb *CircuitBreaker) expireOpen() {
+	atomic.StoreInt64(&cb.openedAt, time.Now().Add(-time.Duration(cb.settings.recoveryTimeout)*time.Second-time.Millisecond).UnixNano())
+}
+
+func TestCircuitBreakerOpensAtFailureRate(t *testing.T) {
+	cb := newBreaker(map[string]interface{}{"failure_threshold": 50, "min_requests": 4})
+
+	// 请求数未达到 min_requests 时不熔断
+	cb.recordFailure()
+	cb.recordFailure()
+	cb.recordFailure()
+	if cb.currentState() != StateClosed {
+		t.Fatal("请求数不足 min_requests 时不应熔断")
+	}
+
+	// 失败率 3/4 达到 50%，但熔断只在记录失败时判断
+	cb.recordSuccess()
+	if cb.currentState() != StateClosed {
+		t.Fatal("记录成功时不应熔断")
+	}
+	cb.recordFailure()
+	if cb.currentState() != StateOpen || cb.allowRequest() {
+		t.Fatalf("失败率 4/5 达到阈值后状态 = %s，期望熔断并拒绝请求", cb.currentState())
+	}
+}
+
+func TestCircuitBreakerStaysClosedBelowThreshold(t *testing.T) {
+	cb := newBreaker(map[string]interface{}{"failure_threshold": 60, "min_requests": 4})
+	// 失败率始终低于 60%（最高 2/4）
+	for _, failed := range []bool{true, false, false, true, false} {
+		if failed {
+			cb.recordFailure()
+		} else {
+			cb.recordSuccess()
+		}
+	}
+	if cb.currentState() != StateClosed || !cb.allowRequest() {
+		t.Fatalf("失败率低于阈值时状态 = %s，期望保持关闭", cb.currentState())
+	}
+}
+
+func TestCircuitBreakerClosesAfterSuccessfulProbes(t *testing.T) {
+	cb := newBreaker(map[string]interface{}{
+		"failure_threshold": 50, "min_requests": 1, "half_open_quota": 3, "success_threshold": 2,
+	})
+	cb.recordFailure()
+	if cb.currentState() != StateOpen {
+		t.Fatal("失败后应熔断")
+	}
+
+	// 恢复超时后进入半开状态，放行 half_open_quota 个探测请求
+	cb.expireOpen()
+	for i := 0; i < 3; i++ {
+		if !cb.allowRequest() {
+			t.Fatalf("半开状态第 %d 个探测请求被拒绝", i+1)
+		}
+	}
+	if cb.currentState() != StateHalfOpen || cb.allowRequest() {
+		t.Fatal("超过探测配额的请求应被拒绝")
+	}
+
+	// 连续 success_threshold 个探测成功后恢复
+	cb.recordSuccess()
+	if cb.currentState() != StateHalfOpen {
+		t.Fatal("探测成功数未达到 success_threshold 时不应恢复")
+	}
+	cb.recordSuccess()
+	if cb.currentState() != StateClosed || !cb.allowRequest() {
+		t.Fatalf("探测成功后状态 = %s，期望恢复关闭", cb.currentState())
+	}
+	// 恢复后统计窗口被清空
+	if failures, total := cb.window.GetStats(); failures != 0 || total != 0 {
+		t.Fatalf("恢复后统计窗口 = %d/%d，期望清空", failures, total)
+	}
+}
+
+func TestCircuitBreakerReopensOnFailedProbe(t *testing.T) {
+	cb := newBreaker(map[string]interface{}{"failure_threshold": 50, "min_requests": 1})
+	cb.recordFailure()
+	cb.expireOpen()
+	if !cb.allowRequest() || cb.currentState() != StateHalfOpen {
+		t.Fatal("恢复超时后应进入半开状态")
+	}
+	cb.recordFailure()
+	if cb.currentState() != StateOpen || cb.allowRequest() {
+		t.Fatalf("探测失败后状态 = %s，期望重新熔断", cb.currentState())
+	}
+}
+
+func TestCircuitBreakerHalfOpensAfterRecoveryTimeout(t *testing.T) {
+	cb := newBreaker(map[string]interface{}{"min_requests": 1, "recovery_timeout": 10})
+	cb.recordFailure()
+
+	// 距恢复超时还差 100ms 时仍拒绝请求
+	atomic.StoreInt64(&cb.openedAt, time.Now().Add(-10*time.Second+100*time.Millisecond).UnixNano())
+	if cb.allowRequest() || cb.currentState() != StateOpen {
+		t.Fatal("恢复超时到期前不应进入半开状态")
+	}
+	if got := cb.retryAfter(); got != 1 {
+		t.Fatalf("retryAfter = %d，期望 1", got)
+	}
+
+	// 恢复超时从熔断时间开始计算，与统计窗口无关
+	atomic.StoreInt64(&cb.openedAt, time.Now().Add(-10*time.Second).UnixNano())
+	if !cb.allowRequest() || cb.currentState() != StateHalfOpen {
+		t.Fatal("恢复超时到期后应进入半开状态")
+	}
+}
+
+func TestCircuitBreakerRecoveryTiming(t *testing.T) {
+	cb := newBreaker(map[string]interface{}{"min_requests": 1, "recovery_timeout": 1})
+	cb.recordFailure()
+	opened := time.Now()
+
+	time.Sleep(800 * time.Millisecond)
+	if cb.allowRequest() {
+		t.Fatal("熔断 800ms 后（恢复超时 1s）不应放行请求")
+	}
+	if got := cb.retryAfter(); got != 1 {
+		t.Fatalf("retryAfter = %d，期望 1", got)
+	}
+
+	time.Sleep(time.Second - time.Since(opened) + 50*time.Millisecond)
+	if !cb.allowRequest() || cb.currentState() != StateHalfOpen {
+		t.Fatal("恢复超时到期后应进入半开状态")
+	}
+
+	// 重新熔断时重新计时
+	cb.recordFailure()
+	if cb.allowRequest() {
+		t.Fatal("探测失败重新熔断后应重新计算恢复超时")
+	}
+	if got := cb.retryAfter(); got != 1 {
+		t.Fatalf("重新熔断后 retryAfter = %d，期望 1", got)
+	}
+}
@@ -40,16 +40,26 @@ func NewWindow(size int, windowSize time.Duration) *Window {
 
 // RecordFailure 记录失败
 func (w *Window) RecordFailure() {
-	w.getCurrentBucket().failures++
+	atomic.AddInt32(&w.getCurrentBucket().failures, 1)
 }
 
 // RecordSuccess 记录成功
 func (w *Window) RecordSuccess() {
-	w.getCurrentBucket().successes++
+	atomic.AddInt32(&w.getCurrentBucket().successes, 1)
 }
 
 // GetFailureRate 获取失败率
 func (w *Window) GetFailureRate() float64 {
+	totalFailures, totalRequests := w.GetStats()
+	if totalRequests == 0 {
+		return 0
+	}
+
+	return float64(totalFailures) / float64(totalRequests)
+}
+
+// GetStats 获取窗口内的失败数和请求总数
+func (w *Window) GetStats() (int32, int32) {
 	var totalFailures, totalRequests int32
 
 	now := time.Now().UnixNano()
@@ -67,11 +77,17 @@ func (w *Window) GetFailureRate() float64 {
 		totalRequests += failures + successes
 	}
 
-	if totalRequests == 0 {
-		return 0
-	}
+	return totalFailures, totalRequests
+}
 
-	return float64(totalFailures) / float64(totalRequests)
+// Reset 清空窗口内的统计
+func (w *Window) Reset() {
+	now := time.Now().UnixNano()
+	for i := range w.buckets {
+		w.buckets[i] = &Bucket{
+			timestamp: now,
+		}
+	}
 }
 
 // getCurrentBucket 获取当前时间桶
@@ -1,7 +1,7 @@
 package consistency
 
 import (
-	"bufio"
+	"bytes"
 	"crypto"
 	"crypto/ecdsa"
 	"crypto/ed25519"
@@ -11,16 +11,17 @@ import (
 	"crypto/sha256"
 	"crypto/x509"
 	"encoding/hex"
+	"encoding/json"
 	"encoding/pem"
 	"fmt"
 	"math"
-	"net"
 	"net/http"
 	"strconv"
 	"strings"
 	"sync"
 	"time"
 
+	"gateway-go/internal/errors"
 	"gateway-go/internal/plugin/core"
 
 	"github.com/gin-gonic/gin"
@@ -80,6 +81,23 @@ func New() *ConsistencyPlugin {
 	}
 }
 
+// configSchema 插件配置结构
+var configSchema = core.ConfigSchema{
+	"enabled":            core.FieldBool,
+	"algorithm":          core.FieldString,
+	"secret":             core.FieldString,
+	"public_key":         core.FieldString,
+	"fields":             core.FieldStringList,
+	"signature_field":    core.FieldString,
+	"check_response":     core.FieldBool,
+	"timestamp_validity": core.FieldInt,
+}
+
+// ValidateConfig 校验插件配置
+func (p *ConsistencyPlugin) ValidateConfig(config map[string]interface{}) error {
+	return configSchema.Validate(config)
+}
+
 // Init 初始化插件
 func (p *ConsistencyPlugin) Init(config interface{}) error {
 	if config == nil {
@@ -117,10 +135,47 @@ func (p *ConsistencyPlugin) Init(config interface{}) error {
 	if checkResponse, ok := configMap["check_response"].(bool); ok {
 		p.config.CheckResponse = checkResponse
 	}
-	if timestampValidity, ok := configMap["timestamp_validity"].(int64); ok {
-		p.config.TimestampValidity = timestampValidity
+	if timestampValidity, ok := core.ToInt(configMap["timestamp_validity"]); ok {
+		p.config.TimestampValidity = int64(timestampValidity)
+	}
+
+	// 非对称算法在初始化时解析公钥，避免配置错误到请求时才暴露
+	switch p.config.Algorithm {
+	case AlgorithmRSA, AlgorithmECDSA, AlgorithmEd25519:
+		if err := checkPublicKey(p.config.Algorithm, p.config.PublicKey); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// checkPublicKey 校验公钥为 PEM 格式且与算法匹配
+func checkPublicKey(algorithm Algorithm, publicKey string) error {
+	if publicKey == "" {
+		return fmt.Errorf("%s public key not configured", algorithm)
+	}
+	block, _ := pem.Decode([]byte(publicKey))
+	if block == nil {
+		return fmt.Errorf("failed to parse %s public key: invalid PEM", algorithm)
+	}
+	pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return fmt.Errorf("failed to parse %s public key: %v", algorithm, err)
 	}
 
+	var ok bool
+	switch algorithm {
+	case AlgorithmRSA:
+		_, ok = pub.(*rsa.PublicKey)
+	case AlgorithmECDSA:
+		_, ok = pub.(*ecdsa.PublicKey)
+	case AlgorithmEd25519:
+		_, ok = pub.(ed25519.PublicKey)
+	}
+	if !ok {
+		return fmt.Errorf("not an %s public key", algorithm)
+	}
 	return nil
 }
 
@@ -132,21 +187,15 @@ func (p *ConsistencyPlugin) Execute(c *gin.Context) error {
 
 	// 校验请求
 	if err := p.checkRequest(c); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": err.Error(),
-		})
-		c.Abort()
+		errors.Abort(c, http.StatusBadRequest, err.Error())
 		return err
 	}
 
-	// 如果需要校验响应
+	// 如果需要校验响应，缓存带签名的响应体，校验通过后在请求结束时写出
 	if p.config.CheckResponse {
-		c.Writer = &responseWriter{
-			ResponseWriter: c.Writer,
-			body:           []byte{},
-			plugin:         p,
-			context:        c,
-		}
+		w := &responseWriter{ResponseWriter: c.Writer, context: c, plugin: p}
+		c.Writer = w
+		core.OnComplete(c, w.flush)
 	}
 
 	return nil
@@ -374,67 +423,114 @@ func (p *ConsistencyPlugin) calculateEd25519Signature(content string) (string, e
 	return hex.EncodeToString(hashed), nil
 }
 
-// responseWriter 响应写入器
+// maxResponseBody 校验签名时缓存的响应体上限，超出时无法校验，按校验失败处理
+const maxResponseBody = 10 << 20
+
+// responseWriter 缓存带签名的成功响应，请求结束时按完整响应体校验签名后写出
 type responseWriter struct {
 	gin.ResponseWriter
-	body    []byte
-	plugin  *ConsistencyPlugin
 	context *gin.Context
+	plugin  *ConsistencyPlugin
+	// 状态码已写入（直接写出或等待校验）
+	statusWritten bool
+	// 是否正在缓存响应，等待校验签名
+	buffering bool
+	// 响应体超过 maxResponseBody
+	overflow bool
+	body     bytes.Buffer
 }
 
-// Write 写入响应
-func (w *responseWriter) Write(b []byte) (int, error) {
-	w.body = append(w.body, b...)
-	return w.ResponseWriter.Write(b)
+// WriteHeader 写入状态码，带签名的 200 响应延迟到请求结束时写出；流式响应不校验
+func (w *responseWriter) WriteHeader(code int) {
+	if w.statusWritten {
+		return
+	}
+	w.statusWritten = true
+	if code == http.StatusOK && !core.IsStreaming(w.context) &&
+		w.ResponseWriter.Header().Get(w.plugin.config.SignatureField) != "" {
+		w.buffering = true
+		return
+	}
+	w.ResponseWriter.WriteHeader(code)
 }
 
-// WriteString 写入字符串响应
-func (w *responseWriter) WriteString(s string) (int, error) {
-	w.body = append(w.body, []byte(s)...)
-	return w.ResponseWriter.WriteString(s)
+// Write 写入响应体，等待校验时缓存
+func (w *responseWriter) Write(data []byte) (int, error) {
+	if !w.statusWritten {
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.buffering {
+		if w.body.Len()+len(data) > maxResponseBody {
+			w.overflow = true
+		} else {
+			w.body.Write(data)
+		}
+		return len(data), nil
+	}
+	return w.ResponseWriter.Write(data)
 }
 
-// WriteHeader 写入响应头
-func (w *responseWriter) WriteHeader(code int) {
-	// 如果响应成功，校验响应内容
-	if code == http.StatusOK {
-		// 获取响应签名
-		signature := w.Header().Get(w.plugin.config.SignatureField)
-		if signature != "" {
-			// 计算响应签名
-			calculatedSignature, err := w.plugin.calculateSignature([]string{string(w.body)})
-			if err != nil {
-				w.ResponseWriter.WriteHeader(http.StatusInternalServerError)
-				return
-			}
+// WriteString 写入字符串响应体
+func (w *responseWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
 
-			// 比较签名
-			if calculatedSignature != signature {
-				w.ResponseWriter.WriteHeader(http.StatusInternalServerError)
-				return
-			}
-		}
+// Status 等待校验时返回上游的状态码
+func (w *responseWriter) Status() int {
+	if w.buffering {
+		return http.StatusOK
 	}
-
-	w.ResponseWriter.WriteHeader(code)
+	return w.ResponseWriter.Status()
 }
 
-// Close 关闭响应写入器
-func (w *responseWriter) Close() error {
-	return nil
+// Written 等待校验的响应视为已写出，避免其他处理再次写入响应
+func (w *responseWriter) Written() bool {
+	return w.buffering || w.ResponseWriter.Written()
 }
 
-// Flush 刷新响应写入器
+// Flush 等待校验时不刷新
 func (w *responseWriter) Flush() {
-	w.ResponseWriter.Flush()
+	if !w.buffering {
+		w.ResponseWriter.Flush()
+	}
 }
 
-// Hijack 实现 http.Hijacker 接口
-func (w *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
-	return w.ResponseWriter.(http.Hijacker).Hijack()
+// Unwrap 返回被包装的写入器，供 http.ResponseController 访问底层连接
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// flush 校验缓存的响应签名，通过时写出原响应，否则返回 500
+func (w *responseWriter) flush() {
+	if !w.buffering {
+		return
+	}
+	w.buffering = false
+
+	if !w.overflow && w.plugin.checkResponse(w.ResponseWriter.Header(), w.body.Bytes()) {
+		w.ResponseWriter.WriteHeader(http.StatusOK)
+		w.ResponseWriter.Write(w.body.Bytes())
+		return
+	}
+
+	body, err := json.Marshal(errors.NewResponse(w.context, http.StatusInternalServerError, "invalid response signature", nil))
+	if err != nil {
+		return
+	}
+	header := w.ResponseWriter.Header()
+	header.Del(w.plugin.config.SignatureField)
+	header.Del("Content-Encoding")
+	header.Set("Content-Type", "application/json; charset=utf-8")
+	header.Set("Content-Length", strconv.Itoa(len(body)))
+	w.ResponseWriter.WriteHeader(http.StatusInternalServerError)
+	w.ResponseWriter.Write(body)
 }
 
-// Pusher 实现 http.Pusher 接口
-func (w *responseWriter) Pusher() http.Pusher {
-	return w.ResponseWriter.(http.Pusher)
+// checkResponse 按完整响应体计算签名，与响应头中的签名比较
+func (p *ConsistencyPlugin) checkResponse(header http.Header, body []byte) bool {
+	calculatedSignature, err := p.calculateSignature([]string{string(body)})
+	if err != nil {
+		return false
+	}
+	return hmac.Equal([]byte(calculatedSignature), []byte(header.Get(p.config.SignatureField)))
 }
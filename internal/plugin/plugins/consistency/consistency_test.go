@@ -0,0 +1,122 @@
+package consistency
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"gateway-go/internal/plugin/core"
+
+	"github.com/gin-gonic/gin"
+)
+
+// sign 计算 hmac-sha256 签名
+func sign(secret string, content string) string {
+	h := hmac.New(sha256.New, []byte(secret))
+	h.Write([]byte(content))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// newEngine 创建执行插件后由 handler 写出响应的引擎，请求结束时执行插件注册的完成回调
+func newEngine(p *ConsistencyPlugin, handler gin.HandlerFunc) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		defer core.RunCompletions(c)
+		if err := p.Execute(c); err != nil || c.IsAborted() {
+			return
+		}
+		c.Next()
+	})
+	r.GET("/", handler)
+	return r
+}
+
+// signedRequest 创建携带有效请求签名的请求
+func signedRequest(secret, nonce string) *http.Request {
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("timestamp", timestamp)
+	req.Header.Set("nonce", nonce)
+	req.Header.Set("X-Signature", sign(secret, timestamp+"&"+nonce))
+	return req
+}
+
+func TestCheckRequestSignature(t *testing.T) {
+	p := New()
+	if err := p.Init(map[string]interface{}{"secret": "s3cret"}); err != nil {
+		t.Fatalf("初始化插件失败: %v", err)
+	}
+	r := newEngine(p, func(c *gin.Context) { c.String(http.StatusOK, "ok") })
+
+	tests := []struct {
+		name   string
+		req    func() *http.Request
+		status int
+	}{
+		{"有效签名", func() *http.Request { return signedRequest("s3cret", "n1") }, http.StatusOK},
+		{"重放的 nonce", func() *http.Request { return signedRequest("s3cret", "n1") }, http.StatusBadRequest},
+		{"签名错误", func() *http.Request { return signedRequest("other", "n2") }, http.StatusBadRequest},
+		{"缺少签名", func() *http.Request { return httptest.NewRequest(http.MethodGet, "/", nil) }, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, tt.req())
+			if rec.Code != tt.status {
+				t.Fatalf("状态码 = %d，期望 %d", rec.Code, tt.status)
+			}
+		})
+	}
+}
+
+func TestCheckResponseSignsBody(t *testing.T) {
+	p := New()
+	if err := p.Init(map[string]interface{}{"secret": "s3cret", "check_response": true}); err != nil {
+		t.Fatalf("初始化插件失败: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		status    int
+		body      string
+		signature string
+		want      int
+		wantBody  string
+	}{
+		{"签名与响应体一致", http.StatusOK, `{"id":1}`, sign("s3cret", `{"id":1}`), http.StatusOK, `{"id":1}`},
+		// 响应体被篡改，签名仍为原响应体的签名
+		{"响应体被篡改", http.StatusOK, `{"id":2}`, sign("s3cret", `{"id":1}`), http.StatusInternalServerError, ""},
+		{"空响应体的签名不能用于非空响应", http.StatusOK, `{"id":1}`, sign("s3cret", ""), http.StatusInternalServerError, ""},
+		{"无签名不校验", http.StatusOK, `{"id":1}`, "", http.StatusOK, `{"id":1}`},
+		{"非 200 不校验", http.StatusNotFound, "missing", "bad", http.StatusNotFound, "missing"},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newEngine(p, func(c *gin.Context) {
+				if tt.signature != "" {
+					c.Header("X-Signature", tt.signature)
+				}
+				c.String(tt.status, tt.body)
+			})
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, signedRequest("s3cret", "resp-"+strconv.Itoa(i)))
+
+			if rec.Code != tt.want {
+				t.Fatalf("状态码 = %d，期望 %d（响应体 %q）", rec.Code, tt.want, rec.Body.String())
+			}
+			if tt.wantBody != "" && rec.Body.String() != tt.wantBody {
+				t.Fatalf("响应体 = %q，期望 %q", rec.Body.String(), tt.wantBody)
+			}
+			// 校验失败时不写出上游的响应体
+			if tt.want == http.StatusInternalServerError && rec.Body.String() == tt.body {
+				t.Fatal("签名校验失败时仍写出了上游响应体")
+			}
+		})
+	}
+}
@@ -0,0 +1,188 @@
+package contenttype
+
+import (
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"strings"
+	"sync"
+
+	"gateway-go/internal/errors"
+	"gateway-go/internal/plugin/core"
+
+	"github.com/gin-gonic/gin"
+)
+
+// defaultMaxBodySize 默认校验的请求体上限
+const defaultMaxBodySize = 1 << 20
+
+// ContentTypePlugin 请求体类型校验插件
+// 拒绝 Content-Type 不在允许列表中的请求，可选校验 JSON 请求体格式
+type ContentTypePlugin struct {
+	*core.BasePlugin
+	settings *settings
+	mu       sync.RWMutex
+}
+
+// settings 解析后的插件配置
+type settings struct {
+	// 允许的媒体类型，小写；以 /* 结尾的表示该主类型下的任意子类型
+	allowed      []string
+	validateJSON bool
+	maxBodySize  int64
+}
+
+// New 创建请求体类型校验插件
+func New() *ContentTypePlugin {
+	return &ContentTypePlugin{
+		BasePlugin: core.NewBasePlugin("content_type", 18, nil),
+	}
+}
+
+// configSchema 插件配置结构
+var configSchema = core.ConfigSchema{
+	"allowed_content_types": core.FieldStringList,
+	"validate_json":         core.FieldBool,
+	"max_body_size":         core.FieldInt,
+}
+
+// ValidateConfig 校验插件配置
+func (p *ContentTypePlugin) ValidateConfig(config map[string]interface{}) error {
+	if err := configSchema.Validate(config); err != nil {
+		return err
+	}
+	_, err := parseSettings(config)
+	return err
+}
+
+// Init 初始化插件
+func (p *ContentTypePlugin) Init(config interface{}) error {
+	// 类型断言
+	configMap, ok := config.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("配置类型错误，期望 map[string]interface{}")
+	}
+
+	s, err := parseSettings(configMap)
+	if err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	p.settings = s
+	p.mu.Unlock()
+	return nil
+}
+
+// parseSettings 解析插件配置
+func parseSettings(config map[string]interface{}) (*settings, error) {
+	s := &settings{
+		maxBodySize: defaultMaxBodySize,
+	}
+
+	if types, ok := config["allowed_content_types"].([]interface{}); ok {
+		for i, item := range types {
+			value, _ := item.(string)
+			mediaType, _, err := mime.ParseMediaType(value)
+			if err != nil || !strings.Contains(mediaType, "/") {
+				return nil, fmt.Errorf("allowed_content_types[%d] 不是有效的媒体类型: %q", i, value)
+			}
+			s.allowed = append(s.allowed, mediaType)
+		}
+	}
+	if validate, ok := config["validate_json"].(bool); ok {
+		s.validateJSON = validate
+	}
+	if size, ok := core.ToInt(config["max_body_size"]); ok {
+		if size <= 0 {
+			return nil, fmt.Errorf("max_body_size 必须大于 0")
+		}
+		s.maxBodySize = int64(size)
+	}
+	if len(s.allowed) == 0 && !s.validateJSON {
+		return nil, fmt.Errorf("allowed_content_types 和 validate_json 至少需要配置一项")
+	}
+	return s, nil
+}
+
+// Execute 执行插件
+func (p *ContentTypePlugin) Execute(ctx *gin.Context) error {
+	p.mu.RLock()
+	s := p.settings
+	p.mu.RUnlock()
+	if s == nil {
+		return nil
+	}
+
+	status, err := s.check(ctx.Request)
+	if err != nil {
+		errors.Abort(ctx, status, err.Error())
+		return err
+	}
+	return nil
+}
+
+// check 校验请求体类型和格式，失败时返回响应状态码和错误
+func (s *settings) check(req *http.Request) (int, error) {
+	// 没有请求体的请求（如 GET）不校验
+	if !hasBody(req) {
+		return 0, nil
+	}
+
+	contentType := req.Header.Get("Content-Type")
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType = ""
+	}
+	if len(s.allowed) > 0 && !s.allows(mediaType) {
+		if contentType == "" {
+			return http.StatusUnsupportedMediaType, fmt.Errorf("缺少 Content-Type")
+		}
+		return http.StatusUnsupportedMediaType, fmt.Errorf("不支持的 Content-Type: %s", contentType)
+	}
+
+	if s.validateJSON && isJSON(mediaType) {
+		body, err := core.ReadBody(req, s.maxBodySize)
+		if err != nil {
+			if err == core.ErrBodyTooLarge {
+				return http.StatusRequestEntityTooLarge, err
+			}
+			return http.StatusBadRequest, fmt.Errorf("读取请求体失败")
+		}
+		if !json.Valid(body) {
+			return http.StatusBadRequest, fmt.Errorf("请求体不是有效的 JSON")
+		}
+	}
+	return 0, nil
+}
+
+// allows 判断媒体类型是否在允许列表中
+func (s *settings) allows(mediaType string) bool {
+	if mediaType == "" {
+		return false
+	}
+	for _, allowed := range s.allowed {
+		if allowed == mediaType {
+			return true
+		}
+		if prefix, ok := strings.CutSuffix(allowed, "*"); ok && strings.HasSuffix(prefix, "/") && strings.HasPrefix(mediaType, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasBody 判断请求是否带有请求体
+func hasBody(req *http.Request) bool {
+	if req.Body == nil || req.Body == http.NoBody {
+		return false
+	}
+	// 分块传输时 ContentLength 为 -1
+	return req.ContentLength != 0
+}
+
+// isJSON 判断媒体类型是否为 JSON（application/json 或 +json 后缀，如 application/problem+json）
+func isJSON(mediaType string) bool {
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
@@ -0,0 +1,96 @@
+package contenttype
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"gateway-go/internal/plugin/plugintest"
+
+	"github.com/gin-gonic/gin"
+)
+
+// execute 执行插件，返回响应状态码（放行时为 0）和插件处理后的请求
+func execute(p *ContentTypePlugin, method, contentType, body string) (int, *http.Request) {
+	gin.SetMode(gin.TestMode)
+	rec := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(rec)
+	c.Request = httptest.NewRequest(method, "/orders", strings.NewReader(body))
+	if body == "" {
+		c.Request.Body = http.NoBody
+		c.Request.ContentLength = 0
+	}
+	if contentType != "" {
+		c.Request.Header.Set("Content-Type", contentType)
+	}
+	p.Execute(c)
+	if !c.IsAborted() {
+		return 0, c.Request
+	}
+	return rec.Code, c.Request
+}
+
+func TestContentTypeValidation(t *testing.T) {
+	p := plugintest.Init(t, New(), map[string]interface{}{
+		"allowed_content_types": []interface{}{"application/json", "text/*"},
+		"validate_json":         true,
+		"max_body_size":         64,
+	})
+
+	tests := []struct {
+		name        string
+		method      string
+		contentType string
+		body        string
+		status      int
+	}{
+		{"允许的 JSON", http.MethodPost, "application/json; charset=utf-8", `{"id":1}`, 0},
+		{"通配的子类型", http.MethodPost, "text/plain", "not json", 0},
+		{"不允许的类型", http.MethodPost, "application/xml", "<id>1</id>", http.StatusUnsupportedMediaType},
+		{"缺少 Content-Type", http.MethodPost, "", `{"id":1}`, http.StatusUnsupportedMediaType},
+		{"无效的 Content-Type", http.MethodPost, "json;;", `{"id":1}`, http.StatusUnsupportedMediaType},
+		{"格式错误的 JSON", http.MethodPost, "application/json", `{"id":`, http.StatusBadRequest},
+		{"请求体过大", http.MethodPut, "application/json", `{"data":"` + strings.Repeat("x", 64) + `"}`, http.StatusRequestEntityTooLarge},
+		{"没有请求体时不校验", http.MethodGet, "", "", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, req := execute(p, tt.method, tt.contentType, tt.body)
+			if status != tt.status {
+				t.Fatalf("状态码 = %d，期望 %d", status, tt.status)
+			}
+			// 放行时请求体保持完整，供后续代理转发
+			if status == 0 {
+				if body, _ := io.ReadAll(req.Body); string(body) != tt.body {
+					t.Fatalf("转发的请求体 = %q，期望 %q", body, tt.body)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateJSONOnly(t *testing.T) {
+	p := plugintest.Init(t, New(), map[string]interface{}{"validate_json": true})
+
+	// 未配置允许列表时不限制类型，只校验 JSON 类型的请求体
+	if status, _ := execute(p, http.MethodPost, "application/xml", "<id>"); status != 0 {
+		t.Fatalf("非 JSON 类型的状态码 = %d，期望放行", status)
+	}
+	if status, _ := execute(p, http.MethodPost, "application/problem+json", "{"); status != http.StatusBadRequest {
+		t.Fatalf("+json 类型的格式错误请求体状态码 = %d，期望 400", status)
+	}
+}
+
+func TestParseSettingsErrors(t *testing.T) {
+	for name, config := range map[string]map[string]interface{}{
+		"未配置任何校验":  {},
+		"无效的媒体类型":  {"allowed_content_types": []interface{}{"json"}},
+		"请求体上限为 0": {"validate_json": true, "max_body_size": 0},
+	} {
+		if _, err := parseSettings(config); err == nil {
+			t.Fatalf("%s: 期望解析失败", name)
+		}
+	}
+}
@@ -4,15 +4,42 @@ import (
 	"fmt"
 	"gateway-go/internal/plugin/core"
 	"net/http"
+	"regexp"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
+// 默认配置
+const (
+	defaultAllowedMethods = "GET, POST, PUT, DELETE, OPTIONS"
+	defaultAllowedHeaders = "*"
+	defaultExposedHeaders = "Content-Length"
+	defaultMaxAge         = 43200 // 默认12小时
+
+	// regexOriginPrefix 正则表达式源的前缀，如 regex:^https://app[0-9]+\.example\.com$
+	regexOriginPrefix = "regex:"
+)
+
+// originPolicy 针对一组源的跨域策略
+type originPolicy struct {
+	origins []string
+	// 由通配符源和正则表达式源编译的匹配规则
+	patterns         []*regexp.Regexp
+	allowedMethods   string
+	allowedHeaders   string
+	exposedHeaders   string
+	maxAge           int
+	allowCredentials bool
+}
+
 // CorsPlugin CORS插件
 type CorsPlugin struct {
 	*core.BasePlugin
-	config map[string]interface{}
+	config   map[string]interface{}
+	policies []*originPolicy
 }
 
 // New 创建CORS插件
@@ -22,6 +49,45 @@ func New() *CorsPlugin {
 	}
 }
 
+// configSchema 插件配置结构
+var configSchema = core.ConfigSchema{
+	"allowed_origins":   core.FieldStringList,
+	"allowed_methods":   core.FieldStringList,
+	"allowed_headers":   core.FieldStringList,
+	"exposed_headers":   core.FieldStringList,
+	"max_age":           core.FieldDuration,
+	"allow_credentials": core.FieldBool,
+	"policies":          core.FieldList,
+}
+
+// policySchema 策略块配置结构
+var policySchema = core.ConfigSchema{
+	"origins":           core.FieldStringList,
+	"allowed_methods":   core.FieldStringList,
+	"allowed_headers":   core.FieldStringList,
+	"exposed_headers":   core.FieldStringList,
+	"max_age":           core.FieldDuration,
+	"allow_credentials": core.FieldBool,
+}
+
+// ValidateConfig 校验插件配置
+func (p *CorsPlugin) ValidateConfig(config map[string]interface{}) error {
+	if err := configSchema.Validate(config); err != nil {
+		return err
+	}
+	if blocks, ok := config["policies"].([]interface{}); ok {
+		for i, block := range blocks {
+			if blockMap, ok := block.(map[string]interface{}); ok {
+				if err := policySchema.Validate(blockMap); err != nil {
+					return fmt.Errorf("policies[%d]: %w", i, err)
+				}
+			}
+		}
+	}
+	_, err := parsePolicies(config)
+	return err
+}
+
 // Init 初始化插件
 func (p *CorsPlugin) Init(config interface{}) error {
 	// 类型断言
@@ -30,16 +96,21 @@ func (p *CorsPlugin) Init(config interface{}) error {
 		return fmt.Errorf("配置类型错误，期望 map[string]interface{}")
 	}
 
+	policies, err := parsePolicies(configMap)
+	if err != nil {
+		return err
+	}
+
 	p.config = configMap
+	p.policies = policies
 	return nil
 }
 
 // Execute 执行插件
 func (p *CorsPlugin) Execute(ctx *gin.Context) error {
-	// 处理预检请求
-	if ctx.Request.Method == "OPTIONS" {
+	// 处理预检请求，预检请求由网关直接响应，不转发到上游
+	if isPreflight(ctx) {
 		p.handlePreflight(ctx)
-		ctx.Abort()
 		return nil
 	}
 
@@ -48,111 +119,262 @@ func (p *CorsPlugin) Execute(ctx *gin.Context) error {
 	return nil
 }
 
-// handlePreflight 处理预检请求
+// isPreflight 判断是否为预检请求：OPTIONS 方法且携带 Origin 和 Access-Control-Request-Method
+// 不满足条件的 OPTIONS 请求按普通请求转发到上游
+func isPreflight(ctx *gin.Context) bool {
+	return ctx.Request.Method == http.MethodOptions &&
+		ctx.GetHeader("Origin") != "" &&
+		ctx.GetHeader("Access-Control-Request-Method") != ""
+}
+
+// handlePreflight 处理预检请求，立即写出响应并中止请求
 func (p *CorsPlugin) handlePreflight(ctx *gin.Context) {
+	ctx.Writer.Header().Add("Vary", "Origin")
+	ctx.Writer.Header().Add("Vary", "Access-Control-Request-Method")
+	ctx.Writer.Header().Add("Vary", "Access-Control-Request-Headers")
+
 	origin := ctx.GetHeader("Origin")
-	if !p.isOriginAllowed(origin) {
-		ctx.Status(http.StatusForbidden)
+	policy := p.matchPolicy(origin)
+	if policy == nil {
+		ctx.AbortWithStatus(http.StatusForbidden)
 		return
 	}
 
 	// 设置CORS响应头
 	ctx.Header("Access-Control-Allow-Origin", origin)
-	ctx.Header("Access-Control-Allow-Methods", p.getAllowedMethods())
-	ctx.Header("Access-Control-Allow-Headers", p.getAllowedHeaders())
-	ctx.Header("Access-Control-Expose-Headers", p.getExposedHeaders())
-	ctx.Header("Access-Control-Max-Age", p.getMaxAge())
+	ctx.Header("Access-Control-Allow-Methods", policy.allowedMethods)
+	ctx.Header("Access-Control-Allow-Headers", policy.allowedHeaders)
+	ctx.Header("Access-Control-Expose-Headers", policy.exposedHeaders)
+	ctx.Header("Access-Control-Max-Age", strconv.Itoa(policy.maxAge))
+	if policy.allowCredentials {
+		ctx.Header("Access-Control-Allow-Credentials", "true")
+	}
 
-	ctx.Status(http.StatusOK)
+	ctx.AbortWithStatus(http.StatusNoContent)
 }
 
 // handleActualRequest 处理实际请求
 func (p *CorsPlugin) handleActualRequest(ctx *gin.Context) {
 	origin := ctx.GetHeader("Origin")
-	if origin != "" && p.isOriginAllowed(origin) {
+	if origin == "" {
+		return
+	}
+
+	ctx.Writer.Header().Add("Vary", "Origin")
+	if policy := p.matchPolicy(origin); policy != nil {
 		ctx.Header("Access-Control-Allow-Origin", origin)
-		ctx.Header("Access-Control-Expose-Headers", p.getExposedHeaders())
+		ctx.Header("Access-Control-Expose-Headers", policy.exposedHeaders)
+		if policy.allowCredentials {
+			ctx.Header("Access-Control-Allow-Credentials", "true")
+		}
 	}
 }
 
-// isOriginAllowed 检查源是否允许
-func (p *CorsPlugin) isOriginAllowed(origin string) bool {
+// matchPolicy 按配置顺序查找第一个允许该源的策略，未匹配时返回 nil
+func (p *CorsPlugin) matchPolicy(origin string) *originPolicy {
 	if origin == "" {
-		return false
+		return nil
 	}
 
-	allowedOrigins := p.getAllowedOrigins()
-	if len(allowedOrigins) == 0 {
-		return false
+	for _, policy := range p.policies {
+		if policy.allows(origin) {
+			return policy
+		}
 	}
+	return nil
+}
 
-	// 如果允许所有源
-	if len(allowedOrigins) == 1 && allowedOrigins[0] == "*" {
-		return true
+// allows 检查源是否被该策略允许
+func (op *originPolicy) allows(origin string) bool {
+	for _, allowedOrigin := range op.origins {
+		if allowedOrigin == "*" || allowedOrigin == origin {
+			return true
+		}
 	}
-
-	// 检查具体源
-	for _, allowedOrigin := range allowedOrigins {
-		if allowedOrigin == origin {
+	for _, pattern := range op.patterns {
+		if pattern.MatchString(origin) {
 			return true
 		}
 	}
-
 	return false
 }
 
-// getAllowedOrigins 获取允许的源
-func (p *CorsPlugin) getAllowedOrigins() []string {
-	if origins, ok := p.config["allowed_origins"].([]interface{}); ok {
-		result := make([]string, len(origins))
-		for i, origin := range origins {
-			result[i] = origin.(string)
+// setOrigins 设置策略的源列表，编译其中的通配符源（如 https://*.example.com）和正则表达式源
+func (op *originPolicy) setOrigins(origins []string) error {
+	op.origins = nil
+	op.patterns = nil
+	for _, origin := range origins {
+		var pattern string
+		switch {
+		case strings.HasPrefix(origin, regexOriginPrefix):
+			pattern = strings.TrimPrefix(origin, regexOriginPrefix)
+		case origin != "*" && strings.Contains(origin, "*"):
+			// 转义域名中的点号，避免 https://*.example.com 匹配 https://evil-example.com
+			pattern = "^" + strings.ReplaceAll(regexp.QuoteMeta(origin), `\*`, ".*") + "$"
+		default:
+			op.origins = append(op.origins, origin)
+			continue
+		}
+
+		regex, err := regexp.Compile(pattern)
+		if err != nil {
+			return fmt.Errorf("无效的源匹配规则 %s: %w", origin, err)
 		}
-		return result
+		op.patterns = append(op.patterns, regex)
 	}
-	return []string{"*"}
+	return nil
 }
 
-// getAllowedMethods 获取允许的方法
-func (p *CorsPlugin) getAllowedMethods() string {
-	if methods, ok := p.config["allowed_methods"].([]interface{}); ok {
-		result := make([]string, len(methods))
-		for i, method := range methods {
-			result[i] = method.(string)
+// parsePolicies 解析跨域策略列表
+// policies 中的每个策略块未配置的字段继承顶层配置；顶层 allowed_origins 作为兜底策略放在最后
+func parsePolicies(configMap map[string]interface{}) ([]*originPolicy, error) {
+	base := &originPolicy{
+		allowedMethods: defaultAllowedMethods,
+		allowedHeaders: defaultAllowedHeaders,
+		exposedHeaders: defaultExposedHeaders,
+		maxAge:         defaultMaxAge,
+	}
+	if err := base.apply(configMap); err != nil {
+		return nil, err
+	}
+
+	var policies []*originPolicy
+	if raw, exists := configMap["policies"]; exists {
+		blocks, ok := raw.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("policies 配置类型错误，期望数组")
+		}
+
+		for i, block := range blocks {
+			blockMap, ok := block.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("policies[%d] 配置类型错误，期望对象", i)
+			}
+
+			policy := *base
+			if err := policy.apply(blockMap); err != nil {
+				return nil, fmt.Errorf("policies[%d]: %w", i, err)
+			}
+
+			origins, err := stringList(blockMap, "origins")
+			if err != nil {
+				return nil, fmt.Errorf("policies[%d]: %w", i, err)
+			}
+			if len(origins) == 0 {
+				return nil, fmt.Errorf("policies[%d]: origins 不能为空", i)
+			}
+			if err := policy.setOrigins(origins); err != nil {
+				return nil, fmt.Errorf("policies[%d]: %w", i, err)
+			}
+			policies = append(policies, &policy)
+		}
+	}
+
+	// 顶层 allowed_origins：未配置 policies 时默认允许所有源，配置了 policies 时仅在显式配置时兜底
+	origins, err := stringList(configMap, "allowed_origins")
+	if err != nil {
+		return nil, err
+	}
+	if _, exists := configMap["allowed_origins"]; !exists && len(policies) == 0 {
+		origins = []string{"*"}
+	}
+	if len(origins) > 0 {
+		if err := base.setOrigins(origins); err != nil {
+			return nil, err
 		}
-		return strings.Join(result, ", ")
+		policies = append(policies, base)
 	}
-	return "GET, POST, PUT, DELETE, OPTIONS"
+
+	return policies, nil
 }
 
-// getAllowedHeaders 获取允许的请求头
-func (p *CorsPlugin) getAllowedHeaders() string {
-	if headers, ok := p.config["allowed_headers"].([]interface{}); ok {
-		result := make([]string, len(headers))
-		for i, header := range headers {
-			result[i] = header.(string)
+// apply 使用配置覆盖策略字段（源列表除外）
+func (op *originPolicy) apply(configMap map[string]interface{}) error {
+	for key, target := range map[string]*string{
+		"allowed_methods": &op.allowedMethods,
+		"allowed_headers": &op.allowedHeaders,
+		"exposed_headers": &op.exposedHeaders,
+	} {
+		if _, exists := configMap[key]; !exists {
+			continue
+		}
+		values, err := stringList(configMap, key)
+		if err != nil {
+			return err
+		}
+		*target = strings.Join(values, ", ")
+	}
+
+	if raw, exists := configMap["max_age"]; exists {
+		maxAge, err := parseMaxAge(raw)
+		if err != nil {
+			return err
+		}
+		op.maxAge = maxAge
+	}
+
+	if raw, exists := configMap["allow_credentials"]; exists {
+		allowCredentials, ok := raw.(bool)
+		if !ok {
+			return fmt.Errorf("allow_credentials 配置类型错误，期望布尔值")
 		}
-		return strings.Join(result, ", ")
+		op.allowCredentials = allowCredentials
 	}
-	return "*"
+
+	return nil
 }
 
-// getExposedHeaders 获取暴露的响应头
-func (p *CorsPlugin) getExposedHeaders() string {
-	if headers, ok := p.config["exposed_headers"].([]interface{}); ok {
-		result := make([]string, len(headers))
-		for i, header := range headers {
-			result[i] = header.(string)
+// stringList 读取字符串数组配置
+func stringList(configMap map[string]interface{}, key string) ([]string, error) {
+	switch values := configMap[key].(type) {
+	case nil:
+		return nil, nil
+	case []string:
+		return values, nil
+	case []interface{}:
+		result := make([]string, len(values))
+		for i, value := range values {
+			s, ok := value.(string)
+			if !ok {
+				return nil, fmt.Errorf("%s 配置类型错误，期望字符串数组", key)
+			}
+			result[i] = s
 		}
-		return strings.Join(result, ", ")
+		return result, nil
+	default:
+		return nil, fmt.Errorf("%s 配置类型错误，期望字符串数组", key)
 	}
-	return "Content-Length"
 }
 
-// getMaxAge 获取预检请求的缓存时间
-func (p *CorsPlugin) getMaxAge() string {
-	if maxAge, ok := p.config["max_age"].(string); ok {
-		return maxAge
+// parseMaxAge 解析预检请求的缓存时间（秒），兼容 "12h" 这类时长字符串
+func parseMaxAge(raw interface{}) (int, error) {
+	var maxAge int
+	switch value := raw.(type) {
+	case int:
+		maxAge = value
+	case int64:
+		maxAge = int(value)
+	case float64:
+		if value != float64(int(value)) {
+			return 0, fmt.Errorf("max_age 必须为整数秒")
+		}
+		maxAge = int(value)
+	case string:
+		if seconds, err := strconv.Atoi(value); err == nil {
+			maxAge = seconds
+			break
+		}
+		duration, err := time.ParseDuration(value)
+		if err != nil {
+			return 0, fmt.Errorf("max_age 格式错误: %w", err)
+		}
+		maxAge = int(duration / time.Second)
+	default:
+		return 0, fmt.Errorf("max_age 配置类型错误，期望整数秒")
+	}
+
+	if maxAge < 0 {
+		return 0, fmt.Errorf("max_age 不能为负数")
 	}
-	return "43200" // 默认12小时
+	return maxAge, nil
 }
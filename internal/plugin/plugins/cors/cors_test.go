@@ -0,0 +1,186 @@
+package cors
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"gateway-go/internal/plugin/plugintest"
+
+	"github.com/gin-gonic/gin"
+)
+
+// preflight 发送来自 origin 的预检请求并返回响应
+func preflight(p *CorsPlugin, origin string) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	rec := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(rec)
+	c.Request = httptest.NewRequest(http.MethodOptions, "/api", nil)
+	c.Request.Header.Set("Origin", origin)
+	c.Request.Header.Set("Access-Control-Request-Method", http.MethodPost)
+	p.Execute(c)
+	return rec
+}
+
+func TestPerOriginPolicies(t *testing.T) {
+	p := plugintest.Init(t, New(), map[string]interface{}{
+		"allowed_origins": []interface{}{"https://public.example.com"},
+		"max_age":         600,
+		"policies": []interface{}{
+			map[string]interface{}{
+				"origins":         []interface{}{"https://admin.example.com"},
+				"allowed_methods": []interface{}{"GET", "POST", "DELETE"},
+				"allowed_headers": []interface{}{"Authorization", "X-Admin"},
+				"max_age":         "1h",
+			},
+			map[string]interface{}{
+				"origins":         []interface{}{"https://app.example.com"},
+				"allowed_methods": []interface{}{"GET"},
+			},
+		},
+	})
+
+	tests := []struct {
+		origin      string
+		wantStatus  int
+		wantMethods string
+		wantHeaders string
+		wantMaxAge  string
+	}{
+		{"https://admin.example.com", http.StatusNoContent, "GET, POST, DELETE", "Authorization, X-Admin", "3600"},
+		// 未配置的字段继承顶层配置
+		{"https://app.example.com", http.StatusNoContent, "GET", defaultAllowedHeaders, "600"},
+		{"https://public.example.com", http.StatusNoContent, defaultAllowedMethods, defaultAllowedHeaders, "600"},
+		{"https://evil.example.com", http.StatusForbidden, "", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.origin, func(t *testing.T) {
+			rec := preflight(p, tt.origin)
+			header := rec.Header()
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("状态码 = %d，期望 %d", rec.Code, tt.wantStatus)
+			}
+			if got := header.Get("Access-Control-Allow-Methods"); got != tt.wantMethods {
+				t.Fatalf("Allow-Methods = %q，期望 %q", got, tt.wantMethods)
+			}
+			if got := header.Get("Access-Control-Allow-Headers"); got != tt.wantHeaders {
+				t.Fatalf("Allow-Headers = %q，期望 %q", got, tt.wantHeaders)
+			}
+			if got := header.Get("Access-Control-Max-Age"); got != tt.wantMaxAge {
+				t.Fatalf("Max-Age = %q，期望 %q", got, tt.wantMaxAge)
+			}
+		})
+	}
+}
+
+func TestParseMaxAge(t *testing.T) {
+	tests := []struct {
+		raw     interface{}
+		want    int
+		wantErr bool
+	}{
+		{600, 600, false},
+		{int64(60), 60, false},
+		{float64(30), 30, false},
+		{"120", 120, false},
+		{"12h", 43200, false},
+		{1.5, 0, true},
+		{-1, 0, true},
+		{"soon", 0, true},
+		{true, 0, true},
+	}
+	for _, tt := range tests {
+		got, err := parseMaxAge(tt.raw)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Fatalf("parseMaxAge(%v) = %d, %v，期望 %d（出错: %v）", tt.raw, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestPolicyConfigErrors(t *testing.T) {
+	for name, config := range map[string]map[string]interface{}{
+		"策略缺少 origins": {"policies": []interface{}{map[string]interface{}{"max_age": 10}}},
+		"策略不是对象":       {"policies": []interface{}{"https://a.example.com"}},
+		"无效的正则源":       {"allowed_origins": []interface{}{"regex:("}},
+	} {
+		if err := New().ValidateConfig(config); err == nil {
+			t.Fatalf("%s: 期望校验失败", name)
+		}
+	}
+}
+
+// actualRequest 发送来自 origin 的普通请求并返回响应头
+func actualRequest(p *CorsPlugin, origin string) http.Header {
+	gin.SetMode(gin.TestMode)
+	rec := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(rec)
+	c.Request = httptest.NewRequest(http.MethodGet, "/api", nil)
+	if origin != "" {
+		c.Request.Header.Set("Origin", origin)
+	}
+	p.Execute(c)
+	return rec.Header()
+}
+
+func TestWildcardAndRegexOrigins(t *testing.T) {
+	p := plugintest.Init(t, New(), map[string]interface{}{
+		"allowed_origins": []interface{}{"https://*.example.com", `regex:^https://app-[0-9]+\.test\.io$`},
+	})
+
+	tests := []struct {
+		origin  string
+		allowed bool
+	}{
+		{"https://api.example.com", true},
+		{"https://a.b.example.com", true},
+		{"https://app-42.test.io", true},
+		// 通配符不匹配根域名，点号不被当作任意字符
+		{"https://example.com", false},
+		{"https://evil-example.com", false},
+		{"https://api.example.com.evil.org", false},
+		{"http://api.example.com", false},
+		{"https://app-x.test.io", false},
+	}
+	for _, tt := range tests {
+		rec := preflight(p, tt.origin)
+		if allowed := rec.Code == http.StatusNoContent; allowed != tt.allowed {
+			t.Fatalf("%s 预检状态码 = %d，期望允许: %v", tt.origin, rec.Code, tt.allowed)
+		}
+		if got := actualRequest(p, tt.origin).Get("Access-Control-Allow-Origin"); (got == tt.origin) != tt.allowed {
+			t.Fatalf("%s 普通请求 Allow-Origin = %q，期望允许: %v", tt.origin, got, tt.allowed)
+		}
+	}
+}
+
+func TestCredentialsEchoOrigin(t *testing.T) {
+	p := plugintest.Init(t, New(), map[string]interface{}{
+		"allowed_origins":   []interface{}{"*"},
+		"allow_credentials": true,
+	})
+
+	// 允许携带凭证时返回具体的源而不是 *
+	rec := preflight(p, "https://app.example.com")
+	if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "https://app.example.com" {
+		t.Fatalf("Allow-Origin = %q，期望回显请求的源", got)
+	}
+	if got := rec.Header().Get("Access-Control-Allow-Credentials"); got != "true" {
+		t.Fatalf("Allow-Credentials = %q，期望 true", got)
+	}
+	header := actualRequest(p, "https://app.example.com")
+	if header.Get("Access-Control-Allow-Origin") != "https://app.example.com" || header.Get("Access-Control-Allow-Credentials") != "true" {
+		t.Fatalf("普通请求响应头 = %v", header)
+	}
+	if vary := header.Values("Vary"); len(vary) != 1 || vary[0] != "Origin" {
+		t.Fatalf("Vary = %v，期望 Origin", vary)
+	}
+
+	// 未开启时不返回 Allow-Credentials
+	p = plugintest.Init(t, New(), map[string]interface{}{"allowed_origins": []interface{}{"*"}})
+	if got := actualRequest(p, "https://app.example.com").Get("Access-Control-Allow-Credentials"); got != "" {
+		t.Fatalf("未开启凭证时 Allow-Credentials = %q", got)
+	}
+	// 没有 Origin 的请求不处理
+	if header := actualRequest(p, ""); header.Get("Access-Control-Allow-Origin") != "" {
+		t.Fatalf("非跨域请求响应头 = %v", header)
+	}
+}
@@ -0,0 +1,180 @@
+package deadline
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"gateway-go/internal/errors"
+	"gateway-go/internal/plugin/core"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 默认配置
+const (
+	defaultHeader = "X-Request-Deadline"
+	grpcTimeout   = "Grpc-Timeout"
+)
+
+// DeadlinePlugin 请求截止时间插件，按客户端携带的截止时间设置请求上下文并传递给上游
+type DeadlinePlugin struct {
+	*core.BasePlugin
+	config map[string]interface{}
+	// 读取截止时间的请求头
+	header string
+	// 转发剩余时间（毫秒）的请求头
+	forwardHeader string
+	// 未携带截止时间时使用的超时，0 表示不限制
+	defaultTimeout time.Duration
+	// 允许的最大超时，0 表示不限制
+	maxTimeout time.Duration
+	// 是否同时设置 grpc-timeout
+	grpcTimeout bool
+}
+
+// New 创建截止时间插件
+func New() *DeadlinePlugin {
+	return &DeadlinePlugin{
+		BasePlugin: core.NewBasePlugin("deadline", 1, nil),
+	}
+}
+
+// configSchema 插件配置结构
+var configSchema = core.ConfigSchema{
+	"header":          core.FieldString,
+	"forward_header":  core.FieldString,
+	"default_timeout": core.FieldDuration,
+	"max_timeout":     core.FieldDuration,
+	"grpc_timeout":    core.FieldBool,
+}
+
+// ValidateConfig 校验插件配置
+func (p *DeadlinePlugin) ValidateConfig(config map[string]interface{}) error {
+	return configSchema.Validate(config)
+}
+
+// Init 初始化插件
+func (p *DeadlinePlugin) Init(config interface{}) error {
+	// 类型断言
+	configMap, ok := config.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("配置类型错误，期望 map[string]interface{}")
+	}
+
+	p.config = configMap
+	p.header = defaultHeader
+	if header, ok := configMap["header"].(string); ok && header != "" {
+		p.header = header
+	}
+	p.forwardHeader = p.header
+	if header, ok := configMap["forward_header"].(string); ok && header != "" {
+		p.forwardHeader = header
+	}
+	p.grpcTimeout, _ = configMap["grpc_timeout"].(bool)
+
+	var err error
+	if p.defaultTimeout, err = durationOption(configMap, "default_timeout"); err != nil {
+		return err
+	}
+	if p.maxTimeout, err = durationOption(configMap, "max_timeout"); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Execute 执行插件
+func (p *DeadlinePlugin) Execute(ctx *gin.Context) error {
+	now := time.Now()
+
+	var deadline time.Time
+	if value := ctx.GetHeader(p.header); value != "" {
+		parsed, err := parseDeadline(value, now)
+		if err != nil {
+			errors.Abort(ctx, http.StatusBadRequest, fmt.Sprintf("无效的截止时间: %s", value))
+			return nil
+		}
+		deadline = parsed
+	} else if p.defaultTimeout > 0 {
+		deadline = now.Add(p.defaultTimeout)
+	} else {
+		return nil
+	}
+
+	// 限制最大超时
+	if p.maxTimeout > 0 && deadline.After(now.Add(p.maxTimeout)) {
+		deadline = now.Add(p.maxTimeout)
+	}
+
+	remaining := deadline.Sub(now)
+	if remaining <= 0 {
+		errors.Abort(ctx, http.StatusGatewayTimeout, "请求已超过截止时间")
+		return nil
+	}
+
+	// 设置请求上下文截止时间，超时后转发到上游的请求被取消
+	// 请求处理结束时父上下文被取消，同时释放计时器
+	reqCtx, cancel := context.WithDeadline(ctx.Request.Context(), deadline)
+	context.AfterFunc(ctx.Request.Context(), cancel)
+	ctx.Request = ctx.Request.WithContext(reqCtx)
+
+	// 向上游传递剩余时间
+	ctx.Request.Header.Set(p.forwardHeader, strconv.FormatInt(ceilMillis(remaining), 10))
+	if p.grpcTimeout {
+		ctx.Request.Header.Set(grpcTimeout, encodeGRPCTimeout(remaining))
+	}
+	return nil
+}
+
+// parseDeadline 解析截止时间：整数毫秒或时长（如 500ms、2s）表示相对超时，RFC3339 时间表示绝对截止时间
+func parseDeadline(value string, now time.Time) (time.Time, error) {
+	if millis, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return now.Add(time.Duration(millis) * time.Millisecond), nil
+	}
+	if timeout, err := time.ParseDuration(value); err == nil {
+		return now.Add(timeout), nil
+	}
+	return time.Parse(time.RFC3339Nano, value)
+}
+
+// durationOption 读取时长配置，支持 "5s" 形式的字符串或整数毫秒
+func durationOption(configMap map[string]interface{}, key string) (time.Duration, error) {
+	switch value := configMap[key].(type) {
+	case nil:
+		return 0, nil
+	case int:
+		return time.Duration(value) * time.Millisecond, nil
+	case float64:
+		return time.Duration(value) * time.Millisecond, nil
+	case string:
+		timeout, err := time.ParseDuration(value)
+		if err != nil {
+			return 0, fmt.Errorf("%s 格式错误: %w", key, err)
+		}
+		return timeout, nil
+	default:
+		return 0, fmt.Errorf("%s 配置类型错误，期望时长字符串", key)
+	}
+}
+
+// ceilMillis 向上取整到毫秒，避免剩余时间不足 1ms 时传递 0
+func ceilMillis(d time.Duration) int64 {
+	return int64((d + time.Millisecond - 1) / time.Millisecond)
+}
+
+// encodeGRPCTimeout 按 gRPC 协议格式编码超时，数值最多 8 位
+func encodeGRPCTimeout(d time.Duration) string {
+	const maxValue = 99999999
+	if millis := ceilMillis(d); millis <= maxValue {
+		return strconv.FormatInt(millis, 10) + "m"
+	}
+	if seconds := int64(d / time.Second); seconds <= maxValue {
+		return strconv.FormatInt(seconds, 10) + "S"
+	}
+	if minutes := int64(d / time.Minute); minutes <= maxValue {
+		return strconv.FormatInt(minutes, 10) + "M"
+	}
+	return strconv.FormatInt(int64(d/time.Hour), 10) + "H"
+}
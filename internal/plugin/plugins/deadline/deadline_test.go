@@ -0,0 +1,43 @@
+package deadline
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseDeadline(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		value   string
+		want    time.Time
+		wantErr bool
+	}{
+		{"250", now.Add(250 * time.Millisecond), false},
+		{"2s", now.Add(2 * time.Second), false},
+		{"2024-01-01T00:00:05Z", now.Add(5 * time.Second), false},
+		{"soon", time.Time{}, true},
+	}
+	for _, tt := range tests {
+		got, err := parseDeadline(tt.value, now)
+		if (err != nil) != tt.wantErr || !got.Equal(tt.want) {
+			t.Fatalf("parseDeadline(%q) = %v, %v，期望 %v", tt.value, got, err, tt.want)
+		}
+	}
+}
+
+func TestEncodeGRPCTimeout(t *testing.T) {
+	tests := []struct {
+		timeout time.Duration
+		want    string
+	}{
+		{1500 * time.Microsecond, "2m"},
+		{3 * time.Second, "3000m"},
+		{200000 * time.Second, "200000S"},
+		{2000000000 * time.Second, "33333333M"},
+	}
+	for _, tt := range tests {
+		if got := encodeGRPCTimeout(tt.timeout); got != tt.want {
+			t.Fatalf("encodeGRPCTimeout(%v) = %q，期望 %q", tt.timeout, got, tt.want)
+		}
+	}
+}
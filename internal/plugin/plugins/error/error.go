@@ -4,8 +4,10 @@ import (
 	"context"
 	"fmt"
 	"gateway-go/internal/errors"
+	"gateway-go/internal/logger"
 	"gateway-go/internal/plugin/core"
 	"net/http"
+	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -15,10 +17,15 @@ import (
 // ErrorPlugin 错误处理插件
 type ErrorPlugin struct {
 	*core.BasePlugin
-	config   map[string]interface{}
-	logger   *zap.Logger
-	notifier *errors.ErrorNotifier
-	retry    errors.RetryConfig
+	config map[string]interface{}
+	logger *zap.Logger
+	// 是否通过 SetLogger 指定了日志记录器，未指定时使用全局日志
+	loggerSet bool
+	notifier  *errors.ErrorNotifier
+	retry     errors.RetryConfig
+	// 降级状态，未配置 fallback 时为 nil
+	fallback *fallbackState
+	mu       sync.RWMutex
 }
 
 // New 创建错误处理插件
@@ -28,6 +35,24 @@ func New() *ErrorPlugin {
 	}
 }
 
+// configSchema 插件配置结构
+var configSchema = core.ConfigSchema{
+	"fallback":     core.FieldObject,
+	"notification": core.FieldObject,
+}
+
+// ValidateConfig 校验插件配置
+func (p *ErrorPlugin) ValidateConfig(config map[string]interface{}) error {
+	if err := configSchema.Validate(config); err != nil {
+		return err
+	}
+	if _, err := parseFallbackSettings(config); err != nil {
+		return err
+	}
+	_, err := parseNotificationConfig(config)
+	return err
+}
+
 // Init 初始化插件
 func (p *ErrorPlugin) Init(config interface{}) error {
 	// 类型断言
@@ -36,10 +61,31 @@ func (p *ErrorPlugin) Init(config interface{}) error {
 		return fmt.Errorf("配置类型错误，期望 map[string]interface{}")
 	}
 
+	fallback, err := parseFallbackSettings(configMap)
+	if err != nil {
+		return err
+	}
+	notification, err := parseNotificationConfig(configMap)
+	if err != nil {
+		return err
+	}
+
 	p.config = configMap
+	p.mu.Lock()
+	p.fallback = nil
+	if fallback != nil {
+		p.fallback = newFallbackState(fallback)
+	}
+	p.mu.Unlock()
+	if !p.loggerSet {
+		p.logger = logger.Log
+	}
+	if p.logger == nil {
+		p.logger = zap.NewNop()
+	}
 
 	// 创建错误通知器
-	notifier := errors.NewErrorNotifier(errors.DefaultNotificationConfig)
+	notifier := errors.NewErrorNotifier(notification)
 
 	p.notifier = notifier
 	p.retry = errors.DefaultRetryConfig
@@ -48,14 +94,22 @@ func (p *ErrorPlugin) Init(config interface{}) error {
 }
 
 // Execute 执行插件
+// 插件在转发前执行，只处理此前插件记录的错误；不能调用 ctx.Next()，
+// 否则会提前执行未匹配路由的 404 处理器，导致上游响应被丢弃
 func (p *ErrorPlugin) Execute(ctx *gin.Context) error {
-	// 设置错误处理中间件
-	ctx.Next()
-
 	// 检查是否有错误
 	if len(ctx.Errors) > 0 {
 		err := ctx.Errors.Last().Err
 		p.handleError(ctx, err)
+		return nil
+	}
+
+	// 降级：错误率超过阈值时返回降级响应，不再转发到上游
+	p.mu.RLock()
+	fallback := p.fallback
+	p.mu.RUnlock()
+	if fallback != nil {
+		fallback.execute(ctx)
 	}
 
 	return nil
@@ -63,6 +117,12 @@ func (p *ErrorPlugin) Execute(ctx *gin.Context) error {
 
 // handleError 处理错误
 func (p *ErrorPlugin) handleError(ctx *gin.Context, err error) {
+	// 响应已写出（如上游错误响应）时不再覆盖
+	if ctx.Writer.Written() {
+		return
+	}
+	defer ctx.Abort()
+
 	// 尝试转换为自定义错误
 	if e, ok := errors.As(err); ok {
 		// 记录错误日志
@@ -77,12 +137,15 @@ func (p *ErrorPlugin) handleError(ctx *gin.Context, err error) {
 		)
 
 		// 发送错误通知
-		notifyCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-		defer cancel()
-		p.notifier.Notify(notifyCtx, e)
-
-		// 返回错误响应
-		ctx.JSON(e.HTTPStatus(), e)
+		p.notify(e)
+
+		// 返回错误响应，协商的语言有对应的错误消息时使用本地化消息
+		status := e.HTTPStatus()
+		message := e.Message
+		if localized, ok := errors.LookupErrorMessage(errors.StatusErrorCode(status), errors.RequestLanguage(ctx.Request)); ok {
+			message = localized
+		}
+		errors.AbortWithDetails(ctx, status, message, e.Details)
 		return
 	}
 
@@ -95,17 +158,31 @@ func (p *ErrorPlugin) handleError(ctx *gin.Context, err error) {
 	)
 
 	// 发送错误通知
-	notifyCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-	p.notifier.Notify(notifyCtx, err)
+	p.notify(err)
 
 	// 返回通用错误响应
-	ctx.JSON(http.StatusInternalServerError, errors.New(errors.ErrInternalServerError, "服务器内部错误"))
+	errors.Abort(ctx, http.StatusInternalServerError, errors.GetErrorMessage(errors.ErrInternalServer, errors.RequestLanguage(ctx.Request)))
+}
+
+// notify 异步发送错误通知，避免通知渠道的网络请求阻塞当前请求
+func (p *ErrorPlugin) notify(err error) {
+	notifier, log := p.notifier, p.logger
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if notifyErr := notifier.Notify(ctx, err); notifyErr != nil {
+			log.Warn("发送错误通知失败", zap.Error(notifyErr))
+		}
+	}()
 }
 
-// SetLogger 设置日志记录器
+// SetLogger 设置日志记录器，传入 nil 时丢弃日志
 func (p *ErrorPlugin) SetLogger(logger *zap.Logger) {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
 	p.logger = logger
+	p.loggerSet = true
 }
 
 // AddNotificationChannel 添加通知渠道
@@ -0,0 +1,80 @@
+package error
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"gateway-go/internal/errors"
+	"gateway-go/internal/logger"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// executeWithError 在记录了 err 的请求上执行插件
+func executeWithError(p *ErrorPlugin, err error) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	rec := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(rec)
+	c.Request = httptest.NewRequest(http.MethodGet, "/orders", nil)
+	c.Error(err)
+	p.Execute(c)
+	return rec
+}
+
+func TestErrorPathLogsWithoutPanic(t *testing.T) {
+	core, logs := observer.New(zap.ErrorLevel)
+	previous := logger.Log
+	logger.Log = zap.New(core)
+	defer func() { logger.Log = previous }()
+
+	// 未调用 SetLogger 时使用全局日志
+	p := New()
+	if err := p.Init(map[string]interface{}{}); err != nil {
+		t.Fatalf("初始化插件失败: %v", err)
+	}
+
+	for _, err := range []error{errors.New(errors.ErrBadGateway, "上游不可用"), fmt.Errorf("boom")} {
+		if rec := executeWithError(p, err); rec.Code != http.StatusInternalServerError {
+			t.Fatalf("%v: 状态码 = %d，期望 500", err, rec.Code)
+		}
+	}
+
+	entries := logs.All()
+	if len(entries) != 2 {
+		t.Fatalf("错误日志 = %v，期望两条错误日志", entries)
+	}
+	if fields := entries[1].ContextMap(); fields["path"] != "/orders" || fields["message"] != "boom" {
+		t.Fatalf("错误日志字段 = %v", fields)
+	}
+}
+
+func TestErrorPathWithoutGlobalLogger(t *testing.T) {
+	previous := logger.Log
+	logger.Log = nil
+	defer func() { logger.Log = previous }()
+
+	p := New()
+	if err := p.Init(map[string]interface{}{}); err != nil {
+		t.Fatalf("初始化插件失败: %v", err)
+	}
+	if rec := executeWithError(p, fmt.Errorf("boom")); rec.Code != http.StatusInternalServerError {
+		t.Fatalf("状态码 = %d，期望 500", rec.Code)
+	}
+}
+
+func TestSetLoggerTakesPrecedence(t *testing.T) {
+	core, logs := observer.New(zap.ErrorLevel)
+	p := New()
+	p.SetLogger(zap.New(core))
+	if err := p.Init(map[string]interface{}{}); err != nil {
+		t.Fatalf("初始化插件失败: %v", err)
+	}
+	executeWithError(p, fmt.Errorf("boom"))
+	if logs.Len() != 1 {
+		t.Fatalf("指定的日志记录器收到 %d 条日志，期望 1", logs.Len())
+	}
+}
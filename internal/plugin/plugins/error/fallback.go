@@ -0,0 +1,229 @@
+package error
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"gateway-go/internal/errors"
+	"gateway-go/internal/plugin/core"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 降级默认响应
+const (
+	defaultFallbackStatus      = http.StatusServiceUnavailable
+	defaultFallbackContent     = `{"error":"服务降级中，请稍后重试"}`
+	defaultFallbackContentType = "application/json; charset=utf-8"
+)
+
+// fallbackSchema fallback 配置结构
+var fallbackSchema = core.ConfigSchema{
+	"window":             core.FieldDuration,
+	"error_threshold":    core.FieldNumber,
+	"recovery_threshold": core.FieldNumber,
+	"min_requests":       core.FieldInt,
+	"status":             core.FieldInt,
+	"content":            core.FieldString,
+	"content_type":       core.FieldString,
+	"routes":             core.FieldObject,
+}
+
+// responseSchema 路由降级响应配置结构
+var responseSchema = core.ConfigSchema{
+	"status":       core.FieldInt,
+	"content":      core.FieldString,
+	"content_type": core.FieldString,
+}
+
+// fallbackResponse 降级时返回的响应
+type fallbackResponse struct {
+	status      int
+	content     string
+	contentType string
+}
+
+// fallbackSettings 解析后的降级配置
+type fallbackSettings struct {
+	config   errors.FallbackConfig
+	response fallbackResponse
+	// 按路由名覆盖的降级响应
+	routes map[string]fallbackResponse
+}
+
+// parseFallbackSettings 解析 fallback 配置，未配置时返回 nil
+func parseFallbackSettings(config map[string]interface{}) (*fallbackSettings, error) {
+	raw, ok := config["fallback"]
+	if !ok || raw == nil {
+		return nil, nil
+	}
+	fallback, ok := raw.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("fallback 配置类型错误，期望对象")
+	}
+	if err := fallbackSchema.Validate(fallback); err != nil {
+		return nil, fmt.Errorf("fallback: %w", err)
+	}
+
+	s := &fallbackSettings{
+		config: errors.DefaultFallbackConfig,
+		response: fallbackResponse{
+			status:      defaultFallbackStatus,
+			content:     defaultFallbackContent,
+			contentType: defaultFallbackContentType,
+		},
+		routes: make(map[string]fallbackResponse),
+	}
+
+	if window, ok := fallback["window"]; ok {
+		d, err := parseDuration(window)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("无效的 fallback.window: %v", window)
+		}
+		s.config.WindowSize = d
+	}
+	if value, ok := fallback["error_threshold"]; ok {
+		threshold, _ := core.ToFloat64(value)
+		if threshold <= 0 || threshold > 1 {
+			return nil, fmt.Errorf("fallback.error_threshold 必须在 (0, 1] 范围内")
+		}
+		s.config.ErrorThreshold = threshold
+	}
+	if value, ok := fallback["recovery_threshold"]; ok {
+		threshold, _ := core.ToFloat64(value)
+		if threshold < 0 || threshold > 1 {
+			return nil, fmt.Errorf("fallback.recovery_threshold 必须在 [0, 1] 范围内")
+		}
+		s.config.RecoveryThreshold = threshold
+	}
+	if s.config.RecoveryThreshold > s.config.ErrorThreshold {
+		return nil, fmt.Errorf("fallback.recovery_threshold 不能大于 error_threshold")
+	}
+	if value, ok := fallback["min_requests"]; ok {
+		minRequests, _ := core.ToInt(value)
+		if minRequests <= 0 {
+			return nil, fmt.Errorf("fallback.min_requests 必须大于 0")
+		}
+		s.config.MinRequests = minRequests
+	}
+
+	response, err := parseFallbackResponse(fallback, s.response)
+	if err != nil {
+		return nil, fmt.Errorf("fallback: %w", err)
+	}
+	s.response = response
+
+	routes, _ := fallback["routes"].(map[string]interface{})
+	for name, value := range routes {
+		route, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("fallback.routes.%s 配置类型错误，期望对象", name)
+		}
+		if err := responseSchema.Validate(route); err != nil {
+			return nil, fmt.Errorf("fallback.routes.%s: %w", name, err)
+		}
+		response, err := parseFallbackResponse(route, s.response)
+		if err != nil {
+			return nil, fmt.Errorf("fallback.routes.%s: %w", name, err)
+		}
+		s.routes[name] = response
+	}
+	return s, nil
+}
+
+// parseFallbackResponse 解析降级响应，未配置的字段使用 base 中的值
+func parseFallbackResponse(config map[string]interface{}, base fallbackResponse) (fallbackResponse, error) {
+	response := base
+	if value, ok := config["status"]; ok {
+		status, _ := core.ToInt(value)
+		if status < 100 || status > 599 {
+			return response, fmt.Errorf("无效的状态码: %v", value)
+		}
+		response.status = status
+	}
+	if content, ok := config["content"].(string); ok {
+		response.content = content
+	}
+	if contentType, ok := config["content_type"].(string); ok && contentType != "" {
+		response.contentType = contentType
+	}
+	return response, nil
+}
+
+// parseDuration 解析时长配置，整数表示毫秒
+func parseDuration(value interface{}) (time.Duration, error) {
+	if s, ok := value.(string); ok {
+		if d, err := time.ParseDuration(s); err == nil {
+			return d, nil
+		}
+	}
+	millis, ok := core.ToInt(value)
+	if !ok {
+		return 0, fmt.Errorf("时长格式错误: %v", value)
+	}
+	return time.Duration(millis) * time.Millisecond, nil
+}
+
+// fallbackState 降级状态，每个路由一个降级处理器
+type fallbackState struct {
+	settings *fallbackSettings
+	handlers map[string]*errors.FallbackHandler
+	mu       sync.Mutex
+}
+
+// newFallbackState 创建降级状态
+func newFallbackState(settings *fallbackSettings) *fallbackState {
+	return &fallbackState{
+		settings: settings,
+		handlers: make(map[string]*errors.FallbackHandler),
+	}
+}
+
+// handler 获取路由的降级处理器，不存在时创建
+func (s *fallbackState) handler(route string) *errors.FallbackHandler {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	h, ok := s.handlers[route]
+	if !ok {
+		h = errors.NewFallbackHandler(s.settings.config)
+		s.handlers[route] = h
+	}
+	return h
+}
+
+// response 获取路由的降级响应
+func (s *fallbackState) response(route string) fallbackResponse {
+	if response, ok := s.settings.routes[route]; ok {
+		return response
+	}
+	return s.settings.response
+}
+
+// execute 已降级时直接返回降级响应并中止请求，否则接管响应写入以统计错误率
+func (s *fallbackState) execute(ctx *gin.Context) {
+	route := ctx.GetString("route")
+	if route == "" {
+		route = ctx.Request.URL.Path
+	}
+
+	h := s.handler(route)
+	if h.IsDegraded() {
+		response := s.response(route)
+		ctx.Data(response.status, response.contentType, []byte(response.content))
+		ctx.Abort()
+		return
+	}
+
+	// 按状态码记录请求结果，5xx 视为失败
+	core.WrapWriter(ctx, func(code int) int {
+		if code >= http.StatusInternalServerError {
+			h.RecordError(fmt.Errorf("响应状态码 %d", code))
+		} else {
+			h.RecordSuccess()
+		}
+		return code
+	})
+}
@@ -0,0 +1,56 @@
+package error
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestParseFallbackSettings(t *testing.T) {
+	s, err := parseFallbackSettings(map[string]interface{}{
+		"fallback": map[string]interface{}{
+			"window":          "30s",
+			"error_threshold": 0.4,
+			"min_requests":    5,
+			"content":         "busy",
+			"routes": map[string]interface{}{
+				"orders": map[string]interface{}{"status": 200},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("解析配置失败: %v", err)
+	}
+	if s.config.WindowSize != 30*time.Second || s.config.ErrorThreshold != 0.4 || s.config.MinRequests != 5 {
+		t.Fatalf("降级配置 = %+v", s.config)
+	}
+	// 未配置的字段使用默认值，路由响应继承顶层配置
+	if s.response != (fallbackResponse{defaultFallbackStatus, "busy", defaultFallbackContentType}) {
+		t.Fatalf("降级响应 = %+v", s.response)
+	}
+	if got := newFallbackState(s).response("orders"); got != (fallbackResponse{http.StatusOK, "busy", defaultFallbackContentType}) {
+		t.Fatalf("路由降级响应 = %+v，期望覆盖状态码并继承其他字段", got)
+	}
+	if got := newFallbackState(s).response("users"); got != s.response {
+		t.Fatalf("未配置的路由降级响应 = %+v，期望使用顶层配置", got)
+	}
+
+	if s, err := parseFallbackSettings(map[string]interface{}{}); s != nil || err != nil {
+		t.Fatalf("未配置 fallback 时返回 %v, %v，期望 nil", s, err)
+	}
+}
+
+func TestParseFallbackSettingsErrors(t *testing.T) {
+	for name, fallback := range map[string]interface{}{
+		"类型错误":       "on",
+		"错误率超过1":     map[string]interface{}{"error_threshold": 1.5},
+		"恢复阈值大于错误阈值": map[string]interface{}{"error_threshold": 0.3, "recovery_threshold": 0.5},
+		"无效窗口":       map[string]interface{}{"window": "soon"},
+		"无效状态码":      map[string]interface{}{"status": 99},
+		"路由响应类型错误":   map[string]interface{}{"routes": map[string]interface{}{"orders": "busy"}},
+	} {
+		if _, err := parseFallbackSettings(map[string]interface{}{"fallback": fallback}); err == nil {
+			t.Fatalf("%s: 期望解析失败", name)
+		}
+	}
+}
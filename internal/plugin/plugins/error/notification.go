@@ -0,0 +1,247 @@
+package error
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"time"
+
+	"gateway-go/internal/errors"
+	"gateway-go/internal/plugin/core"
+)
+
+// notificationSchema notification 配置结构
+var notificationSchema = core.ConfigSchema{
+	"level":    core.FieldString,
+	"interval": core.FieldDuration,
+	"webhook":  core.FieldObject,
+	"email":    core.FieldObject,
+	"slack":    core.FieldObject,
+	"dingtalk": core.FieldObject,
+}
+
+// webhookSchema webhook 渠道配置结构
+var webhookSchema = core.ConfigSchema{
+	"url":     core.FieldString,
+	"headers": core.FieldObject,
+	"timeout": core.FieldDuration,
+}
+
+// slackSchema slack 渠道配置结构
+var slackSchema = core.ConfigSchema{
+	"url":     core.FieldString,
+	"timeout": core.FieldDuration,
+}
+
+// dingTalkSchema dingtalk 渠道配置结构
+var dingTalkSchema = core.ConfigSchema{
+	"url":     core.FieldString,
+	"secret":  core.FieldString,
+	"timeout": core.FieldDuration,
+}
+
+// emailSchema email 渠道配置结构
+var emailSchema = core.ConfigSchema{
+	"addr":           core.FieldString,
+	"username":       core.FieldString,
+	"password":       core.FieldString,
+	"from":           core.FieldString,
+	"to":             core.FieldStringList,
+	"subject_prefix": core.FieldString,
+	"timeout":        core.FieldDuration,
+}
+
+// parseNotificationConfig 解析 notification 配置，未配置时返回默认配置（不含通知渠道）
+func parseNotificationConfig(config map[string]interface{}) (errors.NotificationConfig, error) {
+	result := errors.DefaultNotificationConfig
+	result.Channels = nil
+
+	raw, ok := config["notification"]
+	if !ok || raw == nil {
+		return result, nil
+	}
+	notification, ok := raw.(map[string]interface{})
+	if !ok {
+		return result, fmt.Errorf("notification 配置类型错误，期望对象")
+	}
+	if err := notificationSchema.Validate(notification); err != nil {
+		return result, fmt.Errorf("notification: %w", err)
+	}
+
+	if name, ok := notification["level"].(string); ok && name != "" {
+		level, err := errors.ParseNotificationLevel(name)
+		if err != nil {
+			return result, fmt.Errorf("notification: %w", err)
+		}
+		result.LevelThreshold = level
+	}
+	if value, ok := notification["interval"]; ok {
+		interval, err := parseDuration(value)
+		if err != nil || interval < 0 {
+			return result, fmt.Errorf("无效的 notification.interval: %v", value)
+		}
+		result.Interval = interval
+	}
+
+	if webhook, ok := notification["webhook"].(map[string]interface{}); ok {
+		channel, err := parseWebhookChannel(webhook)
+		if err != nil {
+			return result, fmt.Errorf("notification.webhook: %w", err)
+		}
+		result.Channels = append(result.Channels, channel)
+	}
+	if email, ok := notification["email"].(map[string]interface{}); ok {
+		channel, err := parseEmailChannel(email)
+		if err != nil {
+			return result, fmt.Errorf("notification.email: %w", err)
+		}
+		result.Channels = append(result.Channels, channel)
+	}
+	if slack, ok := notification["slack"].(map[string]interface{}); ok {
+		channel, err := parseSlackChannel(slack)
+		if err != nil {
+			return result, fmt.Errorf("notification.slack: %w", err)
+		}
+		result.Channels = append(result.Channels, channel)
+	}
+	if dingTalk, ok := notification["dingtalk"].(map[string]interface{}); ok {
+		channel, err := parseDingTalkChannel(dingTalk)
+		if err != nil {
+			return result, fmt.Errorf("notification.dingtalk: %w", err)
+		}
+		result.Channels = append(result.Channels, channel)
+	}
+	return result, nil
+}
+
+// parseWebhookChannel 解析 webhook 渠道配置
+func parseWebhookChannel(config map[string]interface{}) (*errors.WebhookChannel, error) {
+	if err := webhookSchema.Validate(config); err != nil {
+		return nil, err
+	}
+	address, err := parseChannelURL(config["url"])
+	if err != nil {
+		return nil, err
+	}
+	headers := make(map[string]string)
+	if raw, ok := config["headers"].(map[string]interface{}); ok {
+		for name, value := range raw {
+			s, ok := value.(string)
+			if !ok {
+				return nil, fmt.Errorf("请求头 %s 的值必须为字符串", name)
+			}
+			headers[name] = s
+		}
+	}
+	timeout, err := parseChannelTimeout(config["timeout"])
+	if err != nil {
+		return nil, err
+	}
+	return errors.NewWebhookChannel(address, headers, timeout), nil
+}
+
+// parseSlackChannel 解析 slack 渠道配置
+func parseSlackChannel(config map[string]interface{}) (*errors.SlackChannel, error) {
+	if err := slackSchema.Validate(config); err != nil {
+		return nil, err
+	}
+	address, err := parseChannelURL(config["url"])
+	if err != nil {
+		return nil, err
+	}
+	timeout, err := parseChannelTimeout(config["timeout"])
+	if err != nil {
+		return nil, err
+	}
+	return errors.NewSlackChannel(address, timeout), nil
+}
+
+// parseDingTalkChannel 解析 dingtalk 渠道配置
+func parseDingTalkChannel(config map[string]interface{}) (*errors.DingTalkChannel, error) {
+	if err := dingTalkSchema.Validate(config); err != nil {
+		return nil, err
+	}
+	address, err := parseChannelURL(config["url"])
+	if err != nil {
+		return nil, err
+	}
+	secret, _ := config["secret"].(string)
+	timeout, err := parseChannelTimeout(config["timeout"])
+	if err != nil {
+		return nil, err
+	}
+	return errors.NewDingTalkChannel(address, secret, timeout), nil
+}
+
+// parseEmailChannel 解析 email 渠道配置
+func parseEmailChannel(config map[string]interface{}) (*errors.EmailChannel, error) {
+	if err := emailSchema.Validate(config); err != nil {
+		return nil, err
+	}
+
+	email := errors.EmailConfig{}
+	email.Addr, _ = config["addr"].(string)
+	if _, _, err := net.SplitHostPort(email.Addr); err != nil {
+		return nil, fmt.Errorf("addr 必须为 host:port 格式")
+	}
+	email.Username, _ = config["username"].(string)
+	email.Password, _ = config["password"].(string)
+	email.From, _ = config["from"].(string)
+	if email.From == "" {
+		return nil, fmt.Errorf("未配置发件人 from")
+	}
+	email.To = toStringList(config["to"])
+	if len(email.To) == 0 {
+		return nil, fmt.Errorf("未配置收件人 to")
+	}
+	email.SubjectPrefix, _ = config["subject_prefix"].(string)
+
+	timeout, err := parseChannelTimeout(config["timeout"])
+	if err != nil {
+		return nil, err
+	}
+	email.Timeout = timeout
+	return errors.NewEmailChannel(email), nil
+}
+
+// parseChannelURL 解析通知渠道地址，只允许 http/https
+func parseChannelURL(value interface{}) (string, error) {
+	address, _ := value.(string)
+	if address == "" {
+		return "", fmt.Errorf("未配置 url")
+	}
+	u, err := url.Parse(address)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", fmt.Errorf("无效的 url: %s", address)
+	}
+	return address, nil
+}
+
+// parseChannelTimeout 解析通知渠道超时时间，未配置时返回 0（使用默认值）
+func parseChannelTimeout(value interface{}) (time.Duration, error) {
+	if value == nil {
+		return 0, nil
+	}
+	timeout, err := parseDuration(value)
+	if err != nil || timeout <= 0 {
+		return 0, fmt.Errorf("无效的 timeout: %v", value)
+	}
+	return timeout, nil
+}
+
+// toStringList 转换字符串数组配置
+func toStringList(value interface{}) []string {
+	switch list := value.(type) {
+	case []string:
+		return list
+	case []interface{}:
+		result := make([]string, 0, len(list))
+		for _, item := range list {
+			if s, ok := item.(string); ok && s != "" {
+				result = append(result, s)
+			}
+		}
+		return result
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package error
+
+import (
+	"testing"
+	"time"
+
+	"gateway-go/internal/errors"
+)
+
+func TestParseNotificationConfig(t *testing.T) {
+	config, err := parseNotificationConfig(map[string]interface{}{
+		"notification": map[string]interface{}{
+			"level":    "critical",
+			"interval": "30s",
+			"webhook":  map[string]interface{}{"url": "https://hooks.example.com/gateway", "headers": map[string]interface{}{"X-Token": "t"}},
+			"slack":    map[string]interface{}{"url": "https://hooks.slack.com/services/T000"},
+			"dingtalk": map[string]interface{}{"url": "https://oapi.dingtalk.com/robot/send?access_token=abc", "secret": "SEC"},
+			"email":    map[string]interface{}{"addr": "smtp.example.com:587", "from": "gw@example.com", "to": []interface{}{"ops@example.com"}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("解析配置失败: %v", err)
+	}
+	if config.LevelThreshold != errors.LevelCritical || config.Interval != 30*time.Second || len(config.Channels) != 4 {
+		t.Fatalf("通知配置 = %+v", config)
+	}
+	if _, ok := config.Channels[2].(*errors.SlackChannel); !ok {
+		t.Fatalf("第 3 个渠道 = %T，期望 Slack", config.Channels[2])
+	}
+	if _, ok := config.Channels[3].(*errors.DingTalkChannel); !ok {
+		t.Fatalf("第 4 个渠道 = %T，期望钉钉", config.Channels[3])
+	}
+
+	// 未配置时使用默认配置且不含通知渠道
+	config, err = parseNotificationConfig(map[string]interface{}{})
+	if err != nil || len(config.Channels) != 0 || config.Interval != errors.DefaultNotificationConfig.Interval {
+		t.Fatalf("未配置 notification 时 = %+v, %v", config, err)
+	}
+}
+
+func TestParseNotificationConfigErrors(t *testing.T) {
+	for name, notification := range map[string]map[string]interface{}{
+		"无效级别":       {"level": "fatal"},
+		"无效间隔":       {"interval": "soon"},
+		"slack 缺少地址": {"slack": map[string]interface{}{}},
+		"钉钉地址协议错误":   {"dingtalk": map[string]interface{}{"url": "ftp://example.com"}},
+		"邮件缺少收件人":    {"email": map[string]interface{}{"addr": "smtp.example.com:25", "from": "gw@example.com"}},
+		"未知字段":       {"sms": map[string]interface{}{}},
+	} {
+		if _, err := parseNotificationConfig(map[string]interface{}{"notification": notification}); err == nil {
+			t.Fatalf("%s: 期望解析失败", name)
+		}
+	}
+}
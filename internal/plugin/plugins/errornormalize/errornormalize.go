@@ -0,0 +1,285 @@
+package errornormalize
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+
+	"gateway-go/internal/errors"
+	"gateway-go/internal/plugin/core"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 默认配置
+const (
+	defaultMinStatus   = 400
+	defaultMaxBodySize = 64 * 1024
+)
+
+// defaultMessageFields 默认从上游错误响应中提取错误信息的字段
+var defaultMessageFields = []string{"message", "error", "msg"}
+
+// ErrorNormalizePlugin 错误响应规范化插件
+// 按上游响应状态码重映射状态码，并把错误响应体改写为网关统一的错误格式（errors.Response）
+type ErrorNormalizePlugin struct {
+	*core.BasePlugin
+	settings *settings
+	mu       sync.RWMutex
+}
+
+// settings 解析后的插件配置
+type settings struct {
+	statusMap     map[int]int
+	rewriteBody   bool
+	minStatus     int
+	messageFields []string
+	maxBodySize   int
+}
+
+// New 创建错误响应规范化插件
+func New() *ErrorNormalizePlugin {
+	return &ErrorNormalizePlugin{
+		BasePlugin: core.NewBasePlugin("error_normalize", 95, nil),
+	}
+}
+
+// configSchema 插件配置结构
+var configSchema = core.ConfigSchema{
+	"status_map":     core.FieldObject,
+	"rewrite_body":   core.FieldBool,
+	"min_status":     core.FieldInt,
+	"message_fields": core.FieldStringList,
+	"max_body_size":  core.FieldInt,
+}
+
+// ValidateConfig 校验插件配置
+func (p *ErrorNormalizePlugin) ValidateConfig(config map[string]interface{}) error {
+	if err := configSchema.Validate(config); err != nil {
+		return err
+	}
+	_, err := parseSettings(config)
+	return err
+}
+
+// Init 初始化插件
+func (p *ErrorNormalizePlugin) Init(config interface{}) error {
+	// 类型断言
+	configMap, ok := config.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("配置类型错误，期望 map[string]interface{}")
+	}
+
+	s, err := parseSettings(configMap)
+	if err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	p.settings = s
+	p.mu.Unlock()
+	return nil
+}
+
+// parseSettings 解析插件配置
+func parseSettings(config map[string]interface{}) (*settings, error) {
+	s := &settings{
+		statusMap:     make(map[int]int),
+		rewriteBody:   true,
+		minStatus:     defaultMinStatus,
+		messageFields: defaultMessageFields,
+		maxBodySize:   defaultMaxBodySize,
+	}
+
+	if statusMap, ok := config["status_map"].(map[string]interface{}); ok {
+		for from, to := range statusMap {
+			fromCode, err := strconv.Atoi(from)
+			if err != nil || !validStatus(fromCode) {
+				return nil, fmt.Errorf("status_map 中的状态码无效: %s", from)
+			}
+			toCode, ok := core.ToInt(to)
+			if !ok || !validStatus(toCode) {
+				return nil, fmt.Errorf("status_map 中 %s 映射的状态码无效: %v", from, to)
+			}
+			s.statusMap[fromCode] = toCode
+		}
+	}
+	if rewrite, ok := config["rewrite_body"].(bool); ok {
+		s.rewriteBody = rewrite
+	}
+	if status, ok := core.ToInt(config["min_status"]); ok {
+		if !validStatus(status) {
+			return nil, fmt.Errorf("min_status 必须在 100-599 之间: %d", status)
+		}
+		s.minStatus = status
+	}
+	if fields, ok := config["message_fields"].([]interface{}); ok {
+		s.messageFields = nil
+		for _, field := range fields {
+			if name, ok := field.(string); ok && name != "" {
+				s.messageFields = append(s.messageFields, name)
+			}
+		}
+	}
+	if size, ok := core.ToInt(config["max_body_size"]); ok {
+		if size <= 0 {
+			return nil, fmt.Errorf("max_body_size 必须大于 0")
+		}
+		s.maxBodySize = size
+	}
+	return s, nil
+}
+
+// validStatus 判断是否为有效的 HTTP 状态码
+func validStatus(code int) bool {
+	return code >= 100 && code <= 599
+}
+
+// Execute 执行插件
+func (p *ErrorNormalizePlugin) Execute(ctx *gin.Context) error {
+	p.mu.RLock()
+	s := p.settings
+	p.mu.RUnlock()
+	if s == nil {
+		return nil
+	}
+
+	w := &normalizeWriter{ResponseWriter: ctx.Writer, context: ctx, settings: s}
+	ctx.Writer = w
+	// 被改写的错误响应在请求处理结束后写出
+	core.OnComplete(ctx, w.flush)
+	return nil
+}
+
+// normalizeWriter 重映射状态码并缓存需要改写的错误响应体
+type normalizeWriter struct {
+	gin.ResponseWriter
+	context  *gin.Context
+	settings *settings
+	// 状态码已写入（直接写出或等待改写）
+	statusWritten bool
+	// 是否正在缓存错误响应，等待改写
+	rewriting bool
+	// 映射后的状态码
+	status int
+	body   bytes.Buffer
+}
+
+// WriteHeader 写入状态码，需要改写的错误响应延迟到请求结束时写出
+func (w *normalizeWriter) WriteHeader(code int) {
+	if w.statusWritten {
+		return
+	}
+	w.statusWritten = true
+	w.status = code
+	if mapped, ok := w.settings.statusMap[code]; ok {
+		w.status = mapped
+	}
+
+	// 流式响应不改写响应体，只映射状态码
+	if w.settings.rewriteBody && code >= w.settings.minStatus && !core.IsStreaming(w.context) {
+		w.rewriting = true
+		return
+	}
+	w.ResponseWriter.WriteHeader(w.status)
+}
+
+// Write 写入响应体，改写模式下只缓存前 max_body_size 字节用于提取错误信息
+func (w *normalizeWriter) Write(data []byte) (int, error) {
+	if !w.statusWritten {
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.rewriting {
+		if remaining := w.settings.maxBodySize - w.body.Len(); remaining > 0 {
+			if len(data) > remaining {
+				w.body.Write(data[:remaining])
+			} else {
+				w.body.Write(data)
+			}
+		}
+		return len(data), nil
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+// WriteString 写入字符串响应体
+func (w *normalizeWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// Status 返回映射后的状态码，等待改写时也返回最终写出的状态码
+func (w *normalizeWriter) Status() int {
+	if w.rewriting {
+		return w.status
+	}
+	return w.ResponseWriter.Status()
+}
+
+// Written 等待改写的响应视为已写出，避免其他处理再次写入响应
+func (w *normalizeWriter) Written() bool {
+	return w.rewriting || w.ResponseWriter.Written()
+}
+
+// Flush 等待改写时不刷新
+func (w *normalizeWriter) Flush() {
+	if !w.rewriting {
+		w.ResponseWriter.Flush()
+	}
+}
+
+// Unwrap 返回被包装的写入器，供 http.ResponseController 访问底层连接
+func (w *normalizeWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// flush 写出改写后的错误响应
+func (w *normalizeWriter) flush() {
+	if !w.rewriting {
+		return
+	}
+	w.rewriting = false
+
+	header := w.ResponseWriter.Header()
+	message := ""
+	// 压缩的响应体无法提取错误信息
+	if header.Get("Content-Encoding") == "" {
+		message = w.settings.extractMessage(w.body.Bytes())
+	}
+	if message == "" {
+		message = http.StatusText(w.status)
+	}
+
+	body, err := json.Marshal(errors.NewResponse(w.context, w.status, message, nil))
+	if err != nil {
+		return
+	}
+	header.Del("Content-Encoding")
+	header.Set("Content-Type", "application/json; charset=utf-8")
+	header.Set("Content-Length", strconv.Itoa(len(body)))
+	w.ResponseWriter.WriteHeader(w.status)
+	w.ResponseWriter.Write(body)
+}
+
+// extractMessage 从 JSON 错误响应中按配置的字段提取错误信息，支持 {"error": {"message": "..."}} 形式的嵌套
+func (s *settings) extractMessage(body []byte) string {
+	var object map[string]interface{}
+	if json.Unmarshal(body, &object) != nil {
+		return ""
+	}
+	for _, field := range s.messageFields {
+		switch value := object[field].(type) {
+		case string:
+			if value != "" {
+				return value
+			}
+		case map[string]interface{}:
+			if nested, ok := value["message"].(string); ok && nested != "" {
+				return nested
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,133 @@
+package errornormalize
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"gateway-go/internal/errors"
+	"gateway-go/internal/plugin/core"
+	"gateway-go/internal/plugin/plugintest"
+
+	"github.com/gin-gonic/gin"
+)
+
+// respond 执行插件后模拟上游写出响应，并在请求结束时执行完成回调
+func respond(p *ErrorNormalizePlugin, status int, header http.Header, body string) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	rec := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(rec)
+	c.Request = httptest.NewRequest(http.MethodGet, "/orders", nil)
+	c.Set(errors.RequestIDKey, "req-1")
+	p.Execute(c)
+
+	for key, values := range header {
+		c.Writer.Header()[key] = values
+	}
+	c.Writer.WriteHeader(status)
+	c.Writer.WriteString(body)
+	core.RunCompletions(c)
+	return rec
+}
+
+// decode 解析统一格式的错误响应
+func decode(t *testing.T, rec *httptest.ResponseRecorder) errors.Response {
+	t.Helper()
+	var resp errors.Response
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("解析响应 %s 失败: %v", rec.Body, err)
+	}
+	return resp
+}
+
+func TestErrorNormalizeRewritesBody(t *testing.T) {
+	p := plugintest.Init(t, New(), map[string]interface{}{"status_map": map[string]interface{}{"418": 400}})
+	jsonHeader := http.Header{"Content-Type": {"application/json"}}
+
+	tests := []struct {
+		name    string
+		status  int
+		header  http.Header
+		body    string
+		code    int
+		message string
+	}{
+		{"重映射状态码", http.StatusTeapot, jsonHeader, `{"error":"bad tea"}`, http.StatusBadRequest, "bad tea"},
+		{"嵌套的错误信息", http.StatusNotFound, jsonHeader, `{"error":{"message":"order not found","code":"E404"}}`, http.StatusNotFound, "order not found"},
+		{"按字段顺序提取", http.StatusConflict, jsonHeader, `{"msg":"ignored","message":"version conflict"}`, http.StatusConflict, "version conflict"},
+		{"非 JSON 响应体", http.StatusBadGateway, http.Header{"Content-Type": {"text/html"}}, "<h1>oops</h1>", http.StatusBadGateway, "Bad Gateway"},
+		{"压缩的响应体", http.StatusInternalServerError, http.Header{"Content-Encoding": {"gzip"}}, "\x1f\x8b", http.StatusInternalServerError, "Internal Server Error"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := respond(p, tt.status, tt.header, tt.body)
+			if rec.Code != tt.code {
+				t.Fatalf("状态码 = %d，期望 %d", rec.Code, tt.code)
+			}
+			resp := decode(t, rec)
+			if resp.Code != tt.code || resp.Message != tt.message || resp.RequestID != "req-1" {
+				t.Fatalf("错误响应 = %+v，期望 code=%d message=%q", resp, tt.code, tt.message)
+			}
+			if rec.Header().Get("Content-Type") != "application/json; charset=utf-8" || rec.Header().Get("Content-Encoding") != "" {
+				t.Fatalf("响应头 = %v", rec.Header())
+			}
+		})
+	}
+}
+
+func TestErrorNormalizeLeavesSuccessResponses(t *testing.T) {
+	p := plugintest.Init(t, New(), map[string]interface{}{"status_map": map[string]interface{}{"201": 200}})
+
+	// 成功响应只映射状态码，响应体原样写出
+	rec := respond(p, http.StatusCreated, nil, `{"id":1}`)
+	if rec.Code != http.StatusOK || rec.Body.String() != `{"id":1}` {
+		t.Fatalf("成功响应 = %d %s，期望 200 原样响应体", rec.Code, rec.Body)
+	}
+}
+
+func TestErrorNormalizeStatusOnly(t *testing.T) {
+	p := plugintest.Init(t, New(), map[string]interface{}{
+		"status_map":   map[string]interface{}{"418": 400},
+		"rewrite_body": false,
+	})
+
+	rec := respond(p, http.StatusTeapot, nil, "teapot")
+	if rec.Code != http.StatusBadRequest || rec.Body.String() != "teapot" {
+		t.Fatalf("不改写响应体时响应 = %d %s，期望 400 原样响应体", rec.Code, rec.Body)
+	}
+}
+
+func TestErrorNormalizeMessageFieldsAndBodyLimit(t *testing.T) {
+	p := plugintest.Init(t, New(), map[string]interface{}{
+		"message_fields": []interface{}{"detail"},
+		"min_status":     500,
+		"max_body_size":  20,
+	})
+
+	// 低于 min_status 的错误响应不改写
+	if rec := respond(p, http.StatusNotFound, nil, `{"detail":"missing"}`); rec.Body.String() != `{"detail":"missing"}` {
+		t.Fatalf("404 响应体 = %s，期望不改写", rec.Body)
+	}
+	if resp := decode(t, respond(p, http.StatusServiceUnavailable, nil, `{"detail":"down"}`)); resp.Message != "down" {
+		t.Fatalf("错误信息 = %q，期望从 detail 字段提取", resp.Message)
+	}
+	// 超出 max_body_size 的响应体被截断，无法解析时使用状态码描述
+	if resp := decode(t, respond(p, http.StatusServiceUnavailable, nil, `{"detail":"maintenance window"}`)); resp.Message != "Service Unavailable" {
+		t.Fatalf("错误信息 = %q，期望使用状态码描述", resp.Message)
+	}
+}
+
+func TestParseSettingsErrors(t *testing.T) {
+	for name, config := range map[string]map[string]interface{}{
+		"源状态码无效":        {"status_map": map[string]interface{}{"abc": 400}},
+		"源状态码超出范围":      {"status_map": map[string]interface{}{"600": 400}},
+		"目标状态码无效":       {"status_map": map[string]interface{}{"418": "bad"}},
+		"min_status 无效": {"min_status": 42},
+		"响应体上限为 0":      {"max_body_size": 0},
+	} {
+		if _, err := parseSettings(config); err == nil {
+			t.Fatalf("%s: 期望解析失败", name)
+		}
+	}
+}
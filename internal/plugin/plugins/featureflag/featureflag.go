@@ -0,0 +1,181 @@
+package featureflag
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"gateway-go/internal/plugin/core"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 默认配置
+const (
+	defaultIdentifierHeader = "X-User-ID"
+	// 分桶数量，百分比精确到 0.01%
+	bucketCount = 10000
+)
+
+// FeatureFlagPlugin 功能开关插件，按用户标识的稳定哈希和放量百分比计算开关并写入请求头
+type FeatureFlagPlugin struct {
+	*core.BasePlugin
+	settings *settings
+	mu       sync.RWMutex
+}
+
+// settings 解析后的插件配置
+type settings struct {
+	identifierHeader string
+	flags            []flag
+}
+
+// flag 单个功能开关
+type flag struct {
+	name   string
+	header string
+	// 放量的分桶数，分桶号小于该值的用户开启
+	buckets uint64
+}
+
+// New 创建功能开关插件
+func New() *FeatureFlagPlugin {
+	return &FeatureFlagPlugin{
+		BasePlugin: core.NewBasePlugin("feature_flag", 30, nil),
+	}
+}
+
+// configSchema 插件配置结构
+var configSchema = core.ConfigSchema{
+	"identifier_header": core.FieldString,
+	"flags":             core.FieldList,
+}
+
+// flagSchema 功能开关配置结构
+var flagSchema = core.ConfigSchema{
+	"name":       core.FieldString,
+	"percentage": core.FieldNumber,
+	"header":     core.FieldString,
+}
+
+// ValidateConfig 校验插件配置
+func (p *FeatureFlagPlugin) ValidateConfig(config map[string]interface{}) error {
+	if err := configSchema.Validate(config); err != nil {
+		return err
+	}
+	_, err := parseSettings(config)
+	return err
+}
+
+// Init 初始化插件
+func (p *FeatureFlagPlugin) Init(config interface{}) error {
+	// 类型断言
+	configMap, ok := config.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("配置类型错误，期望 map[string]interface{}")
+	}
+
+	s, err := parseSettings(configMap)
+	if err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	p.settings = s
+	p.mu.Unlock()
+	return nil
+}
+
+// parseSettings 解析插件配置
+func parseSettings(config map[string]interface{}) (*settings, error) {
+	s := &settings{
+		identifierHeader: defaultIdentifierHeader,
+	}
+	if header, ok := config["identifier_header"].(string); ok && header != "" {
+		s.identifierHeader = header
+	}
+
+	var items []interface{}
+	switch list := config["flags"].(type) {
+	case []interface{}:
+		items = list
+	case nil:
+	default:
+		return nil, fmt.Errorf("flags 必须为数组")
+	}
+	if len(items) == 0 {
+		return nil, fmt.Errorf("至少需要配置一个功能开关")
+	}
+
+	names := make(map[string]bool, len(items))
+	for i, item := range items {
+		raw, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("flags[%d] 必须为对象", i)
+		}
+		if err := flagSchema.Validate(raw); err != nil {
+			return nil, fmt.Errorf("flags[%d]: %w", i, err)
+		}
+
+		name, _ := raw["name"].(string)
+		if name == "" {
+			return nil, fmt.Errorf("flags[%d] 未配置 name", i)
+		}
+		if names[name] {
+			return nil, fmt.Errorf("功能开关 %s 重复配置", name)
+		}
+		names[name] = true
+
+		percentage, ok := core.ToFloat64(raw["percentage"])
+		if !ok || percentage < 0 || percentage > 100 {
+			return nil, fmt.Errorf("功能开关 %s 的 percentage 必须在 0-100 之间", name)
+		}
+
+		header, _ := raw["header"].(string)
+		if header == "" {
+			header = "X-Feature-" + name + "Enabled"
+		}
+		if strings.ContainsAny(header, " \t\r\n:") {
+			return nil, fmt.Errorf("功能开关 %s 的请求头名称无效: %q", name, header)
+		}
+
+		s.flags = append(s.flags, flag{
+			name:    name,
+			header:  header,
+			buckets: uint64(percentage*bucketCount/100 + 0.5),
+		})
+	}
+	return s, nil
+}
+
+// Execute 执行插件
+func (p *FeatureFlagPlugin) Execute(ctx *gin.Context) error {
+	p.mu.RLock()
+	s := p.settings
+	p.mu.RUnlock()
+
+	identifier := ctx.GetHeader(s.identifierHeader)
+	if identifier == "" {
+		identifier = ctx.ClientIP()
+	}
+
+	// 覆盖客户端自带的同名请求头，避免客户端自行开启功能
+	for _, f := range s.flags {
+		ctx.Request.Header.Set(f.header, strconv.FormatBool(f.enabled(identifier)))
+	}
+	return nil
+}
+
+// enabled 计算用户是否开启该功能，同一用户的结果固定
+func (f flag) enabled(identifier string) bool {
+	return bucket(f.name, identifier) < f.buckets
+}
+
+// bucket 按开关名称和用户标识计算分桶号，不同开关的分桶相互独立
+func bucket(name, identifier string) uint64 {
+	sum := sha256.Sum256([]byte(name + ":" + identifier))
+	return binary.BigEndian.Uint64(sum[:8]) % bucketCount
+}
@@ -0,0 +1,107 @@
+package featureflag
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"gateway-go/internal/plugin/plugintest"
+
+	"github.com/gin-gonic/gin"
+)
+
+// flagHeaders 以 userID 执行插件，返回转发给上游的请求头
+func flagHeaders(p *FeatureFlagPlugin, userID string, forged map[string]string) http.Header {
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodGet, "/checkout", nil)
+	c.Request.RemoteAddr = "192.0.2.1:1234"
+	if userID != "" {
+		c.Request.Header.Set("X-User-ID", userID)
+	}
+	for name, value := range forged {
+		c.Request.Header.Set(name, value)
+	}
+	p.Execute(c)
+	return c.Request.Header
+}
+
+func TestPercentageSplit(t *testing.T) {
+	p := plugintest.Init(t, New(), map[string]interface{}{
+		"flags": []interface{}{
+			map[string]interface{}{"name": "NewCheckout", "percentage": 30},
+			map[string]interface{}{"name": "Off", "percentage": 0},
+			map[string]interface{}{"name": "On", "percentage": 100, "header": "X-On"},
+		},
+	})
+
+	const users = 10000
+	enabled := 0
+	for i := 0; i < users; i++ {
+		header := flagHeaders(p, fmt.Sprintf("user-%d", i), nil)
+		if header.Get("X-Feature-NewCheckoutEnabled") == "true" {
+			enabled++
+		}
+		if header.Get("X-Feature-OffEnabled") != "false" || header.Get("X-On") != "true" {
+			t.Fatalf("0%% 和 100%% 放量的开关 = %s, %s", header.Get("X-Feature-OffEnabled"), header.Get("X-On"))
+		}
+	}
+	// 30% 放量，允许 ±2% 的误差
+	if ratio := float64(enabled) / users; ratio < 0.28 || ratio > 0.32 {
+		t.Fatalf("开启比例 = %.3f，期望约 0.30", ratio)
+	}
+}
+
+func TestFlagIsStickyPerUser(t *testing.T) {
+	p := plugintest.Init(t, New(), map[string]interface{}{
+		"flags": []interface{}{map[string]interface{}{"name": "NewCheckout", "percentage": 50}},
+	})
+
+	for i := 0; i < 100; i++ {
+		userID := fmt.Sprintf("user-%d", i)
+		first := flagHeaders(p, userID, nil).Get("X-Feature-NewCheckoutEnabled")
+		for j := 0; j < 5; j++ {
+			if got := flagHeaders(p, userID, nil).Get("X-Feature-NewCheckoutEnabled"); got != first {
+				t.Fatalf("用户 %s 的开关在多次请求间变化: %s -> %s", userID, first, got)
+			}
+		}
+	}
+
+	// 提高放量比例时已开启的用户保持开启
+	wider := plugintest.Init(t, New(), map[string]interface{}{
+		"flags": []interface{}{map[string]interface{}{"name": "NewCheckout", "percentage": 80}},
+	})
+	for i := 0; i < 100; i++ {
+		userID := fmt.Sprintf("user-%d", i)
+		if flagHeaders(p, userID, nil).Get("X-Feature-NewCheckoutEnabled") == "true" &&
+			flagHeaders(wider, userID, nil).Get("X-Feature-NewCheckoutEnabled") != "true" {
+			t.Fatalf("放量提高后用户 %s 的开关被关闭", userID)
+		}
+	}
+}
+
+func TestClientCannotForceFlag(t *testing.T) {
+	p := plugintest.Init(t, New(), map[string]interface{}{
+		"flags": []interface{}{map[string]interface{}{"name": "Beta", "percentage": 0}},
+	})
+	header := flagHeaders(p, "", map[string]string{"X-Feature-BetaEnabled": "true"})
+	if got := header.Get("X-Feature-BetaEnabled"); got != "false" {
+		t.Fatalf("客户端自带的开关请求头未被覆盖: %s", got)
+	}
+}
+
+func TestParseSettingsErrors(t *testing.T) {
+	for name, config := range map[string]map[string]interface{}{
+		"缺少 flags": {},
+		"缺少 name":  {"flags": []interface{}{map[string]interface{}{"percentage": 10}}},
+		"百分比超过100": {"flags": []interface{}{map[string]interface{}{"name": "A", "percentage": 101}}},
+		"重复的开关":    {"flags": []interface{}{map[string]interface{}{"name": "A", "percentage": 1}, map[string]interface{}{"name": "A", "percentage": 2}}},
+		"无效的请求头名称": {"flags": []interface{}{map[string]interface{}{"name": "A", "percentage": 1, "header": "X Bad"}}},
+		"开关不是对象":   {"flags": []interface{}{"A"}},
+	} {
+		if err := New().ValidateConfig(config); err == nil {
+			t.Fatalf("%s: 期望校验失败", name)
+		}
+	}
+}
@@ -0,0 +1,267 @@
+package geoip
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+
+	"gateway-go/internal/errors"
+	"gateway-go/internal/plugin/core"
+
+	"github.com/gin-gonic/gin"
+	"github.com/oschwald/maxminddb-golang"
+)
+
+// 默认配置
+const (
+	defaultCountryHeader = "X-Geo-Country"
+	defaultCacheSize     = 10000
+)
+
+// CountryKey 上下文中客户端所在国家的键，值为 ISO 3166-1 两位国家代码
+const CountryKey = "geo_country"
+
+// GeoIPPlugin 地理位置插件，按客户端IP所在国家放行或拒绝请求
+type GeoIPPlugin struct {
+	*core.BasePlugin
+	state *state
+	mu    sync.RWMutex
+}
+
+// state 插件配置和数据库，配置重载时整体替换
+type state struct {
+	settings *settings
+	reader   *maxminddb.Reader
+	cache    *lookupCache
+}
+
+// settings 解析后的插件配置
+type settings struct {
+	database      string
+	allow         map[string]bool
+	deny          map[string]bool
+	allowUnknown  bool
+	countryHeader string
+	cacheSize     int
+}
+
+// countryRecord GeoLite2 Country/City 数据库中的国家信息
+type countryRecord struct {
+	Country struct {
+		ISOCode string `maxminddb:"iso_code"`
+	} `maxminddb:"country"`
+	RegisteredCountry struct {
+		ISOCode string `maxminddb:"iso_code"`
+	} `maxminddb:"registered_country"`
+}
+
+// New 创建地理位置插件
+func New() *GeoIPPlugin {
+	return &GeoIPPlugin{
+		BasePlugin: core.NewBasePlugin("geoip", 4, nil),
+	}
+}
+
+// configSchema 插件配置结构
+var configSchema = core.ConfigSchema{
+	"database":        core.FieldString,
+	"allow_countries": core.FieldStringList,
+	"deny_countries":  core.FieldStringList,
+	"allow_unknown":   core.FieldBool,
+	"country_header":  core.FieldString,
+	"cache_size":      core.FieldInt,
+}
+
+// ValidateConfig 校验插件配置
+func (p *GeoIPPlugin) ValidateConfig(config map[string]interface{}) error {
+	if err := configSchema.Validate(config); err != nil {
+		return err
+	}
+	_, err := parseSettings(config)
+	return err
+}
+
+// Init 初始化插件，加载 MaxMind 数据库
+func (p *GeoIPPlugin) Init(config interface{}) error {
+	// 类型断言
+	configMap, ok := config.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("配置类型错误，期望 map[string]interface{}")
+	}
+
+	s, err := parseSettings(configMap)
+	if err != nil {
+		return err
+	}
+
+	// 读入内存而不是 mmap，替换数据库时进行中的查询不受影响
+	data, err := os.ReadFile(s.database)
+	if err != nil {
+		return fmt.Errorf("读取 GeoIP 数据库失败: %v", err)
+	}
+	reader, err := maxminddb.FromBytes(data)
+	if err != nil {
+		return fmt.Errorf("解析 GeoIP 数据库失败: %v", err)
+	}
+
+	p.mu.Lock()
+	p.state = &state{
+		settings: s,
+		reader:   reader,
+		cache:    newLookupCache(s.cacheSize),
+	}
+	p.mu.Unlock()
+	return nil
+}
+
+// parseSettings 解析插件配置
+func parseSettings(config map[string]interface{}) (*settings, error) {
+	s := &settings{
+		allowUnknown:  true,
+		countryHeader: defaultCountryHeader,
+		cacheSize:     defaultCacheSize,
+	}
+
+	s.database, _ = config["database"].(string)
+	if s.database == "" {
+		return nil, fmt.Errorf("未配置 GeoIP 数据库路径 database")
+	}
+
+	var err error
+	if s.allow, err = parseCountries(config["allow_countries"], "allow_countries"); err != nil {
+		return nil, err
+	}
+	if s.deny, err = parseCountries(config["deny_countries"], "deny_countries"); err != nil {
+		return nil, err
+	}
+	if unknown, ok := config["allow_unknown"].(bool); ok {
+		s.allowUnknown = unknown
+	}
+	if header, ok := config["country_header"].(string); ok && header != "" {
+		s.countryHeader = header
+	}
+	if size, ok := core.ToInt(config["cache_size"]); ok {
+		if size < 0 {
+			return nil, fmt.Errorf("cache_size 不能为负数: %d", size)
+		}
+		s.cacheSize = size
+	}
+	return s, nil
+}
+
+// parseCountries 解析国家代码列表，统一转为大写
+func parseCountries(value interface{}, field string) (map[string]bool, error) {
+	list, _ := value.([]interface{})
+	if len(list) == 0 {
+		return nil, nil
+	}
+	countries := make(map[string]bool, len(list))
+	for i, item := range list {
+		code, _ := item.(string)
+		if len(code) != 2 {
+			return nil, fmt.Errorf("%s[%d] 必须为两位国家代码: %q", field, i, code)
+		}
+		countries[strings.ToUpper(code)] = true
+	}
+	return countries, nil
+}
+
+// Execute 执行插件
+func (p *GeoIPPlugin) Execute(ctx *gin.Context) error {
+	p.mu.RLock()
+	st := p.state
+	p.mu.RUnlock()
+	if st == nil {
+		return nil
+	}
+	s := st.settings
+
+	// 国家请求头只能由插件写入
+	ctx.Request.Header.Del(s.countryHeader)
+
+	ip := ctx.ClientIP()
+	country := st.lookup(ip)
+	if country != "" {
+		ctx.Set(CountryKey, country)
+		ctx.Request.Header.Set(s.countryHeader, country)
+	}
+
+	if !s.allowed(country) {
+		if country == "" {
+			country = "未知"
+		}
+		errors.Abort(ctx, http.StatusForbidden, "所在地区禁止访问")
+		return fmt.Errorf("客户端 %s 所在地区 %s 禁止访问", ip, country)
+	}
+	return nil
+}
+
+// allowed 判断国家是否允许访问，country 为空表示未能识别
+func (s *settings) allowed(country string) bool {
+	if country == "" {
+		return s.allowUnknown
+	}
+	if s.deny[country] {
+		return false
+	}
+	return s.allow == nil || s.allow[country]
+}
+
+// lookup 查询IP所在国家，优先使用缓存，未找到时返回空字符串
+func (st *state) lookup(ip string) string {
+	if country, ok := st.cache.get(ip); ok {
+		return country
+	}
+
+	var country string
+	if addr := net.ParseIP(ip); addr != nil {
+		var record countryRecord
+		if err := st.reader.Lookup(addr, &record); err == nil {
+			country = record.Country.ISOCode
+			if country == "" {
+				country = record.RegisteredCountry.ISOCode
+			}
+		}
+	}
+	st.cache.set(ip, country)
+	return country
+}
+
+// lookupCache 查询结果缓存，达到容量上限时清空
+type lookupCache struct {
+	size    int
+	entries map[string]string
+	mu      sync.RWMutex
+}
+
+// newLookupCache 创建查询结果缓存，size 为 0 时不缓存
+func newLookupCache(size int) *lookupCache {
+	return &lookupCache{
+		size:    size,
+		entries: make(map[string]string),
+	}
+}
+
+// get 获取缓存的查询结果
+func (c *lookupCache) get(ip string) (string, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	country, ok := c.entries[ip]
+	return country, ok
+}
+
+// set 缓存查询结果
+func (c *lookupCache) set(ip, country string) {
+	if c.size == 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.entries) >= c.size {
+		c.entries = make(map[string]string, c.size)
+	}
+	c.entries[ip] = country
+}
@@ -0,0 +1,276 @@
+package geoip
+
+import (
+	"encoding/binary"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gateway-go/internal/plugin/plugintest"
+
+	"github.com/gin-gonic/gin"
+)
+
+// testNetworks 测试数据库中的网段和所属国家
+var testNetworks = map[string]string{
+	"1.0.16.0/20":     "JP",
+	"2.125.160.0/19":  "GB",
+	"81.2.69.0/24":    "GB",
+	"175.16.199.0/24": "CN",
+	"216.160.83.0/24": "US",
+}
+
+// mmdbNode 构建测试数据库时的搜索树节点，data 非负时为叶子节点
+type mmdbNode struct {
+	children [2]*mmdbNode
+	data     int
+	id       int
+}
+
+// writeTestDatabase 按 MaxMind DB 格式生成只包含 networks 的 IPv4 国家数据库，返回文件路径
+func writeTestDatabase(t *testing.T, networks map[string]string) string {
+	t.Helper()
+
+	// 数据段：每个国家一条 {"country": {"iso_code": <国家>}} 记录
+	var data []byte
+	offsets := make(map[string]int)
+	root := &mmdbNode{data: -1}
+	for cidr, country := range networks {
+		if _, ok := offsets[country]; !ok {
+			offsets[country] = len(data)
+			data = append(data, mmdbMap(1)...)
+			data = append(data, mmdbString("country")...)
+			data = append(data, mmdbMap(1)...)
+			data = append(data, mmdbString("iso_code")...)
+			data = append(data, mmdbString(country)...)
+		}
+
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ones, _ := network.Mask.Size()
+		ip := network.IP.To4()
+		node := root
+		for i := 0; i < ones; i++ {
+			bit := ip[i/8] >> (7 - i%8) & 1
+			if node.children[bit] == nil {
+				node.children[bit] = &mmdbNode{data: -1}
+			}
+			node = node.children[bit]
+		}
+		node.data = offsets[country]
+	}
+
+	// 按先序遍历为内部节点编号
+	var nodes []*mmdbNode
+	var number func(n *mmdbNode)
+	number = func(n *mmdbNode) {
+		if n == nil || n.data >= 0 {
+			return
+		}
+		n.id = len(nodes)
+		nodes = append(nodes, n)
+		number(n.children[0])
+		number(n.children[1])
+	}
+	number(root)
+
+	// 搜索树：每个节点两条 24 位记录，指向子节点、空记录或数据段
+	nodeCount := len(nodes)
+	record := func(child *mmdbNode) uint32 {
+		switch {
+		case child == nil:
+			return uint32(nodeCount)
+		case child.data >= 0:
+			return uint32(nodeCount + 16 + child.data)
+		}
+		return uint32(child.id)
+	}
+	var buf []byte
+	for _, n := range nodes {
+		for _, child := range n.children {
+			value := record(child)
+			buf = append(buf, byte(value>>16), byte(value>>8), byte(value))
+		}
+	}
+	buf = append(buf, make([]byte, 16)...)
+	buf = append(buf, data...)
+
+	// 元数据
+	buf = append(buf, "\xAB\xCD\xEFMaxMind.com"...)
+	buf = append(buf, mmdbMap(4)...)
+	buf = append(buf, mmdbString("node_count")...)
+	buf = append(buf, mmdbUint(6, uint64(nodeCount))...)
+	buf = append(buf, mmdbString("record_size")...)
+	buf = append(buf, mmdbUint(5, 24)...)
+	buf = append(buf, mmdbString("ip_version")...)
+	buf = append(buf, mmdbUint(5, 4)...)
+	buf = append(buf, mmdbString("database_type")...)
+	buf = append(buf, mmdbString("GeoLite2-Country-Test")...)
+
+	path := filepath.Join(t.TempDir(), "GeoLite2-Country-Test.mmdb")
+	if err := os.WriteFile(path, buf, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// mmdbString 编码 UTF-8 字符串（长度小于 29）
+func mmdbString(s string) []byte {
+	return append([]byte{2<<5 | byte(len(s))}, s...)
+}
+
+// mmdbMap 编码包含 size 个键值对的 map 的控制字节
+func mmdbMap(size int) []byte {
+	return []byte{7<<5 | byte(size)}
+}
+
+// mmdbUint 编码无符号整数，typ 为 5（uint16）或 6（uint32）
+func mmdbUint(typ byte, value uint64) []byte {
+	var b [8]byte
+	binary.BigEndian.PutUint64(b[:], value)
+	payload := b[:]
+	for len(payload) > 0 && payload[0] == 0 {
+		payload = payload[1:]
+	}
+	return append([]byte{typ<<5 | byte(len(payload))}, payload...)
+}
+
+// newPlugin 使用测试数据库初始化插件
+func newPlugin(t *testing.T, config map[string]interface{}) *GeoIPPlugin {
+	t.Helper()
+	config["database"] = writeTestDatabase(t, testNetworks)
+	return plugintest.Init(t, New(), config)
+}
+
+// execute 以 clientIP 作为客户端地址执行插件
+func execute(p *GeoIPPlugin, clientIP string, header http.Header) (*httptest.ResponseRecorder, *gin.Context) {
+	gin.SetMode(gin.TestMode)
+	rec := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(rec)
+	c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+	c.Request.RemoteAddr = net.JoinHostPort(clientIP, "40000")
+	for name, values := range header {
+		c.Request.Header[name] = values
+	}
+	p.Execute(c)
+	return rec, c
+}
+
+func TestLookupKnownIPs(t *testing.T) {
+	p := newPlugin(t, map[string]interface{}{})
+
+	tests := []struct {
+		ip      string
+		country string
+	}{
+		{"1.0.16.1", "JP"},
+		{"1.0.31.255", "JP"},
+		{"1.0.32.0", ""},
+		{"2.125.160.216", "GB"},
+		{"81.2.69.142", "GB"},
+		{"175.16.199.0", "CN"},
+		{"216.160.83.56", "US"},
+		{"8.8.8.8", ""},
+		{"::1", ""},
+	}
+	for _, tt := range tests {
+		_, c := execute(p, tt.ip, nil)
+		if got := c.GetString(CountryKey); got != tt.country || c.Request.Header.Get("X-Geo-Country") != tt.country {
+			t.Fatalf("%s 的国家 = %q（请求头 %q），期望 %q", tt.ip, got, c.Request.Header.Get("X-Geo-Country"), tt.country)
+		}
+		if c.IsAborted() {
+			t.Fatalf("未配置国家列表时 %s 被拒绝", tt.ip)
+		}
+	}
+}
+
+func TestAllowAndDenyCountries(t *testing.T) {
+	tests := []struct {
+		name   string
+		config map[string]interface{}
+		ip     string
+		status int
+	}{
+		{"允许列表内", map[string]interface{}{"allow_countries": []interface{}{"cn", "JP"}}, "175.16.199.1", http.StatusOK},
+		{"允许列表外", map[string]interface{}{"allow_countries": []interface{}{"CN"}}, "216.160.83.56", http.StatusForbidden},
+		{"拒绝列表内", map[string]interface{}{"deny_countries": []interface{}{"GB"}}, "81.2.69.142", http.StatusForbidden},
+		{"拒绝优先于允许", map[string]interface{}{"allow_countries": []interface{}{"GB"}, "deny_countries": []interface{}{"GB"}}, "81.2.69.142", http.StatusForbidden},
+		{"未知地区默认放行", map[string]interface{}{"allow_countries": []interface{}{"CN"}}, "8.8.8.8", http.StatusOK},
+		{"拒绝未知地区", map[string]interface{}{"allow_unknown": false}, "8.8.8.8", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newPlugin(t, tt.config)
+			rec, c := execute(p, tt.ip, nil)
+			status := http.StatusOK
+			if c.IsAborted() {
+				status = rec.Code
+			}
+			if status != tt.status {
+				t.Fatalf("%s 的状态码 = %d，期望 %d", tt.ip, status, tt.status)
+			}
+		})
+	}
+}
+
+func TestCountryHeaderCannotBeForged(t *testing.T) {
+	p := newPlugin(t, map[string]interface{}{"country_header": "X-Country"})
+
+	_, c := execute(p, "8.8.8.8", http.Header{"X-Country": {"CN"}})
+	if got := c.Request.Header.Get("X-Country"); got != "" {
+		t.Fatalf("未识别地区时 X-Country = %q，期望删除客户端伪造的值", got)
+	}
+	_, c = execute(p, "216.160.83.56", http.Header{"X-Country": {"CN"}})
+	if got := c.Request.Header.Get("X-Country"); got != "US" {
+		t.Fatalf("X-Country = %q，期望 US", got)
+	}
+}
+
+func TestLookupCache(t *testing.T) {
+	p := newPlugin(t, map[string]interface{}{"cache_size": 2})
+	st := p.state
+
+	st.lookup("81.2.69.142")
+	st.lookup("8.8.8.8")
+	// 未识别的结果同样缓存
+	if country, ok := st.cache.get("8.8.8.8"); !ok || country != "" {
+		t.Fatalf("未识别地区的缓存 = %q, %v", country, ok)
+	}
+	if country, ok := st.cache.get("81.2.69.142"); !ok || country != "GB" {
+		t.Fatalf("缓存的国家 = %q, %v，期望 GB", country, ok)
+	}
+	// 达到容量上限后清空
+	st.lookup("175.16.199.1")
+	if _, ok := st.cache.get("81.2.69.142"); ok {
+		t.Fatal("缓存达到上限后应清空")
+	}
+
+	// cache_size 为 0 时不缓存
+	p = newPlugin(t, map[string]interface{}{"cache_size": 0})
+	p.state.lookup("81.2.69.142")
+	if _, ok := p.state.cache.get("81.2.69.142"); ok {
+		t.Fatal("cache_size 为 0 时不应缓存")
+	}
+}
+
+func TestInitErrors(t *testing.T) {
+	invalid := filepath.Join(t.TempDir(), "invalid.mmdb")
+	os.WriteFile(invalid, []byte("not a database"), 0o600)
+
+	for name, config := range map[string]map[string]interface{}{
+		"未配置数据库":  {},
+		"国家代码错误":  {"database": invalid, "allow_countries": []interface{}{"CHN"}},
+		"缓存大小为负数": {"database": invalid, "cache_size": -1},
+		"数据库不存在":  {"database": filepath.Join(t.TempDir(), "missing.mmdb")},
+		"数据库格式错误": {"database": invalid},
+	} {
+		if err := New().Init(config); err == nil {
+			t.Fatalf("%s: 期望初始化失败", name)
+		}
+	}
+}
@@ -0,0 +1,261 @@
+package hmacauth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gateway-go/internal/errors"
+	"gateway-go/internal/plugin/core"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 默认配置
+const (
+	defaultSignatureHeader = "X-Signature"
+	defaultTimestampHeader = "X-Timestamp"
+	// 默认时间戳有效期（秒）
+	defaultTimestampValidity = 300
+	// 默认参与签名的请求体上限
+	defaultMaxBodySize = 1 << 20
+)
+
+// HMACAuthPlugin HMAC 请求签名校验插件
+// 对请求方法、路径、查询参数、指定请求头、时间戳和请求体摘要计算 HMAC-SHA256，与客户端签名比较
+type HMACAuthPlugin struct {
+	*core.BasePlugin
+	settings *settings
+	mu       sync.RWMutex
+}
+
+// settings 解析后的插件配置
+type settings struct {
+	secret            []byte
+	signatureHeader   string
+	timestampHeader   string
+	signedHeaders     []string
+	timestampValidity time.Duration
+	maxBodySize       int64
+}
+
+// New 创建 HMAC 请求签名校验插件
+func New() *HMACAuthPlugin {
+	return &HMACAuthPlugin{
+		BasePlugin: core.NewBasePlugin("hmac_auth", 16, nil),
+	}
+}
+
+// configSchema 插件配置结构
+var configSchema = core.ConfigSchema{
+	"secret":             core.FieldString,
+	"signature_header":   core.FieldString,
+	"timestamp_header":   core.FieldString,
+	"signed_headers":     core.FieldStringList,
+	"timestamp_validity": core.FieldInt,
+	"max_body_size":      core.FieldInt,
+}
+
+// ValidateConfig 校验插件配置
+func (p *HMACAuthPlugin) ValidateConfig(config map[string]interface{}) error {
+	if err := configSchema.Validate(config); err != nil {
+		return err
+	}
+	_, err := parseSettings(config)
+	return err
+}
+
+// Init 初始化插件
+func (p *HMACAuthPlugin) Init(config interface{}) error {
+	// 类型断言
+	configMap, ok := config.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("配置类型错误，期望 map[string]interface{}")
+	}
+
+	s, err := parseSettings(configMap)
+	if err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	p.settings = s
+	p.mu.Unlock()
+	return nil
+}
+
+// parseSettings 解析插件配置
+func parseSettings(config map[string]interface{}) (*settings, error) {
+	s := &settings{
+		signatureHeader:   defaultSignatureHeader,
+		timestampHeader:   defaultTimestampHeader,
+		timestampValidity: defaultTimestampValidity * time.Second,
+		maxBodySize:       defaultMaxBodySize,
+	}
+
+	secret, _ := config["secret"].(string)
+	if secret == "" {
+		return nil, fmt.Errorf("secret 不能为空")
+	}
+	s.secret = []byte(secret)
+
+	if header, ok := config["signature_header"].(string); ok && header != "" {
+		s.signatureHeader = header
+	}
+	if header, ok := config["timestamp_header"].(string); ok && header != "" {
+		s.timestampHeader = header
+	}
+	if headers, ok := config["signed_headers"].([]interface{}); ok {
+		for _, header := range headers {
+			if name, ok := header.(string); ok && name != "" {
+				s.signedHeaders = append(s.signedHeaders, name)
+			}
+		}
+	}
+	if validity, ok := core.ToInt(config["timestamp_validity"]); ok {
+		if validity <= 0 {
+			return nil, fmt.Errorf("timestamp_validity 必须大于 0")
+		}
+		s.timestampValidity = time.Duration(validity) * time.Second
+	}
+	if size, ok := core.ToInt(config["max_body_size"]); ok {
+		if size <= 0 {
+			return nil, fmt.Errorf("max_body_size 必须大于 0")
+		}
+		s.maxBodySize = int64(size)
+	}
+	return s, nil
+}
+
+// Execute 执行插件
+func (p *HMACAuthPlugin) Execute(ctx *gin.Context) error {
+	p.mu.RLock()
+	s := p.settings
+	p.mu.RUnlock()
+	if s == nil {
+		return nil
+	}
+
+	status, err := s.verify(ctx.Request, time.Now())
+	if err != nil {
+		errors.Abort(ctx, status, err.Error())
+		return err
+	}
+	return nil
+}
+
+// verify 校验请求签名，失败时返回响应状态码和错误
+func (s *settings) verify(req *http.Request, now time.Time) (int, error) {
+	signature := req.Header.Get(s.signatureHeader)
+	if signature == "" {
+		return http.StatusUnauthorized, fmt.Errorf("缺少签名")
+	}
+	expected, err := hex.DecodeString(signature)
+	if err != nil {
+		return http.StatusUnauthorized, fmt.Errorf("签名格式错误")
+	}
+
+	timestamp := req.Header.Get(s.timestampHeader)
+	if timestamp == "" {
+		return http.StatusUnauthorized, fmt.Errorf("缺少时间戳")
+	}
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return http.StatusUnauthorized, fmt.Errorf("时间戳格式错误")
+	}
+	if skew := now.Sub(time.Unix(seconds, 0)); skew > s.timestampValidity || skew < -s.timestampValidity {
+		return http.StatusUnauthorized, fmt.Errorf("时间戳已过期")
+	}
+
+	body, err := core.ReadBody(req, s.maxBodySize)
+	if err != nil {
+		if err == core.ErrBodyTooLarge {
+			return http.StatusRequestEntityTooLarge, err
+		}
+		return http.StatusBadRequest, fmt.Errorf("读取请求体失败")
+	}
+
+	canonical := CanonicalRequest(req, s.signedHeaders, timestamp, body)
+	if !hmac.Equal(expected, computeMAC(s.secret, canonical)) {
+		return http.StatusUnauthorized, fmt.Errorf("签名无效")
+	}
+	return 0, nil
+}
+
+// CanonicalRequest 生成参与签名的规范请求，各部分以换行分隔：
+// 请求方法、转义后的路径、按键和值排序的查询参数、小写名称的签名请求头（name:value，每行一项）、时间戳、请求体的 SHA-256 十六进制摘要
+func CanonicalRequest(req *http.Request, signedHeaders []string, timestamp string, body []byte) string {
+	var b strings.Builder
+	b.WriteString(req.Method)
+	b.WriteByte('\n')
+
+	path := req.URL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	b.WriteString(path)
+	b.WriteByte('\n')
+
+	b.WriteString(canonicalQuery(req.URL.Query()))
+	b.WriteByte('\n')
+
+	for _, name := range signedHeaders {
+		values := req.Header.Values(name)
+		for i, value := range values {
+			values[i] = strings.TrimSpace(value)
+		}
+		b.WriteString(strings.ToLower(name))
+		b.WriteByte(':')
+		b.WriteString(strings.Join(values, ","))
+		b.WriteByte('\n')
+	}
+
+	b.WriteString(timestamp)
+	b.WriteByte('\n')
+
+	sum := sha256.Sum256(body)
+	b.WriteString(hex.EncodeToString(sum[:]))
+	return b.String()
+}
+
+// canonicalQuery 按键和值排序并重新编码查询参数，与客户端的参数顺序和编码方式无关
+func canonicalQuery(query url.Values) string {
+	pairs := make([][2]string, 0, len(query))
+	for key, values := range query {
+		for _, value := range values {
+			pairs = append(pairs, [2]string{url.QueryEscape(key), url.QueryEscape(value)})
+		}
+	}
+	sort.Slice(pairs, func(i, j int) bool {
+		if pairs[i][0] != pairs[j][0] {
+			return pairs[i][0] < pairs[j][0]
+		}
+		return pairs[i][1] < pairs[j][1]
+	})
+
+	encoded := make([]string, len(pairs))
+	for i, pair := range pairs {
+		encoded[i] = pair[0] + "=" + pair[1]
+	}
+	return strings.Join(encoded, "&")
+}
+
+// Sign 计算规范请求的签名，返回十六进制编码
+func Sign(secret, canonical string) string {
+	return hex.EncodeToString(computeMAC([]byte(secret), canonical))
+}
+
+// computeMAC 计算 HMAC-SHA256
+func computeMAC(secret []byte, canonical string) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(canonical))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,157 @@
+package hmacauth
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 文档示例使用的请求
+const (
+	exampleSecret    = "key"
+	exampleTimestamp = "1700000000"
+	exampleTarget    = "/api/orders?b=2&a=1&a=0&a-b=x"
+	exampleBody      = `{"id":1}`
+)
+
+// exampleRequest 创建文档示例中的请求
+func exampleRequest(body string) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, exampleTarget, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Timestamp", exampleTimestamp)
+	return req
+}
+
+// exampleSettings 文档示例使用的配置
+func exampleSettings(t *testing.T) *settings {
+	t.Helper()
+	s, err := parseSettings(map[string]interface{}{
+		"secret":         exampleSecret,
+		"signed_headers": []interface{}{"Content-Type"},
+	})
+	if err != nil {
+		t.Fatalf("解析配置失败: %v", err)
+	}
+	return s
+}
+
+func TestCanonicalRequestKnownVector(t *testing.T) {
+	canonical := CanonicalRequest(exampleRequest(exampleBody), []string{"Content-Type"}, exampleTimestamp, []byte(exampleBody))
+	want := "POST\n/api/orders\na=0&a=1&a-b=x&b=2\ncontent-type:application/json\n1700000000\n" +
+		"037c9214eef74cc3887f3a4f085b4e17d76280dafd273b0ee160c09c4ba1cfd4"
+	if canonical != want {
+		t.Fatalf("规范请求 = %q，期望 %q", canonical, want)
+	}
+	if got := Sign(exampleSecret, canonical); got != "3d0edd06189454f95df0715b68c6f7856ae46c337889ef46f05b13aeb4020ff9" {
+		t.Fatalf("签名 = %s，与文档示例不一致", got)
+	}
+
+	// 无请求体、无查询参数时使用空字符串的摘要
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	want = "GET\n/\n\n0\ne3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+	if canonical := CanonicalRequest(req, nil, "0", nil); canonical != want {
+		t.Fatalf("空请求的规范请求 = %q，期望 %q", canonical, want)
+	}
+
+	// 查询参数的顺序和编码方式不影响规范请求
+	a := httptest.NewRequest(http.MethodGet, "/search?q=a+b&tag=x&tag=%E4%B8%AD", nil)
+	b := httptest.NewRequest(http.MethodGet, "/search?tag=%e4%b8%ad&q=a%20b&tag=x", nil)
+	if CanonicalRequest(a, nil, "0", nil) != CanonicalRequest(b, nil, "0", nil) {
+		t.Fatal("等价的查询参数生成了不同的规范请求")
+	}
+}
+
+func TestVerify(t *testing.T) {
+	s := exampleSettings(t)
+	signature := "3d0edd06189454f95df0715b68c6f7856ae46c337889ef46f05b13aeb4020ff9"
+	signedAt := time.Unix(1700000000, 0)
+
+	tests := []struct {
+		name   string
+		body   string
+		modify func(req *http.Request)
+		now    time.Time
+		status int
+	}{
+		{"签名正确", exampleBody, nil, signedAt.Add(time.Minute), 0},
+		{"请求体被篡改", `{"id":2}`, nil, signedAt, http.StatusUnauthorized},
+		{"查询参数被篡改", exampleBody, func(req *http.Request) { req.URL.RawQuery = "a=0&a=1&a-b=x&b=3" }, signedAt, http.StatusUnauthorized},
+		{"签名请求头被篡改", exampleBody, func(req *http.Request) { req.Header.Set("Content-Type", "text/plain") }, signedAt, http.StatusUnauthorized},
+		{"方法被篡改", exampleBody, func(req *http.Request) { req.Method = http.MethodPut }, signedAt, http.StatusUnauthorized},
+		{"时间戳过期", exampleBody, nil, signedAt.Add(301 * time.Second), http.StatusUnauthorized},
+		{"时间戳超前", exampleBody, nil, signedAt.Add(-301 * time.Second), http.StatusUnauthorized},
+		{"缺少签名", exampleBody, func(req *http.Request) { req.Header.Del("X-Signature") }, signedAt, http.StatusUnauthorized},
+		{"签名格式错误", exampleBody, func(req *http.Request) { req.Header.Set("X-Signature", "zz") }, signedAt, http.StatusUnauthorized},
+		{"缺少时间戳", exampleBody, func(req *http.Request) { req.Header.Del("X-Timestamp") }, signedAt, http.StatusUnauthorized},
+		{"请求体超出上限", strings.Repeat("x", defaultMaxBodySize+1), nil, signedAt, http.StatusRequestEntityTooLarge},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := exampleRequest(tt.body)
+			req.Header.Set("X-Signature", signature)
+			if tt.modify != nil {
+				tt.modify(req)
+			}
+			status, err := s.verify(req, tt.now)
+			if status != tt.status || (tt.status == 0) != (err == nil) {
+				t.Fatalf("verify() = %d, %v，期望状态码 %d", status, err, tt.status)
+			}
+		})
+	}
+}
+
+func TestExecuteRestoresBody(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	p := New()
+	config := map[string]interface{}{"secret": "s3cret", "signed_headers": []interface{}{"Content-Type"}}
+	if err := p.ValidateConfig(config); err != nil {
+		t.Fatalf("校验配置失败: %v", err)
+	}
+	if err := p.Init(config); err != nil {
+		t.Fatalf("初始化插件失败: %v", err)
+	}
+
+	// execute 按当前时间签名 signedBody，发送 body
+	execute := func(signedBody, body string) (*httptest.ResponseRecorder, *gin.Context) {
+		rec := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(rec)
+		c.Request = httptest.NewRequest(http.MethodPost, "/api/orders?a=1", strings.NewReader(body))
+		c.Request.Header.Set("Content-Type", "application/json")
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+		c.Request.Header.Set("X-Timestamp", timestamp)
+		canonical := CanonicalRequest(c.Request, []string{"Content-Type"}, timestamp, []byte(signedBody))
+		c.Request.Header.Set("X-Signature", Sign("s3cret", canonical))
+		p.Execute(c)
+		return rec, c
+	}
+
+	_, c := execute(exampleBody, exampleBody)
+	if c.IsAborted() {
+		t.Fatal("签名正确的请求被拒绝")
+	}
+	if forwarded, _ := io.ReadAll(c.Request.Body); string(forwarded) != exampleBody {
+		t.Fatalf("校验后的请求体 = %q，期望还原为 %q", forwarded, exampleBody)
+	}
+
+	if rec, c := execute(exampleBody, `{"id":1,"admin":true}`); !c.IsAborted() || rec.Code != http.StatusUnauthorized {
+		t.Fatalf("请求体被篡改时状态码 = %d，期望 401", rec.Code)
+	}
+}
+
+func TestParseSettingsErrors(t *testing.T) {
+	for name, config := range map[string]map[string]interface{}{
+		"缺少密钥":     {},
+		"有效期为 0":   {"secret": "s", "timestamp_validity": 0},
+		"请求体上限为负数": {"secret": "s", "max_body_size": -1},
+	} {
+		if _, err := parseSettings(config); err == nil {
+			t.Fatalf("%s: 期望解析失败", name)
+		}
+	}
+}
@@ -0,0 +1,160 @@
+package interface_auth
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	gwerrors "gateway-go/internal/errors"
+)
+
+// ClientConfig 调用认证服务和内省端点的 HTTP 客户端配置
+type ClientConfig struct {
+	// 单次请求超时时间（毫秒）
+	Timeout int `yaml:"timeout" json:"timeout"`
+	// 连接失败或返回 5xx 时的重试次数
+	Retries *int `yaml:"retries" json:"retries"`
+	// 首次重试间隔（毫秒），之后按指数退避增长
+	RetryInterval int `yaml:"retry_interval" json:"retry_interval"`
+	// 保持的空闲连接数
+	MaxIdleConns int `yaml:"max_idle_conns" json:"max_idle_conns"`
+	// 空闲连接的保持时间（秒）
+	IdleConnTimeout int `yaml:"idle_conn_timeout" json:"idle_conn_timeout"`
+	// HTTPS 认证服务的 CA 证书路径，为空时使用系统证书
+	CAFile string `yaml:"ca_file" json:"ca_file"`
+	// 跳过 HTTPS 证书校验，仅用于测试环境
+	InsecureSkipVerify bool `yaml:"insecure_skip_verify" json:"insecure_skip_verify"`
+}
+
+// 客户端默认配置
+const (
+	defaultClientTimeout     = 5000
+	defaultClientRetries     = 1
+	defaultRetryInterval     = 100
+	defaultMaxIdleConns      = 100
+	defaultIdleConnTimeout   = 90
+	maxClientRetryInterval   = time.Second
+	clientRetryBackoffFactor = 2.0
+)
+
+// newHTTPClient 根据客户端配置创建 HTTP 客户端，连接在请求间复用
+func newHTTPClient(cfg ClientConfig) (*http.Client, error) {
+	timeout, idleConns, idleTimeout := cfg.Timeout, cfg.MaxIdleConns, cfg.IdleConnTimeout
+	if timeout <= 0 {
+		timeout = defaultClientTimeout
+	}
+	if idleConns <= 0 {
+		idleConns = defaultMaxIdleConns
+	}
+	if idleTimeout <= 0 {
+		idleTimeout = defaultIdleConnTimeout
+	}
+
+	tlsConfig := &tls.Config{InsecureSkipVerify: cfg.InsecureSkipVerify}
+	if cfg.CAFile != "" {
+		caPEM, err := os.ReadFile(cfg.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("读取认证服务CA证书失败: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caPEM) {
+			return nil, fmt.Errorf("解析认证服务CA证书失败: %s", cfg.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	return &http.Client{
+		Timeout: time.Duration(timeout) * time.Millisecond,
+		Transport: &http.Transport{
+			Proxy:               http.ProxyFromEnvironment,
+			DialContext:         (&net.Dialer{Timeout: time.Duration(timeout) * time.Millisecond, KeepAlive: 30 * time.Second}).DialContext,
+			TLSClientConfig:     tlsConfig,
+			MaxIdleConns:        idleConns,
+			MaxIdleConnsPerHost: idleConns,
+			IdleConnTimeout:     time.Duration(idleTimeout) * time.Second,
+		},
+	}, nil
+}
+
+// retryConfig 返回调用认证服务的重试配置
+func (cfg ClientConfig) retryConfig() gwerrors.RetryConfig {
+	retries, interval := defaultClientRetries, cfg.RetryInterval
+	if cfg.Retries != nil {
+		retries = *cfg.Retries
+	}
+	if interval <= 0 {
+		interval = defaultRetryInterval
+	}
+	return gwerrors.RetryConfig{
+		MaxRetries:       retries,
+		RetryInterval:    time.Duration(interval) * time.Millisecond,
+		MaxRetryInterval: maxClientRetryInterval,
+		BackoffFactor:    clientRetryBackoffFactor,
+		EnableBackoff:    true,
+	}
+}
+
+// do 发送请求，连接失败或返回 5xx 时按退避重试
+// 最后一次尝试仍返回 5xx 时返回该响应，由调用方处理状态码
+func (p *Plugin) do(ctx context.Context, newRequest func(ctx context.Context) (*http.Request, error)) (*http.Response, error) {
+	var resp *http.Response
+	err := gwerrors.Retry(ctx, func() error {
+		if resp != nil {
+			resp.Body.Close()
+			resp = nil
+		}
+		req, err := newRequest(ctx)
+		if err != nil {
+			return err
+		}
+		resp, err = p.httpClient.Do(req)
+		if err != nil {
+			return &gwerrors.RetryableError{Err: err}
+		}
+		if resp.StatusCode >= http.StatusInternalServerError {
+			return &gwerrors.RetryableError{Err: fmt.Errorf("状态码 %d", resp.StatusCode)}
+		}
+		return nil
+	}, p.config.Client.retryConfig())
+	if resp != nil {
+		return resp, nil
+	}
+	return nil, err
+}
+
+// authBaseURL 解析 consumers.host，未指定协议时使用 http
+func authBaseURL(host string) (*url.URL, error) {
+	if host == "" {
+		return nil, fmt.Errorf("consumers.host 不能为空")
+	}
+	if !strings.Contains(host, "://") {
+		host = "http://" + host
+	}
+	base, err := url.Parse(host)
+	if err != nil {
+		return nil, fmt.Errorf("无效的 consumers.host: %v", err)
+	}
+	if base.Scheme != "http" && base.Scheme != "https" {
+		return nil, fmt.Errorf("consumers.host 仅支持 http 和 https: %s", base.Scheme)
+	}
+	if base.Host == "" {
+		return nil, fmt.Errorf("无效的 consumers.host: %s", host)
+	}
+	return base, nil
+}
+
+// buildAuthURL 拼接认证地址：host、auth_api 与令牌之间只保留一个斜杠，令牌按路径段转义
+func buildAuthURL(base *url.URL, authAPI, token string) string {
+	prefix := strings.TrimRight(base.Scheme+"://"+base.Host+base.EscapedPath(), "/")
+	if api := strings.Trim(authAPI, "/"); api != "" {
+		prefix += "/" + api
+	}
+	return prefix + "/" + url.PathEscape(token)
+}
@@ -0,0 +1,141 @@
+package interface_auth
+
+import (
+	"encoding/pem"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"gateway-go/internal/plugin/plugintest"
+)
+
+func TestBuildAuthURL(t *testing.T) {
+	tests := []struct {
+		host    string
+		authAPI string
+		token   string
+		want    string
+	}{
+		{"auth:8080", "/check", "abc", "http://auth:8080/check/abc"},
+		{"http://auth:8080/", "/check/", "abc", "http://auth:8080/check/abc"},
+		{"https://auth.example.com", "check", "abc", "https://auth.example.com/check/abc"},
+		{"https://auth.example.com/v1/", "//check//", "abc", "https://auth.example.com/v1/check/abc"},
+		{"auth:8080", "", "abc", "http://auth:8080/abc"},
+		{"auth:8080", "/check", "a/b c?d", "http://auth:8080/check/a%2Fb%20c%3Fd"},
+	}
+
+	for _, tt := range tests {
+		base, err := authBaseURL(tt.host)
+		if err != nil {
+			t.Fatalf("解析 %q 失败: %v", tt.host, err)
+		}
+		if got := buildAuthURL(base, tt.authAPI, tt.token); got != tt.want {
+			t.Fatalf("buildAuthURL(%q, %q, %q) = %q，期望 %q", tt.host, tt.authAPI, tt.token, got, tt.want)
+		}
+	}
+
+	for host, want := range map[string]string{
+		"":                 "consumers.host 不能为空",
+		"ftp://auth:21":    "仅支持 http 和 https",
+		"http://":          "无效的 consumers.host",
+		"http://auth:port": "无效的 consumers.host",
+	} {
+		if _, err := authBaseURL(host); err == nil || !strings.Contains(err.Error(), want) {
+			t.Fatalf("authBaseURL(%q) 返回 %v，期望包含 %q", host, err, want)
+		}
+	}
+}
+
+func TestAuthServiceRetriesTransientFailure(t *testing.T) {
+	// failures 为认证服务接下来返回 503 的次数
+	var calls, failures atomic.Int64
+	server := authService(t, func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		if failures.Add(-1) >= 0 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte("false"))
+	})
+
+	config := authAPIConfig(server.URL)
+	config["client"] = map[string]interface{}{"retries": 2, "retry_interval": 1}
+	p := plugintest.Init(t, New(), config)
+	failures.Store(1)
+	if rec, _ := authorize(p, "token"); rec.Code != http.StatusOK || calls.Load() != 2 {
+		t.Fatalf("认证服务短暂故障后状态码 = %d，调用 %d 次，期望重试后成功", rec.Code, calls.Load())
+	}
+
+	// 重试次数用尽后返回认证服务的状态码
+	calls.Store(0)
+	failures.Store(100)
+	if rec, _ := authorize(p, "token"); rec.Code != http.StatusServiceUnavailable || calls.Load() != 3 {
+		t.Fatalf("持续故障时状态码 = %d，调用 %d 次，期望重试 2 次后返回 503", rec.Code, calls.Load())
+	}
+
+	// 4xx 不重试
+	var rejected atomic.Int64
+	server = authService(t, func(w http.ResponseWriter, r *http.Request) {
+		rejected.Add(1)
+		w.WriteHeader(http.StatusUnauthorized)
+	})
+	config = authAPIConfig(server.URL)
+	config["client"] = map[string]interface{}{"retries": 2, "retry_interval": 1}
+	if rec, _ := authorize(plugintest.Init(t, New(), config), "token"); rec.Code != http.StatusUnauthorized || rejected.Load() != 1 {
+		t.Fatalf("认证服务返回 401 时状态码 = %d，调用 %d 次，期望不重试", rec.Code, rejected.Load())
+	}
+}
+
+func TestAuthServiceConnectionRefused(t *testing.T) {
+	server := authService(t, func(w http.ResponseWriter, r *http.Request) {})
+	host := server.URL
+	server.Close()
+
+	config := authAPIConfig(host)
+	config["client"] = map[string]interface{}{"retries": 1, "retry_interval": 1, "timeout": 500}
+	if rec, _ := authorize(plugintest.Init(t, New(), config), "token"); rec.Code != http.StatusInternalServerError {
+		t.Fatalf("认证服务不可达时状态码 = %d，期望 500", rec.Code)
+	}
+}
+
+func TestAuthServiceHTTPS(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/check/token" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte("false"))
+	}))
+	defer server.Close()
+	// 不输出证书校验失败时的握手错误日志
+	server.Config.ErrorLog = log.New(io.Discard, "", 0)
+
+	// 未信任证书时连接失败
+	config := authAPIConfig(server.URL)
+	if rec, _ := authorize(plugintest.Init(t, New(), config), "token"); rec.Code != http.StatusInternalServerError {
+		t.Fatalf("证书不受信任时状态码 = %d，期望 500", rec.Code)
+	}
+
+	// 配置 CA 证书后校验通过
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	if err := os.WriteFile(caFile, caPEM, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	config["client"] = map[string]interface{}{"retries": 0, "ca_file": caFile}
+	if rec, _ := authorize(plugintest.Init(t, New(), config), "token"); rec.Code != http.StatusOK {
+		t.Fatalf("配置 CA 证书后状态码 = %d，期望 200", rec.Code)
+	}
+
+	// CA 证书无效时初始化失败
+	config["client"] = map[string]interface{}{"ca_file": filepath.Join(t.TempDir(), "missing.pem")}
+	if err := New().Init(config); err == nil {
+		t.Fatal("CA 证书不存在时期望初始化失败")
+	}
+}
@@ -0,0 +1,130 @@
+package interface_auth
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"gateway-go/internal/plugin/core"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ForwardHeaderConfig 将认证结果中的字段转发为上游请求头
+type ForwardHeaderConfig struct {
+	// 字段路径，嵌套字段用 . 分隔，如 user.id
+	Field string `yaml:"field" json:"field"`
+	// 写入上游请求的请求头
+	Header string `yaml:"header" json:"header"`
+}
+
+// forwardHeaderSchema 转发请求头配置结构
+var forwardHeaderSchema = core.ConfigSchema{
+	"field":  core.FieldString,
+	"header": core.FieldString,
+}
+
+// validateForwardHeaders 校验转发请求头配置
+func validateForwardHeaders(raw interface{}) error {
+	if raw == nil {
+		return nil
+	}
+	items, ok := raw.([]interface{})
+	if !ok {
+		return fmt.Errorf("forward_headers 必须为数组")
+	}
+	headers := make(map[string]bool, len(items))
+	for i, item := range items {
+		entry, ok := item.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("forward_headers[%d] 必须为对象", i)
+		}
+		if err := forwardHeaderSchema.Validate(entry); err != nil {
+			return fmt.Errorf("forward_headers[%d]: %w", i, err)
+		}
+		field, _ := entry["field"].(string)
+		header, _ := entry["header"].(string)
+		if field == "" || header == "" {
+			return fmt.Errorf("forward_headers[%d] 必须配置 field 和 header", i)
+		}
+		canonical := http.CanonicalHeaderKey(header)
+		if headers[canonical] {
+			return fmt.Errorf("forward_headers 中请求头 %s 重复配置", header)
+		}
+		headers[canonical] = true
+	}
+	return nil
+}
+
+// stripForwardHeaders 删除客户端携带的转发请求头，避免伪造身份信息
+func (p *Plugin) stripForwardHeaders(ctx *gin.Context) {
+	for _, fh := range p.config.ForwardHeaders {
+		ctx.Request.Header.Del(fh.Header)
+	}
+}
+
+// forwardIdentity 按配置将认证结果中的字段写入上游请求头，字段不存在时不写入
+func (p *Plugin) forwardIdentity(ctx *gin.Context, result map[string]interface{}) {
+	for _, fh := range p.config.ForwardHeaders {
+		value, ok := lookupField(result, fh.Field)
+		if !ok {
+			continue
+		}
+		if s, ok := headerValue(value); ok {
+			ctx.Request.Header.Set(fh.Header, s)
+		}
+	}
+}
+
+// lookupField 按 . 分隔的路径读取嵌套字段
+func lookupField(data map[string]interface{}, path string) (interface{}, bool) {
+	var current interface{} = data
+	for _, key := range strings.Split(path, ".") {
+		object, ok := current.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if current, ok = object[key]; !ok {
+			return nil, false
+		}
+	}
+	return current, current != nil
+}
+
+// headerValue 将字段值转换为请求头的值，数组以逗号连接，对象按 JSON 编码
+// 包含换行等控制字符的值不转发
+func headerValue(value interface{}) (string, bool) {
+	var s string
+	switch v := value.(type) {
+	case string:
+		s = v
+	case json.Number:
+		s = v.String()
+	case float64:
+		s = strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		s = strconv.FormatBool(v)
+	case []interface{}:
+		parts := make([]string, 0, len(v))
+		for _, item := range v {
+			part, ok := headerValue(item)
+			if !ok {
+				return "", false
+			}
+			parts = append(parts, part)
+		}
+		s = strings.Join(parts, ",")
+	default:
+		encoded, err := json.Marshal(v)
+		if err != nil {
+			return "", false
+		}
+		s = string(encoded)
+	}
+	if strings.ContainsAny(s, "\r\n\x00") {
+		return "", false
+	}
+	return s, true
+}
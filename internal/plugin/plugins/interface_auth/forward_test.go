@@ -0,0 +1,96 @@
+package interface_auth
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"gateway-go/internal/plugin/plugintest"
+)
+
+// forwardConfig 返回调用 host 并转发身份信息的配置
+func forwardConfig(host string) map[string]interface{} {
+	config := authAPIConfig(host)
+	config["forward_headers"] = []interface{}{
+		map[string]interface{}{"field": "user.id", "header": "X-User-ID"},
+		map[string]interface{}{"field": "user.roles", "header": "X-User-Roles"},
+		map[string]interface{}{"field": "tenant", "header": "X-Tenant"},
+		map[string]interface{}{"field": "display_name", "header": "X-User-Name"},
+		map[string]interface{}{"field": "missing", "header": "X-Missing"},
+	}
+	return config
+}
+
+func TestForwardIdentityHeaders(t *testing.T) {
+	server := authService(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"user":{"id":12345678901234567,"roles":["admin","dev"]},"tenant":{"id":"acme"},"display_name":"a\r\nX-Injected: 1"}`))
+	})
+	p := plugintest.Init(t, New(), forwardConfig(server.URL))
+
+	rec, c := authorize(p, "token")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("认证通过时状态码 = %d，期望 200", rec.Code)
+	}
+	header := c.Request.Header
+	for name, want := range map[string]string{
+		// 大整数不丢失精度
+		"X-User-ID":    "12345678901234567",
+		"X-User-Roles": "admin,dev",
+		"X-Tenant":     `{"id":"acme"}`,
+		// 包含换行的值和不存在的字段不转发
+		"X-User-Name": "",
+		"X-Missing":   "",
+	} {
+		if got := header.Get(name); got != want {
+			t.Fatalf("%s = %q，期望 %q", name, got, want)
+		}
+	}
+}
+
+func TestForwardHeadersStripForgedValues(t *testing.T) {
+	server := authService(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"user":{"id":"alice"}}`))
+	})
+	p := plugintest.Init(t, New(), forwardConfig(server.URL))
+
+	// 客户端携带的身份请求头被删除，只保留认证结果写入的值
+	c, _ := newAuthContext("token")
+	c.Request.Header.Set("X-User-ID", "admin")
+	c.Request.Header.Set("X-User-Roles", "admin")
+	p.Execute(c)
+	if got := c.Request.Header.Get("X-User-ID"); got != "alice" {
+		t.Fatalf("X-User-ID = %q，期望认证结果 alice", got)
+	}
+	if got := c.Request.Header.Values("X-User-Roles"); len(got) != 0 {
+		t.Fatalf("X-User-Roles = %v，期望删除伪造的值", got)
+	}
+
+	// 白名单路径同样删除伪造的身份请求头
+	p = plugintest.Init(t, New(), map[string]interface{}{
+		"consumers":        map[string]interface{}{"host": server.URL},
+		"white_interfaces": []interface{}{"/api/*"},
+		"forward_headers":  []interface{}{map[string]interface{}{"field": "user.id", "header": "X-User-ID"}},
+	})
+	c, _ = newAuthContext("")
+	c.Request.Header.Set("X-User-ID", "admin")
+	p.Execute(c)
+	if got := c.Request.Header.Get("X-User-ID"); got != "" {
+		t.Fatalf("白名单请求的 X-User-ID = %q，期望删除", got)
+	}
+}
+
+func TestValidateForwardHeaders(t *testing.T) {
+	for want, headers := range map[string][]interface{}{
+		"forward_headers[0] 必须为对象":               {"X-User-ID"},
+		"forward_headers[0] 必须配置 field 和 header": {map[string]interface{}{"field": "sub"}},
+		"forward_headers 中请求头 x-user-id 重复配置": {
+			map[string]interface{}{"field": "sub", "header": "X-User-ID"},
+			map[string]interface{}{"field": "id", "header": "x-user-id"},
+		},
+	} {
+		err := New().ValidateConfig(map[string]interface{}{"forward_headers": headers})
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Fatalf("校验配置返回 %v，期望包含 %q", err, want)
+		}
+	}
+}
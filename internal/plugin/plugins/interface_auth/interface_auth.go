@@ -1,20 +1,25 @@
 package interface_auth
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
+	"net/url"
 	"regexp"
 	"strings"
 	"sync"
 	"time"
 
+	gwerrors "gateway-go/internal/errors"
+	"gateway-go/internal/plugin"
 	"gateway-go/internal/plugin/core"
 
 	"github.com/gin-gonic/gin"
 )
 
-// ErrorResponse 定义错误响应结构
+// ErrorResponse 定义错误响应的状态码和错误信息，通过 errors.Abort 以统一格式写出
 type ErrorResponse struct {
 	Code    int    `json:"code"`
 	Message string `json:"message"`
@@ -44,21 +49,53 @@ var (
 	}
 )
 
+// maxAuthResponse 认证服务响应体的最大读取长度
+const maxAuthResponse = 4 << 10
+
 // ConsumersConfig 认证服务配置
 type ConsumersConfig struct {
 	Host    string `yaml:"host" json:"host"`
 	AuthAPI string `yaml:"auth_api" json:"auth_api"`
 }
 
+// IntrospectionConfig RFC 7662 令牌内省配置
+type IntrospectionConfig struct {
+	// 内省端点地址
+	Endpoint string `yaml:"endpoint" json:"endpoint"`
+	// 调用内省端点的客户端凭证，使用 HTTP Basic 认证
+	ClientID     string `yaml:"client_id" json:"client_id"`
+	ClientSecret string `yaml:"client_secret" json:"client_secret"`
+	// 内省结果缓存时间（秒），不超过令牌的 exp，0 表示不缓存
+	CacheTTL *int `yaml:"cache_ttl" json:"cache_ttl"`
+}
+
+// 认证模式
+const (
+	// ModeAuthAPI 调用认证服务接口，响应体为 true/false 或 JSON 对象
+	ModeAuthAPI = "auth_api"
+	// ModeIntrospection RFC 7662 令牌内省
+	ModeIntrospection = "introspection"
+)
+
+// defaultIntrospectionCacheTTL 内省结果的默认缓存时间（秒）
+const defaultIntrospectionCacheTTL = 30
+
 // Config 插件配置结构体
 type Config struct {
-	WhiteInterfaces []string        `yaml:"white_interfaces" json:"white_interfaces"`
-	Consumers       ConsumersConfig `yaml:"consumers" json:"consumers"`
+	// 认证模式：auth_api（默认）或 introspection
+	Mode            string              `yaml:"mode" json:"mode"`
+	WhiteInterfaces []string            `yaml:"white_interfaces" json:"white_interfaces"`
+	Consumers       ConsumersConfig     `yaml:"consumers" json:"consumers"`
+	Introspection   IntrospectionConfig `yaml:"introspection" json:"introspection"`
+	Client          ClientConfig        `yaml:"client" json:"client"`
+	// 认证成功后转发给上游的身份信息请求头
+	ForwardHeaders []ForwardHeaderConfig `yaml:"forward_headers" json:"forward_headers"`
 }
 
 // DefaultConfig 返回默认配置
 func DefaultConfig() *Config {
 	return &Config{
+		Mode:            ModeAuthAPI,
 		WhiteInterfaces: []string{},
 		Consumers:       ConsumersConfig{},
 	}
@@ -71,7 +108,11 @@ type Plugin struct {
 	whiteListRegex []*regexp.Regexp
 	whiteListExact map[string]bool
 	httpClient     *http.Client
-	mu             sync.RWMutex
+	// 解析后的认证服务地址（auth_api 模式）
+	authBase *url.URL
+	// 内省结果缓存，按令牌哈希存储
+	introspectionCache *plugin.PluginCache
+	mu                 sync.RWMutex
 }
 
 // New 创建插件实例
@@ -81,11 +122,83 @@ func New() *Plugin {
 		config:         DefaultConfig(),
 		whiteListExact: make(map[string]bool),
 		httpClient: &http.Client{
-			Timeout: 5 * time.Second,
+			Timeout: defaultClientTimeout * time.Millisecond,
 		},
 	}
 }
 
+// configSchema 插件配置结构
+var configSchema = core.ConfigSchema{
+	"mode":             core.FieldString,
+	"white_interfaces": core.FieldStringList,
+	"consumers":        core.FieldObject,
+	"introspection":    core.FieldObject,
+	"client":           core.FieldObject,
+	"forward_headers":  core.FieldList,
+}
+
+// clientSchema HTTP 客户端配置结构
+var clientSchema = core.ConfigSchema{
+	"timeout":              core.FieldInt,
+	"retries":              core.FieldInt,
+	"retry_interval":       core.FieldInt,
+	"max_idle_conns":       core.FieldInt,
+	"idle_conn_timeout":    core.FieldInt,
+	"ca_file":              core.FieldString,
+	"insecure_skip_verify": core.FieldBool,
+}
+
+// introspectionSchema 令牌内省配置结构
+var introspectionSchema = core.ConfigSchema{
+	"endpoint":      core.FieldString,
+	"client_id":     core.FieldString,
+	"client_secret": core.FieldString,
+	"cache_ttl":     core.FieldInt,
+}
+
+// consumersSchema 认证服务配置结构
+var consumersSchema = core.ConfigSchema{
+	"host":     core.FieldString,
+	"auth_api": core.FieldString,
+}
+
+// ValidateConfig 校验插件配置
+func (p *Plugin) ValidateConfig(config map[string]interface{}) error {
+	if err := configSchema.Validate(config); err != nil {
+		return err
+	}
+	if consumers, ok := config["consumers"].(map[string]interface{}); ok {
+		if err := consumersSchema.Validate(consumers); err != nil {
+			return fmt.Errorf("consumers: %w", err)
+		}
+	}
+
+	if client, ok := config["client"].(map[string]interface{}); ok {
+		if err := clientSchema.Validate(client); err != nil {
+			return fmt.Errorf("client: %w", err)
+		}
+	}
+
+	if err := validateForwardHeaders(config["forward_headers"]); err != nil {
+		return err
+	}
+
+	switch mode, _ := config["mode"].(string); mode {
+	case "", ModeAuthAPI:
+	case ModeIntrospection:
+		introspection, _ := config["introspection"].(map[string]interface{})
+		if err := introspectionSchema.Validate(introspection); err != nil {
+			return fmt.Errorf("introspection: %w", err)
+		}
+		if endpoint, _ := introspection["endpoint"].(string); endpoint == "" {
+			return fmt.Errorf("introspection 模式必须配置 introspection.endpoint")
+		}
+	default:
+		return fmt.Errorf("不支持的认证模式: %s", mode)
+	}
+	return nil
+}
+
 // Init 初始化插件
 func (p *Plugin) Init(config interface{}) error {
 	configMap, ok := config.(map[string]interface{})
@@ -96,19 +209,49 @@ func (p *Plugin) Init(config interface{}) error {
 	if err != nil {
 		return fmt.Errorf("配置序列化失败: %v", err)
 	}
-	if err := json.Unmarshal(configBytes, p.config); err != nil {
+	cfg := DefaultConfig()
+	if err := json.Unmarshal(configBytes, cfg); err != nil {
 		return fmt.Errorf("配置解析失败: %v", err)
 	}
+	if cfg.Mode == "" {
+		cfg.Mode = ModeAuthAPI
+	}
+	client, err := newHTTPClient(cfg.Client)
+	if err != nil {
+		return err
+	}
+	var authBase *url.URL
+	if cfg.Mode == ModeAuthAPI {
+		if authBase, err = authBaseURL(cfg.Consumers.Host); err != nil {
+			return err
+		}
+	}
+
+	p.config = cfg
+	p.authBase = authBase
+	p.httpClient.CloseIdleConnections()
+	p.httpClient = client
+	if cfg.Mode == ModeIntrospection && p.introspectionCache == nil {
+		p.introspectionCache = plugin.NewPluginCache(defaultIntrospectionCacheTTL * time.Second)
+	}
 	if err := p.compileWhiteList(); err != nil {
 		return fmt.Errorf("白名单正则编译失败: %v", err)
 	}
 	return nil
 }
 
+// Cacheable 认证结果不参与插件结果缓存，令牌失效后立即拒绝请求
+func (p *Plugin) Cacheable() bool {
+	return false
+}
+
 // Execute 执行插件
 func (p *Plugin) Execute(ctx *gin.Context) error {
 	path := ctx.Request.URL.Path
 
+	// 身份信息请求头只能由认证结果写入
+	p.stripForwardHeaders(ctx)
+
 	// 检查是否在白名单中
 	if p.isWhiteListed(path) {
 		ctx.Set("plugin_result_interface_auth", "whitelist")
@@ -118,17 +261,20 @@ func (p *Plugin) Execute(ctx *gin.Context) error {
 	// 获取Authorization头
 	token := p.getTokenFromAuthorizationHeader(ctx)
 	if token == "" {
-		ctx.JSON(ErrTokenMissingOrInvalid.Code, ErrTokenMissingOrInvalid)
-		ctx.Abort()
+		gwerrors.Abort(ctx, ErrTokenMissingOrInvalid.Code, ErrTokenMissingOrInvalid.Message)
 		return fmt.Errorf("token缺失或无效")
 	}
 
 	// 调用外部认证服务
-	if err := p.callAuthService(ctx, token); err != nil {
+	if p.config.Mode == ModeIntrospection {
+		if err := p.introspect(ctx, token); err != nil {
+			return err
+		}
+	} else if err := p.callAuthService(ctx, token); err != nil {
 		return err
 	}
 
-	// 认证成功，写入缓存结果
+	// 认证成功，记录认证结果
 	ctx.Set("plugin_result_interface_auth", "success")
 	return nil
 }
@@ -194,73 +340,85 @@ func (p *Plugin) getTokenFromAuthorizationHeader(ctx *gin.Context) string {
 // callAuthService 调用外部认证服务
 func (p *Plugin) callAuthService(ctx *gin.Context, token string) error {
 	// 构建认证URL
-	authURL := fmt.Sprintf("http://%s%s/%s", p.config.Consumers.Host, p.config.Consumers.AuthAPI, token)
-
-	// 创建请求
-	req, err := http.NewRequest("GET", authURL, nil)
-	if err != nil {
-		ctx.JSON(ErrAuthServiceCallFailed.Code, ErrAuthServiceCallFailed)
-		ctx.Abort()
-		return fmt.Errorf("创建认证请求失败: %v", err)
-	}
+	authURL := buildAuthURL(p.authBase, p.config.Consumers.AuthAPI, token)
 
-	// 设置请求头
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("X-Timeout", "1000")
-
-	// 发送请求
-	resp, err := p.httpClient.Do(req)
+	// 发送请求，认证服务短暂不可用时重试
+	resp, err := p.do(ctx.Request.Context(), func(reqCtx context.Context) (*http.Request, error) {
+		req, err := http.NewRequestWithContext(reqCtx, http.MethodGet, authURL, nil)
+		if err != nil {
+			return nil, fmt.Errorf("创建认证请求失败: %v", err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Timeout", "1000")
+		return req, nil
+	})
 	if err != nil {
-		ctx.JSON(ErrAuthServiceCallFailed.Code, ErrAuthServiceCallFailed)
-		ctx.Abort()
+		gwerrors.Abort(ctx, ErrAuthServiceCallFailed.Code, ErrAuthServiceCallFailed.Message)
 		return fmt.Errorf("调用认证服务失败: %v", err)
 	}
 	defer resp.Body.Close()
 
 	// 检查响应状态码
 	if resp.StatusCode != http.StatusOK {
-		errResp := ErrorResponse{
-			Code:    resp.StatusCode,
-			Message: "Unauthorized: Invalid response",
-		}
-		ctx.JSON(resp.StatusCode, errResp)
-		ctx.Abort()
+		gwerrors.Abort(ctx, resp.StatusCode, "Unauthorized: Invalid response")
 		return fmt.Errorf("认证服务返回错误状态码: %d", resp.StatusCode)
 	}
 
-	// 读取响应体
-	var body []byte
-	if resp.Body != nil {
-		body = make([]byte, 1024)
-		n, _ := resp.Body.Read(body)
-		body = body[:n]
+	// 读取完整响应体，超出上限的部分丢弃
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxAuthResponse))
+	if err != nil {
+		gwerrors.Abort(ctx, ErrAuthServiceCallFailed.Code, ErrAuthServiceCallFailed.Message)
+		return fmt.Errorf("读取认证服务响应失败: %v", err)
 	}
 
 	if len(body) == 0 {
-		ctx.JSON(ErrNoResponseBody.Code, ErrNoResponseBody)
-		ctx.Abort()
+		gwerrors.Abort(ctx, ErrNoResponseBody.Code, ErrNoResponseBody.Message)
 		return fmt.Errorf("认证服务返回空响应体")
 	}
 
 	// 解析响应
 	responseBody := strings.TrimSpace(string(body))
+	if strings.HasPrefix(responseBody, "{") {
+		return p.handleStructuredResponse(ctx, responseBody)
+	}
 	switch responseBody {
 	case "true":
 		// 认证失败
-		ctx.JSON(ErrForbiddenAccessDenied.Code, ErrForbiddenAccessDenied)
-		ctx.Abort()
+		gwerrors.Abort(ctx, ErrForbiddenAccessDenied.Code, ErrForbiddenAccessDenied.Message)
 		return fmt.Errorf("认证失败")
 	case "false":
 		// 认证成功，继续处理
 		return nil
 	default:
 		// 未知响应
-		ctx.JSON(ErrUnknownResponseType.Code, ErrUnknownResponseType)
-		ctx.Abort()
+		gwerrors.Abort(ctx, ErrUnknownResponseType.Code, ErrUnknownResponseType.Message)
 		return fmt.Errorf("认证服务返回未知响应: %s", responseBody)
 	}
 }
 
+// handleStructuredResponse 处理 JSON 对象格式的认证响应
+// unauthorized 为 true 时认证失败，否则认证成功并按配置转发身份信息
+func (p *Plugin) handleStructuredResponse(ctx *gin.Context, responseBody string) error {
+	decoder := json.NewDecoder(strings.NewReader(responseBody))
+	decoder.UseNumber()
+	var result map[string]interface{}
+	if err := decoder.Decode(&result); err != nil {
+		gwerrors.Abort(ctx, ErrUnknownResponseType.Code, ErrUnknownResponseType.Message)
+		return fmt.Errorf("解析认证服务响应失败: %v", err)
+	}
+
+	if unauthorized, _ := result["unauthorized"].(bool); unauthorized {
+		gwerrors.Abort(ctx, ErrForbiddenAccessDenied.Code, ErrForbiddenAccessDenied.Message)
+		return fmt.Errorf("认证失败")
+	}
+
+	// 供后续插件使用的认证结果
+	ctx.Set("auth_response", result)
+	core.SetIdentity(ctx, result)
+	p.forwardIdentity(ctx, result)
+	return nil
+}
+
 // Stop 停止插件
 func (p *Plugin) Stop() error {
 	// 清理资源
@@ -270,5 +428,11 @@ func (p *Plugin) Stop() error {
 	p.whiteListRegex = nil
 	p.whiteListExact = make(map[string]bool)
 
+	// 停止内省缓存的清理协程，重新初始化时再创建
+	if p.introspectionCache != nil {
+		p.introspectionCache.Close()
+		p.introspectionCache = nil
+	}
+
 	return nil
 }
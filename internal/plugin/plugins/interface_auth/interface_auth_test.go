@@ -0,0 +1,124 @@
+package interface_auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"gateway-go/internal/plugin/plugintest"
+
+	"github.com/gin-gonic/gin"
+)
+
+// newAuthContext 创建携带令牌请求 /api/orders 的上下文，token 为空时不携带 Authorization
+func newAuthContext(token string) (*gin.Context, *httptest.ResponseRecorder) {
+	gin.SetMode(gin.TestMode)
+	rec := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(rec)
+	c.Request = httptest.NewRequest(http.MethodGet, "/api/orders", nil)
+	if token != "" {
+		c.Request.Header.Set("Authorization", "Bearer "+token)
+	}
+	return c, rec
+}
+
+// authorize 携带令牌执行插件，返回响应记录和请求上下文，上下文中的请求即转发给上游的请求
+func authorize(p *Plugin, token string) (*httptest.ResponseRecorder, *gin.Context) {
+	c, rec := newAuthContext(token)
+	p.Execute(c)
+	if !c.IsAborted() {
+		c.Status(http.StatusOK)
+	}
+	return rec, c
+}
+
+func TestValidateConfigModes(t *testing.T) {
+	tests := []struct {
+		name   string
+		config map[string]interface{}
+		want   string
+	}{
+		{"默认模式", map[string]interface{}{"consumers": map[string]interface{}{"host": "auth:8080"}}, ""},
+		{"内省模式", map[string]interface{}{"mode": "introspection", "introspection": map[string]interface{}{"endpoint": "https://idp/introspect"}}, ""},
+		{"内省模式缺少端点", map[string]interface{}{"mode": "introspection"}, "introspection 模式必须配置 introspection.endpoint"},
+		{"未知模式", map[string]interface{}{"mode": "jwt"}, "不支持的认证模式: jwt"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := New().ValidateConfig(tt.config)
+			if tt.want == "" && err != nil {
+				t.Fatalf("校验配置失败: %v", err)
+			}
+			if tt.want != "" && (err == nil || !strings.Contains(err.Error(), tt.want)) {
+				t.Fatalf("校验配置返回 %v，期望包含 %q", err, tt.want)
+			}
+		})
+	}
+}
+
+// authService 模拟认证服务，handler 处理 /check/<令牌> 请求
+func authService(t *testing.T, handler http.HandlerFunc) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	return server
+}
+
+// authAPIConfig 返回调用 host 上 /check 接口的配置，不重试
+func authAPIConfig(host string) map[string]interface{} {
+	return map[string]interface{}{
+		"consumers": map[string]interface{}{"host": host, "auth_api": "/check"},
+		"client":    map[string]interface{}{"retries": 0},
+	}
+}
+
+// writeChunks 分多次写出响应体，每次写出后立即发送
+func writeChunks(w http.ResponseWriter, chunks ...string) {
+	for _, chunk := range chunks {
+		w.Write([]byte(chunk))
+		w.(http.Flusher).Flush()
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestAuthServiceResponseReadFully(t *testing.T) {
+	tests := []struct {
+		name   string
+		chunks []string
+		status int
+	}{
+		// 认证服务返回 false 表示认证通过
+		{"分块返回 false", []string{"fa", "l", "se"}, http.StatusOK},
+		{"分块返回 true", []string{"t", "rue"}, http.StatusForbidden},
+		{"前后空白", []string{"\n  false \r\n"}, http.StatusOK},
+		{"超过 1024 字节的空白填充", []string{strings.Repeat(" ", 1500), "true", strings.Repeat("\n", 1500)}, http.StatusForbidden},
+		{"分块返回较大的 JSON", []string{`{"user_id":"u1","padding":"` + strings.Repeat("x", 2000), `","unauthorized":false}`}, http.StatusOK},
+		{"未知响应", []string{"tr", "ue!"}, http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := authService(t, func(w http.ResponseWriter, r *http.Request) {
+				writeChunks(w, tt.chunks...)
+			})
+			p := plugintest.Init(t, New(), authAPIConfig(server.URL))
+			if rec, _ := authorize(p, "token"); rec.Code != tt.status {
+				t.Fatalf("状态码 = %d，期望 %d", rec.Code, tt.status)
+			}
+		})
+	}
+}
+
+func TestAuthServiceResponseSizeLimit(t *testing.T) {
+	// 超出读取上限的部分被丢弃，截断后的响应视为未知响应
+	server := authService(t, func(w http.ResponseWriter, r *http.Request) {
+		writeChunks(w, strings.Repeat(" ", maxAuthResponse), "false")
+	})
+	p := plugintest.Init(t, New(), authAPIConfig(server.URL))
+	if rec, _ := authorize(p, "token"); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("超出上限的响应状态码 = %d，期望 401", rec.Code)
+	}
+}
@@ -0,0 +1,135 @@
+package interface_auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	gwerrors "gateway-go/internal/errors"
+	"gateway-go/internal/plugin"
+	"gateway-go/internal/plugin/core"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxIntrospectionResponse 内省响应体的最大读取长度
+const maxIntrospectionResponse = 64 << 10
+
+// introspect 通过 RFC 7662 令牌内省校验令牌，结果按令牌哈希缓存
+func (p *Plugin) introspect(ctx *gin.Context, token string) error {
+	cache := p.resultCache()
+	cacheKey := introspectionCacheKey(token)
+	result, cached := cachedIntrospection(cache, cacheKey)
+	if !cached {
+		var err error
+		result, err = p.callIntrospection(ctx.Request.Context(), token)
+		if err != nil {
+			gwerrors.Abort(ctx, ErrAuthServiceCallFailed.Code, ErrAuthServiceCallFailed.Message)
+			return err
+		}
+		if ttl := p.introspectionTTL(result); ttl > 0 && cache != nil {
+			cache.Set(cacheKey, result, ttl)
+		}
+	}
+
+	if active, _ := result["active"].(bool); !active {
+		gwerrors.Abort(ctx, ErrTokenMissingOrInvalid.Code, ErrTokenMissingOrInvalid.Message)
+		return fmt.Errorf("令牌未激活")
+	}
+
+	// 供后续插件和上游使用的令牌信息
+	ctx.Set("token_introspection", result)
+	core.SetIdentity(ctx, result)
+	p.forwardIdentity(ctx, result)
+	return nil
+}
+
+// callIntrospection 调用内省端点，使用客户端凭证进行 HTTP Basic 认证
+func (p *Plugin) callIntrospection(ctx context.Context, token string) (map[string]interface{}, error) {
+	cfg := p.config.Introspection
+	form := url.Values{
+		"token":           {token},
+		"token_type_hint": {"access_token"},
+	}.Encode()
+
+	resp, err := p.do(ctx, func(reqCtx context.Context) (*http.Request, error) {
+		req, err := http.NewRequestWithContext(reqCtx, http.MethodPost, cfg.Endpoint, strings.NewReader(form))
+		if err != nil {
+			return nil, fmt.Errorf("创建内省请求失败: %v", err)
+		}
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.Header.Set("Accept", "application/json")
+		if cfg.ClientID != "" {
+			req.SetBasicAuth(url.QueryEscape(cfg.ClientID), url.QueryEscape(cfg.ClientSecret))
+		}
+		return req, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("调用内省端点失败: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("内省端点返回错误状态码: %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxIntrospectionResponse))
+	if err != nil {
+		return nil, fmt.Errorf("读取内省响应失败: %v", err)
+	}
+	var result map[string]interface{}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("解析内省响应失败: %v", err)
+	}
+	if _, ok := result["active"].(bool); !ok {
+		return nil, fmt.Errorf("内省响应缺少 active 字段")
+	}
+	return result, nil
+}
+
+// resultCache 返回内省结果缓存，插件停止后为 nil
+func (p *Plugin) resultCache() *plugin.PluginCache {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.introspectionCache
+}
+
+// cachedIntrospection 读取缓存的内省结果
+func cachedIntrospection(cache *plugin.PluginCache, cacheKey string) (map[string]interface{}, bool) {
+	if cache == nil {
+		return nil, false
+	}
+	data, ok := cache.Get(cacheKey)
+	if !ok {
+		return nil, false
+	}
+	result, ok := data.(map[string]interface{})
+	return result, ok
+}
+
+// introspectionTTL 计算内省结果的缓存时间，有效令牌不超过其过期时间
+func (p *Plugin) introspectionTTL(result map[string]interface{}) time.Duration {
+	ttl := time.Duration(defaultIntrospectionCacheTTL) * time.Second
+	if p.config.Introspection.CacheTTL != nil {
+		ttl = time.Duration(*p.config.Introspection.CacheTTL) * time.Second
+	}
+	if exp, ok := result["exp"].(float64); ok {
+		if remaining := time.Until(time.Unix(int64(exp), 0)); remaining < ttl {
+			ttl = remaining
+		}
+	}
+	return ttl
+}
+
+// introspectionCacheKey 生成内省结果的缓存键，缓存中不保存令牌原文
+func introspectionCacheKey(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return "interface_auth:introspection:" + hex.EncodeToString(hash[:])
+}
@@ -0,0 +1,169 @@
+package interface_auth
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"gateway-go/internal/plugin/plugintest"
+)
+
+// fakeIntrospection 模拟 RFC 7662 内省端点，tokens 为令牌到内省结果的映射，未知令牌返回 active=false
+func fakeIntrospection(t *testing.T, tokens map[string]map[string]interface{}) (*httptest.Server, *atomic.Int64) {
+	t.Helper()
+	var calls atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		if id, secret, ok := r.BasicAuth(); !ok || id != "gateway" || secret != "s3cret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.Method != http.MethodPost || r.FormValue("token_type_hint") != "access_token" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		result, ok := tokens[r.FormValue("token")]
+		if !ok {
+			result = map[string]interface{}{"active": false}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(result)
+	}))
+	t.Cleanup(server.Close)
+	return server, &calls
+}
+
+// introspectionConfig 返回使用 endpoint 的内省模式配置
+func introspectionConfig(endpoint string, cacheTTL int) map[string]interface{} {
+	return map[string]interface{}{
+		"mode": "introspection",
+		"introspection": map[string]interface{}{
+			"endpoint":      endpoint,
+			"client_id":     "gateway",
+			"client_secret": "s3cret",
+			"cache_ttl":     cacheTTL,
+		},
+		"forward_headers": []interface{}{
+			map[string]interface{}{"field": "sub", "header": "X-User-ID"},
+		},
+	}
+}
+
+func TestIntrospectionActiveToken(t *testing.T) {
+	server, calls := fakeIntrospection(t, map[string]map[string]interface{}{
+		"good": {"active": true, "sub": "alice", "scope": "orders:read"},
+	})
+	p := plugintest.Init(t, New(), introspectionConfig(server.URL, 30))
+
+	rec, c := authorize(p, "good")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("有效令牌状态码 = %d，期望 200", rec.Code)
+	}
+	result, _ := c.Get("token_introspection")
+	if claims, _ := result.(map[string]interface{}); claims["sub"] != "alice" || c.Request.Header.Get("X-User-ID") != "alice" {
+		t.Fatalf("内省结果 = %v，X-User-ID = %q，期望转发 alice", result, c.Request.Header.Get("X-User-ID"))
+	}
+
+	// 缓存期内不再调用内省端点
+	for i := 0; i < 3; i++ {
+		if rec, _ := authorize(p, "good"); rec.Code != http.StatusOK {
+			t.Fatalf("缓存命中时状态码 = %d", rec.Code)
+		}
+	}
+	if got := calls.Load(); got != 1 {
+		t.Fatalf("内省端点被调用 %d 次，期望缓存后只调用 1 次", got)
+	}
+}
+
+func TestIntrospectionInactiveToken(t *testing.T) {
+	server, calls := fakeIntrospection(t, nil)
+	p := plugintest.Init(t, New(), introspectionConfig(server.URL, 30))
+
+	for i := 0; i < 2; i++ {
+		if rec, _ := authorize(p, "revoked"); rec.Code != http.StatusUnauthorized {
+			t.Fatalf("未激活令牌状态码 = %d，期望 401", rec.Code)
+		}
+	}
+	// 未激活的结果同样缓存
+	if got := calls.Load(); got != 1 {
+		t.Fatalf("内省端点被调用 %d 次，期望 1 次", got)
+	}
+	if rec, _ := authorize(p, ""); rec.Code != http.StatusUnauthorized || calls.Load() != 1 {
+		t.Fatalf("缺少令牌时状态码 = %d，期望 401 且不调用内省端点", rec.Code)
+	}
+}
+
+func TestIntrospectionCacheTTL(t *testing.T) {
+	server, calls := fakeIntrospection(t, map[string]map[string]interface{}{
+		"good": {"active": true},
+		// 即将过期的令牌缓存时间不超过 exp
+		"expiring": {"active": true, "exp": float64(time.Now().Add(-time.Second).Unix())},
+	})
+
+	// cache_ttl 为 0 时每次都调用内省端点
+	p := plugintest.Init(t, New(), introspectionConfig(server.URL, 0))
+	authorize(p, "good")
+	authorize(p, "good")
+	if got := calls.Load(); got != 2 {
+		t.Fatalf("不缓存时内省端点被调用 %d 次，期望 2 次", got)
+	}
+
+	p = plugintest.Init(t, New(), introspectionConfig(server.URL, 30))
+	authorize(p, "expiring")
+	authorize(p, "expiring")
+	if got := calls.Load(); got != 4 {
+		t.Fatalf("已过期令牌的内省端点调用次数 = %d，期望不缓存", got-2)
+	}
+}
+
+func TestIntrospectionEndpointFailure(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+	}{
+		{"客户端凭证错误", func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusUnauthorized) }},
+		{"缺少 active", func(w http.ResponseWriter, r *http.Request) { w.Write([]byte(`{"sub":"alice"}`)) }},
+		{"响应不是 JSON", func(w http.ResponseWriter, r *http.Request) { w.Write([]byte("true")) }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(tt.handler)
+			defer server.Close()
+			p := plugintest.Init(t, New(), introspectionConfig(server.URL, 30))
+			if rec, _ := authorize(p, "good"); rec.Code != http.StatusInternalServerError {
+				t.Fatalf("内省失败时状态码 = %d，期望 500", rec.Code)
+			}
+		})
+	}
+}
+
+func TestStopReleasesIntrospectionCache(t *testing.T) {
+	server, calls := fakeIntrospection(t, map[string]map[string]interface{}{
+		"good": {"active": true},
+	})
+	config := introspectionConfig(server.URL, 30)
+	p := plugintest.Init(t, New(), config)
+	authorize(p, "good")
+
+	if err := p.Stop(); err != nil {
+		t.Fatalf("停止插件失败: %v", err)
+	}
+	if p.introspectionCache != nil {
+		t.Fatal("停止后内省缓存未释放")
+	}
+
+	// 停止后缓存的结果不再使用，重新初始化时创建新的缓存
+	if err := p.Init(config); err != nil {
+		t.Fatalf("重新初始化插件失败: %v", err)
+	}
+	if p.introspectionCache == nil {
+		t.Fatal("重新初始化后未创建内省缓存")
+	}
+	if rec, _ := authorize(p, "good"); rec.Code != http.StatusOK || calls.Load() != 2 {
+		t.Fatalf("重新初始化后状态码 = %d，内省端点调用 %d 次，期望 200 且重新调用", rec.Code, calls.Load())
+	}
+}
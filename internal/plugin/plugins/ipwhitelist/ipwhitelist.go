@@ -5,6 +5,7 @@ import (
 	"net"
 	"sync"
 
+	"gateway-go/internal/errors"
 	"gateway-go/internal/plugin/core"
 
 	"github.com/gin-gonic/gin"
@@ -25,6 +26,16 @@ func New() *IPWhitelistPlugin {
 	}
 }
 
+// configSchema 插件配置结构
+var configSchema = core.ConfigSchema{
+	"ip_whitelist": core.FieldStringList,
+}
+
+// ValidateConfig 校验插件配置
+func (p *IPWhitelistPlugin) ValidateConfig(config map[string]interface{}) error {
+	return configSchema.Validate(config)
+}
+
 // Init 初始化插件
 func (p *IPWhitelistPlugin) Init(config interface{}) error {
 	// 类型断言
@@ -54,9 +65,7 @@ func (p *IPWhitelistPlugin) Execute(ctx *gin.Context) error {
 
 	// 检查 IP 是否在白名单中
 	if !p.isIPAllowed(clientIP) {
-		ctx.JSON(403, gin.H{
-			"error": "IP 不在白名单中",
-		})
+		errors.Abort(ctx, 403, "IP 不在白名单中")
 		return fmt.Errorf("IP %s 不在白名单中", clientIP)
 	}
 
@@ -75,25 +84,28 @@ func (p *IPWhitelistPlugin) isIPAllowed(ip string) bool {
 		return true
 	}
 
-	// 检查 CIDR 匹配
+	return Contains(p.GetWhitelist(), ip)
+}
+
+// Contains 检查 IP 是否与列表中的单个 IP 或 CIDR 匹配
+func Contains(entries []string, ip string) bool {
 	clientIP := net.ParseIP(ip)
 	if clientIP == nil {
 		return false
 	}
 
-	var allowed bool
-	p.ipWhitelist.Range(func(key, value interface{}) bool {
-		if cidr, ok := key.(string); ok {
-			_, ipNet, err := net.ParseCIDR(cidr)
-			if err == nil && ipNet.Contains(clientIP) {
-				allowed = true
-				return false
-			}
+	for _, entry := range entries {
+		if entry == ip {
+			return true
 		}
-		return true
-	})
-
-	return allowed
+		if _, ipNet, err := net.ParseCIDR(entry); err == nil && ipNet.Contains(clientIP) {
+			return true
+		}
+		if parsed := net.ParseIP(entry); parsed != nil && parsed.Equal(clientIP) {
+			return true
+		}
+	}
+	return false
 }
 
 // isEmpty 检查白名单是否为空
@@ -0,0 +1,253 @@
+package jsonschema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+
+	"gateway-go/internal/errors"
+	"gateway-go/internal/plugin/core"
+
+	"github.com/gin-gonic/gin"
+	schemalib "github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+// 默认配置
+const (
+	defaultMaxBodySize = 1 << 20
+	// 错误响应中最多返回的校验错误数
+	maxErrorDetails = 20
+)
+
+// defaultMethods 默认校验请求体的请求方法
+var defaultMethods = []string{http.MethodPost, http.MethodPut, http.MethodPatch}
+
+// JSONSchemaPlugin JSON Schema 请求校验插件，请求体不符合 Schema 时返回 400 和校验错误
+type JSONSchemaPlugin struct {
+	*core.BasePlugin
+	settings *settings
+	mu       sync.RWMutex
+}
+
+// settings 解析后的插件配置
+type settings struct {
+	schema      *schemalib.Schema
+	methods     map[string]bool
+	maxBodySize int64
+}
+
+// FieldError 单个字段的校验错误
+type FieldError struct {
+	// 字段路径（JSON Pointer），根对象为 /
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// New 创建 JSON Schema 请求校验插件
+func New() *JSONSchemaPlugin {
+	return &JSONSchemaPlugin{
+		BasePlugin: core.NewBasePlugin("json_schema", 19, nil),
+	}
+}
+
+// configSchema 插件配置结构
+var configSchema = core.ConfigSchema{
+	"schema":        core.FieldString,
+	"schema_file":   core.FieldString,
+	"methods":       core.FieldStringList,
+	"max_body_size": core.FieldInt,
+}
+
+// ValidateConfig 校验插件配置，同时编译 Schema
+func (p *JSONSchemaPlugin) ValidateConfig(config map[string]interface{}) error {
+	if err := configSchema.Validate(config); err != nil {
+		return err
+	}
+	_, err := parseSettings(config)
+	return err
+}
+
+// Init 初始化插件
+func (p *JSONSchemaPlugin) Init(config interface{}) error {
+	// 类型断言
+	configMap, ok := config.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("配置类型错误，期望 map[string]interface{}")
+	}
+
+	s, err := parseSettings(configMap)
+	if err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	p.settings = s
+	p.mu.Unlock()
+	return nil
+}
+
+// parseSettings 解析插件配置并编译 Schema
+func parseSettings(config map[string]interface{}) (*settings, error) {
+	s := &settings{
+		methods:     make(map[string]bool),
+		maxBodySize: defaultMaxBodySize,
+	}
+
+	var err error
+	if s.schema, err = compileSchema(config); err != nil {
+		return nil, err
+	}
+
+	methods := defaultMethods
+	if list, ok := config["methods"].([]interface{}); ok && len(list) > 0 {
+		methods = nil
+		for _, item := range list {
+			if method, ok := item.(string); ok && method != "" {
+				methods = append(methods, method)
+			}
+		}
+	}
+	for _, method := range methods {
+		s.methods[strings.ToUpper(method)] = true
+	}
+
+	if size, ok := core.ToInt(config["max_body_size"]); ok {
+		if size <= 0 {
+			return nil, fmt.Errorf("max_body_size 必须大于 0")
+		}
+		s.maxBodySize = int64(size)
+	}
+	return s, nil
+}
+
+// compileSchema 编译内联 Schema（schema）或 Schema 文件（schema_file），两者只能配置一项
+func compileSchema(config map[string]interface{}) (*schemalib.Schema, error) {
+	inline, _ := config["schema"].(string)
+	file, _ := config["schema_file"].(string)
+	switch {
+	case inline == "" && file == "":
+		return nil, fmt.Errorf("schema 和 schema_file 必须配置一项")
+	case inline != "" && file != "":
+		return nil, fmt.Errorf("schema 和 schema_file 只能配置一项")
+	}
+
+	compiler := schemalib.NewCompiler()
+	url := file
+	if inline != "" {
+		url = "inline.json"
+		if err := compiler.AddResource(url, strings.NewReader(inline)); err != nil {
+			return nil, fmt.Errorf("解析 schema 失败: %v", err)
+		}
+	}
+	schema, err := compiler.Compile(url)
+	if err != nil {
+		return nil, fmt.Errorf("编译 JSON Schema 失败: %v", err)
+	}
+	return schema, nil
+}
+
+// Execute 执行插件
+func (p *JSONSchemaPlugin) Execute(ctx *gin.Context) error {
+	p.mu.RLock()
+	s := p.settings
+	p.mu.RUnlock()
+	if s == nil || !s.methods[ctx.Request.Method] {
+		return nil
+	}
+
+	body, err := core.ReadBody(ctx.Request, s.maxBodySize)
+	if err != nil {
+		status := http.StatusBadRequest
+		message := "读取请求体失败"
+		if err == core.ErrBodyTooLarge {
+			status, message = http.StatusRequestEntityTooLarge, err.Error()
+		}
+		errors.Abort(ctx, status, message)
+		return fmt.Errorf("%s: %v", message, err)
+	}
+
+	details, err := s.validate(body)
+	if err != nil {
+		errors.AbortWithDetails(ctx, http.StatusBadRequest, err.Error(), details)
+		return err
+	}
+	return nil
+}
+
+// validate 按 Schema 校验请求体，失败时返回各字段的校验错误
+func (s *settings) validate(body []byte) ([]FieldError, error) {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	// 保留数字原样，避免大整数和小数精度影响校验
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, fmt.Errorf("请求体不是有效的 JSON")
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return nil, fmt.Errorf("请求体不是有效的 JSON")
+	}
+
+	err := s.schema.Validate(value)
+	if err == nil {
+		return nil, nil
+	}
+	validationErr, ok := err.(*schemalib.ValidationError)
+	if !ok {
+		return nil, fmt.Errorf("请求体校验失败: %v", err)
+	}
+	return fieldErrors(validationErr), fmt.Errorf("请求体校验失败")
+}
+
+// fieldErrors 提取最底层的校验错误，最多返回 maxErrorDetails 个
+func fieldErrors(err *schemalib.ValidationError) []FieldError {
+	var details []FieldError
+	var collect func(*schemalib.ValidationError)
+	collect = func(e *schemalib.ValidationError) {
+		if len(details) >= maxErrorDetails {
+			return
+		}
+		if len(e.Causes) == 0 {
+			// 缺少必填字段的错误位于父对象上，按缺少的字段分别返回
+			if missing, ok := missingProperties(e); ok {
+				for _, name := range missing {
+					details = append(details, FieldError{Field: e.InstanceLocation + "/" + pointerEscaper.Replace(name), Message: "missing required property"})
+				}
+				return
+			}
+			field := e.InstanceLocation
+			if field == "" {
+				field = "/"
+			}
+			details = append(details, FieldError{Field: field, Message: e.Message})
+			return
+		}
+		for _, cause := range e.Causes {
+			collect(cause)
+		}
+	}
+	collect(err)
+	return details
+}
+
+// pointerEscaper 按 JSON Pointer 规则转义字段名
+var pointerEscaper = strings.NewReplacer("~", "~0", "/", "~1")
+
+// missingProperties 解析 required 校验错误中缺少的字段名，消息格式为 missing properties: 'a', 'b'
+func missingProperties(e *schemalib.ValidationError) ([]string, bool) {
+	if !strings.HasSuffix(e.KeywordLocation, "/required") {
+		return nil, false
+	}
+	list, ok := strings.CutPrefix(e.Message, "missing properties: ")
+	if !ok {
+		return nil, false
+	}
+	var names []string
+	for _, name := range strings.Split(list, ", ") {
+		names = append(names, strings.Trim(name, "'"))
+	}
+	return names, true
+}
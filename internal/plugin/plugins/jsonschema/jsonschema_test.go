@@ -0,0 +1,172 @@
+package jsonschema
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gateway-go/internal/plugin/plugintest"
+
+	"github.com/gin-gonic/gin"
+)
+
+// orderSchema 测试用的订单 Schema
+const orderSchema = `{
+	"type": "object",
+	"required": ["id", "items"],
+	"properties": {
+		"id": {"type": "integer"},
+		"note": {"type": "string"},
+		"items": {
+			"type": "array",
+			"items": {
+				"type": "object",
+				"required": ["sku"],
+				"properties": {"sku": {"type": "string"}, "count": {"type": "integer", "minimum": 1}}
+			}
+		}
+	}
+}`
+
+// errorResponse 校验失败时的错误响应
+type errorResponse struct {
+	Code    int          `json:"code"`
+	Message string       `json:"message"`
+	Details []FieldError `json:"details"`
+}
+
+// execute 执行插件，返回响应状态码（放行时为 0）、错误响应和插件处理后的请求
+func execute(t *testing.T, p *JSONSchemaPlugin, method, body string) (int, errorResponse, *http.Request) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	rec := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(rec)
+	c.Request = httptest.NewRequest(method, "/orders", strings.NewReader(body))
+	c.Request.Header.Set("Content-Type", "application/json")
+	p.Execute(c)
+
+	var resp errorResponse
+	if !c.IsAborted() {
+		return 0, resp, c.Request
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("解析错误响应 %s 失败: %v", rec.Body, err)
+	}
+	return rec.Code, resp, c.Request
+}
+
+// fields 返回校验错误中的字段路径
+func fields(details []FieldError) []string {
+	var out []string
+	for _, d := range details {
+		out = append(out, d.Field)
+	}
+	return out
+}
+
+func TestJSONSchemaValidation(t *testing.T) {
+	p := plugintest.Init(t, New(), map[string]interface{}{"schema": orderSchema})
+
+	tests := []struct {
+		name   string
+		body   string
+		status int
+		// 期望的错误字段路径
+		fields []string
+	}{
+		{"有效的请求体", `{"id":1,"items":[{"sku":"A1","count":2}],"note":"gift"}`, 0, nil},
+		{"缺少必填字段", `{"note":"gift"}`, http.StatusBadRequest, []string{"/id", "/items"}},
+		{"嵌套对象缺少必填字段", `{"id":1,"items":[{"count":1}]}`, http.StatusBadRequest, []string{"/items/0/sku"}},
+		{"字段类型错误", `{"id":"1","items":[{"sku":"A1","count":0}]}`, http.StatusBadRequest, []string{"/id", "/items/0/count"}},
+		{"根对象类型错误", `[1]`, http.StatusBadRequest, []string{"/"}},
+		{"不是有效的 JSON", `{"id":1`, http.StatusBadRequest, nil},
+		{"多个 JSON 值", `{"id":1,"items":[]} {}`, http.StatusBadRequest, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, resp, req := execute(t, p, http.MethodPost, tt.body)
+			if status != tt.status {
+				t.Fatalf("状态码 = %d，期望 %d，响应 %+v", status, tt.status, resp)
+			}
+			if status == 0 {
+				// 校验通过时请求体保持完整，供后续代理转发
+				if body, _ := io.ReadAll(req.Body); string(body) != tt.body {
+					t.Fatalf("转发的请求体 = %q，期望 %q", body, tt.body)
+				}
+				return
+			}
+			got := fields(resp.Details)
+			for _, d := range resp.Details {
+				if d.Message == "" {
+					t.Fatalf("字段 %s 缺少错误信息", d.Field)
+				}
+			}
+			if len(got) != len(tt.fields) || (len(got) > 0 && !sameFields(got, tt.fields)) {
+				t.Fatalf("错误字段 = %v，期望 %v", got, tt.fields)
+			}
+		})
+	}
+}
+
+// sameFields 判断两组字段路径是否相同，不考虑顺序
+func sameFields(got, want []string) bool {
+	set := func(list []string) map[string]bool {
+		m := make(map[string]bool)
+		for _, item := range list {
+			m[item] = true
+		}
+		return m
+	}
+	return reflect.DeepEqual(set(got), set(want))
+}
+
+func TestJSONSchemaMethodsAndBodySize(t *testing.T) {
+	p := plugintest.Init(t, New(), map[string]interface{}{"schema": orderSchema, "max_body_size": 32})
+
+	// 默认只校验 POST、PUT、PATCH
+	if status, _, _ := execute(t, p, http.MethodDelete, `{}`); status != 0 {
+		t.Fatalf("DELETE 请求状态码 = %d，期望不校验", status)
+	}
+	if status, _, _ := execute(t, p, http.MethodPatch, `{}`); status != http.StatusBadRequest {
+		t.Fatalf("PATCH 请求状态码 = %d，期望 400", status)
+	}
+	if status, _, _ := execute(t, p, http.MethodPut, `{"id":1,"items":[],"note":"`+strings.Repeat("x", 32)+`"}`); status != http.StatusRequestEntityTooLarge {
+		t.Fatalf("请求体过大时状态码 = %d，期望 413", status)
+	}
+}
+
+func TestJSONSchemaFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "order.json")
+	if err := os.WriteFile(path, []byte(orderSchema), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	p := plugintest.Init(t, New(), map[string]interface{}{"schema_file": path, "methods": []interface{}{"post"}})
+
+	if status, _, _ := execute(t, p, http.MethodPost, `{"id":1,"items":[]}`); status != 0 {
+		t.Fatalf("有效请求体状态码 = %d，期望放行", status)
+	}
+	if status, resp, _ := execute(t, p, http.MethodPost, `{"id":1}`); status != http.StatusBadRequest || !sameFields(fields(resp.Details), []string{"/items"}) {
+		t.Fatalf("缺少字段时状态码 = %d，响应 %+v", status, resp)
+	}
+}
+
+func TestParseSettingsErrors(t *testing.T) {
+	for name, config := range map[string]map[string]interface{}{
+		"未配置 Schema":     {},
+		"同时配置两种 Schema":  {"schema": orderSchema, "schema_file": "order.json"},
+		"Schema 不是 JSON": {"schema": "{"},
+		"Schema 无效":      {"schema": `{"type": 1}`},
+		"Schema 文件不存在":   {"schema_file": filepath.Join(t.TempDir(), "missing.json")},
+		"请求体上限为 0":       {"schema": orderSchema, "max_body_size": 0},
+	} {
+		if _, err := parseSettings(config); err == nil {
+			t.Fatalf("%s: 期望解析失败", name)
+		}
+	}
+}
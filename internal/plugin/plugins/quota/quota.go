@@ -0,0 +1,311 @@
+package quota
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"gateway-go/internal/errors"
+	"gateway-go/internal/logger"
+	"gateway-go/internal/plugin/core"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// 默认配置
+const (
+	defaultWindow         = "day"
+	defaultConsumerHeader = "X-API-Key"
+	defaultKeyPrefix      = "gateway:quota:"
+	defaultRedisTimeout   = 200 * time.Millisecond
+	defaultRedisPoolSize  = 8
+)
+
+// QuotaPlugin 配额插件，按消费者限制每小时/每天/每月的请求总数
+type QuotaPlugin struct {
+	*core.BasePlugin
+	settings *settings
+	// 内存存储在配置重载后保留计数
+	memory *memoryStore
+	store  Store
+	mu     sync.RWMutex
+}
+
+// settings 解析后的插件配置
+type settings struct {
+	limit          int64
+	window         string
+	location       *time.Location
+	consumerHeader string
+	identityField  string
+	store          string
+	keyPrefix      string
+	redisAddr      string
+	redisPassword  string
+	redisDB        int
+	redisTimeout   time.Duration
+	redisPoolSize  int
+}
+
+// New 创建配额插件
+func New() *QuotaPlugin {
+	return &QuotaPlugin{
+		BasePlugin: core.NewBasePlugin("quota", 12, nil),
+		memory:     newMemoryStore(),
+	}
+}
+
+// configSchema 插件配置结构
+var configSchema = core.ConfigSchema{
+	"limit":           core.FieldInt,
+	"window":          core.FieldString,
+	"timezone":        core.FieldString,
+	"consumer_header": core.FieldString,
+	"identity_field":  core.FieldString,
+	"store":           core.FieldString,
+	"key_prefix":      core.FieldString,
+	"redis":           core.FieldObject,
+}
+
+// redisSchema redis 配置结构
+var redisSchema = core.ConfigSchema{
+	"addr":      core.FieldString,
+	"password":  core.FieldString,
+	"db":        core.FieldInt,
+	"timeout":   core.FieldDuration,
+	"pool_size": core.FieldInt,
+}
+
+// ValidateConfig 校验插件配置
+func (p *QuotaPlugin) ValidateConfig(config map[string]interface{}) error {
+	if err := configSchema.Validate(config); err != nil {
+		return err
+	}
+	if redis, ok := config["redis"].(map[string]interface{}); ok {
+		if err := redisSchema.Validate(redis); err != nil {
+			return fmt.Errorf("redis: %w", err)
+		}
+	}
+	_, err := parseSettings(config)
+	return err
+}
+
+// Init 初始化插件
+func (p *QuotaPlugin) Init(config interface{}) error {
+	// 类型断言
+	configMap, ok := config.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("配置类型错误，期望 map[string]interface{}")
+	}
+
+	s, err := parseSettings(configMap)
+	if err != nil {
+		return err
+	}
+
+	var store Store = p.memory
+	if s.store == "redis" {
+		store = newRedisStore(s.redisAddr, s.redisPassword, s.redisDB, s.redisTimeout, s.redisPoolSize)
+	}
+
+	p.mu.Lock()
+	previous := p.store
+	p.settings = s
+	p.store = store
+	p.mu.Unlock()
+
+	if previous != nil && previous != Store(p.memory) {
+		previous.Close()
+	}
+	return nil
+}
+
+// parseSettings 解析插件配置
+func parseSettings(config map[string]interface{}) (*settings, error) {
+	s := &settings{
+		window:         defaultWindow,
+		location:       time.Local,
+		consumerHeader: defaultConsumerHeader,
+		store:          "memory",
+		keyPrefix:      defaultKeyPrefix,
+		redisTimeout:   defaultRedisTimeout,
+		redisPoolSize:  defaultRedisPoolSize,
+	}
+
+	limit, ok := core.ToInt(config["limit"])
+	if !ok || limit <= 0 {
+		return nil, fmt.Errorf("limit 必须大于 0")
+	}
+	s.limit = int64(limit)
+
+	if window, ok := config["window"].(string); ok && window != "" {
+		s.window = window
+	}
+	switch s.window {
+	case "hour", "day", "month":
+	default:
+		return nil, fmt.Errorf("无效的配额窗口: %s，可选 hour/day/month", s.window)
+	}
+
+	if timezone, ok := config["timezone"].(string); ok && timezone != "" {
+		location, err := time.LoadLocation(timezone)
+		if err != nil {
+			return nil, fmt.Errorf("无效的时区: %s", timezone)
+		}
+		s.location = location
+	}
+	if header, ok := config["consumer_header"].(string); ok && header != "" {
+		s.consumerHeader = header
+	}
+	s.identityField, _ = config["identity_field"].(string)
+	if prefix, ok := config["key_prefix"].(string); ok && prefix != "" {
+		s.keyPrefix = prefix
+	}
+
+	if store, ok := config["store"].(string); ok && store != "" {
+		s.store = store
+	}
+	switch s.store {
+	case "memory":
+	case "redis":
+		redis, _ := config["redis"].(map[string]interface{})
+		s.redisAddr, _ = redis["addr"].(string)
+		if s.redisAddr == "" {
+			return nil, fmt.Errorf("使用 redis 存储时必须配置 redis.addr")
+		}
+		s.redisPassword, _ = redis["password"].(string)
+		s.redisDB, _ = core.ToInt(redis["db"])
+		if timeout, ok := redis["timeout"]; ok {
+			d, err := parseDuration(timeout)
+			if err != nil || d <= 0 {
+				return nil, fmt.Errorf("无效的 redis.timeout: %v", timeout)
+			}
+			s.redisTimeout = d
+		}
+		if poolSize, ok := redis["pool_size"]; ok {
+			n, ok := core.ToInt(poolSize)
+			if !ok || n <= 0 {
+				return nil, fmt.Errorf("redis.pool_size 必须大于 0")
+			}
+			s.redisPoolSize = n
+		}
+	default:
+		return nil, fmt.Errorf("无效的配额存储: %s，可选 memory/redis", s.store)
+	}
+	return s, nil
+}
+
+// parseDuration 解析时长配置，整数表示毫秒
+func parseDuration(value interface{}) (time.Duration, error) {
+	if s, ok := value.(string); ok {
+		if d, err := time.ParseDuration(s); err == nil {
+			return d, nil
+		}
+	}
+	millis, ok := core.ToInt(value)
+	if !ok {
+		return 0, fmt.Errorf("时长格式错误: %v", value)
+	}
+	return time.Duration(millis) * time.Millisecond, nil
+}
+
+// Execute 执行插件
+func (p *QuotaPlugin) Execute(ctx *gin.Context) error {
+	p.mu.RLock()
+	s, store := p.settings, p.store
+	p.mu.RUnlock()
+
+	now := time.Now()
+	start, reset := windowBounds(now, s.window, s.location)
+	key := s.keyPrefix + s.window + ":" + strconv.FormatInt(start.Unix(), 10) + ":" + s.consumer(ctx)
+
+	count, err := store.Incr(key, reset)
+	if err != nil {
+		// 存储不可用时放行，避免配额存储故障导致整体不可用
+		if logger.Log != nil && logger.Log.Core().Enabled(zap.WarnLevel) {
+			logger.Log.Warn("配额计数失败",
+				zap.String("store", s.store),
+				zap.String("error", err.Error()),
+			)
+		}
+		return nil
+	}
+
+	remaining := s.limit - count
+	if remaining < 0 {
+		remaining = 0
+	}
+	ctx.Header("X-Quota-Limit", strconv.FormatInt(s.limit, 10))
+	ctx.Header("X-Quota-Remaining", strconv.FormatInt(remaining, 10))
+	ctx.Header("X-Quota-Reset", strconv.FormatInt(reset.Unix(), 10))
+
+	if count > s.limit {
+		retryAfter := int64(reset.Sub(now)/time.Second) + 1
+		ctx.Header("Retry-After", strconv.FormatInt(retryAfter, 10))
+		errors.AbortWithDetails(ctx, http.StatusTooManyRequests, "请求配额已用尽", gin.H{
+			"reset_at": reset.Format(time.RFC3339),
+		})
+		return nil
+	}
+	return nil
+}
+
+// consumer 获取配额消费者标识：认证插件校验通过的身份字段、消费者请求头（取哈希）、客户端IP
+// 身份字段只从认证插件写入上下文的身份信息中读取，不解析客户端提交的未校验令牌
+func (s *settings) consumer(ctx *gin.Context) string {
+	if s.identityField != "" {
+		if value := identityValue(core.Identity(ctx), s.identityField); value != "" {
+			return "id:" + value
+		}
+	}
+	if value := ctx.GetHeader(s.consumerHeader); value != "" {
+		sum := sha256.Sum256([]byte(value))
+		return "key:" + hex.EncodeToString(sum[:8])
+	}
+	return "ip:" + ctx.ClientIP()
+}
+
+// identityValue 读取身份信息中的字段，支持字符串和数字
+func identityValue(identity map[string]interface{}, field string) string {
+	switch value := identity[field].(type) {
+	case string:
+		return value
+	case json.Number:
+		return value.String()
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	}
+	return ""
+}
+
+// windowBounds 返回 now 所在配额窗口的开始时间和重置时间，按自然小时/日/月对齐
+func windowBounds(now time.Time, window string, location *time.Location) (time.Time, time.Time) {
+	now = now.In(location)
+	switch window {
+	case "hour":
+		start := time.Date(now.Year(), now.Month(), now.Day(), now.Hour(), 0, 0, 0, location)
+		return start, start.Add(time.Hour)
+	case "month":
+		start := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, location)
+		return start, start.AddDate(0, 1, 0)
+	default:
+		start := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, location)
+		return start, start.AddDate(0, 0, 1)
+	}
+}
+
+// Stop 停止插件
+func (p *QuotaPlugin) Stop() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.store != nil {
+		return p.store.Close()
+	}
+	return nil
+}
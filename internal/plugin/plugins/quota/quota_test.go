@@ -0,0 +1,147 @@
+package quota
+
+import (
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"gateway-go/internal/plugin/core"
+	"gateway-go/internal/plugin/plugintest"
+
+	"github.com/gin-gonic/gin"
+)
+
+// execute 执行插件，prepare 用于设置请求头或上下文，返回响应记录
+func execute(p *QuotaPlugin, prepare func(c *gin.Context)) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	rec := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(rec)
+	c.Request = httptest.NewRequest(http.MethodGet, "/api", nil)
+	c.Request.RemoteAddr = "192.0.2.1:1234"
+	if prepare != nil {
+		prepare(c)
+	}
+	p.Execute(c)
+	if !c.IsAborted() {
+		c.Status(http.StatusOK)
+	}
+	return rec
+}
+
+// withHeader 设置请求头
+func withHeader(name, value string) func(c *gin.Context) {
+	return func(c *gin.Context) {
+		c.Request.Header.Set(name, value)
+	}
+}
+
+func TestQuotaDecrementsAndBlocks(t *testing.T) {
+	p := plugintest.Init(t, New(), map[string]interface{}{"limit": 3})
+
+	for want := 2; want >= 0; want-- {
+		rec := execute(p, withHeader("X-API-Key", "alice"))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("配额未用尽时状态码 = %d", rec.Code)
+		}
+		if got := rec.Header().Get("X-Quota-Remaining"); got != strconv.Itoa(want) {
+			t.Fatalf("X-Quota-Remaining = %s，期望 %d", got, want)
+		}
+	}
+
+	rec := execute(p, withHeader("X-API-Key", "alice"))
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("配额用尽后状态码 = %d，期望 429", rec.Code)
+	}
+	reset, _ := strconv.ParseInt(rec.Header().Get("X-Quota-Reset"), 10, 64)
+	retryAfter, _ := strconv.Atoi(rec.Header().Get("Retry-After"))
+	if reset <= time.Now().Unix() || retryAfter <= 0 || retryAfter > 86401 {
+		t.Fatalf("重置时间 = %d，Retry-After = %d，期望在当前窗口结束时重置", reset, retryAfter)
+	}
+
+	// 其他消费者不受影响
+	if rec := execute(p, withHeader("X-API-Key", "bob")); rec.Code != http.StatusOK {
+		t.Fatalf("其他消费者状态码 = %d，期望 200", rec.Code)
+	}
+}
+
+func TestQuotaUsesVerifiedIdentity(t *testing.T) {
+	p := plugintest.Init(t, New(), map[string]interface{}{"limit": 1, "identity_field": "sub"})
+
+	verified := func(sub string) func(c *gin.Context) {
+		return func(c *gin.Context) {
+			core.SetIdentity(c, map[string]interface{}{"sub": sub})
+		}
+	}
+	if rec := execute(p, verified("user-1")); rec.Code != http.StatusOK {
+		t.Fatalf("第一次请求状态码 = %d", rec.Code)
+	}
+	if rec := execute(p, verified("user-1")); rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("同一身份配额用尽后状态码 = %d，期望 429", rec.Code)
+	}
+
+	// 客户端伪造的未校验令牌不能切换消费者，按客户端IP统计
+	forged := func(sub string) func(c *gin.Context) {
+		payload := base64.RawURLEncoding.EncodeToString([]byte(`{"sub":"` + sub + `"}`))
+		return withHeader("Authorization", "Bearer e30."+payload+".sig")
+	}
+	if rec := execute(p, forged("user-2")); rec.Code != http.StatusOK {
+		t.Fatalf("未认证请求首次状态码 = %d", rec.Code)
+	}
+	if rec := execute(p, forged("user-3")); rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("伪造不同 sub 的请求状态码 = %d，期望按客户端IP统计并返回 429", rec.Code)
+	}
+}
+
+func TestWindowBounds(t *testing.T) {
+	location := time.UTC
+	tests := []struct {
+		window    string
+		now       time.Time
+		wantStart time.Time
+		wantReset time.Time
+	}{
+		{"hour", time.Date(2024, 3, 1, 10, 59, 59, 0, location), time.Date(2024, 3, 1, 10, 0, 0, 0, location), time.Date(2024, 3, 1, 11, 0, 0, 0, location)},
+		{"day", time.Date(2024, 3, 1, 23, 59, 59, 0, location), time.Date(2024, 3, 1, 0, 0, 0, 0, location), time.Date(2024, 3, 2, 0, 0, 0, 0, location)},
+		{"day", time.Date(2024, 3, 2, 0, 0, 0, 0, location), time.Date(2024, 3, 2, 0, 0, 0, 0, location), time.Date(2024, 3, 3, 0, 0, 0, 0, location)},
+		{"month", time.Date(2024, 2, 29, 12, 0, 0, 0, location), time.Date(2024, 2, 1, 0, 0, 0, 0, location), time.Date(2024, 3, 1, 0, 0, 0, 0, location)},
+	}
+	for _, tt := range tests {
+		start, reset := windowBounds(tt.now, tt.window, location)
+		if !start.Equal(tt.wantStart) || !reset.Equal(tt.wantReset) {
+			t.Fatalf("windowBounds(%v, %s) = %v, %v，期望 %v, %v", tt.now, tt.window, start, reset, tt.wantStart, tt.wantReset)
+		}
+	}
+}
+
+func TestMemoryStoreResetsAtWindowBoundary(t *testing.T) {
+	store := newMemoryStore()
+	expireAt := time.Now().Add(50 * time.Millisecond)
+	for want := int64(1); want <= 3; want++ {
+		if got, _ := store.Incr("k", expireAt); got != want {
+			t.Fatalf("计数 = %d，期望 %d", got, want)
+		}
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	if got, _ := store.Incr("k", time.Now().Add(time.Hour)); got != 1 {
+		t.Fatalf("窗口结束后计数 = %d，期望重新从 1 开始", got)
+	}
+}
+
+func TestParseSettingsErrors(t *testing.T) {
+	for name, config := range map[string]map[string]interface{}{
+		"缺少 limit":   {},
+		"无效窗口":       {"limit": 1, "window": "week"},
+		"无效时区":       {"limit": 1, "timezone": "Mars/Base"},
+		"redis 缺少地址": {"limit": 1, "store": "redis"},
+		"无效的连接池大小":   {"limit": 1, "store": "redis", "redis": map[string]interface{}{"addr": "127.0.0.1:6379", "pool_size": 0}},
+		"不支持的存储":     {"limit": 1, "store": "etcd"},
+	} {
+		if err := New().ValidateConfig(config); err == nil {
+			t.Fatalf("%s: 期望校验失败", name)
+		}
+	}
+}
@@ -0,0 +1,213 @@
+package quota
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// incrScript 原子地递增计数，首次创建时设置过期时间
+const incrScript = `local n = redis.call('INCR', KEYS[1])
+if n == 1 then redis.call('PEXPIREAT', KEYS[1], ARGV[1]) end
+return n`
+
+// redisStore Redis 计数存储，多个网关实例共享配额
+// 使用小型连接池，并发请求各自使用独立连接，不在同一连接上排队
+type redisStore struct {
+	addr     string
+	password string
+	db       int
+	timeout  time.Duration
+
+	// 连接槽位，容量为连接池大小，限制同时打开的连接数
+	slots chan struct{}
+	// 空闲连接
+	idle   chan *redisConn
+	closed bool
+	mu     sync.Mutex
+}
+
+// redisConn 单个 Redis 连接
+type redisConn struct {
+	conn    net.Conn
+	reader  *bufio.Reader
+	timeout time.Duration
+}
+
+// newRedisStore 创建 Redis 计数存储，连接在使用时按需建立，最多 poolSize 个
+func newRedisStore(addr, password string, db int, timeout time.Duration, poolSize int) *redisStore {
+	return &redisStore{
+		addr:     addr,
+		password: password,
+		db:       db,
+		timeout:  timeout,
+		slots:    make(chan struct{}, poolSize),
+		idle:     make(chan *redisConn, poolSize),
+	}
+}
+
+// Incr 将 key 的计数加 1 并返回新值
+func (s *redisStore) Incr(key string, expireAt time.Time) (int64, error) {
+	c, err := s.get()
+	if err != nil {
+		return 0, err
+	}
+	reply, err := c.do("EVAL", incrScript, "1", key, strconv.FormatInt(expireAt.UnixMilli(), 10))
+	if err != nil {
+		// 连接状态未知，关闭后由下次请求重新建立
+		s.release(c, true)
+		return 0, err
+	}
+	s.release(c, false)
+
+	n, ok := reply.(int64)
+	if !ok {
+		return 0, fmt.Errorf("redis 返回值类型错误: %T", reply)
+	}
+	return n, nil
+}
+
+// get 获取空闲连接，没有空闲连接时在连接数未达上限的情况下新建连接
+// 连接池已满时最多等待 timeout；存储关闭后返回错误，不再建立连接
+func (s *redisStore) get() (*redisConn, error) {
+	s.mu.Lock()
+	closed := s.closed
+	s.mu.Unlock()
+	if closed {
+		return nil, fmt.Errorf("redis 存储已关闭")
+	}
+
+	select {
+	case c := <-s.idle:
+		return c, nil
+	default:
+	}
+
+	timer := time.NewTimer(s.timeout)
+	defer timer.Stop()
+	select {
+	case c := <-s.idle:
+		return c, nil
+	case s.slots <- struct{}{}:
+	case <-timer.C:
+		return nil, fmt.Errorf("等待 redis 连接超时")
+	}
+
+	c, err := s.dial()
+	if err != nil {
+		<-s.slots
+		return nil, err
+	}
+	return c, nil
+}
+
+// release 归还连接，broken 为 true 或存储已关闭时关闭连接并释放槽位
+func (s *redisStore) release(c *redisConn, broken bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !broken && !s.closed {
+		// 槽位数与空闲队列容量相同，归还不会阻塞
+		s.idle <- c
+		return
+	}
+	c.conn.Close()
+	<-s.slots
+}
+
+// dial 建立连接并完成认证和选库
+func (s *redisStore) dial() (*redisConn, error) {
+	conn, err := net.DialTimeout("tcp", s.addr, s.timeout)
+	if err != nil {
+		return nil, fmt.Errorf("连接 redis 失败: %w", err)
+	}
+	c := &redisConn{conn: conn, reader: bufio.NewReader(conn), timeout: s.timeout}
+
+	if s.password != "" {
+		if _, err := c.do("AUTH", s.password); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("redis 认证失败: %w", err)
+		}
+	}
+	if s.db != 0 {
+		if _, err := c.do("SELECT", strconv.Itoa(s.db)); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("redis 选择数据库失败: %w", err)
+		}
+	}
+	return c, nil
+}
+
+// do 发送命令并读取回复
+func (c *redisConn) do(args ...string) (interface{}, error) {
+	if err := c.conn.SetDeadline(time.Now().Add(c.timeout)); err != nil {
+		return nil, err
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "*%d\r\n", len(args))
+	for _, arg := range args {
+		fmt.Fprintf(&b, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+	if _, err := io.WriteString(c.conn, b.String()); err != nil {
+		return nil, err
+	}
+	return readReply(c.reader)
+}
+
+// readReply 读取一条 RESP 回复，支持简单字符串、错误、整数和批量字符串
+func readReply(r *bufio.Reader) (interface{}, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	line = strings.TrimSuffix(line, "\r\n")
+	if line == "" {
+		return nil, fmt.Errorf("redis 回复格式错误")
+	}
+
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+	case '-':
+		return nil, fmt.Errorf("redis 错误: %s", line[1:])
+	case ':':
+		return strconv.ParseInt(line[1:], 10, 64)
+	case '$':
+		size, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, fmt.Errorf("redis 回复格式错误: %s", line)
+		}
+		if size < 0 {
+			return nil, nil
+		}
+		buf := make([]byte, size+2)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		return string(buf[:size]), nil
+	}
+	return nil, fmt.Errorf("不支持的 redis 回复类型: %q", line[0])
+}
+
+// Close 关闭空闲连接，使用中的连接在归还时关闭
+func (s *redisStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+
+	for {
+		select {
+		case c := <-s.idle:
+			c.conn.Close()
+			<-s.slots
+		default:
+			return nil
+		}
+	}
+}
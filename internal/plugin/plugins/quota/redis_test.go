@@ -0,0 +1,220 @@
+package quota
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeRedis 最小的 RESP 服务端，EVAL 返回 key 的递增计数，记录打开的连接数
+// 设置 password 后连接需先通过 AUTH，计数按 SELECT 选择的数据库分开保存
+type fakeRedis struct {
+	addr     string
+	delay    time.Duration
+	password string
+	dials    int32
+	counts   map[string]int64
+	mu       sync.Mutex
+}
+
+// startFakeRedis 启动模拟 Redis，每条命令回复前等待 delay，password 不为空时要求认证
+func startFakeRedis(t *testing.T, delay time.Duration, password string) *fakeRedis {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	f := &fakeRedis{addr: ln.Addr().String(), delay: delay, password: password, counts: make(map[string]int64)}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			atomic.AddInt32(&f.dials, 1)
+			go f.serve(conn)
+		}
+	}()
+	return f
+}
+
+// serve 处理单个连接上的命令
+func (f *fakeRedis) serve(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	authed, db := false, "0"
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		n, _ := strconv.Atoi(strings.TrimSpace(line[1:]))
+		args := make([]string, n)
+		for i := range args {
+			header, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+			size, _ := strconv.Atoi(strings.TrimSpace(header[1:]))
+			buf := make([]byte, size+2)
+			if _, err := io.ReadFull(reader, buf); err != nil {
+				return
+			}
+			args[i] = string(buf[:size])
+		}
+
+		time.Sleep(f.delay)
+		switch {
+		case args[0] == "AUTH":
+			if args[1] != f.password {
+				fmt.Fprint(conn, "-WRONGPASS invalid password\r\n")
+				continue
+			}
+			authed = true
+			fmt.Fprint(conn, "+OK\r\n")
+		case f.password != "" && !authed:
+			fmt.Fprint(conn, "-NOAUTH Authentication required.\r\n")
+		case args[0] == "SELECT":
+			db = args[1]
+			fmt.Fprint(conn, "+OK\r\n")
+		case args[0] == "EVAL":
+			f.mu.Lock()
+			f.counts[db+"/"+args[3]]++
+			count := f.counts[db+"/"+args[3]]
+			f.mu.Unlock()
+			fmt.Fprintf(conn, ":%d\r\n", count)
+		default:
+			fmt.Fprint(conn, "+OK\r\n")
+		}
+	}
+}
+
+func TestRedisStorePoolsConnections(t *testing.T) {
+	server := startFakeRedis(t, 50*time.Millisecond, "")
+	store := newRedisStore(server.addr, "", 0, time.Second, 4)
+	defer store.Close()
+
+	// 8 个并发请求最多使用 4 个连接，且不在同一连接上串行排队
+	start := time.Now()
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := store.Incr("k", time.Now().Add(time.Hour)); err != nil {
+				t.Errorf("Incr 失败: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if dials := atomic.LoadInt32(&server.dials); dials < 2 || dials > 4 {
+		t.Fatalf("打开的连接数 = %d，期望 2~4", dials)
+	}
+	if elapsed := time.Since(start); elapsed >= 8*50*time.Millisecond {
+		t.Fatalf("8 个并发请求耗时 %v，期望并行使用多个连接", elapsed)
+	}
+
+	// 空闲连接被复用
+	dials := atomic.LoadInt32(&server.dials)
+	count, err := store.Incr("k", time.Now().Add(time.Hour))
+	if err != nil || count != 9 {
+		t.Fatalf("Incr = %d, %v，期望 9", count, err)
+	}
+	if got := atomic.LoadInt32(&server.dials); got != dials {
+		t.Fatalf("顺序请求新建了连接: %d -> %d", dials, got)
+	}
+}
+
+func TestRedisStoreWaitTimeout(t *testing.T) {
+	server := startFakeRedis(t, 200*time.Millisecond, "")
+	store := newRedisStore(server.addr, "", 0, 50*time.Millisecond, 1)
+	defer store.Close()
+
+	// 唯一的连接在等待回复时超时并被丢弃，槽位随之释放
+	if _, err := store.Incr("k", time.Now().Add(time.Hour)); err == nil {
+		t.Fatal("回复超时时期望返回错误")
+	}
+	select {
+	case store.slots <- struct{}{}:
+		<-store.slots
+	default:
+		t.Fatal("出错的连接未释放槽位")
+	}
+}
+
+func TestRedisStoreAuthAndSelect(t *testing.T) {
+	server := startFakeRedis(t, 0, "secret")
+
+	store := newRedisStore(server.addr, "secret", 2, time.Second, 2)
+	defer store.Close()
+	for i := 1; i <= 2; i++ {
+		if count, err := store.Incr("k", time.Now().Add(time.Hour)); err != nil || count != int64(i) {
+			t.Fatalf("Incr = %d, %v，期望 %d", count, err, i)
+		}
+	}
+	server.mu.Lock()
+	counts := server.counts
+	server.mu.Unlock()
+	if counts["2/k"] != 2 || counts["0/k"] != 0 {
+		t.Fatalf("计数 = %v，期望写入数据库 2", counts)
+	}
+
+	tests := []struct {
+		name     string
+		password string
+		want     string
+	}{
+		{"密码错误", "wrong", "redis 认证失败"},
+		{"未配置密码", "", "NOAUTH"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newRedisStore(server.addr, tt.password, 0, time.Second, 1)
+			defer store.Close()
+			if _, err := store.Incr("k", time.Now().Add(time.Hour)); err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("Incr err = %v，期望包含 %q", err, tt.want)
+			}
+			// 失败的连接释放槽位，不占用连接池
+			select {
+			case store.slots <- struct{}{}:
+				<-store.slots
+			default:
+				t.Fatal("失败的连接未释放槽位")
+			}
+		})
+	}
+}
+
+func TestRedisStoreClose(t *testing.T) {
+	server := startFakeRedis(t, 0, "secret")
+	store := newRedisStore(server.addr, "secret", 2, time.Second, 2)
+
+	if _, err := store.Incr("k", time.Now().Add(time.Hour)); err != nil {
+		t.Fatalf("Incr 失败: %v", err)
+	}
+	if err := store.Close(); err != nil {
+		t.Fatalf("关闭失败: %v", err)
+	}
+	if len(store.idle) != 0 {
+		t.Fatalf("关闭后仍有 %d 个空闲连接", len(store.idle))
+	}
+
+	// 关闭后不再建立新连接
+	dials := atomic.LoadInt32(&server.dials)
+	if _, err := store.Incr("k", time.Now().Add(time.Hour)); err == nil || !strings.Contains(err.Error(), "已关闭") {
+		t.Fatalf("关闭后 Incr err = %v，期望存储已关闭", err)
+	}
+	if got := atomic.LoadInt32(&server.dials); got != dials {
+		t.Fatalf("关闭后新建了连接: %d -> %d", dials, got)
+	}
+}
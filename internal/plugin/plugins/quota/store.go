@@ -0,0 +1,72 @@
+package quota
+
+import (
+	"sync"
+	"time"
+)
+
+// Store 配额计数存储
+type Store interface {
+	// Incr 将 key 的计数加 1 并返回新值，计数在 expireAt 之后失效
+	Incr(key string, expireAt time.Time) (int64, error)
+	// Close 释放存储占用的资源
+	Close() error
+}
+
+// memoryStore 内存计数存储，进程重启后计数清零
+type memoryStore struct {
+	counters map[string]*counter
+	ops      int
+	mu       sync.Mutex
+}
+
+// counter 单个配额窗口的计数
+type counter struct {
+	count    int64
+	expireAt time.Time
+}
+
+// memorySweepInterval 每执行多少次计数清理一次过期窗口
+const memorySweepInterval = 1024
+
+// newMemoryStore 创建内存计数存储
+func newMemoryStore() *memoryStore {
+	return &memoryStore{
+		counters: make(map[string]*counter),
+	}
+}
+
+// Incr 将 key 的计数加 1 并返回新值
+func (s *memoryStore) Incr(key string, expireAt time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	s.ops++
+	if s.ops >= memorySweepInterval {
+		s.ops = 0
+		s.sweep(now)
+	}
+
+	c, exists := s.counters[key]
+	if !exists || !now.Before(c.expireAt) {
+		c = &counter{expireAt: expireAt}
+		s.counters[key] = c
+	}
+	c.count++
+	return c.count, nil
+}
+
+// sweep 删除已过期的计数
+func (s *memoryStore) sweep(now time.Time) {
+	for key, c := range s.counters {
+		if !now.Before(c.expireAt) {
+			delete(s.counters, key)
+		}
+	}
+}
+
+// Close 内存存储无需释放资源
+func (s *memoryStore) Close() error {
+	return nil
+}
@@ -0,0 +1,218 @@
+package ratelimit
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"gateway-go/internal/plugin/core"
+)
+
+// 自适应限流默认配置
+const (
+	defaultLatencyThreshold = 500 * time.Millisecond
+	defaultMinRateRatio     = 0.1
+	defaultDecreaseFactor   = 0.7
+	defaultIncreaseStep     = 0.1
+	defaultLatencyWindow    = 10 * time.Second
+	defaultAdjustInterval   = time.Second
+	defaultMinSamples       = 20
+	// latencySampleLimit 每个路由保留的延迟样本上限
+	latencySampleLimit = 1024
+)
+
+// adaptiveSchema 自适应限流配置结构
+var adaptiveSchema = core.ConfigSchema{
+	"latency_threshold": core.FieldDuration,
+	"min_rate_ratio":    core.FieldNumber,
+	"decrease_factor":   core.FieldNumber,
+	"increase_step":     core.FieldNumber,
+	"window":            core.FieldDuration,
+	"interval":          core.FieldDuration,
+	"min_samples":       core.FieldInt,
+}
+
+// adaptiveSettings 自适应限流配置
+type adaptiveSettings struct {
+	// p95 延迟阈值，超过时降低速率
+	latencyThreshold time.Duration
+	// 速率下限，为 requests_per_second 的比例
+	minRateRatio float64
+	// 每次降低时速率乘以的系数
+	decreaseFactor float64
+	// 每次恢复时增加的速率比例
+	increaseStep float64
+	// 延迟统计窗口
+	window time.Duration
+	// 调整速率的最小间隔
+	interval time.Duration
+	// 调整速率所需的最少样本数
+	minSamples int
+}
+
+// parseAdaptiveSettings 解析自适应限流配置
+func parseAdaptiveSettings(config map[string]interface{}) (*adaptiveSettings, error) {
+	if err := adaptiveSchema.Validate(config); err != nil {
+		return nil, fmt.Errorf("adaptive: %w", err)
+	}
+
+	s := &adaptiveSettings{
+		latencyThreshold: defaultLatencyThreshold,
+		minRateRatio:     defaultMinRateRatio,
+		decreaseFactor:   defaultDecreaseFactor,
+		increaseStep:     defaultIncreaseStep,
+		window:           defaultLatencyWindow,
+		interval:         defaultAdjustInterval,
+		minSamples:       defaultMinSamples,
+	}
+
+	durations := map[string]*time.Duration{
+		"latency_threshold": &s.latencyThreshold,
+		"window":            &s.window,
+		"interval":          &s.interval,
+	}
+	for key, target := range durations {
+		if value, exists := config[key]; exists {
+			d, err := durationValue(value)
+			if err != nil || d <= 0 {
+				return nil, fmt.Errorf("adaptive.%s 必须为大于 0 的时长", key)
+			}
+			*target = d
+		}
+	}
+
+	ratios := []struct {
+		key      string
+		target   *float64
+		min, max float64
+	}{
+		{"min_rate_ratio", &s.minRateRatio, 0, 1},
+		{"decrease_factor", &s.decreaseFactor, 0, 1},
+		{"increase_step", &s.increaseStep, 0, 1},
+	}
+	for _, r := range ratios {
+		if value, exists := config[r.key]; exists {
+			v, _ := core.ToFloat64(value)
+			if v <= r.min || v > r.max {
+				return nil, fmt.Errorf("adaptive.%s 必须在 (0, 1] 范围内: %v", r.key, value)
+			}
+			*r.target = v
+		}
+	}
+	if s.decreaseFactor == 1 {
+		return nil, fmt.Errorf("adaptive.decrease_factor 必须小于 1")
+	}
+
+	if value, exists := config["min_samples"]; exists {
+		n, _ := core.ToInt(value)
+		if n <= 0 {
+			return nil, fmt.Errorf("adaptive.min_samples 必须大于 0")
+		}
+		s.minSamples = n
+	}
+	return s, nil
+}
+
+// durationValue 解析时长配置，整数表示毫秒
+func durationValue(value interface{}) (time.Duration, error) {
+	if s, ok := value.(string); ok {
+		if d, err := time.ParseDuration(s); err == nil {
+			return d, nil
+		}
+		millis, err := strconv.Atoi(s)
+		return time.Duration(millis) * time.Millisecond, err
+	}
+	millis, ok := core.ToInt(value)
+	if !ok {
+		return 0, fmt.Errorf("时长格式错误: %v", value)
+	}
+	return time.Duration(millis) * time.Millisecond, nil
+}
+
+// latencySample 单次请求的处理时长
+type latencySample struct {
+	at      int64
+	latency time.Duration
+}
+
+// adaptiveController 按观测到的请求延迟调整令牌生成速率（加性增、乘性减）
+// p95 延迟超过阈值时速率乘以 decrease_factor，低于阈值时每次增加 increase_step，直到恢复为配置速率
+type adaptiveController struct {
+	settings *adaptiveSettings
+	// 当前速率与配置速率的比例
+	ratio      float64
+	samples    []latencySample
+	next       int
+	lastAdjust time.Time
+	mu         sync.Mutex
+}
+
+// newAdaptiveController 创建自适应速率控制器
+func newAdaptiveController(settings *adaptiveSettings) *adaptiveController {
+	return &adaptiveController{
+		settings:   settings,
+		ratio:      1,
+		samples:    make([]latencySample, 0, latencySampleLimit),
+		lastAdjust: time.Now(),
+	}
+}
+
+// observe 记录一次请求的处理时长，样本数达到上限时覆盖最早的样本
+func (c *adaptiveController) observe(latency time.Duration, now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	sample := latencySample{at: now.UnixNano(), latency: latency}
+	if len(c.samples) < latencySampleLimit {
+		c.samples = append(c.samples, sample)
+		return
+	}
+	c.samples[c.next] = sample
+	c.next = (c.next + 1) % latencySampleLimit
+}
+
+// rateRatio 返回当前速率比例，距上次调整超过 interval 时按窗口内的 p95 延迟调整
+func (c *adaptiveController) rateRatio(now time.Time) float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if now.Sub(c.lastAdjust) < c.settings.interval {
+		return c.ratio
+	}
+	c.lastAdjust = now
+
+	// 样本不足时保持当前速率，降速清空样本后需重新积累足够的样本才恢复
+	p95, count := c.percentile(now, 0.95)
+	if count < c.settings.minSamples {
+		return c.ratio
+	}
+	if p95 > c.settings.latencyThreshold {
+		c.ratio = math.Max(c.settings.minRateRatio, c.ratio*c.settings.decreaseFactor)
+		// 降低速率后重新采样，避免窗口内降速前的样本导致连续降速
+		c.samples = c.samples[:0]
+		c.next = 0
+	} else {
+		c.ratio = math.Min(1, c.ratio+c.settings.increaseStep)
+	}
+	return c.ratio
+}
+
+// percentile 计算窗口内样本的延迟分位数，返回分位数和样本数
+func (c *adaptiveController) percentile(now time.Time, q float64) (time.Duration, int) {
+	windowStart := now.Add(-c.settings.window).UnixNano()
+	latencies := make([]time.Duration, 0, len(c.samples))
+	for _, sample := range c.samples {
+		if sample.at >= windowStart {
+			latencies = append(latencies, sample.latency)
+		}
+	}
+	if len(latencies) == 0 {
+		return 0, 0
+	}
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	index := int(math.Ceil(q*float64(len(latencies)))) - 1
+	return latencies[index], len(latencies)
+}
@@ -0,0 +1,122 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+// testAdaptiveSettings 阈值 100ms、每次调整至少 5 个样本的自适应配置
+func testAdaptiveSettings() *adaptiveSettings {
+	return &adaptiveSettings{
+		latencyThreshold: 100 * time.Millisecond,
+		minRateRatio:     0.1,
+		decreaseFactor:   0.5,
+		increaseStep:     0.25,
+		window:           10 * time.Second,
+		interval:         time.Second,
+		minSamples:       5,
+	}
+}
+
+// observeN 在 at 时刻记录 n 个相同延迟的样本
+func observeN(c *adaptiveController, n int, latency time.Duration, at time.Time) {
+	for i := 0; i < n; i++ {
+		c.observe(latency, at)
+	}
+}
+
+func TestAdaptiveSustainedHighLatency(t *testing.T) {
+	c := newAdaptiveController(testAdaptiveSettings())
+	now := c.lastAdjust
+
+	// 延迟持续超过阈值时速率只降不升，降至下限后保持
+	want := []float64{0.5, 0.25, 0.125, 0.1, 0.1}
+	for i, ratio := range want {
+		now = now.Add(time.Second)
+		observeN(c, 5, 300*time.Millisecond, now)
+		if got := c.rateRatio(now); got != ratio {
+			t.Fatalf("第 %d 次调整后速率比例 = %v，期望 %v", i+1, got, ratio)
+		}
+
+		// 降速清空样本后，样本不足的调整周期保持速率不变
+		now = now.Add(time.Second)
+		observeN(c, 4, 300*time.Millisecond, now)
+		if got := c.rateRatio(now); got != ratio {
+			t.Fatalf("样本不足时速率比例 = %v，期望保持 %v", got, ratio)
+		}
+	}
+
+	// 调整间隔内不调整
+	observeN(c, 5, 300*time.Millisecond, now)
+	if got := c.rateRatio(now.Add(500 * time.Millisecond)); got != 0.1 {
+		t.Fatalf("调整间隔内速率比例 = %v，期望 0.1", got)
+	}
+}
+
+func TestAdaptiveRecovery(t *testing.T) {
+	c := newAdaptiveController(testAdaptiveSettings())
+	now := c.lastAdjust.Add(time.Second)
+	observeN(c, 5, 300*time.Millisecond, now)
+	if got := c.rateRatio(now); got != 0.5 {
+		t.Fatalf("延迟超过阈值后速率比例 = %v，期望 0.5", got)
+	}
+
+	// 没有请求时不恢复
+	now = now.Add(5 * time.Second)
+	if got := c.rateRatio(now); got != 0.5 {
+		t.Fatalf("没有样本时速率比例 = %v，期望保持 0.5", got)
+	}
+
+	// 延迟回落后逐步恢复为配置速率
+	for _, ratio := range []float64{0.75, 1, 1} {
+		now = now.Add(time.Second)
+		observeN(c, 5, 20*time.Millisecond, now)
+		if got := c.rateRatio(now); got != ratio {
+			t.Fatalf("延迟回落后速率比例 = %v，期望 %v", got, ratio)
+		}
+	}
+
+	// p95 只计算窗口内的样本，窗口外的慢请求不影响调整
+	c.samples = c.samples[:0]
+	observeN(c, 5, time.Second, now)
+	now = now.Add(11 * time.Second)
+	observeN(c, 19, 20*time.Millisecond, now)
+	observeN(c, 1, time.Second, now)
+	if got := c.rateRatio(now); got != 1 {
+		t.Fatalf("窗口内 p95 未超过阈值时速率比例 = %v，期望 1", got)
+	}
+}
+
+func TestTokenBucketRatio(t *testing.T) {
+	// refill 返回空桶经过 1 秒后按 ratio 可获取的令牌数
+	refill := func(ratio float64) int {
+		b := &TokenBucket{rate: 100, capacity: 1000, lastRefill: time.Now().Add(-time.Second).UnixNano()}
+		allowed := 0
+		for b.allow(ratio) {
+			allowed++
+		}
+		return allowed
+	}
+
+	if got := refill(1); got < 100 || got > 101 {
+		t.Fatalf("配置速率下 1 秒生成 %d 个令牌，期望 100", got)
+	}
+	if got := refill(0.25); got < 25 || got > 26 {
+		t.Fatalf("速率比例 0.25 时 1 秒生成 %d 个令牌，期望 25", got)
+	}
+}
+
+func TestParseAdaptiveSettingsErrors(t *testing.T) {
+	for name, config := range map[string]map[string]interface{}{
+		"阈值为 0":  {"latency_threshold": "0s"},
+		"下限超过 1": {"min_rate_ratio": 1.5},
+		"系数为 1":  {"decrease_factor": 1},
+		"步长为 0":  {"increase_step": 0},
+		"样本数为 0": {"min_samples": 0},
+		"未知字段":   {"threshold": "1s"},
+	} {
+		if _, err := parseAdaptiveSettings(config); err == nil {
+			t.Fatalf("%s: 期望解析失败", name)
+		}
+	}
+}
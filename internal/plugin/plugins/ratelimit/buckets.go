@@ -0,0 +1,78 @@
+package ratelimit
+
+import (
+	"sync"
+)
+
+// bucketShardCount 令牌桶分片数，限流键按哈希分散到各分片，减少高并发下的锁竞争
+const bucketShardCount = 32
+
+// bucketShard 令牌桶分片，每个分片使用独立的锁
+type bucketShard struct {
+	buckets map[string]*TokenBucket
+	mu      sync.RWMutex
+}
+
+// bucketMap 按限流键分片存储的令牌桶
+type bucketMap struct {
+	shards [bucketShardCount]bucketShard
+}
+
+// newBucketMap 创建分片令牌桶表
+func newBucketMap() *bucketMap {
+	m := &bucketMap{}
+	for i := range m.shards {
+		m.shards[i].buckets = make(map[string]*TokenBucket)
+	}
+	return m
+}
+
+// shard 返回限流键所在的分片（FNV-1a 哈希）
+func (m *bucketMap) shard(key string) *bucketShard {
+	hash := uint32(2166136261)
+	for i := 0; i < len(key); i++ {
+		hash ^= uint32(key[i])
+		hash *= 16777619
+	}
+	return &m.shards[hash%bucketShardCount]
+}
+
+// getOrCreate 获取限流键的令牌桶，不存在时使用 create 创建，只锁定所在分片
+func (m *bucketMap) getOrCreate(key string, create func() *TokenBucket) *TokenBucket {
+	shard := m.shard(key)
+	shard.mu.RLock()
+	bucket, exists := shard.buckets[key]
+	shard.mu.RUnlock()
+	if exists {
+		return bucket
+	}
+
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	// 双重检查
+	if bucket, exists = shard.buckets[key]; exists {
+		return bucket
+	}
+	bucket = create()
+	shard.buckets[key] = bucket
+	return bucket
+}
+
+// removeIdle 删除 lastRefill 早于 before（UnixNano）的令牌桶，逐个分片加锁，不阻塞其他分片的请求
+func (m *bucketMap) removeIdle(before int64) {
+	for i := range m.shards {
+		shard := &m.shards[i]
+		shard.mu.Lock()
+		for key, bucket := range shard.buckets {
+			bucket.mu.RLock()
+			lastRefill := bucket.lastRefill
+			bucket.mu.RUnlock()
+
+			if lastRefill < before {
+				delete(shard.buckets, key)
+			}
+		}
+		shard.mu.Unlock()
+	}
+}
@@ -0,0 +1,142 @@
+package ratelimit
+
+import (
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newTestBucket 创建 lastRefill 为 at 的令牌桶
+func newTestBucket(at time.Time) *TokenBucket {
+	return &TokenBucket{rate: 10, capacity: 20, tokens: 20, lastRefill: at.UnixNano()}
+}
+
+func TestBucketMapGetOrCreate(t *testing.T) {
+	m := newBucketMap()
+	var created atomic.Int64
+	create := func() *TokenBucket {
+		created.Add(1)
+		return newTestBucket(time.Now())
+	}
+
+	// 并发获取同一个键只创建一个令牌桶
+	var wg sync.WaitGroup
+	results := make([]*TokenBucket, 64)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i] = m.getOrCreate("client-1", create)
+		}(i)
+	}
+	wg.Wait()
+	for _, bucket := range results {
+		if bucket != results[0] {
+			t.Fatal("同一个键返回了不同的令牌桶")
+		}
+	}
+	if created.Load() != 1 {
+		t.Fatalf("创建了 %d 个令牌桶，期望 1 个", created.Load())
+	}
+	if m.getOrCreate("client-2", create) == results[0] {
+		t.Fatal("不同的键返回了同一个令牌桶")
+	}
+}
+
+func TestBucketMapRemoveIdle(t *testing.T) {
+	m := newBucketMap()
+	now := time.Now()
+	// 足够多的键覆盖所有分片
+	for i := 0; i < 1000; i++ {
+		at := now
+		if i%2 == 0 {
+			at = now.Add(-10 * time.Minute)
+		}
+		m.getOrCreate("key-"+strconv.Itoa(i), func() *TokenBucket { return newTestBucket(at) })
+	}
+	for i := range m.shards {
+		if len(m.shards[i].buckets) == 0 {
+			t.Fatalf("分片 %d 没有令牌桶，键未分散到所有分片", i)
+		}
+	}
+
+	m.removeIdle(now.Add(-5 * time.Minute).UnixNano())
+
+	remaining := 0
+	for i := range m.shards {
+		for key := range m.shards[i].buckets {
+			remaining++
+			if n, _ := strconv.Atoi(key[len("key-"):]); n%2 == 0 {
+				t.Fatalf("空闲的令牌桶 %s 未被删除", key)
+			}
+		}
+	}
+	if remaining != 500 {
+		t.Fatalf("剩余 %d 个令牌桶，期望 500 个", remaining)
+	}
+}
+
+// singleLockBuckets 使用单个锁保护全部令牌桶，作为分片前实现的基准
+type singleLockBuckets struct {
+	buckets map[string]*TokenBucket
+	mu      sync.RWMutex
+}
+
+func (m *singleLockBuckets) getOrCreate(key string, create func() *TokenBucket) *TokenBucket {
+	m.mu.RLock()
+	bucket, exists := m.buckets[key]
+	m.mu.RUnlock()
+	if exists {
+		return bucket
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if bucket, exists = m.buckets[key]; exists {
+		return bucket
+	}
+	bucket = create()
+	m.buckets[key] = bucket
+	return bucket
+}
+
+// benchmarkKeys 基准测试使用的限流键数量
+const benchmarkKeys = 10000
+
+// benchmarkBuckets 并发获取令牌桶并取令牌，每 64 次请求中有 1 次使用新键，模拟不断出现的新客户端
+func benchmarkBuckets(b *testing.B, getOrCreate func(key string, create func() *TokenBucket) *TokenBucket) {
+	keys := make([]string, benchmarkKeys)
+	for i := range keys {
+		keys[i] = "10.0." + strconv.Itoa(i/256) + "." + strconv.Itoa(i%256)
+	}
+	create := func() *TokenBucket { return newTestBucket(time.Now()) }
+	var seq atomic.Int64
+
+	b.ReportAllocs()
+	b.SetParallelism(8)
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		i := int(seq.Add(1)) * 7919
+		for pb.Next() {
+			i++
+			key := keys[i%benchmarkKeys]
+			if i%64 == 0 {
+				key = "new-" + strconv.Itoa(int(seq.Add(1)))
+			}
+			getOrCreate(key, create).allow(1)
+		}
+	})
+}
+
+// BenchmarkBucketsContention 对比高并发下分片与单锁令牌桶表的吞吐，需在多核下运行（如 -cpu 8,16）才能体现锁竞争
+func BenchmarkBucketsContention(b *testing.B) {
+	b.Run("single", func(b *testing.B) {
+		m := &singleLockBuckets{buckets: make(map[string]*TokenBucket)}
+		benchmarkBuckets(b, m.getOrCreate)
+	})
+	b.Run("sharded", func(b *testing.B) {
+		benchmarkBuckets(b, newBucketMap().getOrCreate)
+	})
+}
@@ -2,6 +2,7 @@ package ratelimit
 
 import (
 	"fmt"
+	"gateway-go/internal/errors"
 	"gateway-go/internal/plugin/core"
 	"net/http"
 	"sync"
@@ -22,20 +23,65 @@ type TokenBucket struct {
 // RateLimitPlugin 限流插件
 type RateLimitPlugin struct {
 	*core.BasePlugin
-	config   map[string]interface{}
-	buckets  map[string]*TokenBucket
-	mu       sync.RWMutex
-	cleanup  *time.Ticker
-	stopChan chan struct{}
+	config map[string]interface{}
+	// 按限流键分片存储，各分片独立加锁
+	buckets *bucketMap
+	// 自适应限流配置，为空时使用固定速率
+	adaptive *adaptiveSettings
+	// 按路由统计延迟并调整速率
+	controllers map[string]*adaptiveController
+	mu          sync.RWMutex
+	cleanup     *time.Ticker
+	stopChan    chan struct{}
 }
 
 // New 创建限流插件
 func New() *RateLimitPlugin {
 	return &RateLimitPlugin{
-		BasePlugin: core.NewBasePlugin("rate_limit", 10, nil),
-		buckets:    make(map[string]*TokenBucket),
-		cleanup:    time.NewTicker(5 * time.Minute),
-		stopChan:   make(chan struct{}),
+		BasePlugin:  core.NewBasePlugin("rate_limit", 10, nil),
+		buckets:     newBucketMap(),
+		controllers: make(map[string]*adaptiveController),
+		cleanup:     time.NewTicker(5 * time.Minute),
+		stopChan:    make(chan struct{}),
+	}
+}
+
+// configSchema 插件配置结构
+var configSchema = core.ConfigSchema{
+	"requests_per_second": core.FieldNumber,
+	"burst":               core.FieldInt,
+	"ip_based":            core.FieldBool,
+	"mode":                core.FieldString,
+	"adaptive":            core.FieldObject,
+}
+
+// 限流模式
+const (
+	// ModeStatic 固定速率
+	ModeStatic = "static"
+	// ModeAdaptive 按上游延迟自动调整速率
+	ModeAdaptive = "adaptive"
+)
+
+// ValidateConfig 校验插件配置
+func (p *RateLimitPlugin) ValidateConfig(config map[string]interface{}) error {
+	if err := configSchema.Validate(config); err != nil {
+		return err
+	}
+	_, err := parseMode(config)
+	return err
+}
+
+// parseMode 解析限流模式，自适应模式返回自适应限流配置
+func parseMode(config map[string]interface{}) (*adaptiveSettings, error) {
+	switch mode, _ := config["mode"].(string); mode {
+	case "", ModeStatic:
+		return nil, nil
+	case ModeAdaptive:
+		adaptive, _ := config["adaptive"].(map[string]interface{})
+		return parseAdaptiveSettings(adaptive)
+	default:
+		return nil, fmt.Errorf("不支持的限流模式: %s", mode)
 	}
 }
 
@@ -47,7 +93,16 @@ func (p *RateLimitPlugin) Init(config interface{}) error {
 		return fmt.Errorf("配置类型错误，期望 map[string]interface{}")
 	}
 
+	adaptive, err := parseMode(configMap)
+	if err != nil {
+		return err
+	}
+
+	p.mu.Lock()
 	p.config = configMap
+	p.adaptive = adaptive
+	p.controllers = make(map[string]*adaptiveController)
+	p.mu.Unlock()
 
 	// 启动清理协程
 	go p.cleanupLoop()
@@ -63,18 +118,49 @@ func (p *RateLimitPlugin) Execute(ctx *gin.Context) error {
 	// 获取或创建令牌桶
 	bucket := p.getBucket(key)
 
+	// 自适应模式下按路由的延迟调整令牌生成速率
+	now := time.Now()
+	ratio := 1.0
+	controller := p.getController(ctx.GetString("route"))
+	if controller != nil {
+		ratio = controller.rateRatio(now)
+	}
+
 	// 尝试获取令牌
-	if !bucket.allow() {
-		ctx.JSON(http.StatusTooManyRequests, gin.H{
-			"error": "请求过于频繁",
-		})
-		ctx.Abort()
+	if !bucket.allow(ratio) {
+		errors.Abort(ctx, http.StatusTooManyRequests, "请求过于频繁")
 		return nil
 	}
 
+	// 请求处理结束后记录延迟
+	if controller != nil {
+		core.OnComplete(ctx, func() {
+			end := time.Now()
+			controller.observe(end.Sub(now), end)
+		})
+	}
 	return nil
 }
 
+// getController 获取路由的自适应速率控制器，固定速率模式返回 nil
+func (p *RateLimitPlugin) getController(route string) *adaptiveController {
+	p.mu.RLock()
+	adaptive := p.adaptive
+	controller := p.controllers[route]
+	p.mu.RUnlock()
+	if adaptive == nil || controller != nil {
+		return controller
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if controller = p.controllers[route]; controller == nil {
+		controller = newAdaptiveController(p.adaptive)
+		p.controllers[route] = controller
+	}
+	return controller
+}
+
 // getLimitKey 获取限流键
 func (p *RateLimitPlugin) getLimitKey(c *gin.Context) string {
 	// 如果配置了基于IP限流，则使用IP作为键
@@ -88,48 +174,36 @@ func (p *RateLimitPlugin) getLimitKey(c *gin.Context) string {
 
 // getBucket 获取或创建令牌桶
 func (p *RateLimitPlugin) getBucket(key string) *TokenBucket {
+	return p.buckets.getOrCreate(key, p.newBucket)
+}
+
+// newBucket 按配置创建令牌桶
+func (p *RateLimitPlugin) newBucket() *TokenBucket {
 	p.mu.RLock()
-	bucket, exists := p.buckets[key]
+	config := p.config
 	p.mu.RUnlock()
 
-	if exists {
-		return bucket
-	}
-
-	p.mu.Lock()
-	defer p.mu.Unlock()
-
-	// 双重检查
-	bucket, exists = p.buckets[key]
-	if exists {
-		return bucket
-	}
-
 	// 从配置中获取参数
 	requestsPerSecond := 10.0
 	burst := 20
 
-	if rps, ok := p.config["requests_per_second"].(float64); ok {
+	if rps, ok := core.ToFloat64(config["requests_per_second"]); ok {
 		requestsPerSecond = rps
 	}
-	if b, ok := p.config["burst"].(int); ok {
+	if b, ok := core.ToInt(config["burst"]); ok {
 		burst = b
 	}
 
-	// 创建新的令牌桶
-	bucket = &TokenBucket{
+	return &TokenBucket{
 		rate:       requestsPerSecond,
 		capacity:   int64(burst),
 		tokens:     int64(burst),
 		lastRefill: time.Now().UnixNano(),
 	}
-	p.buckets[key] = bucket
-
-	return bucket
 }
 
-// allow 尝试获取令牌
-func (b *TokenBucket) allow() bool {
+// allow 尝试获取令牌，ratio 为令牌生成速率相对配置速率的比例
+func (b *TokenBucket) allow(ratio float64) bool {
 	b.mu.Lock()
 	defer b.mu.Unlock()
 
@@ -137,7 +211,7 @@ func (b *TokenBucket) allow() bool {
 	elapsed := float64(now-b.lastRefill) / float64(time.Second)
 
 	// 计算需要添加的令牌数
-	tokensToAdd := int64(elapsed * b.rate)
+	tokensToAdd := int64(elapsed * b.rate * ratio)
 	if tokensToAdd > 0 {
 		b.tokens = min(b.capacity, b.tokens+tokensToAdd)
 		b.lastRefill = now
@@ -165,22 +239,9 @@ func (p *RateLimitPlugin) cleanupLoop() {
 	}
 }
 
-// cleanupBuckets 清理过期令牌桶
+// cleanupBuckets 清理过期令牌桶，超过5分钟没有使用的令牌桶被删除
 func (p *RateLimitPlugin) cleanupBuckets() {
-	p.mu.Lock()
-	defer p.mu.Unlock()
-
-	now := time.Now().UnixNano()
-	for key, bucket := range p.buckets {
-		bucket.mu.RLock()
-		lastRefill := bucket.lastRefill
-		bucket.mu.RUnlock()
-
-		// 如果超过5分钟没有使用，则删除
-		if now-lastRefill > 5*time.Minute.Nanoseconds() {
-			delete(p.buckets, key)
-		}
-	}
+	p.buckets.removeIdle(time.Now().Add(-5 * time.Minute).UnixNano())
 }
 
 // Stop 停止插件
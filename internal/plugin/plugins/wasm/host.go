@@ -0,0 +1,125 @@
+package wasm
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+	"golang.org/x/net/http/httpguts"
+)
+
+// hostModuleName 宿主函数所在的导入模块名
+const hostModuleName = "gateway"
+
+// callKey 上下文中当前调用的键，宿主函数通过它访问请求
+type callKey struct{}
+
+// call 一次入口函数调用，记录模块对请求头的修改，放行时才应用到请求
+type call struct {
+	request *http.Request
+	changes []headerChange
+}
+
+// headerChange 请求头修改，remove 为 true 时删除该请求头
+type headerChange struct {
+	name   string
+	value  string
+	remove bool
+}
+
+// apply 将模块对请求头的修改应用到请求
+func (c *call) apply(req *http.Request) {
+	for _, change := range c.changes {
+		if change.remove {
+			req.Header.Del(change.name)
+		} else {
+			req.Header.Set(change.name, change.value)
+		}
+	}
+}
+
+// instantiateHostModule 注册提供给 WASM 模块的宿主函数
+//
+// 读取类函数把内容写入 buf 并返回内容长度；长度超过 buf_len 时不写入，模块可按返回的长度重新分配后再次调用
+func instantiateHostModule(ctx context.Context, rt wazero.Runtime) error {
+	_, err := rt.NewHostModuleBuilder(hostModuleName).
+		// get_method(buf, buf_len) -> len
+		NewFunctionBuilder().WithFunc(func(ctx context.Context, m api.Module, buf, bufLen uint32) int32 {
+		return writeString(m, buf, bufLen, currentCall(ctx).request.Method)
+	}).Export("get_method").
+		// get_path(buf, buf_len) -> len
+		NewFunctionBuilder().WithFunc(func(ctx context.Context, m api.Module, buf, bufLen uint32) int32 {
+		return writeString(m, buf, bufLen, currentCall(ctx).request.URL.Path)
+	}).Export("get_path").
+		// get_header(name, name_len, buf, buf_len) -> len，请求头不存在时返回 -1
+		NewFunctionBuilder().WithFunc(func(ctx context.Context, m api.Module, name, nameLen, buf, bufLen uint32) int32 {
+		key, ok := readString(m, name, nameLen)
+		if !ok {
+			return -1
+		}
+		values := currentCall(ctx).request.Header.Values(key)
+		if len(values) == 0 {
+			return -1
+		}
+		return writeString(m, buf, bufLen, strings.Join(values, ", "))
+	}).Export("get_header").
+		// set_header(name, name_len, value, value_len) -> 0 成功，-1 名称或值无效
+		NewFunctionBuilder().WithFunc(func(ctx context.Context, m api.Module, name, nameLen, value, valueLen uint32) int32 {
+		key, ok := readString(m, name, nameLen)
+		if !ok || !httpguts.ValidHeaderFieldName(key) {
+			return -1
+		}
+		val, ok := readString(m, value, valueLen)
+		if !ok || !httpguts.ValidHeaderFieldValue(val) {
+			return -1
+		}
+		c := currentCall(ctx)
+		c.changes = append(c.changes, headerChange{name: key, value: val})
+		return 0
+	}).Export("set_header").
+		// remove_header(name, name_len) -> 0 成功，-1 名称无效
+		NewFunctionBuilder().WithFunc(func(ctx context.Context, m api.Module, name, nameLen uint32) int32 {
+		key, ok := readString(m, name, nameLen)
+		if !ok || !httpguts.ValidHeaderFieldName(key) {
+			return -1
+		}
+		c := currentCall(ctx)
+		c.changes = append(c.changes, headerChange{name: key, remove: true})
+		return 0
+	}).Export("remove_header").
+		Instantiate(ctx)
+	return err
+}
+
+// currentCall 获取当前调用
+func currentCall(ctx context.Context) *call {
+	return ctx.Value(callKey{}).(*call)
+}
+
+// readString 读取模块内存中的字符串，模块未导出内存或越界时返回 false
+func readString(m api.Module, ptr, length uint32) (string, bool) {
+	if m.Memory() == nil {
+		return "", false
+	}
+	data, ok := m.Memory().Read(ptr, length)
+	if !ok {
+		return "", false
+	}
+	return string(data), true
+}
+
+// writeString 将字符串写入模块内存，返回字符串长度；buf 不足时不写入，模块未导出内存或越界时返回 -1
+func writeString(m api.Module, buf, bufLen uint32, value string) int32 {
+	if m.Memory() == nil {
+		return -1
+	}
+	if uint32(len(value)) > bufLen {
+		return int32(len(value))
+	}
+	if !m.Memory().WriteString(buf, value) {
+		return -1
+	}
+	return int32(len(value))
+}
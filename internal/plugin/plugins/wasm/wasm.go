@@ -0,0 +1,311 @@
+package wasm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"runtime"
+	"strconv"
+	"sync"
+	"time"
+
+	gwerrors "gateway-go/internal/errors"
+	"gateway-go/internal/plugin/core"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/imports/wasi_snapshot_preview1"
+)
+
+// 默认配置
+const (
+	defaultEntrypoint  = "on_request"
+	defaultTimeout     = 100 * time.Millisecond
+	defaultMaxMemoryMB = 16
+)
+
+// wasmPageSize WASM 内存页大小
+const wasmPageSize = 64 * 1024
+
+// WasmPlugin WASM 插件，在沙箱中执行用户提供的 WASM 模块决定放行、拒绝或修改请求
+type WasmPlugin struct {
+	*core.BasePlugin
+	state *state
+	mu    sync.RWMutex
+}
+
+// settings 解析后的插件配置
+type settings struct {
+	module      string
+	entrypoint  string
+	timeout     time.Duration
+	maxMemoryMB int
+	failOpen    bool
+}
+
+// New 创建 WASM 插件
+func New() *WasmPlugin {
+	return &WasmPlugin{
+		BasePlugin: core.NewBasePlugin("wasm", 25, nil),
+	}
+}
+
+// configSchema 插件配置结构
+var configSchema = core.ConfigSchema{
+	"module":        core.FieldString,
+	"entrypoint":    core.FieldString,
+	"timeout":       core.FieldDuration,
+	"max_memory_mb": core.FieldInt,
+	"fail_open":     core.FieldBool,
+}
+
+// ValidateConfig 校验插件配置
+func (p *WasmPlugin) ValidateConfig(config map[string]interface{}) error {
+	if err := configSchema.Validate(config); err != nil {
+		return err
+	}
+	_, err := parseSettings(config)
+	return err
+}
+
+// Init 初始化插件，编译 WASM 模块并检查入口函数
+func (p *WasmPlugin) Init(config interface{}) error {
+	// 类型断言
+	configMap, ok := config.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("配置类型错误，期望 map[string]interface{}")
+	}
+
+	s, err := parseSettings(configMap)
+	if err != nil {
+		return err
+	}
+	st, err := newState(s)
+	if err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	old := p.state
+	p.state = st
+	p.mu.Unlock()
+	old.release()
+	return nil
+}
+
+// Stop 停止插件，进行中的调用结束后释放 WASM 运行时
+func (p *WasmPlugin) Stop() error {
+	p.mu.Lock()
+	old := p.state
+	p.state = nil
+	p.mu.Unlock()
+	old.release()
+	return nil
+}
+
+// parseSettings 解析插件配置
+func parseSettings(config map[string]interface{}) (*settings, error) {
+	s := &settings{
+		entrypoint:  defaultEntrypoint,
+		timeout:     defaultTimeout,
+		maxMemoryMB: defaultMaxMemoryMB,
+	}
+
+	s.module, _ = config["module"].(string)
+	if s.module == "" {
+		return nil, fmt.Errorf("未配置 WASM 模块路径 module")
+	}
+	if entrypoint, ok := config["entrypoint"].(string); ok && entrypoint != "" {
+		s.entrypoint = entrypoint
+	}
+	if value, exists := config["timeout"]; exists {
+		timeout, err := durationValue(value)
+		if err != nil || timeout <= 0 {
+			return nil, fmt.Errorf("timeout 必须大于 0: %v", value)
+		}
+		s.timeout = timeout
+	}
+	if mb, ok := core.ToInt(config["max_memory_mb"]); ok {
+		// WASM32 内存上限为 4GB
+		if mb <= 0 || mb > 4096 {
+			return nil, fmt.Errorf("max_memory_mb 必须在 1-4096 之间: %d", mb)
+		}
+		s.maxMemoryMB = mb
+	}
+	if failOpen, ok := config["fail_open"].(bool); ok {
+		s.failOpen = failOpen
+	}
+	return s, nil
+}
+
+// durationValue 解析时长配置，整数表示毫秒
+func durationValue(value interface{}) (time.Duration, error) {
+	if s, ok := value.(string); ok {
+		if d, err := time.ParseDuration(s); err == nil {
+			return d, nil
+		}
+		millis, err := strconv.Atoi(s)
+		return time.Duration(millis) * time.Millisecond, err
+	}
+	millis, ok := core.ToInt(value)
+	if !ok {
+		return 0, fmt.Errorf("时长格式错误: %v", value)
+	}
+	return time.Duration(millis) * time.Millisecond, nil
+}
+
+// Execute 执行插件
+func (p *WasmPlugin) Execute(ctx *gin.Context) error {
+	p.mu.RLock()
+	st := p.state
+	if st != nil {
+		st.active.Add(1)
+	}
+	p.mu.RUnlock()
+	if st == nil {
+		return nil
+	}
+	defer st.active.Done()
+
+	status, call, err := st.run(ctx.Request)
+	if err != nil {
+		if st.settings.failOpen {
+			return nil
+		}
+		gwerrors.Abort(ctx, http.StatusInternalServerError, "WASM 插件执行失败")
+		return fmt.Errorf("WASM 模块 %s 执行失败: %v", st.settings.module, err)
+	}
+
+	if status != 0 {
+		gwerrors.Abort(ctx, status, "请求被拒绝")
+		return fmt.Errorf("WASM 模块 %s 拒绝请求: %d", st.settings.module, status)
+	}
+	call.apply(ctx.Request)
+	return nil
+}
+
+// state 编译后的模块和空闲实例，配置重载时整体替换
+type state struct {
+	settings *settings
+	runtime  wazero.Runtime
+	compiled wazero.CompiledModule
+	// 空闲的模块实例，实例不能并发调用，每次调用独占一个实例
+	idle chan api.Module
+	// 进行中的调用，全部结束后才能关闭运行时
+	active sync.WaitGroup
+}
+
+// newState 创建 WASM 运行时并编译模块
+func newState(s *settings) (*state, error) {
+	code, err := os.ReadFile(s.module)
+	if err != nil {
+		return nil, fmt.Errorf("读取 WASM 模块失败: %v", err)
+	}
+
+	ctx := context.Background()
+	// 超时后中断执行中的模块，限制模块可申请的内存
+	rt := wazero.NewRuntimeWithConfig(ctx, wazero.NewRuntimeConfig().
+		WithCloseOnContextDone(true).
+		WithMemoryLimitPages(uint32(s.maxMemoryMB*1024*1024/wasmPageSize)))
+
+	// WASI 仅用于兼容各语言编译出的模块，不开放文件系统、环境变量和网络
+	if _, err := wasi_snapshot_preview1.Instantiate(ctx, rt); err != nil {
+		rt.Close(ctx)
+		return nil, fmt.Errorf("初始化 WASI 失败: %v", err)
+	}
+	if err := instantiateHostModule(ctx, rt); err != nil {
+		rt.Close(ctx)
+		return nil, fmt.Errorf("初始化宿主函数失败: %v", err)
+	}
+
+	compiled, err := rt.CompileModule(ctx, code)
+	if err != nil {
+		rt.Close(ctx)
+		return nil, fmt.Errorf("编译 WASM 模块失败: %v", err)
+	}
+	fn, exists := compiled.ExportedFunctions()[s.entrypoint]
+	if !exists {
+		rt.Close(ctx)
+		return nil, fmt.Errorf("WASM 模块未导出入口函数 %s", s.entrypoint)
+	}
+	if len(fn.ParamTypes()) != 0 || len(fn.ResultTypes()) != 1 || fn.ResultTypes()[0] != api.ValueTypeI32 {
+		rt.Close(ctx)
+		return nil, fmt.Errorf("入口函数 %s 的签名必须为 () -> i32", s.entrypoint)
+	}
+
+	return &state{
+		settings: s,
+		runtime:  rt,
+		compiled: compiled,
+		idle:     make(chan api.Module, runtime.GOMAXPROCS(0)),
+	}, nil
+}
+
+// run 调用入口函数，返回拒绝请求的状态码（0 表示放行）和模块对请求的修改
+func (st *state) run(req *http.Request) (int, *call, error) {
+	mod, err := st.acquire(req.Context())
+	if err != nil {
+		return 0, nil, err
+	}
+
+	// 超时只限制入口函数的执行时间，不包括创建实例
+	call := &call{request: req}
+	ctx, cancel := context.WithTimeout(req.Context(), st.settings.timeout)
+	defer cancel()
+	ctx = context.WithValue(ctx, callKey{}, call)
+	results, err := mod.ExportedFunction(st.settings.entrypoint).Call(ctx)
+	if err != nil {
+		// 执行出错或超时的实例状态不确定，不再复用
+		mod.Close(context.Background())
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return 0, nil, fmt.Errorf("执行超时（%v）", st.settings.timeout)
+		}
+		return 0, nil, err
+	}
+	st.releaseInstance(mod)
+
+	status := int(int32(results[0]))
+	if status != 0 && (status < 100 || status > 599) {
+		return 0, nil, fmt.Errorf("入口函数返回了无效的状态码: %d", status)
+	}
+	return status, call, nil
+}
+
+// acquire 取出空闲实例，没有时创建新实例
+func (st *state) acquire(ctx context.Context) (api.Module, error) {
+	select {
+	case mod := <-st.idle:
+		return mod, nil
+	default:
+	}
+	// 匿名实例，同一个模块可以实例化多次；_initialize 用于 WASI reactor 模块的初始化
+	mod, err := st.runtime.InstantiateModule(ctx, st.compiled,
+		wazero.NewModuleConfig().WithName("").WithStartFunctions("_initialize"))
+	if err != nil {
+		return nil, fmt.Errorf("实例化 WASM 模块失败: %v", err)
+	}
+	return mod, nil
+}
+
+// releaseInstance 归还实例，空闲实例已满时关闭
+func (st *state) releaseInstance(mod api.Module) {
+	select {
+	case st.idle <- mod:
+	default:
+		mod.Close(context.Background())
+	}
+}
+
+// release 等待进行中的调用结束后关闭运行时
+func (st *state) release() {
+	if st == nil {
+		return
+	}
+	go func() {
+		st.active.Wait()
+		st.runtime.Close(context.Background())
+	}()
+}
@@ -0,0 +1,226 @@
+package wasm
+
+import (
+	"encoding/binary"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gateway-go/internal/plugin/plugintest"
+
+	"github.com/gin-gonic/gin"
+)
+
+// WASM 指令和类型编码
+const (
+	opIf        = 0x04
+	opLoop      = 0x03
+	opEnd       = 0x0b
+	opBr        = 0x0c
+	opReturn    = 0x0f
+	opCall      = 0x10
+	opDrop      = 0x1a
+	opLocalGet  = 0x20
+	opLocalSet  = 0x21
+	opI32Load   = 0x28
+	opI32Load16 = 0x2f
+	opI32Const  = 0x41
+	opI32Eq     = 0x46
+	opI32GeS    = 0x4e
+	opI32And    = 0x71
+	typeI32     = 0x7f
+	blockVoid   = 0x40
+)
+
+// i32Const 编码 i32.const 指令（有符号 LEB128）
+func i32Const(value int32) []byte {
+	out := []byte{opI32Const}
+	for {
+		b := byte(value & 0x7f)
+		value >>= 7
+		if (value == 0 && b&0x40 == 0) || (value == -1 && b&0x40 != 0) {
+			return append(out, b)
+		}
+		out = append(out, b|0x80)
+	}
+}
+
+// vec 编码长度前缀（无符号 LEB128，测试模块中均小于 128）加内容
+func vec(items ...[]byte) []byte {
+	var out []byte
+	for _, item := range items {
+		out = append(out, item...)
+	}
+	return append([]byte{byte(len(out))}, out...)
+}
+
+// name 编码名称
+func name(s string) []byte {
+	return append([]byte{byte(len(s))}, s...)
+}
+
+// section 编码段
+func section(id byte, count int, items ...[]byte) []byte {
+	content := []byte{byte(count)}
+	for _, item := range items {
+		content = append(content, item...)
+	}
+	return append([]byte{id, byte(len(content))}, content...)
+}
+
+// join 拼接指令
+func join(parts ...[]byte) []byte {
+	var out []byte
+	for _, part := range parts {
+		out = append(out, part...)
+	}
+	return out
+}
+
+// testModule 生成测试用的 WASM 模块，导出三个入口函数：
+//   - on_request：拒绝 /admin 开头的请求（403），其余请求添加 X-Wasm-Checked: 1
+//   - spin：死循环，用于测试超时
+//   - bad_status：返回无效的状态码 42
+func testModule() []byte {
+	const pathBuf = 1024
+	adm := int32(binary.LittleEndian.Uint32([]byte("/adm")))
+	in := int32(binary.LittleEndian.Uint16([]byte("in")))
+
+	onRequest := join(
+		[]byte{1, 1, typeI32}, // 1 个 i32 局部变量：路径长度
+		i32Const(pathBuf), i32Const(256), []byte{opCall, 0, opLocalSet, 0},
+		[]byte{opLocalGet, 0}, i32Const(6), []byte{opI32GeS, opIf, blockVoid},
+		i32Const(pathBuf), []byte{opI32Load, 2, 0}, i32Const(adm), []byte{opI32Eq},
+		i32Const(pathBuf), []byte{opI32Load16, 1, 4}, i32Const(in), []byte{opI32Eq},
+		[]byte{opI32And, opIf, blockVoid}, i32Const(403), []byte{opReturn, opEnd},
+		[]byte{opEnd},
+		// set_header("X-Wasm-Checked", "1")，名称和值位于数据段的 0 和 14
+		i32Const(0), i32Const(14), i32Const(14), i32Const(1), []byte{opCall, 1, opDrop},
+		i32Const(0), []byte{opEnd},
+	)
+	spin := join([]byte{0, opLoop, blockVoid, opBr, 0, opEnd}, i32Const(0), []byte{opEnd})
+	badStatus := join([]byte{0}, i32Const(42), []byte{opEnd})
+
+	return join(
+		[]byte("\x00asm\x01\x00\x00\x00"),
+		section(1, 3,
+			[]byte{0x60, 2, typeI32, typeI32, 1, typeI32},
+			[]byte{0x60, 4, typeI32, typeI32, typeI32, typeI32, 1, typeI32},
+			[]byte{0x60, 0, 1, typeI32},
+		),
+		section(2, 2,
+			join(name(hostModuleName), name("get_path"), []byte{0, 0}),
+			join(name(hostModuleName), name("set_header"), []byte{0, 1}),
+		),
+		section(3, 3, []byte{2, 2, 2}),
+		section(5, 1, []byte{0, 1}),
+		section(7, 4,
+			join(name("memory"), []byte{2, 0}),
+			join(name("on_request"), []byte{0, 2}),
+			join(name("spin"), []byte{0, 3}),
+			join(name("bad_status"), []byte{0, 4}),
+		),
+		section(10, 3, vec(onRequest), vec(spin), vec(badStatus)),
+		section(11, 1, join([]byte{0}, i32Const(0), []byte{opEnd}, name("X-Wasm-Checked1"))),
+	)
+}
+
+// writeModule 将测试模块写入临时文件
+func writeModule(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "filter.wasm")
+	if err := os.WriteFile(path, testModule(), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// execute 执行插件，返回响应状态码（放行时为 0）和请求上下文
+func execute(p *WasmPlugin, path string) (int, *gin.Context) {
+	gin.SetMode(gin.TestMode)
+	rec := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(rec)
+	c.Request = httptest.NewRequest(http.MethodGet, path, nil)
+	p.Execute(c)
+	if !c.IsAborted() {
+		return 0, c
+	}
+	return rec.Code, c
+}
+
+func TestWasmModuleBlocksPath(t *testing.T) {
+	p := plugintest.Init(t, New(), map[string]interface{}{"module": writeModule(t)})
+
+	tests := []struct {
+		path   string
+		status int
+	}{
+		{"/admin/users", http.StatusForbidden},
+		{"/admin", http.StatusForbidden},
+		{"/api/orders", 0},
+		{"/adm", 0},
+		{"/", 0},
+	}
+	// 实例在请求之间复用，重复执行验证复用的实例结果一致
+	for i := 0; i < 3; i++ {
+		for _, tt := range tests {
+			status, c := execute(p, tt.path)
+			if status != tt.status {
+				t.Fatalf("%s 的状态码 = %d，期望 %d", tt.path, status, tt.status)
+			}
+			// 请求头修改只在放行时应用
+			if want := map[bool]string{true: "1", false: ""}[tt.status == 0]; c.Request.Header.Get("X-Wasm-Checked") != want {
+				t.Fatalf("%s 的 X-Wasm-Checked = %q，期望 %q", tt.path, c.Request.Header.Get("X-Wasm-Checked"), want)
+			}
+		}
+	}
+}
+
+func TestWasmModuleFailures(t *testing.T) {
+	module := writeModule(t)
+
+	tests := []struct {
+		name   string
+		config map[string]interface{}
+		status int
+	}{
+		{"执行超时", map[string]interface{}{"module": module, "entrypoint": "spin", "timeout": "50ms"}, http.StatusInternalServerError},
+		{"超时时放行", map[string]interface{}{"module": module, "entrypoint": "spin", "timeout": 50, "fail_open": true}, 0},
+		{"无效的状态码", map[string]interface{}{"module": module, "entrypoint": "bad_status"}, http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := plugintest.Init(t, New(), tt.config)
+			start := time.Now()
+			if status, _ := execute(p, "/"); status != tt.status {
+				t.Fatalf("状态码 = %d，期望 %d", status, tt.status)
+			}
+			if elapsed := time.Since(start); elapsed > 2*time.Second {
+				t.Fatalf("执行耗时 %v，超时未中断模块", elapsed)
+			}
+		})
+	}
+}
+
+func TestWasmInitErrors(t *testing.T) {
+	module := writeModule(t)
+	invalid := filepath.Join(t.TempDir(), "invalid.wasm")
+	os.WriteFile(invalid, []byte("not wasm"), 0o600)
+
+	for name, config := range map[string]map[string]interface{}{
+		"未配置模块":    {},
+		"模块不存在":    {"module": filepath.Join(t.TempDir(), "missing.wasm")},
+		"模块格式错误":   {"module": invalid},
+		"入口函数不存在":  {"module": module, "entrypoint": "missing"},
+		"入口函数签名错误": {"module": module, "entrypoint": "memory"},
+		"超时为 0":    {"module": module, "timeout": 0},
+		"内存超出上限":   {"module": module, "max_memory_mb": 8192},
+	} {
+		if err := New().Init(config); err == nil {
+			t.Fatalf("%s: 期望初始化失败", name)
+		}
+	}
+}
@@ -0,0 +1,21 @@
+// Package plugintest 提供插件单元测试共用的辅助函数
+package plugintest
+
+import (
+	"testing"
+
+	"gateway-go/internal/plugin/core"
+)
+
+// Init 按加载插件的流程校验配置并初始化 p，失败时终止测试，测试结束时停止插件
+func Init[P core.Plugin](t testing.TB, p P, config map[string]interface{}) P {
+	t.Helper()
+	if err := p.ValidateConfig(config); err != nil {
+		t.Fatalf("校验配置失败: %v", err)
+	}
+	if err := p.Init(config); err != nil {
+		t.Fatalf("初始化插件失败: %v", err)
+	}
+	t.Cleanup(func() { p.Stop() })
+	return p
+}
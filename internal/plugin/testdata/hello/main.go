@@ -0,0 +1,34 @@
+// hello 外部插件示例，测试时编译为 Go 插件（.so）加载
+package main
+
+import (
+	"gateway-go/internal/plugin/core"
+
+	"github.com/gin-gonic/gin"
+)
+
+type helloPlugin struct {
+	*core.BasePlugin
+	value string
+}
+
+// New 供网关加载时调用，每次返回新的插件实例
+func New() core.Plugin {
+	return &helloPlugin{BasePlugin: core.NewBasePlugin("hello", 50, nil), value: "world"}
+}
+
+func (p *helloPlugin) Init(config interface{}) error {
+	if configMap, ok := config.(map[string]interface{}); ok {
+		if value, ok := configMap["value"].(string); ok {
+			p.value = value
+		}
+	}
+	return nil
+}
+
+func (p *helloPlugin) Execute(ctx *gin.Context) error {
+	ctx.Request.Header.Set("X-Hello", p.value)
+	return nil
+}
+
+func main() {}
@@ -2,9 +2,13 @@ package pool
 
 import (
 	"bytes"
+	"io"
 	"sync"
 )
 
+// maxPooledBufferSize 归还时保留的缓冲区容量上限，超过的缓冲区直接丢弃，避免大消息体长期占用内存
+const maxPooledBufferSize = 64 * 1024
+
 // BufferPool 缓冲区对象池
 type BufferPool struct {
 	pool sync.Pool
@@ -26,12 +30,39 @@ func (bp *BufferPool) Get() *bytes.Buffer {
 	return bp.pool.Get().(*bytes.Buffer)
 }
 
-// Put 归还缓冲区
+// Put 归还缓冲区，容量超过上限的缓冲区不再复用
 func (bp *BufferPool) Put(buf *bytes.Buffer) {
+	if buf == nil || buf.Cap() > maxPooledBufferSize {
+		return
+	}
 	buf.Reset()
 	bp.pool.Put(buf)
 }
 
+// pooledReadLimit ReadAll 读入池中缓冲区的字节数上限
+// bytes.Buffer.ReadFrom 每次读取前保留 bytes.MinRead 的空闲容量，留出该余量使缓冲区容量不超过 maxPooledBufferSize，归还后可以复用
+const pooledReadLimit = maxPooledBufferSize - bytes.MinRead
+
+// ReadAll 读取 r 的全部内容，返回的切片不引用池中的缓冲区，读取失败时缓冲区同样归还
+// 前 pooledReadLimit 字节读入池中的缓冲区，内容不超过该大小时只为结果分配一次内存；
+// 超过时与 io.ReadAll 相同，读取到的内容在结果上增长，只多一次已读取部分的复制
+func (bp *BufferPool) ReadAll(r io.Reader) ([]byte, error) {
+	buf := bp.Get()
+	defer bp.Put(buf)
+	n, err := buf.ReadFrom(io.LimitReader(r, pooledReadLimit))
+	if err != nil {
+		return nil, err
+	}
+	if n < pooledReadLimit {
+		return bytes.Clone(buf.Bytes()), nil
+	}
+	data, err := io.ReadAll(io.MultiReader(bytes.NewReader(buf.Bytes()), r))
+	if err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
 // ProxyRequest 代理请求对象
 type ProxyRequest struct {
 	Method  string
@@ -0,0 +1,88 @@
+package pool
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+	"testing/iotest"
+)
+
+func TestReadAll(t *testing.T) {
+	bp := NewBufferPool()
+	for _, size := range []int{0, 1, 1024, pooledReadLimit - 1, pooledReadLimit, pooledReadLimit + 1, maxPooledBufferSize, 1 << 20} {
+		data := make([]byte, size)
+		for i := range data {
+			data[i] = byte(i % 251)
+		}
+		// 逐字节读取的 reader 覆盖多次短读
+		for name, r := range map[string]io.Reader{"一次读取": bytes.NewReader(data), "逐字节读取": iotest.OneByteReader(bytes.NewReader(data))} {
+			got, err := bp.ReadAll(r)
+			if err != nil || !bytes.Equal(got, data) {
+				t.Fatalf("%s %d 字节: 读取 %d 字节，错误 %v", name, size, len(got), err)
+			}
+		}
+	}
+
+	// 返回的切片不引用池中的缓冲区，后续读取不会覆盖
+	first, _ := bp.ReadAll(bytes.NewReader([]byte("first")))
+	bp.ReadAll(bytes.NewReader([]byte("second")))
+	if string(first) != "first" {
+		t.Fatalf("后续读取覆盖了已返回的内容: %q", first)
+	}
+}
+
+func TestReadAllError(t *testing.T) {
+	bp := NewBufferPool()
+	errRead := errors.New("connection reset")
+	for _, size := range []int{10, pooledReadLimit + 10} {
+		r := io.MultiReader(bytes.NewReader(make([]byte, size)), iotest.ErrReader(errRead))
+		if got, err := bp.ReadAll(r); !errors.Is(err, errRead) || got != nil {
+			t.Fatalf("读取 %d 字节后失败: 返回 %d 字节，错误 %v", size, len(got), err)
+		}
+	}
+}
+
+func TestReadAllKeepsPooledBufferSize(t *testing.T) {
+	bp := NewBufferPool()
+	// 读入池中缓冲区的部分不超过容量上限，大消息体之后缓冲区仍可复用
+	buf := bp.Get()
+	bp.Put(buf)
+	for i := 0; i < 3; i++ {
+		bp.ReadAll(bytes.NewReader(make([]byte, 1<<20)))
+	}
+	if got := bp.Get(); got.Cap() > maxPooledBufferSize {
+		t.Fatalf("缓冲区容量 = %d，超过上限 %d", got.Cap(), maxPooledBufferSize)
+	}
+}
+
+// benchmarkSizes 基准测试的消息体大小：小于、接近和超过池中缓冲区的容量上限
+var benchmarkSizes = []int{512, 16 << 10, 60 << 10, 256 << 10}
+
+// BenchmarkReadAll 对比 io.ReadAll 和 BufferPool.ReadAll
+// 不超过 64KB 时只为结果分配一次内存，耗时和分配约为 io.ReadAll 的一半；更大时与 io.ReadAll 持平
+func BenchmarkReadAll(b *testing.B) {
+	for _, size := range benchmarkSizes {
+		data := bytes.Repeat([]byte("x"), size)
+		b.Run(fmt.Sprintf("io/%d", size), func(b *testing.B) {
+			b.ReportAllocs()
+			b.SetBytes(int64(size))
+			for i := 0; i < b.N; i++ {
+				if _, err := io.ReadAll(bytes.NewReader(data)); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+		b.Run(fmt.Sprintf("pool/%d", size), func(b *testing.B) {
+			bp := NewBufferPool()
+			b.ReportAllocs()
+			b.SetBytes(int64(size))
+			for i := 0; i < b.N; i++ {
+				if _, err := bp.ReadAll(bytes.NewReader(data)); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
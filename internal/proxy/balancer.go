@@ -0,0 +1,198 @@
+package proxy
+
+import (
+	"fmt"
+	"math/rand"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"gateway-go/internal/config"
+)
+
+// 负载均衡策略
+const (
+	StrategyRoundRobin     = "round_robin"
+	StrategyLeastLatency   = "least_latency"
+	StrategyConsistentHash = "consistent_hash"
+)
+
+// Balancer 负载均衡器，从路由的多个后端中选择一个
+type Balancer interface {
+	// Next 为请求选择后端地址
+	Next(req *http.Request) string
+	// Observe 记录一次请求结果，err 不为空表示请求失败
+	Observe(backend string, latency time.Duration, err error)
+}
+
+// NewBalancer 根据负载均衡配置创建负载均衡器，cfg 为空时使用轮询
+func NewBalancer(cfg *config.LoadBalancerConfig, backends []string) (Balancer, error) {
+	if len(backends) == 0 {
+		return nil, fmt.Errorf("后端地址不能为空")
+	}
+
+	var lb config.LoadBalancerConfig
+	if cfg != nil {
+		lb = *cfg
+	}
+	switch strategy := lb.Strategy; strategy {
+	case "", StrategyRoundRobin:
+		return &roundRobinBalancer{backends: backends}, nil
+	case StrategyLeastLatency:
+		return newLeastLatencyBalancer(backends), nil
+	case StrategyConsistentHash:
+		return newConsistentHashBalancer(backends, lb.HashKey, lb.VirtualNodes)
+	default:
+		return nil, fmt.Errorf("不支持的负载均衡策略: %s", strategy)
+	}
+}
+
+// roundRobinBalancer 轮询负载均衡
+type roundRobinBalancer struct {
+	backends []string
+	counter  uint64
+}
+
+// Next 按顺序轮流选择后端
+func (b *roundRobinBalancer) Next(req *http.Request) string {
+	n := atomic.AddUint64(&b.counter, 1)
+	return b.backends[(n-1)%uint64(len(b.backends))]
+}
+
+// Observe 轮询策略不使用请求结果
+func (b *roundRobinBalancer) Observe(backend string, latency time.Duration, err error) {}
+
+const (
+	// latencyDecay EWMA 衰减系数，越大越偏向最近的样本
+	latencyDecay = 0.3
+	// exploreRatio 随机探索比例，避免较慢后端恢复后长期得不到流量
+	exploreRatio = 0.05
+	// failurePenalty 请求失败时计入的延迟
+	failurePenalty = 5 * time.Second
+)
+
+// leastLatencyBalancer 最低延迟负载均衡，按后端延迟的 EWMA 选择最快的后端
+type leastLatencyBalancer struct {
+	backends []string
+	// 各后端延迟的 EWMA（纳秒），0 表示尚无样本
+	latency map[string]float64
+	rand    *rand.Rand
+	mu      sync.Mutex
+}
+
+// newLeastLatencyBalancer 创建最低延迟负载均衡器
+func newLeastLatencyBalancer(backends []string) *leastLatencyBalancer {
+	latency := make(map[string]float64, len(backends))
+	for _, backend := range backends {
+		latency[backend] = 0
+	}
+	return &leastLatencyBalancer{
+		backends: backends,
+		latency:  latency,
+		rand:     rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+// Next 优先选择尚无样本的后端，其次按比例随机探索，否则选择 EWMA 最低的后端
+func (b *leastLatencyBalancer) Next(req *http.Request) string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.rand.Float64() < exploreRatio {
+		return b.backends[b.rand.Intn(len(b.backends))]
+	}
+
+	best := b.backends[0]
+	bestLatency := b.latency[best]
+	for _, backend := range b.backends {
+		l := b.latency[backend]
+		if l == 0 {
+			return backend
+		}
+		if l < bestLatency {
+			best, bestLatency = backend, l
+		}
+	}
+	return best
+}
+
+// Observe 更新后端延迟的 EWMA，失败按固定惩罚延迟计入
+func (b *leastLatencyBalancer) Observe(backend string, latency time.Duration, err error) {
+	if err != nil {
+		latency = failurePenalty
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	current, exists := b.latency[backend]
+	if !exists {
+		return
+	}
+	if current == 0 {
+		b.latency[backend] = float64(latency)
+		return
+	}
+	b.latency[backend] = latencyDecay*float64(latency) + (1-latencyDecay)*current
+}
+
+// BalancerManager 按路由缓存负载均衡器，配置未变化时保留延迟统计
+type BalancerManager struct {
+	balancers map[string]*balancerEntry
+	mu        sync.RWMutex
+}
+
+// balancerEntry 缓存的负载均衡器及其配置签名
+type balancerEntry struct {
+	signature string
+	balancer  Balancer
+}
+
+// NewBalancerManager 创建负载均衡器管理器
+func NewBalancerManager() *BalancerManager {
+	return &BalancerManager{
+		balancers: make(map[string]*balancerEntry),
+	}
+}
+
+// Get 获取路由对应的负载均衡器
+func (m *BalancerManager) Get(route *config.RouteConfig) (Balancer, error) {
+	signature := route.Target.URL
+	if lb := route.Target.LoadBalancer; lb != nil {
+		signature += fmt.Sprintf("|%+v", *lb)
+	}
+	if sticky := route.Target.Sticky; sticky != nil {
+		signature += fmt.Sprintf("|%+v", *sticky)
+	}
+
+	m.mu.RLock()
+	entry, exists := m.balancers[route.Name]
+	m.mu.RUnlock()
+
+	if exists && entry.signature == signature {
+		return entry.balancer, nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// 双重检查
+	if entry, exists = m.balancers[route.Name]; exists && entry.signature == signature {
+		return entry.balancer, nil
+	}
+
+	balancer, err := NewBalancer(route.Target.LoadBalancer, route.Target.Backends())
+	if err != nil {
+		return nil, fmt.Errorf("路由 %s 负载均衡配置错误: %w", route.Name, err)
+	}
+	if route.Target.Sticky != nil {
+		balancer = newStickyBalancer(balancer, route.Target.Backends(), route.Target.Sticky)
+	}
+
+	m.balancers[route.Name] = &balancerEntry{
+		signature: signature,
+		balancer:  balancer,
+	}
+	return balancer, nil
+}
@@ -0,0 +1,101 @@
+package proxy
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"gateway-go/internal/config"
+)
+
+// simulate 按 latency 返回的延迟模拟 n 个请求，返回各后端被选中的次数
+func simulate(b Balancer, n int, latency func(backend string) time.Duration) map[string]int {
+	counts := make(map[string]int)
+	for i := 0; i < n; i++ {
+		backend := b.Next(nil)
+		counts[backend]++
+		b.Observe(backend, latency(backend), nil)
+	}
+	return counts
+}
+
+func TestLeastLatencyShiftsToFasterBackend(t *testing.T) {
+	b, err := NewBalancer(&config.LoadBalancerConfig{Strategy: StrategyLeastLatency}, []string{"a", "b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a 较快时大部分流量流向 a
+	latencies := map[string]time.Duration{"a": 5 * time.Millisecond, "b": 20 * time.Millisecond}
+	latency := func(backend string) time.Duration { return latencies[backend] }
+	simulate(b, 100, latency)
+	if counts := simulate(b, 1000, latency); counts["a"] < 850 {
+		t.Fatalf("a 较快时被选中 %d/1000 次，期望多数流量流向 a", counts["a"])
+	}
+
+	// a 变慢后流量逐步转向 b
+	latencies["a"] = 200 * time.Millisecond
+	simulate(b, 100, latency)
+	counts := simulate(b, 1000, latency)
+	if counts["b"] < 850 {
+		t.Fatalf("a 变慢后 b 被选中 %d/1000 次，期望多数流量转向 b", counts["b"])
+	}
+	// 保留少量探索流量，a 恢复后能重新被发现
+	if counts["a"] == 0 {
+		t.Fatal("较慢后端完全没有探索流量")
+	}
+
+	latencies["a"] = time.Millisecond
+	simulate(b, 500, latency)
+	if counts := simulate(b, 1000, latency); counts["a"] < 850 {
+		t.Fatalf("a 恢复后被选中 %d/1000 次，期望流量回到 a", counts["a"])
+	}
+}
+
+func TestLeastLatencyPrefersUnsampledAndPenalizesFailures(t *testing.T) {
+	b := newLeastLatencyBalancer([]string{"a", "b"})
+	b.Observe("a", time.Millisecond, nil)
+
+	// 尚无样本的后端优先被选中（排除随机探索）
+	picked := 0
+	for i := 0; i < 100; i++ {
+		if b.Next(nil) == "b" {
+			picked++
+		}
+	}
+	if picked < 85 {
+		t.Fatalf("无样本的后端被选中 %d/100 次，期望优先选中", picked)
+	}
+
+	// 失败按惩罚延迟计入
+	b.Observe("b", time.Millisecond, nil)
+	b.Observe("a", 0, errors.New("connection refused"))
+	if b.latency["a"] <= b.latency["b"] {
+		t.Fatalf("失败后 a 的延迟 %v 应高于 b 的 %v", time.Duration(b.latency["a"]), time.Duration(b.latency["b"]))
+	}
+
+	// 未知后端的样本被忽略
+	b.Observe("c", time.Second, nil)
+	if _, ok := b.latency["c"]; ok {
+		t.Fatal("不应记录未知后端的延迟")
+	}
+}
+
+func TestRoundRobinBalancer(t *testing.T) {
+	b, err := NewBalancer(nil, []string{"a", "b", "c"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, want := range []string{"a", "b", "c", "a", "b"} {
+		if got := b.Next(nil); got != want {
+			t.Fatalf("第 %d 次选择 %s，期望 %s", i+1, got, want)
+		}
+	}
+
+	if _, err := NewBalancer(&config.LoadBalancerConfig{Strategy: "random"}, []string{"a"}); err == nil {
+		t.Fatal("不支持的策略应返回错误")
+	}
+	if _, err := NewBalancer(nil, nil); err == nil {
+		t.Fatal("后端为空时应返回错误")
+	}
+}
@@ -0,0 +1,118 @@
+package proxy
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"gateway-go/internal/config"
+)
+
+// ConnectionPool 上游连接池，按目标缓存 http.Transport 并在请求间复用
+type ConnectionPool struct {
+	settings   config.TransportConfig
+	transports map[string]*http.Transport
+	mu         sync.RWMutex
+}
+
+// NewConnectionPool 创建连接池
+func NewConnectionPool(settings config.TransportConfig) *ConnectionPool {
+	return &ConnectionPool{
+		settings:   settings,
+		transports: make(map[string]*http.Transport),
+	}
+}
+
+// GetTransport 获取路由目标对应的 Transport，相同目标和TLS配置的路由共享连接
+func (cp *ConnectionPool) GetTransport(route *config.RouteConfig) (http.RoundTripper, error) {
+	key := route.Target.URL
+	if route.Target.TLS != nil {
+		key = fmt.Sprintf("%s|%+v", route.Target.URL, *route.Target.TLS)
+	}
+
+	cp.mu.RLock()
+	transport, exists := cp.transports[key]
+	cp.mu.RUnlock()
+
+	if exists {
+		return transport, nil
+	}
+
+	cp.mu.Lock()
+	defer cp.mu.Unlock()
+
+	// 双重检查
+	if transport, exists = cp.transports[key]; exists {
+		return transport, nil
+	}
+
+	transport = newTransport(cp.settings)
+	if route.Target.TLS != nil {
+		tlsConfig, err := buildUpstreamTLSConfig(route.Target.TLS)
+		if err != nil {
+			return nil, fmt.Errorf("路由 %s 上游TLS配置错误: %w", route.Name, err)
+		}
+		transport.TLSClientConfig = tlsConfig
+	}
+
+	cp.transports[key] = transport
+	return transport, nil
+}
+
+// Reset 配置重载时清空缓存，旧 Transport 的空闲连接被关闭，进行中的请求不受影响
+func (cp *ConnectionPool) Reset(settings config.TransportConfig) {
+	cp.mu.Lock()
+	defer cp.mu.Unlock()
+
+	for _, transport := range cp.transports {
+		transport.CloseIdleConnections()
+	}
+	cp.settings = settings
+	cp.transports = make(map[string]*http.Transport)
+}
+
+// CloseIdleConnections 关闭所有缓存 Transport 的空闲连接
+func (cp *ConnectionPool) CloseIdleConnections() {
+	cp.mu.RLock()
+	defer cp.mu.RUnlock()
+
+	for _, transport := range cp.transports {
+		transport.CloseIdleConnections()
+	}
+}
+
+// newTransport 基于默认 Transport 应用连接池配置
+func newTransport(settings config.TransportConfig) *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	if settings.DialTimeout > 0 || settings.KeepAlive > 0 {
+		dialer := &net.Dialer{
+			Timeout:   30 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}
+		if settings.DialTimeout > 0 {
+			dialer.Timeout = settings.DialTimeout
+		}
+		if settings.KeepAlive > 0 {
+			dialer.KeepAlive = settings.KeepAlive
+		}
+		transport.DialContext = dialer.DialContext
+	}
+	if settings.MaxIdleConns > 0 {
+		transport.MaxIdleConns = settings.MaxIdleConns
+	}
+	if settings.MaxIdleConnsPerHost > 0 {
+		transport.MaxIdleConnsPerHost = settings.MaxIdleConnsPerHost
+	}
+	if settings.MaxConnsPerHost > 0 {
+		transport.MaxConnsPerHost = settings.MaxConnsPerHost
+	}
+	if settings.IdleConnTimeout > 0 {
+		transport.IdleConnTimeout = settings.IdleConnTimeout
+	}
+	transport.DisableKeepAlives = settings.DisableKeepAlives
+
+	return transport
+}
@@ -0,0 +1,79 @@
+package proxy
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httputil"
+	"net/url"
+	"testing"
+	"time"
+
+	"gateway-go/internal/config"
+)
+
+func TestNewTransportAppliesSettings(t *testing.T) {
+	transport := newTransport(config.TransportConfig{
+		MaxIdleConns:        10,
+		MaxIdleConnsPerHost: 5,
+		MaxConnsPerHost:     20,
+		IdleConnTimeout:     time.Minute,
+		DisableKeepAlives:   true,
+	})
+	if transport.MaxIdleConns != 10 || transport.MaxIdleConnsPerHost != 5 || transport.MaxConnsPerHost != 20 {
+		t.Fatalf("连接数配置未生效: %d/%d/%d", transport.MaxIdleConns, transport.MaxIdleConnsPerHost, transport.MaxConnsPerHost)
+	}
+	if transport.IdleConnTimeout != time.Minute || !transport.DisableKeepAlives {
+		t.Fatalf("空闲超时或 keep-alive 配置未生效")
+	}
+
+	// 零值使用默认 Transport 的配置
+	defaults := newTransport(config.TransportConfig{})
+	if defaults.MaxIdleConns != http.DefaultTransport.(*http.Transport).MaxIdleConns {
+		t.Fatalf("MaxIdleConns = %d，期望使用默认值", defaults.MaxIdleConns)
+	}
+}
+
+// benchmarkProxy 通过反向代理向本地上游发送请求，transport 返回每个请求使用的 Transport
+func benchmarkProxy(b *testing.B, transport func() (http.RoundTripper, func())) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "ok")
+	}))
+	defer upstream.Close()
+	target, _ := url.Parse(upstream.URL)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		rt, done := transport()
+		proxy := httputil.NewSingleHostReverseProxy(target)
+		proxy.Transport = rt
+		rec := httptest.NewRecorder()
+		proxy.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+		if rec.Code != http.StatusOK {
+			b.Fatalf("状态码 = %d", rec.Code)
+		}
+		done()
+	}
+}
+
+// BenchmarkProxyTransport 对比复用连接池 Transport 与每个请求新建 Transport 的分配次数
+func BenchmarkProxyTransport(b *testing.B) {
+	route := &config.RouteConfig{Name: "bench"}
+
+	b.Run("pooled", func(b *testing.B) {
+		pool := NewConnectionPool(config.TransportConfig{})
+		defer pool.CloseIdleConnections()
+		benchmarkProxy(b, func() (http.RoundTripper, func()) {
+			transport, _ := pool.GetTransport(route)
+			return transport, func() {}
+		})
+	})
+
+	b.Run("per_request", func(b *testing.B) {
+		benchmarkProxy(b, func() (http.RoundTripper, func()) {
+			transport := newTransport(config.TransportConfig{})
+			return transport, transport.CloseIdleConnections
+		})
+	})
+}
@@ -0,0 +1,142 @@
+package proxy
+
+import (
+	"fmt"
+	"hash/fnv"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultVirtualNodes 一致性哈希环上每个后端的默认虚拟节点数
+const defaultVirtualNodes = 160
+
+// consistentHashBalancer 一致性哈希负载均衡，相同键的请求转发到同一后端
+// 增减后端时只有落在变化节点上的键被重新分配
+type consistentHashBalancer struct {
+	// 按哈希值排序的虚拟节点
+	ring []ringNode
+	key  func(req *http.Request) string
+
+	// 各后端最近一次请求失败的时间，冷却时间内沿哈希环选择下一个后端
+	failures map[string]time.Time
+	mu       sync.RWMutex
+}
+
+// ringNode 哈希环上的虚拟节点
+type ringNode struct {
+	hash    uint64
+	backend string
+}
+
+// newConsistentHashBalancer 创建一致性哈希负载均衡器
+func newConsistentHashBalancer(backends []string, hashKey string, virtualNodes int) (*consistentHashBalancer, error) {
+	key, err := hashKeyFunc(hashKey)
+	if err != nil {
+		return nil, err
+	}
+	if virtualNodes <= 0 {
+		virtualNodes = defaultVirtualNodes
+	}
+
+	ring := make([]ringNode, 0, len(backends)*virtualNodes)
+	for _, backend := range backends {
+		for i := 0; i < virtualNodes; i++ {
+			ring = append(ring, ringNode{
+				hash:    hashKeyString(backend + "#" + strconv.Itoa(i)),
+				backend: backend,
+			})
+		}
+	}
+	sort.Slice(ring, func(i, j int) bool {
+		return ring[i].hash < ring[j].hash
+	})
+
+	return &consistentHashBalancer{
+		ring:     ring,
+		key:      key,
+		failures: make(map[string]time.Time),
+	}, nil
+}
+
+// hashKeyFunc 根据键来源配置返回提取哈希键的函数
+func hashKeyFunc(hashKey string) (func(req *http.Request) string, error) {
+	switch {
+	case hashKey == "" || hashKey == "client_ip":
+		return requestClientIP, nil
+	case hashKey == "path":
+		return func(req *http.Request) string { return req.URL.Path }, nil
+	case strings.HasPrefix(hashKey, "header:") && len(hashKey) > len("header:"):
+		name := strings.TrimPrefix(hashKey, "header:")
+		return func(req *http.Request) string { return req.Header.Get(name) }, nil
+	default:
+		return nil, fmt.Errorf("不支持的一致性哈希键: %s", hashKey)
+	}
+}
+
+// requestClientIP 返回客户端IP，优先使用 X-Forwarded-For 中的第一个地址
+func requestClientIP(req *http.Request) string {
+	if forwarded := req.Header.Get("X-Forwarded-For"); forwarded != "" {
+		ip, _, _ := strings.Cut(forwarded, ",")
+		return strings.TrimSpace(ip)
+	}
+	if host, _, err := net.SplitHostPort(req.RemoteAddr); err == nil {
+		return host
+	}
+	return req.RemoteAddr
+}
+
+// Next 选择哈希环上键所在位置之后的第一个后端，跳过最近请求失败的后端
+func (b *consistentHashBalancer) Next(req *http.Request) string {
+	key := b.key(req)
+	if key == "" {
+		// 请求缺少配置的键（如请求头）时按客户端IP分配
+		key = requestClientIP(req)
+	}
+	hash := hashKeyString(key)
+	start := sort.Search(len(b.ring), func(i int) bool {
+		return b.ring[i].hash >= hash
+	})
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for i := 0; i < len(b.ring); i++ {
+		node := b.ring[(start+i)%len(b.ring)]
+		failedAt, failed := b.failures[node.backend]
+		if !failed || time.Since(failedAt) > backendFailureCooldown {
+			return node.backend
+		}
+	}
+	// 全部后端都在冷却时间内，仍按哈希选择
+	return b.ring[start%len(b.ring)].backend
+}
+
+// Observe 记录后端请求失败时间，请求成功时清除
+func (b *consistentHashBalancer) Observe(backend string, latency time.Duration, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if err != nil {
+		b.failures[backend] = time.Now()
+	} else {
+		delete(b.failures, backend)
+	}
+}
+
+// hashKeyString 计算哈希环使用的哈希值
+func hashKeyString(s string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(s))
+	x := h.Sum64()
+
+	// FNV 对相近输入的高位区分度不足，混合后使虚拟节点在环上分布均匀
+	x ^= x >> 33
+	x *= 0xff51afd7ed558ccd
+	x ^= x >> 33
+	x *= 0xc4ceb9fe1a85ec53
+	x ^= x >> 33
+	return x
+}
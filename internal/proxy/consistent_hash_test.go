@@ -0,0 +1,137 @@
+package proxy
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// pathRequest 创建指定路径的请求
+func pathRequest(path string) *http.Request {
+	return httptest.NewRequest(http.MethodGet, path, nil)
+}
+
+// assignKeys 返回各键分配到的后端
+func assignKeys(b Balancer, keys int) map[string]string {
+	assigned := make(map[string]string, keys)
+	for i := 0; i < keys; i++ {
+		path := "/items/" + strconv.Itoa(i)
+		assigned[path] = b.Next(pathRequest(path))
+	}
+	return assigned
+}
+
+func TestConsistentHashRemapsOnlyRemovedBackend(t *testing.T) {
+	const keys = 10000
+	before, err := newConsistentHashBalancer([]string{"a", "b", "c", "d"}, "path", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	after, err := newConsistentHashBalancer([]string{"a", "b", "c"}, "path", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assignedBefore := assignKeys(before, keys)
+	assignedAfter := assignKeys(after, keys)
+	counts := make(map[string]int)
+	moved := 0
+	for key, backend := range assignedBefore {
+		counts[backend]++
+		if backend == "d" {
+			moved++
+			continue
+		}
+		// 未落在移除后端上的键保持不变
+		if assignedAfter[key] != backend {
+			t.Fatalf("键 %s 从 %s 重新分配到 %s，期望只重新分配移除的后端 d 上的键", key, backend, assignedAfter[key])
+		}
+	}
+
+	// 4 个后端各分到约 1/4 的键，只有 d 上的键被重新分配
+	for _, backend := range []string{"a", "b", "c", "d"} {
+		if counts[backend] < keys/4*8/10 || counts[backend] > keys/4*12/10 {
+			t.Fatalf("后端分配 %v 不均匀", counts)
+		}
+	}
+	if ratio := float64(moved) / keys; ratio > 0.3 {
+		t.Fatalf("移除 1/4 的后端后重新分配了 %.2f 的键", ratio)
+	}
+}
+
+func TestConsistentHashKeySources(t *testing.T) {
+	tests := []struct {
+		hashKey string
+		same    func() *http.Request
+	}{
+		{
+			hashKey: "path",
+			same:    func() *http.Request { return pathRequest("/users/1") },
+		},
+		{
+			hashKey: "header:X-Tenant",
+			same: func() *http.Request {
+				req := pathRequest("/" + strconv.FormatInt(time.Now().UnixNano(), 10))
+				req.Header.Set("X-Tenant", "acme")
+				return req
+			},
+		},
+		{
+			hashKey: "client_ip",
+			same: func() *http.Request {
+				req := pathRequest("/" + strconv.FormatInt(time.Now().UnixNano(), 10))
+				req.Header.Set("X-Forwarded-For", "203.0.113.7, 10.0.0.1")
+				return req
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.hashKey, func(t *testing.T) {
+			b, err := newConsistentHashBalancer([]string{"a", "b", "c", "d", "e"}, tt.hashKey, 50)
+			if err != nil {
+				t.Fatal(err)
+			}
+			first := b.Next(tt.same())
+			for i := 0; i < 20; i++ {
+				if got := b.Next(tt.same()); got != first {
+					t.Fatalf("相同的键选择了 %s，期望始终为 %s", got, first)
+				}
+			}
+		})
+	}
+
+	if _, err := newConsistentHashBalancer([]string{"a"}, "cookie:sid", 0); err == nil {
+		t.Fatal("不支持的哈希键应返回错误")
+	}
+}
+
+func TestConsistentHashSkipsFailedBackend(t *testing.T) {
+	b, err := newConsistentHashBalancer([]string{"a", "b", "c"}, "path", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assigned := assignKeys(b, 1000)
+
+	// 失败的后端在冷却时间内被跳过，其他后端的键不受影响
+	b.Observe("a", time.Millisecond, errors.New("connection refused"))
+	for key, backend := range assignKeys(b, 1000) {
+		if backend == "a" {
+			t.Fatalf("键 %s 仍分配到失败的后端 a", key)
+		}
+		if assigned[key] != "a" && assigned[key] != backend {
+			t.Fatalf("键 %s 从 %s 重新分配到 %s", key, assigned[key], backend)
+		}
+	}
+
+	// 请求成功后恢复原有分配
+	b.Observe("a", time.Millisecond, nil)
+	for key, backend := range assignKeys(b, 1000) {
+		if assigned[key] != backend {
+			t.Fatalf("后端恢复后键 %s 分配到 %s，期望 %s", key, backend, assigned[key])
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package proxy
+
+import (
+	"net/http"
+	"strings"
+)
+
+// 请求头大小写模式
+const (
+	HeaderCaseCanonical = "canonical"
+	HeaderCaseLower     = "lower"
+)
+
+// transportManagedHeaders 由 http.Transport 自行写出的请求头，改写键名会导致重复发送
+var transportManagedHeaders = map[string]bool{
+	"Host":              true,
+	"User-Agent":        true,
+	"Content-Length":    true,
+	"Transfer-Encoding": true,
+	"Trailer":           true,
+	"Accept-Encoding":   true,
+}
+
+// headerCaseTransport 按配置改写转发到上游的请求头名称大小写
+// HTTP/1.x 按请求头 map 中的键原样写出，HTTP/2 协议要求小写，改写不生效
+type headerCaseTransport struct {
+	transport http.RoundTripper
+	mode      string
+	mappings  map[string]string
+}
+
+// NewHeaderCaseTransport 创建改写请求头大小写的 Transport
+// mappings 的键不区分大小写，值为发送到上游的精确写法，优先于 mode
+func NewHeaderCaseTransport(transport http.RoundTripper, mode string, mappings map[string]string) http.RoundTripper {
+	canonical := make(map[string]string, len(mappings))
+	for name, exact := range mappings {
+		canonical[http.CanonicalHeaderKey(name)] = exact
+	}
+	return &headerCaseTransport{
+		transport: transport,
+		mode:      mode,
+		mappings:  canonical,
+	}
+}
+
+// RoundTrip 实现 http.RoundTripper，不修改原请求
+func (t *headerCaseTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	header := make(http.Header, len(req.Header))
+	for name, values := range req.Header {
+		key := name
+		switch exact, mapped := t.mappings[http.CanonicalHeaderKey(name)]; {
+		case transportManagedHeaders[name]:
+			// 由 Transport 写出，保持规范形式
+		case mapped:
+			key = exact
+		case t.mode == HeaderCaseLower:
+			key = strings.ToLower(name)
+		}
+		header[key] = append(header[key], values...)
+	}
+
+	out := new(http.Request)
+	*out = *req
+	out.Header = header
+	return t.transport.RoundTrip(out)
+}
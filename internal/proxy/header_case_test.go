@@ -0,0 +1,99 @@
+package proxy
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// rawHeaderUpstream 启动记录原始请求头行的上游，返回地址和接收请求头行的通道
+func rawHeaderUpstream(t *testing.T) (string, <-chan []string) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	lines := make(chan []string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		reader := bufio.NewReader(conn)
+		var headers []string
+		for {
+			line, err := reader.ReadString('\n')
+			line = strings.TrimRight(line, "\r\n")
+			if err != nil || line == "" {
+				break
+			}
+			headers = append(headers, line)
+		}
+		lines <- headers
+		io.WriteString(conn, "HTTP/1.1 200 OK\r\nContent-Length: 0\r\nConnection: close\r\n\r\n")
+	}()
+	return "http://" + ln.Addr().String(), lines
+}
+
+// sendWithHeaderCase 通过改写大小写的 Transport 发送请求，返回上游收到的原始请求头名称
+func sendWithHeaderCase(t *testing.T, mode string, mappings map[string]string) map[string]bool {
+	t.Helper()
+	url, lines := rawHeaderUpstream(t)
+	transport := NewHeaderCaseTransport(&http.Transport{}, mode, mappings)
+
+	req, _ := http.NewRequest(http.MethodGet, url+"/", nil)
+	req.Header.Set("X-Api-Key", "secret")
+	req.Header.Set("X-Request-Id", "1")
+	resp, err := transport.RoundTrip(req)
+	if err != nil {
+		t.Fatalf("请求失败: %v", err)
+	}
+	resp.Body.Close()
+
+	// 原请求不被修改
+	if _, ok := req.Header["X-Api-Key"]; !ok {
+		t.Fatal("原请求的请求头被修改")
+	}
+
+	names := make(map[string]bool)
+	for _, line := range (<-lines)[1:] {
+		name, _, _ := strings.Cut(line, ":")
+		names[name] = true
+	}
+	return names
+}
+
+func TestHeaderCaseReachesUpstream(t *testing.T) {
+	tests := []struct {
+		name     string
+		mode     string
+		mappings map[string]string
+		want     []string
+		notWant  []string
+	}{
+		{"默认规范形式", "", nil, []string{"X-Api-Key", "X-Request-Id", "User-Agent"}, []string{"x-api-key"}},
+		{"全部小写", HeaderCaseLower, nil, []string{"x-api-key", "x-request-id", "User-Agent"}, []string{"X-Api-Key"}},
+		{"精确映射优先于模式", HeaderCaseLower, map[string]string{"x-api-key": "X-API-KEY"}, []string{"X-API-KEY", "x-request-id"}, []string{"x-api-key"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			names := sendWithHeaderCase(t, tt.mode, tt.mappings)
+			for _, name := range tt.want {
+				if !names[name] {
+					t.Fatalf("上游未收到请求头 %s，实际: %v", name, names)
+				}
+			}
+			for _, name := range tt.notWant {
+				if names[name] {
+					t.Fatalf("上游不应收到请求头 %s，实际: %v", name, names)
+				}
+			}
+		})
+	}
+}
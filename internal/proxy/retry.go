@@ -0,0 +1,114 @@
+package proxy
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// Attempt 一次上游请求尝试的结果
+type Attempt struct {
+	Backend  string        `json:"backend"`
+	Duration time.Duration `json:"duration"`
+	Error    string        `json:"error,omitempty"`
+}
+
+// RetryTransport 上游连接失败时按负载均衡切换后端重试，每个请求使用独立实例
+// 只有请求体可重放（无请求体或设置了 GetBody）时才会重试，上游返回的任何响应（包括 5xx）都不重试
+type RetryTransport struct {
+	transport http.RoundTripper
+	balancer  Balancer
+	backend   string
+	retries   int
+	attempts  []Attempt
+	// ClientError 返回客户端侧错误（如读取请求体失败），非空时不重试且不计入后端延迟
+	ClientError func() error
+}
+
+// NewRetryTransport 创建重试 Transport，backend 为首次请求的后端，retries 为最大重试次数（不含首次请求）
+func NewRetryTransport(transport http.RoundTripper, balancer Balancer, backend string, retries int) *RetryTransport {
+	return &RetryTransport{
+		transport: transport,
+		balancer:  balancer,
+		backend:   backend,
+		retries:   retries,
+	}
+}
+
+// RoundTrip 实现 http.RoundTripper
+func (t *RetryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		start := time.Now()
+		resp, err := t.transport.RoundTrip(req)
+		latency := time.Since(start)
+
+		clientFailed := req.Context().Err() != nil || t.clientError() != nil
+		// 客户端取消或请求体读取失败不计入后端延迟
+		if err == nil || !clientFailed {
+			t.balancer.Observe(t.backend, latency, err)
+		}
+
+		record := Attempt{Backend: t.backend, Duration: latency}
+		if err != nil {
+			record.Error = err.Error()
+		}
+		t.attempts = append(t.attempts, record)
+
+		if err == nil || clientFailed || attempt >= t.retries || !canReplay(req) {
+			return resp, err
+		}
+
+		// 切换后端重试
+		next, retryReq, buildErr := t.nextRequest(req)
+		if buildErr != nil {
+			return nil, buildErr
+		}
+		t.backend = next
+		req = retryReq
+	}
+}
+
+// Attempts 返回所有请求尝试
+func (t *RetryTransport) Attempts() []Attempt {
+	return t.attempts
+}
+
+// Backend 返回最后一次请求的后端
+func (t *RetryTransport) Backend() string {
+	return t.backend
+}
+
+// clientError 返回客户端侧错误
+func (t *RetryTransport) clientError() error {
+	if t.ClientError == nil {
+		return nil
+	}
+	return t.ClientError()
+}
+
+// nextRequest 选择下一个后端并构造重放请求
+func (t *RetryTransport) nextRequest(req *http.Request) (string, *http.Request, error) {
+	backend := t.balancer.Next(req)
+	target, err := url.Parse(backend)
+	if err != nil {
+		return "", nil, fmt.Errorf("无效的目标URL: %w", err)
+	}
+
+	retryReq := req.Clone(req.Context())
+	retryReq.URL.Scheme = target.Scheme
+	retryReq.URL.Host = target.Host
+	if req.Body != nil && req.Body != http.NoBody {
+		body, err := req.GetBody()
+		if err != nil {
+			return "", nil, fmt.Errorf("重放请求体失败: %w", err)
+		}
+		retryReq.Body = body
+	}
+	return backend, retryReq, nil
+}
+
+// canReplay 检查请求体是否可以重放
+func canReplay(req *http.Request) bool {
+	return req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+}
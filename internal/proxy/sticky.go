@@ -0,0 +1,156 @@
+package proxy
+
+import (
+	"hash/fnv"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"gateway-go/internal/config"
+)
+
+const (
+	// defaultStickyCookie 会话保持 cookie 的默认名称
+	defaultStickyCookie = "gateway_sticky"
+	// backendFailureCooldown 后端请求失败后不再固定到该后端的时间
+	backendFailureCooldown = 10 * time.Second
+)
+
+// AffinityBalancer 支持会话保持的负载均衡器
+type AffinityBalancer interface {
+	Balancer
+	// Cookie 返回需要写入响应的会话保持 cookie，请求已通过 cookie 固定到该后端时返回 nil
+	Cookie(req *http.Request, backend string) *http.Cookie
+}
+
+// stickyBalancer 会话保持负载均衡，按 cookie 或请求头将客户端固定到同一后端
+// 固定的后端最近请求失败时回退到底层负载均衡器
+type stickyBalancer struct {
+	Balancer
+	backends []string
+	// cookie 值到后端地址的映射，cookie 中不暴露后端地址
+	ids    map[string]string
+	cookie string
+	header string
+	ttl    time.Duration
+
+	// 各后端最近一次请求失败的时间
+	failures map[string]time.Time
+	mu       sync.RWMutex
+}
+
+// newStickyBalancer 在底层负载均衡器之上创建会话保持负载均衡器
+func newStickyBalancer(balancer Balancer, backends []string, cfg *config.StickyConfig) *stickyBalancer {
+	b := &stickyBalancer{
+		Balancer: balancer,
+		backends: backends,
+		ids:      make(map[string]string, len(backends)),
+		cookie:   cfg.Cookie,
+		header:   cfg.Header,
+		ttl:      cfg.TTL,
+		failures: make(map[string]time.Time),
+	}
+	if b.cookie == "" {
+		b.cookie = defaultStickyCookie
+	}
+	for _, backend := range backends {
+		b.ids[backendID(backend)] = backend
+	}
+	return b
+}
+
+// Next 优先选择请求固定的后端，未固定或固定的后端不可用时由底层负载均衡器选择
+func (b *stickyBalancer) Next(req *http.Request) string {
+	if b.header != "" {
+		if key := req.Header.Get(b.header); key != "" {
+			if backend := b.hashBackend(key); backend != "" {
+				return backend
+			}
+		}
+		return b.Balancer.Next(req)
+	}
+
+	if backend, ok := b.pinned(req); ok && b.healthy(backend) {
+		return backend
+	}
+	return b.Balancer.Next(req)
+}
+
+// Observe 记录后端请求失败时间并交给底层负载均衡器
+func (b *stickyBalancer) Observe(backend string, latency time.Duration, err error) {
+	b.mu.Lock()
+	if err != nil {
+		b.failures[backend] = time.Now()
+	} else {
+		delete(b.failures, backend)
+	}
+	b.mu.Unlock()
+
+	b.Balancer.Observe(backend, latency, err)
+}
+
+// Cookie 请求未固定到所选后端时返回新的会话保持 cookie，按请求头固定时不使用 cookie
+func (b *stickyBalancer) Cookie(req *http.Request, backend string) *http.Cookie {
+	if b.header != "" {
+		return nil
+	}
+	if pinned, ok := b.pinned(req); ok && pinned == backend {
+		return nil
+	}
+
+	cookie := &http.Cookie{
+		Name:     b.cookie,
+		Value:    backendID(backend),
+		Path:     "/",
+		HttpOnly: true,
+	}
+	if b.ttl > 0 {
+		cookie.MaxAge = int(b.ttl / time.Second)
+	}
+	return cookie
+}
+
+// pinned 返回 cookie 固定的后端，后端已不在路由中时视为未固定
+func (b *stickyBalancer) pinned(req *http.Request) (string, bool) {
+	cookie, err := req.Cookie(b.cookie)
+	if err != nil {
+		return "", false
+	}
+	backend, ok := b.ids[cookie.Value]
+	return backend, ok
+}
+
+// healthy 检查后端在失败冷却时间内是否没有请求失败
+func (b *stickyBalancer) healthy(backend string) bool {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	failedAt, failed := b.failures[backend]
+	return !failed || time.Since(failedAt) > backendFailureCooldown
+}
+
+// hashBackend 按最高随机权重哈希为会话键选择可用后端，后端增减时只影响少量会话
+func (b *stickyBalancer) hashBackend(key string) string {
+	var best string
+	var bestScore uint64
+	for _, backend := range b.backends {
+		if !b.healthy(backend) {
+			continue
+		}
+		h := fnv.New64a()
+		h.Write([]byte(key))
+		h.Write([]byte{0})
+		h.Write([]byte(backend))
+		if score := h.Sum64(); best == "" || score > bestScore {
+			best, bestScore = backend, score
+		}
+	}
+	return best
+}
+
+// backendID 生成后端在 cookie 中的标识
+func backendID(backend string) string {
+	h := fnv.New64a()
+	h.Write([]byte(backend))
+	return strconv.FormatUint(h.Sum64(), 36)
+}
@@ -0,0 +1,124 @@
+package proxy
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"gateway-go/internal/config"
+)
+
+// newSticky 在轮询负载均衡器之上创建会话保持负载均衡器
+func newSticky(t *testing.T, backends []string, cfg *config.StickyConfig) *stickyBalancer {
+	t.Helper()
+	balancer, err := NewBalancer(nil, backends)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return newStickyBalancer(balancer, backends, cfg)
+}
+
+// withCookie 创建携带 cookie 的请求，cookie 为 nil 时不携带
+func withCookie(cookie *http.Cookie) *http.Request {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	if cookie != nil {
+		req.AddCookie(cookie)
+	}
+	return req
+}
+
+func TestStickyCookiePinsBackend(t *testing.T) {
+	b := newSticky(t, []string{"a", "b", "c"}, &config.StickyConfig{Cookie: "sid", TTL: time.Hour})
+
+	// 首次请求由底层负载均衡器选择后端并下发 cookie
+	backend := b.Next(withCookie(nil))
+	cookie := b.Cookie(withCookie(nil), backend)
+	if cookie == nil || cookie.Name != "sid" || cookie.MaxAge != 3600 || !cookie.HttpOnly || cookie.Value == backend {
+		t.Fatalf("会话保持 cookie = %+v，期望 sid 且不暴露后端地址", cookie)
+	}
+
+	// 携带 cookie 的请求始终选择同一后端，不再下发 cookie
+	for i := 0; i < 10; i++ {
+		req := withCookie(cookie)
+		if got := b.Next(req); got != backend {
+			t.Fatalf("第 %d 次请求选择 %s，期望固定到 %s", i+1, got, backend)
+		}
+		if b.Cookie(req, backend) != nil {
+			t.Fatal("已固定到所选后端时不应重复下发 cookie")
+		}
+	}
+
+	// 没有 cookie 的请求照常轮询
+	seen := make(map[string]bool)
+	for i := 0; i < 3; i++ {
+		seen[b.Next(withCookie(nil))] = true
+	}
+	if len(seen) != 3 {
+		t.Fatalf("没有 cookie 的请求选择了 %v，期望轮询全部后端", seen)
+	}
+}
+
+func TestStickyFallsBackWhenPinnedBackendFails(t *testing.T) {
+	b := newSticky(t, []string{"a", "b"}, &config.StickyConfig{})
+	cookie := b.Cookie(withCookie(nil), "a")
+	if cookie.Name != defaultStickyCookie || cookie.MaxAge != 0 {
+		t.Fatalf("默认 cookie = %+v，期望 %s 的会话 cookie", cookie, defaultStickyCookie)
+	}
+
+	// 固定的后端请求失败后回退到底层负载均衡器，并改为固定到新的后端
+	b.Observe("a", time.Millisecond, errors.New("connection refused"))
+	req := withCookie(cookie)
+	backend := b.Next(req)
+	if backend == "a" {
+		// 底层轮询可能再次选中失败的后端，下一次必然选择 b
+		backend = b.Next(req)
+	}
+	if backend != "b" {
+		t.Fatalf("固定的后端失败后选择 %s，期望回退到 b", backend)
+	}
+	if next := b.Cookie(req, backend); next == nil || next.Value != backendID("b") {
+		t.Fatalf("回退到 b 时 cookie = %+v，期望改为固定到 b", next)
+	}
+
+	// 后端恢复后继续使用原有的固定关系
+	b.Observe("a", time.Millisecond, nil)
+	if got := b.Next(withCookie(cookie)); got != "a" {
+		t.Fatalf("后端恢复后选择 %s，期望 a", got)
+	}
+
+	// 后端已不在路由中时视为未固定
+	stale := &http.Cookie{Name: defaultStickyCookie, Value: backendID("removed")}
+	if _, ok := b.pinned(withCookie(stale)); ok {
+		t.Fatal("已移除后端的 cookie 不应固定")
+	}
+}
+
+func TestStickyHeaderPinsBackend(t *testing.T) {
+	b := newSticky(t, []string{"a", "b", "c"}, &config.StickyConfig{Header: "X-Session-ID"})
+
+	sessions := make(map[string]string)
+	for i := 0; i < 3; i++ {
+		for _, session := range []string{"s1", "s2", "s3", "s4"} {
+			req := withCookie(nil)
+			req.Header.Set("X-Session-ID", session)
+			backend := b.Next(req)
+			if pinned, ok := sessions[session]; ok && pinned != backend {
+				t.Fatalf("会话 %s 选择 %s，期望固定到 %s", session, backend, pinned)
+			}
+			sessions[session] = backend
+			if b.Cookie(req, backend) != nil {
+				t.Fatal("按请求头固定时不应下发 cookie")
+			}
+		}
+	}
+
+	// 固定的后端不可用时改选其他后端
+	b.Observe(sessions["s1"], time.Millisecond, errors.New("timeout"))
+	req := withCookie(nil)
+	req.Header.Set("X-Session-ID", "s1")
+	if got := b.Next(req); got == sessions["s1"] {
+		t.Fatalf("后端 %s 失败后会话 s1 仍选择该后端", got)
+	}
+}
@@ -0,0 +1,42 @@
+package proxy
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+
+	"gateway-go/internal/config"
+)
+
+// buildUpstreamTLSConfig 根据路由的 target.tls 配置构建上游 TLS 配置
+func buildUpstreamTLSConfig(cfg *config.UpstreamTLSConfig) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		ServerName:         cfg.ServerName,
+		InsecureSkipVerify: cfg.InsecureSkipVerify,
+	}
+
+	// 自定义CA证书
+	if cfg.CAFile != "" {
+		caPEM, err := os.ReadFile(cfg.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("读取上游CA证书失败: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caPEM) {
+			return nil, fmt.Errorf("解析上游CA证书失败: %s", cfg.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	// 客户端证书（mTLS）
+	if cfg.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("加载客户端证书失败: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConfig, nil
+}
@@ -0,0 +1,181 @@
+package proxy
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gateway-go/internal/config"
+)
+
+// writePEM 将 PEM 块写入临时目录下的文件并返回路径
+func writePEM(t *testing.T, name, blockType string, der []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// writeClientCert 生成自签名客户端证书，返回证书、私钥文件路径和证书
+func writeClientCert(t *testing.T) (string, string, *x509.Certificate) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "gateway-client"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return writePEM(t, "client.pem", "CERTIFICATE", der), writePEM(t, "client-key.pem", "EC PRIVATE KEY", keyDER), cert
+}
+
+// upstreamCA 将 httptest TLS 服务器的证书写为 CA 文件
+func upstreamCA(t *testing.T, upstream *httptest.Server) string {
+	t.Helper()
+	return writePEM(t, "ca.pem", "CERTIFICATE", upstream.Certificate().Raw)
+}
+
+// roundTrip 通过连接池为路由获取 Transport 并发送请求，返回响应体
+func roundTrip(pool *ConnectionPool, route *config.RouteConfig) (string, error) {
+	transport, err := pool.GetTransport(route)
+	if err != nil {
+		return "", err
+	}
+	req, _ := http.NewRequest(http.MethodGet, route.Target.URL, nil)
+	resp, err := transport.RoundTrip(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	return string(body), err
+}
+
+func TestUpstreamTLSWithCustomCA(t *testing.T) {
+	upstream := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "ok")
+	}))
+	defer upstream.Close()
+	caFile := upstreamCA(t, upstream)
+
+	tests := []struct {
+		name    string
+		tls     *config.UpstreamTLSConfig
+		wantErr bool
+	}{
+		{"未配置CA时拒绝未知证书", nil, true},
+		{"自定义CA", &config.UpstreamTLSConfig{CAFile: caFile}, false},
+		{"跳过证书校验", &config.UpstreamTLSConfig{InsecureSkipVerify: true}, false},
+		// httptest 证书包含 example.com
+		{"SNI 覆盖", &config.UpstreamTLSConfig{CAFile: caFile, ServerName: "example.com"}, false},
+		{"SNI 与证书不符", &config.UpstreamTLSConfig{CAFile: caFile, ServerName: "other.test"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pool := NewConnectionPool(config.TransportConfig{})
+			route := &config.RouteConfig{Name: "tls", Target: config.TargetConfig{URL: upstream.URL, TLS: tt.tls}}
+			body, err := roundTrip(pool, route)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("请求应因证书校验失败")
+				}
+				return
+			}
+			if err != nil || body != "ok" {
+				t.Fatalf("请求结果 = %q, %v，期望 ok", body, err)
+			}
+		})
+	}
+}
+
+func TestUpstreamMutualTLS(t *testing.T) {
+	certFile, keyFile, clientCert := writeClientCert(t)
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(clientCert)
+
+	upstream := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, r.TLS.PeerCertificates[0].Subject.CommonName)
+	}))
+	upstream.TLS = &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: clientCAs}
+	upstream.StartTLS()
+	defer upstream.Close()
+	caFile := upstreamCA(t, upstream)
+
+	pool := NewConnectionPool(config.TransportConfig{})
+	withoutCert := &config.RouteConfig{Name: "no-cert", Target: config.TargetConfig{URL: upstream.URL, TLS: &config.UpstreamTLSConfig{CAFile: caFile}}}
+	if _, err := roundTrip(pool, withoutCert); err == nil {
+		t.Fatal("未配置客户端证书时请求应失败")
+	}
+
+	withCert := &config.RouteConfig{Name: "mtls", Target: config.TargetConfig{URL: upstream.URL, TLS: &config.UpstreamTLSConfig{
+		CAFile:   caFile,
+		CertFile: certFile,
+		KeyFile:  keyFile,
+	}}}
+	body, err := roundTrip(pool, withCert)
+	if err != nil || body != "gateway-client" {
+		t.Fatalf("mTLS 请求结果 = %q, %v，期望 gateway-client", body, err)
+	}
+}
+
+func TestUpstreamTLSConfigErrors(t *testing.T) {
+	pool := NewConnectionPool(config.TransportConfig{})
+	route := &config.RouteConfig{Name: "bad", Target: config.TargetConfig{URL: "https://127.0.0.1", TLS: &config.UpstreamTLSConfig{CAFile: "/nonexistent/ca.pem"}}}
+	if _, err := pool.GetTransport(route); err == nil {
+		t.Fatal("CA 文件不存在时应返回错误")
+	}
+}
+
+func TestConnectionPoolReusesTransport(t *testing.T) {
+	pool := NewConnectionPool(config.TransportConfig{})
+	tlsConfig := &config.UpstreamTLSConfig{InsecureSkipVerify: true}
+	a := &config.RouteConfig{Name: "a", Target: config.TargetConfig{URL: "https://127.0.0.1:8443", TLS: tlsConfig}}
+	b := &config.RouteConfig{Name: "b", Target: config.TargetConfig{URL: "https://127.0.0.1:8443", TLS: &config.UpstreamTLSConfig{InsecureSkipVerify: true}}}
+	c := &config.RouteConfig{Name: "c", Target: config.TargetConfig{URL: "https://127.0.0.1:8443"}}
+
+	ta, _ := pool.GetTransport(a)
+	tb, _ := pool.GetTransport(b)
+	tc, _ := pool.GetTransport(c)
+	if ta != tb {
+		t.Fatal("目标和TLS配置相同的路由应共享 Transport")
+	}
+	if ta == tc {
+		t.Fatal("TLS配置不同的路由不应共享 Transport")
+	}
+
+	pool.Reset(config.TransportConfig{})
+	if again, _ := pool.GetTransport(a); again == ta {
+		t.Fatal("Reset 后应重建 Transport")
+	}
+}
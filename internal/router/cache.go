@@ -1,49 +1,87 @@
 package router
 
 import (
+	"hash/maphash"
 	"sync"
+
+	"gateway-go/internal/config"
 )
 
+// routeCacheShards 路由缓存分片数，请求路径按哈希分散到各分片，减少高并发下的锁竞争
+const routeCacheShards = 16
+
 // RouteCache 路由缓存
 // 采用简单的LRU策略
-// key为请求路径，value为路由定义
+// key为请求路径，value为路由配置
 // 生产环境可用更高效的LRU库替换
 
 type RouteCache struct {
-	cache map[string]*RouteDefinition
+	shards [routeCacheShards]routeCacheShard
+	seed   maphash.Seed
+	// 每个分片的容量，总容量不超过创建时指定的大小（向上取整到分片数的整数倍）
+	shardSize int
+}
+
+// routeCacheShard 路由缓存分片，每个分片使用独立的锁
+type routeCacheShard struct {
+	cache map[string]*config.RouteConfig
 	mu    sync.RWMutex
-	size  int
 }
 
 // NewRouteCache 创建路由缓存
 func NewRouteCache(size int) *RouteCache {
-	return &RouteCache{
-		cache: make(map[string]*RouteDefinition, size),
-		size:  size,
+	shardSize := (size + routeCacheShards - 1) / routeCacheShards
+	if shardSize < 1 {
+		shardSize = 1
+	}
+	rc := &RouteCache{
+		seed:      maphash.MakeSeed(),
+		shardSize: shardSize,
+	}
+	for i := range rc.shards {
+		rc.shards[i].cache = make(map[string]*config.RouteConfig, shardSize)
 	}
+	return rc
+}
+
+// shard 返回缓存键所在的分片
+func (rc *RouteCache) shard(key string) *routeCacheShard {
+	return &rc.shards[maphash.String(rc.seed, key)%routeCacheShards]
 }
 
 // Get 获取缓存
-func (rc *RouteCache) Get(key string) (*RouteDefinition, bool) {
-	rc.mu.RLock()
-	defer rc.mu.RUnlock()
+func (rc *RouteCache) Get(key string) (*config.RouteConfig, bool) {
+	shard := rc.shard(key)
+	shard.mu.RLock()
+	defer shard.mu.RUnlock()
 
-	route, exists := rc.cache[key]
+	route, exists := shard.cache[key]
 	return route, exists
 }
 
 // Set 设置缓存
-func (rc *RouteCache) Set(key string, route *RouteDefinition) {
-	rc.mu.Lock()
-	defer rc.mu.Unlock()
-
-	// 简单LRU：超出容量时删除第一个
-	if len(rc.cache) >= rc.size {
-		for k := range rc.cache {
-			delete(rc.cache, k)
+func (rc *RouteCache) Set(key string, route *config.RouteConfig) {
+	shard := rc.shard(key)
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	// 简单LRU：分片超出容量时删除其中一个
+	if _, exists := shard.cache[key]; !exists && len(shard.cache) >= rc.shardSize {
+		for k := range shard.cache {
+			delete(shard.cache, k)
 			break
 		}
 	}
 
-	rc.cache[key] = route
+	shard.cache[key] = route
+}
+
+// Clear 清空缓存（路由变更后调用）
+func (rc *RouteCache) Clear() {
+	for i := range rc.shards {
+		shard := &rc.shards[i]
+		shard.mu.Lock()
+		shard.cache = make(map[string]*config.RouteConfig, rc.shardSize)
+		shard.mu.Unlock()
+	}
 }
@@ -0,0 +1,121 @@
+package router
+
+import (
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"gateway-go/internal/config"
+)
+
+// cachedRoutes 返回缓存中的路由总数
+func cachedRoutes(rc *RouteCache) int {
+	total := 0
+	for i := range rc.shards {
+		rc.shards[i].mu.RLock()
+		total += len(rc.shards[i].cache)
+		rc.shards[i].mu.RUnlock()
+	}
+	return total
+}
+
+func TestRouteCacheBounded(t *testing.T) {
+	rc := NewRouteCache(64)
+	route := &config.RouteConfig{Name: "orders"}
+
+	// 并发写入远多于容量的路径，缓存总数不超过容量
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				rc.Set("/orders/"+strconv.Itoa(g)+"/"+strconv.Itoa(i), route)
+			}
+		}(g)
+	}
+	wg.Wait()
+	if n := cachedRoutes(rc); n > 64 || n == 0 {
+		t.Fatalf("缓存路由数 = %d，期望不超过容量 64", n)
+	}
+
+	// 覆盖已缓存的路径不淘汰其他路径
+	rc.Clear()
+	rc.Set("/a", route)
+	rc.Set("/a", &config.RouteConfig{Name: "users"})
+	if got, ok := rc.Get("/a"); !ok || got.Name != "users" || cachedRoutes(rc) != 1 {
+		t.Fatalf("覆盖后缓存 = %v %v，共 %d 条", got, ok, cachedRoutes(rc))
+	}
+	rc.Clear()
+	if _, ok := rc.Get("/a"); ok || cachedRoutes(rc) != 0 {
+		t.Fatal("清空后缓存不为空")
+	}
+}
+
+func TestRouteCacheSmallSize(t *testing.T) {
+	// 容量小于分片数时每个分片至少保留一条
+	rc := NewRouteCache(0)
+	rc.Set("/a", &config.RouteConfig{Name: "a"})
+	if _, ok := rc.Get("/a"); !ok {
+		t.Fatal("容量为 0 时缓存不可用")
+	}
+}
+
+// singleLockRouteCache 使用单个锁保护全部路由，作为分片前实现的基准
+type singleLockRouteCache struct {
+	cache map[string]*config.RouteConfig
+	mu    sync.RWMutex
+}
+
+func (rc *singleLockRouteCache) Get(key string) (*config.RouteConfig, bool) {
+	rc.mu.RLock()
+	defer rc.mu.RUnlock()
+	route, exists := rc.cache[key]
+	return route, exists
+}
+
+func (rc *singleLockRouteCache) Set(key string, route *config.RouteConfig) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.cache[key] = route
+}
+
+// benchmarkRouteCache 并发查找路由缓存，每 100 次查找中有 1 次写入，模拟缓存命中为主的请求路径
+func benchmarkRouteCache(b *testing.B, get func(string) (*config.RouteConfig, bool), set func(string, *config.RouteConfig)) {
+	paths := make([]string, 512)
+	route := &config.RouteConfig{Name: "orders"}
+	for i := range paths {
+		paths[i] = "/api/orders/" + strconv.Itoa(i)
+		set(paths[i], route)
+	}
+	var seq atomic.Int64
+
+	b.ReportAllocs()
+	b.SetParallelism(8)
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		i := int(seq.Add(1)) * 7919
+		for pb.Next() {
+			i++
+			path := paths[i%len(paths)]
+			if i%100 == 0 {
+				set(path, route)
+				continue
+			}
+			get(path)
+		}
+	})
+}
+
+// BenchmarkRouteCacheParallel 对比高并发下分片与单锁路由缓存的吞吐，需在多核下运行（如 -cpu 8,16）才能体现锁竞争
+func BenchmarkRouteCacheParallel(b *testing.B) {
+	b.Run("single", func(b *testing.B) {
+		rc := &singleLockRouteCache{cache: make(map[string]*config.RouteConfig)}
+		benchmarkRouteCache(b, rc.Get, rc.Set)
+	})
+	b.Run("sharded", func(b *testing.B) {
+		rc := NewRouteCache(1024)
+		benchmarkRouteCache(b, rc.Get, rc.Set)
+	})
+}
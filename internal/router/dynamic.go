@@ -0,0 +1,114 @@
+package router
+
+import (
+	"fmt"
+	"sort"
+
+	"gateway-go/internal/config"
+)
+
+// sortRoutes 复制路由表、预编译路径匹配规则并按优先级从高到低排序，优先级相同时保持原顺序
+func sortRoutes(routes []config.RouteConfig) ([]config.RouteConfig, error) {
+	sorted := make([]config.RouteConfig, len(routes))
+	copy(sorted, routes)
+	for i := range sorted {
+		if err := sorted[i].Match.Compile(); err != nil {
+			return nil, fmt.Errorf("路由 %s: 无效的路径匹配规则 %s: %v", sorted[i].Name, sorted[i].Match.Path, err)
+		}
+	}
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Match.Priority > sorted[j].Match.Priority
+	})
+	return sorted, nil
+}
+
+// Routes 返回按优先级排序的路由表快照，调用方不得修改
+func (m *Manager) Routes() []config.RouteConfig {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.routes
+}
+
+// GetRoute 按名称查找路由
+func (m *Manager) GetRoute(name string) (config.RouteConfig, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, route := range m.routes {
+		if route.Name == name {
+			return route, true
+		}
+	}
+	return config.RouteConfig{}, false
+}
+
+// AddRoute 动态添加路由，写时复制替换路由表，无需重建引擎
+func (m *Manager) AddRoute(route config.RouteConfig) error {
+	if err := config.ValidateRouteConfig(&route); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.indexOf(route.Name) >= 0 {
+		return fmt.Errorf("路由 %s 已存在", route.Name)
+	}
+	routes, err := sortRoutes(append(append([]config.RouteConfig(nil), m.routes...), route))
+	if err != nil {
+		return err
+	}
+	m.routes = routes
+	return nil
+}
+
+// UpdateRoute 动态更新路由，路由名称不可修改
+func (m *Manager) UpdateRoute(name string, route config.RouteConfig) error {
+	route.Name = name
+	if err := config.ValidateRouteConfig(&route); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	i := m.indexOf(name)
+	if i < 0 {
+		return fmt.Errorf("路由 %s 不存在", name)
+	}
+	routes := append([]config.RouteConfig(nil), m.routes...)
+	routes[i] = route
+	sorted, err := sortRoutes(routes)
+	if err != nil {
+		return err
+	}
+	m.routes = sorted
+	return nil
+}
+
+// DeleteRoute 动态删除路由
+func (m *Manager) DeleteRoute(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	i := m.indexOf(name)
+	if i < 0 {
+		return fmt.Errorf("路由 %s 不存在", name)
+	}
+
+	routes := make([]config.RouteConfig, 0, len(m.routes)-1)
+	routes = append(routes, m.routes[:i]...)
+	routes = append(routes, m.routes[i+1:]...)
+	m.routes = routes
+	return nil
+}
+
+// indexOf 返回路由在路由表中的位置，不存在时返回 -1，调用方需持有锁
+func (m *Manager) indexOf(name string) int {
+	for i, route := range m.routes {
+		if route.Name == name {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,55 @@
+package router
+
+import (
+	"hash/fnv"
+
+	"gateway-go/internal/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+// splitBucketKey 返回流量分配的分桶依据：依次使用请求ID、用户ID和客户端IP
+func splitBucketKey(c *gin.Context) string {
+	if key := c.GetHeader("X-Request-ID"); key != "" {
+		return key
+	}
+	if key := c.GetHeader("X-User-ID"); key != "" {
+		return key
+	}
+	return c.ClientIP()
+}
+
+// splitPosition 将分桶依据均匀映射到 [0, 1) 区间，相同的分桶依据始终得到相同的位置
+func splitPosition(key string) float64 {
+	// 取哈希值的高 53 位，保证转换为 float64 时不丢失精度
+	return float64(hashString(key)>>11) / (1 << 53)
+}
+
+// SelectSplitTarget 按权重为请求选择流量分配目标，相同分桶依据的请求始终分配到同一目标
+func SelectSplitTarget(c *gin.Context, splits []config.TrafficSplitConfig) string {
+	position := splitPosition(splitBucketKey(c)) * 100
+	cumulative := 0
+	for _, split := range splits {
+		cumulative += split.Weight
+		if position < float64(cumulative) {
+			return split.Target
+		}
+	}
+	// 权重之和在配置验证时保证为 100，此处仅作兜底
+	return splits[len(splits)-1].Target
+}
+
+// hashString 计算字符串哈希值：FNV-1a 后进行位混合，使相近的输入（如连续的用户ID）得到不相关的结果
+func hashString(s string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(s))
+	x := h.Sum64()
+
+	// murmur3 fmix64
+	x ^= x >> 33
+	x *= 0xff51afd7ed558ccd
+	x ^= x >> 33
+	x *= 0xc4ceb9fe1a85ec53
+	x ^= x >> 33
+	return x
+}
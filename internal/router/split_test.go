@@ -0,0 +1,140 @@
+package router
+
+import (
+	"math"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"gateway-go/internal/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+// splitContext 创建带有指定请求头的请求上下文
+func splitContext(remoteAddr string, headers map[string]string) *gin.Context {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest("GET", "/", nil)
+	c.Request.RemoteAddr = remoteAddr
+	for key, value := range headers {
+		c.Request.Header.Set(key, value)
+	}
+	return c
+}
+
+// canarySplits 按 90/8/2 分配到 v1、v2、v3
+var canarySplits = []config.TrafficSplitConfig{
+	{Target: "http://v1:8080", Weight: 90},
+	{Target: "http://v2:8080", Weight: 8},
+	{Target: "http://v3:8080", Weight: 2},
+}
+
+func TestSelectSplitTargetDistribution(t *testing.T) {
+	const samples = 100000
+	counts := make(map[string]int)
+	for i := 0; i < samples; i++ {
+		c := splitContext("10.0.0.1:1234", map[string]string{"X-User-ID": strconv.Itoa(i)})
+		counts[SelectSplitTarget(c, canarySplits)]++
+	}
+
+	for _, split := range canarySplits {
+		ratio := float64(counts[split.Target]) / samples * 100
+		if math.Abs(ratio-float64(split.Weight)) > 0.5 {
+			t.Fatalf("%s 分配到 %.2f%% 的请求，期望 %d%%", split.Target, ratio, split.Weight)
+		}
+	}
+}
+
+func TestSelectSplitTargetSticky(t *testing.T) {
+	// 同一分桶依据的请求始终分配到同一目标
+	for i := 0; i < 1000; i++ {
+		key := "user-" + strconv.Itoa(i)
+		first := SelectSplitTarget(splitContext("10.0.0.1:1234", map[string]string{"X-User-ID": key}), canarySplits)
+		for j := 0; j < 5; j++ {
+			c := splitContext("10.0.0."+strconv.Itoa(j+2)+":1234", map[string]string{"X-User-ID": key})
+			if got := SelectSplitTarget(c, canarySplits); got != first {
+				t.Fatalf("用户 %s 第 %d 次分配到 %s，第一次为 %s", key, j+2, got, first)
+			}
+		}
+	}
+
+	// 分桶依据的优先级：请求ID、用户ID、客户端IP
+	tests := []struct {
+		name       string
+		remoteAddr string
+		headers    map[string]string
+		key        string
+	}{
+		{"请求ID", "10.0.0.1:1234", map[string]string{"X-Request-ID": "req-1", "X-User-ID": "user-1"}, "req-1"},
+		{"用户ID", "10.0.0.1:1234", map[string]string{"X-User-ID": "user-1"}, "user-1"},
+		{"客户端IP", "10.0.0.1:1234", nil, "10.0.0.1"},
+	}
+	for _, tt := range tests {
+		if got := splitBucketKey(splitContext(tt.remoteAddr, tt.headers)); got != tt.key {
+			t.Fatalf("%s: 分桶依据 = %q，期望 %q", tt.name, got, tt.key)
+		}
+	}
+}
+
+func TestSelectSplitTargetSkipsZeroWeight(t *testing.T) {
+	splits := []config.TrafficSplitConfig{
+		{Target: "http://v1:8080", Weight: 0},
+		{Target: "http://v2:8080", Weight: 100},
+		{Target: "http://v3:8080", Weight: 0},
+	}
+	for i := 0; i < 10000; i++ {
+		c := splitContext("10.0.0.1:1234", map[string]string{"X-User-ID": strconv.Itoa(i)})
+		if got := SelectSplitTarget(c, splits); got != "http://v2:8080" {
+			t.Fatalf("权重为 0 的目标 %s 被选中", got)
+		}
+	}
+}
+
+func TestSelectSplitTargetEvenSplit(t *testing.T) {
+	splits := []config.TrafficSplitConfig{
+		{Target: "A", Weight: 50},
+		{Target: "B", Weight: 50},
+	}
+
+	// 连续的用户ID、带相同前缀的请求ID都应均匀分配
+	for name, key := range map[string]func(i int) string{
+		"连续用户ID": strconv.Itoa,
+		"相同前缀":   func(i int) string { return "req-2024-" + strconv.Itoa(i) },
+	} {
+		const samples = 100000
+		a := 0
+		for i := 0; i < samples; i++ {
+			c := splitContext("10.0.0.1:1234", map[string]string{"X-User-ID": key(i)})
+			if SelectSplitTarget(c, splits) == "A" {
+				a++
+			}
+		}
+		// 标准差约为 0.16%，允许 1% 的偏差
+		if ratio := float64(a) / samples; math.Abs(ratio-0.5) > 0.01 {
+			t.Fatalf("%s: A 组占比 %.4f，期望 0.5±0.01", name, ratio)
+		}
+	}
+}
+
+func TestSplitPositionUniform(t *testing.T) {
+	const samples, buckets = 100000, 100
+	var counts [buckets]int
+	for i := 0; i < samples; i++ {
+		position := splitPosition(strconv.Itoa(i))
+		if position < 0 || position >= 1 {
+			t.Fatalf("位置 %v 超出 [0, 1)", position)
+		}
+		counts[int(position*buckets)]++
+	}
+
+	// 卡方检验：自由度 99，显著性 0.001 的临界值约为 148.2
+	expected := float64(samples) / buckets
+	chiSquare := 0.0
+	for _, count := range counts {
+		diff := float64(count) - expected
+		chiSquare += diff * diff / expected
+	}
+	if chiSquare > 148.2 {
+		t.Fatalf("分桶卡方值 %.1f 超过临界值，分布不均匀: %v", chiSquare, counts)
+	}
+}
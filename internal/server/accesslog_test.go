@@ -0,0 +1,129 @@
+package server
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gateway-go/internal/config"
+	"gateway-go/internal/logger"
+)
+
+// readLogEntries 同步日志后读取文件中的 JSON 日志
+func readLogEntries(t *testing.T, path string) []map[string]interface{} {
+	t.Helper()
+	logger.Sync()
+	file, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		t.Fatalf("打开日志文件失败: %v", err)
+	}
+	defer file.Close()
+
+	var entries []map[string]interface{}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var entry map[string]interface{}
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			t.Fatalf("解析日志 %q 失败: %v", scanner.Text(), err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+func TestAccessLogWrittenToSeparateFile(t *testing.T) {
+	upstream := textUpstream(t, "ok")
+	dir := t.TempDir()
+	cfg := testConfig(upstream.URL)
+	cfg.Log.Level = "info"
+	cfg.Log.Output = filepath.Join(dir, "gateway.log")
+	cfg.Log.Access = &config.AccessLogConfig{Output: filepath.Join(dir, "access", "access.log")}
+	_, base := startTestServer(t, cfg)
+
+	if status, _ := get(t, base+"/orders/1?page=2"); status != 200 {
+		t.Fatalf("状态码 = %d，期望 200", status)
+	}
+
+	// 访问日志写入独立文件（自动创建目录），只包含时间和请求字段
+	entries := readLogEntries(t, cfg.Log.Access.Output)
+	if len(entries) != 1 {
+		t.Fatalf("访问日志有 %d 条，期望 1 条: %v", len(entries), entries)
+	}
+	entry := entries[0]
+	if entry["method"] != "GET" || entry["path"] != "/orders/1" || entry["status"] != float64(200) ||
+		entry["client_ip"] != "127.0.0.1" || entry["response_bytes"] != float64(2) {
+		t.Fatalf("访问日志 = %v", entry)
+	}
+	for _, key := range []string{"time", "cost", "target", "request_bytes"} {
+		if _, ok := entry[key]; !ok {
+			t.Fatalf("访问日志缺少字段 %s: %v", key, entry)
+		}
+	}
+	if _, ok := entry["level"]; ok {
+		t.Fatalf("访问日志不应包含 level 字段: %v", entry)
+	}
+
+	// 主日志不包含访问日志
+	for _, entry := range readLogEntries(t, cfg.Log.Output) {
+		if entry["path"] == "/orders/1" {
+			t.Fatalf("主日志包含访问日志: %v", entry)
+		}
+	}
+}
+
+func TestAccessLogSamplingKeepsErrors(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/fail":
+			w.WriteHeader(http.StatusInternalServerError)
+		case "/missing":
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer upstream.Close()
+
+	tests := []struct {
+		name     string
+		sampling *config.LogSamplingConfig
+		// 每个路径期望记录的日志条数范围
+		want map[string][2]int
+	}{
+		{"默认只保留 5xx", &config.LogSamplingConfig{Rate: 0.01}, map[string][2]int{"/fail": {100, 100}, "/missing": {0, 6}, "/ok": {0, 6}}},
+		{"保留 4xx 和 5xx", &config.LogSamplingConfig{Rate: 0.01, AlwaysStatus: 400}, map[string][2]int{"/fail": {100, 100}, "/missing": {100, 100}, "/ok": {0, 6}}},
+		{"只记录错误", &config.LogSamplingConfig{}, map[string][2]int{"/fail": {100, 100}, "/missing": {0, 0}, "/ok": {0, 0}}},
+		{"未配置采样", nil, map[string][2]int{"/fail": {100, 100}, "/missing": {100, 100}, "/ok": {100, 100}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testConfig(upstream.URL)
+			cfg.Log.Level = "info"
+			cfg.Log.Access = &config.AccessLogConfig{Output: filepath.Join(t.TempDir(), "access.log")}
+			cfg.Log.Sampling = tt.sampling
+			_, base := startTestServer(t, cfg)
+
+			for path := range tt.want {
+				for i := 0; i < 100; i++ {
+					get(t, base+path)
+				}
+			}
+
+			counts := make(map[string]int)
+			for _, entry := range readLogEntries(t, cfg.Log.Access.Output) {
+				path, _ := entry["path"].(string)
+				counts[path]++
+			}
+			for path, want := range tt.want {
+				if got := counts[path]; got < want[0] || got > want[1] {
+					t.Fatalf("%s 记录了 %d/100 条访问日志，期望在 [%d, %d] 范围内", path, got, want[0], want[1])
+				}
+			}
+		})
+	}
+}
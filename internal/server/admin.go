@@ -0,0 +1,172 @@
+package server
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"sort"
+	"strings"
+
+	"gateway-go/internal/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+// defaultMaxVersions 默认保留的配置版本数量
+const defaultMaxVersions = 10
+
+// redactedToken 输出配置时替换管理令牌
+const redactedToken = "******"
+
+// registerAdminRoutes 注册配置管理API，未配置管理令牌时不启用
+func (s *Server) registerAdminRoutes(r *gin.Engine, cfg *config.Config) {
+	token := cfg.Server.Admin.Token
+	if token == "" {
+		return
+	}
+
+	admin := r.Group("/gatewaygo/config", adminAuth(token), s.auditAdmin)
+	admin.GET("", s.handleGetConfig)
+	admin.GET("/versions", s.handleListVersions)
+	admin.GET("/versions/:version", s.handleGetVersion)
+	admin.POST("/update", s.handleUpdateConfig)
+	admin.POST("/rollback/:version", s.handleRollbackConfig)
+
+	s.registerRouteAdminRoutes(r, token)
+	s.registerCircuitBreakerAdminRoutes(r, token)
+	s.registerPluginAdminRoutes(r, token)
+	s.registerMaintenanceAdminRoutes(r, token)
+	s.registerConnectionAdminRoutes(r, token)
+
+	capture := r.Group("/gatewaygo/capture", adminAuth(token), s.auditAdmin)
+	capture.GET("", s.handleListCaptures)
+	capture.DELETE("", s.handleClearCaptures)
+}
+
+// adminAuth 校验 Authorization: Bearer <token>，记录调用者标识供审计日志使用
+func adminAuth(token string) gin.HandlerFunc {
+	principal := tokenPrincipal(token)
+	return func(c *gin.Context) {
+		provided := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"error": "未授权访问",
+			})
+			return
+		}
+		c.Set(adminPrincipalKey, principal)
+		c.Next()
+	}
+}
+
+// handleGetConfig 获取当前配置
+func (s *Server) handleGetConfig(c *gin.Context) {
+	settings, err := encodeRedactedConfig(s.configCenter.GetCurrentConfig())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, settings)
+}
+
+// handleListVersions 获取配置版本历史（不含配置内容）
+func (s *Server) handleListVersions(c *gin.Context) {
+	versions := s.configCenter.GetVersions()
+	result := make([]gin.H, 0, len(versions))
+	for _, v := range versions {
+		result = append(result, gin.H{
+			"version":   v.Version,
+			"timestamp": v.Timestamp,
+			"comment":   v.Comment,
+		})
+	}
+	c.JSON(http.StatusOK, gin.H{"versions": result})
+}
+
+// handleGetVersion 获取指定版本的配置
+func (s *Server) handleGetVersion(c *gin.Context) {
+	version, err := s.configCenter.GetVersion(c.Param("version"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	settings, err := encodeRedactedConfig(&version.Config)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"version":   version.Version,
+		"timestamp": version.Timestamp,
+		"comment":   version.Comment,
+		"config":    settings,
+	})
+}
+
+// handleUpdateConfig 部分更新配置，请求体为 JSON，键名与配置文件一致
+func (s *Server) handleUpdateConfig(c *gin.Context) {
+	var settings map[string]interface{}
+	if err := c.ShouldBindJSON(&settings); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的配置: " + err.Error()})
+		return
+	}
+	keys := make([]string, 0, len(settings))
+	for key := range settings {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	setAuditDetail(c, gin.H{"comment": c.DefaultQuery("comment", "管理API更新"), "keys": keys})
+
+	newConfig, err := config.DecodeConfigMap(settings)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	s.adminMu.Lock()
+	defer s.adminMu.Unlock()
+
+	version, err := s.configCenter.UpdateConfig(newConfig, c.DefaultQuery("comment", "管理API更新"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := s.configManager.ApplyConfig(s.configCenter.GetCurrentConfig()); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error(), "version": version})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "配置已更新",
+		"version": version,
+	})
+}
+
+// handleRollbackConfig 回滚到指定版本
+func (s *Server) handleRollbackConfig(c *gin.Context) {
+	s.adminMu.Lock()
+	defer s.adminMu.Unlock()
+
+	version, err := s.configCenter.RollbackConfig(c.Param("version"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err := s.configManager.ApplyConfig(s.configCenter.GetCurrentConfig()); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error(), "version": version})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "配置已回滚",
+		"version": version,
+	})
+}
+
+// encodeRedactedConfig 输出配置并隐藏管理令牌
+func encodeRedactedConfig(cfg *config.Config) (map[string]interface{}, error) {
+	redacted := *cfg
+	if redacted.Server.Admin.Token != "" {
+		redacted.Server.Admin.Token = redactedToken
+	}
+	return config.EncodeConfigMap(&redacted)
+}
@@ -0,0 +1,45 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// circuitBreakerRequest 手动设置熔断器状态的请求体
+type circuitBreakerRequest struct {
+	// 目标服务，与路由的 target.url 一致
+	Target string `json:"target" binding:"required"`
+	// 操作：open、closed、reset
+	State string `json:"state" binding:"required"`
+}
+
+// registerCircuitBreakerAdminRoutes 注册熔断器管理API
+func (s *Server) registerCircuitBreakerAdminRoutes(r *gin.Engine, token string) {
+	breakers := r.Group("/gatewaygo/circuitbreaker", adminAuth(token), s.auditAdmin)
+	breakers.GET("", s.handleListCircuitBreakers)
+	breakers.POST("", s.handleSetCircuitBreaker)
+}
+
+// handleListCircuitBreakers 查看所有熔断器的状态和失败率
+func (s *Server) handleListCircuitBreakers(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"circuit_breakers": s.circuitBreaker.Statuses(),
+	})
+}
+
+// handleSetCircuitBreaker 手动强制熔断、强制放行或恢复自动状态
+func (s *Server) handleSetCircuitBreaker(c *gin.Context) {
+	var req circuitBreakerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求: " + err.Error()})
+		return
+	}
+	setAuditDetail(c, gin.H{"target": req.Target, "state": req.State})
+	status, err := s.circuitBreaker.SetState(req.Target, req.State)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, status)
+}
@@ -0,0 +1,117 @@
+package server
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+
+	"gateway-go/internal/plugin"
+
+	"github.com/gin-gonic/gin"
+)
+
+// routePluginRequest 启用或禁用路由插件的请求体
+type routePluginRequest struct {
+	Route   string `json:"route" binding:"required"`
+	Plugin  string `json:"plugin" binding:"required"`
+	Enabled *bool  `json:"enabled" binding:"required"`
+}
+
+// registerPluginAdminRoutes 注册插件状态管理API
+func (s *Server) registerPluginAdminRoutes(r *gin.Engine, token string) {
+	plugins := r.Group("/gatewaygo/plugins", adminAuth(token), s.auditAdmin)
+	plugins.GET("", s.handleListPlugins)
+	plugins.GET("/events", s.handleWatchPlugins)
+	plugins.GET("/routes", s.handleListRoutePlugins)
+	plugins.POST("/routes", s.handleSetRoutePlugin)
+}
+
+// handleListPlugins 查看各插件的加载状态、启动时间、最近一次错误和依赖
+func (s *Server) handleListPlugins(c *gin.Context) {
+	infos := s.pluginManager.Lifecycle().ListPlugins()
+	names := make([]string, 0, len(infos))
+	for name := range infos {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	result := make([]gin.H, 0, len(names))
+	for _, name := range names {
+		info := infos[name]
+		item := gin.H{
+			"name":         name,
+			"state":        info.State.String(),
+			"dependencies": info.Dependencies,
+		}
+		if !info.StartTime.IsZero() {
+			item["start_time"] = info.StartTime
+		}
+		if info.LastError != nil {
+			item["last_error"] = info.LastError.Error()
+		}
+		result = append(result, item)
+	}
+	c.JSON(http.StatusOK, gin.H{"plugins": result})
+}
+
+// handleListRoutePlugins 查看各路由插件链中插件的执行顺序和启用状态
+func (s *Server) handleListRoutePlugins(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"routes": s.pluginManager.RoutePluginStates()})
+}
+
+// handleSetRoutePlugin 在运行时启用或禁用路由的单个插件，无需修改或重载配置
+func (s *Server) handleSetRoutePlugin(c *gin.Context) {
+	var req routePluginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求: " + err.Error()})
+		return
+	}
+	setAuditDetail(c, gin.H{"route": req.Route, "plugin": req.Plugin, "enabled": *req.Enabled})
+
+	if _, exists := s.routerManager.GetRoute(req.Route); !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("路由 %s 不存在", req.Route)})
+		return
+	}
+	if err := s.pluginManager.SetRoutePluginEnabled(req.Route, req.Plugin, *req.Enabled); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"route":   req.Route,
+		"plugins": s.pluginManager.RoutePluginStates()[req.Route],
+	})
+}
+
+// handleWatchPlugins 以 SSE 推送插件状态变更，客户端断开时结束
+func (s *Server) handleWatchPlugins(c *gin.Context) {
+	changes, cancel := s.pluginManager.Lifecycle().Subscribe()
+	defer cancel()
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case change, ok := <-changes:
+			if !ok {
+				return false
+			}
+			c.SSEvent("state", pluginStateEvent(change))
+			return true
+		case <-c.Request.Context().Done():
+			return false
+		}
+	})
+}
+
+// pluginStateEvent 转换插件状态变更事件
+func pluginStateEvent(change *plugin.PluginStateChange) gin.H {
+	event := gin.H{
+		"name":      change.Name,
+		"old_state": change.OldState.String(),
+		"new_state": change.NewState.String(),
+		"timestamp": change.Timestamp,
+	}
+	if change.Error != nil {
+		event["error"] = change.Error.Error()
+	}
+	return event
+}
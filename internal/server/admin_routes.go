@@ -0,0 +1,206 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+
+	"gateway-go/internal/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+// registerRouteAdminRoutes 注册动态路由管理API
+func (s *Server) registerRouteAdminRoutes(r *gin.Engine, token string) {
+	routes := r.Group("/gatewaygo/routes", adminAuth(token), s.auditAdmin)
+	routes.GET("", s.handleListRoutes)
+	routes.GET("/:name", s.handleGetRoute)
+	routes.POST("", s.handleAddRoute)
+	routes.PUT("/:name", s.handleUpdateRoute)
+	routes.DELETE("/:name", s.handleDeleteRoute)
+}
+
+// handleListRoutes 获取路由表（按匹配优先级排序）
+func (s *Server) handleListRoutes(c *gin.Context) {
+	routes := s.routerManager.Routes()
+	result := make([]map[string]interface{}, 0, len(routes))
+	for i := range routes {
+		settings, err := config.EncodeRouteMap(&routes[i])
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		result = append(result, settings)
+	}
+	c.JSON(http.StatusOK, gin.H{"routes": result})
+}
+
+// handleGetRoute 获取单个路由
+func (s *Server) handleGetRoute(c *gin.Context) {
+	route, exists := s.routerManager.GetRoute(c.Param("name"))
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("路由 %s 不存在", c.Param("name"))})
+		return
+	}
+	settings, err := config.EncodeRouteMap(&route)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, settings)
+}
+
+// handleAddRoute 添加路由
+func (s *Server) handleAddRoute(c *gin.Context) {
+	route, ok := bindRoute(c)
+	if !ok {
+		return
+	}
+	setAuditDetail(c, gin.H{"route": route.Name})
+
+	s.adminMu.Lock()
+	defer s.adminMu.Unlock()
+
+	if _, exists := s.routerManager.GetRoute(route.Name); exists {
+		c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("路由 %s 已存在", route.Name)})
+		return
+	}
+	if !s.checkRouteMatchConflict(c, route) {
+		return
+	}
+
+	// 先加载插件链，避免路由生效时插件（如认证）尚未就绪
+	if err := s.loadDynamicRoutePlugins(route); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := s.routerManager.AddRoute(*route); err != nil {
+		s.pluginManager.RemoveRoutePlugins(route.Name)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	version := s.syncRoutes(fmt.Sprintf("添加路由 %s", route.Name))
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "路由已添加",
+		"version": version,
+	})
+}
+
+// handleUpdateRoute 更新路由，路由名称以路径参数为准
+func (s *Server) handleUpdateRoute(c *gin.Context) {
+	route, ok := bindRoute(c)
+	if !ok {
+		return
+	}
+	name := c.Param("name")
+	route.Name = name
+
+	s.adminMu.Lock()
+	defer s.adminMu.Unlock()
+
+	old, exists := s.routerManager.GetRoute(name)
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("路由 %s 不存在", name)})
+		return
+	}
+	if !s.checkRouteMatchConflict(c, route) {
+		return
+	}
+
+	if err := s.loadDynamicRoutePlugins(route); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := s.routerManager.UpdateRoute(name, *route); err != nil {
+		// 恢复原插件链
+		s.loadDynamicRoutePlugins(&old)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	version := s.syncRoutes(fmt.Sprintf("更新路由 %s", name))
+	c.JSON(http.StatusOK, gin.H{
+		"message": "路由已更新",
+		"version": version,
+	})
+}
+
+// handleDeleteRoute 删除路由
+func (s *Server) handleDeleteRoute(c *gin.Context) {
+	name := c.Param("name")
+
+	s.adminMu.Lock()
+	defer s.adminMu.Unlock()
+
+	if err := s.routerManager.DeleteRoute(name); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	s.pluginManager.RemoveRoutePlugins(name)
+
+	version := s.syncRoutes(fmt.Sprintf("删除路由 %s", name))
+	c.JSON(http.StatusOK, gin.H{
+		"message": "路由已删除",
+		"version": version,
+	})
+}
+
+// bindRoute 解析并验证请求体中的路由配置，失败时写入 400 响应
+func bindRoute(c *gin.Context) (*config.RouteConfig, bool) {
+	var settings map[string]interface{}
+	if err := c.ShouldBindJSON(&settings); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的路由: " + err.Error()})
+		return nil, false
+	}
+	route, err := config.DecodeRouteMap(settings)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	if name := c.Param("name"); name != "" {
+		route.Name = name
+	}
+	if err := config.ValidateRouteConfig(route); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "路由配置验证失败: " + err.Error()})
+		return nil, false
+	}
+	return route, true
+}
+
+// checkRouteMatchConflict 检查路由是否与其他路由的匹配条件和优先级完全相同，冲突时写入 409 响应
+func (s *Server) checkRouteMatchConflict(c *gin.Context, route *config.RouteConfig) bool {
+	for _, existing := range s.routerManager.Routes() {
+		if existing.Name != route.Name && existing.Match.Equivalent(route.Match) {
+			c.JSON(http.StatusConflict, gin.H{
+				"error": fmt.Sprintf("路由 %s 与路由 %s 的匹配条件和优先级相同，无法区分", route.Name, existing.Name),
+			})
+			return false
+		}
+	}
+	return true
+}
+
+// loadDynamicRoutePlugins 加载动态路由的插件链，未配置插件时清空插件链
+func (s *Server) loadDynamicRoutePlugins(route *config.RouteConfig) error {
+	if len(route.Plugins) == 0 {
+		s.pluginManager.RemoveRoutePlugins(route.Name)
+		return nil
+	}
+	if err := s.pluginManager.LoadRoutePlugins(route.Name, route.Plugins, route.PluginConfig, route.PluginOrder == "list"); err != nil {
+		return fmt.Errorf("加载路由 %s 的插件失败: %w", route.Name, err)
+	}
+	return nil
+}
+
+// syncRoutes 将路由表变更同步到当前配置并记录版本（不触发重载），返回新版本号
+func (s *Server) syncRoutes(comment string) string {
+	current := s.configManager.GetConfig()
+	updated := *current
+	updated.Routes = append([]config.RouteConfig(nil), s.routerManager.Routes()...)
+
+	if err := s.configManager.SetConfig(&updated); err != nil {
+		fmt.Printf("同步路由配置失败: %v\n", err)
+		return ""
+	}
+	return s.configCenter.RecordConfig(&updated, "api", comment)
+}
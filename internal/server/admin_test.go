@@ -0,0 +1,309 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"gateway-go/internal/config"
+)
+
+const testAdminToken = "test-admin-token"
+
+// adminConfig 返回启用管理API的测试配置
+func adminConfig(upstream string) *config.Config {
+	cfg := testConfig(upstream)
+	cfg.Server.Admin = config.AdminConfig{Token: testAdminToken}
+	return cfg
+}
+
+// adminDo 携带管理令牌发送请求，返回状态码和解析后的 JSON 响应
+func adminDo(t *testing.T, method, url, token, body string) (int, map[string]interface{}) {
+	t.Helper()
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("请求 %s %s 失败: %v", method, url, err)
+	}
+	defer resp.Body.Close()
+
+	var result map[string]interface{}
+	data, _ := io.ReadAll(resp.Body)
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &result); err != nil {
+			t.Fatalf("解析响应 %q 失败: %v", data, err)
+		}
+	}
+	return resp.StatusCode, result
+}
+
+// textUpstream 返回固定响应体的上游
+func textUpstream(t *testing.T, text string) *httptest.Server {
+	t.Helper()
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, text)
+	}))
+	t.Cleanup(upstream.Close)
+	return upstream
+}
+
+func TestAdminConfigRequiresToken(t *testing.T) {
+	_, base := startTestServer(t, adminConfig("http://127.0.0.1:1"))
+
+	if status, _ := adminDo(t, http.MethodGet, base+"/gatewaygo/config", "", ""); status != http.StatusUnauthorized {
+		t.Fatalf("未携带令牌时状态码 = %d，期望 401", status)
+	}
+	if status, _ := adminDo(t, http.MethodGet, base+"/gatewaygo/config", "wrong", ""); status != http.StatusUnauthorized {
+		t.Fatalf("令牌错误时状态码 = %d，期望 401", status)
+	}
+
+	status, settings := adminDo(t, http.MethodGet, base+"/gatewaygo/config", testAdminToken, "")
+	if status != http.StatusOK {
+		t.Fatalf("获取配置状态码 = %d，期望 200", status)
+	}
+	// 返回的配置隐藏管理令牌
+	server, _ := settings["server"].(map[string]interface{})
+	admin, _ := server["admin"].(map[string]interface{})
+	if admin["token"] == testAdminToken {
+		t.Fatal("返回的配置泄露了管理令牌")
+	}
+}
+
+func TestAdminConfigUpdateRollback(t *testing.T) {
+	one := textUpstream(t, "one")
+	two := textUpstream(t, "two")
+	_, base := startTestServer(t, adminConfig(one.URL))
+
+	status, body := adminDo(t, http.MethodGet, base+"/gatewaygo/config/versions", testAdminToken, "")
+	versions, _ := body["versions"].([]interface{})
+	if status != http.StatusOK || len(versions) != 1 {
+		t.Fatalf("初始版本列表 = %d %v，期望 1 个版本", status, body)
+	}
+	initial := versions[0].(map[string]interface{})["version"].(string)
+
+	// 更新路由目标，立即生效
+	update := `{"routes":[{"name":"default","match":{"type":"prefix","path":"/"},"target":{"url":"` + two.URL + `"}}]}`
+	status, body = adminDo(t, http.MethodPost, base+"/gatewaygo/config/update?comment=switch", testAdminToken, update)
+	if status != http.StatusOK || body["version"] == "" {
+		t.Fatalf("更新配置 = %d %v，期望 200 并返回版本号", status, body)
+	}
+	if _, got := get(t, base+"/"); got != "two" {
+		t.Fatalf("更新后请求转发到 %q，期望 two", got)
+	}
+
+	// 校验失败的更新被拒绝，当前配置不变
+	invalid := `{"routes":[{"name":"broken","match":{"type":"prefix","path":"/"},"target":{"url":""}}]}`
+	if status, _ := adminDo(t, http.MethodPost, base+"/gatewaygo/config/update", testAdminToken, invalid); status != http.StatusBadRequest {
+		t.Fatalf("无效配置更新状态码 = %d，期望 400", status)
+	}
+	if _, got := get(t, base+"/"); got != "two" {
+		t.Fatalf("无效更新后请求转发到 %q，期望保持 two", got)
+	}
+
+	// 回滚到初始版本
+	status, body = adminDo(t, http.MethodPost, base+"/gatewaygo/config/rollback/"+initial, testAdminToken, "")
+	if status != http.StatusOK || body["version"] == "" {
+		t.Fatalf("回滚配置 = %d %v，期望 200 并返回版本号", status, body)
+	}
+	if _, got := get(t, base+"/"); got != "one" {
+		t.Fatalf("回滚后请求转发到 %q，期望 one", got)
+	}
+
+	_, body = adminDo(t, http.MethodGet, base+"/gatewaygo/config/versions", testAdminToken, "")
+	if versions, _ := body["versions"].([]interface{}); len(versions) != 3 {
+		t.Fatalf("版本数 = %d，期望 3（初始、更新、回滚）", len(versions))
+	}
+
+	if status, _ := adminDo(t, http.MethodPost, base+"/gatewaygo/config/rollback/missing", testAdminToken, ""); status != http.StatusNotFound {
+		t.Fatalf("回滚到不存在的版本状态码 = %d，期望 404", status)
+	}
+}
+
+func TestAdminRouteAddUpdateDelete(t *testing.T) {
+	one := textUpstream(t, "one")
+	two := textUpstream(t, "two")
+	three := textUpstream(t, "three")
+	_, base := startTestServer(t, adminConfig(one.URL))
+
+	// 添加路由后立即生效（优先级高于默认的 / 路由）
+	route := `{"name":"new","match":{"type":"prefix","path":"/new","priority":10},"target":{"url":"` + two.URL + `"}}`
+	if status, body := adminDo(t, http.MethodPost, base+"/gatewaygo/routes", testAdminToken, route); status != http.StatusCreated {
+		t.Fatalf("添加路由 = %d %v，期望 201", status, body)
+	}
+	if _, got := get(t, base+"/new/x"); got != "two" {
+		t.Fatalf("添加路由后请求转发到 %q，期望 two", got)
+	}
+	if status, _ := adminDo(t, http.MethodPost, base+"/gatewaygo/routes", testAdminToken, route); status != http.StatusConflict {
+		t.Fatalf("重复添加路由状态码 = %d，期望 409", status)
+	}
+
+	// 更新路由目标
+	updated := `{"match":{"type":"prefix","path":"/new","priority":10},"target":{"url":"` + three.URL + `"}}`
+	if status, body := adminDo(t, http.MethodPut, base+"/gatewaygo/routes/new", testAdminToken, updated); status != http.StatusOK {
+		t.Fatalf("更新路由 = %d %v，期望 200", status, body)
+	}
+	if _, got := get(t, base+"/new/x"); got != "three" {
+		t.Fatalf("更新路由后请求转发到 %q，期望 three", got)
+	}
+
+	// 删除后回落到默认路由
+	if status, body := adminDo(t, http.MethodDelete, base+"/gatewaygo/routes/new", testAdminToken, ""); status != http.StatusOK {
+		t.Fatalf("删除路由 = %d %v，期望 200", status, body)
+	}
+	if _, got := get(t, base+"/new/x"); got != "one" {
+		t.Fatalf("删除路由后请求转发到 %q，期望 one", got)
+	}
+	if status, _ := adminDo(t, http.MethodGet, base+"/gatewaygo/routes/new", testAdminToken, ""); status != http.StatusNotFound {
+		t.Fatalf("获取已删除路由状态码 = %d，期望 404", status)
+	}
+
+	// 校验失败的路由被拒绝
+	invalid := `{"name":"bad","match":{"type":"prefix"},"target":{"url":"` + two.URL + `"}}`
+	if status, _ := adminDo(t, http.MethodPost, base+"/gatewaygo/routes", testAdminToken, invalid); status != http.StatusBadRequest {
+		t.Fatalf("添加无效路由状态码 = %d，期望 400", status)
+	}
+}
+
+func TestAdminCircuitBreakerForceOpenAndReset(t *testing.T) {
+	upstream := textUpstream(t, "ok")
+	cfg := adminConfig(upstream.URL)
+	usePlugin(cfg, "circuit_breaker", nil)
+	_, base := startTestServer(t, cfg)
+	// setState 手动设置上游的熔断器状态
+	setState := func(token, state string) (int, map[string]interface{}) {
+		return adminDo(t, http.MethodPost, base+"/gatewaygo/circuitbreaker", token, `{"target":"`+upstream.URL+`","state":"`+state+`"}`)
+	}
+
+	if status, _ := setState("", "open"); status != http.StatusUnauthorized {
+		t.Fatalf("未携带令牌时状态码 = %d，期望 401", status)
+	}
+
+	// 强制熔断后请求直接返回 503
+	status, body := setState(testAdminToken, "open")
+	if status != http.StatusOK || body["state"] != "open" || body["forced"] != true {
+		t.Fatalf("强制熔断 = %d %v，期望 open 且 forced", status, body)
+	}
+	if status, _ := get(t, base+"/"); status != http.StatusServiceUnavailable {
+		t.Fatalf("强制熔断后状态码 = %d，期望 503", status)
+	}
+
+	_, body = adminDo(t, http.MethodGet, base+"/gatewaygo/circuitbreaker", testAdminToken, "")
+	breakers, _ := body["circuit_breakers"].([]interface{})
+	if len(breakers) != 1 || breakers[0].(map[string]interface{})["state"] != "open" {
+		t.Fatalf("熔断器列表 = %v，期望 1 个 open 的熔断器", body)
+	}
+
+	// 恢复后请求正常转发
+	status, body = setState(testAdminToken, "reset")
+	if status != http.StatusOK || body["state"] != "closed" || body["forced"] != false {
+		t.Fatalf("恢复熔断器 = %d %v，期望 closed 且非 forced", status, body)
+	}
+	if status, got := get(t, base+"/"); status != http.StatusOK || got != "ok" {
+		t.Fatalf("恢复后响应 = %d %q，期望 200 ok", status, got)
+	}
+
+	// 无效操作和不存在的熔断器
+	if status, _ := setState(testAdminToken, "half"); status != http.StatusBadRequest {
+		t.Fatalf("无效操作状态码 = %d，期望 400", status)
+	}
+	if status, _ := adminDo(t, http.MethodPost, base+"/gatewaygo/circuitbreaker", testAdminToken, `{"target":"http://missing","state":"reset"}`); status != http.StatusBadRequest {
+		t.Fatalf("恢复不存在的熔断器状态码 = %d，期望 400", status)
+	}
+}
+
+func TestAdminRoutePluginToggle(t *testing.T) {
+	cfg := adminConfig(textUpstream(t, "ok").URL)
+	usePlugin(cfg, "api_key", map[string]interface{}{
+		"keys": []interface{}{map[string]interface{}{"key": "k1", "consumer": "alice"}},
+	})
+	srv, base := startTestServer(t, cfg)
+	// setPlugin 启用或禁用 default 路由的插件
+	setPlugin := func(plugin string, enabled bool) (int, map[string]interface{}) {
+		return adminDo(t, http.MethodPost, base+"/gatewaygo/plugins/routes", testAdminToken,
+			fmt.Sprintf(`{"route":"default","plugin":"%s","enabled":%v}`, plugin, enabled))
+	}
+
+	if status, _ := get(t, base+"/"); status != http.StatusUnauthorized {
+		t.Fatalf("未携带密钥时状态码 = %d，期望 401", status)
+	}
+
+	// 禁用后该路由的请求跳过插件
+	status, body := setPlugin("api_key", false)
+	plugins, _ := body["plugins"].([]interface{})
+	if status != http.StatusOK || len(plugins) != 1 || plugins[0].(map[string]interface{})["enabled"] != false {
+		t.Fatalf("禁用插件 = %d %v，期望 api_key 已禁用", status, body)
+	}
+	if status, got := get(t, base+"/"); status != http.StatusOK || got != "ok" {
+		t.Fatalf("禁用插件后响应 = %d %q，期望 200 ok", status, got)
+	}
+
+	// 重新启用后恢复校验
+	if status, _ := setPlugin("api_key", true); status != http.StatusOK {
+		t.Fatalf("启用插件状态码 = %d，期望 200", status)
+	}
+	if status, _ := get(t, base+"/"); status != http.StatusUnauthorized {
+		t.Fatalf("重新启用插件后状态码 = %d，期望 401", status)
+	}
+
+	// 重载配置后恢复为配置的状态
+	setPlugin("api_key", false)
+	if err := srv.reload(cfg); err != nil {
+		t.Fatalf("重载配置失败: %v", err)
+	}
+	if status, _ := get(t, base+"/"); status != http.StatusUnauthorized {
+		t.Fatalf("重载配置后状态码 = %d，期望 401", status)
+	}
+
+	// 路由未使用的插件
+	if status, _ := setPlugin("cors", false); status != http.StatusNotFound {
+		t.Fatalf("禁用路由未使用的插件状态码 = %d，期望 404", status)
+	}
+}
+
+func TestAdminPluginsShowsFailedPlugin(t *testing.T) {
+	upstream := textUpstream(t, "ok")
+	cfg := adminConfig(upstream.URL)
+	usePlugin(cfg, "cors", nil)
+	_, base := startTestServer(t, cfg)
+
+	// pluginState 返回插件列表中指定插件的信息
+	pluginState := func(name string) map[string]interface{} {
+		status, body := adminDo(t, http.MethodGet, base+"/gatewaygo/plugins", testAdminToken, "")
+		if status != http.StatusOK {
+			t.Fatalf("查看插件状态码 = %d，期望 200", status)
+		}
+		plugins, _ := body["plugins"].([]interface{})
+		for _, item := range plugins {
+			if info := item.(map[string]interface{}); info["name"] == name {
+				return info
+			}
+		}
+		t.Fatalf("插件列表 %v 中没有 %s", body, name)
+		return nil
+	}
+
+	if info := pluginState("cors"); info["state"] != "running" || info["start_time"] == nil {
+		t.Fatalf("cors 插件 = %v，期望 running 并记录启动时间", info)
+	}
+
+	// 插件初始化失败时更新配置失败，插件状态记录失败原因
+	update := `{"plugins":{"available":[{"name":"cors","enabled":true},{"name":"geoip","enabled":true,"config":{"database":"` + t.TempDir() + `/missing.mmdb"}}]}}`
+	if status, _ := adminDo(t, http.MethodPost, base+"/gatewaygo/config/update", testAdminToken, update); status != http.StatusInternalServerError {
+		t.Fatalf("插件初始化失败时更新配置状态码 = %d，期望 500", status)
+	}
+	info := pluginState("geoip")
+	if lastError, _ := info["last_error"].(string); info["state"] != "failed" || !strings.Contains(lastError, "读取 GeoIP 数据库失败") {
+		t.Fatalf("geoip 插件 = %v，期望 failed 并包含失败原因", info)
+	}
+}
@@ -0,0 +1,176 @@
+package server
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"gateway-go/internal/logger"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+const (
+	// adminPrincipalKey 管理API调用者在上下文中的键
+	adminPrincipalKey = "_admin_principal"
+	// auditDetailKey 管理操作详细信息在上下文中的键
+	auditDetailKey = "_audit_detail"
+)
+
+// auditActions 管理API变更操作的名称，键为请求方法和路由路径
+var auditActions = map[string]string{
+	"POST /gatewaygo/config/update":            "config.update",
+	"POST /gatewaygo/config/rollback/:version": "config.rollback",
+	"POST /gatewaygo/routes":                   "route.add",
+	"PUT /gatewaygo/routes/:name":              "route.update",
+	"DELETE /gatewaygo/routes/:name":           "route.delete",
+	"POST /gatewaygo/circuitbreaker":           "circuit_breaker.set",
+	"POST /gatewaygo/maintenance":              "maintenance.set",
+	"POST /gatewaygo/plugins/routes":           "route_plugin.set",
+	"DELETE /gatewaygo/capture":                "capture.clear",
+}
+
+// auditEntry 管理操作审计记录
+type auditEntry struct {
+	Time      time.Time         `json:"time"`
+	Principal string            `json:"principal"`
+	ClientIP  string            `json:"client_ip"`
+	Action    string            `json:"action"`
+	Method    string            `json:"method"`
+	Path      string            `json:"path"`
+	Params    map[string]string `json:"params,omitempty"`
+	Detail    gin.H             `json:"detail,omitempty"`
+	Status    int               `json:"status"`
+	// 操作前后的最新配置版本，操作未产生新版本时两者相同
+	BeforeVersion string `json:"before_version,omitempty"`
+	AfterVersion  string `json:"after_version,omitempty"`
+}
+
+// auditSink 审计日志输出，writer 为空时写入网关日志
+type auditSink struct {
+	writer io.WriteCloser
+	closed bool
+	mu     sync.Mutex
+}
+
+// newAuditSink 创建审计日志输出，output 为空或 log 时写入网关日志
+func newAuditSink(output string) (*auditSink, error) {
+	sink := &auditSink{}
+	if output != "" && output != "log" {
+		if err := os.MkdirAll(filepath.Dir(output), 0755); err != nil {
+			return nil, fmt.Errorf("创建审计日志目录失败: %w", err)
+		}
+		sink.writer = &lumberjack.Logger{Filename: output}
+	}
+	return sink, nil
+}
+
+// record 写入一条审计记录
+func (a *auditSink) record(entry *auditEntry) {
+	if a == nil {
+		return
+	}
+	if a.writer == nil {
+		a.log(entry)
+		return
+	}
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.closed {
+		return
+	}
+	if _, err := a.writer.Write(append(line, '\n')); err != nil && logger.Log != nil {
+		logger.Log.Warn("写入审计日志失败", zap.String("error", err.Error()))
+	}
+}
+
+// log 将审计记录写入网关日志
+func (a *auditSink) log(entry *auditEntry) {
+	if logger.Log == nil || !logger.Log.Core().Enabled(zap.WarnLevel) {
+		return
+	}
+	logger.Log.Warn("管理操作",
+		zap.String("principal", entry.Principal),
+		zap.String("client_ip", entry.ClientIP),
+		zap.String("action", entry.Action),
+		zap.String("method", entry.Method),
+		zap.String("path", entry.Path),
+		zap.Any("params", entry.Params),
+		zap.Any("detail", entry.Detail),
+		zap.Int("status", entry.Status),
+		zap.String("before_version", entry.BeforeVersion),
+		zap.String("after_version", entry.AfterVersion),
+	)
+}
+
+// Close 关闭审计日志文件
+func (a *auditSink) Close() error {
+	if a == nil || a.writer == nil {
+		return nil
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.closed = true
+	return a.writer.Close()
+}
+
+// tokenPrincipal 返回管理令牌的标识（SHA-256 摘要前 8 位），审计日志中不记录令牌本身
+func tokenPrincipal(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return "token:" + hex.EncodeToString(sum[:4])
+}
+
+// setAuditDetail 补充当前管理操作的审计信息
+func setAuditDetail(c *gin.Context, detail gin.H) {
+	c.Set(auditDetailKey, detail)
+}
+
+// auditAdmin 记录管理API的变更操作（非 GET 请求），包括失败的操作
+func (s *Server) auditAdmin(c *gin.Context) {
+	if c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead {
+		c.Next()
+		return
+	}
+
+	before := s.configCenter.LatestVersion()
+	c.Next()
+
+	entry := &auditEntry{
+		Time:          time.Now(),
+		Principal:     c.GetString(adminPrincipalKey),
+		ClientIP:      c.ClientIP(),
+		Method:        c.Request.Method,
+		Path:          c.Request.URL.Path,
+		Status:        c.Writer.Status(),
+		BeforeVersion: before,
+		AfterVersion:  s.configCenter.LatestVersion(),
+	}
+	entry.Action = auditActions[c.Request.Method+" "+c.FullPath()]
+	if entry.Action == "" {
+		entry.Action = c.Request.Method + " " + c.FullPath()
+	}
+	if len(c.Params) > 0 {
+		entry.Params = make(map[string]string, len(c.Params))
+		for _, param := range c.Params {
+			entry.Params[param.Key] = param.Value
+		}
+	}
+	if detail, ok := c.Get(auditDetailKey); ok {
+		entry.Detail, _ = detail.(gin.H)
+	}
+	s.audit.Load().record(entry)
+}
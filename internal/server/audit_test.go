@@ -0,0 +1,77 @@
+package server
+
+import (
+	"net/http"
+	"path/filepath"
+	"testing"
+)
+
+func TestAuditRecordPerAdminMutation(t *testing.T) {
+	upstream := textUpstream(t, "ok")
+	cfg := adminConfig(upstream.URL)
+	cfg.Server.Admin.AuditLog = filepath.Join(t.TempDir(), "audit", "audit.log")
+	usePlugin(cfg, "circuit_breaker", nil)
+	_, base := startTestServer(t, cfg)
+
+	_, body := adminDo(t, http.MethodGet, base+"/gatewaygo/config/versions", testAdminToken, "")
+	initial := body["versions"].([]interface{})[0].(map[string]interface{})["version"].(string)
+
+	route := `{"name":"new","match":{"type":"prefix","path":"/new","priority":10},"target":{"url":"` + upstream.URL + `"}}`
+	update := `{"routes":[{"name":"default","match":{"type":"prefix","path":"/"},"target":{"url":"` + upstream.URL + `"},"plugins":["circuit_breaker"]}]}`
+	mutations := []struct {
+		method, path, body string
+		action             string
+		status             int
+		// 操作是否产生新的配置版本
+		newVersion bool
+	}{
+		{http.MethodPost, "/gatewaygo/circuitbreaker", `{"target":"` + upstream.URL + `","state":"open"}`, "circuit_breaker.set", http.StatusOK, false},
+		{http.MethodPost, "/gatewaygo/maintenance", `{"enabled":true}`, "maintenance.set", http.StatusOK, false},
+		{http.MethodPost, "/gatewaygo/maintenance", `{"enabled":false}`, "maintenance.set", http.StatusOK, false},
+		{http.MethodPost, "/gatewaygo/plugins/routes", `{"route":"default","plugin":"circuit_breaker","enabled":false}`, "route_plugin.set", http.StatusOK, false},
+		{http.MethodPost, "/gatewaygo/routes", route, "route.add", http.StatusCreated, true},
+		{http.MethodPut, "/gatewaygo/routes/new", route, "route.update", http.StatusOK, true},
+		{http.MethodDelete, "/gatewaygo/routes/new", "", "route.delete", http.StatusOK, true},
+		{http.MethodPost, "/gatewaygo/config/update", update, "config.update", http.StatusOK, true},
+		// 被拒绝的操作同样记录
+		{http.MethodPost, "/gatewaygo/config/update", `{"routes":[{"name":"broken","match":{"type":"prefix","path":"/"},"target":{"url":""}}]}`, "config.update", http.StatusBadRequest, false},
+		{http.MethodPost, "/gatewaygo/config/rollback/" + initial, "", "config.rollback", http.StatusOK, true},
+	}
+	for _, m := range mutations {
+		if status, body := adminDo(t, m.method, base+m.path, testAdminToken, m.body); status != m.status {
+			t.Fatalf("%s %s = %d %v，期望 %d", m.method, m.path, status, body, m.status)
+		}
+		// 查询和未通过认证的请求不记录
+		adminDo(t, http.MethodGet, base+"/gatewaygo/routes", testAdminToken, "")
+		adminDo(t, m.method, base+m.path, "wrong", m.body)
+	}
+
+	entries := readLogEntries(t, cfg.Server.Admin.AuditLog)
+	if len(entries) != len(mutations) {
+		t.Fatalf("审计记录有 %d 条，期望每个变更操作 1 条: %v", len(entries), entries)
+	}
+	previous := initial
+	for i, m := range mutations {
+		entry := entries[i]
+		if entry["action"] != m.action || entry["method"] != m.method || entry["path"] != m.path || entry["status"] != float64(m.status) {
+			t.Fatalf("第 %d 条审计记录 = %v，期望 %s %s %s %d", i+1, entry, m.action, m.method, m.path, m.status)
+		}
+		if entry["principal"] != tokenPrincipal(testAdminToken) || entry["client_ip"] != "127.0.0.1" || entry["time"] == nil {
+			t.Fatalf("第 %d 条审计记录的调用者 = %v", i+1, entry)
+		}
+		before, _ := entry["before_version"].(string)
+		after, _ := entry["after_version"].(string)
+		if before != previous || (after != before) != m.newVersion {
+			t.Fatalf("第 %d 条审计记录的版本 %s -> %s，之前的版本 %s，期望产生新版本 %v", i+1, before, after, previous, m.newVersion)
+		}
+		previous = after
+	}
+
+	// 路径参数和操作详情
+	if params, _ := entries[5]["params"].(map[string]interface{}); params["name"] != "new" {
+		t.Fatalf("更新路由的路径参数 = %v", entries[5]["params"])
+	}
+	if detail, _ := entries[3]["detail"].(map[string]interface{}); detail["plugin"] != "circuit_breaker" || detail["enabled"] != false {
+		t.Fatalf("路由插件开关的操作详情 = %v", entries[3]["detail"])
+	}
+}
@@ -0,0 +1,145 @@
+package server
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"sync"
+
+	gwerrors "gateway-go/internal/errors"
+	"gateway-go/internal/logger"
+	"gateway-go/internal/metrics"
+	"gateway-go/internal/pool"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// StatusClientClosedRequest 客户端在请求处理完成前断开连接（沿用 nginx 的 499）
+const StatusClientClosedRequest = 499
+
+// retryMaxBody 可重试请求的请求体上限，超过时不重试
+const retryMaxBody = 1 << 20
+
+// bodyBuffers 读取请求体和记录响应体使用的缓冲区池
+var bodyBuffers = pool.NewBufferPool()
+
+// bodyReadErrors 请求体读取失败次数
+var bodyReadErrors = metrics.NewCounterVec(
+	"gateway_request_body_read_errors_total",
+	"请求体读取失败次数",
+	"route", "reason",
+)
+
+// readBodyPrefix 读取请求体前 limit 字节并还原请求体供后续处理
+// 读取失败时中止请求并返回 false，客户端断开返回 499，其余错误返回 400
+func readBodyPrefix(c *gin.Context, route string, limit int64) ([]byte, bool) {
+	if c.Request.Body == nil || c.Request.Body == http.NoBody {
+		return nil, true
+	}
+
+	body := c.Request.Body
+	prefix, err := bodyBuffers.ReadAll(io.LimitReader(body, limit))
+	if err != nil {
+		abortBodyReadError(c, route, err)
+		return nil, false
+	}
+
+	// 已读取部分与剩余部分拼接
+	c.Request.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(prefix), body), body}
+	return prefix, true
+}
+
+// bufferBody 缓存最多 limit 字节的请求体，complete 表示请求体已完整缓存
+// 完整缓存时设置 GetBody 以便重试时重放；读取失败时中止请求并返回 ok=false
+func bufferBody(c *gin.Context, route string, limit int64) (body []byte, complete bool, ok bool) {
+	if c.Request.Body == nil || c.Request.Body == http.NoBody {
+		return nil, true, true
+	}
+
+	prefix, ok := readBodyPrefix(c, route, limit+1)
+	if !ok {
+		return nil, false, false
+	}
+	if int64(len(prefix)) > limit {
+		return prefix[:limit], false, true
+	}
+
+	c.Request.Body = io.NopCloser(bytes.NewReader(prefix))
+	c.Request.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(prefix)), nil
+	}
+	return prefix, true, true
+}
+
+// abortBodyReadError 处理请求体读取失败
+func abortBodyReadError(c *gin.Context, route string, err error) {
+	reason := "read_error"
+	status := http.StatusBadRequest
+	if c.Request.Context().Err() != nil {
+		reason = "client_closed"
+		status = StatusClientClosedRequest
+	} else if errors.Is(err, io.ErrUnexpectedEOF) {
+		reason = "truncated"
+	}
+	bodyReadErrors.Inc(route, reason)
+
+	if logger.Log != nil && logger.Log.Core().Enabled(zap.InfoLevel) {
+		logger.Log.Info("读取请求体失败",
+			zap.String("route_name", route),
+			zap.String("method", c.Request.Method),
+			zap.String("path", c.Request.URL.Path),
+			zap.String("client_ip", c.ClientIP()),
+			zap.String("reason", reason),
+			zap.String("error", err.Error()),
+		)
+	}
+
+	// 客户端已断开时无需写响应体
+	if status == StatusClientClosedRequest {
+		c.AbortWithStatus(status)
+		return
+	}
+	gwerrors.Abort(c, status, gwerrors.GetErrorMessage(gwerrors.ErrBodyRead, gwerrors.RequestLanguage(c.Request)))
+}
+
+// trackingBody 记录转发过程中请求体的读取错误，用于区分客户端问题和上游故障
+type trackingBody struct {
+	io.ReadCloser
+	err error
+	mu  sync.Mutex
+}
+
+func (b *trackingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if err != nil && err != io.EOF {
+		b.mu.Lock()
+		b.err = err
+		b.mu.Unlock()
+	}
+	return n, err
+}
+
+// readErr 返回读取请求体时发生的错误
+func (b *trackingBody) readErr() error {
+	if b == nil {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.err
+}
+
+// trackBody 包装请求体以记录读取错误，无请求体时返回 nil
+func trackBody(c *gin.Context) *trackingBody {
+	if c.Request.Body == nil || c.Request.Body == http.NoBody {
+		return nil
+	}
+	tb := &trackingBody{ReadCloser: c.Request.Body}
+	c.Request.Body = tb
+	return tb
+}
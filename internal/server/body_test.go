@@ -0,0 +1,102 @@
+package server
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// truncatedBody 返回部分内容后以 err 结束的请求体
+type truncatedBody struct {
+	r   io.Reader
+	err error
+}
+
+func (b *truncatedBody) Read(p []byte) (int, error) {
+	n, err := b.r.Read(p)
+	if err == io.EOF {
+		return n, b.err
+	}
+	return n, err
+}
+
+func (b *truncatedBody) Close() error { return nil }
+
+// bodyContext 创建请求体为 body 的测试上下文
+func bodyContext(ctx context.Context, body io.ReadCloser) (*gin.Context, *httptest.ResponseRecorder) {
+	gin.SetMode(gin.TestMode)
+	rec := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(rec)
+	req := httptest.NewRequest(http.MethodPost, "/upload", nil).WithContext(ctx)
+	req.Body = body
+	c.Request = req
+	return c, rec
+}
+
+func TestBufferBodyTruncatedRead(t *testing.T) {
+	before := bodyReadErrors.Value("upload", "truncated")
+
+	c, rec := bodyContext(context.Background(), &truncatedBody{r: strings.NewReader("partial"), err: io.ErrUnexpectedEOF})
+	_, _, ok := bufferBody(c, "upload", 1024)
+	if ok {
+		t.Fatal("请求体被截断时应返回失败")
+	}
+	if !c.IsAborted() || rec.Code != http.StatusBadRequest {
+		t.Fatalf("响应状态码 = %d（aborted=%v），期望 400 并中止请求", rec.Code, c.IsAborted())
+	}
+	if !strings.Contains(rec.Body.String(), "读取请求体失败") {
+		t.Fatalf("响应体 %q 应包含错误信息", rec.Body.String())
+	}
+	if got := bodyReadErrors.Value("upload", "truncated"); got != before+1 {
+		t.Fatalf("truncated 计数 = %v，期望 %v", got, before+1)
+	}
+}
+
+func TestBufferBodyClientClosed(t *testing.T) {
+	before := bodyReadErrors.Value("upload", "client_closed")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	c, rec := bodyContext(ctx, &truncatedBody{r: strings.NewReader("partial"), err: context.Canceled})
+	if _, _, ok := bufferBody(c, "upload", 1024); ok {
+		t.Fatal("客户端断开时应返回失败")
+	}
+	if rec.Code != StatusClientClosedRequest || rec.Body.Len() != 0 {
+		t.Fatalf("响应 = %d %q，期望 499 且无响应体", rec.Code, rec.Body.String())
+	}
+	if got := bodyReadErrors.Value("upload", "client_closed"); got != before+1 {
+		t.Fatalf("client_closed 计数 = %v，期望 %v", got, before+1)
+	}
+}
+
+func TestBufferBodyReplay(t *testing.T) {
+	c, _ := bodyContext(context.Background(), io.NopCloser(strings.NewReader("hello")))
+	body, complete, ok := bufferBody(c, "upload", 1024)
+	if !ok || !complete || string(body) != "hello" {
+		t.Fatalf("bufferBody = %q, %v, %v，期望完整缓存 hello", body, complete, ok)
+	}
+	for i := 0; i < 2; i++ {
+		rc, err := c.Request.GetBody()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if data, _ := io.ReadAll(rc); string(data) != "hello" {
+			t.Fatalf("第 %d 次重放请求体 = %q", i+1, data)
+		}
+	}
+
+	// 超过上限时只返回前缀，转发的请求体保持完整
+	c, _ = bodyContext(context.Background(), io.NopCloser(strings.NewReader("hello world")))
+	body, complete, ok = bufferBody(c, "upload", 5)
+	if !ok || complete || string(body) != "hello" {
+		t.Fatalf("bufferBody = %q, %v, %v，期望只缓存前 5 字节", body, complete, ok)
+	}
+	if data, _ := io.ReadAll(c.Request.Body); string(data) != "hello world" {
+		t.Fatalf("转发的请求体 = %q，期望 hello world", data)
+	}
+}
@@ -0,0 +1,260 @@
+package server
+
+import (
+	"encoding/json"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gateway-go/internal/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 请求捕获默认配置
+const (
+	defaultCaptureCapacity = 100
+	defaultCaptureMaxBody  = 1024
+)
+
+// defaultRedactHeaders 默认隐藏的请求头和响应头
+var defaultRedactHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie", "X-Api-Key"}
+
+// captureEntry 捕获的请求和响应摘要
+type captureEntry struct {
+	Time            time.Time           `json:"time"`
+	Route           string              `json:"route"`
+	Method          string              `json:"method"`
+	Host            string              `json:"host"`
+	URI             string              `json:"uri"`
+	ClientIP        string              `json:"client_ip"`
+	Target          string              `json:"target"`
+	Status          int                 `json:"status"`
+	Duration        time.Duration       `json:"duration"`
+	RequestHeaders  map[string][]string `json:"request_headers"`
+	RequestBody     string              `json:"request_body,omitempty"`
+	ResponseHeaders map[string][]string `json:"response_headers"`
+	ResponseBody    string              `json:"response_body,omitempty"`
+}
+
+// captureBuffer 环形缓冲区，保留最近 capacity 条请求摘要
+type captureBuffer struct {
+	sampleRate    float64
+	maxBody       int
+	redactHeaders map[string]bool
+	redactFields  map[string]bool
+	capacity      int
+
+	entries []captureEntry
+	next    int
+	count   int
+	mu      sync.Mutex
+}
+
+// newCaptureBuffer 根据配置创建捕获缓冲区，未启用时返回 nil
+func newCaptureBuffer(cfg config.CaptureConfig) *captureBuffer {
+	if !cfg.Enabled {
+		return nil
+	}
+
+	capacity := cfg.Capacity
+	if capacity <= 0 {
+		capacity = defaultCaptureCapacity
+	}
+	maxBody := cfg.MaxBodySize
+	if maxBody == 0 {
+		maxBody = defaultCaptureMaxBody
+	}
+	sampleRate := cfg.SampleRate
+	if sampleRate == 0 {
+		sampleRate = 1
+	}
+
+	b := &captureBuffer{
+		sampleRate:    sampleRate,
+		maxBody:       maxBody,
+		redactHeaders: make(map[string]bool),
+		redactFields:  make(map[string]bool),
+		capacity:      capacity,
+		entries:       make([]captureEntry, capacity),
+	}
+	for _, name := range append(defaultRedactHeaders, cfg.RedactHeaders...) {
+		b.redactHeaders[http.CanonicalHeaderKey(name)] = true
+	}
+	for _, field := range cfg.RedactFields {
+		b.redactFields[strings.ToLower(field)] = true
+	}
+	return b
+}
+
+// sampled 按采样率决定是否捕获本次请求，缓冲区为空时返回 false
+func (b *captureBuffer) sampled() bool {
+	if b == nil {
+		return false
+	}
+	return b.sampleRate >= 1 || rand.Float64() < b.sampleRate
+}
+
+// add 写入一条记录，缓冲区已满时覆盖最旧的记录
+func (b *captureBuffer) add(entry captureEntry) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.entries[b.next] = entry
+	b.next = (b.next + 1) % len(b.entries)
+	if b.count < len(b.entries) {
+		b.count++
+	}
+}
+
+// recent 按从新到旧返回最多 limit 条记录，route 不为空时只返回该路由的记录
+func (b *captureBuffer) recent(limit int, route string) []captureEntry {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if limit <= 0 || limit > b.count {
+		limit = b.count
+	}
+	result := make([]captureEntry, 0, limit)
+	for i := 1; i <= b.count && len(result) < limit; i++ {
+		entry := b.entries[(b.next-i+len(b.entries))%len(b.entries)]
+		if route != "" && entry.Route != route {
+			continue
+		}
+		result = append(result, entry)
+	}
+	return result
+}
+
+// clear 清空缓冲区
+func (b *captureBuffer) clear() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	clear(b.entries)
+	b.next = 0
+	b.count = 0
+}
+
+// redactHeaderMap 复制请求头并隐藏敏感值
+func (b *captureBuffer) redactHeaderMap(header http.Header) map[string][]string {
+	result := make(map[string][]string, len(header))
+	for name, values := range header {
+		if b.redactHeaders[http.CanonicalHeaderKey(name)] {
+			result[name] = []string{redactedToken}
+			continue
+		}
+		result[name] = append([]string(nil), values...)
+	}
+	return result
+}
+
+// redactBody 隐藏 JSON 消息体中的敏感字段
+// 配置了敏感字段时，无法解析为 JSON 的消息体（包括被截断的）整体隐藏
+func (b *captureBuffer) redactBody(body []byte) string {
+	if len(b.redactFields) == 0 || len(body) == 0 {
+		return string(body)
+	}
+	var value interface{}
+	if err := json.Unmarshal(body, &value); err != nil {
+		return redactedToken
+	}
+	redacted, err := json.Marshal(b.redactValue(value))
+	if err != nil {
+		return redactedToken
+	}
+	return string(redacted)
+}
+
+// redactValue 递归替换敏感字段的值
+func (b *captureBuffer) redactValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			if b.redactFields[strings.ToLower(key)] {
+				v[key] = redactedToken
+			} else {
+				v[key] = b.redactValue(item)
+			}
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = b.redactValue(item)
+		}
+	}
+	return value
+}
+
+// requestCapture 单次请求的捕获状态
+type requestCapture struct {
+	buffer    *captureBuffer
+	startTime time.Time
+	headers   map[string][]string
+	reqBody   []byte
+	writer    *limitedBodyWriter
+}
+
+// newRequestCapture 开始捕获请求并接管响应写入，读取请求体失败时中止请求并返回 false
+func newRequestCapture(c *gin.Context, route *config.RouteConfig, buffer *captureBuffer) (*requestCapture, bool) {
+	capture := &requestCapture{
+		buffer:    buffer,
+		startTime: time.Now(),
+		headers:   buffer.redactHeaderMap(c.Request.Header),
+	}
+	reqBody, ok := readBodyPrefix(c, route.Name, int64(buffer.maxBody))
+	if !ok {
+		return nil, false
+	}
+	capture.reqBody = reqBody
+	capture.writer = newLimitedBodyWriter(c.Writer, buffer.maxBody)
+	c.Writer = capture.writer
+	return capture, true
+}
+
+// finish 请求处理完成后写入缓冲区
+func (r *requestCapture) finish(c *gin.Context, route *config.RouteConfig) {
+	defer r.writer.release()
+	r.buffer.add(captureEntry{
+		Time:            r.startTime,
+		Route:           route.Name,
+		Method:          c.Request.Method,
+		Host:            c.Request.Host,
+		URI:             c.Request.RequestURI,
+		ClientIP:        c.ClientIP(),
+		Target:          c.GetString("target"),
+		Status:          c.Writer.Status(),
+		Duration:        time.Since(r.startTime),
+		RequestHeaders:  r.headers,
+		RequestBody:     r.buffer.redactBody(r.reqBody),
+		ResponseHeaders: r.buffer.redactHeaderMap(c.Writer.Header()),
+		ResponseBody:    r.buffer.redactBody(r.writer.body.Bytes()),
+	})
+}
+
+// handleListCaptures 查看最近捕获的请求，支持 limit 和 route 参数
+func (s *Server) handleListCaptures(c *gin.Context) {
+	buffer := s.capture.Load()
+	if buffer == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "未启用请求捕获"})
+		return
+	}
+	limit, _ := strconv.Atoi(c.Query("limit"))
+	c.JSON(http.StatusOK, gin.H{
+		"capacity": buffer.capacity,
+		"entries":  buffer.recent(limit, c.Query("route")),
+	})
+}
+
+// handleClearCaptures 清空捕获的请求
+func (s *Server) handleClearCaptures(c *gin.Context) {
+	buffer := s.capture.Load()
+	if buffer == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "未启用请求捕获"})
+		return
+	}
+	buffer.clear()
+	c.JSON(http.StatusOK, gin.H{"message": "已清空"})
+}
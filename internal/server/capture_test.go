@@ -0,0 +1,96 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"gateway-go/internal/config"
+)
+
+func TestCaptureKeepsMostRecentEntries(t *testing.T) {
+	upstream := textUpstream(t, `{"token":"upstream-secret","ok":true}`)
+	cfg := adminConfig(upstream.URL)
+	cfg.Server.Capture = config.CaptureConfig{Enabled: true, Capacity: 3, RedactFields: []string{"password", "token"}}
+	_, base := startTestServer(t, cfg)
+
+	for i := 1; i <= 5; i++ {
+		req, _ := http.NewRequest(http.MethodPost, fmt.Sprintf("%s/orders/%d", base, i), strings.NewReader(`{"user":"alice","password":"p@ss"}`))
+		req.Header.Set("Authorization", "Bearer secret")
+		req.Header.Set("X-Trace", "abc")
+		if resp, _ := doRequest(t, req); resp.StatusCode != http.StatusOK {
+			t.Fatalf("请求状态码 = %d", resp.StatusCode)
+		}
+	}
+
+	status, result := adminDo(t, http.MethodGet, base+"/gatewaygo/capture", testAdminToken, "")
+	if status != http.StatusOK || result["capacity"] != float64(3) {
+		t.Fatalf("查看捕获状态码 = %d，响应 = %v", status, result)
+	}
+	entries, _ := result["entries"].([]interface{})
+	if len(entries) != 3 {
+		t.Fatalf("捕获记录数 = %d，期望容量上限 3", len(entries))
+	}
+	// 从新到旧返回最近的 3 条
+	for i, want := range []string{"/orders/5", "/orders/4", "/orders/3"} {
+		entry := entries[i].(map[string]interface{})
+		if entry["uri"] != want {
+			t.Fatalf("第 %d 条记录 URI = %v，期望 %s", i+1, entry["uri"], want)
+		}
+	}
+
+	// 敏感请求头和 JSON 字段被隐藏
+	entry := entries[0].(map[string]interface{})
+	headers := entry["request_headers"].(map[string]interface{})
+	if fmt.Sprint(headers["Authorization"]) != "["+redactedToken+"]" || fmt.Sprint(headers["X-Trace"]) != "[abc]" {
+		t.Fatalf("捕获的请求头 = %v，期望隐藏 Authorization", headers)
+	}
+	if body := entry["request_body"]; body != `{"password":"`+redactedToken+`","user":"alice"}` {
+		t.Fatalf("捕获的请求体 = %v，期望隐藏 password", body)
+	}
+	if body := entry["response_body"]; body != `{"ok":true,"token":"`+redactedToken+`"}` {
+		t.Fatalf("捕获的响应体 = %v，期望隐藏 token", body)
+	}
+	if entry["status"] != float64(http.StatusOK) || entry["route"] != "default" {
+		t.Fatalf("捕获记录 = %v", entry)
+	}
+
+	// limit 参数限制返回数量
+	_, result = adminDo(t, http.MethodGet, base+"/gatewaygo/capture?limit=1", testAdminToken, "")
+	if entries, _ := result["entries"].([]interface{}); len(entries) != 1 {
+		t.Fatalf("limit=1 时记录数 = %d", len(entries))
+	}
+
+	// 清空后不再返回记录
+	if status, _ := adminDo(t, http.MethodDelete, base+"/gatewaygo/capture", testAdminToken, ""); status != http.StatusOK {
+		t.Fatalf("清空捕获状态码 = %d", status)
+	}
+	_, result = adminDo(t, http.MethodGet, base+"/gatewaygo/capture", testAdminToken, "")
+	if entries, _ := result["entries"].([]interface{}); len(entries) != 0 {
+		t.Fatalf("清空后记录数 = %d，期望 0", len(entries))
+	}
+}
+
+func TestCaptureDisabled(t *testing.T) {
+	_, base := startTestServer(t, adminConfig(textUpstream(t, "ok").URL))
+	if status, _ := adminDo(t, http.MethodGet, base+"/gatewaygo/capture", testAdminToken, ""); status != http.StatusNotFound {
+		t.Fatalf("未启用捕获时状态码 = %d，期望 404", status)
+	}
+}
+
+func TestCaptureSampling(t *testing.T) {
+	buffer := newCaptureBuffer(config.CaptureConfig{Enabled: true, SampleRate: 0.5})
+	sampled := 0
+	for i := 0; i < 1000; i++ {
+		if buffer.sampled() {
+			sampled++
+		}
+	}
+	if sampled < 400 || sampled > 600 {
+		t.Fatalf("采样率 0.5 时采样 %d/1000 次", sampled)
+	}
+	if newCaptureBuffer(config.CaptureConfig{}).sampled() {
+		t.Fatal("未启用捕获时不应采样")
+	}
+}
@@ -0,0 +1,97 @@
+package server
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// drainLogInterval 优雅关闭期间输出剩余连接数的间隔
+const drainLogInterval = time.Second
+
+// connTracker 通过 http.Server.ConnState 统计HTTP和HTTPS的客户端连接，HTTP/3 连接不统计
+type connTracker struct {
+	conns map[net.Conn]http.ConnState
+	// 是否正在优雅关闭
+	draining atomic.Bool
+	mu       sync.Mutex
+}
+
+// connStats 客户端连接统计
+type connStats struct {
+	// 未关闭的连接总数
+	Total int `json:"total"`
+	// 正在处理请求的连接数
+	Active int `json:"active"`
+	// 等待下一个请求的 keep-alive 连接数
+	Idle int `json:"idle"`
+	// 已建立但尚未收到请求的连接数
+	New      int  `json:"new"`
+	Draining bool `json:"draining"`
+}
+
+// newConnTracker 创建连接统计
+func newConnTracker() *connTracker {
+	return &connTracker{conns: make(map[net.Conn]http.ConnState)}
+}
+
+// track 记录连接状态变化，作为 http.Server.ConnState 使用
+// 被接管的连接（如 WebSocket）不再由 http.Server 管理，不计入统计
+func (t *connTracker) track(conn net.Conn, state http.ConnState) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	switch state {
+	case http.StateClosed, http.StateHijacked:
+		delete(t.conns, conn)
+	default:
+		t.conns[conn] = state
+	}
+}
+
+// stats 返回当前的连接统计
+func (t *connTracker) stats() connStats {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	stats := connStats{Total: len(t.conns), Draining: t.draining.Load()}
+	for _, state := range t.conns {
+		switch state {
+		case http.StateActive:
+			stats.Active++
+		case http.StateIdle:
+			stats.Idle++
+		case http.StateNew:
+			stats.New++
+		}
+	}
+	return stats
+}
+
+// logDraining 优雅关闭期间定期输出剩余连接数，直到 done 关闭
+func (t *connTracker) logDraining(done <-chan struct{}) {
+	ticker := time.NewTicker(drainLogInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			stats := t.stats()
+			fmt.Printf("等待连接关闭: 剩余 %d 个连接，其中 %d 个正在处理请求\n", stats.Total, stats.Active)
+		}
+	}
+}
+
+// registerConnectionAdminRoutes 注册连接统计API
+func (s *Server) registerConnectionAdminRoutes(r *gin.Engine, token string) {
+	r.GET("/gatewaygo/connections", adminAuth(token), s.auditAdmin, s.handleConnections)
+}
+
+// handleConnections 查看当前客户端连接数
+func (s *Server) handleConnections(c *gin.Context) {
+	c.JSON(http.StatusOK, s.conns.stats())
+}
@@ -0,0 +1,129 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// holdRequests 发起 n 个请求并等待它们都到达上游，返回各请求的结果通道
+func holdRequests(t *testing.T, url string, n int, arrived <-chan struct{}) <-chan error {
+	t.Helper()
+	client := &http.Client{Transport: &http.Transport{}}
+	results := make(chan error, n)
+	for i := 0; i < n; i++ {
+		go func() {
+			resp, err := client.Get(url)
+			if err == nil {
+				resp.Body.Close()
+			}
+			results <- err
+		}()
+	}
+	for i := 0; i < n; i++ {
+		select {
+		case <-arrived:
+		case <-time.After(5 * time.Second):
+			t.Fatal("等待请求到达上游超时")
+		}
+	}
+	return results
+}
+
+// waitConns 等待未关闭的连接数变为 want
+func waitConns(t *testing.T, srv *Server, want int) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for srv.conns.stats().Total != want {
+		if time.Now().After(deadline) {
+			t.Fatalf("连接数 = %+v，期望 %d", srv.conns.stats(), want)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestConnectionCountDecreasesWhileDraining(t *testing.T) {
+	arrived := make(chan struct{}, 3)
+	release := make(chan struct{})
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		arrived <- struct{}{}
+		<-release
+	}))
+	defer upstream.Close()
+	defer close(release)
+
+	srv, base := startTestServer(t, adminConfig(upstream.URL))
+	results := holdRequests(t, base+"/", 3, arrived)
+
+	// 管理API统计的活跃连接包括查询自身
+	status, stats := adminDo(t, http.MethodGet, base+"/gatewaygo/connections", testAdminToken, "")
+	if status != http.StatusOK || stats["active"] != float64(4) || stats["draining"] != false {
+		t.Fatalf("连接统计 = %d %v，期望 4 个活跃连接且未在关闭", status, stats)
+	}
+
+	stopped := make(chan error, 1)
+	go func() { stopped <- srv.Stop() }()
+	deadline := time.Now().Add(5 * time.Second)
+	for !srv.conns.stats().Draining {
+		if time.Now().After(deadline) {
+			t.Fatal("等待开始优雅关闭超时")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	// 每完成一个请求，剩余连接数减一
+	waitConns(t, srv, 3)
+	for remaining := 2; remaining >= 0; remaining-- {
+		release <- struct{}{}
+		if err := <-results; err != nil {
+			t.Fatalf("优雅关闭期间进行中的请求失败: %v", err)
+		}
+		waitConns(t, srv, remaining)
+	}
+
+	select {
+	case err := <-stopped:
+		if err != nil {
+			t.Fatalf("优雅关闭失败: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("连接全部关闭后服务未停止")
+	}
+}
+
+func TestShutdownForceClosesAfterGracePeriod(t *testing.T) {
+	arrived := make(chan struct{}, 1)
+	release := make(chan struct{})
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		arrived <- struct{}{}
+		<-release
+	}))
+	defer upstream.Close()
+	defer close(release)
+
+	cfg := testConfig(upstream.URL)
+	cfg.Server.GracefulShutdownTimeout = 200 * time.Millisecond
+	srv, base := startTestServer(t, cfg)
+	results := holdRequests(t, base+"/", 1, arrived)
+
+	start := time.Now()
+	if err := srv.Stop(); err == nil || !strings.Contains(err.Error(), "关闭HTTP服务器失败") {
+		t.Fatalf("超过优雅关闭时间时 Stop 返回 %v，期望关闭失败", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Fatalf("优雅关闭耗时 %v，期望超时后立即强制关闭", elapsed)
+	}
+
+	// 未完成的请求被强制断开
+	select {
+	case err := <-results:
+		if err == nil {
+			t.Fatal("强制关闭后请求仍成功")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("强制关闭后请求未断开")
+	}
+	waitConns(t, srv, 0)
+}
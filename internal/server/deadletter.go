@@ -0,0 +1,155 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"gateway-go/internal/config"
+	"gateway-go/internal/logger"
+	"gateway-go/internal/metrics"
+	"gateway-go/internal/proxy"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+// defaultDeadLetterMaxBody 死信记录中请求体的默认最大长度
+const defaultDeadLetterMaxBody = 64 * 1024
+
+// deadLetters 死信记录次数
+var deadLetters = metrics.NewCounterVec(
+	"gateway_dead_letters_total",
+	"重试耗尽后仍转发失败的请求数",
+	"route",
+)
+
+// deadLetterEntry 死信记录，包含诊断和重放请求所需的信息
+type deadLetterEntry struct {
+	Time          time.Time           `json:"time"`
+	Route         string              `json:"route"`
+	Method        string              `json:"method"`
+	Host          string              `json:"host"`
+	URI           string              `json:"uri"`
+	ClientIP      string              `json:"client_ip"`
+	Headers       map[string][]string `json:"headers"`
+	Body          []byte              `json:"body,omitempty"`
+	BodyTruncated bool                `json:"body_truncated,omitempty"`
+	Attempts      []proxy.Attempt     `json:"attempts"`
+	Error         string              `json:"error"`
+}
+
+// deadLetterSink 死信输出，writer 为空时写入网关日志
+type deadLetterSink struct {
+	maxBody int
+	writer  io.WriteCloser
+	closed  bool
+	mu      sync.Mutex
+}
+
+// newDeadLetterSink 根据配置创建死信输出，未启用时返回 nil
+func newDeadLetterSink(cfg config.DeadLetterConfig) (*deadLetterSink, error) {
+	if !cfg.Enabled {
+		return nil, nil
+	}
+
+	sink := &deadLetterSink{maxBody: cfg.MaxBodySize}
+	if sink.maxBody == 0 {
+		sink.maxBody = defaultDeadLetterMaxBody
+	}
+
+	if cfg.Output != "" && cfg.Output != "log" {
+		if err := os.MkdirAll(filepath.Dir(cfg.Output), 0755); err != nil {
+			return nil, fmt.Errorf("创建死信日志目录失败: %w", err)
+		}
+		sink.writer = &lumberjack.Logger{Filename: cfg.Output}
+	}
+	return sink, nil
+}
+
+// bodyLimit 返回需要缓存的请求体长度
+func (d *deadLetterSink) bodyLimit() int {
+	if d == nil {
+		return 0
+	}
+	return d.maxBody
+}
+
+// record 记录一条死信
+func (d *deadLetterSink) record(c *gin.Context, route *config.RouteConfig, body []byte, complete bool, attempts []proxy.Attempt, err error) {
+	if d == nil {
+		return
+	}
+	deadLetters.Inc(route.Name)
+
+	if len(body) > d.maxBody {
+		body = body[:d.maxBody]
+		complete = false
+	}
+	entry := &deadLetterEntry{
+		Time:          time.Now(),
+		Route:         route.Name,
+		Method:        c.Request.Method,
+		Host:          c.Request.Host,
+		URI:           c.Request.RequestURI,
+		ClientIP:      c.ClientIP(),
+		Headers:       c.Request.Header.Clone(),
+		Body:          body,
+		BodyTruncated: !complete,
+		Attempts:      attempts,
+		Error:         err.Error(),
+	}
+
+	if d.writer == nil {
+		d.log(entry)
+		return
+	}
+
+	line, marshalErr := json.Marshal(entry)
+	if marshalErr != nil {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.closed {
+		return
+	}
+	if _, writeErr := d.writer.Write(append(line, '\n')); writeErr != nil && logger.Log != nil {
+		logger.Log.Warn("写入死信日志失败", zap.String("error", writeErr.Error()))
+	}
+}
+
+// log 将死信写入网关日志
+func (d *deadLetterSink) log(entry *deadLetterEntry) {
+	if logger.Log == nil || !logger.Log.Core().Enabled(zap.WarnLevel) {
+		return
+	}
+	logger.Log.Warn("死信请求",
+		zap.String("route_name", entry.Route),
+		zap.String("method", entry.Method),
+		zap.String("host", entry.Host),
+		zap.String("uri", entry.URI),
+		zap.String("client_ip", entry.ClientIP),
+		zap.Any("standard_headers", entry.Headers),
+		zap.Binary("body", entry.Body),
+		zap.Bool("body_truncated", entry.BodyTruncated),
+		zap.Any("attempts", entry.Attempts),
+		zap.String("error", entry.Error),
+	)
+}
+
+// Close 关闭死信文件
+func (d *deadLetterSink) Close() error {
+	if d == nil || d.writer == nil {
+		return nil
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.closed = true
+	return d.writer.Close()
+}
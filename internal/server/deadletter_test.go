@@ -0,0 +1,117 @@
+package server
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gateway-go/internal/config"
+)
+
+// refusedURL 返回一个拒绝连接的上游地址
+func refusedURL(t *testing.T) string {
+	t.Helper()
+	return fmt.Sprintf("http://127.0.0.1:%d", freePort(t))
+}
+
+// readDeadLetters 读取死信文件中的记录
+func readDeadLetters(t *testing.T, path string) []deadLetterEntry {
+	t.Helper()
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("打开死信文件失败: %v", err)
+	}
+	defer file.Close()
+
+	var entries []deadLetterEntry
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var entry deadLetterEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			t.Fatalf("解析死信记录失败: %v", err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+func TestDeadLetterRecordsExhaustedRetries(t *testing.T) {
+	output := filepath.Join(t.TempDir(), "dead-letter.log")
+	cfg := testConfig(refusedURL(t) + "," + refusedURL(t))
+	cfg.Server.DeadLetter = config.DeadLetterConfig{Enabled: true, Output: output}
+	cfg.Routes[0].Target.Retries = 1
+	_, base := startTestServer(t, cfg)
+
+	req, _ := http.NewRequest(http.MethodPost, base+"/orders?id=7", strings.NewReader(`{"item":"book"}`))
+	req.Header.Set("X-Trace", "abc")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadGateway {
+		t.Fatalf("所有后端不可用时状态码 = %d，期望 502", resp.StatusCode)
+	}
+
+	entries := readDeadLetters(t, output)
+	if len(entries) != 1 {
+		t.Fatalf("死信记录数 = %d，期望 1", len(entries))
+	}
+	entry := entries[0]
+	if entry.Route != "default" || entry.Method != http.MethodPost || entry.URI != "/orders?id=7" {
+		t.Fatalf("死信记录请求信息 = %s %s %s", entry.Route, entry.Method, entry.URI)
+	}
+	if string(entry.Body) != `{"item":"book"}` || entry.BodyTruncated {
+		t.Fatalf("死信记录请求体 = %q（截断: %v）", entry.Body, entry.BodyTruncated)
+	}
+	if got := entry.Headers["X-Trace"]; len(got) != 1 || got[0] != "abc" {
+		t.Fatalf("死信记录请求头 X-Trace = %v", got)
+	}
+	// 首次请求和一次重试分别发往两个后端
+	if len(entry.Attempts) != 2 || entry.Attempts[0].Backend == entry.Attempts[1].Backend {
+		t.Fatalf("死信记录尝试 = %+v，期望两个不同后端各一次", entry.Attempts)
+	}
+	if entry.Error == "" {
+		t.Fatal("死信记录缺少错误信息")
+	}
+}
+
+func TestDeadLetterTruncatesBody(t *testing.T) {
+	output := filepath.Join(t.TempDir(), "dead-letter.log")
+	cfg := testConfig(refusedURL(t))
+	cfg.Server.DeadLetter = config.DeadLetterConfig{Enabled: true, Output: output, MaxBodySize: 4}
+	_, base := startTestServer(t, cfg)
+
+	resp, err := http.Post(base+"/upload", "text/plain", strings.NewReader("0123456789"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	entries := readDeadLetters(t, output)
+	if len(entries) != 1 || string(entries[0].Body) != "0123" || !entries[0].BodyTruncated {
+		t.Fatalf("死信记录 = %+v，期望请求体截断为 0123", entries)
+	}
+}
+
+func TestDeadLetterSkipsSuccessfulRequests(t *testing.T) {
+	upstream := textUpstream(t, "ok")
+	output := filepath.Join(t.TempDir(), "dead-letter.log")
+	cfg := testConfig(upstream.URL)
+	cfg.Server.DeadLetter = config.DeadLetterConfig{Enabled: true, Output: output}
+	_, base := startTestServer(t, cfg)
+
+	if status, _ := get(t, base+"/"); status != http.StatusOK {
+		t.Fatalf("状态码 = %d，期望 200", status)
+	}
+	if _, err := os.Stat(output); err == nil {
+		if entries := readDeadLetters(t, output); len(entries) != 0 {
+			t.Fatalf("成功请求不应记录死信: %+v", entries)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"sort"
+	"testing"
+
+	"gateway-go/internal/config"
+)
+
+func TestErrorEnvelopeConsistent(t *testing.T) {
+	upstream := textUpstream(t, "ok")
+	cfg := testConfig(upstream.URL)
+	down := refusedURL(t)
+	cfg.Routes = []config.RouteConfig{
+		{Name: "limited", Match: config.RouteMatch{Type: "prefix", Path: "/limited"}, Target: config.TargetConfig{URL: upstream.URL}, Plugins: []string{"rate_limit"}},
+		{Name: "secure", Match: config.RouteMatch{Type: "prefix", Path: "/secure"}, Target: config.TargetConfig{URL: upstream.URL}, Plugins: []string{"api_key"}},
+		{Name: "down", Match: config.RouteMatch{Type: "prefix", Path: "/down"}, Target: config.TargetConfig{URL: down}, Plugins: []string{"circuit_breaker"}},
+	}
+	cfg.Plugins.Available = []config.PluginConfig{
+		{Name: "rate_limit", Enabled: true, Config: map[string]interface{}{"ip_based": true, "requests_per_second": 0.001, "burst": 1}},
+		{Name: "api_key", Enabled: true, Config: map[string]interface{}{"keys": []interface{}{map[string]interface{}{"key": "k1", "consumer": "alice"}}}},
+		{Name: "circuit_breaker", Enabled: true, Config: map[string]interface{}{"min_requests": 2, "failure_threshold": 50}},
+	}
+	_, base := startTestServer(t, cfg)
+
+	// 消耗限流令牌
+	if status, _ := get(t, base+"/limited"); status != http.StatusOK {
+		t.Fatalf("限流前状态码 = %d，期望 200", status)
+	}
+
+	tests := []struct {
+		name   string
+		path   string
+		status int
+	}{
+		{"未匹配路由", "/missing", http.StatusNotFound},
+		{"限流", "/limited", http.StatusTooManyRequests},
+		{"未认证", "/secure", http.StatusUnauthorized},
+		{"上游不可用", "/down", http.StatusBadGateway},
+		{"上游不可用", "/down", http.StatusBadGateway},
+		{"熔断", "/down", http.StatusServiceUnavailable},
+	}
+	for _, tt := range tests {
+		req, _ := http.NewRequest(http.MethodGet, base+tt.path, nil)
+		resp, body := doRequest(t, req)
+		if resp.StatusCode != tt.status {
+			t.Fatalf("%s: 状态码 = %d %s，期望 %d", tt.name, resp.StatusCode, body, tt.status)
+		}
+		if got := resp.Header.Get("Content-Type"); got != "application/json; charset=utf-8" {
+			t.Fatalf("%s: Content-Type = %q", tt.name, got)
+		}
+
+		var envelope map[string]interface{}
+		if err := json.Unmarshal([]byte(body), &envelope); err != nil {
+			t.Fatalf("%s: 解析响应 %s 失败: %v", tt.name, body, err)
+		}
+		keys := make([]string, 0, len(envelope))
+		for key := range envelope {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		if !reflect.DeepEqual(keys, []string{"code", "details", "message", "request_id"}) {
+			t.Fatalf("%s: 响应字段 = %v，期望 code、message、request_id、details", tt.name, keys)
+		}
+		if code, _ := envelope["code"].(float64); int(code) != tt.status {
+			t.Fatalf("%s: code = %v，期望 %d", tt.name, envelope["code"], tt.status)
+		}
+		if message, _ := envelope["message"].(string); message == "" {
+			t.Fatalf("%s: message 为空", tt.name)
+		}
+		if id, _ := envelope["request_id"].(string); id == "" || id != resp.Header.Get("X-Request-ID") {
+			t.Fatalf("%s: request_id = %q，响应头 X-Request-ID = %q", tt.name, id, resp.Header.Get("X-Request-ID"))
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package server
+
+import (
+	"fmt"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"gateway-go/internal/config"
+	"gateway-go/internal/errors"
+	"gateway-go/internal/metrics"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 错误预算默认配置
+const (
+	defaultBudgetWindow       = time.Minute
+	defaultBudgetMinRequests  = 20
+	defaultBudgetMaxShedRatio = 0.9
+)
+
+// shedRequests 因错误预算耗尽被拒绝的请求数
+var shedRequests = metrics.NewCounterVec(
+	"gateway_error_budget_shed_total",
+	"因错误预算耗尽被拒绝的请求数",
+	"route",
+)
+
+// errorBudgetManager 按路由缓存错误率统计，配置未变化时在重载后保留
+type errorBudgetManager struct {
+	budgets map[string]*errorBudget
+	mu      sync.Mutex
+}
+
+// errorBudget 单个路由的错误预算状态
+type errorBudget struct {
+	signature    string
+	budget       float64
+	maxShedRatio float64
+	status       int
+	window       time.Duration
+	handler      *errors.FallbackHandler
+}
+
+// newErrorBudgetManager 创建错误预算管理器
+func newErrorBudgetManager() *errorBudgetManager {
+	return &errorBudgetManager{
+		budgets: make(map[string]*errorBudget),
+	}
+}
+
+// get 获取路由的错误预算状态，路由未配置错误预算时返回 nil
+func (m *errorBudgetManager) get(route *config.RouteConfig) *errorBudget {
+	cfg := route.ErrorBudget
+	if cfg == nil {
+		return nil
+	}
+	signature := fmt.Sprintf("%v|%v|%d|%v|%d", cfg.Budget, cfg.Window, cfg.MinRequests, cfg.MaxShedRatio, cfg.Status)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if b, exists := m.budgets[route.Name]; exists && b.signature == signature {
+		return b
+	}
+
+	b := &errorBudget{
+		signature:    signature,
+		budget:       cfg.Budget,
+		maxShedRatio: cfg.MaxShedRatio,
+		status:       cfg.Status,
+		window:       cfg.Window,
+	}
+	if b.maxShedRatio == 0 {
+		b.maxShedRatio = defaultBudgetMaxShedRatio
+	}
+	if b.status == 0 {
+		b.status = http.StatusServiceUnavailable
+	}
+	if b.window == 0 {
+		b.window = defaultBudgetWindow
+	}
+	minRequests := cfg.MinRequests
+	if minRequests == 0 {
+		minRequests = defaultBudgetMinRequests
+	}
+	b.handler = errors.NewFallbackHandler(errors.FallbackConfig{
+		WindowSize:        b.window,
+		ErrorThreshold:    b.budget,
+		RecoveryThreshold: b.budget,
+		MinRequests:       minRequests,
+	})
+	m.budgets[route.Name] = b
+	return b
+}
+
+// shedRatio 返回当前应拒绝的请求比例
+// 错误率超出预算越多拒绝越多，放行的请求结果继续计入窗口，错误率回落后自动停止拒绝
+func (b *errorBudget) shedRatio() float64 {
+	if !b.handler.IsDegraded() {
+		return 0
+	}
+	ratio := (b.handler.GetErrorRate() - b.budget) / (1 - b.budget)
+	if ratio > b.maxShedRatio {
+		ratio = b.maxShedRatio
+	}
+	return ratio
+}
+
+// admit 按拒绝比例决定是否放行请求，拒绝时写出响应并中止请求
+func (b *errorBudget) admit(c *gin.Context, route string) bool {
+	ratio := b.shedRatio()
+	if ratio <= 0 || rand.Float64() >= ratio {
+		return true
+	}
+	shedRequests.Inc(route)
+	c.Header("Retry-After", strconv.Itoa(int(b.window/time.Second)+1))
+	errors.Abort(c, b.status, "错误预算已耗尽，请稍后重试")
+	return false
+}
+
+// record 记录请求结果，只统计上游响应和上游连接失败、超时，插件拒绝等网关自身的响应不计入
+func (b *errorBudget) record(c *gin.Context) {
+	if _, ok := c.Get(upstreamResponseKey); ok {
+		if c.Writer.Status() >= http.StatusInternalServerError {
+			b.handler.RecordError(fmt.Errorf("上游返回 %d", c.Writer.Status()))
+		} else {
+			b.handler.RecordSuccess()
+		}
+		return
+	}
+	switch c.GetString(gatewayErrorKey) {
+	case gatewayErrorTimeout, gatewayErrorUnavailable:
+		b.handler.RecordError(fmt.Errorf("上游不可用: %s", c.GetString(gatewayErrorKey)))
+	}
+}
@@ -0,0 +1,100 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"gateway-go/internal/config"
+)
+
+func TestErrorBudgetShedsLoad(t *testing.T) {
+	var failing atomic.Bool
+	failing.Store(true)
+	var hits atomic.Int64
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		if failing.Load() {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer upstream.Close()
+
+	cfg := testConfig(upstream.URL)
+	cfg.Routes[0].ErrorBudget = &config.ErrorBudgetConfig{
+		Budget:       0.2,
+		Window:       300 * time.Millisecond,
+		MinRequests:  5,
+		MaxShedRatio: 0.8,
+		Status:       http.StatusTooManyRequests,
+	}
+	_, base := startTestServer(t, cfg)
+
+	// 请求数未达到 min_requests 前不拒绝
+	for i := 0; i < 5; i++ {
+		if status, _ := get(t, base+"/"); status != http.StatusInternalServerError {
+			t.Fatalf("第 %d 个请求状态码 = %d，期望上游的 500", i+1, status)
+		}
+	}
+
+	// 错误率 100% 超出预算，按最大拒绝比例拒绝，其余请求继续探测上游
+	shed, before := 0, hits.Load()
+	for i := 0; i < 100; i++ {
+		req, _ := http.NewRequest(http.MethodGet, base+"/", nil)
+		resp, _ := doRequest(t, req)
+		if resp.StatusCode == http.StatusTooManyRequests {
+			shed++
+			if resp.Header.Get("Retry-After") == "" {
+				t.Fatal("拒绝响应缺少 Retry-After")
+			}
+		}
+	}
+	if shed < 60 || shed > 95 {
+		t.Fatalf("拒绝 %d/100 个请求，期望约 80%%", shed)
+	}
+	if probes := hits.Load() - before; probes != int64(100-shed) {
+		t.Fatalf("上游收到 %d 个请求，期望只收到放行的 %d 个", probes, 100-shed)
+	}
+
+	// 上游恢复且旧结果移出窗口后停止拒绝
+	failing.Store(false)
+	time.Sleep(350 * time.Millisecond)
+	for i := 0; i < 20; i++ {
+		if status, _ := get(t, base+"/"); status != http.StatusOK {
+			t.Fatalf("恢复后第 %d 个请求状态码 = %d，期望 200", i+1, status)
+		}
+	}
+}
+
+func TestErrorBudgetShedRatio(t *testing.T) {
+	m := newErrorBudgetManager()
+	route := &config.RouteConfig{Name: "orders", ErrorBudget: &config.ErrorBudgetConfig{Budget: 0.5, MinRequests: 4}}
+	b := m.get(route)
+
+	for i := 0; i < 3; i++ {
+		b.handler.RecordSuccess()
+	}
+	b.handler.RecordError(nil)
+	if got := b.shedRatio(); got != 0 {
+		t.Fatalf("错误率 25%% 未超出预算时拒绝比例 = %v", got)
+	}
+
+	// 错误率 75% 超出预算一半，拒绝一半请求
+	for i := 0; i < 8; i++ {
+		b.handler.RecordError(nil)
+	}
+	if got := b.shedRatio(); got < 0.49 || got > 0.51 {
+		t.Fatalf("错误率 %.2f 时拒绝比例 = %v，期望 0.5", b.handler.GetErrorRate(), got)
+	}
+
+	// 配置未变化时保留统计，变化后重新统计
+	if m.get(route) != b {
+		t.Fatal("配置未变化时应复用错误预算状态")
+	}
+	changed := &config.RouteConfig{Name: "orders", ErrorBudget: &config.ErrorBudgetConfig{Budget: 0.3, MinRequests: 4}}
+	if next := m.get(changed); next == b || next.handler.GetErrorRate() != 0 {
+		t.Fatal("配置变化后应重新统计")
+	}
+}
@@ -0,0 +1,95 @@
+package server
+
+import (
+	"bytes"
+	"time"
+
+	"gateway-go/internal/config"
+	"gateway-go/internal/logger"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// errorLogMaxBody 失败请求日志中请求体和响应体的最大记录长度
+const errorLogMaxBody = 4096
+
+// errorCapture 暂存请求和响应详情，仅在响应为错误时输出日志
+type errorCapture struct {
+	startTime time.Time
+	reqBody   []byte
+	headers   map[string][]string
+	writer    *limitedBodyWriter
+}
+
+// newErrorCapture 开始暂存请求详情并接管响应写入，读取请求体失败时中止请求并返回 false
+func newErrorCapture(c *gin.Context, route *config.RouteConfig) (*errorCapture, bool) {
+	capture := &errorCapture{
+		startTime: time.Now(),
+		headers:   c.Request.Header.Clone(),
+	}
+	reqBody, ok := readBodyPrefix(c, route.Name, errorLogMaxBody)
+	if !ok {
+		return nil, false
+	}
+	capture.reqBody = reqBody
+	capture.writer = newLimitedBodyWriter(c.Writer, errorLogMaxBody)
+	c.Writer = capture.writer
+	return capture, true
+}
+
+// finish 响应为 4xx/5xx 时输出暂存的详情，否则丢弃
+func (e *errorCapture) finish(c *gin.Context, route *config.RouteConfig) {
+	defer e.writer.release()
+	status := c.Writer.Status()
+	if status < 400 || logger.Log == nil || !logger.Log.Core().Enabled(zap.WarnLevel) {
+		return
+	}
+
+	logger.Log.Warn("请求失败",
+		zap.String("route_name", route.Name),
+		zap.String("method", c.Request.Method),
+		zap.String("path", c.Request.URL.Path),
+		zap.String("raw_query", c.Request.URL.RawQuery),
+		zap.String("client_ip", c.ClientIP()),
+		zap.Any("standard_headers", e.headers),
+		zap.String("body", string(e.reqBody)),
+		zap.Int("status", status),
+		zap.Any("resp_headers", c.Writer.Header()),
+		zap.String("resp_body", e.writer.body.String()),
+		zap.Duration("cost", time.Since(e.startTime)),
+	)
+}
+
+// limitedBodyWriter 记录响应体前 limit 字节的写入器
+type limitedBodyWriter struct {
+	gin.ResponseWriter
+	// 从 bodyBuffers 获取，release 后为 nil
+	body  *bytes.Buffer
+	limit int
+}
+
+// newLimitedBodyWriter 创建记录响应体的写入器，使用完毕后需调用 release 归还缓冲区
+func newLimitedBodyWriter(w gin.ResponseWriter, limit int) *limitedBodyWriter {
+	return &limitedBodyWriter{ResponseWriter: w, body: bodyBuffers.Get(), limit: limit}
+}
+
+func (w *limitedBodyWriter) Write(b []byte) (int, error) {
+	if w.body == nil {
+		return w.ResponseWriter.Write(b)
+	}
+	if remain := w.limit - w.body.Len(); remain > 0 {
+		if len(b) > remain {
+			w.body.Write(b[:remain])
+		} else {
+			w.body.Write(b)
+		}
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// release 归还缓冲区，之后的写入不再记录
+func (w *limitedBodyWriter) release() {
+	bodyBuffers.Put(w.body)
+	w.body = nil
+}
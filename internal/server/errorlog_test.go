@@ -0,0 +1,61 @@
+package server
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+func TestLogOnErrorLogsOnlyFailedRequests(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/fail" {
+			w.WriteHeader(http.StatusInternalServerError)
+			io.WriteString(w, "upstream failure")
+			return
+		}
+		io.WriteString(w, "fine")
+	}))
+	defer upstream.Close()
+
+	cfg := testConfig(upstream.URL)
+	cfg.Routes[0].LogOnError = true
+	_, base := startTestServer(t, cfg)
+	logs := observeLogs(t, zap.WarnLevel)
+
+	if status, _ := get(t, base+"/ok"); status != http.StatusOK {
+		t.Fatalf("状态码 = %d，期望 200", status)
+	}
+	if n := logs.FilterMessage("请求失败").Len(); n != 0 {
+		t.Fatalf("成功请求产生了 %d 条失败日志", n)
+	}
+
+	if status, body := get(t, base+"/fail"); status != http.StatusInternalServerError || body != "upstream failure" {
+		t.Fatalf("响应 = %d %q，期望原样返回上游错误", status, body)
+	}
+	entries := logs.FilterMessage("请求失败").All()
+	if len(entries) != 1 {
+		t.Fatalf("失败请求产生了 %d 条失败日志，期望 1 条", len(entries))
+	}
+	fields := entries[0].ContextMap()
+	if fields["path"] != "/fail" || fields["status"] != int64(http.StatusInternalServerError) || fields["resp_body"] != "upstream failure" {
+		t.Fatalf("失败日志内容不完整: %v", fields)
+	}
+}
+
+func TestLogOnErrorDisabled(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer upstream.Close()
+
+	_, base := startTestServer(t, testConfig(upstream.URL))
+	logs := observeLogs(t, zap.WarnLevel)
+
+	get(t, base+"/")
+	if n := logs.FilterMessage("请求失败").Len(); n != 0 {
+		t.Fatalf("未启用 log_on_error 时产生了 %d 条失败日志", n)
+	}
+}
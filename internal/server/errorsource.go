@@ -0,0 +1,53 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// upstreamResponseKey 标记响应来自上游的上下文键
+	upstreamResponseKey = "_upstream_response"
+	// gatewayErrorKey 网关错误原因的上下文键
+	gatewayErrorKey = "_gateway_error"
+
+	// 网关错误原因
+	gatewayErrorDefault     = "gateway"
+	gatewayErrorNoRoute     = "route_not_found"
+	gatewayErrorTimeout     = "upstream_timeout"
+	gatewayErrorUnavailable = "upstream_unavailable"
+	gatewayErrorOverloaded  = "overloaded"
+)
+
+// errorSourceMiddleware 为网关自身产生的 4xx/5xx 响应添加诊断头，上游响应原样转发
+func errorSourceMiddleware(header string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Writer = &errorSourceWriter{ResponseWriter: c.Writer, context: c, header: header}
+		c.Next()
+	}
+}
+
+// errorSourceWriter 在写入错误状态码时判断响应来源
+type errorSourceWriter struct {
+	gin.ResponseWriter
+	context *gin.Context
+	header  string
+}
+
+// WriteHeader 写入状态码，非上游的错误响应附加诊断头
+func (w *errorSourceWriter) WriteHeader(code int) {
+	if code >= 400 && !w.Written() && !w.context.GetBool(upstreamResponseKey) {
+		reason := w.context.GetString(gatewayErrorKey)
+		if reason == "" {
+			reason = gatewayErrorDefault
+		}
+		w.Header().Set(w.header, reason)
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+// Unwrap 返回被包装的写入器，供 http.ResponseController 访问底层连接
+func (w *errorSourceWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,59 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestUpstreamErrorBodyPassthrough(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/problem+json")
+		w.Header().Set("X-Upstream-Error", "invalid_order")
+		status := http.StatusBadRequest
+		if r.URL.Path == "/fail" {
+			status = http.StatusInternalServerError
+		}
+		w.WriteHeader(status)
+		w.Write([]byte(`{"type":"invalid_order","detail":"quantity must be positive"}`))
+	}))
+	defer upstream.Close()
+
+	cfg := testConfig(upstream.URL)
+	cfg.Server.ErrorSourceHeader = "X-Gateway-Error"
+	usePlugin(cfg, "circuit_breaker", map[string]interface{}{"min_requests": 100})
+	_, base := startTestServer(t, cfg)
+
+	for _, tt := range []struct {
+		path   string
+		status int
+	}{
+		{"/orders", http.StatusBadRequest},
+		{"/fail", http.StatusInternalServerError},
+	} {
+		req, _ := http.NewRequest(http.MethodGet, base+tt.path, nil)
+		resp, body := doRequest(t, req)
+		if resp.StatusCode != tt.status || body != `{"type":"invalid_order","detail":"quantity must be positive"}` {
+			t.Fatalf("%s 响应 = %d %q，期望上游的 %d 响应体原样返回", tt.path, resp.StatusCode, body, tt.status)
+		}
+		if resp.Header.Get("Content-Type") != "application/problem+json" || resp.Header.Get("X-Upstream-Error") != "invalid_order" {
+			t.Fatalf("%s 上游响应头未原样返回: %v", tt.path, resp.Header)
+		}
+		// 上游产生的错误不附加网关诊断头
+		if got := resp.Header.Get("X-Gateway-Error"); got != "" {
+			t.Fatalf("%s 上游错误响应带有网关诊断头 %q", tt.path, got)
+		}
+	}
+}
+
+func TestGatewayErrorDiagnosticHeader(t *testing.T) {
+	cfg := testConfig(refusedURL(t))
+	cfg.Server.ErrorSourceHeader = "X-Gateway-Error"
+	_, base := startTestServer(t, cfg)
+
+	req, _ := http.NewRequest(http.MethodGet, base+"/", nil)
+	resp, _ := doRequest(t, req)
+	if resp.StatusCode != http.StatusBadGateway || resp.Header.Get("X-Gateway-Error") != gatewayErrorUnavailable {
+		t.Fatalf("上游不可用时响应 = %d，诊断头 %q，期望 502 和 %s", resp.StatusCode, resp.Header.Get("X-Gateway-Error"), gatewayErrorUnavailable)
+	}
+}
@@ -0,0 +1,34 @@
+package server
+
+import (
+	"net/http"
+	"sync/atomic"
+)
+
+// atomicHandler 可原子替换的请求处理器
+// 配置重载时新引擎构建完成后整体替换，进行中的请求继续使用旧引擎处理完毕
+type atomicHandler struct {
+	current atomic.Value // handlerHolder
+}
+
+// handlerHolder 保证 atomic.Value 中存储的具体类型一致
+type handlerHolder struct {
+	http.Handler
+}
+
+// newAtomicHandler 创建可原子替换的请求处理器
+func newAtomicHandler(handler http.Handler) *atomicHandler {
+	h := &atomicHandler{}
+	h.Store(handler)
+	return h
+}
+
+// Store 替换当前处理器，之后到达的请求使用新处理器
+func (h *atomicHandler) Store(handler http.Handler) {
+	h.current.Store(handlerHolder{handler})
+}
+
+// ServeHTTP 实现 http.Handler
+func (h *atomicHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.current.Load().(handlerHolder).ServeHTTP(w, r)
+}
@@ -0,0 +1,76 @@
+package server
+
+import (
+	"io"
+	"net/http"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestReloadUnderLoad(t *testing.T) {
+	one := textUpstream(t, "one")
+	two := textUpstream(t, "two")
+	srv, base := startTestServer(t, testConfig(one.URL))
+
+	var (
+		stop     atomic.Bool
+		requests atomic.Int64
+		wg       sync.WaitGroup
+		failures = make(chan string, 1)
+	)
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for !stop.Load() {
+				resp, err := http.Get(base + "/")
+				if err != nil {
+					select {
+					case failures <- err.Error():
+					default:
+					}
+					return
+				}
+				body, _ := io.ReadAll(resp.Body)
+				resp.Body.Close()
+				requests.Add(1)
+				// 每个请求都由完整构建的引擎处理，转发到新旧目标之一
+				if resp.StatusCode != http.StatusOK || (string(body) != "one" && string(body) != "two") {
+					select {
+					case failures <- resp.Status + " " + string(body):
+					default:
+					}
+					return
+				}
+			}
+		}()
+	}
+
+	// 在请求进行中交替切换路由目标并重建引擎
+	for i := 0; i < 50; i++ {
+		// 等待上一次重载后有新的请求完成
+		for done := requests.Load(); requests.Load() == done && len(failures) == 0; {
+			runtime.Gosched()
+		}
+		next := testConfig(one.URL)
+		if i%2 == 0 {
+			next = testConfig(two.URL)
+		}
+		if err := srv.configManager.ApplyConfig(next); err != nil {
+			t.Fatalf("应用配置失败: %v", err)
+		}
+	}
+	stop.Store(true)
+	wg.Wait()
+
+	select {
+	case failure := <-failures:
+		t.Fatalf("重载期间请求失败: %s", failure)
+	default:
+	}
+	if _, body := get(t, base+"/"); body != "one" {
+		t.Fatalf("最后一次重载后请求转发到 %q，期望 one", body)
+	}
+}
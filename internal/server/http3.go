@@ -0,0 +1,45 @@
+package server
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/http"
+
+	"gateway-go/internal/config"
+
+	"github.com/quic-go/quic-go/http3"
+)
+
+// http3Enabled 判断是否启用HTTP/3，需要同时启用HTTPS
+func http3Enabled(cfg *config.Config) bool {
+	return tlsEnabled(cfg) && cfg.Server.TLS.HTTP3 != nil && cfg.Server.TLS.HTTP3.Enabled
+}
+
+// newHTTP3Server 创建HTTP/3服务器，与HTTPS共用证书（含热加载）、客户端认证和处理器
+func newHTTP3Server(tlsConfig *tls.Config, port int, handler http.Handler, cfg *config.ServerConfig) *http3.Server {
+	h3TLS := tlsConfig.Clone()
+	// QUIC 只支持 TLS 1.3，ALPN 由 http3 设置
+	h3TLS.MinVersion = tls.VersionTLS13
+	h3TLS.NextProtos = nil
+	idleTimeout := cfg.IdleTimeout
+	if idleTimeout == 0 {
+		idleTimeout = cfg.ReadTimeout
+	}
+	return &http3.Server{
+		Addr:           fmt.Sprintf(":%d", port),
+		Port:           port,
+		Handler:        handler,
+		TLSConfig:      h3TLS,
+		MaxHeaderBytes: cfg.MaxHeaderBytes,
+		IdleTimeout:    idleTimeout,
+	}
+}
+
+// altSvcHandler 在HTTPS响应中添加 Alt-Svc 响应头，告知客户端可以改用HTTP/3
+func altSvcHandler(h3 *http3.Server, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// HTTP/3 尚未开始监听时不添加
+		_ = h3.SetQUICHeaders(w.Header())
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,54 @@
+package server
+
+import (
+	"crypto/tls"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+
+	"gateway-go/internal/config"
+
+	"github.com/quic-go/quic-go/http3"
+)
+
+func TestHTTP3ListenerServesRequests(t *testing.T) {
+	certFile, keyFile := writeTestCert(t)
+	cfg := testConfig(textUpstream(t, "h3 upstream").URL)
+	cfg.Server.TLS = &config.TLSConfig{
+		Enabled:  true,
+		Port:     freePort(t),
+		CertFile: certFile,
+		KeyFile:  keyFile,
+		HTTP2:    true,
+		HTTP3:    &config.HTTP3Config{Enabled: true},
+	}
+	srv, _ := startTestServer(t, cfg)
+
+	// HTTPS响应通过 Alt-Svc 公布HTTP/3端口
+	_, tlsPort, _ := net.SplitHostPort(srv.TLSAddr())
+	resp, err := tlsClient(t, certFile).Get("https://127.0.0.1:" + tlsPort + "/")
+	if err != nil {
+		t.Fatalf("HTTPS请求失败: %v", err)
+	}
+	resp.Body.Close()
+	_, h3Port, _ := net.SplitHostPort(srv.HTTP3Addr())
+	if altSvc := resp.Header.Get("Alt-Svc"); !strings.Contains(altSvc, `h3=":`+h3Port+`"`) {
+		t.Fatalf("Alt-Svc = %q，期望公布HTTP/3端口 %s", altSvc, h3Port)
+	}
+
+	// HTTP/3 与HTTPS共用证书和处理器
+	rootCAs := tlsClient(t, certFile).Transport.(*http.Transport).TLSClientConfig.RootCAs
+	transport := &http3.Transport{TLSClientConfig: &tls.Config{RootCAs: rootCAs}}
+	defer transport.Close()
+	resp, err = (&http.Client{Transport: transport}).Get("https://127.0.0.1:" + h3Port + "/")
+	if err != nil {
+		t.Fatalf("HTTP/3请求失败: %v", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if resp.ProtoMajor != 3 || string(body) != "h3 upstream" {
+		t.Fatalf("HTTP/3响应 = %s %q，期望 HTTP/3 %q", resp.Proto, body, "h3 upstream")
+	}
+}
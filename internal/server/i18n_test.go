@@ -0,0 +1,65 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	gwerrors "gateway-go/internal/errors"
+)
+
+func TestProxyErrorLocalized(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "en.json"), []byte(`{"PROXY_FAILED": "Proxy request failed"}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { gwerrors.ReloadErrorMessages("") })
+
+	cfg := testConfig(refusedURL(t))
+	cfg.Server.ErrorMessagesDir = dir
+	_, base := startTestServer(t, cfg)
+
+	tests := []struct {
+		accept string
+		prefix string
+	}{
+		{"en-US,zh;q=0.5", "Proxy request failed: "},
+		{"fr, en;q=0.3", "Proxy request failed: "},
+		// 未匹配已加载的语言时使用默认消息
+		{"fr", "代理请求失败: "},
+		{"", "代理请求失败: "},
+	}
+	for _, tt := range tests {
+		req, _ := http.NewRequest(http.MethodGet, base+"/", nil)
+		if tt.accept != "" {
+			req.Header.Set("Accept-Language", tt.accept)
+		}
+		resp, body := doRequest(t, req)
+		var envelope gwerrors.Response
+		if err := json.Unmarshal([]byte(body), &envelope); err != nil {
+			t.Fatalf("解析响应 %s 失败: %v", body, err)
+		}
+		if resp.StatusCode != http.StatusBadGateway || !strings.HasPrefix(envelope.Message, tt.prefix) {
+			t.Fatalf("Accept-Language %q 的响应 = %d %q，期望 502 和前缀 %q", tt.accept, resp.StatusCode, envelope.Message, tt.prefix)
+		}
+	}
+}
+
+func TestErrorMessagesDirInvalid(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "en.json"), []byte("{"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { gwerrors.ReloadErrorMessages("") })
+
+	cfg := testConfig(refusedURL(t))
+	cfg.Server.ErrorMessagesDir = dir
+	cfg.Server.Port = freePort(t)
+	if err := newTestServer(t, cfg).Start(context.Background()); err == nil {
+		t.Fatal("错误消息文件格式错误时期望启动失败")
+	}
+}
@@ -0,0 +1,207 @@
+package server
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"gateway-go/internal/plugin/plugins/ipwhitelist"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 维护模式默认响应
+const (
+	defaultMaintenanceBody        = `{"error":"服务维护中，请稍后重试"}`
+	defaultMaintenanceContentType = "application/json; charset=utf-8"
+)
+
+// maintenanceMode 单个作用范围的维护模式设置
+type maintenanceMode struct {
+	// 作用的路由，为空表示全局
+	Route       string `json:"route,omitempty"`
+	Status      int    `json:"status"`
+	Body        string `json:"body"`
+	ContentType string `json:"content_type"`
+	// Retry-After 响应头（秒），为 0 时不设置
+	RetryAfter int `json:"retry_after,omitempty"`
+	// 不受维护模式影响的客户端 IP 或 CIDR
+	AllowIPs []string  `json:"allow_ips,omitempty"`
+	Since    time.Time `json:"since"`
+}
+
+// maintenanceState 全局和路由级维护模式，只保存在内存中，配置重载不受影响
+type maintenanceState struct {
+	global *maintenanceMode
+	routes map[string]*maintenanceMode
+	mu     sync.RWMutex
+}
+
+// newMaintenanceState 创建维护模式状态
+func newMaintenanceState() *maintenanceState {
+	return &maintenanceState{
+		routes: make(map[string]*maintenanceMode),
+	}
+}
+
+// admit 路由或全局处于维护模式时返回维护响应并返回 false，路由级设置优先
+func (m *maintenanceState) admit(c *gin.Context, route string) bool {
+	m.mu.RLock()
+	mode := m.routes[route]
+	if mode == nil {
+		mode = m.global
+	}
+	m.mu.RUnlock()
+
+	if mode == nil || ipwhitelist.Contains(mode.AllowIPs, c.ClientIP()) {
+		return true
+	}
+
+	if mode.RetryAfter > 0 {
+		c.Header("Retry-After", strconv.Itoa(mode.RetryAfter))
+	}
+	c.Data(mode.Status, mode.ContentType, []byte(mode.Body))
+	c.Abort()
+	return false
+}
+
+// set 开启维护模式，route 为空时作用于所有路由
+func (m *maintenanceState) set(mode *maintenanceMode) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if mode.Route == "" {
+		m.global = mode
+		return
+	}
+	m.routes[mode.Route] = mode
+}
+
+// clear 关闭维护模式
+func (m *maintenanceState) clear(route string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if route == "" {
+		m.global = nil
+		return
+	}
+	delete(m.routes, route)
+}
+
+// list 返回当前生效的维护模式，全局设置在前
+func (m *maintenanceState) list() []*maintenanceMode {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	modes := make([]*maintenanceMode, 0, len(m.routes)+1)
+	if m.global != nil {
+		modes = append(modes, m.global)
+	}
+	for _, mode := range m.routes {
+		modes = append(modes, mode)
+	}
+	sort.SliceStable(modes, func(i, j int) bool {
+		return modes[i].Route < modes[j].Route
+	})
+	return modes
+}
+
+// maintenanceRequest 设置维护模式的请求体
+type maintenanceRequest struct {
+	// 作用的路由，为空表示全局
+	Route   string `json:"route"`
+	Enabled *bool  `json:"enabled" binding:"required"`
+	// 维护响应，未设置时返回 503 和默认 JSON 响应体
+	Status      int      `json:"status"`
+	Body        string   `json:"body"`
+	ContentType string   `json:"content_type"`
+	RetryAfter  int      `json:"retry_after"`
+	AllowIPs    []string `json:"allow_ips"`
+}
+
+// toMode 校验请求并转换为维护模式设置
+func (r *maintenanceRequest) toMode() (*maintenanceMode, error) {
+	mode := &maintenanceMode{
+		Route:       r.Route,
+		Status:      r.Status,
+		Body:        r.Body,
+		ContentType: r.ContentType,
+		RetryAfter:  r.RetryAfter,
+		AllowIPs:    r.AllowIPs,
+		Since:       time.Now(),
+	}
+	if mode.Status == 0 {
+		mode.Status = http.StatusServiceUnavailable
+	}
+	if mode.Status < 100 || mode.Status > 599 {
+		return nil, fmt.Errorf("无效的状态码: %d", mode.Status)
+	}
+	if mode.Body == "" {
+		mode.Body = defaultMaintenanceBody
+		if mode.ContentType == "" {
+			mode.ContentType = defaultMaintenanceContentType
+		}
+	}
+	if mode.ContentType == "" {
+		mode.ContentType = "text/plain; charset=utf-8"
+	}
+	if mode.RetryAfter < 0 {
+		return nil, fmt.Errorf("无效的 retry_after: %d", mode.RetryAfter)
+	}
+	for _, entry := range mode.AllowIPs {
+		if net.ParseIP(entry) == nil {
+			if _, _, err := net.ParseCIDR(entry); err != nil {
+				return nil, fmt.Errorf("无效的 IP 或 CIDR: %s", entry)
+			}
+		}
+	}
+	return mode, nil
+}
+
+// registerMaintenanceAdminRoutes 注册维护模式管理API
+func (s *Server) registerMaintenanceAdminRoutes(r *gin.Engine, token string) {
+	maintenance := r.Group("/gatewaygo/maintenance", adminAuth(token), s.auditAdmin)
+	maintenance.GET("", s.handleListMaintenance)
+	maintenance.POST("", s.handleSetMaintenance)
+}
+
+// handleListMaintenance 查看当前生效的维护模式
+func (s *Server) handleListMaintenance(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"maintenance": s.maintenance.list(),
+	})
+}
+
+// handleSetMaintenance 开启或关闭全局、路由级维护模式
+func (s *Server) handleSetMaintenance(c *gin.Context) {
+	var req maintenanceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求: " + err.Error()})
+		return
+	}
+	setAuditDetail(c, gin.H{"route": req.Route, "enabled": *req.Enabled})
+
+	if req.Route != "" {
+		if _, exists := s.routerManager.GetRoute(req.Route); !exists {
+			c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("路由 %s 不存在", req.Route)})
+			return
+		}
+	}
+
+	if !*req.Enabled {
+		s.maintenance.clear(req.Route)
+		c.JSON(http.StatusOK, gin.H{"maintenance": s.maintenance.list()})
+		return
+	}
+
+	mode, err := req.toMode()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	s.maintenance.set(mode)
+	c.JSON(http.StatusOK, gin.H{"maintenance": s.maintenance.list()})
+}
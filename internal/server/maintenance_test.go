@@ -0,0 +1,92 @@
+package server
+
+import (
+	"net/http"
+	"testing"
+
+	"gateway-go/internal/config"
+)
+
+func TestMaintenanceToggle(t *testing.T) {
+	upstream := textUpstream(t, "ok")
+	cfg := adminConfig(upstream.URL)
+	cfg.Routes = append(cfg.Routes, config.RouteConfig{
+		Name:   "orders",
+		Match:  config.RouteMatch{Type: "prefix", Path: "/orders", Priority: 10},
+		Target: config.TargetConfig{URL: upstream.URL},
+	})
+	_, base := startTestServer(t, cfg)
+	// setMaintenance 调用管理API设置维护模式
+	setMaintenance := func(body string) (int, map[string]interface{}) {
+		return adminDo(t, http.MethodPost, base+"/gatewaygo/maintenance", testAdminToken, body)
+	}
+	// expect 校验路径的响应
+	expect := func(path string, status int, body string) {
+		t.Helper()
+		if gotStatus, got := get(t, base+path); gotStatus != status || got != body {
+			t.Fatalf("%s 响应 = %d %q，期望 %d %q", path, gotStatus, got, status, body)
+		}
+	}
+
+	if status, _ := adminDo(t, http.MethodPost, base+"/gatewaygo/maintenance", "", `{"enabled":true}`); status != http.StatusUnauthorized {
+		t.Fatalf("未携带令牌时状态码 = %d，期望 401", status)
+	}
+
+	// 路由级维护只影响该路由，使用自定义响应
+	status, body := setMaintenance(`{"route":"orders","enabled":true,"status":502,"body":"升级中","retry_after":120}`)
+	if status != http.StatusOK {
+		t.Fatalf("开启路由维护 = %d %v", status, body)
+	}
+	expect("/orders/1", http.StatusBadGateway, "升级中")
+	expect("/users/1", http.StatusOK, "ok")
+	req, _ := http.NewRequest(http.MethodGet, base+"/orders/1", nil)
+	resp, _ := doRequest(t, req)
+	if resp.Header.Get("Retry-After") != "120" || resp.Header.Get("Content-Type") != "text/plain; charset=utf-8" {
+		t.Fatalf("维护响应头 = %v", resp.Header)
+	}
+
+	// 全局维护影响其他路由，路由级设置优先
+	if status, body := setMaintenance(`{"enabled":true}`); status != http.StatusOK {
+		t.Fatalf("开启全局维护 = %d %v", status, body)
+	}
+	expect("/users/1", http.StatusServiceUnavailable, defaultMaintenanceBody)
+	expect("/orders/1", http.StatusBadGateway, "升级中")
+
+	_, body = adminDo(t, http.MethodGet, base+"/gatewaygo/maintenance", testAdminToken, "")
+	modes, _ := body["maintenance"].([]interface{})
+	if len(modes) != 2 || modes[0].(map[string]interface{})["route"] != nil || modes[1].(map[string]interface{})["route"] != "orders" {
+		t.Fatalf("维护模式列表 = %v，期望全局设置在前", body)
+	}
+
+	// 关闭路由维护后该路由使用全局设置
+	setMaintenance(`{"route":"orders","enabled":false}`)
+	expect("/orders/1", http.StatusServiceUnavailable, defaultMaintenanceBody)
+
+	// 白名单中的客户端不受维护模式影响
+	setMaintenance(`{"enabled":true,"allow_ips":["127.0.0.0/8"]}`)
+	expect("/users/1", http.StatusOK, "ok")
+
+	// 全部关闭后恢复转发，管理API始终可用
+	setMaintenance(`{"enabled":false}`)
+	expect("/orders/1", http.StatusOK, "ok")
+	expect("/users/1", http.StatusOK, "ok")
+}
+
+func TestMaintenanceRejectsInvalidRequest(t *testing.T) {
+	_, base := startTestServer(t, adminConfig("http://127.0.0.1:1"))
+
+	for body, want := range map[string]int{
+		`{"route":"missing","enabled":true}`:        http.StatusNotFound,
+		`{"enabled":true,"status":700}`:             http.StatusBadRequest,
+		`{"enabled":true,"retry_after":-1}`:         http.StatusBadRequest,
+		`{"enabled":true,"allow_ips":["10.0.0.x"]}`: http.StatusBadRequest,
+		`{"route":"default"}`:                       http.StatusBadRequest,
+	} {
+		if status, _ := adminDo(t, http.MethodPost, base+"/gatewaygo/maintenance", testAdminToken, body); status != want {
+			t.Fatalf("请求 %s 状态码 = %d，期望 %d", body, status, want)
+		}
+	}
+	if _, body := adminDo(t, http.MethodGet, base+"/gatewaygo/maintenance", testAdminToken, ""); len(body["maintenance"].([]interface{})) != 0 {
+		t.Fatalf("无效请求不应开启维护模式: %v", body)
+	}
+}
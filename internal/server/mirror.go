@@ -0,0 +1,136 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"gateway-go/internal/config"
+	"gateway-go/internal/logger"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// 流量镜像
+const (
+	// mirrorHeader 镜像请求的标记请求头
+	mirrorHeader = "X-Gateway-Mirror"
+	// defaultMirrorTimeout 镜像请求的默认超时时间
+	defaultMirrorTimeout = 5 * time.Second
+)
+
+// hopHeaders 逐跳请求头，不复制到镜像请求
+var hopHeaders = []string{
+	"Connection",
+	"Keep-Alive",
+	"Proxy-Connection",
+	"Te",
+	"Trailer",
+	"Transfer-Encoding",
+	"Upgrade",
+}
+
+// mirrorSampled 按镜像百分比决定是否镜像本次请求
+func mirrorSampled(mirror *config.MirrorConfig) bool {
+	if mirror == nil || mirror.Percentage <= 0 {
+		return false
+	}
+	return mirror.Percentage >= 100 || rand.Float64()*100 < mirror.Percentage
+}
+
+// mirrorRequest 异步将请求复制到镜像服务，镜像响应被丢弃，失败只记录日志
+// 请求头和请求体在调用时复制，不受转发过程修改的影响
+func (s *Server) mirrorRequest(c *gin.Context, route *config.RouteConfig, body []byte, path, rawPath string) {
+	mirror := route.Target.Mirror
+	target, err := url.Parse(mirror.URL)
+	if err != nil {
+		return
+	}
+	transport, err := s.connectionPool.GetTransport(mirrorRoute(route))
+	if err != nil {
+		logMirrorError(route, err)
+		return
+	}
+
+	reqURL := *target
+	reqURL.Path = singleJoiningSlash(target.Path, path)
+	if rawPath != "" {
+		reqURL.RawPath = singleJoiningSlash(target.EscapedPath(), rawPath)
+	}
+	reqURL.RawQuery = c.Request.URL.RawQuery
+
+	header := c.Request.Header.Clone()
+	for _, name := range hopHeaders {
+		header.Del(name)
+	}
+	header.Set("X-Forwarded-Host", c.Request.Host)
+	header.Set(mirrorHeader, "true")
+	method := c.Request.Method
+
+	timeout := defaultMirrorTimeout
+	if mirror.Timeout > 0 {
+		timeout = time.Duration(mirror.Timeout) * time.Millisecond
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+
+		req, err := http.NewRequestWithContext(ctx, method, reqURL.String(), bytes.NewReader(body))
+		if err != nil {
+			logMirrorError(route, err)
+			return
+		}
+		req.Header = header
+		if len(body) == 0 {
+			req.Body = http.NoBody
+		}
+
+		resp, err := transport.RoundTrip(req)
+		if err != nil {
+			logMirrorError(route, err)
+			return
+		}
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+	}()
+}
+
+// mirrorRoute 镜像服务使用的路由配置，上游连接与主目标相互独立
+func mirrorRoute(route *config.RouteConfig) *config.RouteConfig {
+	mirror := *route
+	mirror.Name = route.Name + "|mirror"
+	mirror.Target.URL = route.Target.Mirror.URL
+	mirror.Target.TLS = nil
+	return &mirror
+}
+
+// logMirrorError 记录镜像请求失败，不影响客户端请求
+func logMirrorError(route *config.RouteConfig, err error) {
+	if logger.Log != nil && logger.Log.Core().Enabled(zap.InfoLevel) {
+		logger.Log.Info("镜像请求失败",
+			zap.String("route_name", route.Name),
+			zap.String("mirror_url", route.Target.Mirror.URL),
+			zap.String("error", err.Error()),
+		)
+	}
+}
+
+// singleJoiningSlash 拼接路径，与 httputil.NewSingleHostReverseProxy 的规则一致
+func singleJoiningSlash(a, b string) string {
+	aslash := strings.HasSuffix(a, "/")
+	bslash := strings.HasPrefix(b, "/")
+	switch {
+	case aslash && bslash:
+		return a + b[1:]
+	case !aslash && !bslash:
+		return a + "/" + b
+	}
+	return a + b
+}
@@ -0,0 +1,148 @@
+package server
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"gateway-go/internal/config"
+
+	"go.uber.org/zap"
+)
+
+// mirroredRequest 镜像服务收到的请求
+type mirroredRequest struct {
+	method string
+	uri    string
+	header http.Header
+	body   string
+}
+
+// mirrorServer 记录收到的请求并返回 500，hold 为 true 时在测试结束前不返回响应
+func mirrorServer(t *testing.T, hold bool) (*httptest.Server, chan mirroredRequest) {
+	t.Helper()
+	requests := make(chan mirroredRequest, 500)
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests <- mirroredRequest{method: r.Method, uri: r.RequestURI, header: r.Header.Clone(), body: string(body)}
+		if hold {
+			<-release
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	t.Cleanup(server.Close)
+	t.Cleanup(func() { close(release) })
+	return server, requests
+}
+
+func TestMirrorDoesNotAffectClient(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		io.WriteString(w, "primary:"+string(body))
+	}))
+	defer upstream.Close()
+	mirror, requests := mirrorServer(t, true)
+
+	cfg := testConfig(upstream.URL)
+	cfg.Routes[0].Target.Mirror = &config.MirrorConfig{URL: mirror.URL, Percentage: 100}
+	_, base := startTestServer(t, cfg)
+
+	// 镜像服务一直不返回响应，客户端仍立即收到主上游的响应
+	start := time.Now()
+	req, _ := http.NewRequest(http.MethodPost, base+"/api/orders?id=7", strings.NewReader(`{"item":"book"}`))
+	req.Header.Set("Content-Type", "application/json")
+	resp, body := doRequest(t, req)
+	if resp.StatusCode != http.StatusOK || body != `primary:{"item":"book"}` {
+		t.Fatalf("客户端响应 = %d %q，期望主上游的响应", resp.StatusCode, body)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("客户端请求耗时 %v，镜像请求不应增加延迟", elapsed)
+	}
+
+	select {
+	case r := <-requests:
+		if r.method != http.MethodPost || r.uri != "/api/orders?id=7" || r.body != `{"item":"book"}` {
+			t.Fatalf("镜像请求 = %s %s %q", r.method, r.uri, r.body)
+		}
+		if r.header.Get(mirrorHeader) != "true" || r.header.Get("Content-Type") != "application/json" {
+			t.Fatalf("镜像请求头 = %v，期望带有 %s 标记并保留原请求头", r.header, mirrorHeader)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("镜像服务未收到请求")
+	}
+}
+
+func TestMirrorSamplesPercentage(t *testing.T) {
+	upstream := textUpstream(t, "ok")
+	mirror, requests := mirrorServer(t, false)
+
+	tests := []struct {
+		percentage float64
+		min, max   int
+	}{
+		{0, 0, 0},
+		{30, 35, 85},
+		{100, 200, 200},
+	}
+	for _, tt := range tests {
+		cfg := testConfig(upstream.URL)
+		cfg.Routes[0].Target.Mirror = &config.MirrorConfig{URL: mirror.URL, Percentage: tt.percentage}
+		srv, base := startTestServer(t, cfg)
+
+		for i := 0; i < 200; i++ {
+			if status, body := get(t, base+"/"); status != http.StatusOK || body != "ok" {
+				t.Fatalf("镜像比例 %v%% 时客户端响应 = %d %q", tt.percentage, status, body)
+			}
+		}
+		srv.Stop()
+
+		// 等待异步镜像请求全部到达
+		mirrored := 0
+		for done := false; !done; {
+			select {
+			case <-requests:
+				mirrored++
+			case <-time.After(300 * time.Millisecond):
+				done = true
+			}
+		}
+		if mirrored < tt.min || mirrored > tt.max {
+			t.Fatalf("镜像比例 %v%% 时镜像了 %d/200 个请求，期望在 [%d, %d] 范围内", tt.percentage, mirrored, tt.min, tt.max)
+		}
+	}
+}
+
+func TestMirrorFailureIsLogged(t *testing.T) {
+	var primary atomic.Int64
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		primary.Add(1)
+	}))
+	defer upstream.Close()
+	mirror := httptest.NewServer(http.NotFoundHandler())
+	mirror.Close()
+
+	cfg := testConfig(upstream.URL)
+	cfg.Routes[0].Target.Mirror = &config.MirrorConfig{URL: mirror.URL, Percentage: 100}
+	_, base := startTestServer(t, cfg)
+	logs := observeLogs(t, zap.InfoLevel)
+
+	// 镜像服务不可用时客户端请求照常成功，失败只记录日志
+	if status, _ := get(t, base+"/"); status != http.StatusOK || primary.Load() != 1 {
+		t.Fatalf("镜像服务不可用时状态码 = %d，主上游收到 %d 个请求", status, primary.Load())
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for logs.FilterMessage("镜像请求失败").Len() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("镜像请求失败未记录日志")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if entry := logs.FilterMessage("镜像请求失败").All()[0]; entry.ContextMap()["mirror_url"] != mirror.URL {
+		t.Fatalf("镜像失败日志字段 = %v", entry.ContextMap())
+	}
+}
@@ -0,0 +1,214 @@
+package server
+
+import (
+	"fmt"
+	"log"
+
+	"gateway-go/internal/config"
+	"gateway-go/internal/plugin"
+	"gateway-go/internal/plugin/core"
+	"gateway-go/internal/plugin/plugins/apikey"
+	"gateway-go/internal/plugin/plugins/bulkhead"
+	"gateway-go/internal/plugin/plugins/circuitbreaker"
+	"gateway-go/internal/plugin/plugins/consistency"
+	"gateway-go/internal/plugin/plugins/contenttype"
+	"gateway-go/internal/plugin/plugins/cors"
+	"gateway-go/internal/plugin/plugins/deadline"
+	errorplugin "gateway-go/internal/plugin/plugins/error"
+	"gateway-go/internal/plugin/plugins/errornormalize"
+	"gateway-go/internal/plugin/plugins/featureflag"
+	"gateway-go/internal/plugin/plugins/geoip"
+	"gateway-go/internal/plugin/plugins/hmacauth"
+	"gateway-go/internal/plugin/plugins/interface_auth"
+	"gateway-go/internal/plugin/plugins/ipwhitelist"
+	"gateway-go/internal/plugin/plugins/jsonschema"
+	"gateway-go/internal/plugin/plugins/quota"
+	"gateway-go/internal/plugin/plugins/ratelimit"
+	"gateway-go/internal/plugin/plugins/wasm"
+)
+
+// registerPlugins 注册所有插件
+func (s *Server) registerPlugins() {
+	// 注册限流插件
+	if err := s.pluginManager.Register(ratelimit.New()); err != nil {
+		log.Printf("注册限流插件失败: %v", err)
+	}
+
+	// 注册熔断器插件
+	s.circuitBreaker = circuitbreaker.New()
+	if err := s.pluginManager.Register(s.circuitBreaker); err != nil {
+		log.Printf("注册熔断器插件失败: %v", err)
+	}
+
+	// 注册跨域插件
+	// 跨域策略通常因路由而异，通过工厂注册以支持路由级配置
+	if err := s.pluginManager.RegisterFactory(func() core.Plugin { return cors.New() }); err != nil {
+		log.Printf("注册跨域插件失败: %v", err)
+	}
+
+	// 注册错误处理插件
+	if err := s.pluginManager.Register(errorplugin.New()); err != nil {
+		log.Printf("注册错误处理插件失败: %v", err)
+	}
+
+	// 注册IP白名单插件
+	if err := s.pluginManager.Register(ipwhitelist.New()); err != nil {
+		log.Printf("注册IP白名单插件失败: %v", err)
+	}
+
+	// 注册一致性校验插件
+	if err := s.pluginManager.Register(consistency.New()); err != nil {
+		log.Printf("注册一致性校验插件失败: %v", err)
+	}
+
+	// 注册外部接口认证插件
+	if err := s.pluginManager.Register(interface_auth.New()); err != nil {
+		log.Printf("注册外部接口认证插件失败: %v", err)
+	}
+
+	// 注册截止时间插件
+	if err := s.pluginManager.Register(deadline.New()); err != nil {
+		log.Printf("注册截止时间插件失败: %v", err)
+	}
+
+	// 注册配额插件
+	if err := s.pluginManager.Register(quota.New()); err != nil {
+		log.Printf("注册配额插件失败: %v", err)
+	}
+
+	// 注册功能开关插件
+	if err := s.pluginManager.Register(featureflag.New()); err != nil {
+		log.Printf("注册功能开关插件失败: %v", err)
+	}
+
+	// 注册 API Key 认证插件
+	if err := s.pluginManager.Register(apikey.New()); err != nil {
+		log.Printf("注册 API Key 认证插件失败: %v", err)
+	}
+
+	// 注册 HMAC 请求签名插件
+	if err := s.pluginManager.Register(hmacauth.New()); err != nil {
+		log.Printf("注册 HMAC 请求签名插件失败: %v", err)
+	}
+
+	// 注册请求体类型校验插件
+	// 各路由接受的请求体类型不同，通过工厂注册以支持路由级配置
+	if err := s.pluginManager.RegisterFactory(func() core.Plugin { return contenttype.New() }); err != nil {
+		log.Printf("注册请求体类型校验插件失败: %v", err)
+	}
+
+	// 注册 JSON Schema 请求校验插件
+	// 各路由的请求体结构不同，通过工厂注册以支持路由级配置
+	if err := s.pluginManager.RegisterFactory(func() core.Plugin { return jsonschema.New() }); err != nil {
+		log.Printf("注册 JSON Schema 请求校验插件失败: %v", err)
+	}
+
+	// 注册并发隔离插件
+	// 各路由上游的处理能力不同，通过工厂注册以支持路由级配置
+	if err := s.pluginManager.RegisterFactory(func() core.Plugin { return bulkhead.New() }); err != nil {
+		log.Printf("注册并发隔离插件失败: %v", err)
+	}
+
+	// 注册地理位置插件
+	// 各路由允许访问的地区可能不同，通过工厂注册以支持路由级配置
+	if err := s.pluginManager.RegisterFactory(func() core.Plugin { return geoip.New() }); err != nil {
+		log.Printf("注册地理位置插件失败: %v", err)
+	}
+
+	// 注册 WASM 插件
+	// 各路由执行的 WASM 模块不同，通过工厂注册以支持路由级配置
+	if err := s.pluginManager.RegisterFactory(func() core.Plugin { return wasm.New() }); err != nil {
+		log.Printf("注册 WASM 插件失败: %v", err)
+	}
+
+	// 注册错误响应规范化插件
+	// 各路由上游的错误格式和状态码约定不同，通过工厂注册以支持路由级配置
+	if err := s.pluginManager.RegisterFactory(func() core.Plugin { return errornormalize.New() }); err != nil {
+		log.Printf("注册错误响应规范化插件失败: %v", err)
+	}
+
+	fmt.Println("✓ 所有插件已注册")
+}
+
+// TestPlugins 按配置初始化所有启用的插件和路由级插件实例，用于启动前检查插件配置
+// 检查完成后停止已初始化的插件，不启动监听
+func TestPlugins(cfg *config.Config) error {
+	return testPlugins(plugin.NewManager(), cfg)
+}
+
+// testPlugins 在 manager 上注册并初始化插件，返回前停止 manager 中已初始化的插件
+func testPlugins(manager *plugin.Manager, cfg *config.Config) error {
+	s := &Server{pluginManager: manager}
+	defer s.pluginManager.Stop()
+
+	s.registerPlugins()
+	if err := s.registerExternalPlugins(cfg); err != nil {
+		return err
+	}
+	if err := s.loadAvailablePlugins(cfg); err != nil {
+		return err
+	}
+	return s.loadRoutePlugins(cfg)
+}
+
+// registerExternalPlugins 加载并注册 plugins.external 中尚未加载的外部插件
+// Go 插件加载后无法卸载，从配置中移除的外部插件在重启前保持注册
+func (s *Server) registerExternalPlugins(cfg *config.Config) error {
+	for _, path := range cfg.Plugins.External {
+		if _, loaded := s.externalPlugins[path]; loaded {
+			continue
+		}
+		name, err := s.pluginManager.RegisterExternal(path)
+		if err != nil {
+			return fmt.Errorf("加载外部插件 %s 失败: %w", path, err)
+		}
+		if s.externalPlugins == nil {
+			s.externalPlugins = make(map[string]string)
+		}
+		s.externalPlugins[path] = name
+		fmt.Printf("✓ 已加载外部插件 %s: %s\n", name, path)
+	}
+	return nil
+}
+
+// loadAvailablePlugins 加载可用插件配置
+func (s *Server) loadAvailablePlugins(cfg *config.Config) error {
+	if cfg.Plugins.Available == nil {
+		return nil
+	}
+
+	// 转换配置格式
+	var pluginConfigs []plugin.PluginConfig
+	for _, p := range cfg.Plugins.Available {
+		pluginConfigs = append(pluginConfigs, plugin.PluginConfig{
+			Name:        p.Name,
+			Enabled:     p.Enabled,
+			Order:       p.Order,
+			Config:      p.Config,
+			CacheTTL:    p.CacheTTL,
+			SkipPaths:   p.SkipPaths,
+			SkipMethods: p.SkipMethods,
+		})
+	}
+
+	// 加载可用插件
+	if err := s.pluginManager.LoadAvailablePlugins(pluginConfigs); err != nil {
+		return fmt.Errorf("加载可用插件失败: %v", err)
+	}
+
+	fmt.Printf("✓ 已加载 %d 个可用插件\n", len(pluginConfigs))
+	return nil
+}
+
+// loadRoutePlugins 加载路由插件
+func (s *Server) loadRoutePlugins(cfg *config.Config) error {
+	for _, route := range cfg.Routes {
+		if len(route.Plugins) > 0 {
+			if err := s.pluginManager.LoadRoutePlugins(route.Name, route.Plugins, route.PluginConfig, route.PluginOrder == "list"); err != nil {
+				return fmt.Errorf("加载路由 %s 的插件失败: %v", route.Name, err)
+			}
+			fmt.Printf("✓ 路由 %s 已加载插件: %v\n", route.Name, route.Plugins)
+		}
+	}
+	return nil
+}
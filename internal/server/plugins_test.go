@@ -0,0 +1,526 @@
+package server
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"gateway-go/internal/config"
+	"gateway-go/internal/plugin"
+	"gateway-go/internal/plugin/core"
+
+	"github.com/gin-gonic/gin"
+)
+
+// usePlugin 启用插件并添加到所有路由
+func usePlugin(cfg *config.Config, name string, settings map[string]interface{}) {
+	cfg.Plugins.Available = append(cfg.Plugins.Available, config.PluginConfig{
+		Name:    name,
+		Enabled: true,
+		Config:  settings,
+	})
+	for i := range cfg.Routes {
+		cfg.Routes[i].Plugins = append(cfg.Routes[i].Plugins, name)
+	}
+}
+
+// doRequest 发送请求并返回响应（响应体已读取并关闭）和响应体
+func doRequest(t *testing.T, req *http.Request) (*http.Response, string) {
+	t.Helper()
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("请求 %s 失败: %v", req.URL, err)
+	}
+	defer resp.Body.Close()
+	body := new(strings.Builder)
+	io.Copy(body, resp.Body)
+	return resp, body.String()
+}
+
+func TestDeadlinePluginAbortsSlowUpstream(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			select {
+			case <-r.Context().Done():
+				return
+			case <-time.After(2 * time.Second):
+			}
+		}
+		io.WriteString(w, r.Header.Get("X-Request-Deadline")+" "+r.Header.Get("Grpc-Timeout"))
+	}))
+	defer upstream.Close()
+
+	cfg := testConfig(upstream.URL)
+	usePlugin(cfg, "deadline", map[string]interface{}{"grpc_timeout": true})
+	_, base := startTestServer(t, cfg)
+
+	// 截止时间传递给上游
+	req, _ := http.NewRequest(http.MethodGet, base+"/fast", nil)
+	req.Header.Set("X-Request-Deadline", "1500")
+	resp, body := doRequest(t, req)
+	forwarded, grpcTimeout, _ := strings.Cut(body, " ")
+	millis, _ := strconv.Atoi(forwarded)
+	if resp.StatusCode != http.StatusOK || millis <= 0 || millis > 1500 || !strings.HasSuffix(grpcTimeout, "m") {
+		t.Fatalf("上游收到的截止时间 = %q，期望不超过 1500ms 的剩余时间和 grpc-timeout", body)
+	}
+
+	// 较短的截止时间中止慢上游
+	req, _ = http.NewRequest(http.MethodGet, base+"/slow", nil)
+	req.Header.Set("X-Request-Deadline", "100ms")
+	start := time.Now()
+	resp, _ = doRequest(t, req)
+	if resp.StatusCode != http.StatusGatewayTimeout {
+		t.Fatalf("超过截止时间时状态码 = %d，期望 504", resp.StatusCode)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("请求耗时 %v，期望在截止时间后立即返回", elapsed)
+	}
+
+	// 无效的截止时间
+	req, _ = http.NewRequest(http.MethodGet, base+"/fast", nil)
+	req.Header.Set("X-Request-Deadline", "tomorrow")
+	if resp, _ := doRequest(t, req); resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("无效截止时间的状态码 = %d，期望 400", resp.StatusCode)
+	}
+}
+
+func TestOpenCircuitBreakerSkipsUpstream(t *testing.T) {
+	var hits atomic.Int64
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer upstream.Close()
+
+	cfg := testConfig(upstream.URL)
+	usePlugin(cfg, "circuit_breaker", map[string]interface{}{"min_requests": 2, "failure_threshold": 50})
+	_, base := startTestServer(t, cfg)
+
+	for i := 0; i < 2; i++ {
+		if status, _ := get(t, base+"/"); status != http.StatusInternalServerError {
+			t.Fatalf("熔断前状态码 = %d，期望上游的 500", status)
+		}
+	}
+
+	// 熔断后直接返回 503，不再请求上游
+	req, _ := http.NewRequest(http.MethodGet, base+"/", nil)
+	resp, body := doRequest(t, req)
+	if resp.StatusCode != http.StatusServiceUnavailable || resp.Header.Get("Retry-After") == "" {
+		t.Fatalf("熔断后响应 = %d %q，期望 503 和 Retry-After", resp.StatusCode, body)
+	}
+	if got := hits.Load(); got != 2 {
+		t.Fatalf("上游请求次数 = %d，期望熔断后不再转发（2）", got)
+	}
+}
+
+func TestErrorPluginServesFallback(t *testing.T) {
+	var hits atomic.Int64
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer upstream.Close()
+
+	cfg := testConfig(upstream.URL)
+	usePlugin(cfg, "error", map[string]interface{}{
+		"fallback": map[string]interface{}{
+			"min_requests":    2,
+			"error_threshold": 0.5,
+			"routes": map[string]interface{}{
+				"default": map[string]interface{}{"status": 200, "content": "cached", "content_type": "text/plain"},
+			},
+		},
+	})
+	_, base := startTestServer(t, cfg)
+
+	for i := 0; i < 2; i++ {
+		if status, _ := get(t, base+"/"); status != http.StatusInternalServerError {
+			t.Fatalf("降级前状态码 = %d，期望上游的 500", status)
+		}
+	}
+
+	// 错误率超过阈值后返回路由配置的降级响应，不再请求上游
+	req, _ := http.NewRequest(http.MethodGet, base+"/", nil)
+	resp, body := doRequest(t, req)
+	if resp.StatusCode != http.StatusOK || body != "cached" || resp.Header.Get("Content-Type") != "text/plain" {
+		t.Fatalf("降级响应 = %d %q（%s），期望路由配置的降级响应", resp.StatusCode, body, resp.Header.Get("Content-Type"))
+	}
+	if got := hits.Load(); got != 2 {
+		t.Fatalf("上游请求次数 = %d，期望降级后不再转发（2）", got)
+	}
+}
+
+// preflightRequest 发送来自 origin 的预检请求
+func preflightRequest(t *testing.T, url, origin string) *http.Response {
+	t.Helper()
+	req, _ := http.NewRequest(http.MethodOptions, url, nil)
+	req.Header.Set("Origin", origin)
+	req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+	resp, _ := doRequest(t, req)
+	return resp
+}
+
+func TestPerRouteCORS(t *testing.T) {
+	cfg := testConfig(textUpstream(t, "ok").URL)
+	cfg.Routes = append(cfg.Routes, config.RouteConfig{
+		Name:   "internal",
+		Match:  config.RouteMatch{Type: "prefix", Path: "/internal", Priority: 10},
+		Target: cfg.Routes[0].Target,
+	})
+	usePlugin(cfg, "cors", map[string]interface{}{
+		"allowed_origins": []interface{}{"https://public.example.com"},
+	})
+	cfg.Routes[1].PluginConfig = map[string]map[string]interface{}{
+		"cors": {
+			"allowed_origins": []interface{}{"https://admin.example.com"},
+			"allowed_methods": []interface{}{"GET", "DELETE"},
+		},
+	}
+	_, base := startTestServer(t, cfg)
+
+	tests := []struct {
+		path        string
+		origin      string
+		wantStatus  int
+		wantMethods string
+	}{
+		{"/api", "https://public.example.com", http.StatusNoContent, "GET, POST, PUT, DELETE, OPTIONS"},
+		{"/api", "https://admin.example.com", http.StatusForbidden, ""},
+		{"/internal/users", "https://admin.example.com", http.StatusNoContent, "GET, DELETE"},
+		{"/internal/users", "https://public.example.com", http.StatusForbidden, ""},
+	}
+	for _, tt := range tests {
+		resp := preflightRequest(t, base+tt.path, tt.origin)
+		if resp.StatusCode != tt.wantStatus || resp.Header.Get("Access-Control-Allow-Methods") != tt.wantMethods {
+			t.Fatalf("%s 来自 %s 的预检 = %d（%q），期望 %d（%q）", tt.path, tt.origin,
+				resp.StatusCode, resp.Header.Get("Access-Control-Allow-Methods"), tt.wantStatus, tt.wantMethods)
+		}
+	}
+}
+
+func TestCORSPreflightSkipsUpstream(t *testing.T) {
+	var hits atomic.Int64
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		io.WriteString(w, "upstream")
+	}))
+	defer upstream.Close()
+
+	cfg := testConfig(upstream.URL)
+	usePlugin(cfg, "cors", map[string]interface{}{"allowed_origins": []interface{}{"https://app.example.com"}})
+	_, base := startTestServer(t, cfg)
+
+	// 预检请求由网关直接返回 204，不转发到上游
+	for _, tt := range []struct {
+		origin string
+		status int
+	}{
+		{"https://app.example.com", http.StatusNoContent},
+		{"https://evil.example.com", http.StatusForbidden},
+	} {
+		req, _ := http.NewRequest(http.MethodOptions, base+"/orders", nil)
+		req.Header.Set("Origin", tt.origin)
+		req.Header.Set("Access-Control-Request-Method", http.MethodPut)
+		resp, body := doRequest(t, req)
+		if resp.StatusCode != tt.status || body != "" {
+			t.Fatalf("来自 %s 的预检响应 = %d %q，期望 %d 且无响应体", tt.origin, resp.StatusCode, body, tt.status)
+		}
+	}
+	if got := hits.Load(); got != 0 {
+		t.Fatalf("预检请求转发到上游 %d 次，期望 0", got)
+	}
+
+	// 不是预检的 OPTIONS 请求照常转发
+	req, _ := http.NewRequest(http.MethodOptions, base+"/orders", nil)
+	if _, body := doRequest(t, req); body != "upstream" || hits.Load() != 1 {
+		t.Fatalf("普通 OPTIONS 请求响应 = %q，上游请求次数 = %d", body, hits.Load())
+	}
+}
+
+func TestSkipPathsBypassRateLimit(t *testing.T) {
+	upstream := textUpstream(t, "ok")
+	cfg := testConfig(upstream.URL)
+	usePlugin(cfg, "rate_limit", map[string]interface{}{"ip_based": true, "requests_per_second": 0.001, "burst": 2})
+	cfg.Plugins.Available[0].SkipPaths = []string{"/health*"}
+	cfg.Plugins.Available[0].SkipMethods = []string{"head"}
+	_, base := startTestServer(t, cfg)
+
+	// 跳过的路径和方法不执行限流，也不消耗令牌
+	for i := 0; i < 5; i++ {
+		if status, _ := get(t, base+"/health/live"); status != http.StatusOK {
+			t.Fatalf("第 %d 个健康检查请求状态码 = %d，期望跳过限流返回 200", i+1, status)
+		}
+		req, _ := http.NewRequest(http.MethodHead, base+"/orders", nil)
+		if resp, _ := doRequest(t, req); resp.StatusCode != http.StatusOK {
+			t.Fatalf("第 %d 个 HEAD 请求状态码 = %d，期望跳过限流返回 200", i+1, resp.StatusCode)
+		}
+	}
+
+	// 其余请求照常限流
+	for i := 0; i < 2; i++ {
+		if status, _ := get(t, base+"/orders"); status != http.StatusOK {
+			t.Fatalf("第 %d 个请求状态码 = %d，期望在突发额度内返回 200", i+1, status)
+		}
+	}
+	if status, _ := get(t, base+"/orders"); status != http.StatusTooManyRequests {
+		t.Fatalf("超出突发额度时状态码 = %d，期望 429", status)
+	}
+	if status, _ := get(t, base+"/health"); status != http.StatusOK {
+		t.Fatalf("限流后健康检查状态码 = %d，期望 200", status)
+	}
+}
+
+func TestInterfaceAuthForwardsIdentityHeaders(t *testing.T) {
+	auth := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `{"user_id":"u-42","roles":["admin","ops"]}`)
+	}))
+	defer auth.Close()
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, r.Header.Get("X-User-ID")+"|"+r.Header.Get("X-User-Roles"))
+	}))
+	defer upstream.Close()
+
+	cfg := testConfig(upstream.URL)
+	usePlugin(cfg, "interface_auth", map[string]interface{}{
+		"consumers": map[string]interface{}{"host": auth.URL, "auth_api": "/check"},
+		"forward_headers": []interface{}{
+			map[string]interface{}{"field": "user_id", "header": "X-User-ID"},
+			map[string]interface{}{"field": "roles", "header": "X-User-Roles"},
+		},
+	})
+	_, base := startTestServer(t, cfg)
+
+	// 上游收到认证结果映射的请求头，客户端伪造的值被覆盖
+	req, _ := http.NewRequest(http.MethodGet, base+"/orders", nil)
+	req.Header.Set("Authorization", "Bearer token")
+	req.Header.Set("X-User-ID", "root")
+	if resp, body := doRequest(t, req); resp.StatusCode != http.StatusOK || body != "u-42|admin,ops" {
+		t.Fatalf("上游收到的身份信息 = %d %q，期望 u-42|admin,ops", resp.StatusCode, body)
+	}
+}
+
+func TestBulkheadLimitsConcurrency(t *testing.T) {
+	arrived := make(chan string, 10)
+	release := make(chan struct{})
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		arrived <- r.URL.Path
+		<-release
+	}))
+	defer upstream.Close()
+	defer close(release)
+
+	cfg := testConfig(upstream.URL)
+	cfg.Routes = append(cfg.Routes, config.RouteConfig{
+		Name:   "reports",
+		Match:  config.RouteMatch{Type: "prefix", Path: "/reports", Priority: 10},
+		Target: config.TargetConfig{URL: upstream.URL},
+	})
+	usePlugin(cfg, "bulkhead", map[string]interface{}{"max_concurrent": 2, "max_queue": 1, "queue_timeout": "300ms"})
+	_, base := startTestServer(t, cfg)
+
+	// send 在后台发起请求，状态码写入 results
+	results := make(chan int, 10)
+	send := func(path string) {
+		go func() {
+			resp, err := http.Get(base + path)
+			if err != nil {
+				results <- 0
+				return
+			}
+			resp.Body.Close()
+			results <- resp.StatusCode
+		}()
+	}
+	// waitArrived 等待请求到达上游
+	waitArrived := func(path string) {
+		t.Helper()
+		select {
+		case got := <-arrived:
+			if got != path {
+				t.Fatalf("到达上游的请求 = %s，期望 %s", got, path)
+			}
+		case status := <-results:
+			t.Fatalf("请求 %s 未到达上游，状态码 = %d", path, status)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("等待请求 %s 到达上游超时", path)
+		}
+	}
+
+	// 占满路由的 2 个并发槽位
+	send("/a")
+	waitArrived("/a")
+	send("/b")
+	waitArrived("/b")
+
+	// 排队请求等待超时后返回 503
+	start := time.Now()
+	if status, _ := get(t, base+"/c"); status != http.StatusServiceUnavailable {
+		t.Fatalf("排队超时后状态码 = %d，期望 503", status)
+	}
+	if elapsed := time.Since(start); elapsed < 250*time.Millisecond {
+		t.Fatalf("排队请求在 %v 后返回，期望等待 queue_timeout", elapsed)
+	}
+
+	// 排队名额已满时立即返回 503
+	send("/queued")
+	time.Sleep(50 * time.Millisecond)
+	start = time.Now()
+	if status, _ := get(t, base+"/d"); status != http.StatusServiceUnavailable || time.Since(start) > 200*time.Millisecond {
+		t.Fatalf("排队已满时状态码 = %d，耗时 %v，期望立即返回 503", status, time.Since(start))
+	}
+
+	// 其他路由使用独立的隔离舱，转发时去掉路由前缀
+	send("/reports/1")
+	waitArrived("/1")
+
+	// 请求完成后释放槽位，排队请求得到处理
+	release <- struct{}{}
+	waitArrived("/queued")
+	for i := 0; i < 3; i++ {
+		release <- struct{}{}
+	}
+	for i := 0; i < 4; i++ {
+		if status := <-results; status != http.StatusOK {
+			t.Fatalf("放行的请求状态码 = %d，期望 200", status)
+		}
+	}
+
+	// 槽位全部释放后恢复接受请求
+	go func() { release <- struct{}{} }()
+	if status, _ := get(t, base+"/e"); status != http.StatusOK {
+		t.Fatalf("槽位释放后状态码 = %d，期望 200", status)
+	}
+	<-arrived
+}
+
+func TestPluginHeadersForwardedUpstream(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, strings.Join([]string{
+			r.Header.Get("X-Consumer-ID"),
+			r.Header.Get("X-Api-Key"),
+			r.Header.Get("X-Client"),
+			r.Header.Get("X-Forwarded-For"),
+		}, "|"))
+	}))
+	defer upstream.Close()
+
+	cfg := testConfig(upstream.URL)
+	usePlugin(cfg, "api_key", map[string]interface{}{
+		"hide_credentials": true,
+		"keys": []interface{}{
+			map[string]interface{}{"key": "k1", "consumer": "alice"},
+		},
+	})
+	_, base := startTestServer(t, cfg)
+
+	// 插件设置和删除的请求头作用于上游请求，客户端的其他请求头和转发链照常转发
+	req, _ := http.NewRequest(http.MethodGet, base+"/", nil)
+	req.Header.Set("X-Api-Key", "k1")
+	req.Header.Set("X-Client", "app")
+	resp, body := doRequest(t, req)
+	if resp.StatusCode != http.StatusOK || body != "alice||app|127.0.0.1" {
+		t.Fatalf("上游收到的请求头 = %d %q，期望 alice||app|127.0.0.1", resp.StatusCode, body)
+	}
+}
+
+func TestTestPluginsReportsInitFailure(t *testing.T) {
+	tests := []struct {
+		name     string
+		plugin   string
+		settings map[string]interface{}
+		want     string
+	}{
+		{"公钥无法解析", "consistency", map[string]interface{}{"algorithm": "rsa", "public_key": "not a pem"}, "invalid PEM"},
+		{"GeoIP 数据库不存在", "geoip", map[string]interface{}{"database": "/nonexistent/GeoLite2-Country.mmdb"}, "读取 GeoIP 数据库失败"},
+		{"配置正确", "rate_limit", map[string]interface{}{"requests_per_second": 10, "burst": 20}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testConfig("http://127.0.0.1:1")
+			usePlugin(cfg, tt.plugin, tt.settings)
+
+			// 配置校验（gateway -t）只检查配置结构，不初始化插件
+			if err := config.ValidateConfig(cfg); err != nil {
+				t.Fatalf("配置校验失败: %v", err)
+			}
+
+			err := TestPlugins(cfg)
+			if tt.want == "" {
+				if err != nil {
+					t.Fatalf("TestPlugins() = %v，期望成功", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("TestPlugins() = %v，期望包含 %q", err, tt.want)
+			}
+		})
+	}
+}
+
+// stopRecorder 记录是否被停止的测试插件
+type stopRecorder struct {
+	*core.BasePlugin
+	stopped bool
+}
+
+func (p *stopRecorder) Init(config interface{}) error { return nil }
+
+func (p *stopRecorder) Execute(ctx *gin.Context) error { return nil }
+
+func (p *stopRecorder) Stop() error {
+	p.stopped = true
+	return nil
+}
+
+func TestTestPluginsStopsInitializedPlugins(t *testing.T) {
+	tests := []struct {
+		name   string
+		caFile func(t *testing.T) string
+		want   string
+	}{
+		{"CA 证书不存在", func(t *testing.T) string { return filepath.Join(t.TempDir(), "missing.pem") }, "读取认证服务CA证书失败"},
+		{"配置正确", func(t *testing.T) string { return "" }, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manager := plugin.NewManager()
+			recorder := &stopRecorder{BasePlugin: core.NewBasePlugin("recorder", 1, nil)}
+			if err := manager.Register(recorder); err != nil {
+				t.Fatalf("注册插件失败: %v", err)
+			}
+
+			// recorder 先于 interface_auth 初始化
+			cfg := testConfig("http://127.0.0.1:1")
+			usePlugin(cfg, "recorder", nil)
+			usePlugin(cfg, "interface_auth", map[string]interface{}{
+				"consumers": map[string]interface{}{"host": "http://127.0.0.1:1", "auth_api": "/check"},
+				"client":    map[string]interface{}{"ca_file": tt.caFile(t)},
+			})
+			cfg.Plugins.Available[0].Order = 1
+			cfg.Plugins.Available[1].Order = 2
+
+			err := testPlugins(manager, cfg)
+			if tt.want == "" && err != nil {
+				t.Fatalf("testPlugins() = %v，期望成功", err)
+			}
+			if tt.want != "" && (err == nil || !strings.Contains(err.Error(), tt.want)) {
+				t.Fatalf("testPlugins() = %v，期望包含 %q", err, tt.want)
+			}
+
+			// 无论检查是否通过，已初始化的插件都被停止
+			if !recorder.stopped {
+				t.Fatal("检查结束后已初始化的插件未停止")
+			}
+			if state := manager.Lifecycle().ListPlugins()["recorder"].State; state != plugin.StateStopped {
+				t.Fatalf("插件状态 = %s，期望 stopped", state)
+			}
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package server
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"gateway-go/internal/config"
+)
+
+// methodPolicyRoute 配置 GET/HEAD 使用较长超时和重试、其他方法使用严格策略的路由
+func methodPolicyRoute(route *config.RouteConfig) {
+	retries := 1
+	route.Target.Timeout = 100
+	route.Target.Retries = 0
+	route.Target.MethodPolicies = []config.MethodPolicyConfig{
+		{Methods: []string{"GET", "HEAD"}, Timeout: 2000, Retries: &retries},
+	}
+}
+
+func TestMethodPolicyTimeouts(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-time.After(300 * time.Millisecond):
+		}
+		io.WriteString(w, "slow")
+	}))
+	defer upstream.Close()
+
+	cfg := testConfig(upstream.URL)
+	methodPolicyRoute(&cfg.Routes[0])
+	_, base := startTestServer(t, cfg)
+
+	// GET 使用较长的超时
+	if status, body := get(t, base+"/"); status != http.StatusOK || body != "slow" {
+		t.Fatalf("GET 响应 = %d %q，期望在较长超时内成功", status, body)
+	}
+
+	// POST 使用严格的超时
+	resp, err := http.Post(base+"/", "text/plain", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusGatewayTimeout {
+		t.Fatalf("POST 状态码 = %d，期望 504", resp.StatusCode)
+	}
+}
+
+func TestMethodPolicyRetries(t *testing.T) {
+	cfg := testConfig(refusedURL(t) + "," + refusedURL(t))
+	methodPolicyRoute(&cfg.Routes[0])
+	_, base := startTestServer(t, cfg)
+
+	attempts := func(method string) int {
+		req, _ := http.NewRequest(method, base+"/", nil)
+		_, body := doRequest(t, req)
+		var result struct {
+			Details proxyErrorDetails `json:"details"`
+		}
+		if err := json.Unmarshal([]byte(body), &result); err != nil {
+			t.Fatalf("解析错误响应 %q 失败: %v", body, err)
+		}
+		return result.Details.Attempts
+	}
+
+	if n := attempts(http.MethodGet); n != 2 {
+		t.Fatalf("GET 尝试次数 = %d，期望 2（重试 1 次）", n)
+	}
+	if n := attempts(http.MethodPost); n != 1 {
+		t.Fatalf("POST 尝试次数 = %d，期望 1（不重试）", n)
+	}
+}
@@ -0,0 +1,80 @@
+package server
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+
+	"gateway-go/internal/config"
+)
+
+// buildProxyPath 计算转发到上游的路径
+// 返回解码后的 Path 和原始编码的 RawPath（RawPath 为空时由 net/url 按默认规则编码）
+func buildProxyPath(reqURL *url.URL, route *config.RouteConfig) (string, string) {
+	path := reqURL.Path
+	escapedPath := reqURL.EscapedPath()
+
+	// 前缀匹配时去掉前缀
+	if route.Match.Type == "prefix" && route.Match.Path != "/" {
+		path = trimPathPrefix(path, route.Match.Path)
+		escapedPrefix := (&url.URL{Path: route.Match.Path}).EscapedPath()
+		if strings.HasPrefix(escapedPath, escapedPrefix) {
+			escapedPath = trimPathPrefix(escapedPath, escapedPrefix)
+		} else {
+			// 客户端对前缀本身做了编码，无法可靠地保留原始编码
+			escapedPath = ""
+		}
+	}
+
+	// decoded 模式：按解码后的路径重新编码（%2F 等会被还原为 /）
+	if route.Target.PathEncoding == "decoded" {
+		return path, ""
+	}
+
+	// 仅当原始编码与解码路径一致时才使用，否则交由 net/url 重新编码
+	if escapedPath == "" {
+		return path, ""
+	}
+	if unescaped, err := url.PathUnescape(escapedPath); err != nil || unescaped != path {
+		return path, ""
+	}
+	return path, escapedPath
+}
+
+// trimPathPrefix 去掉路径前缀并保证以 / 开头
+func trimPathPrefix(path, prefix string) string {
+	path = strings.TrimPrefix(path, prefix)
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	return path
+}
+
+// essentialResponseHeaders allowlist 模式下始终转发的响应头，缺少时客户端无法正确解析响应体
+var essentialResponseHeaders = []string{"Content-Type", "Content-Length", "Content-Encoding"}
+
+// filterResponseHeaders 按路由配置过滤上游响应头
+func filterResponseHeaders(header http.Header, filter *config.ResponseHeaderFilterConfig) {
+	if filter == nil {
+		return
+	}
+	for name := range header {
+		if containsHeader(filter.Deny, name) {
+			delete(header, name)
+			continue
+		}
+		if len(filter.Allow) > 0 && !containsHeader(filter.Allow, name) && !containsHeader(essentialResponseHeaders, name) {
+			delete(header, name)
+		}
+	}
+}
+
+// containsHeader 检查响应头名称是否在列表中，不区分大小写
+func containsHeader(names []string, name string) bool {
+	for _, n := range names {
+		if strings.EqualFold(n, name) {
+			return true
+		}
+	}
+	return false
+}
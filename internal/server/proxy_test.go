@@ -0,0 +1,189 @@
+package server
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"gateway-go/internal/config"
+)
+
+func TestBuildProxyPath(t *testing.T) {
+	tests := []struct {
+		name        string
+		requestURI  string
+		match       config.RouteMatch
+		encoding    string
+		wantPath    string
+		wantRawPath string
+	}{
+		{"普通路径", "/api/users", config.RouteMatch{Type: "exact", Path: "/api/users"}, "", "/api/users", "/api/users"},
+		{"保留编码的斜杠", "/files/a%2Fb", config.RouteMatch{Type: "prefix", Path: "/"}, "", "/files/a/b", "/files/a%2Fb"},
+		{"去掉前缀后保留编码", "/api/a%2Fb", config.RouteMatch{Type: "prefix", Path: "/api"}, "", "/a/b", "/a%2Fb"},
+		{"前缀本身被编码", "/%61pi/a%2Fb", config.RouteMatch{Type: "prefix", Path: "/api"}, "", "/a/b", ""},
+		{"decoded 模式", "/files/a%2Fb", config.RouteMatch{Type: "prefix", Path: "/"}, "decoded", "/files/a/b", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reqURL, err := url.ParseRequestURI(tt.requestURI)
+			if err != nil {
+				t.Fatal(err)
+			}
+			route := &config.RouteConfig{Match: tt.match, Target: config.TargetConfig{PathEncoding: tt.encoding}}
+			path, rawPath := buildProxyPath(reqURL, route)
+			if path != tt.wantPath || rawPath != tt.wantRawPath {
+				t.Fatalf("buildProxyPath(%s) = (%q, %q)，期望 (%q, %q)", tt.requestURI, path, rawPath, tt.wantPath, tt.wantRawPath)
+			}
+		})
+	}
+}
+
+func TestProxyPreservesEncodedSlashes(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, r.RequestURI)
+	}))
+	defer upstream.Close()
+
+	cfg := testConfig(upstream.URL)
+	cfg.Routes = []config.RouteConfig{
+		{Name: "raw", Match: config.RouteMatch{Type: "prefix", Path: "/raw"}, Target: config.TargetConfig{URL: upstream.URL}},
+		{Name: "decoded", Match: config.RouteMatch{Type: "prefix", Path: "/decoded"}, Target: config.TargetConfig{URL: upstream.URL, PathEncoding: "decoded"}},
+	}
+	_, base := startTestServer(t, cfg)
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/raw/a%2Fb/c%20d?q=%2F", "/a%2Fb/c%20d?q=%2F"},
+		{"/decoded/a%2Fb", "/a/b"},
+	}
+	for _, tt := range tests {
+		status, body := get(t, base+tt.path)
+		if status != http.StatusOK || body != tt.want {
+			t.Fatalf("%s 转发到上游的路径 = %d %q，期望 %q", tt.path, status, body, tt.want)
+		}
+	}
+}
+
+func TestResponseHeaderFilter(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Server", "nginx/1.25")
+		w.Header().Set("X-Powered-By", "php")
+		w.Header().Set("X-Request-Id", "abc")
+		w.Header().Set("Cache-Control", "no-store")
+		w.Write([]byte(`{}`))
+	}))
+	defer upstream.Close()
+
+	tests := []struct {
+		name    string
+		filter  *config.ResponseHeaderFilterConfig
+		want    []string
+		notWant []string
+	}{
+		{"未配置", nil, []string{"Server", "X-Powered-By", "X-Request-Id", "Cache-Control"}, nil},
+		{"denylist", &config.ResponseHeaderFilterConfig{Deny: []string{"server", "X-Powered-By"}},
+			[]string{"X-Request-Id", "Cache-Control", "Content-Type"}, []string{"Server", "X-Powered-By"}},
+		// allowlist 模式下 Content-Type 等必要响应头始终转发
+		{"allowlist", &config.ResponseHeaderFilterConfig{Allow: []string{"x-request-id", "Server"}, Deny: []string{"Server"}},
+			[]string{"X-Request-Id", "Content-Type", "Content-Length"}, []string{"Server", "X-Powered-By", "Cache-Control"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testConfig(upstream.URL)
+			cfg.Routes[0].Target.ResponseHeaders = tt.filter
+			_, base := startTestServer(t, cfg)
+
+			req, _ := http.NewRequest(http.MethodGet, base+"/", nil)
+			resp, body := doRequest(t, req)
+			if resp.StatusCode != http.StatusOK || body != `{}` {
+				t.Fatalf("响应 = %d %q", resp.StatusCode, body)
+			}
+			for _, name := range tt.want {
+				if resp.Header.Get(name) == "" {
+					t.Fatalf("客户端未收到响应头 %s: %v", name, resp.Header)
+				}
+			}
+			for _, name := range tt.notWant {
+				if resp.Header.Get(name) != "" {
+					t.Fatalf("客户端不应收到响应头 %s: %v", name, resp.Header)
+				}
+			}
+		})
+	}
+}
+
+func TestTargetHeadersInjected(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, r.Header.Get("X-Internal")+" "+r.Header.Get("X-Backend-Token")+" "+r.Header.Get("X-Client"))
+	}))
+	defer upstream.Close()
+
+	cfg := testConfig(upstream.URL)
+	cfg.Routes[0].Target.Headers = map[string]string{
+		"X-Internal":      "true",
+		"x-backend-token": "static-token",
+	}
+	_, base := startTestServer(t, cfg)
+
+	// 注入的请求头覆盖客户端发送的同名请求头，其他请求头照常转发
+	req, _ := http.NewRequest(http.MethodGet, base+"/", nil)
+	req.Header.Set("X-Internal", "false")
+	req.Header.Set("X-Client", "app")
+	resp, body := doRequest(t, req)
+	if body != "true static-token app" {
+		t.Fatalf("上游收到的请求头 = %q，期望注入的值", body)
+	}
+
+	// 注入的请求头只发往上游，不出现在客户端响应中
+	for _, name := range []string{"X-Internal", "X-Backend-Token"} {
+		if resp.Header.Get(name) != "" {
+			t.Fatalf("客户端响应包含注入的请求头 %s: %v", name, resp.Header)
+		}
+	}
+}
+
+func TestForwardedHeaders(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, r.Header.Get("X-Forwarded-For")+"|"+r.Header.Get("X-Forwarded-Proto")+"|"+r.Header.Get("X-Forwarded-Host"))
+	}))
+	defer upstream.Close()
+
+	tests := []struct {
+		name           string
+		trustedProxies []string
+		forwardedFor   string
+		forwardedProto string
+		want           string
+	}{
+		{name: "新请求", want: "127.0.0.1|http|gateway.test"},
+		{name: "已有转发链", forwardedFor: "203.0.113.7, 10.0.0.1", want: "203.0.113.7, 10.0.0.1, 127.0.0.1|http|gateway.test"},
+		{name: "未配置可信代理时采信协议", forwardedProto: "https", want: "127.0.0.1|https|gateway.test"},
+		// 不可信的对端传来的协议不被采信，转发链仍然保留
+		{name: "不可信的代理", trustedProxies: []string{"10.0.0.0/8"}, forwardedFor: "203.0.113.7", forwardedProto: "https", want: "203.0.113.7, 127.0.0.1|http|gateway.test"},
+		{name: "可信的代理", trustedProxies: []string{"127.0.0.1"}, forwardedFor: "203.0.113.7", forwardedProto: "https", want: "203.0.113.7, 127.0.0.1|https|gateway.test"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testConfig(upstream.URL)
+			cfg.Server.TrustedProxies = tt.trustedProxies
+			_, base := startTestServer(t, cfg)
+
+			req, _ := http.NewRequest(http.MethodGet, base+"/", nil)
+			req.Host = "gateway.test"
+			if tt.forwardedFor != "" {
+				req.Header.Set("X-Forwarded-For", tt.forwardedFor)
+			}
+			if tt.forwardedProto != "" {
+				req.Header.Set("X-Forwarded-Proto", tt.forwardedProto)
+			}
+			if _, body := doRequest(t, req); body != tt.want {
+				t.Fatalf("上游收到的转发请求头 = %q，期望 %q", body, tt.want)
+			}
+		})
+	}
+}
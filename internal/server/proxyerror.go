@@ -0,0 +1,82 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+
+	"gateway-go/internal/config"
+	gwerrors "gateway-go/internal/errors"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 转发失败的错误类别
+const (
+	proxyErrorDNS      = "dns"
+	proxyErrorConnect  = "connect"
+	proxyErrorTimeout  = "timeout"
+	proxyErrorReset    = "reset"
+	proxyErrorCanceled = "canceled"
+	proxyErrorOther    = "other"
+)
+
+// proxyErrorDetails 转发失败时错误响应的详情
+type proxyErrorDetails struct {
+	Route    string `json:"route"`
+	Target   string `json:"target"`
+	Category string `json:"category"`
+	Attempts int    `json:"attempts"`
+}
+
+// classifyProxyError 判断转发失败的错误类别
+func classifyProxyError(err error) string {
+	var dnsErr *net.DNSError
+	var netErr net.Error
+	var opErr *net.OpError
+	switch {
+	case errors.As(err, &dnsErr):
+		return proxyErrorDNS
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		return proxyErrorTimeout
+	case errors.Is(err, context.Canceled):
+		return proxyErrorCanceled
+	case errors.Is(err, syscall.ECONNREFUSED), errors.Is(err, syscall.EHOSTUNREACH), errors.Is(err, syscall.ENETUNREACH),
+		errors.As(err, &opErr) && opErr.Op == "dial":
+		return proxyErrorConnect
+	case errors.Is(err, syscall.ECONNRESET), errors.Is(err, syscall.EPIPE), errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF):
+		// 上游在返回响应前关闭连接
+		return proxyErrorReset
+	default:
+		return proxyErrorOther
+	}
+}
+
+// abortUpstreamError 按 server.upstream_errors 中该错误类别的配置写出响应，未配置响应体时使用统一格式的错误响应
+func abortUpstreamError(c *gin.Context, status int, message string, details proxyErrorDetails, response config.UpstreamErrorConfig) {
+	if response.RetryAfter > 0 {
+		c.Header("Retry-After", strconv.Itoa(response.RetryAfter))
+	}
+	if response.Body == "" || c.Writer.Written() {
+		gwerrors.AbortWithDetails(c, status, message, details)
+		return
+	}
+	contentType := response.ContentType
+	if contentType == "" {
+		contentType = "application/json"
+	}
+	c.Data(status, contentType, []byte(response.Body))
+	c.Abort()
+}
+
+// proxyErrorStatus 转发失败时返回的状态码，超时返回 504，其他返回 502
+func proxyErrorStatus(category string) int {
+	if category == proxyErrorTimeout {
+		return http.StatusGatewayTimeout
+	}
+	return http.StatusBadGateway
+}
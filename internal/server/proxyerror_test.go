@@ -0,0 +1,228 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"gateway-go/internal/config"
+
+	"go.uber.org/zap"
+)
+
+// proxyErrorResponse 转发失败时的错误响应
+type proxyErrorResponse struct {
+	Code      int               `json:"code"`
+	Message   string            `json:"message"`
+	RequestID string            `json:"request_id"`
+	Details   proxyErrorDetails `json:"details"`
+}
+
+func TestProxyErrorDetails(t *testing.T) {
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(2 * time.Second):
+		case <-r.Context().Done():
+		}
+	}))
+	defer slow.Close()
+	refused := refusedURL(t)
+
+	tests := []struct {
+		name     string
+		target   string
+		timeout  int
+		status   int
+		category string
+	}{
+		{"连接被拒绝", refused, 0, http.StatusBadGateway, proxyErrorConnect},
+		{"上游超时", slow.URL, 50, http.StatusGatewayTimeout, proxyErrorTimeout},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testConfig(tt.target)
+			cfg.Routes[0].Name = "orders"
+			cfg.Routes[0].Target.Timeout = tt.timeout
+			_, base := startTestServer(t, cfg)
+			logs := observeLogs(t, zap.WarnLevel)
+
+			req, _ := http.NewRequest(http.MethodGet, base+"/orders/1", nil)
+			req.Header.Set("X-Request-ID", "trace-1")
+			resp, body := doRequest(t, req)
+			if resp.StatusCode != tt.status {
+				t.Fatalf("状态码 = %d，期望 %d", resp.StatusCode, tt.status)
+			}
+			var got proxyErrorResponse
+			if err := json.Unmarshal([]byte(body), &got); err != nil {
+				t.Fatalf("解析响应 %s 失败: %v", body, err)
+			}
+			want := proxyErrorDetails{Route: "orders", Target: tt.target, Category: tt.category, Attempts: 1}
+			if got.Code != tt.status || got.RequestID != "trace-1" || got.Details != want {
+				t.Fatalf("错误响应 = %+v，期望 details %+v 和请求 ID trace-1", got, want)
+			}
+
+			entries := logs.FilterMessage("反向代理失败").All()
+			if len(entries) != 1 {
+				t.Fatalf("转发失败日志 %d 条，期望 1 条", len(entries))
+			}
+			fields := entries[0].ContextMap()
+			if fields["route_name"] != "orders" || fields["target_url"] != tt.target || fields["category"] != tt.category ||
+				fields["request_id"] != "trace-1" || fields["status"] != int64(tt.status) {
+				t.Fatalf("转发失败日志字段 = %v", fields)
+			}
+		})
+	}
+}
+
+func TestClassifyProxyError(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		category string
+	}{
+		{"域名解析失败", &net.OpError{Op: "dial", Err: &net.DNSError{Err: "no such host", Name: "backend.invalid"}}, proxyErrorDNS},
+		{"连接被拒绝", &net.OpError{Op: "dial", Err: os.NewSyscallError("connect", syscall.ECONNREFUSED)}, proxyErrorConnect},
+		{"拨号超时", &net.OpError{Op: "dial", Err: &timeoutError{}}, proxyErrorTimeout},
+		{"截止时间", fmt.Errorf("转发失败: %w", context.DeadlineExceeded), proxyErrorTimeout},
+		{"连接被重置", &net.OpError{Op: "read", Err: os.NewSyscallError("read", syscall.ECONNRESET)}, proxyErrorReset},
+		{"响应前关闭连接", io.EOF, proxyErrorReset},
+		{"客户端取消", context.Canceled, proxyErrorCanceled},
+		{"其他错误", fmt.Errorf("malformed HTTP response"), proxyErrorOther},
+	}
+	for _, tt := range tests {
+		if got := classifyProxyError(tt.err); got != tt.category {
+			t.Fatalf("%s: 类别 = %s，期望 %s", tt.name, got, tt.category)
+		}
+	}
+}
+
+// timeoutError 模拟网络超时错误
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+// hangupUpstream 返回读取请求后不响应直接关闭连接的上游
+func hangupUpstream(t *testing.T) string {
+	t.Helper()
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, _, err := http.NewResponseController(w).Hijack()
+		if err == nil {
+			conn.Close()
+		}
+	}))
+	t.Cleanup(upstream.Close)
+	return upstream.URL
+}
+
+// malformedUpstream 返回响应格式错误的上游
+func malformedUpstream(t *testing.T) string {
+	t.Helper()
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, buf, err := http.NewResponseController(w).Hijack()
+		if err == nil {
+			buf.WriteString("NOT-HTTP\r\n\r\n")
+			buf.Flush()
+			conn.Close()
+		}
+	}))
+	t.Cleanup(upstream.Close)
+	return upstream.URL
+}
+
+func TestUpstreamErrorMapping(t *testing.T) {
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(2 * time.Second):
+		case <-r.Context().Done():
+		}
+	}))
+	defer slow.Close()
+
+	upstreams := map[string]string{
+		proxyErrorDNS:     "http://backend.invalid",
+		proxyErrorConnect: refusedURL(t),
+		proxyErrorTimeout: slow.URL,
+		proxyErrorReset:   hangupUpstream(t),
+		proxyErrorOther:   malformedUpstream(t),
+	}
+	// build 为每个错误类别创建一条路由，路径为 /<类别>
+	build := func(mapping map[string]config.UpstreamErrorConfig) *config.Config {
+		cfg := testConfig(refusedURL(t))
+		cfg.Routes = nil
+		for category, target := range upstreams {
+			cfg.Routes = append(cfg.Routes, config.RouteConfig{
+				Name:   category,
+				Match:  config.RouteMatch{Type: "prefix", Path: "/" + category},
+				Target: config.TargetConfig{URL: target, Timeout: 200},
+			})
+		}
+		cfg.Server.UpstreamErrors = mapping
+		return cfg
+	}
+
+	t.Run("默认响应", func(t *testing.T) {
+		_, base := startTestServer(t, build(nil))
+		for category := range upstreams {
+			req, _ := http.NewRequest(http.MethodGet, base+"/"+category, nil)
+			resp, body := doRequest(t, req)
+			var got proxyErrorResponse
+			json.Unmarshal([]byte(body), &got)
+			if resp.StatusCode != proxyErrorStatus(category) || got.Details.Category != category || resp.Header.Get("Retry-After") != "" {
+				t.Fatalf("%s: 响应 = %d %s，期望 %d", category, resp.StatusCode, body, proxyErrorStatus(category))
+			}
+		}
+	})
+
+	t.Run("按类别映射", func(t *testing.T) {
+		_, base := startTestServer(t, build(map[string]config.UpstreamErrorConfig{
+			proxyErrorDNS:     {Status: http.StatusServiceUnavailable},
+			proxyErrorConnect: {Status: http.StatusServiceUnavailable, RetryAfter: 30},
+			proxyErrorTimeout: {Status: http.StatusServiceUnavailable, Body: `{"error":"upstream slow"}`},
+			proxyErrorReset:   {Body: "connection reset", ContentType: "text/plain", RetryAfter: 5},
+			proxyErrorOther:   {Status: http.StatusInternalServerError},
+		}))
+
+		tests := []struct {
+			category    string
+			status      int
+			retryAfter  string
+			contentType string
+			// 期望的自定义响应体，为空时期望统一格式的错误响应
+			body string
+		}{
+			{proxyErrorDNS, http.StatusServiceUnavailable, "", "application/json; charset=utf-8", ""},
+			{proxyErrorConnect, http.StatusServiceUnavailable, "30", "application/json; charset=utf-8", ""},
+			{proxyErrorTimeout, http.StatusServiceUnavailable, "", "application/json", `{"error":"upstream slow"}`},
+			{proxyErrorReset, http.StatusBadGateway, "5", "text/plain", "connection reset"},
+			{proxyErrorOther, http.StatusInternalServerError, "", "application/json; charset=utf-8", ""},
+		}
+		for _, tt := range tests {
+			req, _ := http.NewRequest(http.MethodGet, base+"/"+tt.category, nil)
+			resp, body := doRequest(t, req)
+			if resp.StatusCode != tt.status || resp.Header.Get("Retry-After") != tt.retryAfter || resp.Header.Get("Content-Type") != tt.contentType {
+				t.Fatalf("%s: 响应 = %d，Retry-After %q，Content-Type %q，期望 %d %q %q", tt.category,
+					resp.StatusCode, resp.Header.Get("Retry-After"), resp.Header.Get("Content-Type"), tt.status, tt.retryAfter, tt.contentType)
+			}
+			if tt.body != "" {
+				if body != tt.body {
+					t.Fatalf("%s: 响应体 = %q，期望 %q", tt.category, body, tt.body)
+				}
+				continue
+			}
+			var got proxyErrorResponse
+			if err := json.Unmarshal([]byte(body), &got); err != nil || got.Code != tt.status || got.Details.Category != tt.category {
+				t.Fatalf("%s: 错误响应 = %s，期望状态码 %d 的统一格式", tt.category, body, tt.status)
+			}
+		}
+	})
+}
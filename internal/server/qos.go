@@ -0,0 +1,149 @@
+package server
+
+import (
+	"net/http"
+	"sync/atomic"
+
+	"gateway-go/internal/config"
+	gwerrors "gateway-go/internal/errors"
+	"gateway-go/internal/metrics"
+	"gateway-go/internal/plugin/plugins/ipwhitelist"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 并发限制默认配置
+const (
+	defaultQoSConsumerHeader = "X-API-Key"
+	defaultQoSMaxRatio       = 0.8
+	// defaultQoSClass 未匹配任何分类的请求所属分类
+	defaultQoSClass = "default"
+)
+
+// concurrencyShed 因并发超限被拒绝的请求数
+var concurrencyShed = metrics.NewCounterVec(
+	"gateway_concurrency_shed_total",
+	"因并发超限被拒绝的请求数",
+	"class",
+)
+
+// concurrencyLimiter 全局并发限制器，按优先级分类设置不同的并发上限
+// 低优先级分类的上限更低，并发接近饱和时先拒绝低优先级请求，剩余容量留给高优先级请求
+type concurrencyLimiter struct {
+	inflight atomic.Int64
+	// 配置重载时替换，进行中的请求计数保留
+	settings atomic.Pointer[qosSettings]
+}
+
+// qosSettings 并发限制配置
+type qosSettings struct {
+	consumerHeader string
+	defaultLimit   int64
+	classes        []qosClass
+	// 只按来自可信代理的请求头分类，其他来源的请求头可由客户端伪造
+	trustedProxies []string
+}
+
+// qosClass 优先级分类
+type qosClass struct {
+	name      string
+	consumers map[string]bool
+	headers   map[string]string
+	limit     int64
+}
+
+// newConcurrencyLimiter 创建并发限制器
+func newConcurrencyLimiter() *concurrencyLimiter {
+	return &concurrencyLimiter{}
+}
+
+// update 更新并发限制配置，max_requests 为 0 时不限制
+func (l *concurrencyLimiter) update(cfg config.ConcurrencyConfig, trustedProxies []string) {
+	if cfg.MaxRequests <= 0 {
+		l.settings.Store(nil)
+		return
+	}
+
+	settings := &qosSettings{
+		consumerHeader: cfg.ConsumerHeader,
+		defaultLimit:   classLimit(cfg.MaxRequests, cfg.DefaultMaxRatio, defaultQoSMaxRatio),
+		trustedProxies: trustedProxies,
+	}
+	if settings.consumerHeader == "" {
+		settings.consumerHeader = defaultQoSConsumerHeader
+	}
+	for _, classCfg := range cfg.Classes {
+		class := qosClass{
+			name:    classCfg.Name,
+			headers: classCfg.Headers,
+			limit:   classLimit(cfg.MaxRequests, classCfg.MaxRatio, 1),
+		}
+		if len(classCfg.Consumers) > 0 {
+			class.consumers = make(map[string]bool, len(classCfg.Consumers))
+			for _, consumer := range classCfg.Consumers {
+				class.consumers[consumer] = true
+			}
+		}
+		settings.classes = append(settings.classes, class)
+	}
+	l.settings.Store(settings)
+}
+
+// classLimit 按比例计算分类的并发上限，至少为 1
+func classLimit(maxRequests int, ratio, defaultRatio float64) int64 {
+	if ratio <= 0 {
+		ratio = defaultRatio
+	}
+	limit := int64(float64(maxRequests) * ratio)
+	if limit < 1 {
+		limit = 1
+	}
+	return limit
+}
+
+// classify 按顺序匹配请求的优先级分类，返回分类名称和并发上限
+// 分类依据的消费者标识和请求头未经网关校验，只采信可信代理发送的值，其他请求归入默认分类
+func (s *qosSettings) classify(c *gin.Context) (string, int64) {
+	if !ipwhitelist.Contains(s.trustedProxies, c.RemoteIP()) {
+		return defaultQoSClass, s.defaultLimit
+	}
+	for _, class := range s.classes {
+		if class.matches(c, s.consumerHeader) {
+			return class.name, class.limit
+		}
+	}
+	return defaultQoSClass, s.defaultLimit
+}
+
+// matches 检查请求是否满足分类的全部条件
+func (class *qosClass) matches(c *gin.Context, consumerHeader string) bool {
+	if class.consumers != nil && !class.consumers[c.GetHeader(consumerHeader)] {
+		return false
+	}
+	for name, value := range class.headers {
+		actual := c.GetHeader(name)
+		if actual == "" || (value != "" && actual != value) {
+			return false
+		}
+	}
+	return true
+}
+
+// admit 检查请求所属分类的并发上限，超出时写出响应并中止请求；放行时返回释放函数
+func (l *concurrencyLimiter) admit(c *gin.Context) (func(), bool) {
+	settings := l.settings.Load()
+	if settings == nil {
+		return func() {}, true
+	}
+
+	class, limit := settings.classify(c)
+	if l.inflight.Add(1) > limit {
+		l.inflight.Add(-1)
+		concurrencyShed.Inc(class)
+		c.Set(gatewayErrorKey, gatewayErrorOverloaded)
+		c.Header("Retry-After", "1")
+		gwerrors.Abort(c, http.StatusServiceUnavailable, "服务繁忙，请稍后重试")
+		return nil, false
+	}
+	return func() { l.inflight.Add(-1) }, true
+}
@@ -0,0 +1,140 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"gateway-go/internal/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestQoSShedsLowPriorityFirst(t *testing.T) {
+	arrived := make(chan struct{}, 10)
+	release := make(chan struct{})
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		arrived <- struct{}{}
+		<-release
+	}))
+	defer upstream.Close()
+	defer close(release)
+
+	cfg := testConfig(upstream.URL)
+	cfg.Server.TrustedProxies = []string{"127.0.0.1"}
+	cfg.Server.Concurrency = config.ConcurrencyConfig{
+		MaxRequests:     4,
+		DefaultMaxRatio: 0.5,
+		Classes: []config.QoSClassConfig{
+			{Name: "premium", Consumers: []string{"paid-key"}},
+			{Name: "internal", Headers: map[string]string{"X-Internal": ""}, MaxRatio: 0.75},
+		},
+	}
+	_, base := startTestServer(t, cfg)
+
+	// hold 发起一个占用并发的请求，等待其到达上游
+	results := make(chan int, 10)
+	hold := func(header, value string) {
+		req, _ := http.NewRequest(http.MethodGet, base+"/", nil)
+		if header != "" {
+			req.Header.Set(header, value)
+		}
+		go func() {
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				results <- 0
+				return
+			}
+			resp.Body.Close()
+			results <- resp.StatusCode
+		}()
+		select {
+		case <-arrived:
+		case status := <-results:
+			t.Fatalf("请求未到达上游，状态码 = %d", status)
+		case <-time.After(5 * time.Second):
+			t.Fatal("等待请求到达上游超时")
+		}
+	}
+	// request 发起请求并返回状态码，被拒绝的请求立即返回
+	request := func(header, value string) int {
+		req, _ := http.NewRequest(http.MethodGet, base+"/", nil)
+		if header != "" {
+			req.Header.Set(header, value)
+		}
+		resp, _ := doRequest(t, req)
+		return resp.StatusCode
+	}
+
+	// 默认分类最多占用 2 个并发
+	hold("", "")
+	hold("X-API-Key", "free-key")
+	if status := request("X-API-Key", "free-key"); status != http.StatusServiceUnavailable {
+		t.Fatalf("低优先级请求超出分类上限时状态码 = %d，期望 503", status)
+	}
+
+	// 内部请求最多占用 3 个并发，付费请求可使用全部 4 个
+	hold("X-Internal", "1")
+	if status := request("X-Internal", "1"); status != http.StatusServiceUnavailable {
+		t.Fatalf("内部请求超出分类上限时状态码 = %d，期望 503", status)
+	}
+	hold("X-API-Key", "paid-key")
+
+	// 并发已满时所有分类都被拒绝
+	if status := request("X-API-Key", "paid-key"); status != http.StatusServiceUnavailable {
+		t.Fatalf("并发已满时状态码 = %d，期望 503", status)
+	}
+
+	// 放行的请求全部成功，释放后恢复接受低优先级请求
+	for i := 0; i < 4; i++ {
+		release <- struct{}{}
+		if status := <-results; status != http.StatusOK {
+			t.Fatalf("放行的请求状态码 = %d，期望 200", status)
+		}
+	}
+	go func() { release <- struct{}{} }()
+	if status := request("", ""); status != http.StatusOK {
+		t.Fatalf("并发释放后低优先级请求状态码 = %d，期望 200", status)
+	}
+}
+
+func TestQoSIgnoresHeadersFromUntrustedClients(t *testing.T) {
+	l := newConcurrencyLimiter()
+	l.update(config.ConcurrencyConfig{
+		MaxRequests:     10,
+		DefaultMaxRatio: 0.5,
+		Classes: []config.QoSClassConfig{
+			{Name: "premium", Consumers: []string{"paid-key"}},
+			{Name: "internal", Headers: map[string]string{"X-Internal": ""}, MaxRatio: 0.8},
+		},
+	}, []string{"10.0.0.0/8"})
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		header     string
+		value      string
+		wantClass  string
+		wantLimit  int64
+	}{
+		{"可信代理转发的付费消费者", "10.0.0.1:1234", "X-API-Key", "paid-key", "premium", 10},
+		{"可信代理转发的内部请求", "10.0.0.1:1234", "X-Internal", "1", "internal", 8},
+		// 客户端直连时伪造的请求头不提升优先级
+		{"伪造付费消费者", "203.0.113.5:1234", "X-API-Key", "paid-key", defaultQoSClass, 5},
+		{"伪造内部请求头", "203.0.113.5:1234", "X-Internal", "1", defaultQoSClass, 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+			c.Request.RemoteAddr = tt.remoteAddr
+			c.Request.Header.Set(tt.header, tt.value)
+			class, limit := l.settings.Load().classify(c)
+			if class != tt.wantClass || limit != tt.wantLimit {
+				t.Fatalf("分类 = %s（上限 %d），期望 %s（上限 %d）", class, limit, tt.wantClass, tt.wantLimit)
+			}
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package server
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+
+	gwerrors "gateway-go/internal/errors"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxRequestIDLength 沿用客户端请求 ID 的最大长度，超过时重新生成
+const maxRequestIDLength = 128
+
+// requestIDMiddleware 为每个请求确定请求 ID：沿用客户端传入的 X-Request-ID，没有或无效时生成
+// 请求 ID 写入响应头和错误响应体，并在转发时传给上游
+func requestIDMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(gwerrors.RequestIDHeader)
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+		c.Set(gwerrors.RequestIDKey, id)
+		c.Header(gwerrors.RequestIDHeader, id)
+		c.Next()
+	}
+}
+
+// validRequestID 判断客户端传入的请求 ID 是否可以沿用，只允许可见的 ASCII 字符
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// newRequestID 生成 32 位十六进制的随机请求 ID
+func newRequestID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(b[:])
+}
@@ -0,0 +1,110 @@
+package server
+
+import (
+	"io"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	gwerrors "gateway-go/internal/errors"
+	"gateway-go/internal/logger"
+	"gateway-go/internal/metrics"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// requestSize 请求体大小
+var requestSize = metrics.NewHistogramVec(
+	"gateway_request_size_bytes",
+	"请求体大小（字节）",
+	metrics.SizeBuckets,
+	"route",
+)
+
+// responseSize 响应体大小
+var responseSize = metrics.NewHistogramVec(
+	"gateway_response_size_bytes",
+	"响应体大小（字节）",
+	metrics.SizeBuckets,
+	"route",
+)
+
+// slowRequests 慢请求次数
+var slowRequests = metrics.NewCounterVec(
+	"gateway_slow_requests_total",
+	"耗时超过慢请求阈值的请求次数",
+	"route",
+)
+
+// countingBody 统计实际读取的请求体字节数
+type countingBody struct {
+	io.ReadCloser
+	n atomic.Int64
+}
+
+func (b *countingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.n.Add(int64(n))
+	return n, err
+}
+
+// requestStats 记录单个请求的请求体和响应体大小
+type requestStats struct {
+	body *countingBody
+}
+
+// newRequestStats 包装请求体以统计读取的字节数，需在插件替换请求体之前调用
+func newRequestStats(c *gin.Context) *requestStats {
+	stats := &requestStats{}
+	if c.Request.Body != nil && c.Request.Body != http.NoBody {
+		stats.body = &countingBody{ReadCloser: c.Request.Body}
+		c.Request.Body = stats.body
+	}
+	return stats
+}
+
+// requestBytes 返回已读取的请求体字节数，未读取完的请求体只统计已读取的部分
+func (s *requestStats) requestBytes() int64 {
+	if s.body == nil {
+		return 0
+	}
+	return s.body.n.Load()
+}
+
+// responseBytes 返回已写出的响应体字节数
+func responseBytes(c *gin.Context) int64 {
+	if size := c.Writer.Size(); size > 0 {
+		return int64(size)
+	}
+	return 0
+}
+
+// finish 请求处理结束后记录大小指标，耗时不低于 threshold 时记录慢请求日志
+func (s *requestStats) finish(c *gin.Context, route string, start time.Time, threshold time.Duration) {
+	reqBytes, respBytes := s.requestBytes(), responseBytes(c)
+	requestSize.Observe(float64(reqBytes), route)
+	responseSize.Observe(float64(respBytes), route)
+
+	if threshold <= 0 {
+		return
+	}
+	cost := time.Since(start)
+	if cost < threshold {
+		return
+	}
+	slowRequests.Inc(route)
+	if logger.Log != nil && logger.Log.Core().Enabled(zap.WarnLevel) {
+		logger.Log.Warn("慢请求",
+			zap.String("route_name", route),
+			zap.String("method", c.Request.Method),
+			zap.String("path", c.Request.URL.Path),
+			zap.Int("status", c.Writer.Status()),
+			zap.Duration("cost", cost),
+			zap.Duration("threshold", threshold),
+			zap.Int64("request_bytes", reqBytes),
+			zap.Int64("response_bytes", respBytes),
+			zap.String("request_id", gwerrors.RequestID(c)),
+		)
+	}
+}
@@ -0,0 +1,79 @@
+package server
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+func TestSlowRequestLoggedWithSizes(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		if r.URL.Path == "/slow" {
+			time.Sleep(100 * time.Millisecond)
+		}
+		w.Write([]byte(strings.Repeat("r", 2048)))
+	}))
+	defer upstream.Close()
+
+	cfg := testConfig(upstream.URL)
+	cfg.Routes[0].Name = "stats"
+	cfg.Log.SlowRequestThreshold = 50 * time.Millisecond
+	_, base := startTestServer(t, cfg)
+	logs := observeLogs(t, zap.WarnLevel)
+	sizesBefore, slowBefore := requestSize.Count("stats"), slowRequests.Value("stats")
+
+	for _, path := range []string{"/fast", "/slow"} {
+		req, _ := http.NewRequest(http.MethodPost, base+path, strings.NewReader(strings.Repeat("q", 1000)))
+		req.Header.Set("X-Request-ID", "slow-1")
+		if resp, _ := doRequest(t, req); resp.StatusCode != http.StatusOK {
+			t.Fatalf("%s 状态码 = %d，期望 200", path, resp.StatusCode)
+		}
+	}
+
+	// 只有超过阈值的请求记录慢请求日志
+	entries := logs.FilterMessage("慢请求").All()
+	if len(entries) != 1 {
+		t.Fatalf("慢请求日志 %d 条，期望 1 条", len(entries))
+	}
+	fields := entries[0].ContextMap()
+	if fields["route_name"] != "stats" || fields["path"] != "/slow" || fields["status"] != int64(http.StatusOK) ||
+		fields["request_bytes"] != int64(1000) || fields["response_bytes"] != int64(2048) || fields["request_id"] != "slow-1" {
+		t.Fatalf("慢请求日志字段 = %v", fields)
+	}
+	if cost, _ := fields["cost"].(time.Duration); cost < 100*time.Millisecond {
+		t.Fatalf("慢请求耗时 = %v，期望不低于上游延迟 100ms", cost)
+	}
+
+	// 大小指标记录所有请求，慢请求计数只增加一次
+	if got := requestSize.Count("stats") - sizesBefore; got != 2 {
+		t.Fatalf("请求体大小观测次数增加 %d，期望 2", got)
+	}
+	if got := responseSize.Count("stats"); got < 2 {
+		t.Fatalf("响应体大小观测次数 = %d，期望至少 2", got)
+	}
+	if got := slowRequests.Value("stats") - slowBefore; got != 1 {
+		t.Fatalf("慢请求计数增加 %v，期望 1", got)
+	}
+}
+
+func TestSlowRequestThresholdDisabled(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(20 * time.Millisecond)
+	}))
+	defer upstream.Close()
+
+	_, base := startTestServer(t, testConfig(upstream.URL))
+	logs := observeLogs(t, zap.WarnLevel)
+	if status, _ := get(t, base+"/"); status != http.StatusOK {
+		t.Fatalf("状态码 = %d，期望 200", status)
+	}
+	if n := logs.FilterMessage("慢请求").Len(); n != 0 {
+		t.Fatalf("未配置阈值时记录了 %d 条慢请求日志", n)
+	}
+}
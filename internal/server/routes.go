@@ -0,0 +1,614 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+	"time"
+
+	"gateway-go/internal/config"
+	gwerrors "gateway-go/internal/errors"
+	"gateway-go/internal/logger"
+	"gateway-go/internal/metrics"
+	"gateway-go/internal/plugin/core"
+	"gateway-go/internal/plugin/plugins/ipwhitelist"
+	gwproxy "gateway-go/internal/proxy"
+	"gateway-go/internal/router"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// buildEngine 构建gin引擎
+func (s *Server) buildEngine() *gin.Engine {
+	r := gin.New()
+
+	// 可信代理：只采信来自可信代理的转发头，避免客户端伪造IP
+	if cfg := s.configManager.GetConfig(); cfg != nil && len(cfg.Server.TrustedProxies) > 0 {
+		if err := r.SetTrustedProxies(cfg.Server.TrustedProxies); err != nil {
+			fmt.Printf("设置可信代理失败: %v\n", err)
+		}
+	}
+
+	// 使用基础的gin中间件
+	r.Use(gin.Recovery())
+	r.Use(requestIDMiddleware())
+	if cfg := s.configManager.GetConfig(); cfg != nil && cfg.Server.ErrorSourceHeader != "" {
+		r.Use(errorSourceMiddleware(cfg.Server.ErrorSourceHeader))
+	}
+	// 嵌入方注册的全局中间件，在路由匹配之前执行
+	if s.routerManager != nil {
+		r.Use(s.routerManager.Middlewares()...)
+	}
+
+	s.registerConfigRoutes(r)
+	s.registerRoutes(r)
+	return r
+}
+
+// reloadRoutes 重新加载路由
+func (s *Server) reloadRoutes() {
+	// 重新构建引擎，构建完成后原子替换，进行中的请求不受影响
+	s.engine = s.buildEngine()
+	s.handler.Store(s.engine)
+
+	fmt.Println("✓ 路由已重新加载")
+}
+
+// registerConfigRoutes 注册配置管理路由
+func (s *Server) registerConfigRoutes(r *gin.Engine) {
+	// 健康检查路由
+	r.GET("/gatewaygo/health", func(c *gin.Context) {
+		c.JSON(200, gin.H{"status": "ok"})
+	})
+
+	// 监控指标（Prometheus 文本格式）
+	r.GET("/gatewaygo/metrics", func(c *gin.Context) {
+		c.Header("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		c.Status(http.StatusOK)
+		metrics.WritePrometheus(c.Writer)
+	})
+
+	// 配置管理API
+	if cfg := s.configManager.GetConfig(); cfg != nil {
+		s.registerAdminRoutes(r, cfg)
+	}
+}
+
+// registerRoutes 注册业务路由
+func (s *Server) registerRoutes(r *gin.Engine) {
+	cfg := s.configManager.GetConfig()
+	if cfg == nil {
+		return
+	}
+	trustedProxies := cfg.Server.TrustedProxies
+	sampling := cfg.Log.Sampling
+	upstreamErrors := cfg.Server.UpstreamErrors
+	slowThreshold := cfg.Log.SlowRequestThreshold
+
+	// 创建路由处理中间件
+	r.Use(func(c *gin.Context) {
+		requestStart := time.Now()
+		// 在插件替换 c.Writer 之前获取底层连接的控制器，用于解除流式响应的写超时
+		controller := http.NewResponseController(c.Writer)
+		if logger.Log != nil && logger.Log.Core().Enabled(zap.DebugLevel) {
+			req := c.Request
+			headers := make(map[string][]string)
+			for k, v := range req.Header {
+				headers[k] = v
+			}
+			// 读取body内容（最多4KB），读取失败时中止请求
+			bodyBytes, ok := readBodyPrefix(c, "-", 4096)
+			if !ok {
+				return
+			}
+			bodyStr := string(bodyBytes)
+			startTime := time.Now()
+			// 捕获响应体
+			blw := newBodyLogWriter(c)
+			defer blw.release()
+			c.Writer = blw
+			// 1. 收到请求
+			logger.Log.Debug("收到请求",
+				zap.String("method", req.Method),
+				zap.String("path", req.URL.Path),
+				zap.String("raw_query", req.URL.RawQuery),
+				zap.Any("standard_headers", headers),
+				zap.String("client_ip", c.ClientIP()),
+				zap.Time("start_time", startTime),
+				zap.String("body", bodyStr),
+			)
+			c.Set("_debug_start_time", startTime)
+			c.Set("_debug_req_body", bodyStr)
+			c.Set("_debug_headers", headers)
+		}
+
+		path := c.Request.URL.Path
+		var matchedRoute *config.RouteConfig
+
+		// 查找匹配的路由（路由表按优先级排序，支持动态增改）
+		for _, route := range s.routerManager.Routes() {
+			if matchRoute(path, route.Match, c) {
+				matchedRoute = &route
+				if logger.Log != nil && logger.Log.Core().Enabled(zap.DebugLevel) {
+					headers, _ := c.Get("_debug_headers")
+					logger.Log.Debug("匹配到路由",
+						zap.String("route_name", route.Name),
+						zap.String("method", c.Request.Method),
+						zap.String("path", c.Request.URL.Path),
+						zap.String("match_type", route.Match.Type),
+						zap.String("match_path", route.Match.Path),
+						zap.Any("standard_headers", headers),
+					)
+				}
+				break
+			}
+		}
+
+		// 如果没有匹配的路由，继续下一个处理器
+		if matchedRoute == nil {
+			c.Next()
+			return
+		}
+
+		// 设置路由和目标信息到上下文
+		c.Set("route", matchedRoute.Name)
+		c.Set("target", matchedRoute.Target.URL)
+
+		// 请求和响应大小：所有处理（包括完成回调）结束后记录，并检查是否为慢请求
+		stats := newRequestStats(c)
+		defer stats.finish(c, matchedRoute.Name, requestStart, slowThreshold)
+
+		// 维护模式：返回维护响应，允许的客户端IP不受影响
+		if !s.maintenance.admit(c, matchedRoute.Name) {
+			return
+		}
+
+		// 并发限制：超出请求所属优先级分类的并发上限时拒绝请求
+		release, ok := s.concurrency.admit(c)
+		if !ok {
+			return
+		}
+		defer release()
+
+		// 失败请求日志：暂存详情，响应成功时丢弃
+		if matchedRoute.LogOnError {
+			capture, ok := newErrorCapture(c, matchedRoute)
+			if !ok {
+				return
+			}
+			defer capture.finish(c, matchedRoute)
+		}
+
+		// 请求捕获：按采样率记录请求和响应摘要
+		if buffer := s.capture.Load(); buffer.sampled() {
+			capture, ok := newRequestCapture(c, matchedRoute, buffer)
+			if !ok {
+				return
+			}
+			defer capture.finish(c, matchedRoute)
+		}
+
+		// 错误预算：上游错误率超出预算时按比例拒绝请求
+		if budget := s.errorBudgets.get(matchedRoute); budget != nil {
+			if !budget.admit(c, matchedRoute.Name) {
+				return
+			}
+			defer budget.record(c)
+		}
+
+		// 执行插件链，请求处理结束后执行插件注册的完成回调
+		defer core.RunCompletions(c)
+		if err := s.pluginManager.Execute(c, matchedRoute.Name); err != nil {
+			if logger.Log != nil && logger.Log.Core().Enabled(zap.WarnLevel) {
+				logger.Log.Warn("插件执行失败",
+					zap.String("route_name", matchedRoute.Name),
+					zap.String("error", err.Error()),
+				)
+			}
+			// 插件已写出错误响应时不再覆盖
+			gwerrors.Abort(c, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		// 如果请求被中止，直接返回
+		if c.IsAborted() {
+			return
+		}
+
+		// 检查是否为内部响应配置
+		if strings.HasPrefix(matchedRoute.Target.URL, "internal://") {
+			// 处理内部响应
+			if matchedRoute.Response != nil {
+				// 设置内容类型
+				if matchedRoute.Response.ContentType != "" {
+					c.Header("Content-Type", matchedRoute.Response.ContentType)
+				} else {
+					c.Header("Content-Type", "text/plain")
+				}
+
+				// 返回自定义响应，未配置状态码时返回 200
+				status := matchedRoute.Response.Status
+				if status == 0 {
+					status = 200
+				}
+				c.String(status, matchedRoute.Response.Content)
+			} else {
+				// 默认响应
+				c.String(200, "gateway-go running")
+			}
+			c.Abort()
+			return
+		}
+
+		// 按流量分配选择目标，各目标使用独立的负载均衡器和上游连接
+		upstreamRoute := matchedRoute
+		if len(matchedRoute.Target.TrafficSplit) > 0 {
+			upstreamRoute = splitRoute(matchedRoute, router.SelectSplitTarget(c, matchedRoute.Target.TrafficSplit))
+		}
+
+		// 选择后端
+		balancer, err := s.balancers.Get(upstreamRoute)
+		if err != nil {
+			if logger.Log != nil && logger.Log.Core().Enabled(zap.WarnLevel) {
+				logger.Log.Warn("负载均衡配置无效",
+					zap.String("route_name", matchedRoute.Name),
+					zap.String("error", err.Error()),
+				)
+			}
+			gwerrors.Abort(c, http.StatusInternalServerError, fmt.Sprintf("负载均衡配置无效: %v", err))
+			return
+		}
+		backend := balancer.Next(c.Request)
+		c.Set("target", backend)
+		if affinity, ok := balancer.(gwproxy.AffinityBalancer); ok {
+			if cookie := affinity.Cookie(c.Request, backend); cookie != nil {
+				http.SetCookie(c.Writer, cookie)
+			}
+		}
+
+		// 创建反向代理
+		target, err := url.Parse(backend)
+		if err != nil {
+			if logger.Log != nil && logger.Log.Core().Enabled(zap.WarnLevel) {
+				logger.Log.Warn("目标URL无效",
+					zap.String("route_name", matchedRoute.Name),
+					zap.String("target_url", backend),
+					zap.String("error", err.Error()),
+				)
+			}
+			gwerrors.Abort(c, http.StatusInternalServerError, fmt.Sprintf("无效的目标URL: %v", err))
+			return
+		}
+		if logger.Log != nil && logger.Log.Core().Enabled(zap.DebugLevel) {
+			logger.Log.Debug("开始转发请求",
+				zap.String("target_url", backend),
+				zap.String("method", c.Request.Method),
+				zap.String("path", c.Request.URL.Path),
+			)
+		}
+
+		// 处理路径前缀，保留客户端原始编码
+		proxyPath, proxyRawPath := buildProxyPath(c.Request.URL, matchedRoute)
+
+		// 获取上游连接
+		transport, err := s.connectionPool.GetTransport(upstreamRoute)
+		if err != nil {
+			if logger.Log != nil && logger.Log.Core().Enabled(zap.WarnLevel) {
+				logger.Log.Warn("上游连接配置无效",
+					zap.String("route_name", matchedRoute.Name),
+					zap.String("error", err.Error()),
+				)
+			}
+			gwerrors.Abort(c, http.StatusBadGateway, fmt.Sprintf("上游连接配置无效: %v", err))
+			return
+		}
+
+		// 改写转发请求头的大小写
+		if hc := matchedRoute.Target.HeaderCase; hc != nil {
+			transport = gwproxy.NewHeaderCaseTransport(transport, hc.Mode, hc.Mappings)
+		}
+
+		// 按请求方法确定超时和重试次数
+		timeout, retries := matchedRoute.Target.Policy(c.Request.Method)
+
+		// 缓存请求体，用于重试时重放、记录死信和流量镜像
+		deadLetter := s.deadLetter.Load()
+		mirrored := mirrorSampled(matchedRoute.Target.Mirror)
+		var bufferedBody []byte
+		bodyComplete := true
+		limit := deadLetter.bodyLimit()
+		if (retries > 0 || mirrored) && limit < retryMaxBody {
+			limit = retryMaxBody
+		}
+		if limit > 0 {
+			var ok bool
+			if bufferedBody, bodyComplete, ok = bufferBody(c, matchedRoute.Name, int64(limit)); !ok {
+				return
+			}
+		}
+
+		// 流量镜像：请求体超过缓存上限时不镜像
+		if mirrored && bodyComplete {
+			s.mirrorRequest(c, matchedRoute, bufferedBody, proxyPath, proxyRawPath)
+		}
+
+		// 创建反向代理
+		reqBody := trackBody(c)
+		retry := gwproxy.NewRetryTransport(transport, balancer, backend, retries)
+		retry.ClientError = reqBody.readErr
+		proxy := httputil.NewSingleHostReverseProxy(target)
+		proxy.Transport = retry
+		// 设置自定义的 Director
+		// req 的请求头复制自插件处理后的 c.Request，只覆盖转发相关的请求头，不修改客户端请求本身
+		originalDirector := proxy.Director
+		proxy.Director = func(req *http.Request) {
+			originalDirector(req)
+			req.URL.Path = proxyPath
+			req.URL.RawPath = proxyRawPath
+			// X-Forwarded-For 由 ReverseProxy 在 Director 之后追加对端地址，保留客户端已有的转发链
+			req.Header.Set("X-Forwarded-Host", c.Request.Host)
+			req.Header.Set("X-Forwarded-Proto", forwardedProto(c, trustedProxies))
+			req.Header.Set("X-Origin-Host", target.Host)
+			req.Header.Set(gwerrors.RequestIDHeader, gwerrors.RequestID(c))
+			for name, value := range matchedRoute.Target.Headers {
+				req.Header.Set(name, value)
+			}
+		}
+		// 设置错误处理
+		proxy.ErrorHandler = func(rw http.ResponseWriter, req *http.Request, err error) {
+			// 读取客户端请求体失败，不属于上游故障
+			if readErr := reqBody.readErr(); readErr != nil {
+				abortBodyReadError(c, matchedRoute.Name, readErr)
+				return
+			}
+			details := proxyErrorDetails{
+				Route:    matchedRoute.Name,
+				Target:   retry.Backend(),
+				Category: classifyProxyError(err),
+				Attempts: len(retry.Attempts()),
+			}
+			// 超过请求超时时间或截止时间
+			deadlineExceeded := errors.Is(req.Context().Err(), context.DeadlineExceeded)
+			if deadlineExceeded {
+				details.Category = proxyErrorTimeout
+			}
+			response, mapped := upstreamErrors[details.Category]
+			status := proxyErrorStatus(details.Category)
+			if mapped && response.Status != 0 {
+				status = response.Status
+			}
+			if logger.Log != nil && logger.Log.Core().Enabled(zap.WarnLevel) {
+				logger.Log.Warn("反向代理失败",
+					zap.String("route_name", details.Route),
+					zap.String("target_url", details.Target),
+					zap.Int("attempts", details.Attempts),
+					zap.String("category", details.Category),
+					zap.Int("status", status),
+					zap.String("request_id", gwerrors.RequestID(c)),
+					zap.String("error", err.Error()),
+				)
+			}
+			lang := gwerrors.RequestLanguage(c.Request)
+			message := gwerrors.GetErrorMessage(gwerrors.ErrDeadlineExceeded, lang)
+			if !deadlineExceeded {
+				// 重试耗尽仍失败，记录死信（客户端取消的请求除外）
+				if req.Context().Err() == nil {
+					deadLetter.record(c, matchedRoute, bufferedBody, bodyComplete, retry.Attempts(), err)
+				}
+				message = fmt.Sprintf("%s: %v", gwerrors.GetErrorMessage(gwerrors.ErrProxyFailed, lang), err)
+			}
+			if details.Category == proxyErrorTimeout {
+				c.Set(gatewayErrorKey, gatewayErrorTimeout)
+			} else {
+				c.Set(gatewayErrorKey, gatewayErrorUnavailable)
+			}
+			if mapped {
+				abortUpstreamError(c, status, message, details, response)
+				return
+			}
+			gwerrors.AbortWithDetails(c, status, message, details)
+		}
+		// 捕获后端响应体
+		proxy.ModifyResponse = func(resp *http.Response) error {
+			// 上游响应（包括 4xx/5xx）原样转发
+			c.Set(upstreamResponseKey, true)
+			filterResponseHeaders(resp.Header, matchedRoute.Target.ResponseHeaders)
+			// 流式响应每次写入后立即刷新，不读取响应体，且不受 server.write_timeout 限制
+			if isStreamingResponse(resp, matchedRoute.Target.Streaming) {
+				proxy.FlushInterval = -1
+				core.MarkStreaming(c)
+				if err := controller.SetWriteDeadline(time.Time{}); err != nil && !errors.Is(err, http.ErrNotSupported) {
+					return err
+				}
+				return nil
+			}
+			if logger.Log != nil && logger.Log.Core().Enabled(zap.DebugLevel) {
+				respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+				logger.Log.Debug("收到后端响应",
+					zap.String("target_url", retry.Backend()),
+					zap.Int("status", resp.StatusCode),
+					zap.String("resp_body", string(respBody)),
+				)
+				resp.Body = io.NopCloser(bytes.NewBuffer(respBody))
+			}
+			return nil
+		}
+		// 限制请求总时长（含重试）
+		if timeout > 0 {
+			ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+			defer cancel()
+			c.Request = c.Request.WithContext(ctx)
+		}
+		// 执行代理请求
+		proxy.ServeHTTP(c.Writer, c.Request)
+		c.Set("target", retry.Backend())
+		c.Abort()
+		if logger.Log != nil && logger.Log.Core().Enabled(zap.DebugLevel) {
+			startTime, _ := c.Get("_debug_start_time")
+			cost := time.Since(startTime.(time.Time))
+			logger.Log.Debug("返回给用户",
+				zap.String("method", c.Request.Method),
+				zap.String("path", c.Request.URL.Path),
+				zap.Duration("cost", cost),
+			)
+		}
+
+		// access log: 写入独立的访问日志，未配置时在 info 级别下写入主日志
+		if accessLog := accessLogger(); accessLog != nil {
+			blw := newBodyLogWriter(c)
+			defer blw.release()
+			c.Writer = blw
+			c.Next()
+			// 耗时从网关收到请求开始计算，包含插件链和上游响应
+			cost := time.Since(requestStart)
+			statusCode := c.Writer.Status()
+			target := "-"
+			if v, ok := c.Get("target"); ok {
+				target, _ = v.(string)
+			}
+			if accessSampled(sampling, statusCode) {
+				accessLog.Info("access",
+					zap.String("method", c.Request.Method),
+					zap.String("path", c.Request.URL.Path),
+					zap.Int("status", statusCode),
+					zap.Duration("cost", cost),
+					zap.String("client_ip", c.ClientIP()),
+					zap.String("target", target),
+					zap.Int64("request_bytes", stats.requestBytes()),
+					zap.Int64("response_bytes", responseBytes(c)),
+				)
+			}
+		}
+	})
+
+	// 注册一个通配符路由来捕获所有请求
+	r.NoRoute(func(c *gin.Context) {
+		if logger.Log != nil && logger.Log.Core().Enabled(zap.WarnLevel) {
+			logger.Log.Warn("未匹配到路由",
+				zap.String("method", c.Request.Method),
+				zap.String("path", c.Request.URL.Path),
+				zap.String("client_ip", c.ClientIP()),
+			)
+		}
+		c.Set(gatewayErrorKey, gatewayErrorNoRoute)
+		gwerrors.Abort(c, http.StatusNotFound, "未找到匹配的路由")
+	})
+}
+
+// accessLogger 返回访问日志的记录器
+// 配置了 log.access 时使用独立的访问日志，否则仅在主日志为 info 级别时写入主日志（debug 级别已记录完整请求）
+func accessLogger() *zap.Logger {
+	if logger.Access != nil {
+		return logger.Access
+	}
+	if logger.Log != nil && logger.Log.Core().Enabled(zap.InfoLevel) && !logger.Log.Core().Enabled(zap.DebugLevel) {
+		return logger.Log
+	}
+	return nil
+}
+
+// accessSampled 按采样配置决定是否记录访问日志，状态码不低于 always_status 的请求始终记录
+func accessSampled(sampling *config.LogSamplingConfig, status int) bool {
+	if sampling == nil {
+		return true
+	}
+	alwaysStatus := sampling.AlwaysStatus
+	if alwaysStatus == 0 {
+		alwaysStatus = http.StatusInternalServerError
+	}
+	if status >= alwaysStatus {
+		return true
+	}
+	return rand.Float64() < sampling.Rate
+}
+
+// splitRoute 返回使用流量分配目标的路由副本，名称附加目标以区分各目标的负载均衡器
+func splitRoute(route *config.RouteConfig, target string) *config.RouteConfig {
+	split := *route
+	split.Name = route.Name + "|" + target
+	split.Target.URL = target
+	return &split
+}
+
+// forwardedProto 返回客户端请求使用的协议
+// 请求来自可信代理（未配置 trusted_proxies 时采信所有来源）时沿用其 X-Forwarded-Proto，否则按网关监听的协议
+func forwardedProto(c *gin.Context, trustedProxies []string) string {
+	if proto := c.GetHeader("X-Forwarded-Proto"); proto != "" {
+		if len(trustedProxies) == 0 || ipwhitelist.Contains(trustedProxies, c.RemoteIP()) {
+			return proto
+		}
+	}
+	if c.Request.TLS != nil {
+		return "https"
+	}
+	return "http"
+}
+
+// matchRoute 检查路径是否匹配路由规则
+func matchRoute(path string, match config.RouteMatch, c *gin.Context) bool {
+	// 路径匹配，regex 和 wildcard 类型使用加载路由时预编译的正则
+	if !match.MatchPath(path) {
+		return false
+	}
+	// Host 匹配
+	if match.Host != "" && c.Request.Host != match.Host {
+		return false
+	}
+	// Method 匹配
+	if match.Method != "" && c.Request.Method != match.Method {
+		return false
+	}
+	// Headers 匹配
+	for k, v := range match.Headers {
+		reqVal := c.GetHeader(k)
+		if reqVal != v {
+			return false
+		}
+	}
+	// QueryParams 匹配
+	for k, v := range match.QueryParams {
+		if c.Query(k) != v {
+			return false
+		}
+	}
+	// 客户端IP匹配
+	if !match.MatchSourceIP(c.ClientIP()) {
+		return false
+	}
+	return true
+}
+
+// bodyLogWriter 记录响应体的写入器
+type bodyLogWriter struct {
+	gin.ResponseWriter
+	// 从 bodyBuffers 获取，release 后为 nil
+	body    *bytes.Buffer
+	context *gin.Context
+}
+
+// newBodyLogWriter 接管响应写入并记录响应体，请求结束后需调用 release 归还缓冲区
+func newBodyLogWriter(c *gin.Context) *bodyLogWriter {
+	return &bodyLogWriter{body: bodyBuffers.Get(), ResponseWriter: c.Writer, context: c}
+}
+
+// Write 写入响应体，流式响应不记录
+func (w *bodyLogWriter) Write(b []byte) (int, error) {
+	if w.body != nil && !core.IsStreaming(w.context) {
+		w.body.Write(b)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// release 归还缓冲区，之后的写入不再记录
+func (w *bodyLogWriter) release() {
+	bodyBuffers.Put(w.body)
+	w.body = nil
+}
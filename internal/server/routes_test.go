@@ -0,0 +1,351 @@
+package server
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"gateway-go/internal/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestSourceCIDRRoute(t *testing.T) {
+	internal := textUpstream(t, "internal")
+	public := textUpstream(t, "public")
+
+	cfg := testConfig(public.URL)
+	cfg.Server.TrustedProxies = []string{"127.0.0.1"}
+	cfg.Routes = append(cfg.Routes, config.RouteConfig{
+		Name:   "admin",
+		Match:  config.RouteMatch{Type: "prefix", Path: "/admin", Priority: 10, SourceCIDR: []string{"10.0.0.0/8", "192.168.1.10"}},
+		Target: config.TargetConfig{URL: internal.URL},
+	})
+	_, base := startTestServer(t, cfg)
+
+	tests := []struct {
+		name      string
+		path      string
+		forwarded string
+		want      string
+	}{
+		{"网段内的客户端", "/admin/users", "10.1.2.3", "internal"},
+		{"单个IP", "/admin/users", "192.168.1.10", "internal"},
+		{"网段外的客户端", "/admin/users", "192.168.1.11", "public"},
+		// 直连客户端 127.0.0.1 不在网段内
+		{"无转发头", "/admin/users", "", "public"},
+		{"网段内但路径不匹配", "/orders", "10.1.2.3", "public"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, base+tt.path, nil)
+			if tt.forwarded != "" {
+				req.Header.Set("X-Forwarded-For", tt.forwarded)
+			}
+			if _, body := doRequest(t, req); body != tt.want {
+				t.Fatalf("请求转发到 %q，期望 %s", body, tt.want)
+			}
+		})
+	}
+}
+
+func TestSourceCIDRRouteWithoutFallback(t *testing.T) {
+	cfg := testConfig(textUpstream(t, "internal").URL)
+	cfg.Routes[0].Match.SourceCIDR = []string{"10.0.0.0/8"}
+	// 不可信的代理传来的 X-Forwarded-For 不被采信
+	cfg.Server.TrustedProxies = []string{"192.0.2.1"}
+	_, base := startTestServer(t, cfg)
+
+	req, _ := http.NewRequest(http.MethodGet, base+"/", nil)
+	req.Header.Set("X-Forwarded-For", "10.1.2.3")
+	if resp, _ := doRequest(t, req); resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("来源不在网段内时状态码 = %d，期望 404", resp.StatusCode)
+	}
+}
+
+func TestInternalRouteResponse(t *testing.T) {
+	cfg := testConfig(textUpstream(t, "upstream").URL)
+	cfg.Routes = append(cfg.Routes,
+		config.RouteConfig{
+			Name:   "health",
+			Match:  config.RouteMatch{Type: "exact", Path: "/health", Priority: 10},
+			Target: config.TargetConfig{URL: "internal://health"},
+		},
+		config.RouteConfig{
+			Name:     "maintenance",
+			Match:    config.RouteMatch{Type: "prefix", Path: "/legacy", Priority: 10},
+			Target:   config.TargetConfig{URL: "internal://maintenance"},
+			Response: &config.ResponseConfig{Status: http.StatusServiceUnavailable, Content: `{"error":"维护中"}`, ContentType: "application/json"},
+		},
+		config.RouteConfig{
+			Name:     "ping",
+			Match:    config.RouteMatch{Type: "exact", Path: "/ping", Priority: 10},
+			Target:   config.TargetConfig{URL: "internal://ping"},
+			Response: &config.ResponseConfig{Content: "pong"},
+		},
+	)
+	_, base := startTestServer(t, cfg)
+
+	tests := []struct {
+		path        string
+		status      int
+		contentType string
+		body        string
+	}{
+		// 未配置 response 时返回默认内容
+		{"/health", http.StatusOK, "text/plain", "gateway-go running"},
+		{"/legacy/orders", http.StatusServiceUnavailable, "application/json", `{"error":"维护中"}`},
+		// 未配置状态码和内容类型时使用 200 和 text/plain
+		{"/ping", http.StatusOK, "text/plain", "pong"},
+		{"/orders", http.StatusOK, "", "upstream"},
+	}
+	for _, tt := range tests {
+		req, _ := http.NewRequest(http.MethodGet, base+tt.path, nil)
+		resp, body := doRequest(t, req)
+		if resp.StatusCode != tt.status || body != tt.body {
+			t.Fatalf("%s 响应 = %d %q，期望 %d %q", tt.path, resp.StatusCode, body, tt.status, tt.body)
+		}
+		if !strings.HasPrefix(resp.Header.Get("Content-Type"), tt.contentType) {
+			t.Fatalf("%s 内容类型 = %q，期望 %q", tt.path, resp.Header.Get("Content-Type"), tt.contentType)
+		}
+	}
+}
+
+func TestStickyCookieRoute(t *testing.T) {
+	one := textUpstream(t, "one")
+	two := textUpstream(t, "two")
+	cfg := testConfig(one.URL + "," + two.URL)
+	cfg.Routes[0].Target.Sticky = &config.StickyConfig{Cookie: "sid"}
+	_, base := startTestServer(t, cfg)
+
+	req, _ := http.NewRequest(http.MethodGet, base+"/", nil)
+	resp, first := doRequest(t, req)
+	cookies := resp.Cookies()
+	if len(cookies) != 1 || cookies[0].Name != "sid" {
+		t.Fatalf("首次请求的 cookie = %v，期望下发 sid", cookies)
+	}
+
+	// 携带 cookie 的请求始终转发到同一后端
+	for i := 0; i < 10; i++ {
+		req, _ := http.NewRequest(http.MethodGet, base+"/", nil)
+		req.AddCookie(cookies[0])
+		resp, body := doRequest(t, req)
+		if body != first || len(resp.Cookies()) != 0 {
+			t.Fatalf("第 %d 次请求转发到 %q（cookie %v），期望固定到 %q 且不重复下发", i+1, body, resp.Cookies(), first)
+		}
+	}
+}
+
+func TestWildcardAndRegexRoutes(t *testing.T) {
+	cfg := testConfig(textUpstream(t, "default").URL)
+	cfg.Routes = append(cfg.Routes,
+		config.RouteConfig{
+			Name:   "assets",
+			Match:  config.RouteMatch{Type: "wildcard", Path: "/static/*.css", Priority: 10},
+			Target: config.TargetConfig{URL: textUpstream(t, "assets").URL},
+		},
+		config.RouteConfig{
+			Name:   "users",
+			Match:  config.RouteMatch{Type: "regex", Path: `^/users/\d+$`, Priority: 10},
+			Target: config.TargetConfig{URL: textUpstream(t, "users").URL},
+		},
+	)
+	srv, base := startTestServer(t, cfg)
+
+	// 动态添加的通配符路由同样生效
+	if err := srv.routerManager.AddRoute(config.RouteConfig{
+		Name:   "reports",
+		Match:  config.RouteMatch{Type: "wildcard", Path: "/reports/*/pdf", Priority: 20},
+		Target: config.TargetConfig{URL: textUpstream(t, "reports").URL},
+	}); err != nil {
+		t.Fatalf("添加路由失败: %v", err)
+	}
+	srv.reloadRoutes()
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/static/css/site.css", "assets"},
+		{"/static/site.js", "default"},
+		{"/users/42", "users"},
+		{"/users/42/orders", "default"},
+		{"/users/abc", "default"},
+		{"/reports/2024/q1/pdf", "reports"},
+		{"/reports/2024/q1/csv", "default"},
+	}
+	for _, tt := range tests {
+		if _, body := get(t, base+tt.path); body != tt.want {
+			t.Fatalf("%s 转发到 %q，期望 %s", tt.path, body, tt.want)
+		}
+	}
+}
+
+func TestRegexRouteColdStartDoesNotHang(t *testing.T) {
+	cfg := testConfig(textUpstream(t, "default").URL)
+	cfg.Routes = append(cfg.Routes, config.RouteConfig{
+		Name:   "users",
+		Match:  config.RouteMatch{Type: "regex", Path: `^/users/\d+$`, Priority: 10},
+		Target: config.TargetConfig{URL: textUpstream(t, "users").URL},
+	})
+	srv, base := startTestServer(t, cfg)
+
+	// 新启动的服务直接并发请求正则路由，同时动态增改路由
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		var wg sync.WaitGroup
+		for i := 0; i < 20; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				path := "/users/" + strconv.Itoa(i)
+				if _, body := get(t, base+path); body != "users" {
+					t.Errorf("%s 转发到 %q，期望 users", path, body)
+				}
+			}(i)
+		}
+		for i := 0; i < 5; i++ {
+			route := config.RouteConfig{
+				Name:   "svc-" + strconv.Itoa(i),
+				Match:  config.RouteMatch{Type: "wildcard", Path: "/svc" + strconv.Itoa(i) + "/*"},
+				Target: config.TargetConfig{URL: "http://127.0.0.1:1"},
+			}
+			if err := srv.routerManager.AddRoute(route); err != nil {
+				t.Errorf("添加路由失败: %v", err)
+			}
+			route.Match.Type = "regex"
+			route.Match.Path = "^/svc" + strconv.Itoa(i) + "/.+$"
+			if err := srv.routerManager.UpdateRoute(route.Name, route); err != nil {
+				t.Errorf("更新路由失败: %v", err)
+			}
+		}
+		wg.Wait()
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("冷启动时并发请求正则路由超时，疑似死锁")
+	}
+}
+
+func TestServerUseRunsBeforeRouteMatching(t *testing.T) {
+	cfg := testConfig(textUpstream(t, "ok").URL)
+	cfg.Routes[0].Match.Path = "/api"
+	srv := newTestServer(t, cfg)
+
+	var seen []string
+	var mu sync.Mutex
+	srv.Use(func(c *gin.Context) {
+		mu.Lock()
+		seen = append(seen, c.Request.URL.Path)
+		mu.Unlock()
+		c.Header("X-Trace", "traced")
+	}, func(c *gin.Context) {
+		// 自定义认证：在路由匹配之前拒绝请求
+		if c.GetHeader("X-Deny") != "" {
+			c.AbortWithStatus(http.StatusForbidden)
+		}
+	})
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv.SetListener(ln)
+	if err := srv.Start(context.Background()); err != nil {
+		t.Fatalf("启动网关失败: %v", err)
+	}
+	t.Cleanup(func() { srv.Stop() })
+	base := "http://" + srv.Addr()
+
+	tests := []struct {
+		path   string
+		deny   bool
+		status int
+	}{
+		{"/api/orders", false, http.StatusOK},
+		{"/missing", false, http.StatusNotFound},
+		{"/gatewaygo/health", false, http.StatusOK},
+		{"/api/orders", true, http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		req, _ := http.NewRequest(http.MethodGet, base+tt.path, nil)
+		if tt.deny {
+			req.Header.Set("X-Deny", "1")
+		}
+		resp, _ := doRequest(t, req)
+		if resp.StatusCode != tt.status || resp.Header.Get("X-Trace") != "traced" {
+			t.Fatalf("%s 响应 = %d X-Trace=%q，期望 %d 且经过全局中间件", tt.path, resp.StatusCode, resp.Header.Get("X-Trace"), tt.status)
+		}
+	}
+
+	// 重建路由引擎后中间件仍然生效
+	srv.reloadRoutes()
+	req, _ := http.NewRequest(http.MethodGet, base+"/missing", nil)
+	if resp, _ := doRequest(t, req); resp.Header.Get("X-Trace") != "traced" {
+		t.Fatal("重建路由引擎后 404 请求未经过全局中间件")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if want := "/api/orders,/missing,/gatewaygo/health,/api/orders,/missing"; strings.Join(seen, ",") != want {
+		t.Fatalf("全局中间件处理的请求 = %v，期望 %s", seen, want)
+	}
+}
+
+// wildcardRoutes 返回 n 条通配符路由，请求路径只匹配最后一条
+func wildcardRoutes(n int) []config.RouteConfig {
+	routes := make([]config.RouteConfig, n)
+	for i := range routes {
+		routes[i] = config.RouteConfig{
+			Name:  "svc-" + strconv.Itoa(i),
+			Match: config.RouteMatch{Type: "wildcard", Path: "/svc" + strconv.Itoa(i) + "/*/items/*.json"},
+		}
+	}
+	return routes
+}
+
+// BenchmarkMatchRouteWildcard 在 100 条通配符路由中查找最后一条，对比每次匹配时编译与加载时预编译
+func BenchmarkMatchRouteWildcard(b *testing.B) {
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodGet, "/svc99/v1/items/42.json", nil)
+	path := c.Request.URL.Path
+
+	b.Run("compile", func(b *testing.B) {
+		routes := wildcardRoutes(100)
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			for _, route := range routes {
+				// 预编译之前的做法：每次匹配时构建并编译正则
+				expr := "^" + strings.ReplaceAll(regexp.QuoteMeta(route.Match.Path), `\*`, ".*") + "$"
+				if regexp.MustCompile(expr).MatchString(path) {
+					break
+				}
+			}
+		}
+	})
+	b.Run("precompiled", func(b *testing.B) {
+		routes := wildcardRoutes(100)
+		for i := range routes {
+			routes[i].Match.Compile()
+		}
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			for _, route := range routes {
+				if matchRoute(path, route.Match, c) {
+					break
+				}
+			}
+		}
+	})
+}
@@ -0,0 +1,604 @@
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"sync"
+	"sync/atomic"
+
+	"gateway-go/internal/config"
+	"gateway-go/internal/errors"
+	"gateway-go/internal/logger"
+	"gateway-go/internal/plugin"
+	"gateway-go/internal/plugin/plugins/circuitbreaker"
+	"gateway-go/internal/proxy"
+	"gateway-go/internal/router"
+
+	"github.com/gin-gonic/gin"
+	"github.com/quic-go/quic-go/http3"
+)
+
+// Server 网关服务实例，封装插件、路由和HTTP监听的完整生命周期
+type Server struct {
+	configManager *config.ConfigManager
+	configCenter  *config.ConfigCenter
+	// 配置来源：file、etcd 或 consul
+	configSource  string
+	pluginManager *plugin.Manager
+	// 已加载的外部插件，键为 .so 路径，值为插件名称
+	externalPlugins map[string]string
+	routerManager   *router.Manager
+	// 熔断器插件实例，供管理API手动干预熔断状态
+	circuitBreaker *circuitbreaker.CircuitBreakerPlugin
+
+	connectionPool *proxy.ConnectionPool
+	balancers      *proxy.BalancerManager
+	errorBudgets   *errorBudgetManager
+	concurrency    *concurrencyLimiter
+	maintenance    *maintenanceState
+	// 客户端连接统计，优雅关闭时输出剩余连接数
+	conns      *connTracker
+	deadLetter atomic.Pointer[deadLetterSink]
+	audit      atomic.Pointer[auditSink]
+	capture    atomic.Pointer[captureBuffer]
+
+	engine     *gin.Engine
+	handler    *atomicHandler
+	httpServer *http.Server
+	tlsServer  *http.Server
+	h3Server   *http3.Server
+	certLoader *certReloader
+
+	// Start 之前注册的全局中间件，启动时交给路由管理器
+	middlewares []gin.HandlerFunc
+
+	// 外部传入的HTTP监听器（为空时按配置端口监听）
+	listener net.Listener
+	httpAddr net.Addr
+	tlsAddr  net.Addr
+	h3Addr   net.Addr
+	started  bool
+	stopOnce sync.Once
+	mu       sync.RWMutex
+	// 串行化管理API的配置变更
+	adminMu     sync.Mutex
+	stoppedChan chan struct{}
+}
+
+// New 创建网关服务，configManager 必须已加载配置
+func New(configManager *config.ConfigManager) *Server {
+	return &Server{
+		configManager: configManager,
+		balancers:     proxy.NewBalancerManager(),
+		errorBudgets:  newErrorBudgetManager(),
+		concurrency:   newConcurrencyLimiter(),
+		maintenance:   newMaintenanceState(),
+		conns:         newConnTracker(),
+		stoppedChan:   make(chan struct{}),
+	}
+}
+
+// SetListener 指定HTTP监听器，需在 Start 之前调用
+// 可传入 127.0.0.1:0 的监听器以使用随机端口（测试或嵌入场景）
+func (s *Server) SetListener(ln net.Listener) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.listener = ln
+}
+
+// Use 注册在路由匹配之前执行的全局中间件（如自定义认证、链路追踪），需在 Start 之前调用
+// 中间件对所有请求生效，包括未匹配路由的 404 请求
+func (s *Server) Use(middlewares ...gin.HandlerFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.middlewares = append(s.middlewares, middlewares...)
+}
+
+// Start 初始化插件和路由并启动监听，监听就绪后返回
+// ctx 结束时服务自动停止；启动失败时已打开的资源均被释放，不会留下后台协程
+func (s *Server) Start(ctx context.Context) (err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.started {
+		return fmt.Errorf("服务已启动")
+	}
+
+	cfg := s.configManager.GetConfig()
+	if cfg == nil {
+		return fmt.Errorf("配置未加载")
+	}
+
+	// 初始化配置版本管理，配置了远程配置来源时以远程配置替换本地引导配置
+	if cfg, err = s.initConfigCenter(cfg); err != nil {
+		return err
+	}
+
+	// 根据配置文件设置 gin 运行模式
+	if cfg.Server.Mode == "release" {
+		gin.SetMode(gin.ReleaseMode)
+	} else if cfg.Server.Mode == "test" {
+		gin.SetMode(gin.TestMode)
+	} else {
+		gin.SetMode(gin.DebugMode)
+	}
+	// 初始化日志系统
+	if err := logger.Init(&cfg.Log); err != nil {
+		return fmt.Errorf("初始化日志失败: %w", err)
+	}
+
+	// 初始化上游连接池
+	s.connectionPool = proxy.NewConnectionPool(cfg.Server.Transport)
+
+	// 初始化死信日志
+	deadLetter, err := newDeadLetterSink(cfg.Server.DeadLetter)
+	if err != nil {
+		return fmt.Errorf("初始化死信日志失败: %w", err)
+	}
+	s.deadLetter.Store(deadLetter)
+	// 启动失败时关闭已打开的日志文件
+	defer func() {
+		if err != nil {
+			s.deadLetter.Swap(nil).Close()
+			s.audit.Swap(nil).Close()
+		}
+	}()
+
+	// 初始化审计日志
+	audit, err := newAuditSink(cfg.Server.Admin.AuditLog)
+	if err != nil {
+		return fmt.Errorf("初始化审计日志失败: %w", err)
+	}
+	s.audit.Store(audit)
+
+	// 加载错误消息
+	if dir := cfg.Server.ErrorMessagesDir; dir != "" {
+		if err := errors.LoadAllErrorMessages(dir); err != nil {
+			return fmt.Errorf("加载错误消息失败: %w", err)
+		}
+	}
+
+	// 初始化请求捕获
+	s.capture.Store(newCaptureBuffer(cfg.Server.Capture))
+
+	// 初始化并发限制
+	s.concurrency.update(cfg.Server.Concurrency, cfg.Server.TrustedProxies)
+
+	// 初始化插件管理器，启动失败时停止已初始化的插件
+	s.pluginManager = plugin.NewManager()
+	defer func() {
+		if err != nil {
+			s.pluginManager.Stop()
+		}
+	}()
+
+	// 注册所有插件
+	s.registerPlugins()
+
+	// 加载外部插件
+	if err := s.registerExternalPlugins(cfg); err != nil {
+		return err
+	}
+
+	// 加载可用插件配置
+	if err := s.loadAvailablePlugins(cfg); err != nil {
+		return fmt.Errorf("加载可用插件失败: %w", err)
+	}
+
+	// 加载路由插件
+	if err := s.loadRoutePlugins(cfg); err != nil {
+		return fmt.Errorf("加载路由插件失败: %w", err)
+	}
+
+	// 初始化路由管理器
+	s.routerManager = router.NewManagerFromConfig(s.configManager, s.pluginManager)
+	s.routerManager.Use(s.middlewares...)
+
+	// 构建HTTP服务器
+	s.engine = s.buildEngine()
+	s.handler = newAtomicHandler(s.engine)
+	s.httpServer = s.newHTTPServer(fmt.Sprintf(":%d", cfg.Server.Port), s.handler, &cfg.Server)
+
+	// 构建HTTPS服务器
+	if tlsEnabled(cfg) {
+		var err error
+		s.certLoader, err = newCertReloader(cfg.Server.TLS.CertFile, cfg.Server.TLS.KeyFile)
+		if err != nil {
+			return fmt.Errorf("初始化TLS失败: %w", err)
+		}
+		tlsConfig, err := buildTLSConfig(cfg.Server.TLS, s.certLoader)
+		if err != nil {
+			return fmt.Errorf("初始化TLS失败: %w", err)
+		}
+		tlsPort := cfg.Server.TLS.Port
+		if httpsOnly(cfg) {
+			tlsPort = cfg.Server.Port
+		}
+		var tlsHandler http.Handler = s.handler
+		// HTTP/3 与 HTTPS 共用处理器，并在HTTPS响应中通过 Alt-Svc 公布
+		if http3Enabled(cfg) {
+			h3Port := cfg.Server.TLS.HTTP3.Port
+			if h3Port == 0 {
+				h3Port = tlsPort
+			}
+			s.h3Server = newHTTP3Server(tlsConfig, h3Port, s.handler, &cfg.Server)
+			tlsHandler = altSvcHandler(s.h3Server, s.handler)
+		}
+		s.tlsServer = s.newHTTPServer(fmt.Sprintf(":%d", tlsPort), tlsHandler, &cfg.Server)
+		s.tlsServer.TLSConfig = tlsConfig
+	}
+
+	// 绑定所有监听，任一失败时关闭已绑定的监听
+	listeners, err := s.listen(cfg)
+	if err != nil {
+		return err
+	}
+
+	// 添加配置重载钩子
+	s.configManager.AddReloadHook(s.reload)
+	s.configManager.AddReloadHook(func(cfg *config.Config) error {
+		s.configCenter.RecordConfig(cfg, s.configSource, "配置重载")
+		return nil
+	})
+
+	// 启动配置监视（内存配置无文件可监视），所有可能失败的步骤完成后再启动后台协程
+	if s.configSource != "file" {
+		// 远程配置的变更经 ApplyConfig 执行与文件重载相同的流程
+		if err := s.configCenter.WatchConfig(s.configManager.ApplyConfig); err != nil {
+			listeners.close()
+			return fmt.Errorf("启动远程配置监视失败: %w", err)
+		}
+	} else {
+		if s.configManager.GetConfigPath() != "" {
+			if err := s.configManager.WatchConfig(); err != nil {
+				listeners.close()
+				return fmt.Errorf("启动配置监视失败: %w", err)
+			}
+		}
+		// 启动重载工作协程，使用远程配置时不从本地文件重载
+		s.configManager.StartReloadWorker()
+	}
+
+	s.serve(listeners)
+	s.started = true
+
+	// ctx 结束时自动停止
+	go func() {
+		select {
+		case <-ctx.Done():
+			s.Stop()
+		case <-s.stoppedChan:
+		}
+	}()
+
+	return nil
+}
+
+// initConfigCenter 初始化配置版本管理，返回服务使用的配置
+// 配置了 server.config_source 时从远程读取完整配置，验证通过后替换本地引导配置
+func (s *Server) initConfigCenter(cfg *config.Config) (*config.Config, error) {
+	maxVersions := cfg.Server.Admin.MaxVersions
+	if maxVersions <= 0 {
+		maxVersions = defaultMaxVersions
+	}
+	s.configCenter = config.NewConfigCenter(maxVersions)
+
+	source := cfg.Server.ConfigSource
+	if source == nil {
+		s.configSource = "file"
+		if err := s.configCenter.InitFromConfig(cfg, "file"); err != nil {
+			return nil, fmt.Errorf("初始化配置版本失败: %w", err)
+		}
+		return cfg, nil
+	}
+
+	backend, err := config.NewRemoteBackend(*source)
+	if err != nil {
+		return nil, fmt.Errorf("初始化远程配置来源失败: %w", err)
+	}
+	s.configCenter.SetBackend(backend)
+	if err := s.configCenter.Init(""); err != nil {
+		return nil, fmt.Errorf("读取远程配置失败: %w", err)
+	}
+	s.configSource = backend.Name()
+
+	remote := s.configCenter.GetCurrentConfig()
+	if err := s.configManager.SetConfig(remote); err != nil {
+		return nil, err
+	}
+	return remote, nil
+}
+
+// serverListeners 启动时绑定的监听
+type serverListeners struct {
+	http net.Listener
+	tls  net.Listener
+	h3   net.PacketConn
+	// http 是否为 SetListener 传入的监听，启动失败时由调用方关闭
+	external bool
+}
+
+// close 关闭启动过程中绑定的监听
+func (l *serverListeners) close() {
+	if l.http != nil && !l.external {
+		l.http.Close()
+	}
+	if l.tls != nil {
+		l.tls.Close()
+	}
+	if l.h3 != nil {
+		l.h3.Close()
+	}
+}
+
+// listen 绑定HTTP、HTTPS和HTTP/3监听，任一失败时关闭已绑定的监听并返回错误
+func (s *Server) listen(cfg *config.Config) (*serverListeners, error) {
+	listeners := &serverListeners{}
+
+	// HTTP（只监听HTTPS时跳过）
+	if !httpsOnly(cfg) {
+		listeners.http, listeners.external = s.listener, s.listener != nil
+		if listeners.http == nil {
+			ln, err := net.Listen("tcp", s.httpServer.Addr)
+			if err != nil {
+				return nil, fmt.Errorf("HTTP服务器监听失败: %w", err)
+			}
+			listeners.http = ln
+		}
+	}
+
+	// HTTPS
+	if s.tlsServer != nil {
+		ln, err := net.Listen("tcp", s.tlsServer.Addr)
+		if err != nil {
+			listeners.close()
+			return nil, fmt.Errorf("HTTPS服务器监听失败: %w", err)
+		}
+		listeners.tls = ln
+	}
+
+	// HTTP/3
+	if s.h3Server != nil {
+		conn, err := net.ListenPacket("udp", s.h3Server.Addr)
+		if err != nil {
+			listeners.close()
+			return nil, fmt.Errorf("HTTP/3服务器监听失败: %w", err)
+		}
+		listeners.h3 = conn
+	}
+
+	return listeners, nil
+}
+
+// serve 在已绑定的监听上启动各服务
+func (s *Server) serve(listeners *serverListeners) {
+	if ln := listeners.http; ln != nil {
+		s.httpAddr = ln.Addr()
+		go func() {
+			fmt.Printf("启动HTTP服务器，监听地址: %s\n", ln.Addr())
+			if err := s.httpServer.Serve(ln); err != nil && err != http.ErrServerClosed {
+				log.Printf("HTTP服务器异常退出: %v", err)
+			}
+		}()
+	}
+
+	if ln := listeners.tls; ln != nil {
+		s.tlsAddr = ln.Addr()
+		go func() {
+			fmt.Printf("启动HTTPS服务器，监听地址: %s\n", ln.Addr())
+			// 证书由 TLSConfig.GetCertificate 提供
+			if err := s.tlsServer.Serve(tls.NewListener(ln, s.tlsServer.TLSConfig)); err != nil && err != http.ErrServerClosed {
+				log.Printf("HTTPS服务器异常退出: %v", err)
+			}
+		}()
+	}
+
+	if conn := listeners.h3; conn != nil {
+		s.h3Addr = conn.LocalAddr()
+		go func() {
+			fmt.Printf("启动HTTP/3服务器，监听地址: %s\n", conn.LocalAddr())
+			if err := s.h3Server.Serve(conn); err != nil && err != http.ErrServerClosed {
+				log.Printf("HTTP/3服务器异常退出: %v", err)
+			}
+		}()
+	}
+}
+
+// newHTTPServer 按服务器配置创建 http.Server，超时和请求头大小限制在启动时确定，修改后需要重启生效
+// 流式响应在转发时解除写超时，不受 write_timeout 限制
+func (s *Server) newHTTPServer(addr string, handler http.Handler, cfg *config.ServerConfig) *http.Server {
+	return &http.Server{
+		ConnState:         s.conns.track,
+		Addr:              addr,
+		Handler:           handler,
+		ReadTimeout:       cfg.ReadTimeout,
+		ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+		WriteTimeout:      cfg.WriteTimeout,
+		IdleTimeout:       cfg.IdleTimeout,
+		MaxHeaderBytes:    cfg.MaxHeaderBytes,
+	}
+}
+
+// Stop 优雅停止服务，等待进行中的请求完成（最长 graceful_shutdown_timeout）
+func (s *Server) Stop() error {
+	var stopErr error
+	s.stopOnce.Do(func() {
+		fmt.Println("正在关闭服务器...")
+
+		s.mu.RLock()
+		httpServer, tlsServer, h3Server := s.httpServer, s.tlsServer, s.h3Server
+		s.mu.RUnlock()
+
+		timeout := s.configManager.GetConfig().Server.GracefulShutdownTimeout
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+
+		// 各监听同时停止接收新连接并等待进行中的请求完成，期间定期输出剩余连接数
+		s.conns.draining.Store(true)
+		done := make(chan struct{})
+		go s.conns.logDraining(done)
+		var wg sync.WaitGroup
+		errs := make([]error, 3)
+		for i, srv := range []*http.Server{httpServer, tlsServer} {
+			if srv == nil {
+				continue
+			}
+			wg.Add(1)
+			go func(i int, srv *http.Server) {
+				defer wg.Done()
+				errs[i] = shutdownHTTPServer(ctx, srv)
+			}(i, srv)
+		}
+		if h3Server != nil {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				errs[2] = h3Server.Shutdown(ctx)
+			}()
+		}
+		wg.Wait()
+		close(done)
+		for i, name := range []string{"HTTP", "HTTPS", "HTTP/3"} {
+			if errs[i] != nil {
+				stopErr = fmt.Errorf("关闭%s服务器失败: %w", name, errs[i])
+			}
+		}
+
+		// 停止插件
+		if s.pluginManager != nil {
+			s.pluginManager.Stop()
+		}
+
+		// 释放上游空闲连接
+		if s.connectionPool != nil {
+			s.connectionPool.CloseIdleConnections()
+		}
+
+		// 关闭死信日志和审计日志
+		s.deadLetter.Swap(nil).Close()
+		s.audit.Swap(nil).Close()
+
+		// 停止配置管理器和远程配置监视
+		s.configManager.Stop()
+		if s.configCenter != nil {
+			s.configCenter.StopWatch()
+		}
+		close(s.stoppedChan)
+	})
+	return stopErr
+}
+
+// shutdownHTTPServer 优雅关闭 http.Server，超过 graceful_shutdown_timeout 时强制关闭剩余连接
+func shutdownHTTPServer(ctx context.Context, srv *http.Server) error {
+	err := srv.Shutdown(ctx)
+	if err == nil {
+		return nil
+	}
+	fmt.Printf("优雅关闭超时，强制关闭 %s 的剩余连接\n", srv.Addr)
+	srv.Close()
+	return err
+}
+
+// Done 返回服务停止后关闭的通道
+func (s *Server) Done() <-chan struct{} {
+	return s.stoppedChan
+}
+
+// Addr 返回HTTP实际监听地址，未监听时返回空字符串
+func (s *Server) Addr() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.httpAddr == nil {
+		return ""
+	}
+	return s.httpAddr.String()
+}
+
+// TLSAddr 返回HTTPS实际监听地址，未监听时返回空字符串
+func (s *Server) TLSAddr() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.tlsAddr == nil {
+		return ""
+	}
+	return s.tlsAddr.String()
+}
+
+// HTTP3Addr 返回HTTP/3实际监听的UDP地址，未监听时返回空字符串
+func (s *Server) HTTP3Addr() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.h3Addr == nil {
+		return ""
+	}
+	return s.h3Addr.String()
+}
+
+// Handler 返回请求处理器，配置重载后自动使用新的路由引擎，未启动时返回 nil
+func (s *Server) Handler() http.Handler {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.handler == nil {
+		return nil
+	}
+	return s.handler
+}
+
+// reload 配置重载钩子
+func (s *Server) reload(cfg *config.Config) error {
+	fmt.Println("正在重新加载路由配置...")
+	// 先加载插件再切换路由表，避免新路由在插件就绪前接收请求
+	// 加载新增的外部插件
+	if err := s.registerExternalPlugins(cfg); err != nil {
+		return err
+	}
+	// 重新加载可用插件
+	if err := s.loadAvailablePlugins(cfg); err != nil {
+		return err
+	}
+	// 重新加载路由插件
+	if err := s.loadRoutePlugins(cfg); err != nil {
+		return err
+	}
+	if err := s.routerManager.ReloadFromConfig(s.configManager, s.pluginManager); err != nil {
+		return err
+	}
+	// 重新加载证书
+	if s.certLoader != nil && tlsEnabled(cfg) {
+		if err := s.certLoader.Reload(cfg.Server.TLS.CertFile, cfg.Server.TLS.KeyFile); err != nil {
+			return err
+		}
+		fmt.Println("✓ 证书已重新加载")
+	}
+	// 重建上游连接池
+	s.connectionPool.Reset(cfg.Server.Transport)
+	// 重建死信日志
+	deadLetter, err := newDeadLetterSink(cfg.Server.DeadLetter)
+	if err != nil {
+		return err
+	}
+	s.deadLetter.Swap(deadLetter).Close()
+	// 重建审计日志
+	audit, err := newAuditSink(cfg.Server.Admin.AuditLog)
+	if err != nil {
+		return err
+	}
+	s.audit.Swap(audit).Close()
+	// 重新加载错误消息，失败时保留原有消息，不影响其他配置生效
+	if err := errors.ReloadErrorMessages(cfg.Server.ErrorMessagesDir); err != nil {
+		log.Printf("重新加载错误消息失败，继续使用原有消息: %v", err)
+	}
+	// 重建请求捕获缓冲区
+	s.capture.Store(newCaptureBuffer(cfg.Server.Capture))
+	// 更新并发限制，进行中的请求计数保留
+	s.concurrency.update(cfg.Server.Concurrency, cfg.Server.TrustedProxies)
+	// 重新注册路由
+	s.mu.Lock()
+	s.reloadRoutes()
+	s.mu.Unlock()
+	return nil
+}
@@ -0,0 +1,393 @@
+package server
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"gateway-go/internal/config"
+	"gateway-go/internal/logger"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// testConfig 返回将所有请求转发到 upstream 的最小配置
+func testConfig(upstream string) *config.Config {
+	return &config.Config{
+		Server: config.ServerConfig{
+			Port:                    8080,
+			Mode:                    "test",
+			ReadTimeout:             5 * time.Second,
+			WriteTimeout:            5 * time.Second,
+			MaxHeaderBytes:          1 << 20,
+			GracefulShutdownTimeout: 5 * time.Second,
+		},
+		Log: config.LogConfig{
+			Level:      "error",
+			Format:     "json",
+			Output:     "stdout",
+			MaxSize:    1,
+			MaxAge:     1,
+			MaxBackups: 1,
+		},
+		Routes: []config.RouteConfig{
+			{
+				Name:   "default",
+				Match:  config.RouteMatch{Type: "prefix", Path: "/"},
+				Target: config.TargetConfig{URL: upstream},
+			},
+		},
+	}
+}
+
+// newTestServer 使用内存配置创建网关服务
+func newTestServer(t *testing.T, cfg *config.Config) *Server {
+	t.Helper()
+	cm := config.NewConfigManager("")
+	if err := cm.SetConfig(cfg); err != nil {
+		t.Fatalf("设置配置失败: %v", err)
+	}
+	return New(cm)
+}
+
+// startTestServer 在随机端口上进程内启动网关，测试结束时停止，返回服务和HTTP地址
+func startTestServer(t *testing.T, cfg *config.Config) (*Server, string) {
+	t.Helper()
+	srv := newTestServer(t, cfg)
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("监听失败: %v", err)
+	}
+	srv.SetListener(ln)
+	if err := srv.Start(context.Background()); err != nil {
+		t.Fatalf("启动网关失败: %v", err)
+	}
+	t.Cleanup(func() { srv.Stop() })
+	return srv, "http://" + srv.Addr()
+}
+
+// get 发送 GET 请求并返回状态码和响应体
+func get(t *testing.T, url string) (int, string) {
+	t.Helper()
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatalf("请求 %s 失败: %v", url, err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	return resp.StatusCode, string(body)
+}
+
+// observeLogs 将网关日志替换为内存记录器（在启动网关之后调用），测试结束时恢复
+func observeLogs(t *testing.T, level zapcore.Level) *observer.ObservedLogs {
+	t.Helper()
+	core, logs := observer.New(level)
+	previous := logger.Log
+	logger.Log = zap.New(core)
+	t.Cleanup(func() { logger.Log = previous })
+	return logs
+}
+
+// freePort 返回当前空闲的本地TCP端口
+func freePort(t *testing.T) int {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("获取空闲端口失败: %v", err)
+	}
+	defer ln.Close()
+	return ln.Addr().(*net.TCPAddr).Port
+}
+
+// writeTestCert 生成 127.0.0.1 的自签名证书，返回证书和私钥文件路径
+func writeTestCert(t *testing.T) (string, string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("生成私钥失败: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "127.0.0.1"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("生成证书失败: %v", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("编码私钥失败: %v", err)
+	}
+
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "cert.pem")
+	keyFile := filepath.Join(dir, "key.pem")
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return certFile, keyFile
+}
+
+func TestServerStartProxiesAndStops(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "upstream "+r.URL.Path)
+	}))
+	defer upstream.Close()
+
+	srv, base := startTestServer(t, testConfig(upstream.URL))
+
+	status, body := get(t, base+"/hello")
+	if status != http.StatusOK || body != "upstream /hello" {
+		t.Fatalf("转发结果 = %d %q，期望 200 %q", status, body, "upstream /hello")
+	}
+
+	if err := srv.Stop(); err != nil {
+		t.Fatalf("停止网关失败: %v", err)
+	}
+	select {
+	case <-srv.Done():
+	case <-time.After(time.Second):
+		t.Fatal("停止后 Done 通道未关闭")
+	}
+	if _, err := http.Get(base + "/hello"); err == nil {
+		t.Fatal("停止后仍可访问网关")
+	}
+}
+
+func TestServerStopsWhenContextDone(t *testing.T) {
+	srv := newTestServer(t, testConfig("http://127.0.0.1:1"))
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv.SetListener(ln)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	if err := srv.Start(ctx); err != nil {
+		t.Fatalf("启动网关失败: %v", err)
+	}
+	cancel()
+	select {
+	case <-srv.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("ctx 结束后网关未停止")
+	}
+}
+
+func TestServerStartTwice(t *testing.T) {
+	srv, _ := startTestServer(t, testConfig("http://127.0.0.1:1"))
+	if err := srv.Start(context.Background()); err == nil {
+		t.Fatal("重复启动应返回错误")
+	}
+}
+
+func TestServerStartFailureReleasesResources(t *testing.T) {
+	certFile, keyFile := writeTestCert(t)
+
+	// 占用HTTPS端口，使HTTPS监听失败
+	busy, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer busy.Close()
+
+	// 配置文件路径非空时会启动配置监视
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "config.yaml")
+	if err := os.WriteFile(configPath, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := testConfig("http://127.0.0.1:1")
+	cfg.Server.Port = freePort(t)
+	cfg.Server.TLS = &config.TLSConfig{
+		Enabled:  true,
+		Port:     busy.Addr().(*net.TCPAddr).Port,
+		CertFile: certFile,
+		KeyFile:  keyFile,
+	}
+	cm := config.NewConfigManager(configPath)
+	if err := cm.SetConfig(cfg); err != nil {
+		t.Fatal(err)
+	}
+	srv := New(cm)
+
+	goroutines := runtime.NumGoroutine()
+	if err := srv.Start(context.Background()); err == nil || !strings.Contains(err.Error(), "HTTPS服务器监听失败") {
+		t.Fatalf("HTTPS端口被占用时启动应失败，实际: %v", err)
+	}
+
+	// HTTP端口已释放
+	ln, err := net.Listen("tcp", srv.httpServer.Addr)
+	if err != nil {
+		t.Fatalf("启动失败后HTTP端口未释放: %v", err)
+	}
+	ln.Close()
+
+	// 未留下配置监视、重载和服务协程
+	deadline := time.Now().Add(time.Second)
+	for runtime.NumGoroutine() > goroutines && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if n := runtime.NumGoroutine(); n > goroutines {
+		t.Fatalf("启动失败后残留 %d 个协程", n-goroutines)
+	}
+}
+
+func TestReadHeaderTimeoutClosesSlowClient(t *testing.T) {
+	cfg := testConfig(textUpstream(t, "ok").URL)
+	cfg.Server.ReadHeaderTimeout = 200 * time.Millisecond
+	srv, base := startTestServer(t, cfg)
+
+	conn, err := net.Dial("tcp", srv.Addr())
+	if err != nil {
+		t.Fatalf("连接网关失败: %v", err)
+	}
+	defer conn.Close()
+
+	// 只发送部分请求头后停止发送（slowloris）
+	start := time.Now()
+	if _, err := io.WriteString(conn, "GET / HTTP/1.1\r\nHost: gateway\r\n"); err != nil {
+		t.Fatal(err)
+	}
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	io.Copy(io.Discard, conn)
+	if elapsed := time.Since(start); elapsed < 150*time.Millisecond || elapsed > 2*time.Second {
+		t.Fatalf("请求头发送缓慢的连接在 %v 后关闭，期望约 200ms", elapsed)
+	}
+
+	// 正常请求不受影响
+	if status, body := get(t, base+"/"); status != http.StatusOK || body != "ok" {
+		t.Fatalf("正常请求响应 = %d %q，期望 200 ok", status, body)
+	}
+}
+
+// remoteRouteYAML 返回只有一条默认路由、转发到 upstream 的完整配置
+func remoteRouteYAML(upstream string) string {
+	return `
+server:
+  port: 8080
+  mode: test
+  read_timeout: 5s
+  write_timeout: 5s
+  max_header_bytes: 1048576
+  graceful_shutdown_timeout: 5s
+log:
+  level: error
+  format: json
+  output: stdout
+  max_size: 1
+  max_age: 1
+  max_backups: 1
+routes:
+  - name: default
+    match:
+      type: prefix
+      path: /
+    target:
+      url: ` + upstream + "\n"
+}
+
+func TestServerStartLoadsRemoteConfigSource(t *testing.T) {
+	a := textUpstream(t, "a")
+	b := textUpstream(t, "b")
+
+	// 模拟 Consul KV，index 未变化时短暂等待后返回，模拟阻塞查询
+	var mu sync.Mutex
+	value, index := remoteRouteYAML(a.URL), 1
+	consul := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/kv/gateway/config.yaml" {
+			http.NotFound(w, r)
+			return
+		}
+		mu.Lock()
+		current := strconv.Itoa(index)
+		mu.Unlock()
+		if r.URL.Query().Get("index") == current {
+			time.Sleep(20 * time.Millisecond)
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		w.Header().Set("X-Consul-Index", strconv.Itoa(index))
+		io.WriteString(w, value)
+	}))
+	defer consul.Close()
+
+	// 本地引导配置只提供远程配置来源，其路由不应生效
+	cfg := testConfig("http://127.0.0.1:1")
+	cfg.Server.ConfigSource = &config.RemoteBackendConfig{
+		Provider: "consul", Endpoint: consul.URL, Key: "gateway/config.yaml", PollInterval: time.Second,
+	}
+	srv, base := startTestServer(t, cfg)
+
+	if status, body := get(t, base+"/"); status != http.StatusOK || body != "a" {
+		t.Fatalf("启动后响应 = %d %q，期望转发到远程配置的上游 a", status, body)
+	}
+
+	// 远程配置变更后自动重载
+	mu.Lock()
+	value, index = remoteRouteYAML(b.URL), 2
+	mu.Unlock()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		if _, body := get(t, base+"/"); body == "b" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("远程配置变更后未重载路由")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	if n := len(srv.configCenter.GetVersions()); n != 2 {
+		t.Fatalf("版本数 = %d，期望远程初始版本和重载版本共 2 个", n)
+	}
+}
+
+func TestServerStartFailsWhenRemoteConfigUnavailable(t *testing.T) {
+	cfg := testConfig("http://127.0.0.1:1")
+	cfg.Server.ConfigSource = &config.RemoteBackendConfig{
+		Provider: "etcd", Endpoint: fmt.Sprintf("http://127.0.0.1:%d", freePort(t)), Key: "gateway/config.yaml",
+	}
+	srv := newTestServer(t, cfg)
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("监听失败: %v", err)
+	}
+	defer ln.Close()
+	srv.SetListener(ln)
+
+	err = srv.Start(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "读取远程配置失败") {
+		t.Fatalf("Start() err = %v，期望读取远程配置失败", err)
+	}
+}
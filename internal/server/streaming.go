@@ -0,0 +1,32 @@
+package server
+
+import (
+	"mime"
+	"net/http"
+	"strings"
+
+	"gateway-go/internal/config"
+)
+
+// eventStreamType SSE 响应类型，始终按流式转发
+const eventStreamType = "text/event-stream"
+
+// isStreamingResponse 判断上游响应是否按流式转发：text/event-stream 或路由配置的流式响应类型
+func isStreamingResponse(resp *http.Response, streaming *config.StreamingConfig) bool {
+	mediaType, _, err := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if err != nil {
+		return false
+	}
+	if mediaType == eventStreamType {
+		return true
+	}
+	if streaming == nil {
+		return false
+	}
+	for _, contentType := range streaming.ContentTypes {
+		if configured, _, err := mime.ParseMediaType(contentType); err == nil && strings.EqualFold(configured, mediaType) {
+			return true
+		}
+	}
+	return false
+}
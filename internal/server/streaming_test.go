@@ -0,0 +1,106 @@
+package server
+
+import (
+	"bufio"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"gateway-go/internal/config"
+)
+
+// streamUpstream 逐条发送 events，每条发送后刷新并等待 next 放行下一条
+func streamUpstream(t *testing.T, contentType string, events []string) (*httptest.Server, chan struct{}) {
+	t.Helper()
+	next := make(chan struct{})
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", contentType)
+		w.WriteHeader(http.StatusOK)
+		for _, event := range events {
+			fmt.Fprint(w, event)
+			w.(http.Flusher).Flush()
+			select {
+			case <-next:
+			case <-r.Context().Done():
+				return
+			}
+		}
+	}))
+	t.Cleanup(upstream.Close)
+	t.Cleanup(func() { close(next) })
+	return upstream, next
+}
+
+// readLine 在超时前从流中读取一行
+func readLine(t *testing.T, reader *bufio.Reader) string {
+	t.Helper()
+	lines := make(chan string, 1)
+	go func() {
+		line, _ := reader.ReadString('\n')
+		lines <- line
+	}()
+	select {
+	case line := <-lines:
+		return line
+	case <-time.After(3 * time.Second):
+		t.Fatal("等待流式数据超时，响应可能被缓冲")
+		return ""
+	}
+}
+
+func TestStreamingResponsesArriveIncrementally(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		streaming   *config.StreamingConfig
+		events      []string
+	}{
+		{
+			name:        "SSE",
+			contentType: "text/event-stream; charset=utf-8",
+			events:      []string{"data: first\n\n", "data: second\n\n", "data: third\n\n"},
+		},
+		{
+			name:        "配置的流式类型",
+			contentType: "application/x-ndjson",
+			streaming:   &config.StreamingConfig{ContentTypes: []string{"application/x-ndjson"}},
+			events:      []string{"{\"n\":1}\n", "{\"n\":2}\n", "{\"n\":3}\n"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			upstream, next := streamUpstream(t, tt.contentType, tt.events)
+			cfg := testConfig(upstream.URL)
+			cfg.Routes[0].Target.Streaming = tt.streaming
+			// 替换响应写入器的插件不能缓冲流式响应
+			usePlugin(cfg, "circuit_breaker", nil)
+			usePlugin(cfg, "error_normalize", map[string]interface{}{"rewrite_body": true, "min_status": 200})
+			_, base := startTestServer(t, cfg)
+
+			resp, err := http.Get(base + "/events")
+			if err != nil {
+				t.Fatalf("请求失败: %v", err)
+			}
+			defer resp.Body.Close()
+			if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != tt.contentType {
+				t.Fatalf("响应 = %d %s", resp.StatusCode, resp.Header.Get("Content-Type"))
+			}
+
+			// 上游发送下一条之前，客户端已收到当前这一条
+			reader := bufio.NewReader(resp.Body)
+			for i, event := range tt.events {
+				var got strings.Builder
+				for got.Len() < len(event) {
+					got.WriteString(readLine(t, reader))
+				}
+				if got.String() != event {
+					t.Fatalf("第 %d 条数据 = %q，期望 %q", i+1, got.String(), event)
+				}
+				next <- struct{}{}
+			}
+		})
+	}
+}
@@ -0,0 +1,102 @@
+package server
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"sync"
+
+	"gateway-go/internal/config"
+)
+
+// certReloader 证书热加载器，配置重载时重新读取证书文件
+type certReloader struct {
+	certFile string
+	keyFile  string
+	cert     *tls.Certificate
+	mu       sync.RWMutex
+}
+
+// newCertReloader 创建证书热加载器
+func newCertReloader(certFile, keyFile string) (*certReloader, error) {
+	r := &certReloader{}
+	if err := r.Reload(certFile, keyFile); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Reload 重新加载证书
+func (r *certReloader) Reload(certFile, keyFile string) error {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return fmt.Errorf("加载证书失败: %w", err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.certFile = certFile
+	r.keyFile = keyFile
+	r.cert = &cert
+	return nil
+}
+
+// GetCertificate 实现 tls.Config.GetCertificate
+func (r *certReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.cert, nil
+}
+
+// buildTLSConfig 根据配置构建 tls.Config
+func buildTLSConfig(cfg *config.TLSConfig, reloader *certReloader) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		GetCertificate: reloader.GetCertificate,
+		MinVersion:     parseTLSVersion(cfg.MinVersion),
+	}
+	// 启用 HTTP/2 时通过 ALPN 协商，http.Server 检测到 h2 后自动启用 HTTP/2
+	if cfg.HTTP2 {
+		tlsConfig.NextProtos = []string{"h2", "http/1.1"}
+	}
+
+	// 配置客户端CA时启用双向认证
+	if cfg.ClientCAFile != "" {
+		caPEM, err := os.ReadFile(cfg.ClientCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("读取客户端CA证书失败: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caPEM) {
+			return nil, fmt.Errorf("解析客户端CA证书失败: %s", cfg.ClientCAFile)
+		}
+		tlsConfig.ClientCAs = pool
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	return tlsConfig, nil
+}
+
+// parseTLSVersion 解析TLS版本，默认 TLS 1.2
+func parseTLSVersion(version string) uint16 {
+	switch version {
+	case "1.0":
+		return tls.VersionTLS10
+	case "1.1":
+		return tls.VersionTLS11
+	case "1.3":
+		return tls.VersionTLS13
+	default:
+		return tls.VersionTLS12
+	}
+}
+
+// tlsEnabled 判断是否启用HTTPS
+func tlsEnabled(cfg *config.Config) bool {
+	return cfg.Server.TLS != nil && cfg.Server.TLS.Enabled
+}
+
+// httpsOnly 判断是否只监听HTTPS（HTTPS端口未配置或与HTTP端口相同）
+func httpsOnly(cfg *config.Config) bool {
+	return tlsEnabled(cfg) && (cfg.Server.TLS.Port == 0 || cfg.Server.TLS.Port == cfg.Server.Port)
+}
@@ -0,0 +1,126 @@
+package server
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"gateway-go/internal/config"
+)
+
+// tlsClient 返回信任 certFile 的HTTPS客户端
+func tlsClient(t *testing.T, certFile string) *http.Client {
+	t.Helper()
+	certPEM, err := os.ReadFile(certFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(certPEM) {
+		t.Fatal("解析证书失败")
+	}
+	return &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool}}}
+}
+
+func TestTLSListenerServesRequests(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "secure "+r.Header.Get("X-Forwarded-Proto"))
+	}))
+	defer upstream.Close()
+
+	certFile, keyFile := writeTestCert(t)
+	cfg := testConfig(upstream.URL)
+	cfg.Server.TLS = &config.TLSConfig{
+		Enabled:  true,
+		Port:     freePort(t),
+		CertFile: certFile,
+		KeyFile:  keyFile,
+	}
+	srv, base := startTestServer(t, cfg)
+
+	_, port, _ := net.SplitHostPort(srv.TLSAddr())
+	resp, err := tlsClient(t, certFile).Get("https://127.0.0.1:" + port + "/")
+	if err != nil {
+		t.Fatalf("HTTPS请求失败: %v", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK || string(body) != "secure https" {
+		t.Fatalf("HTTPS响应 = %d %q，期望 200 %q", resp.StatusCode, body, "secure https")
+	}
+
+	// HTTP端口同时可用
+	if status, _ := get(t, base+"/"); status != http.StatusOK {
+		t.Fatalf("HTTP响应状态码 = %d，期望 200", status)
+	}
+}
+
+func TestTLSListenerNegotiatesHTTP2(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "h2 upstream")
+	}))
+	defer upstream.Close()
+
+	certFile, keyFile := writeTestCert(t)
+	for _, tt := range []struct {
+		http2 bool
+		proto string
+	}{
+		{true, "HTTP/2.0"},
+		{false, "HTTP/1.1"},
+	} {
+		cfg := testConfig(upstream.URL)
+		cfg.Server.TLS = &config.TLSConfig{
+			Enabled:  true,
+			Port:     freePort(t),
+			CertFile: certFile,
+			KeyFile:  keyFile,
+			HTTP2:    tt.http2,
+		}
+		srv, _ := startTestServer(t, cfg)
+
+		client := tlsClient(t, certFile)
+		client.Transport.(*http.Transport).ForceAttemptHTTP2 = true
+		_, port, _ := net.SplitHostPort(srv.TLSAddr())
+		resp, err := client.Get("https://127.0.0.1:" + port + "/")
+		if err != nil {
+			t.Fatalf("http2=%v: HTTPS请求失败: %v", tt.http2, err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if resp.Proto != tt.proto || string(body) != "h2 upstream" {
+			t.Fatalf("http2=%v: 响应 = %s %q，期望 %s %q", tt.http2, resp.Proto, body, tt.proto, "h2 upstream")
+		}
+	}
+}
+
+func TestCertReloaderPicksUpNewCertificate(t *testing.T) {
+	certFile, keyFile := writeTestCert(t)
+	reloader, err := newCertReloader(certFile, keyFile)
+	if err != nil {
+		t.Fatalf("加载证书失败: %v", err)
+	}
+	before, _ := reloader.GetCertificate(nil)
+
+	newCert, newKey := writeTestCert(t)
+	if err := reloader.Reload(newCert, newKey); err != nil {
+		t.Fatalf("重新加载证书失败: %v", err)
+	}
+	after, _ := reloader.GetCertificate(nil)
+	if string(before.Certificate[0]) == string(after.Certificate[0]) {
+		t.Fatal("重新加载后仍使用旧证书")
+	}
+
+	// 加载失败时保留当前证书
+	if err := reloader.Reload(certFile+".missing", keyFile); err == nil {
+		t.Fatal("加载不存在的证书应返回错误")
+	}
+	if current, _ := reloader.GetCertificate(nil); current != after {
+		t.Fatal("加载失败后证书被替换")
+	}
+}